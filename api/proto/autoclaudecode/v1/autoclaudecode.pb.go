@@ -0,0 +1,1758 @@
+// auto-claude-code gRPC 服务定义，与 REST API 的任务和 worktree 接口一一对应
+//
+// 服务端由 internal/mcp 实现，启用 mcp.grpc 后在单独的端口监听，认证与 REST API 相同：
+// 在 authorization 元数据中以 "Bearer <令牌>" 携带令牌。修改后在 api/proto 目录下重新生成代码：
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	  --go-grpc_out=. --go-grpc_opt=paths=source_relative autoclaudecode/v1/autoclaudecode.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: autoclaudecode/v1/autoclaudecode.proto
+
+package autoclaudecodev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ResourceLimits 任务级资源限制，非零字段覆盖 mcp.task_limits
+type ResourceLimits struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Nice            int32                  `protobuf:"varint,1,opt,name=nice,proto3" json:"nice,omitempty"`
+	MaxMemoryMb     int32                  `protobuf:"varint,2,opt,name=max_memory_mb,json=maxMemoryMb,proto3" json:"max_memory_mb,omitempty"`
+	MaxOpenFiles    int32                  `protobuf:"varint,3,opt,name=max_open_files,json=maxOpenFiles,proto3" json:"max_open_files,omitempty"`
+	KillGracePeriod *durationpb.Duration   `protobuf:"bytes,4,opt,name=kill_grace_period,json=killGracePeriod,proto3" json:"kill_grace_period,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ResourceLimits) Reset() {
+	*x = ResourceLimits{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResourceLimits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResourceLimits) ProtoMessage() {}
+
+func (x *ResourceLimits) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResourceLimits.ProtoReflect.Descriptor instead.
+func (*ResourceLimits) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ResourceLimits) GetNice() int32 {
+	if x != nil {
+		return x.Nice
+	}
+	return 0
+}
+
+func (x *ResourceLimits) GetMaxMemoryMb() int32 {
+	if x != nil {
+		return x.MaxMemoryMb
+	}
+	return 0
+}
+
+func (x *ResourceLimits) GetMaxOpenFiles() int32 {
+	if x != nil {
+		return x.MaxOpenFiles
+	}
+	return 0
+}
+
+func (x *ResourceLimits) GetKillGracePeriod() *durationpb.Duration {
+	if x != nil {
+		return x.KillGracePeriod
+	}
+	return nil
+}
+
+type SubmitTaskRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	ProjectPath   string                 `protobuf:"bytes,2,opt,name=project_path,json=projectPath,proto3" json:"project_path,omitempty"`
+	Command       string                 `protobuf:"bytes,3,opt,name=command,proto3" json:"command,omitempty"`
+	Args          []string               `protobuf:"bytes,4,rep,name=args,proto3" json:"args,omitempty"`
+	Context       *structpb.Struct       `protobuf:"bytes,5,opt,name=context,proto3" json:"context,omitempty"`
+	Priority      int32                  `protobuf:"varint,6,opt,name=priority,proto3" json:"priority,omitempty"`
+	Timeout       *durationpb.Duration   `protobuf:"bytes,7,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	Limits        *ResourceLimits        `protobuf:"bytes,8,opt,name=limits,proto3" json:"limits,omitempty"`
+	Gpu           bool                   `protobuf:"varint,9,opt,name=gpu,proto3" json:"gpu,omitempty"`
+	Distro        string                 `protobuf:"bytes,10,opt,name=distro,proto3" json:"distro,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitTaskRequest) Reset() {
+	*x = SubmitTaskRequest{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitTaskRequest) ProtoMessage() {}
+
+func (x *SubmitTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitTaskRequest.ProtoReflect.Descriptor instead.
+func (*SubmitTaskRequest) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SubmitTaskRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *SubmitTaskRequest) GetProjectPath() string {
+	if x != nil {
+		return x.ProjectPath
+	}
+	return ""
+}
+
+func (x *SubmitTaskRequest) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *SubmitTaskRequest) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *SubmitTaskRequest) GetContext() *structpb.Struct {
+	if x != nil {
+		return x.Context
+	}
+	return nil
+}
+
+func (x *SubmitTaskRequest) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *SubmitTaskRequest) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+func (x *SubmitTaskRequest) GetLimits() *ResourceLimits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+func (x *SubmitTaskRequest) GetGpu() bool {
+	if x != nil {
+		return x.Gpu
+	}
+	return false
+}
+
+func (x *SubmitTaskRequest) GetDistro() string {
+	if x != nil {
+		return x.Distro
+	}
+	return ""
+}
+
+// Task 任务状态，status 取值: pending, running, completed, failed, cancelled
+type Task struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	ProjectPath   string                 `protobuf:"bytes,3,opt,name=project_path,json=projectPath,proto3" json:"project_path,omitempty"`
+	Progress      float64                `protobuf:"fixed64,4,opt,name=progress,proto3" json:"progress,omitempty"`
+	Message       string                 `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	Result        *structpb.Value        `protobuf:"bytes,6,opt,name=result,proto3" json:"result,omitempty"`
+	Error         string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	StartTime     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime       *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	WorktreeId    string                 `protobuf:"bytes,11,opt,name=worktree_id,json=worktreeId,proto3" json:"worktree_id,omitempty"`
+	RequestId     string                 `protobuf:"bytes,12,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Metadata      *structpb.Struct       `protobuf:"bytes,13,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Task) Reset() {
+	*x = Task{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Task) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Task) ProtoMessage() {}
+
+func (x *Task) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Task.ProtoReflect.Descriptor instead.
+func (*Task) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Task) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Task) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Task) GetProjectPath() string {
+	if x != nil {
+		return x.ProjectPath
+	}
+	return ""
+}
+
+func (x *Task) GetProgress() float64 {
+	if x != nil {
+		return x.Progress
+	}
+	return 0
+}
+
+func (x *Task) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Task) GetResult() *structpb.Value {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *Task) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Task) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Task) GetStartTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartTime
+	}
+	return nil
+}
+
+func (x *Task) GetEndTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndTime
+	}
+	return nil
+}
+
+func (x *Task) GetWorktreeId() string {
+	if x != nil {
+		return x.WorktreeId
+	}
+	return ""
+}
+
+func (x *Task) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *Task) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type GetTaskRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTaskRequest) Reset() {
+	*x = GetTaskRequest{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTaskRequest) ProtoMessage() {}
+
+func (x *GetTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTaskRequest.ProtoReflect.Descriptor instead.
+func (*GetTaskRequest) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetTaskRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type CancelTaskRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelTaskRequest) Reset() {
+	*x = CancelTaskRequest{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelTaskRequest) ProtoMessage() {}
+
+func (x *CancelTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelTaskRequest.ProtoReflect.Descriptor instead.
+func (*CancelTaskRequest) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CancelTaskRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type CancelTaskResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelTaskResponse) Reset() {
+	*x = CancelTaskResponse{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelTaskResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelTaskResponse) ProtoMessage() {}
+
+func (x *CancelTaskResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelTaskResponse.ProtoReflect.Descriptor instead.
+func (*CancelTaskResponse) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{5}
+}
+
+// ListTasksRequest 与 GET /tasks 的查询参数相同，cursor 只能与默认排序一起使用
+type ListTasksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Statuses      []string               `protobuf:"bytes,1,rep,name=statuses,proto3" json:"statuses,omitempty"`
+	Project       string                 `protobuf:"bytes,2,opt,name=project,proto3" json:"project,omitempty"`
+	Since         *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=since,proto3" json:"since,omitempty"`
+	Sort          string                 `protobuf:"bytes,4,opt,name=sort,proto3" json:"sort,omitempty"` // 字段名，前缀 - 表示降序，默认 -createdAt
+	Limit         int32                  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,6,opt,name=offset,proto3" json:"offset,omitempty"`
+	Cursor        string                 `protobuf:"bytes,7,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTasksRequest) Reset() {
+	*x = ListTasksRequest{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTasksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTasksRequest) ProtoMessage() {}
+
+func (x *ListTasksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTasksRequest.ProtoReflect.Descriptor instead.
+func (*ListTasksRequest) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListTasksRequest) GetStatuses() []string {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+func (x *ListTasksRequest) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+func (x *ListTasksRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *ListTasksRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListTasksRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListTasksRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListTasksRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+type ListTasksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tasks         []*Task                `protobuf:"bytes,1,rep,name=tasks,proto3" json:"tasks,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Offset        int32                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	NextOffset    int32                  `protobuf:"varint,4,opt,name=next_offset,json=nextOffset,proto3" json:"next_offset,omitempty"` // 0 表示没有下一页
+	NextCursor    string                 `protobuf:"bytes,5,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTasksResponse) Reset() {
+	*x = ListTasksResponse{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTasksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTasksResponse) ProtoMessage() {}
+
+func (x *ListTasksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTasksResponse.ProtoReflect.Descriptor instead.
+func (*ListTasksResponse) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListTasksResponse) GetTasks() []*Task {
+	if x != nil {
+		return x.Tasks
+	}
+	return nil
+}
+
+func (x *ListTasksResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ListTasksResponse) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListTasksResponse) GetNextOffset() int32 {
+	if x != nil {
+		return x.NextOffset
+	}
+	return 0
+}
+
+func (x *ListTasksResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+// GetTaskOutputRequest offset 和 limit 为字节数，与 GET /tasks/{id}/output 的查询参数相同
+type GetTaskOutputRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"` // 负数表示从末尾倒数
+	Limit         int64                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTaskOutputRequest) Reset() {
+	*x = GetTaskOutputRequest{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTaskOutputRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTaskOutputRequest) ProtoMessage() {}
+
+func (x *GetTaskOutputRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTaskOutputRequest.ProtoReflect.Descriptor instead.
+func (*GetTaskOutputRequest) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetTaskOutputRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *GetTaskOutputRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *GetTaskOutputRequest) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type GetTaskOutputResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TaskId        string                 `protobuf:"bytes,1,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`                           // 本页起始字节偏移
+	NextOffset    int64                  `protobuf:"varint,3,opt,name=next_offset,json=nextOffset,proto3" json:"next_offset,omitempty"` // 下一页起始字节偏移
+	TotalSize     int64                  `protobuf:"varint,4,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`    // 当前已捕获的输出总字节数
+	Data          string                 `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+	Eof           bool                   `protobuf:"varint,6,opt,name=eof,proto3" json:"eof,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTaskOutputResponse) Reset() {
+	*x = GetTaskOutputResponse{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTaskOutputResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTaskOutputResponse) ProtoMessage() {}
+
+func (x *GetTaskOutputResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTaskOutputResponse.ProtoReflect.Descriptor instead.
+func (*GetTaskOutputResponse) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetTaskOutputResponse) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *GetTaskOutputResponse) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *GetTaskOutputResponse) GetNextOffset() int64 {
+	if x != nil {
+		return x.NextOffset
+	}
+	return 0
+}
+
+func (x *GetTaskOutputResponse) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+func (x *GetTaskOutputResponse) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *GetTaskOutputResponse) GetEof() bool {
+	if x != nil {
+		return x.Eof
+	}
+	return false
+}
+
+type WatchTaskEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TaskId        string                 `protobuf:"bytes,1,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`                   // 非空时只接收该任务的事件
+	LastEventId   uint64                 `protobuf:"varint,2,opt,name=last_event_id,json=lastEventId,proto3" json:"last_event_id,omitempty"` // 重连时补发该 ID 之后的事件
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchTaskEventsRequest) Reset() {
+	*x = WatchTaskEventsRequest{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchTaskEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchTaskEventsRequest) ProtoMessage() {}
+
+func (x *WatchTaskEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchTaskEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchTaskEventsRequest) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *WatchTaskEventsRequest) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *WatchTaskEventsRequest) GetLastEventId() uint64 {
+	if x != nil {
+		return x.LastEventId
+	}
+	return 0
+}
+
+// TaskEvent type 取值与 SSE 事件名相同，如 task.started、task.completed
+type TaskEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	Task          *Task                  `protobuf:"bytes,4,opt,name=task,proto3" json:"task,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskEvent) Reset() {
+	*x = TaskEvent{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskEvent) ProtoMessage() {}
+
+func (x *TaskEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskEvent.ProtoReflect.Descriptor instead.
+func (*TaskEvent) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TaskEvent) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *TaskEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *TaskEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *TaskEvent) GetTask() *Task {
+	if x != nil {
+		return x.Task
+	}
+	return nil
+}
+
+type StreamTaskOutputRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"` // 起始字节偏移，负数表示从末尾倒数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamTaskOutputRequest) Reset() {
+	*x = StreamTaskOutputRequest{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamTaskOutputRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamTaskOutputRequest) ProtoMessage() {}
+
+func (x *StreamTaskOutputRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamTaskOutputRequest.ProtoReflect.Descriptor instead.
+func (*StreamTaskOutputRequest) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StreamTaskOutputRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *StreamTaskOutputRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+// TaskOutputMessage 一行输出，或任务结束时的最终状态
+type TaskOutputMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Message:
+	//
+	//	*TaskOutputMessage_Line
+	//	*TaskOutputMessage_Finished
+	Message       isTaskOutputMessage_Message `protobuf_oneof:"message"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskOutputMessage) Reset() {
+	*x = TaskOutputMessage{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskOutputMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskOutputMessage) ProtoMessage() {}
+
+func (x *TaskOutputMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskOutputMessage.ProtoReflect.Descriptor instead.
+func (*TaskOutputMessage) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TaskOutputMessage) GetMessage() isTaskOutputMessage_Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *TaskOutputMessage) GetLine() *OutputLine {
+	if x != nil {
+		if x, ok := x.Message.(*TaskOutputMessage_Line); ok {
+			return x.Line
+		}
+	}
+	return nil
+}
+
+func (x *TaskOutputMessage) GetFinished() *Task {
+	if x != nil {
+		if x, ok := x.Message.(*TaskOutputMessage_Finished); ok {
+			return x.Finished
+		}
+	}
+	return nil
+}
+
+type isTaskOutputMessage_Message interface {
+	isTaskOutputMessage_Message()
+}
+
+type TaskOutputMessage_Line struct {
+	Line *OutputLine `protobuf:"bytes,1,opt,name=line,proto3,oneof"`
+}
+
+type TaskOutputMessage_Finished struct {
+	Finished *Task `protobuf:"bytes,2,opt,name=finished,proto3,oneof"`
+}
+
+func (*TaskOutputMessage_Line) isTaskOutputMessage_Message() {}
+
+func (*TaskOutputMessage_Finished) isTaskOutputMessage_Message() {}
+
+type OutputLine struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stream        string                 `protobuf:"bytes,1,opt,name=stream,proto3" json:"stream,omitempty"` // stdout 或 stderr，连接前已捕获的输出为空
+	Line          string                 `protobuf:"bytes,2,opt,name=line,proto3" json:"line,omitempty"`
+	Offset        int64                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OutputLine) Reset() {
+	*x = OutputLine{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OutputLine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutputLine) ProtoMessage() {}
+
+func (x *OutputLine) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutputLine.ProtoReflect.Descriptor instead.
+func (*OutputLine) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *OutputLine) GetStream() string {
+	if x != nil {
+		return x.Stream
+	}
+	return ""
+}
+
+func (x *OutputLine) GetLine() string {
+	if x != nil {
+		return x.Line
+	}
+	return ""
+}
+
+func (x *OutputLine) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+// Worktree status 取值: active, idle, cleanup
+type Worktree struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ProjectPath   string                 `protobuf:"bytes,2,opt,name=project_path,json=projectPath,proto3" json:"project_path,omitempty"`
+	WslPath       string                 `protobuf:"bytes,3,opt,name=wsl_path,json=wslPath,proto3" json:"wsl_path,omitempty"`
+	Branch        string                 `protobuf:"bytes,4,opt,name=branch,proto3" json:"branch,omitempty"`
+	BaseCommit    string                 `protobuf:"bytes,5,opt,name=base_commit,json=baseCommit,proto3" json:"base_commit,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	LastUsed      *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=last_used,json=lastUsed,proto3" json:"last_used,omitempty"`
+	Status        string                 `protobuf:"bytes,8,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Worktree) Reset() {
+	*x = Worktree{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Worktree) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Worktree) ProtoMessage() {}
+
+func (x *Worktree) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Worktree.ProtoReflect.Descriptor instead.
+func (*Worktree) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Worktree) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Worktree) GetProjectPath() string {
+	if x != nil {
+		return x.ProjectPath
+	}
+	return ""
+}
+
+func (x *Worktree) GetWslPath() string {
+	if x != nil {
+		return x.WslPath
+	}
+	return ""
+}
+
+func (x *Worktree) GetBranch() string {
+	if x != nil {
+		return x.Branch
+	}
+	return ""
+}
+
+func (x *Worktree) GetBaseCommit() string {
+	if x != nil {
+		return x.BaseCommit
+	}
+	return ""
+}
+
+func (x *Worktree) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Worktree) GetLastUsed() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastUsed
+	}
+	return nil
+}
+
+func (x *Worktree) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type ListWorktreesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWorktreesRequest) Reset() {
+	*x = ListWorktreesRequest{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWorktreesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWorktreesRequest) ProtoMessage() {}
+
+func (x *ListWorktreesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWorktreesRequest.ProtoReflect.Descriptor instead.
+func (*ListWorktreesRequest) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{16}
+}
+
+type ListWorktreesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Worktrees     []*Worktree            `protobuf:"bytes,1,rep,name=worktrees,proto3" json:"worktrees,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWorktreesResponse) Reset() {
+	*x = ListWorktreesResponse{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWorktreesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWorktreesResponse) ProtoMessage() {}
+
+func (x *ListWorktreesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWorktreesResponse.ProtoReflect.Descriptor instead.
+func (*ListWorktreesResponse) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ListWorktreesResponse) GetWorktrees() []*Worktree {
+	if x != nil {
+		return x.Worktrees
+	}
+	return nil
+}
+
+type GetWorktreeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWorktreeRequest) Reset() {
+	*x = GetWorktreeRequest{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWorktreeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWorktreeRequest) ProtoMessage() {}
+
+func (x *GetWorktreeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWorktreeRequest.ProtoReflect.Descriptor instead.
+func (*GetWorktreeRequest) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetWorktreeRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetWorktreeDiffRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWorktreeDiffRequest) Reset() {
+	*x = GetWorktreeDiffRequest{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWorktreeDiffRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWorktreeDiffRequest) ProtoMessage() {}
+
+func (x *GetWorktreeDiffRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWorktreeDiffRequest.ProtoReflect.Descriptor instead.
+func (*GetWorktreeDiffRequest) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetWorktreeDiffRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetWorktreeDiffResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Diff          string                 `protobuf:"bytes,1,opt,name=diff,proto3" json:"diff,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWorktreeDiffResponse) Reset() {
+	*x = GetWorktreeDiffResponse{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWorktreeDiffResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWorktreeDiffResponse) ProtoMessage() {}
+
+func (x *GetWorktreeDiffResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWorktreeDiffResponse.ProtoReflect.Descriptor instead.
+func (*GetWorktreeDiffResponse) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetWorktreeDiffResponse) GetDiff() string {
+	if x != nil {
+		return x.Diff
+	}
+	return ""
+}
+
+type DeleteWorktreeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteWorktreeRequest) Reset() {
+	*x = DeleteWorktreeRequest{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteWorktreeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteWorktreeRequest) ProtoMessage() {}
+
+func (x *DeleteWorktreeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteWorktreeRequest.ProtoReflect.Descriptor instead.
+func (*DeleteWorktreeRequest) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *DeleteWorktreeRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteWorktreeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteWorktreeResponse) Reset() {
+	*x = DeleteWorktreeResponse{}
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteWorktreeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteWorktreeResponse) ProtoMessage() {}
+
+func (x *DeleteWorktreeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteWorktreeResponse.ProtoReflect.Descriptor instead.
+func (*DeleteWorktreeResponse) Descriptor() ([]byte, []int) {
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP(), []int{22}
+}
+
+var File_autoclaudecode_v1_autoclaudecode_proto protoreflect.FileDescriptor
+
+const file_autoclaudecode_v1_autoclaudecode_proto_rawDesc = "" +
+	"\n" +
+	"&autoclaudecode/v1/autoclaudecode.proto\x12\x11autoclaudecode.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb5\x01\n" +
+	"\x0eResourceLimits\x12\x12\n" +
+	"\x04nice\x18\x01 \x01(\x05R\x04nice\x12\"\n" +
+	"\rmax_memory_mb\x18\x02 \x01(\x05R\vmaxMemoryMb\x12$\n" +
+	"\x0emax_open_files\x18\x03 \x01(\x05R\fmaxOpenFiles\x12E\n" +
+	"\x11kill_grace_period\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\x0fkillGracePeriod\"\xe1\x02\n" +
+	"\x11SubmitTaskRequest\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12!\n" +
+	"\fproject_path\x18\x02 \x01(\tR\vprojectPath\x12\x18\n" +
+	"\acommand\x18\x03 \x01(\tR\acommand\x12\x12\n" +
+	"\x04args\x18\x04 \x03(\tR\x04args\x121\n" +
+	"\acontext\x18\x05 \x01(\v2\x17.google.protobuf.StructR\acontext\x12\x1a\n" +
+	"\bpriority\x18\x06 \x01(\x05R\bpriority\x123\n" +
+	"\atimeout\x18\a \x01(\v2\x19.google.protobuf.DurationR\atimeout\x129\n" +
+	"\x06limits\x18\b \x01(\v2!.autoclaudecode.v1.ResourceLimitsR\x06limits\x12\x10\n" +
+	"\x03gpu\x18\t \x01(\bR\x03gpu\x12\x16\n" +
+	"\x06distro\x18\n" +
+	" \x01(\tR\x06distro\"\xef\x03\n" +
+	"\x04Task\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12!\n" +
+	"\fproject_path\x18\x03 \x01(\tR\vprojectPath\x12\x1a\n" +
+	"\bprogress\x18\x04 \x01(\x01R\bprogress\x12\x18\n" +
+	"\amessage\x18\x05 \x01(\tR\amessage\x12.\n" +
+	"\x06result\x18\x06 \x01(\v2\x16.google.protobuf.ValueR\x06result\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"start_time\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tstartTime\x125\n" +
+	"\bend_time\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\aendTime\x12\x1f\n" +
+	"\vworktree_id\x18\v \x01(\tR\n" +
+	"worktreeId\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\f \x01(\tR\trequestId\x123\n" +
+	"\bmetadata\x18\r \x01(\v2\x17.google.protobuf.StructR\bmetadata\" \n" +
+	"\x0eGetTaskRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"#\n" +
+	"\x11CancelTaskRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x14\n" +
+	"\x12CancelTaskResponse\"\xd4\x01\n" +
+	"\x10ListTasksRequest\x12\x1a\n" +
+	"\bstatuses\x18\x01 \x03(\tR\bstatuses\x12\x18\n" +
+	"\aproject\x18\x02 \x01(\tR\aproject\x120\n" +
+	"\x05since\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x12\x12\n" +
+	"\x04sort\x18\x04 \x01(\tR\x04sort\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x06 \x01(\x05R\x06offset\x12\x16\n" +
+	"\x06cursor\x18\a \x01(\tR\x06cursor\"\xb2\x01\n" +
+	"\x11ListTasksResponse\x12-\n" +
+	"\x05tasks\x18\x01 \x03(\v2\x17.autoclaudecode.v1.TaskR\x05tasks\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\x12\x1f\n" +
+	"\vnext_offset\x18\x04 \x01(\x05R\n" +
+	"nextOffset\x12\x1f\n" +
+	"\vnext_cursor\x18\x05 \x01(\tR\n" +
+	"nextCursor\"T\n" +
+	"\x14GetTaskOutputRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x03R\x05limit\"\xae\x01\n" +
+	"\x15GetTaskOutputResponse\x12\x17\n" +
+	"\atask_id\x18\x01 \x01(\tR\x06taskId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\x12\x1f\n" +
+	"\vnext_offset\x18\x03 \x01(\x03R\n" +
+	"nextOffset\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x04 \x01(\x03R\ttotalSize\x12\x12\n" +
+	"\x04data\x18\x05 \x01(\tR\x04data\x12\x10\n" +
+	"\x03eof\x18\x06 \x01(\bR\x03eof\"U\n" +
+	"\x16WatchTaskEventsRequest\x12\x17\n" +
+	"\atask_id\x18\x01 \x01(\tR\x06taskId\x12\"\n" +
+	"\rlast_event_id\x18\x02 \x01(\x04R\vlastEventId\"\x8c\x01\n" +
+	"\tTaskEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12+\n" +
+	"\x04task\x18\x04 \x01(\v2\x17.autoclaudecode.v1.TaskR\x04task\"A\n" +
+	"\x17StreamTaskOutputRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\"\x8a\x01\n" +
+	"\x11TaskOutputMessage\x123\n" +
+	"\x04line\x18\x01 \x01(\v2\x1d.autoclaudecode.v1.OutputLineH\x00R\x04line\x125\n" +
+	"\bfinished\x18\x02 \x01(\v2\x17.autoclaudecode.v1.TaskH\x00R\bfinishedB\t\n" +
+	"\amessage\"P\n" +
+	"\n" +
+	"OutputLine\x12\x16\n" +
+	"\x06stream\x18\x01 \x01(\tR\x06stream\x12\x12\n" +
+	"\x04line\x18\x02 \x01(\tR\x04line\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x03R\x06offset\"\x9d\x02\n" +
+	"\bWorktree\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12!\n" +
+	"\fproject_path\x18\x02 \x01(\tR\vprojectPath\x12\x19\n" +
+	"\bwsl_path\x18\x03 \x01(\tR\awslPath\x12\x16\n" +
+	"\x06branch\x18\x04 \x01(\tR\x06branch\x12\x1f\n" +
+	"\vbase_commit\x18\x05 \x01(\tR\n" +
+	"baseCommit\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x127\n" +
+	"\tlast_used\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\blastUsed\x12\x16\n" +
+	"\x06status\x18\b \x01(\tR\x06status\"\x16\n" +
+	"\x14ListWorktreesRequest\"R\n" +
+	"\x15ListWorktreesResponse\x129\n" +
+	"\tworktrees\x18\x01 \x03(\v2\x1b.autoclaudecode.v1.WorktreeR\tworktrees\"$\n" +
+	"\x12GetWorktreeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"(\n" +
+	"\x16GetWorktreeDiffRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"-\n" +
+	"\x17GetWorktreeDiffResponse\x12\x12\n" +
+	"\x04diff\x18\x01 \x01(\tR\x04diff\"'\n" +
+	"\x15DeleteWorktreeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x18\n" +
+	"\x16DeleteWorktreeResponse2\xfe\x04\n" +
+	"\vTaskService\x12K\n" +
+	"\n" +
+	"SubmitTask\x12$.autoclaudecode.v1.SubmitTaskRequest\x1a\x17.autoclaudecode.v1.Task\x12E\n" +
+	"\aGetTask\x12!.autoclaudecode.v1.GetTaskRequest\x1a\x17.autoclaudecode.v1.Task\x12Y\n" +
+	"\n" +
+	"CancelTask\x12$.autoclaudecode.v1.CancelTaskRequest\x1a%.autoclaudecode.v1.CancelTaskResponse\x12V\n" +
+	"\tListTasks\x12#.autoclaudecode.v1.ListTasksRequest\x1a$.autoclaudecode.v1.ListTasksResponse\x12b\n" +
+	"\rGetTaskOutput\x12'.autoclaudecode.v1.GetTaskOutputRequest\x1a(.autoclaudecode.v1.GetTaskOutputResponse\x12\\\n" +
+	"\x0fWatchTaskEvents\x12).autoclaudecode.v1.WatchTaskEventsRequest\x1a\x1c.autoclaudecode.v1.TaskEvent0\x01\x12f\n" +
+	"\x10StreamTaskOutput\x12*.autoclaudecode.v1.StreamTaskOutputRequest\x1a$.autoclaudecode.v1.TaskOutputMessage0\x012\x99\x03\n" +
+	"\x0fWorktreeService\x12b\n" +
+	"\rListWorktrees\x12'.autoclaudecode.v1.ListWorktreesRequest\x1a(.autoclaudecode.v1.ListWorktreesResponse\x12Q\n" +
+	"\vGetWorktree\x12%.autoclaudecode.v1.GetWorktreeRequest\x1a\x1b.autoclaudecode.v1.Worktree\x12h\n" +
+	"\x0fGetWorktreeDiff\x12).autoclaudecode.v1.GetWorktreeDiffRequest\x1a*.autoclaudecode.v1.GetWorktreeDiffResponse\x12e\n" +
+	"\x0eDeleteWorktree\x12(.autoclaudecode.v1.DeleteWorktreeRequest\x1a).autoclaudecode.v1.DeleteWorktreeResponseB?Z=auto-claude-code/api/proto/autoclaudecode/v1;autoclaudecodev1b\x06proto3"
+
+var (
+	file_autoclaudecode_v1_autoclaudecode_proto_rawDescOnce sync.Once
+	file_autoclaudecode_v1_autoclaudecode_proto_rawDescData []byte
+)
+
+func file_autoclaudecode_v1_autoclaudecode_proto_rawDescGZIP() []byte {
+	file_autoclaudecode_v1_autoclaudecode_proto_rawDescOnce.Do(func() {
+		file_autoclaudecode_v1_autoclaudecode_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_autoclaudecode_v1_autoclaudecode_proto_rawDesc), len(file_autoclaudecode_v1_autoclaudecode_proto_rawDesc)))
+	})
+	return file_autoclaudecode_v1_autoclaudecode_proto_rawDescData
+}
+
+var file_autoclaudecode_v1_autoclaudecode_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
+var file_autoclaudecode_v1_autoclaudecode_proto_goTypes = []any{
+	(*ResourceLimits)(nil),          // 0: autoclaudecode.v1.ResourceLimits
+	(*SubmitTaskRequest)(nil),       // 1: autoclaudecode.v1.SubmitTaskRequest
+	(*Task)(nil),                    // 2: autoclaudecode.v1.Task
+	(*GetTaskRequest)(nil),          // 3: autoclaudecode.v1.GetTaskRequest
+	(*CancelTaskRequest)(nil),       // 4: autoclaudecode.v1.CancelTaskRequest
+	(*CancelTaskResponse)(nil),      // 5: autoclaudecode.v1.CancelTaskResponse
+	(*ListTasksRequest)(nil),        // 6: autoclaudecode.v1.ListTasksRequest
+	(*ListTasksResponse)(nil),       // 7: autoclaudecode.v1.ListTasksResponse
+	(*GetTaskOutputRequest)(nil),    // 8: autoclaudecode.v1.GetTaskOutputRequest
+	(*GetTaskOutputResponse)(nil),   // 9: autoclaudecode.v1.GetTaskOutputResponse
+	(*WatchTaskEventsRequest)(nil),  // 10: autoclaudecode.v1.WatchTaskEventsRequest
+	(*TaskEvent)(nil),               // 11: autoclaudecode.v1.TaskEvent
+	(*StreamTaskOutputRequest)(nil), // 12: autoclaudecode.v1.StreamTaskOutputRequest
+	(*TaskOutputMessage)(nil),       // 13: autoclaudecode.v1.TaskOutputMessage
+	(*OutputLine)(nil),              // 14: autoclaudecode.v1.OutputLine
+	(*Worktree)(nil),                // 15: autoclaudecode.v1.Worktree
+	(*ListWorktreesRequest)(nil),    // 16: autoclaudecode.v1.ListWorktreesRequest
+	(*ListWorktreesResponse)(nil),   // 17: autoclaudecode.v1.ListWorktreesResponse
+	(*GetWorktreeRequest)(nil),      // 18: autoclaudecode.v1.GetWorktreeRequest
+	(*GetWorktreeDiffRequest)(nil),  // 19: autoclaudecode.v1.GetWorktreeDiffRequest
+	(*GetWorktreeDiffResponse)(nil), // 20: autoclaudecode.v1.GetWorktreeDiffResponse
+	(*DeleteWorktreeRequest)(nil),   // 21: autoclaudecode.v1.DeleteWorktreeRequest
+	(*DeleteWorktreeResponse)(nil),  // 22: autoclaudecode.v1.DeleteWorktreeResponse
+	(*durationpb.Duration)(nil),     // 23: google.protobuf.Duration
+	(*structpb.Struct)(nil),         // 24: google.protobuf.Struct
+	(*structpb.Value)(nil),          // 25: google.protobuf.Value
+	(*timestamppb.Timestamp)(nil),   // 26: google.protobuf.Timestamp
+}
+var file_autoclaudecode_v1_autoclaudecode_proto_depIdxs = []int32{
+	23, // 0: autoclaudecode.v1.ResourceLimits.kill_grace_period:type_name -> google.protobuf.Duration
+	24, // 1: autoclaudecode.v1.SubmitTaskRequest.context:type_name -> google.protobuf.Struct
+	23, // 2: autoclaudecode.v1.SubmitTaskRequest.timeout:type_name -> google.protobuf.Duration
+	0,  // 3: autoclaudecode.v1.SubmitTaskRequest.limits:type_name -> autoclaudecode.v1.ResourceLimits
+	25, // 4: autoclaudecode.v1.Task.result:type_name -> google.protobuf.Value
+	26, // 5: autoclaudecode.v1.Task.created_at:type_name -> google.protobuf.Timestamp
+	26, // 6: autoclaudecode.v1.Task.start_time:type_name -> google.protobuf.Timestamp
+	26, // 7: autoclaudecode.v1.Task.end_time:type_name -> google.protobuf.Timestamp
+	24, // 8: autoclaudecode.v1.Task.metadata:type_name -> google.protobuf.Struct
+	26, // 9: autoclaudecode.v1.ListTasksRequest.since:type_name -> google.protobuf.Timestamp
+	2,  // 10: autoclaudecode.v1.ListTasksResponse.tasks:type_name -> autoclaudecode.v1.Task
+	26, // 11: autoclaudecode.v1.TaskEvent.time:type_name -> google.protobuf.Timestamp
+	2,  // 12: autoclaudecode.v1.TaskEvent.task:type_name -> autoclaudecode.v1.Task
+	14, // 13: autoclaudecode.v1.TaskOutputMessage.line:type_name -> autoclaudecode.v1.OutputLine
+	2,  // 14: autoclaudecode.v1.TaskOutputMessage.finished:type_name -> autoclaudecode.v1.Task
+	26, // 15: autoclaudecode.v1.Worktree.created_at:type_name -> google.protobuf.Timestamp
+	26, // 16: autoclaudecode.v1.Worktree.last_used:type_name -> google.protobuf.Timestamp
+	15, // 17: autoclaudecode.v1.ListWorktreesResponse.worktrees:type_name -> autoclaudecode.v1.Worktree
+	1,  // 18: autoclaudecode.v1.TaskService.SubmitTask:input_type -> autoclaudecode.v1.SubmitTaskRequest
+	3,  // 19: autoclaudecode.v1.TaskService.GetTask:input_type -> autoclaudecode.v1.GetTaskRequest
+	4,  // 20: autoclaudecode.v1.TaskService.CancelTask:input_type -> autoclaudecode.v1.CancelTaskRequest
+	6,  // 21: autoclaudecode.v1.TaskService.ListTasks:input_type -> autoclaudecode.v1.ListTasksRequest
+	8,  // 22: autoclaudecode.v1.TaskService.GetTaskOutput:input_type -> autoclaudecode.v1.GetTaskOutputRequest
+	10, // 23: autoclaudecode.v1.TaskService.WatchTaskEvents:input_type -> autoclaudecode.v1.WatchTaskEventsRequest
+	12, // 24: autoclaudecode.v1.TaskService.StreamTaskOutput:input_type -> autoclaudecode.v1.StreamTaskOutputRequest
+	16, // 25: autoclaudecode.v1.WorktreeService.ListWorktrees:input_type -> autoclaudecode.v1.ListWorktreesRequest
+	18, // 26: autoclaudecode.v1.WorktreeService.GetWorktree:input_type -> autoclaudecode.v1.GetWorktreeRequest
+	19, // 27: autoclaudecode.v1.WorktreeService.GetWorktreeDiff:input_type -> autoclaudecode.v1.GetWorktreeDiffRequest
+	21, // 28: autoclaudecode.v1.WorktreeService.DeleteWorktree:input_type -> autoclaudecode.v1.DeleteWorktreeRequest
+	2,  // 29: autoclaudecode.v1.TaskService.SubmitTask:output_type -> autoclaudecode.v1.Task
+	2,  // 30: autoclaudecode.v1.TaskService.GetTask:output_type -> autoclaudecode.v1.Task
+	5,  // 31: autoclaudecode.v1.TaskService.CancelTask:output_type -> autoclaudecode.v1.CancelTaskResponse
+	7,  // 32: autoclaudecode.v1.TaskService.ListTasks:output_type -> autoclaudecode.v1.ListTasksResponse
+	9,  // 33: autoclaudecode.v1.TaskService.GetTaskOutput:output_type -> autoclaudecode.v1.GetTaskOutputResponse
+	11, // 34: autoclaudecode.v1.TaskService.WatchTaskEvents:output_type -> autoclaudecode.v1.TaskEvent
+	13, // 35: autoclaudecode.v1.TaskService.StreamTaskOutput:output_type -> autoclaudecode.v1.TaskOutputMessage
+	17, // 36: autoclaudecode.v1.WorktreeService.ListWorktrees:output_type -> autoclaudecode.v1.ListWorktreesResponse
+	15, // 37: autoclaudecode.v1.WorktreeService.GetWorktree:output_type -> autoclaudecode.v1.Worktree
+	20, // 38: autoclaudecode.v1.WorktreeService.GetWorktreeDiff:output_type -> autoclaudecode.v1.GetWorktreeDiffResponse
+	22, // 39: autoclaudecode.v1.WorktreeService.DeleteWorktree:output_type -> autoclaudecode.v1.DeleteWorktreeResponse
+	29, // [29:40] is the sub-list for method output_type
+	18, // [18:29] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
+}
+
+func init() { file_autoclaudecode_v1_autoclaudecode_proto_init() }
+func file_autoclaudecode_v1_autoclaudecode_proto_init() {
+	if File_autoclaudecode_v1_autoclaudecode_proto != nil {
+		return
+	}
+	file_autoclaudecode_v1_autoclaudecode_proto_msgTypes[13].OneofWrappers = []any{
+		(*TaskOutputMessage_Line)(nil),
+		(*TaskOutputMessage_Finished)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_autoclaudecode_v1_autoclaudecode_proto_rawDesc), len(file_autoclaudecode_v1_autoclaudecode_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   23,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_autoclaudecode_v1_autoclaudecode_proto_goTypes,
+		DependencyIndexes: file_autoclaudecode_v1_autoclaudecode_proto_depIdxs,
+		MessageInfos:      file_autoclaudecode_v1_autoclaudecode_proto_msgTypes,
+	}.Build()
+	File_autoclaudecode_v1_autoclaudecode_proto = out.File
+	file_autoclaudecode_v1_autoclaudecode_proto_goTypes = nil
+	file_autoclaudecode_v1_autoclaudecode_proto_depIdxs = nil
+}
@@ -0,0 +1,610 @@
+// auto-claude-code gRPC 服务定义，与 REST API 的任务和 worktree 接口一一对应
+//
+// 服务端由 internal/mcp 实现，启用 mcp.grpc 后在单独的端口监听，认证与 REST API 相同：
+// 在 authorization 元数据中以 "Bearer <令牌>" 携带令牌。修改后在 api/proto 目录下重新生成代码：
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	  --go-grpc_out=. --go-grpc_opt=paths=source_relative autoclaudecode/v1/autoclaudecode.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: autoclaudecode/v1/autoclaudecode.proto
+
+package autoclaudecodev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TaskService_SubmitTask_FullMethodName       = "/autoclaudecode.v1.TaskService/SubmitTask"
+	TaskService_GetTask_FullMethodName          = "/autoclaudecode.v1.TaskService/GetTask"
+	TaskService_CancelTask_FullMethodName       = "/autoclaudecode.v1.TaskService/CancelTask"
+	TaskService_ListTasks_FullMethodName        = "/autoclaudecode.v1.TaskService/ListTasks"
+	TaskService_GetTaskOutput_FullMethodName    = "/autoclaudecode.v1.TaskService/GetTaskOutput"
+	TaskService_WatchTaskEvents_FullMethodName  = "/autoclaudecode.v1.TaskService/WatchTaskEvents"
+	TaskService_StreamTaskOutput_FullMethodName = "/autoclaudecode.v1.TaskService/StreamTaskOutput"
+)
+
+// TaskServiceClient is the client API for TaskService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// TaskService 任务管理，对应 /tasks 和 /api/v1/events
+type TaskServiceClient interface {
+	// SubmitTask 提交任务，对应 POST /tasks
+	SubmitTask(ctx context.Context, in *SubmitTaskRequest, opts ...grpc.CallOption) (*Task, error)
+	// GetTask 获取任务状态，对应 GET /tasks/{id}
+	GetTask(ctx context.Context, in *GetTaskRequest, opts ...grpc.CallOption) (*Task, error)
+	// CancelTask 取消任务，对应 DELETE /tasks/{id}
+	CancelTask(ctx context.Context, in *CancelTaskRequest, opts ...grpc.CallOption) (*CancelTaskResponse, error)
+	// ListTasks 过滤、排序和分页列出任务，对应 GET /tasks
+	ListTasks(ctx context.Context, in *ListTasksRequest, opts ...grpc.CallOption) (*ListTasksResponse, error)
+	// GetTaskOutput 获取任务已捕获的输出，对应 GET /tasks/{id}/output
+	GetTaskOutput(ctx context.Context, in *GetTaskOutputRequest, opts ...grpc.CallOption) (*GetTaskOutputResponse, error)
+	// WatchTaskEvents 订阅任务生命周期事件，对应 GET /api/v1/events
+	WatchTaskEvents(ctx context.Context, in *WatchTaskEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TaskEvent], error)
+	// StreamTaskOutput 逐行接收任务输出直到任务结束，对应 /api/v1/tasks/{id}/output/stream
+	StreamTaskOutput(ctx context.Context, in *StreamTaskOutputRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TaskOutputMessage], error)
+}
+
+type taskServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTaskServiceClient(cc grpc.ClientConnInterface) TaskServiceClient {
+	return &taskServiceClient{cc}
+}
+
+func (c *taskServiceClient) SubmitTask(ctx context.Context, in *SubmitTaskRequest, opts ...grpc.CallOption) (*Task, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Task)
+	err := c.cc.Invoke(ctx, TaskService_SubmitTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskServiceClient) GetTask(ctx context.Context, in *GetTaskRequest, opts ...grpc.CallOption) (*Task, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Task)
+	err := c.cc.Invoke(ctx, TaskService_GetTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskServiceClient) CancelTask(ctx context.Context, in *CancelTaskRequest, opts ...grpc.CallOption) (*CancelTaskResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelTaskResponse)
+	err := c.cc.Invoke(ctx, TaskService_CancelTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskServiceClient) ListTasks(ctx context.Context, in *ListTasksRequest, opts ...grpc.CallOption) (*ListTasksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTasksResponse)
+	err := c.cc.Invoke(ctx, TaskService_ListTasks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskServiceClient) GetTaskOutput(ctx context.Context, in *GetTaskOutputRequest, opts ...grpc.CallOption) (*GetTaskOutputResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTaskOutputResponse)
+	err := c.cc.Invoke(ctx, TaskService_GetTaskOutput_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskServiceClient) WatchTaskEvents(ctx context.Context, in *WatchTaskEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TaskEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TaskService_ServiceDesc.Streams[0], TaskService_WatchTaskEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchTaskEventsRequest, TaskEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TaskService_WatchTaskEventsClient = grpc.ServerStreamingClient[TaskEvent]
+
+func (c *taskServiceClient) StreamTaskOutput(ctx context.Context, in *StreamTaskOutputRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TaskOutputMessage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TaskService_ServiceDesc.Streams[1], TaskService_StreamTaskOutput_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamTaskOutputRequest, TaskOutputMessage]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TaskService_StreamTaskOutputClient = grpc.ServerStreamingClient[TaskOutputMessage]
+
+// TaskServiceServer is the server API for TaskService service.
+// All implementations must embed UnimplementedTaskServiceServer
+// for forward compatibility.
+//
+// TaskService 任务管理，对应 /tasks 和 /api/v1/events
+type TaskServiceServer interface {
+	// SubmitTask 提交任务，对应 POST /tasks
+	SubmitTask(context.Context, *SubmitTaskRequest) (*Task, error)
+	// GetTask 获取任务状态，对应 GET /tasks/{id}
+	GetTask(context.Context, *GetTaskRequest) (*Task, error)
+	// CancelTask 取消任务，对应 DELETE /tasks/{id}
+	CancelTask(context.Context, *CancelTaskRequest) (*CancelTaskResponse, error)
+	// ListTasks 过滤、排序和分页列出任务，对应 GET /tasks
+	ListTasks(context.Context, *ListTasksRequest) (*ListTasksResponse, error)
+	// GetTaskOutput 获取任务已捕获的输出，对应 GET /tasks/{id}/output
+	GetTaskOutput(context.Context, *GetTaskOutputRequest) (*GetTaskOutputResponse, error)
+	// WatchTaskEvents 订阅任务生命周期事件，对应 GET /api/v1/events
+	WatchTaskEvents(*WatchTaskEventsRequest, grpc.ServerStreamingServer[TaskEvent]) error
+	// StreamTaskOutput 逐行接收任务输出直到任务结束，对应 /api/v1/tasks/{id}/output/stream
+	StreamTaskOutput(*StreamTaskOutputRequest, grpc.ServerStreamingServer[TaskOutputMessage]) error
+	mustEmbedUnimplementedTaskServiceServer()
+}
+
+// UnimplementedTaskServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTaskServiceServer struct{}
+
+func (UnimplementedTaskServiceServer) SubmitTask(context.Context, *SubmitTaskRequest) (*Task, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitTask not implemented")
+}
+func (UnimplementedTaskServiceServer) GetTask(context.Context, *GetTaskRequest) (*Task, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTask not implemented")
+}
+func (UnimplementedTaskServiceServer) CancelTask(context.Context, *CancelTaskRequest) (*CancelTaskResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelTask not implemented")
+}
+func (UnimplementedTaskServiceServer) ListTasks(context.Context, *ListTasksRequest) (*ListTasksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTasks not implemented")
+}
+func (UnimplementedTaskServiceServer) GetTaskOutput(context.Context, *GetTaskOutputRequest) (*GetTaskOutputResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTaskOutput not implemented")
+}
+func (UnimplementedTaskServiceServer) WatchTaskEvents(*WatchTaskEventsRequest, grpc.ServerStreamingServer[TaskEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchTaskEvents not implemented")
+}
+func (UnimplementedTaskServiceServer) StreamTaskOutput(*StreamTaskOutputRequest, grpc.ServerStreamingServer[TaskOutputMessage]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamTaskOutput not implemented")
+}
+func (UnimplementedTaskServiceServer) mustEmbedUnimplementedTaskServiceServer() {}
+func (UnimplementedTaskServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeTaskServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TaskServiceServer will
+// result in compilation errors.
+type UnsafeTaskServiceServer interface {
+	mustEmbedUnimplementedTaskServiceServer()
+}
+
+func RegisterTaskServiceServer(s grpc.ServiceRegistrar, srv TaskServiceServer) {
+	// If the following call pancis, it indicates UnimplementedTaskServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TaskService_ServiceDesc, srv)
+}
+
+func _TaskService_SubmitTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskServiceServer).SubmitTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskService_SubmitTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskServiceServer).SubmitTask(ctx, req.(*SubmitTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskService_GetTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskServiceServer).GetTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskService_GetTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskServiceServer).GetTask(ctx, req.(*GetTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskService_CancelTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskServiceServer).CancelTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskService_CancelTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskServiceServer).CancelTask(ctx, req.(*CancelTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskService_ListTasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTasksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskServiceServer).ListTasks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskService_ListTasks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskServiceServer).ListTasks(ctx, req.(*ListTasksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskService_GetTaskOutput_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTaskOutputRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskServiceServer).GetTaskOutput(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskService_GetTaskOutput_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskServiceServer).GetTaskOutput(ctx, req.(*GetTaskOutputRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskService_WatchTaskEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchTaskEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TaskServiceServer).WatchTaskEvents(m, &grpc.GenericServerStream[WatchTaskEventsRequest, TaskEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TaskService_WatchTaskEventsServer = grpc.ServerStreamingServer[TaskEvent]
+
+func _TaskService_StreamTaskOutput_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamTaskOutputRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TaskServiceServer).StreamTaskOutput(m, &grpc.GenericServerStream[StreamTaskOutputRequest, TaskOutputMessage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TaskService_StreamTaskOutputServer = grpc.ServerStreamingServer[TaskOutputMessage]
+
+// TaskService_ServiceDesc is the grpc.ServiceDesc for TaskService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TaskService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "autoclaudecode.v1.TaskService",
+	HandlerType: (*TaskServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitTask",
+			Handler:    _TaskService_SubmitTask_Handler,
+		},
+		{
+			MethodName: "GetTask",
+			Handler:    _TaskService_GetTask_Handler,
+		},
+		{
+			MethodName: "CancelTask",
+			Handler:    _TaskService_CancelTask_Handler,
+		},
+		{
+			MethodName: "ListTasks",
+			Handler:    _TaskService_ListTasks_Handler,
+		},
+		{
+			MethodName: "GetTaskOutput",
+			Handler:    _TaskService_GetTaskOutput_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchTaskEvents",
+			Handler:       _TaskService_WatchTaskEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamTaskOutput",
+			Handler:       _TaskService_StreamTaskOutput_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "autoclaudecode/v1/autoclaudecode.proto",
+}
+
+const (
+	WorktreeService_ListWorktrees_FullMethodName   = "/autoclaudecode.v1.WorktreeService/ListWorktrees"
+	WorktreeService_GetWorktree_FullMethodName     = "/autoclaudecode.v1.WorktreeService/GetWorktree"
+	WorktreeService_GetWorktreeDiff_FullMethodName = "/autoclaudecode.v1.WorktreeService/GetWorktreeDiff"
+	WorktreeService_DeleteWorktree_FullMethodName  = "/autoclaudecode.v1.WorktreeService/DeleteWorktree"
+)
+
+// WorktreeServiceClient is the client API for WorktreeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// WorktreeService worktree 管理，对应 /worktrees
+type WorktreeServiceClient interface {
+	// ListWorktrees 列出所有 worktree
+	ListWorktrees(ctx context.Context, in *ListWorktreesRequest, opts ...grpc.CallOption) (*ListWorktreesResponse, error)
+	// GetWorktree 获取 worktree 信息
+	GetWorktree(ctx context.Context, in *GetWorktreeRequest, opts ...grpc.CallOption) (*Worktree, error)
+	// GetWorktreeDiff 获取 worktree 相对基准提交的统一 diff，对应 MCP 资源 worktree diff
+	GetWorktreeDiff(ctx context.Context, in *GetWorktreeDiffRequest, opts ...grpc.CallOption) (*GetWorktreeDiffResponse, error)
+	// DeleteWorktree 删除 worktree
+	DeleteWorktree(ctx context.Context, in *DeleteWorktreeRequest, opts ...grpc.CallOption) (*DeleteWorktreeResponse, error)
+}
+
+type worktreeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWorktreeServiceClient(cc grpc.ClientConnInterface) WorktreeServiceClient {
+	return &worktreeServiceClient{cc}
+}
+
+func (c *worktreeServiceClient) ListWorktrees(ctx context.Context, in *ListWorktreesRequest, opts ...grpc.CallOption) (*ListWorktreesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWorktreesResponse)
+	err := c.cc.Invoke(ctx, WorktreeService_ListWorktrees_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *worktreeServiceClient) GetWorktree(ctx context.Context, in *GetWorktreeRequest, opts ...grpc.CallOption) (*Worktree, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Worktree)
+	err := c.cc.Invoke(ctx, WorktreeService_GetWorktree_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *worktreeServiceClient) GetWorktreeDiff(ctx context.Context, in *GetWorktreeDiffRequest, opts ...grpc.CallOption) (*GetWorktreeDiffResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetWorktreeDiffResponse)
+	err := c.cc.Invoke(ctx, WorktreeService_GetWorktreeDiff_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *worktreeServiceClient) DeleteWorktree(ctx context.Context, in *DeleteWorktreeRequest, opts ...grpc.CallOption) (*DeleteWorktreeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteWorktreeResponse)
+	err := c.cc.Invoke(ctx, WorktreeService_DeleteWorktree_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WorktreeServiceServer is the server API for WorktreeService service.
+// All implementations must embed UnimplementedWorktreeServiceServer
+// for forward compatibility.
+//
+// WorktreeService worktree 管理，对应 /worktrees
+type WorktreeServiceServer interface {
+	// ListWorktrees 列出所有 worktree
+	ListWorktrees(context.Context, *ListWorktreesRequest) (*ListWorktreesResponse, error)
+	// GetWorktree 获取 worktree 信息
+	GetWorktree(context.Context, *GetWorktreeRequest) (*Worktree, error)
+	// GetWorktreeDiff 获取 worktree 相对基准提交的统一 diff，对应 MCP 资源 worktree diff
+	GetWorktreeDiff(context.Context, *GetWorktreeDiffRequest) (*GetWorktreeDiffResponse, error)
+	// DeleteWorktree 删除 worktree
+	DeleteWorktree(context.Context, *DeleteWorktreeRequest) (*DeleteWorktreeResponse, error)
+	mustEmbedUnimplementedWorktreeServiceServer()
+}
+
+// UnimplementedWorktreeServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedWorktreeServiceServer struct{}
+
+func (UnimplementedWorktreeServiceServer) ListWorktrees(context.Context, *ListWorktreesRequest) (*ListWorktreesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWorktrees not implemented")
+}
+func (UnimplementedWorktreeServiceServer) GetWorktree(context.Context, *GetWorktreeRequest) (*Worktree, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWorktree not implemented")
+}
+func (UnimplementedWorktreeServiceServer) GetWorktreeDiff(context.Context, *GetWorktreeDiffRequest) (*GetWorktreeDiffResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWorktreeDiff not implemented")
+}
+func (UnimplementedWorktreeServiceServer) DeleteWorktree(context.Context, *DeleteWorktreeRequest) (*DeleteWorktreeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteWorktree not implemented")
+}
+func (UnimplementedWorktreeServiceServer) mustEmbedUnimplementedWorktreeServiceServer() {}
+func (UnimplementedWorktreeServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeWorktreeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WorktreeServiceServer will
+// result in compilation errors.
+type UnsafeWorktreeServiceServer interface {
+	mustEmbedUnimplementedWorktreeServiceServer()
+}
+
+func RegisterWorktreeServiceServer(s grpc.ServiceRegistrar, srv WorktreeServiceServer) {
+	// If the following call pancis, it indicates UnimplementedWorktreeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&WorktreeService_ServiceDesc, srv)
+}
+
+func _WorktreeService_ListWorktrees_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWorktreesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorktreeServiceServer).ListWorktrees(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WorktreeService_ListWorktrees_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorktreeServiceServer).ListWorktrees(ctx, req.(*ListWorktreesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WorktreeService_GetWorktree_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWorktreeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorktreeServiceServer).GetWorktree(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WorktreeService_GetWorktree_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorktreeServiceServer).GetWorktree(ctx, req.(*GetWorktreeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WorktreeService_GetWorktreeDiff_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWorktreeDiffRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorktreeServiceServer).GetWorktreeDiff(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WorktreeService_GetWorktreeDiff_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorktreeServiceServer).GetWorktreeDiff(ctx, req.(*GetWorktreeDiffRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WorktreeService_DeleteWorktree_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteWorktreeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorktreeServiceServer).DeleteWorktree(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WorktreeService_DeleteWorktree_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorktreeServiceServer).DeleteWorktree(ctx, req.(*DeleteWorktreeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// WorktreeService_ServiceDesc is the grpc.ServiceDesc for WorktreeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var WorktreeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "autoclaudecode.v1.WorktreeService",
+	HandlerType: (*WorktreeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListWorktrees",
+			Handler:    _WorktreeService_ListWorktrees_Handler,
+		},
+		{
+			MethodName: "GetWorktree",
+			Handler:    _WorktreeService_GetWorktree_Handler,
+		},
+		{
+			MethodName: "GetWorktreeDiff",
+			Handler:    _WorktreeService_GetWorktreeDiff_Handler,
+		},
+		{
+			MethodName: "DeleteWorktree",
+			Handler:    _WorktreeService_DeleteWorktree_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "autoclaudecode/v1/autoclaudecode.proto",
+}
@@ -5,11 +5,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"os/user"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -17,11 +22,16 @@ import (
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 
+	"auto-claude-code/internal/audit"
+	"auto-claude-code/internal/auth"
 	"auto-claude-code/internal/config"
 	"auto-claude-code/internal/converter"
 	apperrors "auto-claude-code/internal/errors"
 	"auto-claude-code/internal/logger"
 	"auto-claude-code/internal/mcp"
+	"auto-claude-code/internal/terminal"
+	"auto-claude-code/internal/tracing"
+	"auto-claude-code/internal/websocket"
 	"auto-claude-code/internal/wsl"
 
 	ui "github.com/gizak/termui/v3"
@@ -35,17 +45,25 @@ var (
 	date    = "unknown"
 
 	// 全局配置
-	cfg *config.Config
-	log logger.Logger
+	cfg            *config.Config
+	log            logger.Logger
+	configFileUsed string // 实际加载的配置文件，没有找到配置文件时为空
 
 	// 命令行参数
-	configFile  string
-	debug       bool
-	logLevel    string
-	targetDir   string
-	distro      string
-	claudeArgs  []string
-	showVersion bool
+	configFile    string
+	configProfile string
+	debug         bool
+	logLevel      string
+	logLevelSet   bool // 命令行指定了日志级别，指定时优先于配置文件
+	targetDir     string
+	distro        string
+	claudeArgs    []string
+	showVersion   bool
+
+	// Windows Terminal 参数
+	terminalMode    bool
+	terminalProfile string
+	terminalPane    bool
 )
 
 // rootCmd 根命令
@@ -70,6 +88,9 @@ var rootCmd = &cobra.Command{
   # 指定 WSL 发行版
   auto-claude-code --distro Ubuntu-20.04
 
+  # 在新的 Windows Terminal 标签页中启动
+  auto-claude-code --terminal --terminal-profile Ubuntu
+
   # 调试模式
   auto-claude-code --debug
 
@@ -93,13 +114,21 @@ func main() {
 func setupFlags() {
 	// 全局参数
 	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "配置文件路径")
+	rootCmd.PersistentFlags().StringVar(&configProfile, "profile", "", "使用的配置档案（默认使用 "+config.ProfileEnv+" 环境变量）")
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "启用调试模式")
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "l", "info", "日志级别 (debug, info, warn, error, fatal)")
 	rootCmd.PersistentFlags().BoolVarP(&showVersion, "version", "v", false, "显示版本信息")
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		// 默认值 info 与显式指定的 --log-level info 需要区分
+		logLevelSet = cmd.Flags().Changed("log-level")
+	}
 
 	// 主命令参数
 	rootCmd.Flags().StringVar(&targetDir, "dir", "", "目标目录（默认为当前目录）")
 	rootCmd.Flags().StringVar(&distro, "distro", "", "WSL 发行版名称（默认使用系统默认）")
+	rootCmd.Flags().BoolVar(&terminalMode, "terminal", false, "在新的 Windows Terminal 标签页中启动")
+	rootCmd.Flags().StringVar(&terminalProfile, "terminal-profile", "", "Windows Terminal 配置文件名称")
+	rootCmd.Flags().BoolVar(&terminalPane, "terminal-pane", false, "在当前 Windows Terminal 窗口中拆分窗格启动")
 
 	// 版本命令
 	versionCmd := &cobra.Command{
@@ -120,6 +149,38 @@ func setupFlags() {
 	}
 	rootCmd.AddCommand(checkCmd)
 
+	// 安装命令
+	installCmd := &cobra.Command{
+		Use:   "install",
+		Short: "安装或更新 Claude Code",
+		Long:  "在 WSL 发行版中安装或更新 Claude Code，并验证安装结果",
+		RunE:  runInstall,
+	}
+	installCmd.Flags().String("method", wsl.InstallMethodNPM, "安装方式 (npm, native)")
+	installCmd.Flags().StringVar(&distro, "distro", "", "WSL 发行版名称（默认使用系统默认）")
+	rootCmd.AddCommand(installCmd)
+
+	// 认证命令
+	authCmd := &cobra.Command{
+		Use:   "auth",
+		Short: "登录 Claude Code",
+		Long:  "在 WSL 发行版中运行 Claude Code 登录流程，设备码链接和令牌输入会直接转发到当前终端",
+		RunE:  runAuth,
+	}
+	authCmd.Flags().StringVar(&distro, "distro", "", "WSL 发行版名称（默认使用系统默认）")
+	rootCmd.AddCommand(authCmd)
+
+	// 调优命令
+	tuneCmd := &cobra.Command{
+		Use:   "tune",
+		Short: "检查并优化 WSL 配置",
+		Long:  "检查 .wslconfig 和 wsl.conf 中的内存、处理器、swap 及自动挂载设置，根据最大并发任务数给出建议",
+		RunE:  runTune,
+	}
+	tuneCmd.Flags().Bool("apply", false, "将建议写入配置文件（原 .wslconfig 会备份为 .wslconfig.bak）")
+	tuneCmd.Flags().StringVar(&distro, "distro", "", "WSL 发行版名称（默认使用系统默认）")
+	rootCmd.AddCommand(tuneCmd)
+
 	// 配置命令
 	configCmd := &cobra.Command{
 		Use:   "config",
@@ -139,9 +200,60 @@ func setupFlags() {
 		RunE:  runConfigInit,
 	}
 
-	configCmd.AddCommand(configShowCmd, configInitCmd)
+	configValidateCmd := &cobra.Command{
+		Use:   "validate [file]",
+		Short: "检查配置文件",
+		Long:  "加载配置文件并检查全部问题，包括无法识别的配置项和无效的配置值（如 task_timeout 等时长），列出出错的配置项。未指定文件时检查 --config 指定或默认查找到的配置文件",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  runConfigValidate,
+		// 配置有问题不是用法错误
+		SilenceUsage: true,
+	}
+
+	configCmd.AddCommand(configShowCmd, configInitCmd, configValidateCmd)
 	rootCmd.AddCommand(configCmd)
 
+	// 令牌管理命令
+	tokenCmd := &cobra.Command{
+		Use:   "token",
+		Short: "API令牌管理",
+		Long:  "管理 MCP 服务器 HTTP 接口的访问令牌（mcp.auth.method 为 token 时生效），令牌以哈希形式保存在令牌存储文件中",
+	}
+
+	tokenCreateCmd := &cobra.Command{
+		Use:   "create",
+		Short: "创建令牌",
+		RunE:  runTokenCreate,
+	}
+	tokenCreateCmd.Flags().String("name", "", "令牌名称")
+	tokenCreateCmd.Flags().StringSlice("scopes", []string{auth.ScopeRead}, "权限范围 (read, submit, admin)")
+	tokenCreateCmd.Flags().String("expires", "", "有效期，如 720h（默认永不过期）")
+
+	tokenListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "列出令牌",
+		RunE:  runTokenList,
+	}
+
+	tokenRevokeCmd := &cobra.Command{
+		Use:   "revoke <token-id>",
+		Short: "撤销令牌",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runTokenRevoke,
+	}
+
+	tokenRotateCmd := &cobra.Command{
+		Use:   "rotate <token-id>",
+		Short: "轮换令牌",
+		Long:  "以相同名称、权限范围和有效期创建新令牌，并撤销旧令牌",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runTokenRotate,
+	}
+
+	tokenCmd.PersistentFlags().String("store", "", "令牌存储文件（默认使用配置中的 mcp.auth.token_store）")
+	tokenCmd.AddCommand(tokenCreateCmd, tokenListCmd, tokenRevokeCmd, tokenRotateCmd)
+	rootCmd.AddCommand(tokenCmd)
+
 	// MCP服务器命令
 	mcpCmd := &cobra.Command{
 		Use:   "mcp-server",
@@ -170,8 +282,8 @@ func setupFlags() {
 	// 列出任务命令
 	taskListCmd := &cobra.Command{
 		Use:   "list",
-		Short: "列出所有任务",
-		Long:  "列出MCP服务器上的所有任务及其状态",
+		Short: "列出任务",
+		Long:  "按状态、项目和创建时间过滤，分页列出MCP服务器上的任务",
 		RunE:  runTaskList,
 	}
 
@@ -193,6 +305,72 @@ func setupFlags() {
 		RunE:  runTaskCancel,
 	}
 
+	// 添加任务备注命令
+	taskNoteCmd := &cobra.Command{
+		Use:   "note <task-id> <text>",
+		Short: "添加任务备注",
+		Long:  "为任务追加一条带时间戳的备注，如审查意见或分诊状态，可通过 task show 查看",
+		Args:  cobra.MinimumNArgs(2),
+		RunE:  runTaskNote,
+	}
+	taskNoteCmd.Flags().String("author", "", "备注作者，服务器启用认证时使用认证身份，默认为当前用户名")
+
+	// 重新运行任务命令
+	taskRerunCmd := &cobra.Command{
+		Use:   "rerun <task-id>",
+		Short: "重新运行任务",
+		Long:  "以已结束任务的原始请求提交一个新任务，可覆盖命令、优先级和模型或在命令后附加补充说明，新旧任务在 metadata 中互相关联",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runTaskRerun,
+	}
+	taskRerunCmd.Flags().String("id", "", "新任务的ID，留空自动生成")
+	taskRerunCmd.Flags().String("command", "", "替换原任务的命令")
+	taskRerunCmd.Flags().String("prompt-suffix", "", "附加在命令之后的补充说明")
+	taskRerunCmd.Flags().Int("priority", 0, "新任务的优先级，0 表示沿用原任务")
+	taskRerunCmd.Flags().String("model", "", "新任务使用的 Claude 模型，留空沿用原任务")
+	taskRerunCmd.Flags().Int("max-turns", 0, "新任务的最大轮次，0 表示沿用原任务")
+
+	// 合并任务命令
+	taskMergeCmd := &cobra.Command{
+		Use:   "merge <task-id>",
+		Short: "将任务的修改合并回项目",
+		Long:  "提交已成功完成的任务在工作树中的修改，并合并回创建工作树时项目所在的分支；先检测冲突，冲突或试运行时不修改项目",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runTaskMerge,
+	}
+	taskMergeCmd.Flags().String("strategy", "", "合并策略 (merge, rebase)，留空使用服务器配置")
+	taskMergeCmd.Flags().Bool("dry-run", false, "只提交任务分支并检测冲突，不修改项目分支")
+	taskMergeCmd.Flags().StringP("message", "m", "", "任务分支的提交信息，留空时由任务命令生成")
+
+	// 导出任务命令
+	taskExportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "导出已结束的任务",
+		Long:  "以 JSON Lines 导出已结束的任务记录（含输出引用），格式与服务器归档文件相同，用于任务存储之外的长期分析",
+		Args:  cobra.NoArgs,
+		RunE:  runTaskExport,
+	}
+	taskExportCmd.Flags().String("since", "", "只导出最近一段时间内结束的任务，如 7d、24h，也可以是 RFC 3339 时间")
+	taskExportCmd.Flags().StringSlice("status", nil, "只导出这些状态的任务，可重复或用逗号分隔")
+	taskExportCmd.Flags().StringP("output", "o", "", "输出文件，留空输出到标准输出")
+
+	// 查看任务输出命令
+	taskLogsCmd := &cobra.Command{
+		Use:   "logs <task-id>",
+		Short: "查看任务输出",
+		Long:  "打印任务已捕获的输出，使用 --follow 持续输出新内容直到任务结束",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runTaskLogs,
+	}
+
+	taskAttachCmd := &cobra.Command{
+		Use:   "attach <task-id>",
+		Short: "连接交互式任务的终端",
+		Long:  "连接 interactive 类型任务的 Claude Code 终端，可回答权限确认并继续对话；任务尚未开始时等待，按 Ctrl+] 断开，会话继续运行",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runTaskAttach,
+	}
+
 	// 提交任务命令
 	taskSubmitCmd := &cobra.Command{
 		Use:   "submit",
@@ -217,22 +395,174 @@ func setupFlags() {
 		RunE:  runTaskTUI,
 	}
 
+	// 任务模板命令
+	taskTemplateCmd := &cobra.Command{
+		Use:   "template",
+		Short: "任务模板管理",
+		Long:  "管理MCP服务器上可复用的任务模板，提交任务时通过 task submit --template 引用",
+	}
+
+	taskTemplateListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "列出任务模板",
+		RunE:  runTemplateList,
+	}
+
+	taskTemplateShowCmd := &cobra.Command{
+		Use:   "show <name>",
+		Short: "查看任务模板",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runTemplateShow,
+	}
+
+	taskTemplateSaveCmd := &cobra.Command{
+		Use:   "save <name>",
+		Short: "创建或替换任务模板",
+		Long:  "创建或替换任务模板，项目路径、描述和参数中的 {{参数名}} 在提交任务时替换，{{projectPath}} 为提交时的项目路径",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runTemplateSave,
+	}
+
+	taskTemplateDeleteCmd := &cobra.Command{
+		Use:   "delete <name>",
+		Short: "删除任务模板",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runTemplateDelete,
+	}
+
+	taskTemplateSaveCmd.Flags().String("description", "", "任务描述，作为 Claude Code 的第一个参数（必需）")
+	taskTemplateSaveCmd.Flags().String("summary", "", "模板说明")
+	taskTemplateSaveCmd.Flags().StringP("project", "p", "", "项目路径，留空时提交任务必须指定 -p")
+	taskTemplateSaveCmd.Flags().StringSliceP("args", "a", []string{}, "传递给Claude Code的参数")
+	taskTemplateSaveCmd.Flags().StringP("priority", "r", "", "任务优先级 (low, medium, high)，留空使用服务器默认优先级")
+	taskTemplateSaveCmd.Flags().StringP("timeout", "t", "", "任务超时时间，留空使用 mcp.task_timeout")
+	taskTemplateSaveCmd.Flags().String("distro", "", "执行任务的 WSL 发行版")
+	taskTemplateSaveCmd.Flags().StringToString("default", nil, "参数默认值，格式 name=value，可重复")
+	taskTemplateSaveCmd.MarkFlagRequired("description")
+
+	taskTemplateCmd.AddCommand(taskTemplateListCmd, taskTemplateShowCmd, taskTemplateSaveCmd, taskTemplateDeleteCmd)
+
 	// 添加任务提交的参数
-	taskSubmitCmd.Flags().StringP("project", "p", "", "项目路径（必需）")
-	taskSubmitCmd.Flags().String("description", "", "任务描述（必需）")
+	taskSubmitCmd.Flags().StringP("project", "p", "", "项目路径（未使用模板或模板未指定项目路径时必需）")
+	taskSubmitCmd.Flags().String("description", "", "任务描述（未使用模板且不是交互式任务时必需）")
 	taskSubmitCmd.Flags().StringP("priority", "r", "medium", "任务优先级 (low, medium, high)")
 	taskSubmitCmd.Flags().StringP("timeout", "t", "30m", "任务超时时间")
 	taskSubmitCmd.Flags().StringSliceP("args", "a", []string{}, "传递给Claude Code的参数")
-	taskSubmitCmd.MarkFlagRequired("project")
-	taskSubmitCmd.MarkFlagRequired("description")
+	taskSubmitCmd.Flags().String("distro", "", "执行任务的 WSL 发行版（默认由服务器的发行版池选择或使用默认发行版）")
+	taskSubmitCmd.Flags().StringSlice("distro-tag", nil, "执行任务的发行版需要具有的标签，如 gpu、node20，可重复或用逗号分隔")
+	taskSubmitCmd.Flags().String("model", "", "Claude Code 使用的模型，如 sonnet、opus")
+	taskSubmitCmd.Flags().Int("max-turns", 0, "最大对话轮数，0 表示不限制")
+	taskSubmitCmd.Flags().String("permission-mode", "", "权限模式 (default, acceptEdits, plan, bypassPermissions)")
+	taskSubmitCmd.Flags().StringSlice("allowed-tools", nil, "允许 Claude Code 使用的工具，可重复或用逗号分隔，如 Read,Edit,Bash(git diff:*)")
+	taskSubmitCmd.Flags().StringSlice("depends-on", nil, "依赖的任务ID，全部成功完成后才开始执行，可重复或用逗号分隔")
+	taskSubmitCmd.Flags().String("on-success", "", "任务成功后自动提交的后续任务描述")
+	taskSubmitCmd.Flags().String("on-failure", "", "任务失败后自动提交的后续任务描述，附带失败任务的错误和输出")
+	taskSubmitCmd.Flags().String("template", "", "套用的任务模板，未指定的参数取自模板")
+	taskSubmitCmd.Flags().StringToString("param", nil, "模板参数，格式 name=value，可重复")
+	taskSubmitCmd.Flags().Bool("validate-only", false, "只检查任务能否执行并显示执行计划，不提交任务")
+	taskSubmitCmd.Flags().Bool("merge-back", false, "任务成功后提交修改并合并回项目的原分支")
+	taskSubmitCmd.Flags().String("merge-strategy", "", "合并回项目的策略 (merge, rebase)，留空使用服务器配置")
+	taskSubmitCmd.Flags().Bool("merge-dry-run", false, "合并回项目时只提交任务分支并检测冲突，不修改项目分支")
+	taskSubmitCmd.Flags().Bool("interactive", false, "提交交互式任务，在终端中运行 Claude Code，使用 task attach 连接")
+	taskAttachCmd.Flags().String("token", "", "连接会话使用的 API 令牌（默认使用 "+attachTokenEnv+" 环境变量）")
 
 	// 添加服务器地址参数
 	taskCmd.PersistentFlags().StringP("server", "s", "http://localhost:8080", "MCP服务器地址")
-	taskWatchCmd.Flags().IntP("interval", "i", 2, "刷新间隔（秒）")
-	taskTUICmd.Flags().IntP("interval", "i", 2, "刷新间隔（秒）")
-
-	taskCmd.AddCommand(taskListCmd, taskShowCmd, taskCancelCmd, taskSubmitCmd, taskWatchCmd, taskTUICmd)
+	taskWatchCmd.Flags().IntP("interval", "i", 2, "事件流不可用时的轮询间隔（秒）")
+	taskTUICmd.Flags().IntP("interval", "i", 2, "事件流不可用时的轮询间隔（秒）")
+
+	// 任务列表的过滤、排序和分页参数
+	taskListCmd.Flags().StringSlice("status", nil, "只显示指定状态的任务，可重复或用逗号分隔")
+	taskListCmd.Flags().StringP("project", "p", "", "只显示指定项目路径的任务")
+	taskListCmd.Flags().Duration("since", 0, "只显示最近一段时间内创建的任务，如 24h")
+	taskListCmd.Flags().String("sort", "-createdAt", "排序字段（createdAt、startTime、endTime、status、progress、id），\"-\" 前缀表示降序")
+	taskListCmd.Flags().IntP("limit", "n", 50, "最多显示的任务数")
+	taskListCmd.Flags().Int("offset", 0, "跳过的任务数")
+
+	taskLogsCmd.Flags().BoolP("follow", "f", false, "持续输出新内容直到任务结束")
+	taskLogsCmd.Flags().Int("tail", 0, "只显示最后多少字节，0 表示全部")
+	taskLogsCmd.Flags().String("stream", "", "只显示 stdout 或 stderr 的输出日志文件")
+
+	taskCmd.AddCommand(taskListCmd, taskShowCmd, taskLogsCmd, taskCancelCmd, taskRerunCmd, taskMergeCmd, taskAttachCmd, taskNoteCmd, taskExportCmd, taskSubmitCmd, taskWatchCmd, taskTUICmd, taskTemplateCmd)
 	rootCmd.AddCommand(taskCmd)
+
+	// 服务器运维命令
+	serverCmd := &cobra.Command{
+		Use:   "server",
+		Short: "MCP服务器运维",
+		Long:  "管理运行中的MCP服务器的任务调度",
+	}
+
+	serverPauseCmd := &cobra.Command{
+		Use:   "pause",
+		Short: "暂停任务分发",
+		Long:  "暂停向工作器分发新任务，正在执行的任务继续运行，新提交的任务继续排队；用于 WSL 维护或发行版升级前让系统静默",
+		RunE:  runServerPause,
+	}
+	serverPauseCmd.Flags().String("reason", "", "暂停原因，显示在队列状态中")
+
+	serverResumeCmd := &cobra.Command{
+		Use:   "resume",
+		Short: "恢复任务分发",
+		Long:  "恢复向工作器分发排队的任务",
+		RunE:  runServerResume,
+	}
+
+	serverStatusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "查看任务队列状态",
+		Long:  "查看任务队列是否暂停以及排队的任务数",
+		RunE:  runServerStatus,
+	}
+
+	serverCmd.PersistentFlags().StringP("server", "s", "http://localhost:8080", "MCP服务器地址")
+	serverCmd.AddCommand(serverPauseCmd, serverResumeCmd, serverStatusCmd)
+	rootCmd.AddCommand(serverCmd)
+
+	// Worktree 管理命令
+	worktreeCmd := &cobra.Command{
+		Use:   "worktree",
+		Short: "Worktree管理",
+		Long:  "查看MCP服务器上任务使用的worktree",
+	}
+
+	worktreeShowCmd := &cobra.Command{
+		Use:   "show <worktree-id>",
+		Short: "查看worktree状态",
+		Long:  "查看worktree的分支、git status 和相对基准提交修改的文件",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runWorktreeShow,
+	}
+	worktreeShowCmd.Flags().Bool("porcelain", false, "只输出 git status --porcelain 的结果")
+
+	worktreePinCmd := &cobra.Command{
+		Use:   "pin <worktree-id>",
+		Short: "固定worktree",
+		Long:  "固定worktree，空闲清理不会删除固定的worktree，适合需要人工审查的修改",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runWorktreePin,
+	}
+
+	worktreeUnpinCmd := &cobra.Command{
+		Use:   "unpin <worktree-id>",
+		Short: "取消固定worktree",
+		Long:  "取消固定worktree，之后空闲清理可以删除该worktree",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runWorktreePin,
+	}
+
+	worktreeCleanCmd := &cobra.Command{
+		Use:   "clean <worktree-id>",
+		Short: "删除worktree",
+		Long:  "删除worktree及其目录，固定的worktree需要 --force",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runWorktreeClean,
+	}
+	worktreeCleanCmd.Flags().Bool("force", false, "先取消固定再删除")
+
+	worktreeCmd.PersistentFlags().StringP("server", "s", "http://localhost:8080", "MCP服务器地址")
+	worktreeCmd.AddCommand(worktreeShowCmd, worktreePinCmd, worktreeUnpinCmd, worktreeCleanCmd)
+	rootCmd.AddCommand(worktreeCmd)
 }
 
 // runMain 主命令执行函数
@@ -258,8 +588,14 @@ func runMain(cmd *cobra.Command, args []string) error {
 		zap.String("workingDir", workingDir),
 		zap.String("distro", distro))
 
+	// 创建执行桥接器
+	wslBridge, err := wsl.NewBridge(cfg, log.GetZapLogger())
+	if err != nil {
+		return err
+	}
+
 	// 创建路径转换器
-	pathConverter := converter.NewPathConverter()
+	pathConverter := wslBridge.PathConverter()
 
 	// 验证路径
 	if err := pathConverter.ValidatePath(workingDir); err != nil {
@@ -276,8 +612,10 @@ func runMain(cmd *cobra.Command, args []string) error {
 		zap.String("windowsPath", workingDir),
 		zap.String("wslPath", wslPath))
 
-	// 创建 WSL 桥接器
-	wslBridge := wsl.NewWSLBridge(log.GetZapLogger())
+	// 在 Windows Terminal 中重新启动自身，新标签页中的进程负责后续流程
+	if terminalMode || terminalPane {
+		return launchInTerminal(workingDir, args)
+	}
 
 	// 检查 WSL 环境
 	if err := wslBridge.CheckWSL(); err != nil {
@@ -285,21 +623,18 @@ func runMain(cmd *cobra.Command, args []string) error {
 	}
 
 	// 获取 WSL 发行版
-	if distro == "" {
-		if cfg.WSL.DefaultDistro != "" {
-			distro = cfg.WSL.DefaultDistro
-		} else {
-			distro, err = wslBridge.GetDefaultDistro()
-			if err != nil {
-				return fmt.Errorf("获取默认 WSL 发行版失败: %w", err)
-			}
-		}
+	distro, err = resolveDistro(wslBridge)
+	if err != nil {
+		return err
 	}
 
 	log.Info("使用 WSL 发行版", zap.String("distro", distro))
 
 	// 检查 Claude Code
 	if err := wslBridge.CheckClaudeCode(distro); err != nil {
+		if apperrors.IsCode(err, apperrors.ErrClaudeCodeAuthRequired) {
+			fmt.Fprintln(os.Stderr, "提示: 运行 auto-claude-code auth 完成 Claude Code 登录")
+		}
 		return fmt.Errorf("Claude Code 检查失败: %w", err)
 	}
 
@@ -312,7 +647,7 @@ func runMain(cmd *cobra.Command, args []string) error {
 		zap.Strings("args", claudeCodeArgs))
 
 	// 启动 Claude Code
-	if err := wslBridge.StartClaudeCode(distro, wslPath, claudeCodeArgs); err != nil {
+	if err := wslBridge.StartClaudeCode(distro, wslPath, claudeCodeArgs, nil); err != nil {
 		return fmt.Errorf("Claude Code 启动失败: %w", err)
 	}
 
@@ -320,6 +655,55 @@ func runMain(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// launchInTerminal 在新的 Windows Terminal 标签页或窗格中启动当前程序
+func launchInTerminal(workingDir string, args []string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("无法获取程序路径: %w", err)
+	}
+
+	command := []string{self, "--dir", workingDir}
+	if configFile != "" {
+		// 新标签页的工作目录不同，需要使用绝对路径
+		absConfig, err := filepath.Abs(configFile)
+		if err != nil {
+			return fmt.Errorf("无法解析配置文件路径: %w", err)
+		}
+		command = append(command, "--config", absConfig)
+	}
+	if distro != "" {
+		command = append(command, "--distro", distro)
+	}
+	if debug {
+		command = append(command, "--debug")
+	}
+	if logLevelSet {
+		command = append(command, "--log-level", logLevel)
+	}
+	if len(args) > 0 {
+		command = append(command, "--")
+		command = append(command, args...)
+	}
+
+	opts := terminal.LaunchOptions{
+		Profile:    terminalProfile,
+		SplitPane:  terminalPane,
+		WorkingDir: workingDir,
+		Title:      filepath.Base(workingDir),
+		Command:    command,
+	}
+
+	log.Info("在 Windows Terminal 中启动",
+		zap.String("profile", opts.Profile),
+		zap.Bool("splitPane", opts.SplitPane),
+		zap.String("title", opts.Title))
+
+	if err := terminal.Launch(opts); err != nil {
+		return fmt.Errorf("Windows Terminal 启动失败: %w", err)
+	}
+	return nil
+}
+
 // runCheck 检查命令执行函数
 func runCheck(cmd *cobra.Command, args []string) error {
 	if err := initApp(); err != nil {
@@ -329,9 +713,30 @@ func runCheck(cmd *cobra.Command, args []string) error {
 	fmt.Println("🔍 系统环境检查")
 	fmt.Println("================")
 
+	// 检查 worktree 磁盘用量
+	fmt.Print("Worktree 磁盘: ")
+	if usage, err := mcp.MeasureWorktreeDisk(cfg.MCP.WorktreeBaseDir); err != nil {
+		fmt.Printf("❌ 统计失败 - %v\n", err)
+	} else {
+		usage.QuotaBytes = cfg.MCP.WorktreeDisk.QuotaBytes
+		text := fmt.Sprintf("%s（%d 个 worktree）", formatBytes(usage.TotalBytes), len(usage.Worktrees))
+		switch {
+		case usage.QuotaBytes == 0:
+			fmt.Printf("✅ %s，未设置配额\n", text)
+		case usage.Exceeded():
+			fmt.Printf("❌ %s，已达到配额 %s，新的 worktree 将被拒绝或等待\n", text, formatBytes(usage.QuotaBytes))
+		default:
+			fmt.Printf("✅ %s / 配额 %s\n", text, formatBytes(usage.QuotaBytes))
+		}
+	}
+
 	// 检查 WSL
-	wslBridge := wsl.NewWSLBridge(log.GetZapLogger())
+	wslBridge, err := wsl.NewBridge(cfg, log.GetZapLogger())
+	if err != nil {
+		return err
+	}
 
+	fmt.Printf("执行后端: %s\n", wslBridge.Backend())
 	fmt.Print("WSL 环境: ")
 	if err := wslBridge.CheckWSL(); err != nil {
 		fmt.Printf("❌ 失败 - %v\n", err)
@@ -372,11 +777,64 @@ func runCheck(cmd *cobra.Command, args []string) error {
 		} else {
 			fmt.Println("✅ 可用")
 		}
+
+		// 检查 Node.js 运行时
+		fmt.Print("Node.js: ")
+		if diag, err := wslBridge.DiagnoseNode(defaultDistro); err != nil {
+			fmt.Printf("❌ 诊断失败 - %v\n", err)
+		} else {
+			if diag.NodePath != "" {
+				source := "系统"
+				if diag.UsesNVM() {
+					source = "nvm"
+				}
+				fmt.Printf("%s (%s, npm %s) %s\n", diag.NodeVersion, source, diag.NPMVersion, diag.NodePath)
+			} else {
+				fmt.Println("未找到")
+			}
+			for _, issue := range diag.Issues() {
+				fmt.Printf("  ⚠️  %s\n", issue)
+			}
+		}
+
+		// 检查 GPU 支持
+		fmt.Print("GPU 加速: ")
+		if gpu, err := wslBridge.DetectGPU(defaultDistro); err != nil {
+			fmt.Printf("❌ 检测失败 - %v\n", err)
+		} else {
+			if gpu.Available() {
+				fmt.Printf("✅ CUDA 可用 %s\n", gpu.GPUName)
+			} else {
+				fmt.Println("⚠️  CUDA 不可用（需要 GPU 的任务将被拒绝）")
+			}
+			if gpu.WSLg {
+				fmt.Println("  WSLg: ✅ 可用")
+			} else {
+				fmt.Println("  WSLg: ⚠️  不可用")
+			}
+		}
+
+		// 检查发行版健康状态
+		fmt.Println("发行版健康检查:")
+		checks, err := wslBridge.CheckDistroHealth(defaultDistro)
+		if err != nil {
+			fmt.Printf("  ❌ 检查失败 - %v\n", err)
+		}
+		for _, check := range checks {
+			if check.Passed {
+				fmt.Printf("  ✅ %s: %s\n", check.Name, check.Message)
+				continue
+			}
+			fmt.Printf("  ❌ %s: %s\n", check.Name, check.Message)
+			if check.Suggestion != "" {
+				fmt.Printf("     建议: %s\n", check.Suggestion)
+			}
+		}
 	}
 
 	// 检查路径转换
 	fmt.Print("路径转换: ")
-	pathConverter := converter.NewPathConverter()
+	pathConverter := wslBridge.PathConverter()
 	currentDir, err := converter.GetCurrentDirectory()
 	if err != nil {
 		fmt.Printf("❌ 获取当前目录失败 - %v\n", err)
@@ -397,454 +855,2176 @@ func runCheck(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// runConfigShow 显示配置命令
-func runConfigShow(cmd *cobra.Command, args []string) error {
+// runInstall 安装命令执行函数
+func runInstall(cmd *cobra.Command, args []string) error {
 	if err := initApp(); err != nil {
 		return err
 	}
 
-	fmt.Println("📋 当前配置")
-	fmt.Println("============")
-	fmt.Printf("调试模式: %v\n", cfg.Debug)
-	fmt.Printf("日志级别: %s\n", cfg.LogLevel)
-	fmt.Printf("默认 WSL 发行版: %s\n", cfg.WSL.DefaultDistro)
-	fmt.Printf("Claude Code 可执行文件: %s\n", cfg.ClaudeCode.Executable)
-	fmt.Printf("Claude Code 默认参数: %v\n", cfg.ClaudeCode.DefaultArgs)
-	fmt.Printf("交互模式: %v\n", cfg.ClaudeCode.Interactive)
+	method, _ := cmd.Flags().GetString("method")
 
-	// 显示配置文件路径
-	cm := config.NewConfigManager()
-	if configFile != "" {
-		cm.SetConfigPath(configFile)
+	wslBridge, err := wsl.NewBridge(cfg, log.GetZapLogger())
+	if err != nil {
+		return err
+	}
+	if err := wslBridge.CheckWSL(); err != nil {
+		return fmt.Errorf("WSL 环境检查失败: %w", err)
 	}
-	fmt.Printf("配置文件路径: %s\n", cm.GetConfigPath())
 
-	return nil
-}
+	targetDistro, err := resolveDistro(wslBridge)
+	if err != nil {
+		return err
+	}
 
-// runConfigInit 初始化配置命令
-func runConfigInit(cmd *cobra.Command, args []string) error {
-	cm := config.NewConfigManager()
-	if configFile != "" {
-		cm.SetConfigPath(configFile)
+	fmt.Println("📦 安装 Claude Code")
+	fmt.Println("==================")
+	fmt.Printf("发行版: %s\n", targetDistro)
+	fmt.Printf("安装方式: %s\n\n", method)
+
+	fmt.Print("[1/3] 当前状态: ")
+	if err := wslBridge.CheckClaudeCode(targetDistro); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+	} else {
+		fmt.Println("✅ 已安装，将执行更新")
 	}
 
-	// 创建默认配置
-	defaultConfig := config.GetDefaultConfig()
+	fmt.Println("[2/3] 正在安装...")
+	if err := wslBridge.InstallClaudeCode(targetDistro, method); err != nil {
+		fmt.Printf("❌ 安装失败 - %v\n", err)
+		return err
+	}
 
-	// 保存配置
-	if err := cm.SaveConfig(defaultConfig); err != nil {
-		return fmt.Errorf("保存配置失败: %w", err)
+	fmt.Print("[3/3] 验证安装: ")
+	if err := wslBridge.CheckClaudeCode(targetDistro); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return err
 	}
+	fmt.Println("✅ 可用")
 
-	fmt.Printf("✅ 配置文件已创建: %s\n", cm.GetConfigPath())
+	fmt.Println("\n✅ Claude Code 安装完成")
 	return nil
 }
 
-// initApp 初始化应用程序
-func initApp() error {
-	// 加载配置
-	var err error
-	if configFile != "" {
-		cfg, err = config.LoadConfigFromFile(configFile)
-	} else {
-		cm := config.NewConfigManager()
-		cfg, err = cm.LoadConfig()
+// runAuth 认证命令执行函数
+func runAuth(cmd *cobra.Command, args []string) error {
+	if err := initApp(); err != nil {
+		return err
 	}
 
+	wslBridge, err := wsl.NewBridge(cfg, log.GetZapLogger())
 	if err != nil {
-		// 如果配置加载失败，使用默认配置
-		cfg = config.GetDefaultConfig()
-	}
-
-	// 命令行参数覆盖配置
-	if debug {
-		cfg.Debug = true
+		return err
 	}
-	if logLevel != "info" {
-		cfg.LogLevel = logLevel
+	if err := wslBridge.CheckWSL(); err != nil {
+		return fmt.Errorf("WSL 环境检查失败: %w", err)
 	}
 
-	// 初始化日志器
-	log, err = logger.CreateLoggerFromConfig(cfg.LogLevel, cfg.Debug, "")
+	targetDistro, err := resolveDistro(wslBridge)
 	if err != nil {
-		return fmt.Errorf("初始化日志器失败: %w", err)
+		return err
 	}
 
-	// 设置全局日志器
-	logger.SetGlobalLogger(log)
+	fmt.Println("🔑 Claude Code 登录")
+	fmt.Println("==================")
+	fmt.Printf("发行版: %s\n\n", targetDistro)
 
-	log.Debug("应用程序初始化完成",
-		zap.Bool("debug", cfg.Debug),
-		zap.String("logLevel", cfg.LogLevel))
+	err = wslBridge.CheckClaudeCode(targetDistro)
+	switch {
+	case err == nil:
+		fmt.Println("✅ Claude Code 已登录，无需重复认证")
+		return nil
+	case !apperrors.IsCode(err, apperrors.ErrClaudeCodeAuthRequired):
+		fmt.Printf("❌ %v\n", err)
+		return err
+	}
+
+	fmt.Println("请按照下方提示完成登录（在浏览器中打开链接，并在此处粘贴令牌）：")
+	if err := wslBridge.AuthenticateClaudeCode(targetDistro); err != nil {
+		fmt.Printf("❌ 登录失败 - %v\n", err)
+		return err
+	}
 
+	fmt.Print("\n验证登录状态: ")
+	if err := wslBridge.CheckClaudeCode(targetDistro); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return err
+	}
+	fmt.Println("✅ 已登录")
 	return nil
 }
 
-// getWorkingDirectory 获取工作目录
-func getWorkingDirectory() (string, error) {
-	if targetDir != "" {
-		// 使用指定目录
-		absPath, err := filepath.Abs(targetDir)
-		if err != nil {
-			return "", apperrors.Wrapf(err, apperrors.ErrInvalidPath, "无法获取绝对路径: %s", targetDir)
-		}
-		return absPath, nil
+// runTune 调优命令执行函数
+func runTune(cmd *cobra.Command, args []string) error {
+	if err := initApp(); err != nil {
+		return err
 	}
 
-	// 使用当前目录
-	return converter.GetCurrentDirectory()
-}
+	apply, _ := cmd.Flags().GetBool("apply")
 
-// printVersion 打印版本信息
-func printVersion() {
-	fmt.Printf("Auto Claude Code v%s\n", version)
-	fmt.Printf("Commit: %s\n", commit)
-	fmt.Printf("Build Date: %s\n", date)
-	fmt.Printf("Go Version: %s\n", "go1.21+")
-}
+	wslBridge, err := wsl.NewBridge(cfg, log.GetZapLogger())
+	if err != nil {
+		return err
+	}
+	if wslBridge.Backend() != wsl.BackendWSL {
+		return fmt.Errorf("tune 命令仅适用于 WSL 后端，当前后端: %s", wslBridge.Backend())
+	}
+	if err := wslBridge.CheckWSL(); err != nil {
+		return fmt.Errorf("WSL 环境检查失败: %w", err)
+	}
 
-// runMCPServer MCP服务器命令执行函数
-func runMCPServer(cmd *cobra.Command, args []string) error {
-	if err := initApp(); err != nil {
+	targetDistro, err := resolveDistro(wslBridge)
+	if err != nil {
 		return err
 	}
 
-	// 检查MCP配置
-	if !cfg.MCP.Enabled {
-		return fmt.Errorf("MCP服务器未启用，请在配置文件中设置 mcp.enabled = true")
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("获取用户目录失败: %w", err)
 	}
+	wslconfigPath := filepath.Join(homeDir, wsl.WSLConfigFile)
 
-	log.Info("启动MCP服务器",
-		zap.String("host", cfg.MCP.Host),
-		zap.Int("port", cfg.MCP.Port),
-		zap.Int("maxConcurrentTasks", cfg.MCP.MaxConcurrentTasks))
+	wslconfigContent, err := os.ReadFile(wslconfigPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("读取 %s 失败: %w", wslconfigPath, err)
+	}
+	wslconfContent, err := wslBridge.ExecuteCommandWithOutput(targetDistro, wsl.WSLConfReadCommand)
+	if err != nil {
+		return fmt.Errorf("读取 %s 失败: %w", wsl.WSLConfFile, err)
+	}
 
-	// 创建WSL桥接器
-	wslBridge := wsl.NewWSLBridge(log.GetZapLogger())
+	wslconfig := wsl.ParseINI(string(wslconfigContent))
+	wslconf := wsl.ParseINI(wslconfContent)
+	host := wsl.DetectHostResources()
+	maxTasks := cfg.MCP.MaxConcurrentTasks
 
-	// 检查WSL环境
-	if err := wslBridge.CheckWSL(); err != nil {
-		return fmt.Errorf("WSL环境检查失败: %w", err)
+	fmt.Println("🔧 WSL 配置调优")
+	fmt.Println("===============")
+	fmt.Printf("发行版: %s\n", targetDistro)
+	fmt.Printf("最大并发任务数: %d\n", maxTasks)
+	if host.MemoryMB > 0 {
+		fmt.Printf("宿主机: %d 个处理器, %dMB 内存\n", host.CPUs, host.MemoryMB)
 	}
+	fmt.Println()
 
-	// 创建MCP服务器
-	mcpServer := mcp.NewMCPServer(&cfg.MCP, log, wslBridge)
-
-	// 创建上下文
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// 启动服务器
-	if err := mcpServer.Start(ctx); err != nil {
-		return fmt.Errorf("MCP服务器启动失败: %w", err)
+	advice := wsl.AdviseWSLConfig(wslconfig, wslconf, maxTasks, host)
+	if len(advice) == 0 {
+		fmt.Println("✅ 当前 WSL 配置适合运行的并发任务数，无需调整")
+		return nil
 	}
 
-	log.Info("MCP服务器启动成功", zap.String("address", mcpServer.GetAddress()))
+	for _, a := range advice {
+		current := a.Current
+		if current == "" {
+			current = "未设置"
+		}
+		fmt.Printf("⚠️  %s [%s] %s: %s → %s\n", a.File, a.Section, a.Key, current, a.Recommended)
+		fmt.Printf("   %s\n", a.Reason)
+	}
 
-	// 等待信号
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	if !apply {
+		fmt.Println("\n使用 --apply 写入以上建议")
+		return nil
+	}
 
-	// 阻塞等待信号
-	sig := <-sigChan
-	log.Info("收到信号，开始关闭服务器", zap.String("signal", sig.String()))
+	wsl.ApplyAdvice(wslconfig, wslconf, advice)
+	fmt.Println()
 
-	// 优雅关闭
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer shutdownCancel()
+	if newContent := wslconfig.String(); newContent != string(wslconfigContent) {
+		if len(wslconfigContent) > 0 {
+			if err := os.WriteFile(wslconfigPath+".bak", wslconfigContent, 0644); err != nil {
+				return fmt.Errorf("备份 %s 失败: %w", wslconfigPath, err)
+			}
+		}
+		if err := os.WriteFile(wslconfigPath, []byte(newContent), 0644); err != nil {
+			return fmt.Errorf("写入 %s 失败: %w", wslconfigPath, err)
+		}
+		fmt.Printf("✅ 已更新 %s\n", wslconfigPath)
+	}
 
-	if err := mcpServer.Stop(shutdownCtx); err != nil {
-		log.Error("MCP服务器关闭失败", zap.Error(err))
-		return err
+	if newContent := wslconf.String(); newContent != wsl.ParseINI(wslconfContent).String() {
+		fmt.Printf("更新 %s 需要 sudo 权限：\n", wsl.WSLConfFile)
+		if err := wslBridge.ExecuteCommand(targetDistro, wsl.WSLConfWriteCommand(newContent)); err != nil {
+			return fmt.Errorf("写入 %s 失败: %w", wsl.WSLConfFile, err)
+		}
+		fmt.Printf("✅ 已更新 %s\n", wsl.WSLConfFile)
 	}
 
-	log.Info("MCP服务器已关闭")
+	fmt.Println("\n请执行 wsl --shutdown 后重新打开发行版使配置生效")
 	return nil
 }
 
-// runMCPStdio MCP stdio服务器命令执行函数
-func runMCPStdio(cmd *cobra.Command, args []string) error {
-	if err := initApp(); err != nil {
-		return err
+// resolveDistro 确定要使用的 WSL 发行版（命令行 > 配置 > 系统默认）
+func resolveDistro(wslBridge wsl.WSLBridge) (string, error) {
+	if distro != "" {
+		return distro, nil
 	}
 
-	log.Info("启动MCP stdio服务器")
+	if cfg.WSL.DefaultDistro != "" {
+		return cfg.WSL.DefaultDistro, nil
+	}
 
-	// 创建WSL桥接器
-	wslBridge := wsl.NewWSLBridge(log.GetZapLogger())
+	defaultDistro, err := wslBridge.GetDefaultDistro()
+	if err != nil {
+		return "", fmt.Errorf("获取默认 WSL 发行版失败: %w", err)
+	}
+	return defaultDistro, nil
+}
 
-	// 检查WSL环境
-	if err := wslBridge.CheckWSL(); err != nil {
-		return fmt.Errorf("WSL环境检查失败: %w", err)
+// runConfigShow 显示配置命令
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	if err := initApp(); err != nil {
+		return err
 	}
 
-	// 配置stdio传输
-	cfg.MCP.Enabled = true
-	cfg.MCP.HTTP.Enabled = false // 禁用HTTP
-	cfg.MCP.Stdio.Enabled = true // 启用stdio
-	cfg.MCP.Stdio.Reader = os.Stdin
-	cfg.MCP.Stdio.Writer = os.Stdout
+	fmt.Println("📋 当前配置")
+	fmt.Println("============")
+	fmt.Printf("调试模式: %v\n", cfg.Debug)
+	fmt.Printf("日志级别: %s\n", cfg.LogLevel)
+	if cfg.Profile != "" {
+		fmt.Printf("配置档案: %s\n", cfg.Profile)
+	}
+	fmt.Printf("默认 WSL 发行版: %s\n", cfg.WSL.DefaultDistro)
+	fmt.Printf("Claude Code 可执行文件: %s\n", cfg.ClaudeCode.Executable)
+	fmt.Printf("Claude Code 默认参数: %v\n", cfg.ClaudeCode.DefaultArgs)
+	fmt.Printf("交互模式: %v\n", cfg.ClaudeCode.Interactive)
 
-	// 创建MCP服务器
-	mcpServer := mcp.NewMCPServer(&cfg.MCP, log, wslBridge)
+	// 显示配置文件路径
+	cm := config.NewConfigManager()
+	if configFile != "" {
+		cm.SetConfigPath(configFile)
+	}
+	fmt.Printf("配置文件路径: %s\n", cm.GetConfigPath())
 
-	// 创建上下文
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	return nil
+}
 
-	// 启动服务器
-	if err := mcpServer.Start(ctx); err != nil {
-		return fmt.Errorf("MCP stdio服务器启动失败: %w", err)
+// runConfigValidate 检查配置文件命令，有问题时返回错误
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	path := configFile
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if path == "" {
+		// 找到的配置文件有问题时 LoadConfig 同样失败，之后逐项检查；没有找到文件时报告错误
+		cm := config.NewConfigManager()
+		_, err := cm.LoadConfig()
+		if path = cm.ConfigFileUsed(); path == "" {
+			if err != nil {
+				return fmt.Errorf("查找配置文件失败: %w", err)
+			}
+			return fmt.Errorf("未找到配置文件，请指定要检查的文件")
+		}
 	}
 
-	log.Info("MCP stdio服务器启动成功")
-
-	// 等待信号或stdin关闭
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	fmt.Printf("🔍 检查配置文件: %s\n", path)
+	problems, err := config.ValidateConfigFile(path, configProfile)
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		fmt.Println("✅ 配置有效")
+		return nil
+	}
 
-	// 监控stdin状态
-	stdinChan := make(chan struct{})
-	go func() {
-		// 当stdin关闭时发送信号
-		scanner := bufio.NewScanner(os.Stdin)
-		for scanner.Scan() {
-			// 继续读取，直到stdin关闭
+	for _, problem := range problems {
+		key := problem.Key
+		if key == "" {
+			key = "(文件)"
 		}
-		close(stdinChan)
-	}()
+		fmt.Printf("❌ %s: %v\n", key, problem.Err)
+	}
+	return fmt.Errorf("配置文件中有 %d 个问题", len(problems))
+}
 
-	// 等待退出信号
-	select {
-	case sig := <-sigChan:
-		log.Info("收到信号，开始关闭服务器", zap.String("signal", sig.String()))
-	case <-stdinChan:
-		log.Info("stdin已关闭，开始关闭服务器")
+// runConfigInit 初始化配置命令
+func runConfigInit(cmd *cobra.Command, args []string) error {
+	if err := initApp(); err != nil {
+		return err
 	}
 
-	// 优雅关闭
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer shutdownCancel()
+	cm := config.NewConfigManager()
+	if configFile != "" {
+		cm.SetConfigPath(configFile)
+	}
 
-	if err := mcpServer.Stop(shutdownCtx); err != nil {
-		log.Error("MCP stdio服务器关闭失败", zap.Error(err))
-		return err
+	// 创建默认配置
+	defaultConfig := config.GetDefaultConfig()
+
+	// 保存配置
+	err := cm.SaveConfig(defaultConfig)
+	recordAudit(audit.ActionConfigChange, cm.GetConfigPath(), map[string]interface{}{"operation": "init"}, err)
+	if err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
 	}
 
-	log.Info("MCP stdio服务器已关闭")
+	fmt.Printf("✅ 配置文件已创建: %s\n", cm.GetConfigPath())
 	return nil
 }
 
-// runTaskList 列出所有任务
-func runTaskList(cmd *cobra.Command, args []string) error {
-	serverURL, _ := cmd.Flags().GetString("server")
+// recordAudit 记录命令行发起的变更操作，操作者为当前系统用户；审计日志不可用时只输出警告
+func recordAudit(action, resource string, params map[string]interface{}, opErr error) {
+	if !cfg.MCP.Audit.Enabled {
+		return
+	}
 
-	resp, err := http.Get(serverURL + "/tasks")
+	auditLog, err := audit.NewLog(cfg.MCP.Audit.FilePath(), log)
 	if err != nil {
-		return fmt.Errorf("连接MCP服务器失败: %w", err)
+		log.Warn("打开审计日志失败", zap.Error(err))
+		return
 	}
-	defer resp.Body.Close()
+	defer auditLog.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("服务器返回错误: %s", resp.Status)
+	actor := "cli"
+	if u, err := user.Current(); err == nil {
+		actor = "cli:" + u.Username
 	}
+	auditLog.Record(audit.WithActor(context.Background(), actor), action, resource, params, opErr)
+}
 
-	var result struct {
-		Tasks []map[string]interface{} `json:"tasks"`
+// openTokenStore 打开令牌存储
+func openTokenStore(cmd *cobra.Command) (*auth.TokenStore, error) {
+	if err := initApp(); err != nil {
+		return nil, err
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("解析响应失败: %w", err)
+	path, _ := cmd.Flags().GetString("store")
+	if path == "" {
+		path = cfg.MCP.Auth.TokenStorePath()
 	}
 
-	// 打印任务列表
-	fmt.Println("📋 任务列表")
-	fmt.Println("=" + strings.Repeat("=", 80))
+	return auth.NewTokenStore(path, log)
+}
 
-	if len(result.Tasks) == 0 {
-		fmt.Println("暂无任务")
-		return nil
+// runTokenCreate 创建令牌
+func runTokenCreate(cmd *cobra.Command, args []string) error {
+	store, err := openTokenStore(cmd)
+	if err != nil {
+		return err
 	}
 
-	// 按状态分组统计
-	statusCount := make(map[string]int)
-	for _, task := range result.Tasks {
-		if status, ok := task["status"].(string); ok {
-			statusCount[status]++
+	name, _ := cmd.Flags().GetString("name")
+	scopes, _ := cmd.Flags().GetStringSlice("scopes")
+	expires, _ := cmd.Flags().GetString("expires")
+
+	var ttl time.Duration
+	if expires != "" {
+		if ttl, err = time.ParseDuration(expires); err != nil || ttl <= 0 {
+			return fmt.Errorf("无效的有效期: %s", expires)
 		}
 	}
 
-	// 显示统计信息
-	fmt.Printf("总计: %d 个任务", len(result.Tasks))
-	for status, count := range statusCount {
-		emoji := getStatusEmoji(status)
-		fmt.Printf(" | %s %s: %d", emoji, status, count)
+	token, record, err := store.Create(auth.CreateTokenRequest{Name: name, Scopes: scopes, TTL: ttl})
+	var tokenID string
+	if record != nil {
+		tokenID = record.ID
+	}
+	recordAudit(audit.ActionTokenCreate, tokenID, map[string]interface{}{
+		"name":      name,
+		"scopes":    scopes,
+		"expiresIn": expires,
+	}, err)
+	if err != nil {
+		return err
 	}
-	fmt.Println("\n")
 
-	// 显示任务详情
-	fmt.Printf("%-12s %-10s %-20s %-30s %-15s\n", "任务ID", "状态", "优先级", "描述", "创建时间")
-	fmt.Println(strings.Repeat("-", 90))
+	fmt.Printf("✅ 令牌已创建: %s\n", record.ID)
+	printTokenRecord(record)
+	fmt.Printf("令牌: %s\n", token)
+	fmt.Println("⚠️  令牌只显示这一次，请妥善保存")
+	return nil
+}
 
-	for _, task := range result.Tasks {
-		taskID := getStringField(task, "id", "")
-		status := getStringField(task, "status", "unknown")
-		priority := getStringField(task, "priority", "medium")
-		description := getStringField(task, "task_description", "")
-		createdAt := getStringField(task, "created_at", "")
+// runTokenList 列出令牌
+func runTokenList(cmd *cobra.Command, args []string) error {
+	store, err := openTokenStore(cmd)
+	if err != nil {
+		return err
+	}
 
-		// 截断长描述
-		if len(description) > 28 {
-			description = description[:25] + "..."
-		}
+	records := store.List()
+	fmt.Println("🔑 令牌列表")
+	fmt.Println("=" + strings.Repeat("=", 80))
 
-		// 格式化时间
-		if createdAt != "" {
-			if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
-				createdAt = t.Format("01-02 15:04")
-			}
-		}
+	if len(records) == 0 {
+		fmt.Println("暂无令牌")
+		return nil
+	}
 
-		emoji := getStatusEmoji(status)
-		fmt.Printf("%-12s %s %-8s %-20s %-30s %-15s\n",
-			taskID[:min(12, len(taskID))], emoji, status, priority, description, createdAt)
+	now := time.Now()
+	fmt.Printf("%-18s %-16s %-20s %-8s %-20s %s\n", "ID", "名称", "权限", "状态", "过期时间", "最近使用")
+	for _, record := range records {
+		status := "有效"
+		if record.RevokedAt != nil {
+			status = "已撤销"
+		} else if !record.Active(now) {
+			status = "已过期"
+		}
+		fmt.Printf("%-18s %-16s %-20s %-8s %-20s %s\n",
+			record.ID,
+			truncateString(record.Name, 16),
+			strings.Join(record.Scopes, ","),
+			status,
+			formatTokenTime(record.ExpiresAt, "永不过期"),
+			formatTokenTime(record.LastUsedAt, "-"))
 	}
 
 	return nil
 }
 
-// runTaskShow 查看任务详情
-func runTaskShow(cmd *cobra.Command, args []string) error {
-	serverURL, _ := cmd.Flags().GetString("server")
-	taskID := args[0]
+// runTokenRevoke 撤销令牌
+func runTokenRevoke(cmd *cobra.Command, args []string) error {
+	store, err := openTokenStore(cmd)
+	if err != nil {
+		return err
+	}
 
-	resp, err := http.Get(serverURL + "/tasks/" + taskID)
+	err = store.Revoke(args[0])
+	recordAudit(audit.ActionTokenRevoke, args[0], nil, err)
 	if err != nil {
-		return fmt.Errorf("连接MCP服务器失败: %w", err)
+		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotFound {
-		return fmt.Errorf("任务不存在: %s", taskID)
+	fmt.Printf("✅ 令牌已撤销: %s\n", args[0])
+	return nil
+}
+
+// runTokenRotate 轮换令牌
+func runTokenRotate(cmd *cobra.Command, args []string) error {
+	store, err := openTokenStore(cmd)
+	if err != nil {
+		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("服务器返回错误: %s", resp.Status)
+	token, record, err := store.Rotate(args[0])
+	params := map[string]interface{}{}
+	if record != nil {
+		params["newTokenId"] = record.ID
+	}
+	recordAudit(audit.ActionTokenRotate, args[0], params, err)
+	if err != nil {
+		return err
 	}
 
-	var task map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&task); err != nil {
-		return fmt.Errorf("解析响应失败: %w", err)
+	fmt.Printf("✅ 令牌已轮换: %s -> %s\n", args[0], record.ID)
+	printTokenRecord(record)
+	fmt.Printf("令牌: %s\n", token)
+	fmt.Println("⚠️  令牌只显示这一次，请妥善保存，旧令牌已失效")
+	return nil
+}
+
+// printTokenRecord 打印令牌信息
+func printTokenRecord(record *auth.TokenRecord) {
+	fmt.Printf("名称: %s\n", record.Name)
+	fmt.Printf("权限: %s\n", strings.Join(record.Scopes, ", "))
+	fmt.Printf("过期时间: %s\n", formatTokenTime(record.ExpiresAt, "永不过期"))
+}
+
+// formatTokenTime 格式化令牌时间，为空时返回 empty
+func formatTokenTime(t *time.Time, empty string) string {
+	if t == nil {
+		return empty
 	}
+	return t.Local().Format("2006-01-02 15:04")
+}
 
-	// 打印任务详情
-	fmt.Printf("🔍 任务详情: %s\n", taskID)
-	fmt.Println("=" + strings.Repeat("=", 50))
+// initApp 初始化应用程序
+func initApp() error {
+	// 加载配置
+	var err, loadErr error
+	cm := config.NewConfigManager()
+	if configFile != "" {
+		cm.SetConfigPath(configFile)
+	}
+	cm.SetProfile(configProfile)
+	cfg, loadErr = cm.LoadConfig()
+	configFileUsed = cm.ConfigFileUsed()
 
-	status := getStringField(task, "status", "unknown")
-	emoji := getStatusEmoji(status)
+	if loadErr != nil {
+		// 指定了配置档案时不能退回默认配置，否则档案名错误会丢掉整个配置文件（包括认证配置）
+		if configProfile != "" || os.Getenv(config.ProfileEnv) != "" {
+			return fmt.Errorf("加载配置失败: %w", loadErr)
+		}
+		// 如果配置加载失败，使用默认配置
+		cfg = config.GetDefaultConfig()
+	}
 
-	fmt.Printf("状态: %s %s\n", emoji, status)
-	fmt.Printf("优先级: %s\n", getStringField(task, "priority", "medium"))
-	fmt.Printf("描述: %s\n", getStringField(task, "task_description", ""))
-	fmt.Printf("项目路径: %s\n", getStringField(task, "project_path", ""))
-	fmt.Printf("创建时间: %s\n", formatTime(getStringField(task, "created_at", "")))
+	// 命令行参数覆盖配置
+	if debug {
+		cfg.Debug = true
+	}
+	if logLevelSet {
+		cfg.LogLevel = logLevel
+	}
+
+	// 初始化日志器
+	log, err = logger.CreateLoggerFromConfig(cfg.LogLevel, cfg.Debug, "")
+	if err != nil {
+		return fmt.Errorf("初始化日志器失败: %w", err)
+	}
+
+	// 设置全局日志器
+	logger.SetGlobalLogger(log)
+
+	log.Debug("应用程序初始化完成",
+		zap.Bool("debug", cfg.Debug),
+		zap.String("logLevel", cfg.LogLevel))
+
+	return nil
+}
+
+// getWorkingDirectory 获取工作目录
+func getWorkingDirectory() (string, error) {
+	if targetDir != "" {
+		// 使用指定目录
+		absPath, err := filepath.Abs(targetDir)
+		if err != nil {
+			return "", apperrors.Wrapf(err, apperrors.ErrInvalidPath, "无法获取绝对路径: %s", targetDir)
+		}
+		return absPath, nil
+	}
+
+	// 使用当前目录
+	return converter.GetCurrentDirectory()
+}
+
+// printVersion 打印版本信息
+func printVersion() {
+	fmt.Printf("Auto Claude Code v%s\n", version)
+	fmt.Printf("Commit: %s\n", commit)
+	fmt.Printf("Build Date: %s\n", date)
+	fmt.Printf("Go Version: %s\n", "go1.21+")
+}
+
+// setupTracing 按配置启用 OpenTelemetry 追踪，返回的函数用于导出剩余 span 并关闭
+func setupTracing() (func(), error) {
+	if !cfg.Tracing.Enabled {
+		return func() {}, nil
+	}
+
+	tracer, err := tracing.NewTracer(cfg.Tracing, log)
+	if err != nil {
+		return nil, err
+	}
+	tracing.SetTracer(tracer)
+
+	return func() {
+		tracing.SetTracer(nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := tracer.Shutdown(ctx); err != nil {
+			log.Warn("关闭追踪导出器超时", zap.Error(err))
+		}
+	}, nil
+}
+
+// watchConfig 监视加载的配置文件，修改后调整日志级别并重新加载MCP服务器的配置，返回的函数停止监视
+func watchConfig(mcpServer mcp.MCPServer) func() {
+	if !cfg.MCP.ConfigReload || configFileUsed == "" {
+		return func() {}
+	}
+
+	currentLevel := cfg.LogLevel
+	watcher, err := config.WatchConfigFile(configFileUsed, configProfile, func(newCfg *config.Config) {
+		var fields []string
+		// 命令行指定的日志级别继续优先
+		if !logLevelSet && newCfg.LogLevel != currentLevel {
+			if err := logger.SetLevel(log, newCfg.LogLevel); err != nil {
+				log.Warn("调整日志级别失败", zap.Error(err))
+			} else {
+				currentLevel = newCfg.LogLevel
+				fields = append(fields, "log_level")
+			}
+		}
+		fields = append(fields, mcpServer.Reload(&newCfg.MCP)...)
+		if len(fields) > 0 {
+			recordAudit(audit.ActionConfigChange, configFileUsed, map[string]interface{}{"operation": "reload", "fields": fields}, nil)
+		}
+	}, func(err error) {
+		log.Warn("重新加载配置失败，继续使用原配置", zap.Error(err))
+	})
+	if err != nil {
+		log.Warn("无法监视配置文件，修改配置后需要重启服务器", zap.Error(err))
+		return func() {}
+	}
+
+	log.Info("监视配置文件的修改", zap.String("path", configFileUsed))
+	return func() {
+		watcher.Close()
+	}
+}
+
+// runMCPServer MCP服务器命令执行函数
+func runMCPServer(cmd *cobra.Command, args []string) error {
+	if err := initApp(); err != nil {
+		return err
+	}
+
+	// 检查MCP配置
+	if !cfg.MCP.Enabled {
+		return fmt.Errorf("MCP服务器未启用，请在配置文件中设置 mcp.enabled = true")
+	}
+
+	log.Info("启动MCP服务器",
+		zap.String("host", cfg.MCP.Host),
+		zap.Int("port", cfg.MCP.Port),
+		zap.Int("maxConcurrentTasks", cfg.MCP.MaxConcurrentTasks))
+
+	// 创建WSL桥接器
+	wslBridge, err := wsl.NewBridge(cfg, log.GetZapLogger())
+	if err != nil {
+		return err
+	}
+
+	// 检查WSL环境
+	if err := wslBridge.CheckWSL(); err != nil {
+		return fmt.Errorf("WSL环境检查失败: %w", err)
+	}
+
+	// 启用追踪
+	shutdownTracing, err := setupTracing()
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing()
+
+	// 创建MCP服务器
+	mcpServer := mcp.NewMCPServer(&cfg.MCP, log, wslBridge)
+
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 启动服务器
+	if err := mcpServer.Start(ctx); err != nil {
+		return fmt.Errorf("MCP服务器启动失败: %w", err)
+	}
+
+	log.Info("MCP服务器启动成功", zap.String("address", mcpServer.GetAddress()))
+
+	// 配置文件修改后在运行时应用
+	stopWatching := watchConfig(mcpServer)
+	defer stopWatching()
+
+	// 等待信号
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	// 阻塞等待信号
+	sig := <-sigChan
+	log.Info("收到信号，开始关闭服务器", zap.String("signal", sig.String()))
+
+	// 优雅关闭
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+
+	if err := mcpServer.Stop(shutdownCtx); err != nil {
+		log.Error("MCP服务器关闭失败", zap.Error(err))
+		return err
+	}
+
+	log.Info("MCP服务器已关闭")
+	return nil
+}
+
+// runMCPStdio MCP stdio服务器命令执行函数
+func runMCPStdio(cmd *cobra.Command, args []string) error {
+	if err := initApp(); err != nil {
+		return err
+	}
+
+	log.Info("启动MCP stdio服务器")
+
+	// 创建WSL桥接器
+	wslBridge, err := wsl.NewBridge(cfg, log.GetZapLogger())
+	if err != nil {
+		return err
+	}
+
+	// 检查WSL环境
+	if err := wslBridge.CheckWSL(); err != nil {
+		return fmt.Errorf("WSL环境检查失败: %w", err)
+	}
+
+	// 配置stdio传输
+	cfg.MCP.Enabled = true
+	cfg.MCP.HTTP.Enabled = false // 禁用HTTP
+	cfg.MCP.Stdio.Enabled = true // 启用stdio
+	cfg.MCP.Stdio.Reader = os.Stdin
+	cfg.MCP.Stdio.Writer = os.Stdout
+
+	// 启用追踪
+	shutdownTracing, err := setupTracing()
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing()
+
+	// 创建MCP服务器
+	mcpServer := mcp.NewMCPServer(&cfg.MCP, log, wslBridge)
+
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 启动服务器
+	if err := mcpServer.Start(ctx); err != nil {
+		return fmt.Errorf("MCP stdio服务器启动失败: %w", err)
+	}
+
+	log.Info("MCP stdio服务器启动成功")
+
+	// 等待信号或stdin关闭
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	// 监控stdin状态
+	stdinChan := make(chan struct{})
+	go func() {
+		// 当stdin关闭时发送信号
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			// 继续读取，直到stdin关闭
+		}
+		close(stdinChan)
+	}()
+
+	// 等待退出信号
+	select {
+	case sig := <-sigChan:
+		log.Info("收到信号，开始关闭服务器", zap.String("signal", sig.String()))
+	case <-stdinChan:
+		log.Info("stdin已关闭，开始关闭服务器")
+	}
+
+	// 优雅关闭
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
+
+	if err := mcpServer.Stop(shutdownCtx); err != nil {
+		log.Error("MCP stdio服务器关闭失败", zap.Error(err))
+		return err
+	}
+
+	log.Info("MCP stdio服务器已关闭")
+	return nil
+}
+
+// serverError 将服务器的错误响应转换为错误，优先使用响应体中的错误代码和详情
+func serverError(resp *http.Response, action string) error {
+	var problem struct {
+		Detail    string `json:"detail"`
+		Code      string `json:"code"`
+		RequestID string `json:"requestId"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil || problem.Detail == "" {
+		return fmt.Errorf("%s: %s", action, resp.Status)
+	}
+	if problem.RequestID != "" {
+		return fmt.Errorf("%s: %s [%s] (request_id=%s)", action, problem.Detail, problem.Code, problem.RequestID)
+	}
+	return fmt.Errorf("%s: %s [%s]", action, problem.Detail, problem.Code)
+}
+
+// taskRefreshLimit watch 和 tui 每次刷新获取的最新任务数
+const taskRefreshLimit = 100
+
+const (
+	// tuiLogBacklogBytes tui 切换任务时先显示的已有输出字节数
+	tuiLogBacklogBytes = 8 * 1024
+	// tuiMaxLogLines tui 日志面板保留的最大行数
+	tuiMaxLogLines = 500
+)
+
+// runTaskList 列出任务
+func runTaskList(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+	statuses, _ := cmd.Flags().GetStringSlice("status")
+	project, _ := cmd.Flags().GetString("project")
+	since, _ := cmd.Flags().GetDuration("since")
+	sortBy, _ := cmd.Flags().GetString("sort")
+	limit, _ := cmd.Flags().GetInt("limit")
+	offset, _ := cmd.Flags().GetInt("offset")
+
+	query := url.Values{}
+	if len(statuses) > 0 {
+		query.Set("status", strings.Join(statuses, ","))
+	}
+	if project != "" {
+		query.Set("project", project)
+	}
+	if since > 0 {
+		query.Set("since", time.Now().Add(-since).Format(time.RFC3339))
+	}
+	query.Set("sort", sortBy)
+	query.Set("limit", fmt.Sprint(limit))
+	query.Set("offset", fmt.Sprint(offset))
+
+	resp, err := http.Get(serverURL + "/tasks?" + query.Encode())
+	if err != nil {
+		return fmt.Errorf("连接MCP服务器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return serverError(resp, "服务器返回错误")
+	}
+
+	var result struct {
+		Tasks      []map[string]interface{} `json:"tasks"`
+		Total      int                      `json:"total"`
+		NextOffset int                      `json:"nextOffset"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	// 打印任务列表
+	fmt.Println("📋 任务列表")
+	fmt.Println("=" + strings.Repeat("=", 80))
+
+	if len(result.Tasks) == 0 {
+		fmt.Println("暂无任务")
+		return nil
+	}
+
+	// 按状态分组统计
+	statusCount := make(map[string]int)
+	for _, task := range result.Tasks {
+		if status, ok := task["status"].(string); ok {
+			statusCount[status]++
+		}
+	}
+
+	// 显示统计信息
+	fmt.Printf("总计: %d 个任务，本页 %d 个", result.Total, len(result.Tasks))
+	for status, count := range statusCount {
+		emoji := getStatusEmoji(status)
+		fmt.Printf(" | %s %s: %d", emoji, status, count)
+	}
+	fmt.Print("\n\n")
+
+	// 显示任务详情
+	fmt.Printf("%-12s %-10s %-20s %-30s %-15s\n", "任务ID", "状态", "优先级", "描述", "创建时间")
+	fmt.Println(strings.Repeat("-", 90))
+
+	for _, task := range result.Tasks {
+		taskID := getStringField(task, "id", "")
+		status := getStringField(task, "status", "unknown")
+		priority := getStringField(task, "priority", "medium")
+		description := getStringField(task, "task_description", "")
+		createdAt := getStringField(task, "created_at", "")
+
+		// 截断长描述
+		if len(description) > 28 {
+			description = description[:25] + "..."
+		}
+
+		// 格式化时间
+		if createdAt != "" {
+			if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
+				createdAt = t.Format("01-02 15:04")
+			}
+		}
+
+		emoji := getStatusEmoji(status)
+		fmt.Printf("%-12s %s %-8s %-20s %-30s %-15s\n",
+			taskID[:min(12, len(taskID))], emoji, status, priority, description, createdAt)
+	}
+
+	if result.NextOffset > 0 {
+		fmt.Printf("\n还有更多任务，使用 --offset %d 查看下一页\n", result.NextOffset)
+	}
+
+	return nil
+}
+
+// runTaskShow 查看任务详情
+func runTaskShow(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+	taskID := args[0]
+
+	resp, err := http.Get(serverURL + "/tasks/" + taskID)
+	if err != nil {
+		return fmt.Errorf("连接MCP服务器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("任务不存在: %s", taskID)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return serverError(resp, "服务器返回错误")
+	}
+
+	var task map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&task); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	// 打印任务详情
+	fmt.Printf("🔍 任务详情: %s\n", taskID)
+	fmt.Println("=" + strings.Repeat("=", 50))
+
+	status := getStringField(task, "status", "unknown")
+	emoji := getStatusEmoji(status)
+
+	fmt.Printf("状态: %s %s\n", emoji, status)
+	fmt.Printf("优先级: %s\n", getStringField(task, "priority", "medium"))
+	fmt.Printf("描述: %s\n", getStringField(task, "task_description", ""))
+	fmt.Printf("项目路径: %s\n", getStringField(task, "project_path", ""))
+	if owner := getStringField(task, "owner", ""); owner != "" {
+		fmt.Printf("所有者: %s\n", owner)
+	}
+	fmt.Printf("创建时间: %s\n", formatTime(getStringField(task, "created_at", "")))
 	fmt.Printf("开始时间: %s\n", formatTime(getStringField(task, "started_at", "")))
 	fmt.Printf("完成时间: %s\n", formatTime(getStringField(task, "completed_at", "")))
 
-	if worktreeID := getStringField(task, "worktree_id", ""); worktreeID != "" {
-		fmt.Printf("Worktree ID: %s\n", worktreeID)
-	}
+	if worktreeID := getStringField(task, "worktree_id", ""); worktreeID != "" {
+		fmt.Printf("Worktree ID: %s\n", worktreeID)
+	}
+
+	if errorMsg := getStringField(task, "error", ""); errorMsg != "" {
+		fmt.Printf("错误信息: %s\n", errorMsg)
+	}
+
+	if truncation, ok := task["truncation"].(map[string]interface{}); ok {
+		total, _ := truncation["totalBytes"].(float64)
+		omitted, _ := truncation["omittedBytes"].(float64)
+		fmt.Printf("输出已截断: 共 %.0f 字节，省略中间 %.0f 字节\n", total, omitted)
+	}
+
+	if result, ok := task["result"].(map[string]interface{}); ok {
+		if mergeBack, ok := result["mergeBack"].(map[string]interface{}); ok {
+			fmt.Printf("合并回项目: %s %s -> %s\n", getStringField(mergeBack, "status", ""),
+				getStringField(mergeBack, "branch", ""), getStringField(mergeBack, "targetBranch", ""))
+		}
+		if pr, ok := result["pullRequest"].(map[string]interface{}); ok {
+			switch status := getStringField(pr, "status", ""); status {
+			case mcp.PullRequestStatusCreated:
+				fmt.Printf("拉取请求: %s\n", getStringField(pr, "url", ""))
+			case mcp.PullRequestStatusFailed:
+				fmt.Printf("拉取请求: 创建失败: %s\n", getStringField(pr, "error", ""))
+			default:
+				fmt.Printf("拉取请求: %s\n", status)
+			}
+		}
+	}
+
+	if metadata, ok := task["metadata"].(map[string]interface{}); ok {
+		if distro := getStringField(metadata, "distro", ""); distro != "" {
+			fmt.Printf("发行版: %s\n", distro)
+		}
+		if rerunOf := getStringField(metadata, "rerunOf", ""); rerunOf != "" {
+			fmt.Printf("重新运行自: %s\n", rerunOf)
+		}
+		if reruns, ok := metadata["reruns"].([]interface{}); ok && len(reruns) > 0 {
+			ids := make([]string, 0, len(reruns))
+			for _, id := range reruns {
+				ids = append(ids, fmt.Sprint(id))
+			}
+			fmt.Printf("重新运行: %s\n", strings.Join(ids, ", "))
+		}
+	}
+
+	if notes, ok := task["notes"].([]interface{}); ok && len(notes) > 0 {
+		fmt.Printf("\n📝 备注:\n")
+		for _, item := range notes {
+			note, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			author := getStringField(note, "author", "")
+			if author != "" {
+				author = " " + author
+			}
+			fmt.Printf("  [%s]%s: %s\n", formatTime(getStringField(note, "time", "")), author, getStringField(note, "text", ""))
+		}
+	}
+
+	if output := getStringField(task, "output", ""); output != "" {
+		fmt.Printf("\n📄 输出:\n%s\n", output)
+	}
+
+	return nil
+}
+
+// runTaskNote 为任务添加备注
+func runTaskNote(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+	author, _ := cmd.Flags().GetString("author")
+	taskID := args[0]
+
+	if author == "" {
+		author = os.Getenv("USER")
+	}
+	if author == "" {
+		author = os.Getenv("USERNAME")
+	}
+	reqBody, err := json.Marshal(map[string]string{"text": strings.Join(args[1:], " "), "author": author})
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	resp, err := http.Post(serverURL+"/tasks/"+taskID+"/notes", "application/json", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return fmt.Errorf("连接MCP服务器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("任务不存在: %s", taskID)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return serverError(resp, "添加备注失败")
+	}
+
+	var note mcp.TaskNote
+	if err := json.NewDecoder(resp.Body).Decode(&note); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	fmt.Printf("✅ 已添加备注: %s (%s)\n", taskID, note.Time.Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// runTaskRerun 重新运行已结束的任务
+func runTaskRerun(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+	taskID := args[0]
+
+	var overrides mcp.RerunOverrides
+	overrides.ID, _ = cmd.Flags().GetString("id")
+	overrides.Command, _ = cmd.Flags().GetString("command")
+	overrides.PromptSuffix, _ = cmd.Flags().GetString("prompt-suffix")
+	overrides.Priority, _ = cmd.Flags().GetInt("priority")
+	overrides.Model, _ = cmd.Flags().GetString("model")
+	overrides.MaxTurns, _ = cmd.Flags().GetInt("max-turns")
+
+	reqBody, err := json.Marshal(overrides)
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	resp, err := http.Post(serverURL+"/tasks/"+url.PathEscape(taskID)+"/rerun", "application/json", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return fmt.Errorf("连接MCP服务器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return serverError(resp, "重新运行任务失败")
+	}
+
+	var status mcp.TaskStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	fmt.Printf("✅ 已重新运行任务: %s -> %s (%s)\n", taskID, status.ID, status.Status)
+	return nil
+}
+
+// runTaskMerge 将任务的修改合并回项目
+func runTaskMerge(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+	taskID := args[0]
+
+	var opts mcp.MergeOptions
+	opts.Strategy, _ = cmd.Flags().GetString("strategy")
+	opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	opts.Message, _ = cmd.Flags().GetString("message")
+
+	reqBody, err := json.Marshal(opts)
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	resp, err := http.Post(serverURL+"/tasks/"+url.PathEscape(taskID)+"/merge", "application/json", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return fmt.Errorf("连接MCP服务器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return serverError(resp, "合并任务失败")
+	}
+
+	var result mcp.MergeResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	printMergeResult(&result)
+	if result.Status == mcp.MergeStatusConflict {
+		return fmt.Errorf("任务分支与 %s 冲突，项目未被修改", result.TargetBranch)
+	}
+	return nil
+}
+
+// printMergeResult 显示任务分支合并回项目的结果
+func printMergeResult(result *mcp.MergeResult) {
+	switch result.Status {
+	case mcp.MergeStatusMerged:
+		fmt.Printf("✅ 已将 %s 合并到 %s (%s): %s\n", result.Branch, result.TargetBranch, result.Strategy, result.MergeCommit)
+	case mcp.MergeStatusReady:
+		fmt.Printf("✅ 试运行: %s 可以无冲突合并到 %s (%s)\n", result.Branch, result.TargetBranch, result.Strategy)
+	case mcp.MergeStatusNoChanges:
+		fmt.Printf("ℹ️  %s 没有需要合并到 %s 的修改\n", result.Branch, result.TargetBranch)
+	case mcp.MergeStatusConflict:
+		fmt.Printf("⚠️  %s 与 %s 冲突:\n", result.Branch, result.TargetBranch)
+		for _, file := range result.Conflicts {
+			fmt.Printf("   %s\n", file)
+		}
+	default:
+		fmt.Printf("❌ 合并失败: %s\n", result.Error)
+	}
+}
+
+// runTaskExport 导出已结束的任务记录
+func runTaskExport(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+	sinceFlag, _ := cmd.Flags().GetString("since")
+	statuses, _ := cmd.Flags().GetStringSlice("status")
+	output, _ := cmd.Flags().GetString("output")
+
+	query := url.Values{}
+	if sinceFlag != "" {
+		since, err := parseSince(sinceFlag, time.Now())
+		if err != nil {
+			return err
+		}
+		query.Set("since", since.Format(time.RFC3339))
+	}
+	if len(statuses) > 0 {
+		query.Set("status", strings.Join(statuses, ","))
+	}
+
+	resp, err := http.Get(serverURL + "/export?" + query.Encode())
+	if err != nil {
+		return fmt.Errorf("连接MCP服务器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return serverError(resp, "导出任务失败")
+	}
+
+	if output == "" {
+		_, err := io.Copy(os.Stdout, resp.Body)
+		return err
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("创建输出文件失败: %w", err)
+	}
+	counter := &lineCounter{}
+	if _, err := io.Copy(io.MultiWriter(file, counter), resp.Body); err != nil {
+		file.Close()
+		return fmt.Errorf("写入输出文件失败: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("写入输出文件失败: %w", err)
+	}
+	fmt.Printf("✅ 已导出 %d 个任务: %s\n", counter.lines, output)
+	return nil
+}
+
+// parseSince 解析相对时长（支持 d 表示天，如 7d）或 RFC 3339 时间，返回起始时间
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	} else if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("无效的 --since: %s (示例: 7d, 24h, 2024-01-01T00:00:00Z)", value)
+}
+
+// lineCounter 统计写入的行数
+type lineCounter struct {
+	lines int
+}
+
+// Write 实现 io.Writer
+func (c *lineCounter) Write(p []byte) (int, error) {
+	c.lines += bytes.Count(p, []byte("\n"))
+	return len(p), nil
+}
+
+// runTaskCancel 取消任务
+func runTaskCancel(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+	taskID := args[0]
+
+	req, err := http.NewRequest(http.MethodDelete, serverURL+"/tasks/"+taskID, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("连接MCP服务器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("任务不存在: %s", taskID)
+	}
+
+	if resp.StatusCode != http.StatusNoContent {
+		return serverError(resp, "取消任务失败")
+	}
+
+	fmt.Printf("✅ 任务已取消: %s\n", taskID)
+	return nil
+}
+
+// runServerPause 暂停任务分发
+func runServerPause(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+	reason, _ := cmd.Flags().GetString("reason")
+
+	reqBody, err := json.Marshal(map[string]string{"reason": reason})
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %w", err)
+	}
+	state, err := postQueueAction(serverURL+"/queue/pause", reqBody, "暂停任务分发失败")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("⏸️  任务分发已暂停（%s 起）\n", state.PausedAt.Local().Format("2006-01-02 15:04:05"))
+	if state.Reason != "" {
+		fmt.Printf("原因: %s\n", state.Reason)
+	}
+	fmt.Printf("排队任务: %d，正在执行的任务会继续运行\n", state.Length)
+	return nil
+}
+
+// runServerResume 恢复任务分发
+func runServerResume(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+
+	state, err := postQueueAction(serverURL+"/queue/resume", nil, "恢复任务分发失败")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("▶️  任务分发已恢复，排队任务: %d\n", state.Length)
+	return nil
+}
+
+// runServerStatus 查看任务队列状态
+func runServerStatus(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+
+	resp, err := http.Get(serverURL + "/queue")
+	if err != nil {
+		return fmt.Errorf("连接MCP服务器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return serverError(resp, "获取队列状态失败")
+	}
+	var state mcp.QueueState
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	if state.Paused {
+		fmt.Printf("任务分发: 已暂停（%s 起）\n", state.PausedAt.Local().Format("2006-01-02 15:04:05"))
+		if state.Reason != "" {
+			fmt.Printf("原因: %s\n", state.Reason)
+		}
+	} else {
+		fmt.Println("任务分发: 运行中")
+	}
+	if state.Capacity > 0 {
+		fmt.Printf("排队任务: %d/%d\n", state.Length, state.Capacity)
+	} else {
+		fmt.Printf("排队任务: %d\n", state.Length)
+	}
+	return nil
+}
+
+// postQueueAction 发送暂停或恢复任务分发的请求并返回队列状态
+func postQueueAction(url string, body []byte, action string) (*mcp.QueueState, error) {
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("连接MCP服务器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, serverError(resp, action)
+	}
+	var state mcp.QueueState
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	return &state, nil
+}
+
+// taskPriorityLevels task submit 的优先级名称对应的服务器优先级
+var taskPriorityLevels = map[string]int{"low": 1, "medium": 2, "high": 3}
+
+// runWorktreeShow 显示worktree的信息、git 状态和修改的文件
+func runWorktreeShow(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+	porcelain, _ := cmd.Flags().GetBool("porcelain")
+	worktreeID := url.PathEscape(args[0])
+
+	var status mcp.WorktreeStatus
+	if err := getServerJSON(serverURL+"/worktrees/"+worktreeID+"/status", "获取worktree状态失败", &status); err != nil {
+		return err
+	}
+	if porcelain {
+		if status.Porcelain != "" {
+			fmt.Println(status.Porcelain)
+		}
+		return nil
+	}
+
+	var info mcp.WorktreeInfo
+	if err := getServerJSON(serverURL+"/worktrees/"+worktreeID, "获取worktree失败", &info); err != nil {
+		return err
+	}
+
+	fmt.Printf("🌳 Worktree: %s\n", info.ID)
+	fmt.Println("=" + strings.Repeat("=", 50))
+	fmt.Printf("项目路径: %s\n", info.ProjectPath)
+	fmt.Printf("WSL路径: %s\n", info.WSLPath)
+	fmt.Printf("状态: %s\n", info.Status)
+	if info.TaskID != "" {
+		fmt.Printf("任务: %s\n", info.TaskID)
+	}
+	if info.Pinned {
+		fmt.Println("已固定: 是（不会被空闲清理删除）")
+	}
+	fmt.Printf("分支: %s (基于 %s)\n", status.Branch, info.Branch)
+	fmt.Printf("基准提交: %s\n", status.BaseCommit)
+	fmt.Printf("当前提交: %s\n", status.Head)
+	if status.Clean {
+		fmt.Println("工作区: 干净")
+	} else {
+		fmt.Println("工作区: 有未提交的修改")
+		for _, line := range strings.Split(status.Porcelain, "\n") {
+			fmt.Printf("   %s\n", line)
+		}
+	}
+
+	if len(status.Files) == 0 {
+		fmt.Println("\n相对基准提交没有修改")
+		return nil
+	}
+	fmt.Printf("\n修改的文件 (%d, +%d -%d):\n", len(status.Files), status.Additions, status.Deletions)
+	for _, file := range status.Files {
+		fmt.Println("   " + formatChangedFile(file))
+	}
+	return nil
+}
+
+// formatChangedFile 格式化一个修改的文件，如 "M README.md +3 -1（未提交）"
+func formatChangedFile(file mcp.ChangedFile) string {
+	code := map[string]string{
+		mcp.FileStatusAdded:    "A",
+		mcp.FileStatusModified: "M",
+		mcp.FileStatusDeleted:  "D",
+		mcp.FileStatusRenamed:  "R",
+	}[file.Status]
+	path := file.Path
+	if file.OldPath != "" {
+		path = file.OldPath + " -> " + file.Path
+	}
+	text := fmt.Sprintf("%s %s", code, path)
+	if file.Binary {
+		text += " (二进制)"
+	} else {
+		text += fmt.Sprintf(" +%d -%d", file.Additions, file.Deletions)
+	}
+	switch {
+	case file.Untracked:
+		text += "（未跟踪）"
+	case file.Uncommitted:
+		text += "（未提交）"
+	}
+	return text
+}
+
+// runWorktreePin 固定或取消固定worktree，由命令名决定
+func runWorktreePin(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+	pinned := cmd.Name() == "pin"
+
+	reqBody, err := json.Marshal(mcp.WorktreeUpdate{Pinned: &pinned})
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPatch, serverURL+"/worktrees/"+url.PathEscape(args[0]), bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("连接MCP服务器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return serverError(resp, "更新worktree失败")
+	}
+
+	if pinned {
+		fmt.Printf("📌 Worktree已固定: %s\n", args[0])
+	} else {
+		fmt.Printf("✅ Worktree已取消固定: %s\n", args[0])
+	}
+	return nil
+}
+
+// runWorktreeClean 删除worktree
+func runWorktreeClean(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+	force, _ := cmd.Flags().GetBool("force")
+
+	endpoint := serverURL + "/worktrees/" + url.PathEscape(args[0])
+	if force {
+		endpoint += "?force=true"
+	}
+	req, err := http.NewRequest(http.MethodDelete, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("连接MCP服务器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return serverError(resp, "删除worktree失败（固定的worktree需要 --force，任务使用中的worktree不能删除）")
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		return serverError(resp, "删除worktree失败")
+	}
+
+	fmt.Printf("✅ Worktree已删除: %s\n", args[0])
+	return nil
+}
+
+// formatBytes 以 1024 进制格式化字节数，如 1.5 GB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 4; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTP"[exp])
+}
+
+// getServerJSON 获取服务器的 JSON 响应，非 200 时返回服务器给出的错误
+func getServerJSON(endpoint, action string, v interface{}) error {
+	resp, err := http.Get(endpoint)
+	if err != nil {
+		return fmt.Errorf("连接MCP服务器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return serverError(resp, action)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return nil
+}
+
+// runTaskSubmit 提交新任务
+func runTaskSubmit(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+	projectPath, _ := cmd.Flags().GetString("project")
+	description, _ := cmd.Flags().GetString("description")
+	priority, _ := cmd.Flags().GetString("priority")
+	timeout, _ := cmd.Flags().GetString("timeout")
+	claudeArgs, _ := cmd.Flags().GetStringSlice("args")
+	taskDistro, _ := cmd.Flags().GetString("distro")
+	distroTags, _ := cmd.Flags().GetStringSlice("distro-tag")
+	model, _ := cmd.Flags().GetString("model")
+	maxTurns, _ := cmd.Flags().GetInt("max-turns")
+	permissionMode, _ := cmd.Flags().GetString("permission-mode")
+	allowedTools, _ := cmd.Flags().GetStringSlice("allowed-tools")
+	dependsOn, _ := cmd.Flags().GetStringSlice("depends-on")
+	onSuccess, _ := cmd.Flags().GetString("on-success")
+	onFailure, _ := cmd.Flags().GetString("on-failure")
+	template, _ := cmd.Flags().GetString("template")
+	params, _ := cmd.Flags().GetStringToString("param")
+	validateOnly, _ := cmd.Flags().GetBool("validate-only")
+	interactive, _ := cmd.Flags().GetBool("interactive")
+	mergeBack, _ := cmd.Flags().GetBool("merge-back")
+	mergeStrategy, _ := cmd.Flags().GetString("merge-strategy")
+	mergeDryRun, _ := cmd.Flags().GetBool("merge-dry-run")
+
+	if template == "" {
+		if projectPath == "" || (description == "" && !interactive) {
+			return fmt.Errorf("未使用模板时必须指定 --project 和 --description")
+		}
+		if len(params) > 0 {
+			return fmt.Errorf("--param 只能与 --template 一起使用")
+		}
+	}
+
+	priorityLevel, ok := taskPriorityLevels[priority]
+	if !ok {
+		return fmt.Errorf("无效的优先级: %s (可选: low, medium, high)", priority)
+	}
+	timeoutDuration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return fmt.Errorf("无效的超时时间: %s", timeout)
+	}
+	// 服务器只接受绝对路径
+	if projectPath != "" {
+		if absPath, err := filepath.Abs(projectPath); err == nil {
+			projectPath = absPath
+		}
+	}
+
+	// 构建任务请求，字段与服务器的 TaskRequest 一致，任务描述作为 Claude Code 的第一个参数
+	// 使用模板时只发送显式指定的字段，其余字段由服务器取自模板
+	taskReq := map[string]interface{}{
+		"type":        "claude_code",
+		"projectPath": projectPath,
+		"command":     description,
+		"args":        claudeArgs,
+		"priority":    priorityLevel,
+		"timeout":     timeoutDuration,
+	}
+	if template != "" {
+		taskReq["template"] = template
+		if len(params) > 0 {
+			taskReq["params"] = params
+		}
+		if !cmd.Flags().Changed("priority") {
+			delete(taskReq, "priority")
+		}
+		if !cmd.Flags().Changed("timeout") {
+			delete(taskReq, "timeout")
+		}
+	}
+	if taskDistro != "" {
+		taskReq["distro"] = taskDistro
+	}
+	if len(distroTags) > 0 {
+		taskReq["distroTags"] = distroTags
+	}
+	if model != "" {
+		taskReq["model"] = model
+	}
+	if maxTurns > 0 {
+		taskReq["maxTurns"] = maxTurns
+	}
+	if permissionMode != "" {
+		taskReq["permissionMode"] = permissionMode
+	}
+	if len(allowedTools) > 0 {
+		taskReq["allowedTools"] = allowedTools
+	}
+	if len(dependsOn) > 0 {
+		taskReq["dependsOn"] = dependsOn
+	}
+	if onSuccess != "" {
+		taskReq["onSuccess"] = map[string]interface{}{"command": onSuccess}
+	}
+	if onFailure != "" {
+		taskReq["onFailure"] = map[string]interface{}{"command": onFailure, "includeOutput": true}
+	}
+	if mergeBack || mergeStrategy != "" || mergeDryRun {
+		taskReq["mergeBack"] = mcp.MergeOptions{Strategy: mergeStrategy, DryRun: mergeDryRun}
+	}
+	if validateOnly {
+		taskReq["validateOnly"] = true
+	}
+	if interactive {
+		taskReq["type"] = "interactive"
+	}
+
+	reqBody, err := json.Marshal(taskReq)
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	resp, err := http.Post(serverURL+"/tasks", "application/json", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return fmt.Errorf("连接MCP服务器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if validateOnly {
+		if resp.StatusCode != http.StatusOK {
+			return serverError(resp, "任务检查未通过")
+		}
+		var plan mcp.TaskPlan
+		if err := json.NewDecoder(resp.Body).Decode(&plan); err != nil {
+			return fmt.Errorf("解析响应失败: %w", err)
+		}
+		printTaskPlan(&plan)
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		return serverError(resp, "提交任务失败")
+	}
+
+	var task map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&task); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	taskID := getStringField(task, "id", "")
+	fmt.Printf("✅ 任务已提交: %s\n", taskID)
+	fmt.Printf("状态: %s\n", getStringField(task, "status", ""))
+	if template != "" {
+		fmt.Printf("模板: %s\n", template)
+	}
+	if _, ok := taskReq["priority"]; ok {
+		fmt.Printf("优先级: %s\n", priority)
+	}
+	if description != "" {
+		fmt.Printf("描述: %s\n", description)
+	}
+	if model != "" {
+		fmt.Printf("模型: %s\n", model)
+	}
+	if len(dependsOn) > 0 {
+		fmt.Printf("依赖: %s\n", strings.Join(dependsOn, ", "))
+	}
+	if interactive {
+		fmt.Printf("连接终端: auto-claude-code task attach %s\n", taskID)
+	}
+
+	return nil
+}
+
+// printTaskPlan 显示 --validate-only 返回的执行计划
+func printTaskPlan(plan *mcp.TaskPlan) {
+	fmt.Println("✅ 任务检查通过，未提交任务")
+	fmt.Printf("项目路径: %s\n", plan.ProjectPath)
+	fmt.Printf("WSL 路径: %s\n", plan.WSLPath)
+	fmt.Printf("发行版: %s\n", plan.Distro)
+	fmt.Printf("参数: %s\n", strings.Join(plan.Args, " "))
+	fmt.Printf("优先级: %d\n", plan.Priority)
+	fmt.Printf("超时: %s\n", plan.Timeout)
+	if plan.Worktree != nil {
+		fmt.Printf("工作树: %s", plan.Worktree.Mode)
+		if plan.Worktree.Branch != "" {
+			fmt.Printf("（分支 %s）", plan.Worktree.Branch)
+		}
+		fmt.Printf("，当前 %d/%d\n", plan.Worktree.Active, plan.Worktree.MaxWorktrees)
+	}
+	if plan.WaitsForDependencies {
+		fmt.Println("依赖尚未完成，提交后任务会先等待依赖")
+	}
+}
+
+// runTemplateList 列出任务模板
+func runTemplateList(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+
+	resp, err := http.Get(serverURL + "/templates")
+	if err != nil {
+		return fmt.Errorf("连接MCP服务器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return serverError(resp, "获取任务模板失败")
+	}
+
+	var list struct {
+		Templates []*mcp.TaskTemplate `json:"templates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	fmt.Println("📋 任务模板")
+	fmt.Println("=" + strings.Repeat("=", 80))
+
+	if len(list.Templates) == 0 {
+		fmt.Println("暂无模板")
+		return nil
+	}
+
+	fmt.Printf("%-20s %-30s %-20s %s\n", "名称", "参数", "更新时间", "说明")
+	for _, template := range list.Templates {
+		fmt.Printf("%-20s %-30s %-20s %s\n",
+			template.Name,
+			truncateString(strings.Join(template.Parameters, ","), 30),
+			template.UpdatedAt.Local().Format("2006-01-02 15:04"),
+			template.Description)
+	}
+
+	return nil
+}
+
+// runTemplateShow 查看任务模板
+func runTemplateShow(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+
+	resp, err := http.Get(serverURL + "/templates/" + url.PathEscape(args[0]))
+	if err != nil {
+		return fmt.Errorf("连接MCP服务器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return serverError(resp, "获取任务模板失败")
+	}
+
+	var template mcp.TaskTemplate
+	if err := json.NewDecoder(resp.Body).Decode(&template); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	fmt.Printf("📋 任务模板: %s\n", template.Name)
+	fmt.Println("=" + strings.Repeat("=", 50))
+	printTaskTemplate(&template)
+	return nil
+}
+
+// runTemplateSave 创建或替换任务模板
+func runTemplateSave(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+	description, _ := cmd.Flags().GetString("description")
+	summary, _ := cmd.Flags().GetString("summary")
+	projectPath, _ := cmd.Flags().GetString("project")
+	claudeArgs, _ := cmd.Flags().GetStringSlice("args")
+	priority, _ := cmd.Flags().GetString("priority")
+	timeout, _ := cmd.Flags().GetString("timeout")
+	distro, _ := cmd.Flags().GetString("distro")
+	defaults, _ := cmd.Flags().GetStringToString("default")
+
+	template := mcp.TaskTemplate{
+		Name:        args[0],
+		Description: summary,
+		ProjectPath: projectPath,
+		Command:     description,
+		Args:        claudeArgs,
+		Distro:      distro,
+		Defaults:    defaults,
+	}
+	if priority != "" {
+		level, ok := taskPriorityLevels[priority]
+		if !ok {
+			return fmt.Errorf("无效的优先级: %s (可选: low, medium, high)", priority)
+		}
+		template.Priority = level
+	}
+	if timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("无效的超时时间: %s", timeout)
+		}
+		template.Timeout = d
+	}
+
+	reqBody, err := json.Marshal(template)
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPut, serverURL+"/templates/"+url.PathEscape(template.Name), bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("连接MCP服务器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return serverError(resp, "保存任务模板失败")
+	}
+
+	var saved mcp.TaskTemplate
+	if err := json.NewDecoder(resp.Body).Decode(&saved); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusCreated {
+		fmt.Printf("✅ 任务模板已创建: %s\n", saved.Name)
+	} else {
+		fmt.Printf("✅ 任务模板已更新: %s\n", saved.Name)
+	}
+	printTaskTemplate(&saved)
+	return nil
+}
+
+// runTemplateDelete 删除任务模板
+func runTemplateDelete(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+
+	req, err := http.NewRequest(http.MethodDelete, serverURL+"/templates/"+url.PathEscape(args[0]), nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("连接MCP服务器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return serverError(resp, "删除任务模板失败")
+	}
+
+	fmt.Printf("✅ 任务模板已删除: %s\n", args[0])
+	return nil
+}
+
+// printTaskTemplate 打印任务模板
+func printTaskTemplate(template *mcp.TaskTemplate) {
+	if template.Description != "" {
+		fmt.Printf("说明: %s\n", template.Description)
+	}
+	if template.ProjectPath != "" {
+		fmt.Printf("项目路径: %s\n", template.ProjectPath)
+	} else {
+		fmt.Println("项目路径: 提交时指定")
+	}
+	fmt.Printf("描述: %s\n", template.Command)
+	if len(template.Args) > 0 {
+		fmt.Printf("参数: %s\n", strings.Join(template.Args, " "))
+	}
+	if template.Priority > 0 {
+		fmt.Printf("优先级: %d\n", template.Priority)
+	}
+	if template.Timeout > 0 {
+		fmt.Printf("超时时间: %s\n", template.Timeout)
+	}
+	if template.Distro != "" {
+		fmt.Printf("发行版: %s\n", template.Distro)
+	}
+	for _, name := range template.Parameters {
+		if value, ok := template.Defaults[name]; ok {
+			fmt.Printf("模板参数: %s (默认: %s)\n", name, value)
+		} else {
+			fmt.Printf("模板参数: %s\n", name)
+		}
+	}
+}
+
+// runTaskLogs 打印任务输出，--follow 时通过 WebSocket 持续接收新输出
+func runTaskLogs(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+	follow, _ := cmd.Flags().GetBool("follow")
+	tail, _ := cmd.Flags().GetInt("tail")
+	stream, _ := cmd.Flags().GetString("stream")
+	taskID := args[0]
+
+	if follow {
+		if stream != "" {
+			return fmt.Errorf("--stream 不能与 --follow 同时使用")
+		}
+		return followTaskOutput(serverURL, taskID, -tail)
+	}
+
+	query := url.Values{}
+	if stream != "" {
+		query.Set("stream", stream)
+	}
+	offset := -tail
+	for {
+		query.Set("offset", strconv.Itoa(offset))
+		resp, err := http.Get(fmt.Sprintf("%s/tasks/%s/output?%s", serverURL, url.PathEscape(taskID), query.Encode()))
+		if err != nil {
+			return fmt.Errorf("连接MCP服务器失败: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := serverError(resp, "获取任务输出失败")
+			resp.Body.Close()
+			return err
+		}
+
+		var page mcp.TaskOutputPage
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("解析响应失败: %w", err)
+		}
+
+		fmt.Print(page.Data)
+		if page.EOF {
+			return nil
+		}
+		offset = page.NextOffset
+	}
+}
+
+// followTaskOutput 通过 WebSocket 持续打印任务输出，任务结束或按 Ctrl+C 时返回
+// offset 为起始字节偏移，负数表示从末尾倒数
+func followTaskOutput(serverURL, taskID string, offset int) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	return streamTaskOutput(ctx, serverURL, taskID, offset, func(msg taskOutputMessage) {
+		switch msg.Type {
+		case "output":
+			if msg.Stream == wsl.StreamStderr {
+				fmt.Fprintln(os.Stderr, msg.Line)
+			} else {
+				fmt.Println(msg.Line)
+			}
+		case "status":
+			fmt.Fprintf(os.Stderr, "\n%s 任务已结束: %s\n", getStatusEmoji(msg.Status), msg.Status)
+			if msg.Error != "" {
+				fmt.Fprintf(os.Stderr, "错误: %s\n", msg.Error)
+			}
+		}
+	})
+}
+
+// taskOutputMessage 任务输出流中的一条消息
+type taskOutputMessage struct {
+	Type   string `json:"type"` // "output" 或 "status"
+	Stream string `json:"stream"`
+	Line   string `json:"line"`
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
 
-	if errorMsg := getStringField(task, "error", ""); errorMsg != "" {
-		fmt.Printf("错误信息: %s\n", errorMsg)
+// streamTaskOutput 订阅任务输出流并逐条回调，任务结束、服务器正常关闭或 ctx 取消时返回 nil
+func streamTaskOutput(ctx context.Context, serverURL, taskID string, offset int, handle func(msg taskOutputMessage)) error {
+	streamURL := fmt.Sprintf("%s/api/v1/tasks/%s/output/stream?offset=%d", serverURL, url.PathEscape(taskID), offset)
+	conn, err := websocket.Dial(ctx, streamURL, nil)
+	if err != nil {
+		var handshakeErr *websocket.HandshakeError
+		if errors.As(err, &handshakeErr) {
+			defer handshakeErr.Response.Body.Close()
+			return serverError(handshakeErr.Response, "订阅任务输出失败")
+		}
+		return fmt.Errorf("连接MCP服务器失败: %w", err)
 	}
+	defer conn.Close(websocket.CloseNormal, "")
 
-	if output := getStringField(task, "output", ""); output != "" {
-		fmt.Printf("\n📄 输出:\n%s\n", output)
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close(websocket.CloseNormal, "")
+	})
+	defer stop()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) && closeErr.Code == websocket.CloseNormal {
+				return nil
+			}
+			return fmt.Errorf("任务输出流中断: %w", err)
+		}
+
+		var msg taskOutputMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		handle(msg)
+		if msg.Type == "status" {
+			return nil
+		}
 	}
+}
 
-	return nil
+// taskDetachKey 断开交互式会话的按键 Ctrl+]
+const taskDetachKey = 0x1d
+
+// taskSessionMessage 交互式会话中的 JSON 控制消息
+type taskSessionMessage struct {
+	Type     string `json:"type"`
+	Cols     int    `json:"cols,omitempty"`
+	Rows     int    `json:"rows,omitempty"`
+	ExitCode int    `json:"exitCode,omitempty"`
 }
 
-// runTaskCancel 取消任务
-func runTaskCancel(cmd *cobra.Command, args []string) error {
+// runTaskAttach 连接交互式任务的终端，键盘输入转发到任务，按 Ctrl+] 断开
+func runTaskAttach(cmd *cobra.Command, args []string) error {
 	serverURL, _ := cmd.Flags().GetString("server")
+	token, _ := cmd.Flags().GetString("token")
+	if token == "" {
+		token = os.Getenv(attachTokenEnv)
+	}
 	taskID := args[0]
 
-	req, err := http.NewRequest(http.MethodDelete, serverURL+"/tasks/"+taskID, nil)
-	if err != nil {
-		return fmt.Errorf("创建请求失败: %w", err)
-	}
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	conn, err := dialTaskSession(ctx, serverURL, taskID, token)
 	if err != nil {
-		return fmt.Errorf("连接MCP服务器失败: %w", err)
+		return err
 	}
-	defer resp.Body.Close()
+	defer conn.Close(websocket.CloseNormal, "")
 
-	if resp.StatusCode == http.StatusNotFound {
-		return fmt.Errorf("任务不存在: %s", taskID)
+	// 原始模式下 Ctrl+C 等按键作为输入发送给 Claude Code
+	stdin, stdout := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if terminal.IsTerminal(stdin) {
+		state, err := terminal.MakeRaw(stdin)
+		if err != nil {
+			return err
+		}
+		defer terminal.Restore(stdin, state)
 	}
+	terminal.EnableVirtualTerminal(stdout)
+	fmt.Fprintf(os.Stderr, "已连接任务 %s 的交互式会话，按 Ctrl+] 断开\r\n", taskID)
 
-	if resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("取消任务失败: %s", resp.Status)
+	sendResize := func(cols, rows int) {
+		conn.WriteJSON(&taskSessionMessage{Type: "resize", Cols: cols, Rows: rows})
 	}
+	if cols, rows, err := terminal.Size(stdout); err == nil {
+		sendResize(cols, rows)
+	}
+	go terminal.NotifyResize(ctx, stdout, sendResize)
 
-	fmt.Printf("✅ 任务已取消: %s\n", taskID)
-	return nil
-}
-
-// runTaskSubmit 提交新任务
-func runTaskSubmit(cmd *cobra.Command, args []string) error {
-	serverURL, _ := cmd.Flags().GetString("server")
-	projectPath, _ := cmd.Flags().GetString("project")
-	description, _ := cmd.Flags().GetString("description")
-	priority, _ := cmd.Flags().GetString("priority")
-	timeout, _ := cmd.Flags().GetString("timeout")
-	claudeArgs, _ := cmd.Flags().GetStringSlice("args")
+	detached := make(chan struct{})
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			input := buf[:n]
+			if i := bytes.IndexByte(input, taskDetachKey); i >= 0 {
+				if i > 0 {
+					conn.WriteMessage(websocket.OpBinary, input[:i])
+				}
+				close(detached)
+				conn.Close(websocket.CloseNormal, "")
+				return
+			}
+			if err := conn.WriteMessage(websocket.OpBinary, input); err != nil {
+				return
+			}
+		}
+	}()
 
-	// 构建任务请求
-	taskReq := map[string]interface{}{
-		"project_path":     projectPath,
-		"task_description": description,
-		"priority":         priority,
-		"timeout":          timeout,
-		"claude_args":      claudeArgs,
-	}
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close(websocket.CloseNormal, "")
+	})
+	defer stop()
 
-	reqBody, err := json.Marshal(taskReq)
-	if err != nil {
-		return fmt.Errorf("序列化请求失败: %w", err)
-	}
+	for {
+		op, data, err := conn.ReadMessage()
+		if err != nil {
+			select {
+			case <-detached:
+				fmt.Fprintf(os.Stderr, "\r\n已断开，会话继续运行，可再次执行 task attach %s 连接\r\n", taskID)
+				return nil
+			default:
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) && closeErr.Code == websocket.CloseNormal {
+				return nil
+			}
+			return fmt.Errorf("交互式会话中断: %w", err)
+		}
 
-	resp, err := http.Post(serverURL+"/tasks", "application/json", bytes.NewBuffer(reqBody))
-	if err != nil {
-		return fmt.Errorf("连接MCP服务器失败: %w", err)
+		if op == websocket.OpBinary {
+			os.Stdout.Write(data)
+			continue
+		}
+		var msg taskSessionMessage
+		if err := json.Unmarshal(data, &msg); err == nil && msg.Type == "exit" {
+			fmt.Fprintf(os.Stderr, "\r\n交互式会话已结束，退出码: %d\r\n", msg.ExitCode)
+			return nil
+		}
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("提交任务失败: %s", resp.Status)
-	}
+// attachTokenEnv 未指定 --token 时 task attach 使用的令牌环境变量
+const attachTokenEnv = "AUTO_CLAUDE_CODE_TOKEN"
 
-	var task map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&task); err != nil {
-		return fmt.Errorf("解析响应失败: %w", err)
+// dialTaskSession 连接任务的交互式会话，任务排队中或会话尚未启动时每秒重试
+// 服务器要求连接会话时认证，token 以 Bearer 令牌发送
+func dialTaskSession(ctx context.Context, serverURL, taskID, token string) (*websocket.Conn, error) {
+	attachURL := fmt.Sprintf("%s/tasks/%s/attach", serverURL, url.PathEscape(taskID))
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
 	}
+	waiting := false
+	for {
+		conn, err := websocket.Dial(ctx, attachURL, header)
+		if err == nil {
+			return conn, nil
+		}
+		var handshakeErr *websocket.HandshakeError
+		if !errors.As(err, &handshakeErr) {
+			return nil, fmt.Errorf("连接MCP服务器失败: %w", err)
+		}
+		if handshakeErr.Response.StatusCode != http.StatusConflict {
+			defer handshakeErr.Response.Body.Close()
+			return nil, serverError(handshakeErr.Response, "连接交互式会话失败")
+		}
+		handshakeErr.Response.Body.Close()
 
-	taskID := getStringField(task, "id", "")
-	fmt.Printf("✅ 任务已提交: %s\n", taskID)
-	fmt.Printf("状态: %s\n", getStringField(task, "status", ""))
-	fmt.Printf("优先级: %s\n", priority)
-	fmt.Printf("描述: %s\n", description)
+		// 会话不存在时，任务尚未结束才继续等待
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, serverURL+"/tasks/"+url.PathEscape(taskID), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header = header.Clone()
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("连接MCP服务器失败: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			return nil, serverError(resp, "获取任务状态失败")
+		}
+		var status mcp.TaskStatus
+		err = json.NewDecoder(resp.Body).Decode(&status)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("解析响应失败: %w", err)
+		}
+		if status.Status != "pending" && status.Status != "running" {
+			return nil, fmt.Errorf("任务没有正在运行的交互式会话: %s (%s)", taskID, status.Status)
+		}
+		if !waiting {
+			fmt.Fprintf(os.Stderr, "等待任务 %s 的交互式会话启动...\n", taskID)
+			waiting = true
+		}
 
-	return nil
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
 }
 
 // runTaskWatch 实时监控任务状态
+// 通过服务器的任务事件流在任务变化时立即刷新，事件流不可用时按 interval 轮询
 func runTaskWatch(cmd *cobra.Command, args []string) error {
 	serverURL, _ := cmd.Flags().GetString("server")
 	interval, _ := cmd.Flags().GetInt("interval")
@@ -856,46 +3036,103 @@ func runTaskWatch(cmd *cobra.Command, args []string) error {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	ticker := time.NewTicker(time.Duration(interval) * time.Second)
-	defer ticker.Stop()
-
 	// 立即显示一次
 	if err := displayTaskStatus(serverURL); err != nil {
 		return err
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan struct{}, 1)
+	streamErr := make(chan error, 1)
+	go func() {
+		streamErr <- followTaskEvents(ctx, serverURL, changes)
+	}()
+
+	// 事件流断开后才开始轮询
+	var poll <-chan time.Time
+
+	refresh := func() {
+		// 清屏
+		fmt.Print("\033[2J\033[H")
+		fmt.Println("🔄 实时监控任务状态 (按 Ctrl+C 退出)")
+		fmt.Println("=" + strings.Repeat("=", 50))
+
+		if err := displayTaskStatus(serverURL); err != nil {
+			fmt.Printf("❌ 获取任务状态失败: %v\n", err)
+		}
+	}
+
 	for {
 		select {
 		case <-sigChan:
 			fmt.Println("\n👋 监控已停止")
 			return nil
-		case <-ticker.C:
-			// 清屏
-			fmt.Print("\033[2J\033[H")
-			fmt.Println("🔄 实时监控任务状态 (按 Ctrl+C 退出)")
-			fmt.Println("=" + strings.Repeat("=", 50))
-
-			if err := displayTaskStatus(serverURL); err != nil {
-				fmt.Printf("❌ 获取任务状态失败: %v\n", err)
-			}
+		case <-changes:
+			refresh()
+		case err := <-streamErr:
+			fmt.Printf("⚠️  任务事件流不可用（%v），改为每 %d 秒轮询\n", err, interval)
+			ticker := time.NewTicker(time.Duration(interval) * time.Second)
+			defer ticker.Stop()
+			poll = ticker.C
+		case <-poll:
+			refresh()
 		}
 	}
 }
 
+// followTaskEvents 订阅服务器的任务事件流，每收到一个事件向 changes 发送一次通知
+// 未读取的通知会被合并；连接失败或断开时返回错误
+func followTaskEvents(ctx context.Context, serverURL string, changes chan<- struct{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, serverURL+"/api/v1/events", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return serverError(resp, "订阅任务事件失败")
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if !strings.HasPrefix(scanner.Text(), "event:") {
+			continue
+		}
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("事件流已关闭")
+}
+
 // displayTaskStatus 显示任务状态
 func displayTaskStatus(serverURL string) error {
-	resp, err := http.Get(serverURL + "/tasks")
+	resp, err := http.Get(fmt.Sprintf("%s/tasks?limit=%d", serverURL, taskRefreshLimit))
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("服务器返回错误: %s", resp.Status)
+		return serverError(resp, "服务器返回错误")
 	}
 
 	var result struct {
 		Tasks []map[string]interface{} `json:"tasks"`
+		Total int                      `json:"total"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
@@ -910,11 +3147,11 @@ func displayTaskStatus(serverURL string) error {
 	}
 
 	// 显示统计
-	fmt.Printf("📊 总计: %d 个任务 | 更新时间: %s\n\n",
-		len(result.Tasks), time.Now().Format("15:04:05"))
+	fmt.Printf("📊 总计: %d 个任务（显示最新 %d 个）| 更新时间: %s\n\n",
+		result.Total, len(result.Tasks), time.Now().Format("15:04:05"))
 
 	// 按状态显示
-	statusOrder := []string{"running", "pending", "completed", "failed", "cancelled", "timeout"}
+	statusOrder := []string{"running", "pending", "completed", "failed", "cancelled", "interrupted", "timeout"}
 	for _, status := range statusOrder {
 		tasks := statusGroups[status]
 		if len(tasks) == 0 {
@@ -952,6 +3189,8 @@ func getStatusEmoji(status string) string {
 		return "❌"
 	case "cancelled":
 		return "🚫"
+	case "interrupted":
+		return "⚡"
 	case "timeout":
 		return "⏰"
 	default:
@@ -1000,15 +3239,17 @@ func runTaskTUI(cmd *cobra.Command, args []string) error {
 
 // TaskInfo 任务信息结构
 type TaskInfo struct {
-	ID          string     `json:"id"`
-	Status      string     `json:"status"`
-	ProjectPath string     `json:"project_path"`
-	Description string     `json:"description"`
-	Priority    string     `json:"priority"`
-	CreatedAt   time.Time  `json:"created_at"`
-	StartedAt   *time.Time `json:"started_at,omitempty"`
-	CompletedAt *time.Time `json:"completed_at,omitempty"`
-	Error       string     `json:"error,omitempty"`
+	ID          string         `json:"id"`
+	Status      string         `json:"status"`
+	ProjectPath string         `json:"project_path"`
+	Description string         `json:"description"`
+	Priority    string         `json:"priority"`
+	CreatedAt   time.Time      `json:"created_at"`
+	StartedAt   *time.Time     `json:"started_at,omitempty"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	WorktreeID  string         `json:"worktreeId,omitempty"`
+	Notes       []mcp.TaskNote `json:"notes,omitempty"`
 }
 
 // TaskTUI TUI界面结构
@@ -1016,9 +3257,24 @@ type TaskTUI struct {
 	serverURL    string
 	interval     int
 	tasks        []TaskInfo
+	tasksETag    string // 上次任务列表响应的 ETag，内容未变时服务器返回 304
 	systemInfo   SystemInfo
 	lastUpdate   time.Time
 	selectedTask int
+
+	// 详情面板显示选中任务的 worktree 状态
+	worktreeStatus *mcp.WorktreeStatus
+
+	// 日志面板跟随选中任务的实时输出
+	logTaskID string
+	logLines  []string
+	logCancel context.CancelFunc
+}
+
+// tuiLogLine 日志面板收到的一行输出
+type tuiLogLine struct {
+	taskID string
+	text   string
 }
 
 // SystemInfo 系统信息
@@ -1029,6 +3285,8 @@ type SystemInfo struct {
 	FailedTasks    int
 	Uptime         time.Duration
 	StartTime      time.Time
+	Resources      *wsl.ResourceMetrics
+	Scheduling     *mcp.SchedulingMetrics
 }
 
 // NewTaskTUI 创建新的TUI实例
@@ -1059,11 +3317,16 @@ func (t *TaskTUI) Run() error {
 
 	taskTable := widgets.NewTable()
 	taskTable.Title = "任务列表"
-	taskTable.SetRect(0, 8, 120, 25)
+	taskTable.SetRect(0, 8, 120, 17)
 	taskTable.BorderStyle.Fg = ui.ColorYellow
 	taskTable.RowSeparator = false
 	taskTable.FillRow = true
 
+	logPane := widgets.NewParagraph()
+	logPane.Title = "任务日志"
+	logPane.SetRect(0, 17, 120, 25)
+	logPane.BorderStyle.Fg = ui.ColorBlue
+
 	details := widgets.NewParagraph()
 	details.Title = "任务详情"
 	details.SetRect(40, 3, 120, 8)
@@ -1076,15 +3339,31 @@ func (t *TaskTUI) Run() error {
 	help.BorderStyle.Fg = ui.ColorWhite
 
 	// 初始渲染
-	ui.Render(header, summary, taskTable, details, help)
+	ui.Render(header, summary, taskTable, details, logPane, help)
+
+	// 订阅任务事件流，任务变化时立即刷新；事件流断开后才按 interval 轮询
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan struct{}, 1)
+	streamErr := make(chan error, 1)
+	go func() {
+		streamErr <- followTaskEvents(ctx, t.serverURL, changes)
+	}()
 
-	// 创建定时器
-	ticker := time.NewTicker(time.Duration(t.interval) * time.Second)
-	defer ticker.Stop()
+	var poll <-chan time.Time
+
+	logLines := make(chan tuiLogLine, 256)
+	defer func() {
+		if t.logCancel != nil {
+			t.logCancel()
+		}
+	}()
 
 	// 立即更新一次
 	t.updateData()
-	t.renderAll(header, summary, taskTable, details)
+	t.followSelectedLogs(ctx, logLines)
+	t.renderAll(header, summary, taskTable, details, logPane)
 
 	// 事件循环
 	uiEvents := ui.PollEvents()
@@ -1097,16 +3376,22 @@ func (t *TaskTUI) Run() error {
 			case "<Up>":
 				if t.selectedTask > 0 {
 					t.selectedTask--
+					t.updateWorktreeStatus()
+					t.followSelectedLogs(ctx, logLines)
 					t.renderTaskTable(taskTable)
 					t.renderTaskDetails(details)
-					ui.Render(taskTable, details)
+					t.renderLogs(logPane)
+					ui.Render(taskTable, details, logPane)
 				}
 			case "<Down>":
 				if t.selectedTask < len(t.tasks)-1 {
 					t.selectedTask++
+					t.updateWorktreeStatus()
+					t.followSelectedLogs(ctx, logLines)
 					t.renderTaskTable(taskTable)
 					t.renderTaskDetails(details)
-					ui.Render(taskTable, details)
+					t.renderLogs(logPane)
+					ui.Render(taskTable, details, logPane)
 				}
 			case "<Enter>":
 				if len(t.tasks) > 0 && t.selectedTask < len(t.tasks) {
@@ -1118,36 +3403,76 @@ func (t *TaskTUI) Run() error {
 				}
 			case "r":
 				t.updateData()
-				t.renderAll(header, summary, taskTable, details)
+				t.followSelectedLogs(ctx, logLines)
+				t.renderAll(header, summary, taskTable, details, logPane)
 			case "<Resize>":
 				payload := e.Payload.(ui.Resize)
+				// 任务表和日志面板平分中间区域
+				middle := 8 + (payload.Height-3-8)/2
 				header.SetRect(0, 0, payload.Width, 3)
 				summary.SetRect(0, 3, payload.Width/3, 8)
 				details.SetRect(payload.Width/3, 3, payload.Width, 8)
-				taskTable.SetRect(0, 8, payload.Width, payload.Height-6)
+				taskTable.SetRect(0, 8, payload.Width, middle)
+				logPane.SetRect(0, middle, payload.Width, payload.Height-3)
 				help.SetRect(0, payload.Height-3, payload.Width, payload.Height)
 				ui.Clear()
-				t.renderAll(header, summary, taskTable, details)
+				t.renderAll(header, summary, taskTable, details, logPane)
 				ui.Render(help)
 			}
-		case <-ticker.C:
+		case line := <-logLines:
+			if line.taskID == t.logTaskID {
+				t.logLines = append(t.logLines, line.text)
+				if len(t.logLines) > tuiMaxLogLines {
+					t.logLines = t.logLines[len(t.logLines)-tuiMaxLogLines:]
+				}
+				t.renderLogs(logPane)
+				ui.Render(logPane)
+			}
+		case <-changes:
+			t.updateData()
+			t.followSelectedLogs(ctx, logLines)
+			t.renderAll(header, summary, taskTable, details, logPane)
+		case <-streamErr:
+			ticker := time.NewTicker(time.Duration(t.interval) * time.Second)
+			defer ticker.Stop()
+			poll = ticker.C
+		case <-poll:
 			t.updateData()
-			t.renderAll(header, summary, taskTable, details)
+			t.followSelectedLogs(ctx, logLines)
+			t.renderAll(header, summary, taskTable, details, logPane)
 		}
 	}
 }
 
 // updateData 更新数据
 func (t *TaskTUI) updateData() {
-	// 获取任务列表
-	resp, err := http.Get(fmt.Sprintf("%s/api/tasks", t.serverURL))
+	t.lastUpdate = time.Now()
+	t.systemInfo.Uptime = time.Since(t.systemInfo.StartTime)
+
+	// 更新WSL资源指标
+	t.updateResourceMetrics()
+
+	// 获取任务列表，带上次的 ETag 避免重复下载未变化的列表
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/tasks?limit=%d", t.serverURL, taskRefreshLimit), nil)
+	if err != nil {
+		return
+	}
+	if t.tasksETag != "" {
+		req.Header.Set("If-None-Match", t.tasksETag)
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return
+	}
+
 	var result struct {
 		Tasks []TaskInfo `json:"tasks"`
+		Total int        `json:"total"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
@@ -1155,14 +3480,13 @@ func (t *TaskTUI) updateData() {
 	}
 
 	t.tasks = result.Tasks
-	t.lastUpdate = time.Now()
+	t.tasksETag = resp.Header.Get("ETag")
 
-	// 更新系统信息
-	t.systemInfo.TotalTasks = len(t.tasks)
+	// 更新系统信息，运行/完成/失败数只统计最新的一页任务
+	t.systemInfo.TotalTasks = result.Total
 	t.systemInfo.RunningTasks = 0
 	t.systemInfo.CompletedTasks = 0
 	t.systemInfo.FailedTasks = 0
-	t.systemInfo.Uptime = time.Since(t.systemInfo.StartTime)
 
 	for _, task := range t.tasks {
 		switch task.Status {
@@ -1170,7 +3494,7 @@ func (t *TaskTUI) updateData() {
 			t.systemInfo.RunningTasks++
 		case "completed":
 			t.systemInfo.CompletedTasks++
-		case "failed":
+		case "failed", "timeout":
 			t.systemInfo.FailedTasks++
 		}
 	}
@@ -1182,15 +3506,119 @@ func (t *TaskTUI) updateData() {
 	if t.selectedTask < 0 {
 		t.selectedTask = 0
 	}
+	t.updateWorktreeStatus()
+}
+
+// updateWorktreeStatus 获取选中任务的 worktree 状态，任务没有 worktree 或获取失败时清空
+func (t *TaskTUI) updateWorktreeStatus() {
+	t.worktreeStatus = nil
+	if t.selectedTask >= len(t.tasks) || t.tasks[t.selectedTask].WorktreeID == "" {
+		return
+	}
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(t.serverURL + "/worktrees/" + url.PathEscape(t.tasks[t.selectedTask].WorktreeID) + "/status")
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	var status mcp.WorktreeStatus
+	if resp.StatusCode == http.StatusOK && json.NewDecoder(resp.Body).Decode(&status) == nil {
+		t.worktreeStatus = &status
+	}
+}
+
+// updateResourceMetrics 从/metrics获取WSL资源使用情况和调度指标
+func (t *TaskTUI) updateResourceMetrics() {
+	resp, err := http.Get(fmt.Sprintf("%s/metrics", t.serverURL))
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Scheduling *mcp.SchedulingMetrics `json:"scheduling"`
+		System     *wsl.ResourceMetrics   `json:"system"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return
+	}
+
+	t.systemInfo.Scheduling = result.Scheduling
+
+	if result.System != nil && result.System.MemoryTotalKB > 0 {
+		t.systemInfo.Resources = result.System
+	} else {
+		t.systemInfo.Resources = nil
+	}
 }
 
 // renderAll 渲染所有组件
-func (t *TaskTUI) renderAll(header, summary *widgets.Paragraph, taskTable *widgets.Table, details *widgets.Paragraph) {
+func (t *TaskTUI) renderAll(header, summary *widgets.Paragraph, taskTable *widgets.Table, details, logPane *widgets.Paragraph) {
 	t.renderHeader(header)
 	t.renderSummary(summary)
 	t.renderTaskTable(taskTable)
 	t.renderTaskDetails(details)
-	ui.Render(header, summary, taskTable, details)
+	t.renderLogs(logPane)
+	ui.Render(header, summary, taskTable, details, logPane)
+}
+
+// followSelectedLogs 选中的任务变化时改为订阅新任务的输出流，收到的行发送到 lines
+func (t *TaskTUI) followSelectedLogs(ctx context.Context, lines chan<- tuiLogLine) {
+	taskID := ""
+	if t.selectedTask < len(t.tasks) {
+		taskID = t.tasks[t.selectedTask].ID
+	}
+	if taskID == t.logTaskID {
+		return
+	}
+
+	if t.logCancel != nil {
+		t.logCancel()
+	}
+	t.logTaskID, t.logLines, t.logCancel = taskID, nil, nil
+	if taskID == "" {
+		return
+	}
+
+	logCtx, cancel := context.WithCancel(ctx)
+	t.logCancel = cancel
+	go func() {
+		send := func(text string) {
+			select {
+			case lines <- tuiLogLine{taskID: taskID, text: text}:
+			case <-logCtx.Done():
+			}
+		}
+		err := streamTaskOutput(logCtx, t.serverURL, taskID, -tuiLogBacklogBytes, func(msg taskOutputMessage) {
+			switch msg.Type {
+			case "output":
+				send(msg.Line)
+			case "status":
+				send(fmt.Sprintf("--- 任务已结束: %s ---", msg.Status))
+			}
+		})
+		if err != nil && logCtx.Err() == nil {
+			send(fmt.Sprintf("--- %v ---", err))
+		}
+	}()
+}
+
+// renderLogs 渲染日志面板，只显示能放下的最新几行
+func (t *TaskTUI) renderLogs(logPane *widgets.Paragraph) {
+	logPane.Title = "任务日志"
+	if t.logTaskID == "" {
+		logPane.Text = "无任务选中"
+		return
+	}
+	logPane.Title = fmt.Sprintf("任务日志 - %s", truncateString(t.logTaskID, 20))
+
+	lines := t.logLines
+	if visible := logPane.Inner.Dy(); visible > 0 && len(lines) > visible {
+		lines = lines[len(lines)-visible:]
+	}
+	logPane.Text = strings.Join(lines, "\n")
 }
 
 // renderHeader 渲染头部
@@ -1211,6 +3639,42 @@ func (t *TaskTUI) renderSummary(summary *widgets.Paragraph) {
 		t.systemInfo.CompletedTasks,
 		t.systemInfo.FailedTasks,
 		formatDuration(t.systemInfo.Uptime))
+
+	if res := t.systemInfo.Resources; res != nil {
+		summary.Text += fmt.Sprintf("\nWSL CPU: %s 内存: %s 磁盘: %s",
+			formatPercent(res.CPUUsedPercent),
+			formatPercent(res.MemoryUsedPercent),
+			formatPercent(res.DiskUsedPercent))
+	}
+	if sched := t.systemInfo.Scheduling; sched != nil && sched.Execution.Count > 0 {
+		summary.Text += fmt.Sprintf("\n等待 p95: %s 执行 p95: %s 吞吐: %d/h 失败率: %s",
+			sched.QueueWait.P95, sched.Execution.P95, sched.Throughput,
+			formatFailureRate(sched.FailureRate))
+	}
+}
+
+// formatFailureRate 格式化失败率，超过阈值时高亮
+func formatFailureRate(rate float64) string {
+	switch {
+	case rate >= 0.5:
+		return fmt.Sprintf("[%.0f%%](fg:red)", rate*100)
+	case rate >= 0.2:
+		return fmt.Sprintf("[%.0f%%](fg:yellow)", rate*100)
+	default:
+		return fmt.Sprintf("[%.0f%%](fg:green)", rate*100)
+	}
+}
+
+// formatPercent 格式化使用率，超过阈值时高亮
+func formatPercent(value float64) string {
+	switch {
+	case value >= 90:
+		return fmt.Sprintf("[%.0f%%](fg:red)", value)
+	case value >= 70:
+		return fmt.Sprintf("[%.0f%%](fg:yellow)", value)
+	default:
+		return fmt.Sprintf("[%.0f%%](fg:green)", value)
+	}
 }
 
 // renderTaskTable 渲染任务表格
@@ -1282,6 +3746,41 @@ func (t *TaskTUI) renderTaskDetails(details *widgets.Paragraph) {
 		task.CreatedAt.Format("2006-01-02 15:04:05"),
 		formatTimePtr(task.StartedAt),
 		formatTimePtr(task.CompletedAt))
+
+	if status := t.worktreeStatus; status != nil && status.WorktreeID == task.WorktreeID {
+		details.Text += "\n工作树: " + formatWorktreeSummary(status)
+	}
+
+	// 只显示最新的一条备注，完整备注在详情弹窗中查看
+	if n := len(task.Notes); n > 0 {
+		details.Text += fmt.Sprintf("\n备注 (%d): %s", n, formatTaskNote(task.Notes[n-1]))
+	}
+}
+
+// formatWorktreeSummary 格式化 worktree 修改的文件数、行数和未提交的文件数
+func formatWorktreeSummary(status *mcp.WorktreeStatus) string {
+	if len(status.Files) == 0 {
+		return "无修改"
+	}
+	uncommitted := 0
+	for _, file := range status.Files {
+		if file.Uncommitted {
+			uncommitted++
+		}
+	}
+	summary := fmt.Sprintf("%d 个文件 [+%d](fg:green) [-%d](fg:red)", len(status.Files), status.Additions, status.Deletions)
+	if uncommitted > 0 {
+		summary += fmt.Sprintf("，%d 个未提交", uncommitted)
+	}
+	return summary
+}
+
+// formatTaskNote 格式化一条任务备注
+func formatTaskNote(note mcp.TaskNote) string {
+	if note.Author != "" {
+		return fmt.Sprintf("[%s] %s: %s", note.Time.Format("01-02 15:04"), note.Author, note.Text)
+	}
+	return fmt.Sprintf("[%s] %s", note.Time.Format("01-02 15:04"), note.Text)
 }
 
 // showTaskDetails 显示任务详细信息（弹窗）
@@ -1403,6 +3902,15 @@ func (t *TaskTUI) buildTaskDetailsText(task *TaskInfo) string {
 		details.WriteString(fmt.Sprintf("%s\n\n", task.Error))
 	}
 
+	// 备注
+	if len(task.Notes) > 0 {
+		details.WriteString("[备注](fg:magenta,modifier:bold)\n")
+		for _, note := range task.Notes {
+			details.WriteString(formatTaskNote(note) + "\n")
+		}
+		details.WriteString("\n")
+	}
+
 	// 获取详细状态信息
 	if detailedStatus := t.getDetailedTaskStatus(task.ID); detailedStatus != "" {
 		details.WriteString(fmt.Sprintf("[详细状态](fg:yellow,modifier:bold)\n"))
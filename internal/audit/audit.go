@@ -0,0 +1,309 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/auth"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// 审计动作
+const (
+	ActionTaskSubmit     = "task.submit"
+	ActionTaskCancel     = "task.cancel"
+	ActionTaskNote       = "task.note"
+	ActionTaskRerun      = "task.rerun"
+	ActionTaskAttach     = "task.attach"
+	ActionTaskMerge      = "task.merge"
+	ActionWorktreeDelete = "worktree.delete"
+	ActionWorktreePin    = "worktree.pin"
+	ActionShellRun       = "shell.run"
+	ActionAuthFailure    = "auth.failure"
+	ActionTokenCreate    = "token.create"
+	ActionTokenRevoke    = "token.revoke"
+	ActionTokenRotate    = "token.rotate"
+	ActionConfigChange   = "config.change"
+	ActionTemplateSave   = "template.save"
+	ActionTemplateDelete = "template.delete"
+	ActionQueuePause     = "queue.pause"
+	ActionQueueResume    = "queue.resume"
+)
+
+// 审计结果
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeDenied  = "denied" // 认证或权限检查未通过
+)
+
+// maxQueryLimit 单次查询返回的最大事件数
+const maxQueryLimit = 1000
+
+// Event 审计事件，每个事件在审计文件中占一行 JSON
+type Event struct {
+	Time       time.Time              `json:"time"`
+	Action     string                 `json:"action"`
+	Outcome    string                 `json:"outcome"`
+	Actor      string                 `json:"actor"`
+	AuthMethod string                 `json:"authMethod,omitempty"`
+	SourceIP   string                 `json:"sourceIp,omitempty"`
+	RequestID  string                 `json:"requestId,omitempty"`
+	Resource   string                 `json:"resource,omitempty"` // 操作对象，如任务ID、worktree ID
+	Params     map[string]interface{} `json:"params,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// Filter 审计事件查询条件，零值字段不参与过滤
+type Filter struct {
+	Action  string // 精确匹配，以 "." 结尾时按前缀匹配（如 "task."）
+	Actor   string
+	Outcome string
+	Since   time.Time
+	Until   time.Time
+	Limit   int // 返回最新的 Limit 条，默认 100
+}
+
+// Log 追加写入的审计日志，nil Log 的方法均为空操作
+type Log struct {
+	path   string
+	logger logger.Logger
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewLog 打开（或创建）审计日志文件
+func NewLog(path string, log logger.Logger) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrConfigInvalid, "无法创建审计日志目录")
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, apperrors.Wrapf(err, apperrors.ErrConfigInvalid, "无法打开审计日志: %s", path)
+	}
+
+	return &Log{path: path, logger: log, file: file}, nil
+}
+
+// Path 审计日志文件路径
+func (l *Log) Path() string {
+	if l == nil {
+		return ""
+	}
+	return l.path
+}
+
+// Append 写入审计事件
+func (l *Log) Append(event Event) {
+	if l == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	event.Time = event.Time.UTC()
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		l.logger.Error("序列化审计事件失败", zap.String("action", event.Action), zap.Error(err))
+		return
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return
+	}
+	if _, err := l.file.Write(data); err != nil {
+		// 审计写入失败不影响业务，但必须在日志中留下痕迹
+		l.logger.Error("写入审计日志失败",
+			zap.String("action", event.Action),
+			zap.String("actor", event.Actor),
+			zap.Error(err))
+	}
+}
+
+// Record 根据上下文中的身份、来源地址和请求ID记录一次操作，err 决定操作结果
+func (l *Log) Record(ctx context.Context, action, resource string, params map[string]interface{}, err error) {
+	if l == nil {
+		return
+	}
+
+	event := NewEvent(ctx, action)
+	event.Resource = resource
+	event.Params = params
+	if err != nil {
+		event.Outcome = outcomeOf(err)
+		event.Error = err.Error()
+	}
+	l.Append(event)
+}
+
+// NewEvent 创建成功结果的审计事件，操作者取自上下文
+func NewEvent(ctx context.Context, action string) Event {
+	event := Event{
+		Time:      time.Now(),
+		Action:    action,
+		Outcome:   OutcomeSuccess,
+		Actor:     "anonymous",
+		SourceIP:  SourceIPFromContext(ctx),
+		RequestID: logger.RequestIDFromContext(ctx),
+	}
+
+	if identity := auth.IdentityFromContext(ctx); identity != nil {
+		event.Actor = identity.Subject
+		event.AuthMethod = identity.Method
+	} else if actor := actorFromContext(ctx); actor != "" {
+		event.Actor = actor
+	} else if event.SourceIP == "" {
+		// 没有HTTP来源的请求来自本地 stdio 客户端
+		event.Actor = "local"
+	}
+	return event
+}
+
+// outcomeOf 根据错误代码区分拒绝和失败
+func outcomeOf(err error) string {
+	switch apperrors.GetCode(err) {
+	case apperrors.ErrUnauthorized, apperrors.ErrTokenExpired, apperrors.ErrForbidden, apperrors.ErrCommandDenied:
+		return OutcomeDenied
+	default:
+		return OutcomeFailure
+	}
+}
+
+// Query 按条件查询审计事件，按时间从新到旧返回
+func (l *Log) Query(filter Filter) ([]Event, error) {
+	if l == nil {
+		return nil, apperrors.New(apperrors.ErrResourceNotFound, "未启用审计日志")
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > maxQueryLimit {
+		limit = maxQueryLimit
+	}
+
+	file, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Event{}, nil
+		}
+		return nil, apperrors.Wrap(err, apperrors.ErrInternal, "读取审计日志失败")
+	}
+	defer file.Close()
+
+	// 只保留最新的 limit 条匹配事件
+	matched := make([]Event, 0, limit)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			// 进程崩溃可能留下不完整的最后一行，跳过即可
+			continue
+		}
+		if !filter.matches(event) {
+			continue
+		}
+		if len(matched) == limit {
+			matched = append(matched[1:], event)
+		} else {
+			matched = append(matched, event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrInternal, "读取审计日志失败")
+	}
+
+	for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+		matched[i], matched[j] = matched[j], matched[i]
+	}
+	return matched, nil
+}
+
+// matches 事件是否满足查询条件
+func (f Filter) matches(event Event) bool {
+	if f.Action != "" {
+		if strings.HasSuffix(f.Action, ".") {
+			if !strings.HasPrefix(event.Action, f.Action) {
+				return false
+			}
+		} else if event.Action != f.Action {
+			return false
+		}
+	}
+	if f.Actor != "" && event.Actor != f.Actor {
+		return false
+	}
+	if f.Outcome != "" && event.Outcome != f.Outcome {
+		return false
+	}
+	if !f.Since.IsZero() && event.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && event.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Close 关闭审计日志文件
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// sourceIPContextKey 上下文中客户端地址的键
+type sourceIPContextKey struct{}
+
+// actorContextKey 上下文中非认证操作者（如命令行用户）的键
+type actorContextKey struct{}
+
+// WithSourceIP 在上下文中记录客户端地址
+func WithSourceIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, sourceIPContextKey{}, ip)
+}
+
+// SourceIPFromContext 获取客户端地址，本地请求返回空字符串
+func SourceIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(sourceIPContextKey{}).(string)
+	return ip
+}
+
+// WithActor 在上下文中记录未经认证的操作者，如执行命令行的系统用户
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// actorFromContext 获取上下文中的操作者
+func actorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorContextKey{}).(string)
+	return actor
+}
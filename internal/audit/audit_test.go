@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"auto-claude-code/internal/auth"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+func newTestLog(t *testing.T) *Log {
+	t.Helper()
+
+	log, err := logger.CreateLoggerFromConfig("info", false, "")
+	if err != nil {
+		t.Fatalf("创建日志器失败: %v", err)
+	}
+	auditLog, err := NewLog(filepath.Join(t.TempDir(), "audit", "audit.log"), log)
+	if err != nil {
+		t.Fatalf("打开审计日志失败: %v", err)
+	}
+	t.Cleanup(func() { auditLog.Close() })
+	return auditLog
+}
+
+func TestRecordActor(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		actor   string
+		method  string
+		outcome string
+		err     error
+	}{
+		{
+			name:    "已认证身份",
+			ctx:     WithSourceIP(auth.WithIdentity(context.Background(), &auth.Identity{Subject: "alice", Method: "jwt"}), "10.0.0.1"),
+			actor:   "alice",
+			method:  "jwt",
+			outcome: OutcomeSuccess,
+		},
+		{
+			name:    "未认证的HTTP请求",
+			ctx:     WithSourceIP(context.Background(), "10.0.0.2"),
+			actor:   "anonymous",
+			outcome: OutcomeFailure,
+			err:     apperrors.New(apperrors.ErrTaskNotFound, "任务不存在"),
+		},
+		{
+			name:    "本地 stdio 请求",
+			ctx:     context.Background(),
+			actor:   "local",
+			outcome: OutcomeDenied,
+			err:     apperrors.New(apperrors.ErrForbidden, "权限不足"),
+		},
+		{
+			name:    "命令行用户",
+			ctx:     WithActor(context.Background(), "cli:bob"),
+			actor:   "cli:bob",
+			outcome: OutcomeSuccess,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditLog := newTestLog(t)
+			auditLog.Record(logger.WithRequestID(tt.ctx, "req-1"), ActionTaskCancel, "t1", nil, tt.err)
+
+			events, err := auditLog.Query(Filter{})
+			if err != nil || len(events) != 1 {
+				t.Fatalf("Query() = %v, %v", events, err)
+			}
+			event := events[0]
+			if event.Actor != tt.actor || event.AuthMethod != tt.method || event.Outcome != tt.outcome {
+				t.Errorf("event = %+v", event)
+			}
+			if event.RequestID != "req-1" || event.Resource != "t1" || event.SourceIP != SourceIPFromContext(tt.ctx) {
+				t.Errorf("event = %+v", event)
+			}
+		})
+	}
+}
+
+func TestQueryFilter(t *testing.T) {
+	auditLog := newTestLog(t)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	events := []Event{
+		{Time: base, Action: ActionTaskSubmit, Actor: "alice", Outcome: OutcomeSuccess},
+		{Time: base.Add(time.Minute), Action: ActionTaskCancel, Actor: "bob", Outcome: OutcomeSuccess},
+		{Time: base.Add(2 * time.Minute), Action: ActionAuthFailure, Actor: "anonymous", Outcome: OutcomeDenied},
+		{Time: base.Add(3 * time.Minute), Action: ActionTaskSubmit, Actor: "bob", Outcome: OutcomeFailure},
+	}
+	for _, event := range events {
+		auditLog.Append(event)
+	}
+
+	// 模拟进程崩溃留下的不完整行
+	f, _ := os.OpenFile(auditLog.Path(), os.O_WRONLY|os.O_APPEND, 0600)
+	f.WriteString(`{"time":"2024-01-01T00:04:00Z","act`)
+	f.Close()
+
+	tests := []struct {
+		name    string
+		filter  Filter
+		actions []string
+	}{
+		{"全部按时间倒序", Filter{}, []string{ActionTaskSubmit, ActionAuthFailure, ActionTaskCancel, ActionTaskSubmit}},
+		{"动作前缀", Filter{Action: "task."}, []string{ActionTaskSubmit, ActionTaskCancel, ActionTaskSubmit}},
+		{"精确动作", Filter{Action: ActionTaskSubmit}, []string{ActionTaskSubmit, ActionTaskSubmit}},
+		{"操作者", Filter{Actor: "bob"}, []string{ActionTaskSubmit, ActionTaskCancel}},
+		{"结果", Filter{Outcome: OutcomeDenied}, []string{ActionAuthFailure}},
+		{"时间范围", Filter{Since: base.Add(time.Minute), Until: base.Add(2 * time.Minute)}, []string{ActionAuthFailure, ActionTaskCancel}},
+		{"数量限制保留最新", Filter{Limit: 2}, []string{ActionTaskSubmit, ActionAuthFailure}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := auditLog.Query(tt.filter)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if len(got) != len(tt.actions) {
+				t.Fatalf("返回 %d 条，want %d: %+v", len(got), len(tt.actions), got)
+			}
+			for i, event := range got {
+				if event.Action != tt.actions[i] {
+					t.Errorf("第 %d 条 action = %s, want %s", i, event.Action, tt.actions[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNilLog(t *testing.T) {
+	var auditLog *Log
+	auditLog.Record(context.Background(), ActionTaskSubmit, "t1", nil, nil)
+	if err := auditLog.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+	if _, err := auditLog.Query(Filter{}); !apperrors.IsCode(err, apperrors.ErrResourceNotFound) {
+		t.Errorf("Query() error = %v", err)
+	}
+}
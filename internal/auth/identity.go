@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+)
+
+// Identity 通过认证的客户端身份
+type Identity struct {
+	Subject string                 `json:"subject"`
+	Name    string                 `json:"name,omitempty"`
+	Method  string                 `json:"method"` // 认证方式：token、jwt、oauth2
+	Roles   []string               `json:"roles,omitempty"`
+	Scopes  []string               `json:"scopes,omitempty"`
+	Claims  map[string]interface{} `json:"-"`
+}
+
+// TokenValidator 访问令牌验证器
+type TokenValidator interface {
+	// Validate 验证令牌并返回客户端身份
+	Validate(ctx context.Context, token string) (*Identity, error)
+}
+
+// HasScopes 身份是否拥有全部指定的权限范围
+func (i *Identity) HasScopes(scopes ...string) bool {
+	for _, scope := range scopes {
+		found := false
+		for _, s := range i.Scopes {
+			if s == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// identityContextKey 请求上下文中的身份键
+type identityContextKey struct{}
+
+// WithIdentity 在上下文中记录客户端身份
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, identity)
+}
+
+// IdentityFromContext 获取客户端身份，未认证（如 stdio 或未启用认证）时返回 nil
+func IdentityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityContextKey{}).(*Identity)
+	return identity
+}
@@ -0,0 +1,393 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"hash"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// jwksMinRefreshInterval 遇到未知 kid 时重新获取 JWKS 的最小间隔，避免被伪造的 kid 放大请求
+const jwksMinRefreshInterval = time.Minute
+
+// jwtAlgorithm JWT 签名算法
+type jwtAlgorithm struct {
+	hmac bool
+	hash crypto.Hash
+	new  func() hash.Hash
+}
+
+// jwtAlgorithms 支持的签名算法
+var jwtAlgorithms = map[string]jwtAlgorithm{
+	"HS256": {hmac: true, hash: crypto.SHA256, new: sha256.New},
+	"HS384": {hmac: true, hash: crypto.SHA384, new: sha512.New384},
+	"HS512": {hmac: true, hash: crypto.SHA512, new: sha512.New},
+	"RS256": {hash: crypto.SHA256, new: sha256.New},
+	"RS384": {hash: crypto.SHA384, new: sha512.New384},
+	"RS512": {hash: crypto.SHA512, new: sha512.New},
+}
+
+// jwtHeader JWT 头部
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// JWTValidator JWT Bearer Token 验证器
+type JWTValidator struct {
+	config config.MCPJWTConfig
+	logger logger.Logger
+	leeway time.Duration
+
+	secret    []byte
+	publicKey *rsa.PublicKey
+
+	// JWKS 缓存
+	jwksMu      sync.Mutex
+	jwksKeys    map[string]*rsa.PublicKey
+	jwksFetched time.Time
+	jwksRefresh time.Duration
+	httpClient  *http.Client
+}
+
+// NewJWTValidator 创建 JWT 验证器，加载 HMAC 密钥或 RSA 公钥
+func NewJWTValidator(cfg config.MCPJWTConfig, log logger.Logger) (*JWTValidator, error) {
+	v := &JWTValidator{
+		config:     cfg,
+		logger:     log,
+		leeway:     parseDurationOr(cfg.Leeway, 30*time.Second),
+		jwksKeys:   make(map[string]*rsa.PublicKey),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	v.jwksRefresh = parseDurationOr(cfg.JWKSRefresh, time.Hour)
+
+	switch {
+	case cfg.Secret != "":
+		v.secret = []byte(cfg.Secret)
+	case cfg.PublicKeyFile != "":
+		data, err := os.ReadFile(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, apperrors.Wrapf(err, apperrors.ErrConfigInvalid, "读取 JWT 公钥失败: %s", cfg.PublicKeyFile)
+		}
+		key, err := parseRSAPublicKeyPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		v.publicKey = key
+	case cfg.JWKSURL == "":
+		return nil, apperrors.New(apperrors.ErrConfigInvalid, "jwt 认证未配置签名密钥")
+	}
+
+	return v, nil
+}
+
+// Validate 验证 Token 的签名、有效期、签发方和受众，并将声明映射为客户端身份
+func (v *JWTValidator) Validate(ctx context.Context, token string) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, apperrors.New(apperrors.ErrUnauthorized, "无效的 JWT 格式")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrUnauthorized, "无效的 JWT 头部")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrUnauthorized, "无效的 JWT 签名编码")
+	}
+	if err := v.verifySignature(ctx, header, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrUnauthorized, "无效的 JWT 声明")
+	}
+	if err := v.validateClaims(claims, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return v.identityFromClaims(claims), nil
+}
+
+// verifySignature 校验签名，算法必须与配置的密钥类型一致（防止以公钥作为 HMAC 密钥的算法混淆攻击）
+func (v *JWTValidator) verifySignature(ctx context.Context, header jwtHeader, signingInput string, signature []byte) error {
+	alg, ok := jwtAlgorithms[header.Alg]
+	if !ok {
+		return apperrors.Newf(apperrors.ErrUnauthorized, "不支持的 JWT 签名算法: %s", header.Alg)
+	}
+
+	if alg.hmac {
+		if v.secret == nil {
+			return apperrors.Newf(apperrors.ErrUnauthorized, "不接受的 JWT 签名算法: %s", header.Alg)
+		}
+		mac := hmac.New(alg.new, v.secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return apperrors.New(apperrors.ErrUnauthorized, "JWT 签名无效")
+		}
+		return nil
+	}
+
+	key, err := v.rsaKey(ctx, header.Kid)
+	if err != nil {
+		return err
+	}
+	h := alg.new()
+	h.Write([]byte(signingInput))
+	if err := rsa.VerifyPKCS1v15(key, alg.hash, h.Sum(nil), signature); err != nil {
+		return apperrors.New(apperrors.ErrUnauthorized, "JWT 签名无效")
+	}
+	return nil
+}
+
+// rsaKey 获取验证签名的 RSA 公钥
+func (v *JWTValidator) rsaKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	if v.publicKey != nil {
+		return v.publicKey, nil
+	}
+	if v.config.JWKSURL == "" {
+		return nil, apperrors.New(apperrors.ErrUnauthorized, "不接受的 JWT 签名算法: 未配置 RSA 公钥")
+	}
+
+	v.jwksMu.Lock()
+	defer v.jwksMu.Unlock()
+
+	key, ok := v.lookupJWKS(kid)
+	since := time.Since(v.jwksFetched)
+	if ok && since < v.jwksRefresh {
+		return key, nil
+	}
+	if !ok && since < jwksMinRefreshInterval {
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "未知的 JWT 密钥: %s", kid)
+	}
+
+	if err := v.fetchJWKS(ctx); err != nil {
+		// 身份提供方暂时不可用时继续使用缓存的密钥
+		if ok {
+			v.logger.Warn("刷新 JWKS 失败，使用缓存的密钥", zap.Error(err))
+			return key, nil
+		}
+		return nil, err
+	}
+
+	if key, ok = v.lookupJWKS(kid); !ok {
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "未知的 JWT 密钥: %s", kid)
+	}
+	return key, nil
+}
+
+// lookupJWKS 在缓存中查找密钥，Token 未指定 kid 且只有一个密钥时使用该密钥
+func (v *JWTValidator) lookupJWKS(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(v.jwksKeys) == 1 {
+		for _, key := range v.jwksKeys {
+			return key, true
+		}
+	}
+	key, ok := v.jwksKeys[kid]
+	return key, ok
+}
+
+// fetchJWKS 获取 JWKS 中的 RSA 签名密钥
+func (v *JWTValidator) fetchJWKS(ctx context.Context) error {
+	v.jwksFetched = time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.config.JWKSURL, nil)
+	if err != nil {
+		return apperrors.Wrap(err, apperrors.ErrUnauthorized, "无效的 JWKS 地址")
+	}
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return apperrors.Wrap(err, apperrors.ErrUnauthorized, "获取 JWKS 失败")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return apperrors.Newf(apperrors.ErrUnauthorized, "获取 JWKS 失败: HTTP %d", resp.StatusCode)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrUnauthorized, "解析 JWKS 失败")
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			v.logger.Warn("跳过无效的 JWKS 密钥", zap.String("kid", k.Kid))
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	v.jwksKeys = keys
+	v.logger.Debug("已刷新 JWKS", zap.String("url", v.config.JWKSURL), zap.Int("keys", len(keys)))
+	return nil
+}
+
+// validateClaims 校验 exp、nbf、iss、aud
+func (v *JWTValidator) validateClaims(claims map[string]interface{}, now time.Time) error {
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return apperrors.New(apperrors.ErrUnauthorized, "JWT 缺少过期时间 (exp)")
+	}
+	if now.After(time.Unix(exp, 0).Add(v.leeway)) {
+		return apperrors.New(apperrors.ErrTokenExpired, "JWT 已过期")
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(v.leeway).Before(time.Unix(nbf, 0)) {
+		return apperrors.New(apperrors.ErrUnauthorized, "JWT 尚未生效")
+	}
+
+	if v.config.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.config.Issuer {
+			return apperrors.Newf(apperrors.ErrUnauthorized, "JWT 签发方不匹配: %s", iss)
+		}
+	}
+
+	if v.config.Audience != "" {
+		audiences := stringsClaim(claims, "aud")
+		found := false
+		for _, aud := range audiences {
+			if aud == v.config.Audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return apperrors.Newf(apperrors.ErrUnauthorized, "JWT 受众不匹配: %v", audiences)
+		}
+	}
+
+	return nil
+}
+
+// identityFromClaims 按配置的声明名称映射客户端身份
+func (v *JWTValidator) identityFromClaims(claims map[string]interface{}) *Identity {
+	identity := &Identity{
+		Method: "jwt",
+		Claims: claims,
+	}
+	identity.Subject, _ = claims[claimName(v.config.SubjectClaim, "sub")].(string)
+	identity.Name, _ = claims[claimName(v.config.NameClaim, "name")].(string)
+	identity.Roles = stringsClaim(claims, claimName(v.config.RolesClaim, "roles"))
+	identity.Scopes = stringsClaim(claims, claimName(v.config.ScopesClaim, "scope"))
+	return identity
+}
+
+// decodeSegment 解码 JWT 的 base64url JSON 段
+func decodeSegment(segment string, target interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// numericClaim 读取 NumericDate 类型的声明
+func numericClaim(claims map[string]interface{}, name string) (int64, bool) {
+	value, ok := claims[name].(float64)
+	return int64(value), ok
+}
+
+// stringsClaim 读取字符串或字符串数组类型的声明，字符串按空格分隔（OAuth2 scope 格式）
+func stringsClaim(claims map[string]interface{}, name string) []string {
+	switch value := claims[name].(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// claimName 获取声明名称，未配置时使用默认名称
+func claimName(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// parseRSAPublicKeyPEM 解析 PEM 格式的 RSA 公钥（PKIX 或 PKCS#1）或证书
+func parseRSAPublicKeyPEM(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, apperrors.New(apperrors.ErrConfigInvalid, "JWT 公钥不是有效的 PEM 格式")
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, apperrors.Wrap(err, apperrors.ErrConfigInvalid, "解析 JWT 公钥失败")
+		}
+		return key, nil
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, apperrors.Wrap(err, apperrors.ErrConfigInvalid, "解析 JWT 证书失败")
+		}
+		if key, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+	default:
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, apperrors.Wrap(err, apperrors.ErrConfigInvalid, "解析 JWT 公钥失败")
+		}
+		if key, ok := pub.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+	}
+	return nil, apperrors.New(apperrors.ErrConfigInvalid, "JWT 公钥必须是 RSA 公钥")
+}
+
+// parseDurationOr 解析时间间隔，为空或无效时使用默认值
+func parseDurationOr(value string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
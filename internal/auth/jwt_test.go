@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/logger"
+)
+
+// signJWT 生成测试用 JWT，key 为 []byte 时使用 HS256，为 *rsa.PrivateKey 时使用 RS256
+func signJWT(t *testing.T, key interface{}, kid string, claims map[string]interface{}) string {
+	t.Helper()
+
+	header := map[string]string{"typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	switch key.(type) {
+	case []byte:
+		header["alg"] = "HS256"
+	case *rsa.PrivateKey:
+		header["alg"] = "RS256"
+	}
+
+	encode := func(v interface{}) string {
+		data, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signingInput := encode(header) + "." + encode(claims)
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signingInput))
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("签名失败: %v", err)
+		}
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWTValidator_HMAC(t *testing.T) {
+	secret := []byte("test-secret")
+	v, err := NewJWTValidator(config.MCPJWTConfig{
+		Secret:   string(secret),
+		Issuer:   "https://idp.example.com",
+		Audience: "auto-claude-code",
+	}, logger.FromZap(zap.NewNop()))
+	if err != nil {
+		t.Fatalf("创建验证器失败: %v", err)
+	}
+
+	now := time.Now().Unix()
+	valid := map[string]interface{}{
+		"sub":   "alice",
+		"name":  "Alice",
+		"iss":   "https://idp.example.com",
+		"aud":   []string{"other", "auto-claude-code"},
+		"exp":   now + 3600,
+		"roles": []string{"submitter"},
+		"scope": "tasks:read tasks:write",
+	}
+	with := func(key string, value interface{}) map[string]interface{} {
+		claims := make(map[string]interface{})
+		for k, v := range valid {
+			claims[k] = v
+		}
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"有效Token", signJWT(t, secret, "", valid), true},
+		{"签名错误", signJWT(t, []byte("wrong"), "", valid), false},
+		{"已过期", signJWT(t, secret, "", with("exp", now-3600)), false},
+		{"时钟偏差内", signJWT(t, secret, "", with("exp", now-10)), true},
+		{"缺少exp", signJWT(t, secret, "", with("exp", nil)), false},
+		{"尚未生效", signJWT(t, secret, "", with("nbf", now+3600)), false},
+		{"签发方不匹配", signJWT(t, secret, "", with("iss", "https://evil.example.com")), false},
+		{"受众不匹配", signJWT(t, secret, "", with("aud", "other")), false},
+		{"算法与密钥类型不符", signJWT(t, rsaKey, "", valid), false},
+		{"格式错误", "not-a-jwt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := v.Validate(context.Background(), tt.token)
+			if (err == nil) != tt.ok {
+				t.Fatalf("Validate() error = %v, 期望通过 = %v", err, tt.ok)
+			}
+			if tt.ok && (identity.Subject != "alice" || identity.Name != "Alice" || identity.Method != "jwt") {
+				t.Errorf("身份 = %+v", identity)
+			}
+		})
+	}
+
+	identity, _ := v.Validate(context.Background(), signJWT(t, secret, "", valid))
+	if len(identity.Roles) != 1 || identity.Roles[0] != "submitter" || len(identity.Scopes) != 2 {
+		t.Errorf("角色和权限范围映射错误: %+v", identity)
+	}
+}
+
+func TestJWTValidator_JWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "key-1",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	defer server.Close()
+
+	v, err := NewJWTValidator(config.MCPJWTConfig{JWKSURL: server.URL}, logger.FromZap(zap.NewNop()))
+	if err != nil {
+		t.Fatalf("创建验证器失败: %v", err)
+	}
+
+	claims := map[string]interface{}{"sub": "svc", "exp": time.Now().Add(time.Hour).Unix()}
+	for i := 0; i < 2; i++ {
+		if _, err := v.Validate(context.Background(), signJWT(t, key, "key-1", claims)); err != nil {
+			t.Fatalf("第 %d 次验证失败: %v", i+1, err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("JWKS 应被缓存, 实际获取 %d 次", fetches)
+	}
+
+	// 未知 kid 在最小刷新间隔内不会重新获取
+	if _, err := v.Validate(context.Background(), signJWT(t, key, "key-2", claims)); err == nil {
+		t.Error("未知 kid 应验证失败")
+	}
+	if fetches != 1 {
+		t.Errorf("未知 kid 不应立即重新获取 JWKS, 实际获取 %d 次", fetches)
+	}
+
+	// HMAC Token 不能通过 RSA 密钥验证
+	if _, err := v.Validate(context.Background(), signJWT(t, []byte("secret"), "key-1", claims)); err == nil {
+		t.Error("HS256 Token 应被拒绝")
+	}
+}
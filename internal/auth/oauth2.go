@@ -0,0 +1,319 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// ProviderMetadata 授权服务器元数据（OIDC 发现文档中使用的字段）
+type ProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	JWKSURI               string `json:"jwks_uri"`
+	IntrospectionEndpoint string `json:"introspection_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+}
+
+// introspectionResult 令牌内省响应（RFC 7662）
+type introspectionResult struct {
+	Active   bool        `json:"active"`
+	Scope    string      `json:"scope"`
+	ClientID string      `json:"client_id"`
+	Username string      `json:"username"`
+	Subject  string      `json:"sub"`
+	Exp      int64       `json:"exp"`
+	Aud      interface{} `json:"aud"`
+}
+
+// cachedIdentity 缓存的内省结果
+type cachedIdentity struct {
+	identity *Identity
+	expires  time.Time
+}
+
+// OAuth2Validator OAuth2/OIDC 访问令牌验证器
+type OAuth2Validator struct {
+	config     config.OAuth2Config
+	logger     logger.Logger
+	httpClient *http.Client
+	cacheTTL   time.Duration
+
+	mu       sync.Mutex
+	metadata *ProviderMetadata
+	jwt      *JWTValidator
+
+	cacheMu sync.Mutex
+	cache   map[string]cachedIdentity
+}
+
+// NewOAuth2Validator 创建 OAuth2 验证器，授权服务器元数据在首次验证时获取
+func NewOAuth2Validator(cfg config.OAuth2Config, log logger.Logger) (*OAuth2Validator, error) {
+	if cfg.IssuerURL == "" && cfg.IntrospectionURL == "" && cfg.JWKSURL == "" {
+		return nil, apperrors.New(apperrors.ErrConfigInvalid, "oauth2 认证未配置授权服务器")
+	}
+
+	return &OAuth2Validator{
+		config:     cfg,
+		logger:     log,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		cacheTTL:   parseDurationOr(cfg.CacheTTL, time.Minute),
+		cache:      make(map[string]cachedIdentity),
+	}, nil
+}
+
+// Validate 验证访问令牌并检查所需的权限范围
+func (v *OAuth2Validator) Validate(ctx context.Context, token string) (*Identity, error) {
+	if token == "" {
+		return nil, apperrors.New(apperrors.ErrUnauthorized, "缺少访问令牌")
+	}
+
+	var identity *Identity
+	var err error
+	if v.config.Validation == "jwks" {
+		identity, err = v.validateJWT(ctx, token)
+	} else {
+		identity, err = v.introspect(ctx, token)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !identity.HasScopes(v.config.RequiredScopes...) {
+		return nil, apperrors.Newf(apperrors.ErrForbidden, "访问令牌缺少所需的权限范围: %s", strings.Join(v.config.RequiredScopes, " "))
+	}
+	return identity, nil
+}
+
+// Metadata 获取授权服务器元数据，配置中的端点优先于发现文档
+func (v *OAuth2Validator) Metadata(ctx context.Context) (*ProviderMetadata, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.metadata != nil {
+		return v.metadata, nil
+	}
+
+	metadata := &ProviderMetadata{
+		Issuer:                v.config.IssuerURL,
+		JWKSURI:               v.config.JWKSURL,
+		IntrospectionEndpoint: v.config.IntrospectionURL,
+		TokenEndpoint:         v.config.TokenURL,
+	}
+
+	if v.config.IssuerURL != "" {
+		discovered, err := v.discover(ctx)
+		if err != nil {
+			// 配置了所需端点时发现失败不影响验证
+			if !v.hasRequiredEndpoint(metadata) {
+				return nil, err
+			}
+			v.logger.Warn("获取 OIDC 发现文档失败，使用配置的端点", zap.Error(err))
+		} else {
+			if metadata.JWKSURI == "" {
+				metadata.JWKSURI = discovered.JWKSURI
+			}
+			if metadata.IntrospectionEndpoint == "" {
+				metadata.IntrospectionEndpoint = discovered.IntrospectionEndpoint
+			}
+			if metadata.TokenEndpoint == "" {
+				metadata.TokenEndpoint = discovered.TokenEndpoint
+			}
+		}
+	}
+
+	if !v.hasRequiredEndpoint(metadata) {
+		return nil, apperrors.New(apperrors.ErrUnauthorized, "授权服务器未提供所需的令牌验证端点")
+	}
+
+	v.metadata = metadata
+	return metadata, nil
+}
+
+// hasRequiredEndpoint 是否具备当前验证方式所需的端点
+func (v *OAuth2Validator) hasRequiredEndpoint(metadata *ProviderMetadata) bool {
+	if v.config.Validation == "jwks" {
+		return metadata.JWKSURI != ""
+	}
+	return metadata.IntrospectionEndpoint != ""
+}
+
+// discover 获取 OIDC 发现文档
+func (v *OAuth2Validator) discover(ctx context.Context) (*ProviderMetadata, error) {
+	discoveryURL := strings.TrimSuffix(v.config.IssuerURL, "/") + "/.well-known/openid-configuration"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrUnauthorized, "无效的 issuer_url")
+	}
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrUnauthorized, "获取 OIDC 发现文档失败")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "获取 OIDC 发现文档失败: HTTP %d", resp.StatusCode)
+	}
+
+	var metadata ProviderMetadata
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrUnauthorized, "解析 OIDC 发现文档失败")
+	}
+	return &metadata, nil
+}
+
+// validateJWT 使用授权服务器的 JWKS 在本地验证 JWT 访问令牌
+func (v *OAuth2Validator) validateJWT(ctx context.Context, token string) (*Identity, error) {
+	metadata, err := v.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	if v.jwt == nil {
+		v.jwt, err = NewJWTValidator(config.MCPJWTConfig{
+			JWKSURL:  metadata.JWKSURI,
+			Issuer:   v.config.IssuerURL,
+			Audience: v.config.Audience,
+		}, v.logger)
+	}
+	validator := v.jwt
+	v.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := validator.Validate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	identity.Method = "oauth2"
+	return identity, nil
+}
+
+// introspect 通过令牌内省端点验证令牌，结果按 cache_ttl 缓存且不超过令牌有效期
+func (v *OAuth2Validator) introspect(ctx context.Context, token string) (*Identity, error) {
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:])
+	now := time.Now()
+
+	v.cacheMu.Lock()
+	cached, ok := v.cache[key]
+	v.cacheMu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.identity, nil
+	}
+
+	metadata, err := v.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.IntrospectionEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrUnauthorized, "无效的令牌内省地址")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(v.config.ClientID), url.QueryEscape(v.config.ClientSecret))
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrUnauthorized, "令牌内省请求失败")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "令牌内省请求失败: HTTP %d", resp.StatusCode)
+	}
+
+	var result introspectionResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrUnauthorized, "解析令牌内省响应失败")
+	}
+
+	if !result.Active {
+		// 内省端点对过期和撤销的令牌都返回 inactive，缓存中有记录说明令牌曾经有效
+		if ok {
+			v.evict(key)
+			return nil, apperrors.New(apperrors.ErrTokenExpired, "访问令牌已过期或已撤销")
+		}
+		return nil, apperrors.New(apperrors.ErrUnauthorized, "访问令牌无效或已撤销")
+	}
+	if result.Exp > 0 && now.After(time.Unix(result.Exp, 0)) {
+		return nil, apperrors.New(apperrors.ErrTokenExpired, "访问令牌已过期")
+	}
+	if v.config.Audience != "" && !containsAudience(result.Aud, v.config.Audience) {
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "访问令牌受众不匹配: %v", result.Aud)
+	}
+
+	identity := &Identity{
+		Subject: firstNonEmpty(result.Subject, result.Username, result.ClientID),
+		Name:    result.Username,
+		Method:  "oauth2",
+		Scopes:  strings.Fields(result.Scope),
+		Claims: map[string]interface{}{
+			"client_id": result.ClientID,
+			"aud":       result.Aud,
+		},
+	}
+
+	expires := now.Add(v.cacheTTL)
+	if result.Exp > 0 && time.Unix(result.Exp, 0).Before(expires) {
+		expires = time.Unix(result.Exp, 0)
+	}
+	v.cacheMu.Lock()
+	v.cache[key] = cachedIdentity{identity: identity, expires: expires}
+	// 顺便清理过期的缓存项
+	for k, c := range v.cache {
+		if now.After(c.expires) {
+			delete(v.cache, k)
+		}
+	}
+	v.cacheMu.Unlock()
+
+	return identity, nil
+}
+
+// evict 删除缓存的内省结果
+func (v *OAuth2Validator) evict(key string) {
+	v.cacheMu.Lock()
+	delete(v.cache, key)
+	v.cacheMu.Unlock()
+}
+
+// containsAudience aud 声明（字符串或数组）是否包含指定受众
+func containsAudience(aud interface{}, audience string) bool {
+	switch value := aud.(type) {
+	case string:
+		return value == audience
+	case []interface{}:
+		for _, item := range value {
+			if item == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// newIntrospectionServer 创建测试用授权服务器，提供 OIDC 发现文档和令牌内省端点
+func newIntrospectionServer(t *testing.T, tokens map[string]map[string]interface{}, calls *int32) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 server.URL,
+			"introspection_endpoint": server.URL + "/introspect",
+			"token_endpoint":         server.URL + "/token",
+		})
+	})
+	mux.HandleFunc("/introspect", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		if user, pass, ok := r.BasicAuth(); !ok || user != "mcp" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		result, ok := tokens[r.PostFormValue("token")]
+		if !ok {
+			result = map[string]interface{}{"active": false}
+		}
+		json.NewEncoder(w).Encode(result)
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOAuth2ValidatorIntrospection(t *testing.T) {
+	future := time.Now().Add(time.Hour).Unix()
+	tokens := map[string]map[string]interface{}{
+		"good":     {"active": true, "sub": "alice", "username": "Alice", "scope": "mcp:read mcp:write", "exp": future, "aud": "mcp"},
+		"noscope":  {"active": true, "sub": "bob", "scope": "mcp:read", "exp": future, "aud": "mcp"},
+		"expired":  {"active": true, "sub": "carol", "scope": "mcp:write", "exp": time.Now().Add(-time.Minute).Unix(), "aud": "mcp"},
+		"otheraud": {"active": true, "sub": "dave", "scope": "mcp:write", "exp": future, "aud": []interface{}{"other"}},
+	}
+	var calls int32
+	server := newIntrospectionServer(t, tokens, &calls)
+
+	v, err := NewOAuth2Validator(config.OAuth2Config{
+		IssuerURL:      server.URL,
+		Validation:     "introspection",
+		ClientID:       "mcp",
+		ClientSecret:   "secret",
+		Audience:       "mcp",
+		RequiredScopes: []string{"mcp:write"},
+		CacheTTL:       "1m",
+	}, logger.FromZap(zap.NewNop()))
+	if err != nil {
+		t.Fatalf("创建验证器失败: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		code    apperrors.ErrorCode
+		subject string
+	}{
+		{name: "有效令牌", token: "good", subject: "alice"},
+		{name: "未知令牌", token: "unknown", code: apperrors.ErrUnauthorized},
+		{name: "缺少scope", token: "noscope", code: apperrors.ErrForbidden},
+		{name: "已过期", token: "expired", code: apperrors.ErrTokenExpired},
+		{name: "受众不匹配", token: "otheraud", code: apperrors.ErrUnauthorized},
+		{name: "空令牌", token: "", code: apperrors.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := v.Validate(context.Background(), tt.token)
+			if tt.code != "" {
+				if !apperrors.IsCode(err, tt.code) {
+					t.Fatalf("期望错误代码 %s，实际: %v", tt.code, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("验证失败: %v", err)
+			}
+			if identity.Subject != tt.subject || identity.Method != "oauth2" {
+				t.Errorf("身份不符: %+v", identity)
+			}
+		})
+	}
+
+	// 有效令牌的内省结果应被缓存
+	before := atomic.LoadInt32(&calls)
+	if _, err := v.Validate(context.Background(), "good"); err != nil {
+		t.Fatalf("验证失败: %v", err)
+	}
+	if atomic.LoadInt32(&calls) != before {
+		t.Errorf("缓存命中时不应再次调用内省端点")
+	}
+
+	metadata, err := v.Metadata(context.Background())
+	if err != nil {
+		t.Fatalf("获取元数据失败: %v", err)
+	}
+	if metadata.TokenEndpoint != server.URL+"/token" {
+		t.Errorf("token_endpoint = %s", metadata.TokenEndpoint)
+	}
+}
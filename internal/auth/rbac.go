@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// Role 访问角色，高级角色拥有低级角色的全部权限
+type Role string
+
+const (
+	RoleViewer    Role = "viewer"    // 查看任务、worktree 和资源
+	RoleSubmitter Role = "submitter" // 提交和取消任务
+	RoleAdmin     Role = "admin"     // 删除 worktree、管理令牌和服务器设置
+)
+
+// roleRanks 角色等级
+var roleRanks = map[Role]int{
+	RoleViewer:    1,
+	RoleSubmitter: 2,
+	RoleAdmin:     3,
+}
+
+// scopeRoles 令牌权限范围对应的角色
+var scopeRoles = map[string]Role{
+	ScopeRead:   RoleViewer,
+	ScopeSubmit: RoleSubmitter,
+	ScopeAdmin:  RoleAdmin,
+}
+
+// ParseRole 解析角色名称
+func ParseRole(name string) (Role, error) {
+	role := Role(name)
+	if _, ok := roleRanks[role]; !ok {
+		return "", apperrors.Newf(apperrors.ErrConfigInvalid, "无效的角色: %s，支持: viewer, submitter, admin", name)
+	}
+	return role, nil
+}
+
+// Allows 当前角色是否满足所需角色
+func (r Role) Allows(required Role) bool {
+	return roleRanks[r] >= roleRanks[required]
+}
+
+// Role 获取身份的最高角色，角色来自 roles 声明或权限范围，都没有时使用 defaultRole
+func (i *Identity) Role(defaultRole Role) Role {
+	var best Role
+	consider := func(role Role) {
+		if roleRanks[role] > roleRanks[best] {
+			best = role
+		}
+	}
+
+	for _, name := range i.Roles {
+		consider(Role(name))
+	}
+	for _, scope := range i.Scopes {
+		if role, ok := scopeRoles[scope]; ok {
+			consider(role)
+		} else {
+			consider(Role(scope))
+		}
+	}
+
+	if best == "" {
+		return defaultRole
+	}
+	return best
+}
+
+// RoleFromContext 获取请求的角色，未经认证的请求（未启用认证或本地 stdio）视为管理员
+func RoleFromContext(ctx context.Context, defaultRole Role) Role {
+	identity := IdentityFromContext(ctx)
+	if identity == nil {
+		return RoleAdmin
+	}
+	return identity.Role(defaultRole)
+}
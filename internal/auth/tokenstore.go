@@ -0,0 +1,438 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// 令牌权限范围
+const (
+	ScopeRead   = "read"   // 只读：查询任务、worktree 和资源
+	ScopeSubmit = "submit" // 提交和取消任务
+	ScopeAdmin  = "admin"  // 管理：删除 worktree、管理令牌和服务器设置
+)
+
+// tokenPrefix 令牌明文前缀，便于在日志和密钥扫描中识别
+const tokenPrefix = "acc_"
+
+// ValidScopes 支持的令牌权限范围
+var ValidScopes = []string{ScopeRead, ScopeSubmit, ScopeAdmin}
+
+// TokenRecord 令牌记录，只保存令牌的 SHA-256 哈希
+type TokenRecord struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Hash       string     `json:"hash,omitempty"`
+	Hint       string     `json:"hint"` // 明文末尾4位，用于辨认令牌
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RotatedTo  string     `json:"rotated_to,omitempty"`
+}
+
+// Active 令牌是否未撤销且未过期
+func (r *TokenRecord) Active(now time.Time) bool {
+	return r.RevokedAt == nil && (r.ExpiresAt == nil || now.Before(*r.ExpiresAt))
+}
+
+// CreateTokenRequest 创建令牌请求
+type CreateTokenRequest struct {
+	Name   string        `json:"name"`
+	Scopes []string      `json:"scopes"`
+	TTL    time.Duration `json:"-"` // 0 表示永不过期
+}
+
+// TokenStore 持久化的 API 令牌存储
+type TokenStore struct {
+	path   string
+	logger logger.Logger
+
+	mu      sync.Mutex
+	records map[string]*TokenRecord // key: 令牌ID
+	byHash  map[string]*TokenRecord
+	dirty   bool     // LastUsedAt 已更新但未落盘
+	sum     [32]byte // 最近一次加载或写入时的文件内容哈希
+}
+
+// NewTokenStore 创建令牌存储并加载已有令牌，文件不存在时在首次写入时创建
+func NewTokenStore(path string, log logger.Logger) (*TokenStore, error) {
+	store := &TokenStore{
+		path:    path,
+		logger:  log,
+		records: make(map[string]*TokenRecord),
+		byHash:  make(map[string]*TokenRecord),
+	}
+
+	if err := store.loadLocked(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// loadLocked 从磁盘加载令牌，保留内存中尚未落盘的使用时间，调用方需持有写锁
+func (s *TokenStore) loadLocked() error {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrConfigInvalid, "读取令牌存储失败: %s", s.path)
+	}
+	return s.parseLocked(data)
+}
+
+// parseLocked 解析令牌文件内容并替换内存中的令牌，调用方需持有写锁
+func (s *TokenStore) parseLocked(data []byte) error {
+	var records []*TokenRecord
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := json.Unmarshal(data, &records); err != nil {
+			return apperrors.Wrapf(err, apperrors.ErrConfigInvalid, "解析令牌存储失败: %s", s.path)
+		}
+	}
+
+	loaded := make(map[string]*TokenRecord, len(records))
+	byHash := make(map[string]*TokenRecord, len(records))
+	for _, record := range records {
+		if old, ok := s.records[record.ID]; ok && old.LastUsedAt != nil &&
+			(record.LastUsedAt == nil || old.LastUsedAt.After(*record.LastUsedAt)) {
+			record.LastUsedAt = old.LastUsedAt
+		}
+		loaded[record.ID] = record
+		byHash[record.Hash] = record
+	}
+
+	s.records = loaded
+	s.byHash = byHash
+	s.sum = sha256.Sum256(data)
+	return nil
+}
+
+// reloadIfChangedLocked 令牌文件被其他进程（如 token 命令）修改时重新加载，调用方需持有写锁
+// 按内容哈希判断是否修改，粗粒度时间戳的文件系统上同一时刻的两次写入也能发现；重新加载失败时返回错误并继续使用已加载的令牌
+func (s *TokenStore) reloadIfChangedLocked() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil || sha256.Sum256(data) == s.sum {
+		return nil
+	}
+	if err := s.parseLocked(data); err != nil {
+		s.logger.Warn("重新加载令牌存储失败，继续使用已加载的令牌", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Create 创建令牌，明文令牌只在此时返回一次
+func (s *TokenStore) Create(req CreateTokenRequest) (string, *TokenRecord, error) {
+	if err := validateScopes(req.Scopes); err != nil {
+		return "", nil, err
+	}
+	if req.TTL < 0 {
+		return "", nil, apperrors.New(apperrors.ErrConfigInvalid, "令牌有效期不能为负数")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reloadIfChangedLocked()
+
+	token, record, err := s.newRecord(req.Name, req.Scopes, req.TTL)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := s.saveLocked(); err != nil {
+		s.removeLocked(record)
+		return "", nil, err
+	}
+
+	s.logger.Info("已创建API令牌",
+		zap.String("id", record.ID),
+		zap.String("name", record.Name),
+		zap.Strings("scopes", record.Scopes))
+
+	return token, copyRecord(record), nil
+}
+
+// List 列出全部令牌（不含明文），按创建时间排序
+func (s *TokenStore) List() []*TokenRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reloadIfChangedLocked()
+
+	records := make([]*TokenRecord, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, copyRecord(record))
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+	return records
+}
+
+// Get 获取令牌记录
+func (s *TokenStore) Get(id string) (*TokenRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reloadIfChangedLocked()
+
+	record, ok := s.records[id]
+	if !ok {
+		return nil, apperrors.Newf(apperrors.ErrResourceNotFound, "令牌不存在: %s", id)
+	}
+	return copyRecord(record), nil
+}
+
+// Revoke 撤销令牌
+func (s *TokenStore) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reloadIfChangedLocked()
+
+	record, ok := s.records[id]
+	if !ok {
+		return apperrors.Newf(apperrors.ErrResourceNotFound, "令牌不存在: %s", id)
+	}
+	if record.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	record.RevokedAt = &now
+	if err := s.saveLocked(); err != nil {
+		record.RevokedAt = nil
+		return err
+	}
+
+	s.logger.Info("已撤销API令牌", zap.String("id", id), zap.String("name", record.Name))
+	return nil
+}
+
+// Rotate 轮换令牌：以相同名称、权限范围和有效期创建新令牌，并撤销旧令牌
+func (s *TokenStore) Rotate(id string) (string, *TokenRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reloadIfChangedLocked()
+
+	old, ok := s.records[id]
+	if !ok {
+		return "", nil, apperrors.Newf(apperrors.ErrResourceNotFound, "令牌不存在: %s", id)
+	}
+	if !old.Active(time.Now()) {
+		return "", nil, apperrors.Newf(apperrors.ErrConflict, "令牌已撤销或已过期，无法轮换: %s", id)
+	}
+
+	var ttl time.Duration
+	if old.ExpiresAt != nil {
+		ttl = old.ExpiresAt.Sub(old.CreatedAt)
+	}
+
+	token, record, err := s.newRecord(old.Name, old.Scopes, ttl)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	old.RevokedAt = &now
+	old.RotatedTo = record.ID
+	if err := s.saveLocked(); err != nil {
+		old.RevokedAt = nil
+		old.RotatedTo = ""
+		s.removeLocked(record)
+		return "", nil, err
+	}
+
+	s.logger.Info("已轮换API令牌", zap.String("old_id", id), zap.String("new_id", record.ID))
+	return token, copyRecord(record), nil
+}
+
+// Validate 验证令牌，实现 TokenValidator
+func (s *TokenStore) Validate(ctx context.Context, token string) (*Identity, error) {
+	if token == "" {
+		return nil, apperrors.New(apperrors.ErrUnauthorized, "缺少访问令牌")
+	}
+
+	hash := hashToken(token)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reloadIfChangedLocked()
+
+	record, ok := s.byHash[hash]
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrUnauthorized, "无效的访问令牌")
+	}
+	if record.RevokedAt != nil {
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "访问令牌已撤销: %s", record.ID)
+	}
+
+	now := time.Now()
+	if record.ExpiresAt != nil && !now.Before(*record.ExpiresAt) {
+		return nil, apperrors.Newf(apperrors.ErrTokenExpired, "访问令牌已过期: %s", record.ID)
+	}
+
+	record.LastUsedAt = &now
+	s.dirty = true
+
+	return &Identity{
+		Subject: "token:" + record.ID,
+		Name:    record.Name,
+		Method:  "token",
+		Scopes:  append([]string(nil), record.Scopes...),
+		Claims:  map[string]interface{}{"token_id": record.ID},
+	}, nil
+}
+
+// Flush 将令牌使用时间写入磁盘
+// 写入前先合并其他进程的修改，避免用内存中的旧记录覆盖期间创建或撤销的令牌；文件无法解析时不写入
+func (s *TokenStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirty {
+		return nil
+	}
+	if err := s.reloadIfChangedLocked(); err != nil {
+		return err
+	}
+	return s.saveLocked()
+}
+
+// newRecord 生成令牌明文和记录，调用方需持有写锁
+func (s *TokenStore) newRecord(name string, scopes []string, ttl time.Duration) (string, *TokenRecord, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, apperrors.Wrap(err, apperrors.ErrMCPServerError, "生成令牌失败")
+	}
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", nil, apperrors.Wrap(err, apperrors.ErrMCPServerError, "生成令牌ID失败")
+	}
+
+	token := tokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	now := time.Now()
+	record := &TokenRecord{
+		ID:        hex.EncodeToString(idBytes),
+		Name:      name,
+		Hash:      hashToken(token),
+		Hint:      token[len(token)-4:],
+		Scopes:    normalizeScopes(scopes),
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		expiresAt := now.Add(ttl)
+		record.ExpiresAt = &expiresAt
+	}
+
+	s.records[record.ID] = record
+	s.byHash[record.Hash] = record
+	return token, record, nil
+}
+
+// removeLocked 删除内存中的记录，用于写盘失败时回滚
+func (s *TokenStore) removeLocked(record *TokenRecord) {
+	delete(s.records, record.ID)
+	delete(s.byHash, record.Hash)
+}
+
+// saveLocked 原子写入令牌存储文件，调用方需持有写锁
+func (s *TokenStore) saveLocked() error {
+	records := make([]*TokenRecord, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return apperrors.Wrap(err, apperrors.ErrMCPServerError, "序列化令牌存储失败")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrMCPServerError, "创建令牌存储目录失败: %s", filepath.Dir(s.path))
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrMCPServerError, "写入令牌存储失败: %s", s.path)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return apperrors.Wrapf(err, apperrors.ErrMCPServerError, "写入令牌存储失败: %s", s.path)
+	}
+
+	s.sum = sha256.Sum256(data)
+	s.dirty = false
+	return nil
+}
+
+// hashToken 计算令牌的 SHA-256 哈希
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// validateScopes 校验权限范围
+func validateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "至少需要一个权限范围，支持: %s", strings.Join(ValidScopes, ", "))
+	}
+	for _, scope := range scopes {
+		valid := false
+		for _, v := range ValidScopes {
+			if scope == v {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的权限范围: %s，支持: %s", scope, strings.Join(ValidScopes, ", "))
+		}
+	}
+	return nil
+}
+
+// normalizeScopes 去重并排序权限范围
+func normalizeScopes(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// copyRecord 复制令牌记录并去掉哈希，避免调用方修改存储中的数据
+func copyRecord(record *TokenRecord) *TokenRecord {
+	copied := *record
+	copied.Hash = ""
+	copied.Scopes = append([]string(nil), record.Scopes...)
+	return &copied
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+func TestTokenStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	log := logger.FromZap(zap.NewNop())
+
+	store, err := NewTokenStore(path, log)
+	if err != nil {
+		t.Fatalf("创建令牌存储失败: %v", err)
+	}
+
+	if _, _, err := store.Create(CreateTokenRequest{Name: "bad", Scopes: []string{"root"}}); !apperrors.IsCode(err, apperrors.ErrConfigInvalid) {
+		t.Fatalf("无效的权限范围应被拒绝: %v", err)
+	}
+
+	token, record, err := store.Create(CreateTokenRequest{Name: "ci", Scopes: []string{ScopeSubmit, ScopeRead, ScopeRead}})
+	if err != nil {
+		t.Fatalf("创建令牌失败: %v", err)
+	}
+	if record.Hash != "" {
+		t.Errorf("返回的令牌记录不应包含哈希")
+	}
+
+	identity, err := store.Validate(context.Background(), token)
+	if err != nil {
+		t.Fatalf("验证令牌失败: %v", err)
+	}
+	if !identity.HasScopes(ScopeRead, ScopeSubmit) || identity.HasScopes(ScopeAdmin) {
+		t.Errorf("权限范围不符: %v", identity.Scopes)
+	}
+
+	// 重新加载后令牌仍然有效
+	reloaded, err := NewTokenStore(path, log)
+	if err != nil {
+		t.Fatalf("重新加载令牌存储失败: %v", err)
+	}
+	if _, err := reloaded.Validate(context.Background(), token); err != nil {
+		t.Fatalf("重新加载后验证令牌失败: %v", err)
+	}
+
+	// 轮换后旧令牌失效，新令牌继承权限范围
+	newToken, newRecord, err := store.Rotate(record.ID)
+	if err != nil {
+		t.Fatalf("轮换令牌失败: %v", err)
+	}
+	if _, err := store.Validate(context.Background(), token); !apperrors.IsCode(err, apperrors.ErrUnauthorized) {
+		t.Errorf("轮换后旧令牌应失效: %v", err)
+	}
+	if _, err := store.Validate(context.Background(), newToken); err != nil {
+		t.Errorf("新令牌验证失败: %v", err)
+	}
+
+	// 其他进程（reloaded）撤销的令牌也应立即失效
+	time.Sleep(10 * time.Millisecond)
+	if err := reloaded.Revoke(newRecord.ID); err != nil {
+		t.Fatalf("撤销令牌失败: %v", err)
+	}
+	if _, err := store.Validate(context.Background(), newToken); !apperrors.IsCode(err, apperrors.ErrUnauthorized) {
+		t.Errorf("撤销后令牌应失效: %v", err)
+	}
+
+	if err := store.Revoke("missing"); !apperrors.IsCode(err, apperrors.ErrResourceNotFound) {
+		t.Errorf("撤销不存在的令牌应返回 RESOURCE_NOT_FOUND: %v", err)
+	}
+}
+
+func TestTokenStoreExpiry(t *testing.T) {
+	store, err := NewTokenStore(filepath.Join(t.TempDir(), "tokens.json"), logger.FromZap(zap.NewNop()))
+	if err != nil {
+		t.Fatalf("创建令牌存储失败: %v", err)
+	}
+
+	token, _, err := store.Create(CreateTokenRequest{Name: "short", Scopes: []string{ScopeRead}, TTL: time.Millisecond})
+	if err != nil {
+		t.Fatalf("创建令牌失败: %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if _, err := store.Validate(context.Background(), token); !apperrors.IsCode(err, apperrors.ErrTokenExpired) {
+		t.Errorf("过期令牌应返回 TOKEN_EXPIRED: %v", err)
+	}
+}
+
+func TestTokenStoreFlushKeepsExternalChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	log := logger.FromZap(zap.NewNop())
+
+	server, err := NewTokenStore(path, log)
+	if err != nil {
+		t.Fatalf("创建令牌存储失败: %v", err)
+	}
+	token, record, err := server.Create(CreateTokenRequest{Name: "ci", Scopes: []string{ScopeRead}})
+	if err != nil {
+		t.Fatalf("创建令牌失败: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 服务器使用令牌后，token 命令在同一时间戳内撤销令牌并创建新令牌
+	if _, err := server.Validate(context.Background(), token); err != nil {
+		t.Fatalf("验证令牌失败: %v", err)
+	}
+	cli, err := NewTokenStore(path, log)
+	if err != nil {
+		t.Fatalf("加载令牌存储失败: %v", err)
+	}
+	if err := cli.Revoke(record.ID); err != nil {
+		t.Fatalf("撤销令牌失败: %v", err)
+	}
+	newToken, _, err := cli.Create(CreateTokenRequest{Name: "deploy", Scopes: []string{ScopeSubmit}})
+	if err != nil {
+		t.Fatalf("创建令牌失败: %v", err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 服务器停止时写入使用时间，不能恢复已撤销的令牌或丢失新令牌
+	if err := server.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	reloaded, err := NewTokenStore(path, log)
+	if err != nil {
+		t.Fatalf("重新加载令牌存储失败: %v", err)
+	}
+	if _, err := reloaded.Validate(context.Background(), token); !apperrors.IsCode(err, apperrors.ErrUnauthorized) {
+		t.Errorf("已撤销的令牌 Validate() error = %v", err)
+	}
+	if _, err := reloaded.Validate(context.Background(), newToken); err != nil {
+		t.Errorf("新令牌 Validate() error = %v", err)
+	}
+	if got, _ := reloaded.Get(record.ID); got.LastUsedAt == nil {
+		t.Error("使用时间未写入")
+	}
+}
@@ -1,10 +1,17 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	apperrors "auto-claude-code/internal/errors"
 
@@ -17,14 +24,40 @@ type Config struct {
 	Debug    bool   `mapstructure:"debug" yaml:"debug"`
 	LogLevel string `mapstructure:"log_level" yaml:"log_level"`
 
+	// 执行后端配置："wsl"、"ssh" 或 "container"
+	Backend string `mapstructure:"backend" yaml:"backend"`
+
 	// WSL 配置
 	WSL WSLConfig `mapstructure:"wsl" yaml:"wsl"`
 
+	// SSH 远程主机配置（backend 为 ssh 时使用）
+	SSH SSHConfig `mapstructure:"ssh" yaml:"ssh"`
+
+	// 容器配置（backend 为 container 时使用）
+	Container ContainerConfig `mapstructure:"container" yaml:"container"`
+
 	// Claude Code 配置
 	ClaudeCode ClaudeCodeConfig `mapstructure:"claude_code" yaml:"claude_code"`
 
 	// MCP 配置（为后续功能预留）
 	MCP MCPConfig `mapstructure:"mcp" yaml:"mcp"`
+
+	// 链路追踪配置
+	Tracing TracingConfig `mapstructure:"tracing" yaml:"tracing"`
+
+	// 使用的配置档案，由 --profile 或 AUTO_CLAUDE_CODE_PROFILE 选择，为空时只使用顶层配置
+	Profile string `mapstructure:"-" yaml:"-"`
+}
+
+// TracingConfig OpenTelemetry 链路追踪配置，span 以 OTLP/HTTP JSON 格式导出
+type TracingConfig struct {
+	Enabled        bool              `mapstructure:"enabled" yaml:"enabled"`
+	Endpoint       string            `mapstructure:"endpoint" yaml:"endpoint"` // OTLP/HTTP traces 地址，如 http://localhost:4318/v1/traces
+	ServiceName    string            `mapstructure:"service_name" yaml:"service_name"`
+	SampleRatio    float64           `mapstructure:"sample_ratio" yaml:"sample_ratio"` // 根 span 采样率（0~1）
+	Headers        map[string]string `mapstructure:"headers" yaml:"headers"`           // 导出请求附加的HTTP头，如认证信息
+	BatchSize      int               `mapstructure:"batch_size" yaml:"batch_size"`
+	ExportInterval string            `mapstructure:"export_interval" yaml:"export_interval"`
 }
 
 // WSLConfig WSL 相关配置
@@ -32,6 +65,31 @@ type WSLConfig struct {
 	DefaultDistro string            `mapstructure:"default_distro" yaml:"default_distro"`
 	PathMappings  map[string]string `mapstructure:"path_mappings" yaml:"path_mappings"`
 	Timeout       string            `mapstructure:"timeout" yaml:"timeout"`
+
+	// Shell 配置
+	Shell      string `mapstructure:"shell" yaml:"shell"`             // 执行命令使用的 shell
+	LoginShell bool   `mapstructure:"login_shell" yaml:"login_shell"` // 是否以登录 shell 方式执行（加载 profile）
+
+	// 环境变量传递配置
+	EnvPassthrough []string `mapstructure:"env_passthrough" yaml:"env_passthrough"` // 从 Windows 环境透传的变量名
+	EnvSet         []string `mapstructure:"env_set" yaml:"env_set"`                 // 固定设置的变量，格式 NAME=value
+}
+
+// SSHConfig SSH 远程 Linux 主机配置
+type SSHConfig struct {
+	Host      string   `mapstructure:"host" yaml:"host"`
+	User      string   `mapstructure:"user" yaml:"user"`
+	Port      int      `mapstructure:"port" yaml:"port"`
+	KeyFile   string   `mapstructure:"key_file" yaml:"key_file"`
+	ExtraArgs []string `mapstructure:"extra_args" yaml:"extra_args"` // 额外传递给 ssh 的参数
+}
+
+// ContainerConfig Docker/Podman 容器执行配置
+type ContainerConfig struct {
+	Runtime   string   `mapstructure:"runtime" yaml:"runtime"` // "docker" 或 "podman"
+	Image     string   `mapstructure:"image" yaml:"image"`
+	Mounts    []string `mapstructure:"mounts" yaml:"mounts"`         // 额外挂载，格式 host:container[:options]
+	ExtraArgs []string `mapstructure:"extra_args" yaml:"extra_args"` // 额外传递给 run 子命令的参数
 }
 
 // ClaudeCodeConfig Claude Code 相关配置
@@ -40,6 +98,10 @@ type ClaudeCodeConfig struct {
 	DefaultArgs  []string `mapstructure:"default_args" yaml:"default_args"`
 	Interactive  bool     `mapstructure:"interactive" yaml:"interactive"`
 	WorkspaceDir string   `mapstructure:"workspace_dir" yaml:"workspace_dir"`
+
+	// 版本管理
+	Version  string `mapstructure:"version" yaml:"version"`   // 要求的版本，支持前缀匹配（如 1.0），留空不校验
+	Launcher string `mapstructure:"launcher" yaml:"launcher"` // 启动方式："direct" 使用 executable，"npx" 按版本通过 npx 启动
 }
 
 // MCPConfig MCP 服务器配置
@@ -50,15 +112,49 @@ type MCPConfig struct {
 	Host               string `mapstructure:"host" yaml:"host"`
 	MaxConcurrentTasks int    `mapstructure:"max_concurrent_tasks" yaml:"max_concurrent_tasks"`
 	TaskTimeout        string `mapstructure:"task_timeout" yaml:"task_timeout"`
+	ConfigReload       bool   `mapstructure:"config_reload" yaml:"config_reload"` // 配置文件修改后在运行时应用日志级别、清理策略、令牌文件和 Webhook
 
 	// Git Worktree 配置
 	WorktreeBaseDir string `mapstructure:"worktree_base_dir" yaml:"worktree_base_dir"`
 	CleanupInterval string `mapstructure:"cleanup_interval" yaml:"cleanup_interval"`
 	MaxWorktrees    int    `mapstructure:"max_worktrees" yaml:"max_worktrees"`
+	BranchTemplate  string `mapstructure:"branch_template" yaml:"branch_template"` // 任务分支名模板，如 acc/{task_id}/{slug(description)}
+
+	// worktree 的存放位置，可放在 WSL 发行版的文件系统中
+	WorktreeStorage WorktreeStorageConfig `mapstructure:"worktree_storage" yaml:"worktree_storage"`
+
+	// 空闲worktree的清理策略
+	WorktreeCleanup WorktreeCleanupConfig `mapstructure:"worktree_cleanup" yaml:"worktree_cleanup"`
+
+	// 定期与源仓库的 git worktree 记录对账
+	WorktreeReconcile WorktreeReconcileConfig `mapstructure:"worktree_reconcile" yaml:"worktree_reconcile"`
+
+	// worktree 基础目录的磁盘配额
+	WorktreeDisk WorktreeDiskConfig `mapstructure:"worktree_disk" yaml:"worktree_disk"`
+
+	// 非 Git 项目复制到 worktree 的配置
+	WorktreeCopy WorktreeCopyConfig `mapstructure:"worktree_copy" yaml:"worktree_copy"`
+
+	// 创建 Git worktree 时初始化子模块
+	WorktreeSubmodules WorktreeSubmoduleConfig `mapstructure:"worktree_submodules" yaml:"worktree_submodules"`
+
+	// 创建 Git worktree 时拉取 Git LFS 文件
+	WorktreeLFS WorktreeLFSConfig `mapstructure:"worktree_lfs" yaml:"worktree_lfs"`
+
+	// 创建worktree后按模板注入的上下文文件，如 CLAUDE.md
+	WorktreeContext WorktreeContextConfig `mapstructure:"worktree_context" yaml:"worktree_context"`
+
+	// Git 项目的本地裸仓库镜像，任务worktree从镜像创建
+	WorktreeMirror WorktreeMirrorConfig `mapstructure:"worktree_mirror" yaml:"worktree_mirror"`
+
+	// 按项目的 worktree 创建配置
+	WorktreeProjects []WorktreeProjectConfig `mapstructure:"worktree_projects" yaml:"worktree_projects"`
 
 	// 传输配置
 	HTTP  MCPHTTPConfig  `mapstructure:"http" yaml:"http"`
 	Stdio MCPStdioConfig `mapstructure:"stdio" yaml:"stdio"`
+	SSE   MCPSSEConfig   `mapstructure:"sse" yaml:"sse"`
+	GRPC  MCPGRPCConfig  `mapstructure:"grpc" yaml:"grpc"`
 
 	// 认证配置
 	Auth MCPAuthConfig `mapstructure:"auth" yaml:"auth"`
@@ -66,16 +162,98 @@ type MCPConfig struct {
 	// 任务队列配置
 	Queue MCPQueueConfig `mapstructure:"queue" yaml:"queue"`
 
+	// 任务发行版池，未配置时任务在请求指定的发行版或默认发行版中执行
+	Distros []DistroConfig `mapstructure:"distros" yaml:"distros"`
+
+	// 任务进程资源限制（任务可单独覆盖）
+	TaskLimits ResourceLimits `mapstructure:"task_limits" yaml:"task_limits"`
+
+	// run_shell_command 工具配置
+	ShellTool ShellToolConfig `mapstructure:"shell_tool" yaml:"shell_tool"`
+
+	// 请求限流配置
+	RateLimit RateLimitConfig `mapstructure:"rate_limit" yaml:"rate_limit"`
+
+	// 按客户端的任务配额
+	Quota QuotaConfig `mapstructure:"quota" yaml:"quota"`
+
+	// 审计日志配置
+	Audit AuditConfig `mapstructure:"audit" yaml:"audit"`
+
+	// 任务生命周期事件的出站 Webhook
+	Webhooks []WebhookConfig `mapstructure:"webhooks" yaml:"webhooks"`
+
+	// 任务持久化存储配置
+	Storage StorageConfig `mapstructure:"storage" yaml:"storage"`
+
+	// 任务输出日志文件配置
+	TaskOutput TaskOutputConfig `mapstructure:"task_output" yaml:"task_output"`
+
+	// 任务进度估算配置
+	TaskProgress TaskProgressConfig `mapstructure:"task_progress" yaml:"task_progress"`
+
+	// 已结束任务的保留策略
+	Retention RetentionConfig `mapstructure:"retention" yaml:"retention"`
+
+	// 任务产物收集配置
+	Artifacts ArtifactsConfig `mapstructure:"artifacts" yaml:"artifacts"`
+
+	// 任务模板存储配置
+	Templates TemplatesConfig `mapstructure:"templates" yaml:"templates"`
+
+	// 任务成功后将任务分支合并回项目的配置
+	MergeBack MergeBackConfig `mapstructure:"merge_back" yaml:"merge_back"`
+
+	// 任务成功后推送任务分支并创建拉取请求的配置
+	PullRequests PullRequestConfig `mapstructure:"pull_requests" yaml:"pull_requests"`
+
 	// 监控配置
 	Monitoring MCPMonitoringConfig `mapstructure:"monitoring" yaml:"monitoring"`
 }
 
 // MCPAuthConfig MCP 认证配置
 type MCPAuthConfig struct {
-	Enabled    bool     `mapstructure:"enabled" yaml:"enabled"`
-	Method     string   `mapstructure:"method" yaml:"method"` // "token", "oauth2", "none"
-	TokenFile  string   `mapstructure:"token_file" yaml:"token_file"`
-	AllowedIPs []string `mapstructure:"allowed_ips" yaml:"allowed_ips"`
+	Enabled     bool         `mapstructure:"enabled" yaml:"enabled"`
+	Method      string       `mapstructure:"method" yaml:"method"`         // "token", "jwt", "oauth2", "none"
+	TokenFile   string       `mapstructure:"token_file" yaml:"token_file"` // 已弃用：静态明文令牌文件
+	TokenStore  string       `mapstructure:"token_store" yaml:"token_store"`
+	AllowedIPs  []string     `mapstructure:"allowed_ips" yaml:"allowed_ips"`
+	DefaultRole string       `mapstructure:"default_role" yaml:"default_role"` // 身份中没有角色或权限范围时使用的角色
+	SharedTasks bool         `mapstructure:"shared_tasks" yaml:"shared_tasks"` // 非 admin 身份也能查看和取消其他身份提交的任务
+	JWT         MCPJWTConfig `mapstructure:"jwt" yaml:"jwt"`
+	OAuth2      OAuth2Config `mapstructure:"oauth2" yaml:"oauth2"`
+}
+
+// OAuth2Config OAuth2/OIDC 访问令牌认证配置
+// validation 为 "introspection" 时通过授权服务器的令牌内省端点（RFC 7662）验证，为 "jwks" 时按 JWT 在本地验证签名
+// 未配置的端点通过 issuer_url 的 OIDC 发现文档获取
+type OAuth2Config struct {
+	IssuerURL        string   `mapstructure:"issuer_url" yaml:"issuer_url"`
+	Validation       string   `mapstructure:"validation" yaml:"validation"` // "introspection", "jwks"
+	IntrospectionURL string   `mapstructure:"introspection_url" yaml:"introspection_url"`
+	JWKSURL          string   `mapstructure:"jwks_url" yaml:"jwks_url"`
+	TokenURL         string   `mapstructure:"token_url" yaml:"token_url"` // 刷新令牌的地址，返回给客户端作为指引
+	ClientID         string   `mapstructure:"client_id" yaml:"client_id"` // 调用内省端点的客户端凭据
+	ClientSecret     string   `mapstructure:"client_secret" yaml:"client_secret"`
+	Audience         string   `mapstructure:"audience" yaml:"audience"`
+	RequiredScopes   []string `mapstructure:"required_scopes" yaml:"required_scopes"`
+	CacheTTL         string   `mapstructure:"cache_ttl" yaml:"cache_ttl"` // 内省结果缓存时间
+}
+
+// MCPJWTConfig JWT Bearer Token 认证配置
+// 签名密钥三选一：secret（HS256/384/512）、public_key_file（RS256/384/512 PEM 公钥）、jwks_url（从身份提供方获取 RSA 公钥）
+type MCPJWTConfig struct {
+	Secret        string `mapstructure:"secret" yaml:"secret"`
+	PublicKeyFile string `mapstructure:"public_key_file" yaml:"public_key_file"`
+	JWKSURL       string `mapstructure:"jwks_url" yaml:"jwks_url"`
+	JWKSRefresh   string `mapstructure:"jwks_refresh" yaml:"jwks_refresh"` // JWKS 缓存刷新间隔
+	Issuer        string `mapstructure:"issuer" yaml:"issuer"`             // 非空时校验 iss
+	Audience      string `mapstructure:"audience" yaml:"audience"`         // 非空时校验 aud
+	Leeway        string `mapstructure:"leeway" yaml:"leeway"`             // exp/nbf 允许的时钟偏差
+	SubjectClaim  string `mapstructure:"subject_claim" yaml:"subject_claim"`
+	NameClaim     string `mapstructure:"name_claim" yaml:"name_claim"`
+	RolesClaim    string `mapstructure:"roles_claim" yaml:"roles_claim"`
+	ScopesClaim   string `mapstructure:"scopes_claim" yaml:"scopes_claim"`
 }
 
 // MCPQueueConfig MCP 任务队列配置
@@ -84,30 +262,1011 @@ type MCPQueueConfig struct {
 	RetryAttempts  int    `mapstructure:"retry_attempts" yaml:"retry_attempts"`
 	RetryInterval  string `mapstructure:"retry_interval" yaml:"retry_interval"`
 	PriorityLevels int    `mapstructure:"priority_levels" yaml:"priority_levels"`
+	MaxPerProject  int    `mapstructure:"max_per_project" yaml:"max_per_project"` // 同一项目同时执行的任务数上限，0 表示不限制
+
+	Aging PriorityAgingConfig `mapstructure:"aging" yaml:"aging"`
+}
+
+// PriorityAgingConfig 优先级老化配置，任务在队列中每等待 interval 有效优先级提升一级，最高到 priority_levels，避免低优先级任务饿死
+type PriorityAgingConfig struct {
+	Enabled  bool              `mapstructure:"enabled" yaml:"enabled"`
+	Interval string            `mapstructure:"interval" yaml:"interval"` // 默认老化间隔
+	Levels   map[string]string `mapstructure:"levels" yaml:"levels"`     // 按任务原始优先级覆盖 interval，值为 0 时该优先级不老化
+}
+
+// IntervalFor 获取原始优先级为 priority 的任务的老化间隔，返回 0 表示不老化
+func (a PriorityAgingConfig) IntervalFor(priority int) time.Duration {
+	if !a.Enabled {
+		return 0
+	}
+	value := a.Interval
+	if v, ok := a.Levels[strconv.Itoa(priority)]; ok {
+		value = v
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < 0 {
+		return 0
+	}
+	return interval
+}
+
+// Validate 验证优先级老化配置，levels 为优先级级数
+func (a PriorityAgingConfig) Validate(levels int) error {
+	if !a.Enabled {
+		return nil
+	}
+	if d, err := time.ParseDuration(a.Interval); err != nil || d <= 0 {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 queue.aging.interval: %s", a.Interval)
+	}
+	for key, value := range a.Levels {
+		if level, err := strconv.Atoi(key); err != nil || level < 1 || level > levels {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "queue.aging.levels 的键必须是 1 到 %d 的优先级: %s", levels, key)
+		}
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 queue.aging.levels.%s: %s", key, value)
+		}
+	}
+	return nil
+}
+
+// ResourceLimits Claude Code 任务进程的资源限制，零值表示不限制
+type ResourceLimits struct {
+	Nice            int    `mapstructure:"nice" yaml:"nice" json:"nice,omitempty"`                                      // CPU 调度优先级 (-20 ~ 19)
+	MaxMemoryMB     int    `mapstructure:"max_memory_mb" yaml:"max_memory_mb" json:"maxMemoryMB,omitempty"`             // 最大内存，优先使用 cgroup，否则使用 ulimit -v
+	MaxOpenFiles    int    `mapstructure:"max_open_files" yaml:"max_open_files" json:"maxOpenFiles,omitempty"`          // 最大打开文件数
+	KillGracePeriod string `mapstructure:"kill_grace_period" yaml:"kill_grace_period" json:"killGracePeriod,omitempty"` // 超时或取消时 SIGTERM 到 SIGKILL 的等待时间
+}
+
+// Merge 使用 override 中的非零值覆盖当前限制，返回新的限制
+func (l ResourceLimits) Merge(override *ResourceLimits) ResourceLimits {
+	if override == nil {
+		return l
+	}
+	if override.Nice != 0 {
+		l.Nice = override.Nice
+	}
+	if override.MaxMemoryMB != 0 {
+		l.MaxMemoryMB = override.MaxMemoryMB
+	}
+	if override.MaxOpenFiles != 0 {
+		l.MaxOpenFiles = override.MaxOpenFiles
+	}
+	if override.KillGracePeriod != "" {
+		l.KillGracePeriod = override.KillGracePeriod
+	}
+	return l
+}
+
+// Validate 验证资源限制
+func (l ResourceLimits) Validate() error {
+	if l.Nice < -20 || l.Nice > 19 {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "nice 值必须在 -20 到 19 之间: %d", l.Nice)
+	}
+	if l.MaxMemoryMB < 0 || l.MaxOpenFiles < 0 {
+		return apperrors.New(apperrors.ErrConfigInvalid, "内存和文件数限制不能为负数")
+	}
+	if l.KillGracePeriod != "" {
+		if d, err := time.ParseDuration(l.KillGracePeriod); err != nil || d < 0 {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 kill_grace_period: %s", l.KillGracePeriod)
+		}
+	}
+	return nil
+}
+
+// TokenStorePath 获取令牌存储文件路径，未配置时使用 ~/.auto-claude-code/tokens.json
+func (a MCPAuthConfig) TokenStorePath() string {
+	if a.TokenStore != "" {
+		return a.TokenStore
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "./tokens.json"
+	}
+
+	return filepath.Join(homeDir, ".auto-claude-code", "tokens.json")
+}
+
+// Validate 验证认证配置
+func (a MCPAuthConfig) Validate() error {
+	switch a.DefaultRole {
+	case "", "viewer", "submitter", "admin":
+	default:
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 default_role: %s，支持: viewer, submitter, admin", a.DefaultRole)
+	}
+
+	switch a.Method {
+	case "none", "token":
+	case "oauth2":
+		switch a.OAuth2.Validation {
+		case "", "introspection":
+			if a.OAuth2.IntrospectionURL == "" && a.OAuth2.IssuerURL == "" {
+				return apperrors.New(apperrors.ErrConfigInvalid, "oauth2 内省验证需要配置 introspection_url 或 issuer_url")
+			}
+			if a.OAuth2.ClientID == "" {
+				return apperrors.New(apperrors.ErrConfigInvalid, "oauth2 内省验证需要配置 client_id")
+			}
+		case "jwks":
+			if a.OAuth2.JWKSURL == "" && a.OAuth2.IssuerURL == "" {
+				return apperrors.New(apperrors.ErrConfigInvalid, "oauth2 jwks 验证需要配置 jwks_url 或 issuer_url")
+			}
+		default:
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 oauth2 验证方式: %s，支持: introspection, jwks", a.OAuth2.Validation)
+		}
+		if a.OAuth2.CacheTTL != "" {
+			if d, err := time.ParseDuration(a.OAuth2.CacheTTL); err != nil || d < 0 {
+				return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 oauth2.cache_ttl: %s", a.OAuth2.CacheTTL)
+			}
+		}
+	case "jwt":
+		keys := 0
+		for _, v := range []string{a.JWT.Secret, a.JWT.PublicKeyFile, a.JWT.JWKSURL} {
+			if v != "" {
+				keys++
+			}
+		}
+		if keys != 1 {
+			return apperrors.New(apperrors.ErrConfigInvalid, "jwt 认证需要且只能配置 secret、public_key_file、jwks_url 之一")
+		}
+		for name, value := range map[string]string{"jwks_refresh": a.JWT.JWKSRefresh, "leeway": a.JWT.Leeway} {
+			if value == "" {
+				continue
+			}
+			if d, err := time.ParseDuration(value); err != nil || d < 0 {
+				return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 jwt.%s: %s", name, value)
+			}
+		}
+	default:
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的认证方式: %s，支持: none, token, jwt, oauth2", a.Method)
+	}
+	return nil
+}
+
+// ShellToolConfig run_shell_command 工具的安全策略
+type ShellToolConfig struct {
+	Enabled             bool     `mapstructure:"enabled" yaml:"enabled"`
+	Allowlist           []string `mapstructure:"allowlist" yaml:"allowlist"`                         // 允许的命令名，留空表示不限制
+	Denylist            []string `mapstructure:"denylist" yaml:"denylist"`                           // 禁止的命令正则表达式，匹配整条命令
+	AllowShellOperators bool     `mapstructure:"allow_shell_operators" yaml:"allow_shell_operators"` // 配置了允许列表时是否允许 ; | & $() 等组合命令
+	Timeout             string   `mapstructure:"timeout" yaml:"timeout"`                             // 单条命令的默认超时
+}
+
+// RateLimitConfig 请求限流配置（令牌桶），按认证身份限流，未认证时按客户端IP限流
+type RateLimitConfig struct {
+	Enabled     bool    `mapstructure:"enabled" yaml:"enabled"`
+	RPS         float64 `mapstructure:"rps" yaml:"rps"`                   // MCP 调用每秒补充的令牌数
+	Burst       int     `mapstructure:"burst" yaml:"burst"`               // MCP 调用的突发容量
+	SubmitRPS   float64 `mapstructure:"submit_rps" yaml:"submit_rps"`     // 任务提交每秒补充的令牌数
+	SubmitBurst int     `mapstructure:"submit_burst" yaml:"submit_burst"` // 任务提交的突发容量
+}
+
+// QuotaConfig 按客户端（认证身份，未认证时按客户端IP）的任务配额，本地 stdio 请求不受限制
+// 提交数和执行时间按最近 window 内累计，clients 中按身份 subject（不区分大小写）整体替换 default
+type QuotaConfig struct {
+	Enabled bool                   `mapstructure:"enabled" yaml:"enabled"`
+	Window  string                 `mapstructure:"window" yaml:"window"`
+	Default QuotaLimits            `mapstructure:"default" yaml:"default"`
+	Clients map[string]QuotaLimits `mapstructure:"clients" yaml:"clients"`
+}
+
+// QuotaLimits 单个客户端的任务配额，零值表示不限制
+type QuotaLimits struct {
+	MaxSubmitted     int    `mapstructure:"max_submitted" yaml:"max_submitted" json:"maxSubmitted,omitempty"`               // window 内最多提交的任务数
+	MaxConcurrent    int    `mapstructure:"max_concurrent" yaml:"max_concurrent" json:"maxConcurrent,omitempty"`            // 同时未结束（排队、等待或执行中）的任务数上限
+	MaxExecutionTime string `mapstructure:"max_execution_time" yaml:"max_execution_time" json:"maxExecutionTime,omitempty"` // window 内任务累计执行时间上限
+}
+
+// LimitsFor 获取客户端的配额，subject 为认证身份
+func (q QuotaConfig) LimitsFor(subject string) QuotaLimits {
+	for name, limits := range q.Clients {
+		if strings.EqualFold(name, subject) {
+			return limits
+		}
+	}
+	return q.Default
+}
+
+// Validate 验证配额配置
+func (q QuotaConfig) Validate() error {
+	if !q.Enabled {
+		return nil
+	}
+	if d, err := time.ParseDuration(q.Window); err != nil || d <= 0 {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 quota.window: %s", q.Window)
+	}
+	if err := q.Default.validate("quota.default"); err != nil {
+		return err
+	}
+	for name, limits := range q.Clients {
+		if err := limits.validate("quota.clients." + name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate 验证单个客户端的配额，prefix 为错误信息中的配置路径
+func (l QuotaLimits) validate(prefix string) error {
+	if l.MaxSubmitted < 0 || l.MaxConcurrent < 0 {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "%s 的 max_submitted 和 max_concurrent 不能为负数", prefix)
+	}
+	if l.MaxExecutionTime != "" {
+		if d, err := time.ParseDuration(l.MaxExecutionTime); err != nil || d < 0 {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 %s.max_execution_time: %s", prefix, l.MaxExecutionTime)
+		}
+	}
+	return nil
+}
+
+// AuditConfig 审计日志配置，变更类操作以 JSON Lines 追加写入审计文件
+type AuditConfig struct {
+	Enabled bool   `mapstructure:"enabled" yaml:"enabled"`
+	File    string `mapstructure:"file" yaml:"file"`
+}
+
+// TemplatesConfig 任务模板存储配置，模板以 JSON 保存在 file 中
+type TemplatesConfig struct {
+	File string `mapstructure:"file" yaml:"file"`
+}
+
+// MergeBackConfig 任务成功后提交 worktree 中的修改并合并回创建 worktree 时项目所在的分支
+// enabled 为 false 时只有请求中设置了 mergeBack 的任务才会合并
+type MergeBackConfig struct {
+	Enabled     bool   `mapstructure:"enabled" yaml:"enabled"`
+	Strategy    string `mapstructure:"strategy" yaml:"strategy"`         // merge（合并提交）或 rebase（变基后快进）
+	DryRun      bool   `mapstructure:"dry_run" yaml:"dry_run"`           // 只在任务分支提交并检测冲突，不修改项目分支
+	AuthorName  string `mapstructure:"author_name" yaml:"author_name"`   // 任务分支提交的作者，留空使用 git 配置
+	AuthorEmail string `mapstructure:"author_email" yaml:"author_email"` // 任务分支提交的作者邮箱，留空使用 git 配置
+
+	CommitterName  string `mapstructure:"committer_name" yaml:"committer_name"`   // 提交者，留空与作者相同
+	CommitterEmail string `mapstructure:"committer_email" yaml:"committer_email"` // 提交者邮箱，留空与作者相同
+
+	// SigningKey 对提交签名的密钥，openpgp 为 GPG 密钥ID，ssh 为公钥文件路径或 "key::" 开头的公钥
+	// 配置了作者、提交者或签名密钥时，未配置 signing_key 的提交不签名，不受 git 配置中 commit.gpgsign 的影响
+	SigningKey     string `mapstructure:"signing_key" yaml:"signing_key"`
+	SigningFormat  string `mapstructure:"signing_format" yaml:"signing_format"`   // openpgp（默认）、ssh 或 x509
+	SigningProgram string `mapstructure:"signing_program" yaml:"signing_program"` // 签名程序，留空使用 gpg、ssh-keygen 或 gpgsm
+}
+
+// 提交签名的格式，与 git 的 gpg.format 相同
+const (
+	SigningFormatOpenPGP = "openpgp"
+	SigningFormatSSH     = "ssh"
+	SigningFormatX509    = "x509"
+)
+
+// HasIdentity 是否配置了提交的身份或签名，未配置时使用 git 配置
+func (m MergeBackConfig) HasIdentity() bool {
+	return m.AuthorName != "" || m.AuthorEmail != "" || m.CommitterName != "" || m.CommitterEmail != "" || m.SigningKey != ""
+}
+
+// Validate 验证合并回项目的配置
+func (m MergeBackConfig) Validate() error {
+	switch m.Strategy {
+	case "", "merge", "rebase":
+	default:
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 merge_back.strategy: %s (可选: merge, rebase)", m.Strategy)
+	}
+	switch m.SigningFormat {
+	case "", SigningFormatOpenPGP, SigningFormatSSH, SigningFormatX509:
+	default:
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 merge_back.signing_format: %s (可选: openpgp, ssh, x509)", m.SigningFormat)
+	}
+	if m.SigningKey == "" && (m.SigningFormat != "" || m.SigningProgram != "") {
+		return apperrors.New(apperrors.ErrConfigInvalid, "merge_back 设置了 signing_format 或 signing_program 但没有 signing_key")
+	}
+	for _, value := range []string{m.AuthorName, m.AuthorEmail, m.CommitterName, m.CommitterEmail, m.SigningKey} {
+		if strings.ContainsAny(value, "\n\x00") {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "merge_back 的身份和签名配置不能包含换行: %q", value)
+		}
+	}
+	return nil
+}
+
+// PullRequestProviders 支持创建拉取请求的代码托管平台
+var PullRequestProviders = []string{"github", "gitlab"}
+
+// repositoryRegex 代码托管平台上的仓库名称，如 owner/repo，GitLab 可以包含子组
+var repositoryRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+(/[A-Za-z0-9_.-]+)+$`)
+
+// PullRequestConfig 任务成功后将任务分支推送到项目的远程仓库并创建拉取请求（GitLab 为合并请求），只对 projects 中配置的项目生效
+type PullRequestConfig struct {
+	TaskURL  string                     `mapstructure:"task_url" yaml:"task_url"` // 拉取请求中链接回任务的地址，{id} 替换为任务ID，留空时只写任务ID
+	Timeout  string                     `mapstructure:"timeout" yaml:"timeout"`   // 调用托管平台 API 的超时，默认 30s
+	Projects []PullRequestProjectConfig `mapstructure:"projects" yaml:"projects"`
+}
+
+// PullRequestProjectConfig 单个项目的拉取请求配置，path 与任务的 projectPath 匹配，不区分大小写和路径分隔符
+type PullRequestProjectConfig struct {
+	Path       string `mapstructure:"path" yaml:"path"`
+	Provider   string `mapstructure:"provider" yaml:"provider"`       // 代码托管平台：github（默认）或 gitlab
+	Repository string `mapstructure:"repository" yaml:"repository"`   // 仓库名称，如 owner/repo，GitLab 可以为 group/subgroup/repo
+	Token      string `mapstructure:"token" yaml:"token"`             // 推送任务分支和调用 API 使用的访问令牌
+	APIURL     string `mapstructure:"api_url" yaml:"api_url"`         // 留空使用 https://api.github.com 或 https://gitlab.com/api/v4，自建实例填写其 API 地址
+	Remote     string `mapstructure:"remote" yaml:"remote"`           // 推送任务分支的 git 远程，默认 origin
+	BaseBranch string `mapstructure:"base_branch" yaml:"base_branch"` // 拉取请求的目标分支，留空使用创建工作树时项目所在的分支
+	Draft      bool   `mapstructure:"draft" yaml:"draft"`             // 创建为草稿
+}
+
+// Validate 验证拉取请求配置
+func (p PullRequestConfig) Validate() error {
+	if p.TaskURL != "" && !strings.HasPrefix(p.TaskURL, "http://") && !strings.HasPrefix(p.TaskURL, "https://") {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "pull_requests.task_url 必须以 http:// 或 https:// 开头: %s", p.TaskURL)
+	}
+	if p.Timeout != "" {
+		if d, err := time.ParseDuration(p.Timeout); err != nil || d <= 0 {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 pull_requests.timeout: %s", p.Timeout)
+		}
+	}
+
+	paths := make(map[string]bool, len(p.Projects))
+	for _, project := range p.Projects {
+		if err := project.Validate(); err != nil {
+			return err
+		}
+		key := strings.TrimRight(strings.ToLower(strings.ReplaceAll(project.Path, "\\", "/")), "/")
+		if paths[key] {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "pull_requests 中的项目重复: %s", project.Path)
+		}
+		paths[key] = true
+	}
+	return nil
+}
+
+// Validate 验证单个项目的拉取请求配置
+func (p PullRequestProjectConfig) Validate() error {
+	if p.Path == "" {
+		return apperrors.New(apperrors.ErrConfigInvalid, "pull_requests 项目的 path 不能为空")
+	}
+	if p.Provider != "" && !contains(PullRequestProviders, p.Provider) {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "项目 %s 的 provider 无效: %s，支持: %s", p.Path, p.Provider, strings.Join(PullRequestProviders, ", "))
+	}
+	if !repositoryRegex.MatchString(p.Repository) || (p.Provider != "gitlab" && strings.Count(p.Repository, "/") != 1) {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "项目 %s 的 repository 无效: %q，格式为 owner/repo", p.Path, p.Repository)
+	}
+	if p.Token == "" {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "项目 %s 的 token 不能为空", p.Path)
+	}
+	if p.APIURL != "" && !strings.HasPrefix(p.APIURL, "http://") && !strings.HasPrefix(p.APIURL, "https://") {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "项目 %s 的 api_url 必须以 http:// 或 https:// 开头: %s", p.Path, p.APIURL)
+	}
+	return nil
+}
+
+// BranchTemplateVariables 任务分支名模板支持的变量
+var BranchTemplateVariables = []string{"task_id", "worktree_id", "timestamp", "date", "project", "description"}
+
+// BranchTemplatePlaceholder 任务分支名模板中的变量，{name} 或 {slug(name)}
+var BranchTemplatePlaceholder = regexp.MustCompile(`\{(?:slug\(([a-z_]+)\)|([a-z_]+))\}`)
+
+// branchTemplateInvalid 模板中变量以外的部分不能出现的分支名字符
+var branchTemplateInvalid = regexp.MustCompile(`[\x00-\x20\x7f~^:?*\[\\{}]|\.\.|@\{|//`)
+
+// ValidateBranchTemplate 验证任务分支名模板只使用支持的变量，且其余部分是合法的分支名
+func ValidateBranchTemplate(template string) error {
+	for _, match := range BranchTemplatePlaceholder.FindAllStringSubmatch(template, -1) {
+		name := match[1] + match[2]
+		if !contains(BranchTemplateVariables, name) {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "branch_template 中的变量无效: %s，支持: %s", match[0], strings.Join(BranchTemplateVariables, ", "))
+		}
+	}
+	literal := BranchTemplatePlaceholder.ReplaceAllString(template, "x")
+	if branchTemplateInvalid.MatchString(literal) || strings.HasPrefix(literal, "/") || strings.HasPrefix(literal, "-") ||
+		strings.HasSuffix(literal, "/") || strings.HasSuffix(literal, ".") || strings.HasSuffix(literal, ".lock") {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "branch_template 不是合法的分支名: %s", template)
+	}
+	return nil
+}
+
+// StorageConfig 任务持久化存储配置
+// driver 为 "memory" 时任务只保存在内存中，服务器重启后丢失；为 "file" 时任务状态、提交请求和输出保存在 path 目录下；
+// 为 "bbolt" 时全部保存在 path 指定的 BoltDB 数据库文件中
+// requeue 决定重启后哪些未结束的任务重新排队："none" 全部标记为中断，"pending" 只重排等待中的任务，"all" 同时重排执行中被中断的任务
+type StorageConfig struct {
+	Driver  string `mapstructure:"driver" yaml:"driver"`
+	Path    string `mapstructure:"path" yaml:"path"`
+	Requeue string `mapstructure:"requeue" yaml:"requeue"`
+}
+
+// DataDir 获取 file 驱动的数据目录，未配置时使用 ~/.auto-claude-code/tasks
+func (s StorageConfig) DataDir() string {
+	if s.Path != "" {
+		return s.Path
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "./tasks"
+	}
+
+	return filepath.Join(homeDir, ".auto-claude-code", "tasks")
+}
+
+// DBPath 获取 bbolt 驱动的数据库文件，未配置时使用 ~/.auto-claude-code/tasks.db
+func (s StorageConfig) DBPath() string {
+	if s.Path != "" {
+		return s.Path
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "./tasks.db"
+	}
+
+	return filepath.Join(homeDir, ".auto-claude-code", "tasks.db")
+}
+
+// Validate 验证存储配置
+func (s StorageConfig) Validate() error {
+	switch s.Driver {
+	case "", "memory", "file", "bbolt":
+	default:
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的存储驱动: %s，支持: memory, file, bbolt", s.Driver)
+	}
+
+	switch s.Requeue {
+	case "", "none", "pending", "all":
+		return nil
+	default:
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的重启重排策略: %s，支持: none, pending, all", s.Requeue)
+	}
+}
+
+// WorktreeCleanupConfig 空闲worktree的清理策略，固定的worktree始终保留
+type WorktreeCleanupConfig struct {
+	IdleTTL        string `mapstructure:"idle_ttl" yaml:"idle_ttl"`               // 空闲超过该时间后由定期清理删除，"0" 表示不按空闲时间清理
+	Eviction       string `mapstructure:"eviction" yaml:"eviction"`               // 达到 max_worktrees 或磁盘配额时：lru 删除最久未使用的空闲worktree，none 不删除未过期的
+	KeepConflicted bool   `mapstructure:"keep_conflicted" yaml:"keep_conflicted"` // 保留合并冲突的worktree
+}
+
+// Validate 验证worktree清理策略
+func (c WorktreeCleanupConfig) Validate() error {
+	if c.IdleTTL != "" {
+		if ttl, err := time.ParseDuration(c.IdleTTL); err != nil || ttl < 0 {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 worktree_cleanup.idle_ttl: %s", c.IdleTTL)
+		}
+	}
+	switch c.Eviction {
+	case "", "lru", "none":
+	default:
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 worktree_cleanup.eviction: %s (可选: lru, none)", c.Eviction)
+	}
+	return nil
+}
+
+// WorktreeDiskConfig worktree 基础目录的磁盘用量统计和配额
+// 用量按 scan_interval 缓存；创建 worktree 时用量达到 quota_bytes 会先删除所有空闲的 worktree，
+// 仍然超出时 on_exceeded 为 "refuse" 直接拒绝，为 "wait" 时最多等待 wait_timeout 直到用量降到配额以下
+type WorktreeDiskConfig struct {
+	QuotaBytes   int64  `mapstructure:"quota_bytes" yaml:"quota_bytes"` // 0 表示不限制
+	ScanInterval string `mapstructure:"scan_interval" yaml:"scan_interval"`
+	OnExceeded   string `mapstructure:"on_exceeded" yaml:"on_exceeded"`
+	WaitTimeout  string `mapstructure:"wait_timeout" yaml:"wait_timeout"`
+}
+
+// Validate 验证 worktree 磁盘配额配置
+func (d WorktreeDiskConfig) Validate() error {
+	if d.QuotaBytes < 0 {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "worktree_disk.quota_bytes 不能为负数: %d", d.QuotaBytes)
+	}
+	switch d.OnExceeded {
+	case "", "refuse", "wait":
+	default:
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 worktree_disk.on_exceeded: %s (可选: refuse, wait)", d.OnExceeded)
+	}
+	for name, value := range map[string]string{"scan_interval": d.ScanInterval, "wait_timeout": d.WaitTimeout} {
+		if value == "" {
+			continue
+		}
+		if duration, err := time.ParseDuration(value); err != nil || duration <= 0 {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 worktree_disk.%s: %s", name, value)
+		}
+	}
+	return nil
+}
+
+// WorktreeSubmoduleConfig 创建 Git worktree 时对含 .gitmodules 的项目执行 git submodule update --init
+type WorktreeSubmoduleConfig struct {
+	Enabled   bool `mapstructure:"enabled" yaml:"enabled"`
+	Recursive bool `mapstructure:"recursive" yaml:"recursive"` // 同时初始化嵌套的子模块
+	Depth     int  `mapstructure:"depth" yaml:"depth"`         // 浅克隆子模块的提交数，0 表示完整历史
+	Jobs      int  `mapstructure:"jobs" yaml:"jobs"`           // 并行拉取的子模块数，0 使用 git 的默认值
+}
+
+// Validate 验证子模块配置
+func (s WorktreeSubmoduleConfig) Validate() error {
+	if s.Depth < 0 {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "worktree_submodules.depth 不能为负数: %d", s.Depth)
+	}
+	if s.Jobs < 0 {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "worktree_submodules.jobs 不能为负数: %d", s.Jobs)
+	}
+	return nil
+}
+
+// WorktreeLFSConfig 创建 Git worktree 时对使用 Git LFS 的项目执行 git lfs install 和 git lfs pull
+// include 和 exclude 为 git lfs pull 的路径模式，项目的 lfs_include 和 lfs_exclude 非空时覆盖它们
+type WorktreeLFSConfig struct {
+	Enabled bool     `mapstructure:"enabled" yaml:"enabled"`
+	Include []string `mapstructure:"include" yaml:"include"`
+	Exclude []string `mapstructure:"exclude" yaml:"exclude"`
+}
+
+// Validate 验证 Git LFS 配置
+func (l WorktreeLFSConfig) Validate() error {
+	if pattern, ok := invalidLFSPattern(l.Include, l.Exclude); ok {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "worktree_lfs 的路径模式无效: %q", pattern)
+	}
+	return nil
+}
+
+// invalidLFSPattern 返回第一个无效的 LFS 路径模式，git lfs 以逗号分隔多个模式，单个模式不能为空或包含逗号
+func invalidLFSPattern(lists ...[]string) (string, bool) {
+	for _, patterns := range lists {
+		for _, pattern := range patterns {
+			if strings.TrimSpace(pattern) == "" || strings.ContainsAny(pattern, ",\n\x00") {
+				return pattern, true
+			}
+		}
+	}
+	return "", false
+}
+
+// 上下文文件的写入方式
+const (
+	ContextModeAppend  = "append"  // 追加到已有文件末尾，文件不存在时创建
+	ContextModeReplace = "replace" // 覆盖已有文件
+	ContextModeCreate  = "create"  // 只在文件不存在时创建
+)
+
+// WorktreeContextConfig 创建worktree后注入的上下文文件，对所有项目生效，项目的 context_files 追加在其后
+type WorktreeContextConfig struct {
+	Files []WorktreeContextFile `mapstructure:"files" yaml:"files"`
+}
+
+// Validate 验证上下文文件配置
+func (c WorktreeContextConfig) Validate() error {
+	for _, file := range c.Files {
+		if err := file.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WorktreeContextFile 一个上下文文件，template 中的 {{变量}} 替换为任务和worktree的信息后写入worktree的 target
+type WorktreeContextFile struct {
+	Template string `mapstructure:"template" yaml:"template"` // 服务器上的模板文件
+	Target   string `mapstructure:"target" yaml:"target"`     // worktree中的相对路径，默认 CLAUDE.md
+	Mode     string `mapstructure:"mode" yaml:"mode"`         // append（默认）、replace 或 create
+}
+
+// Validate 验证上下文文件的模板、目标路径和写入方式
+func (f WorktreeContextFile) Validate() error {
+	if f.Template == "" {
+		return apperrors.New(apperrors.ErrConfigInvalid, "上下文文件的 template 不能为空")
+	}
+	if target := filepath.ToSlash(f.Target); target != "" {
+		if path.IsAbs(target) || filepath.IsAbs(f.Target) || strings.HasPrefix(path.Clean(target), "..") || path.Clean(target) == "." {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "上下文文件的 target 必须是worktree中的相对路径: %s", f.Target)
+		}
+	}
+	switch f.Mode {
+	case "", ContextModeAppend, ContextModeReplace, ContextModeCreate:
+	default:
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "上下文文件的 mode 只能为 append、replace 或 create: %s", f.Mode)
+	}
+	return nil
+}
+
+// worktree 的存放位置
+const (
+	WorktreeStorageHost   = "host"   // 存放在服务器本地的 worktree_base_dir
+	WorktreeStorageDistro = "distro" // 存放在 WSL 发行版的文件系统 (ext4) 中
+)
+
+// WorktreeStorageConfig worktree 的存放位置
+// distro 模式下worktree位于发行版的文件系统中，服务器经 \\wsl$ 共享访问，Claude Code 和创建后命令直接在发行版中的worktree运行
+type WorktreeStorageConfig struct {
+	Mode   string `mapstructure:"mode" yaml:"mode"`     // host 或 distro，默认 host
+	Distro string `mapstructure:"distro" yaml:"distro"` // distro 模式使用的发行版，默认为默认发行版
+	Dir    string `mapstructure:"dir" yaml:"dir"`       // distro 模式下发行版中的目录，~ 开头时相对发行版用户的主目录
+}
+
+// Validate 验证worktree存放位置配置
+func (s WorktreeStorageConfig) Validate() error {
+	switch s.Mode {
+	case "", WorktreeStorageHost, WorktreeStorageDistro:
+	default:
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "worktree_storage.mode 只能为 host 或 distro: %s", s.Mode)
+	}
+	if s.Dir != "" && s.Dir != "~" && !strings.HasPrefix(s.Dir, "/") && !strings.HasPrefix(s.Dir, "~/") {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "worktree_storage.dir 必须为绝对路径或以 ~/ 开头: %s", s.Dir)
+	}
+	return nil
+}
+
+// WorktreeReconcileConfig 定期将跟踪的worktree与目录和源仓库的 git worktree 记录对账
+type WorktreeReconcileConfig struct {
+	Enabled  bool   `mapstructure:"enabled" yaml:"enabled"`
+	Interval string `mapstructure:"interval" yaml:"interval"` // 对账间隔，启动时先对账一次
+}
+
+// Validate 验证worktree对账配置
+func (r WorktreeReconcileConfig) Validate() error {
+	if r.Interval == "" {
+		return nil
+	}
+	if interval, err := time.ParseDuration(r.Interval); err != nil || interval <= 0 {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 worktree_reconcile.interval: %s", r.Interval)
+	}
+	return nil
+}
+
+// WorktreeMirrorConfig 按项目维护的裸仓库镜像，定期从项目拉取
+type WorktreeMirrorConfig struct {
+	Enabled       bool   `mapstructure:"enabled" yaml:"enabled"`
+	Dir           string `mapstructure:"dir" yaml:"dir"`                       // 镜像目录，默认为 worktree_base_dir 下的 .mirrors
+	FetchInterval string `mapstructure:"fetch_interval" yaml:"fetch_interval"` // 后台拉取间隔，创建worktree时始终先拉取基准分支
+}
+
+// Validate 验证裸仓库镜像配置
+func (m WorktreeMirrorConfig) Validate() error {
+	if m.FetchInterval == "" {
+		return nil
+	}
+	if interval, err := time.ParseDuration(m.FetchInterval); err != nil || interval <= 0 {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 worktree_mirror.fetch_interval: %s", m.FetchInterval)
+	}
+	return nil
+}
+
+// WorktreeCopyConfig 非 Git 项目复制到 worktree 的配置
+// exclude 为 .gitignore 风格的模式：不含 / 的模式匹配任意层的文件名，以 / 开头或中间含 / 的相对项目根目录，/ 结尾只匹配目录，! 重新包含
+type WorktreeCopyConfig struct {
+	Exclude []string `mapstructure:"exclude" yaml:"exclude"`
+	Workers int      `mapstructure:"workers" yaml:"workers"` // 并行复制的文件数，默认 4
+}
+
+// Validate 验证非 Git 项目的复制配置
+func (c WorktreeCopyConfig) Validate() error {
+	if c.Workers < 0 {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "worktree_copy.workers 不能为负数: %d", c.Workers)
+	}
+	if pattern, ok := invalidIgnorePattern(c.Exclude); ok {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "worktree_copy.exclude 模式无效: %q", pattern)
+	}
+	return nil
+}
+
+// invalidIgnorePattern 返回第一个不是合法 glob 的 .gitignore 风格模式
+func invalidIgnorePattern(patterns []string) (string, bool) {
+	for _, pattern := range patterns {
+		glob := strings.TrimRight(strings.TrimPrefix(filepath.ToSlash(strings.TrimSpace(pattern)), "!"), "/")
+		for _, segment := range strings.Split(glob, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return pattern, true
+			}
+		}
+	}
+	return "", false
+}
+
+// WorktreeProjectConfig 单个项目的 worktree 创建配置，path 与任务的 projectPath 匹配，不区分大小写和路径分隔符
+type WorktreeProjectConfig struct {
+	Path           string   `mapstructure:"path" yaml:"path"`
+	SparseCheckout []string `mapstructure:"sparse_checkout" yaml:"sparse_checkout"` // 只检出匹配的文件，cone 模式下为目录，否则为 .gitignore 风格的模式
+	SparseCone     bool     `mapstructure:"sparse_cone" yaml:"sparse_cone"`         // 以 cone 模式检出目录，比任意模式更快
+	CopyExclude    []string `mapstructure:"copy_exclude" yaml:"copy_exclude"`       // 非 Git 项目复制时额外排除的 .gitignore 风格模式
+	LFSInclude     []string `mapstructure:"lfs_include" yaml:"lfs_include"`         // 只拉取匹配的 LFS 文件，覆盖 worktree_lfs.include
+	LFSExclude     []string `mapstructure:"lfs_exclude" yaml:"lfs_exclude"`         // 不拉取匹配的 LFS 文件，覆盖 worktree_lfs.exclude
+
+	// PostCreate 创建worktree后、启动 Claude Code 前在 WSL 中依次运行的 shell 命令，工作目录为worktree
+	PostCreate        []string `mapstructure:"post_create" yaml:"post_create"`
+	PostCreateTimeout string   `mapstructure:"post_create_timeout" yaml:"post_create_timeout"` // 每条命令的超时时间，默认 10m
+
+	// ContextFiles 追加在 worktree_context.files 之后注入的上下文文件
+	ContextFiles []WorktreeContextFile `mapstructure:"context_files" yaml:"context_files"`
+}
+
+// Validate 验证单个项目的 worktree 创建配置
+func (w WorktreeProjectConfig) Validate() error {
+	if w.Path == "" {
+		return apperrors.New(apperrors.ErrConfigInvalid, "worktree_projects 项目的 path 不能为空")
+	}
+	for _, pattern := range w.SparseCheckout {
+		if strings.TrimSpace(pattern) == "" || strings.ContainsAny(pattern, "\n\x00") {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "项目 %s 的 sparse_checkout 模式无效: %q", w.Path, pattern)
+		}
+	}
+	if pattern, ok := invalidIgnorePattern(w.CopyExclude); ok {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "项目 %s 的 copy_exclude 模式无效: %q", w.Path, pattern)
+	}
+	if pattern, ok := invalidLFSPattern(w.LFSInclude, w.LFSExclude); ok {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "项目 %s 的 LFS 路径模式无效: %q", w.Path, pattern)
+	}
+	if w.SparseCone && len(w.SparseCheckout) == 0 {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "项目 %s 设置了 sparse_cone 但没有 sparse_checkout", w.Path)
+	}
+	for _, command := range w.PostCreate {
+		if strings.TrimSpace(command) == "" {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "项目 %s 的 post_create 命令不能为空", w.Path)
+		}
+	}
+	if w.PostCreateTimeout != "" {
+		if timeout, err := time.ParseDuration(w.PostCreateTimeout); err != nil || timeout <= 0 {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "项目 %s 的 post_create_timeout 无效: %s", w.Path, w.PostCreateTimeout)
+		}
+	}
+	for _, file := range w.ContextFiles {
+		if err := file.Validate(); err != nil {
+			return apperrors.Wrapf(err, apperrors.ErrConfigInvalid, "项目 %s 的 context_files 无效", w.Path)
+		}
+	}
+	return nil
+}
+
+// TaskOutputConfig 任务输出配置
+// 启用时每个任务的 stdout 和 stderr 分别写入 dir 下的 <任务ID>.stdout.log 和 <任务ID>.stderr.log，每个文件最多 max_bytes 字节
+// 内存中捕获并随任务结果保存的输出最多 max_capture_bytes 字节，不受 enabled 影响；超过上限时都保留开头和结尾各一半
+type TaskOutputConfig struct {
+	Enabled         bool   `mapstructure:"enabled" yaml:"enabled"`
+	Dir             string `mapstructure:"dir" yaml:"dir"`
+	MaxBytes        int64  `mapstructure:"max_bytes" yaml:"max_bytes"`
+	MaxCaptureBytes int64  `mapstructure:"max_capture_bytes" yaml:"max_capture_bytes"`
+}
+
+// TaskProgressConfig 任务进度估算配置
+// 启用 stream_json 时以 --print --output-format stream-json 运行 Claude Code，根据输出的轮次、工具调用和 token 用量估算进度
+type TaskProgressConfig struct {
+	StreamJSON    bool `mapstructure:"stream_json" yaml:"stream_json"`
+	ExpectedTurns int  `mapstructure:"expected_turns" yaml:"expected_turns"` // 任务未指定 --max-turns 时预计的轮次，进度在此轮次时过半
+}
+
+// LogDir 获取输出日志目录，未配置时使用 ~/.auto-claude-code/output
+func (o TaskOutputConfig) LogDir() string {
+	if o.Dir != "" {
+		return o.Dir
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "./output"
+	}
+
+	return filepath.Join(homeDir, ".auto-claude-code", "output")
+}
+
+// RetentionConfig 已结束任务的保留策略，同时作用于内存中的任务和持久化存储
+// 结束超过 max_age 的任务被清理，失败和中断的任务按 failed_max_age 保留（留空同 max_age）；
+// max_count 大于 0 时只保留最近结束的 max_count 个任务；启用 archive 时清理前先追加写入 archive_dir 下按月分割的 JSON Lines 文件，
+// 同时配置 archive_interval 时按该间隔归档新结束的任务，不必等到任务被清理
+type RetentionConfig struct {
+	MaxAge          string `mapstructure:"max_age" yaml:"max_age"`
+	FailedMaxAge    string `mapstructure:"failed_max_age" yaml:"failed_max_age"`
+	MaxCount        int    `mapstructure:"max_count" yaml:"max_count"`
+	CheckInterval   string `mapstructure:"check_interval" yaml:"check_interval"`
+	Archive         bool   `mapstructure:"archive" yaml:"archive"`
+	ArchiveDir      string `mapstructure:"archive_dir" yaml:"archive_dir"`
+	ArchiveOutput   bool   `mapstructure:"archive_output" yaml:"archive_output"`     // 归档时包含任务输出
+	ArchiveInterval string `mapstructure:"archive_interval" yaml:"archive_interval"` // 留空只在清理时归档
+}
+
+// ArchivePath 获取归档目录，未配置时使用 ~/.auto-claude-code/archive
+func (r RetentionConfig) ArchivePath() string {
+	if r.ArchiveDir != "" {
+		return r.ArchiveDir
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "./archive"
+	}
+
+	return filepath.Join(homeDir, ".auto-claude-code", "archive")
+}
+
+// Validate 验证保留策略配置
+func (r RetentionConfig) Validate() error {
+	for _, field := range []struct {
+		name, value string
+		optional    bool
+	}{
+		{"max_age", r.MaxAge, false},
+		{"failed_max_age", r.FailedMaxAge, true},
+		{"check_interval", r.CheckInterval, false},
+		{"archive_interval", r.ArchiveInterval, true},
+	} {
+		if field.value == "" && field.optional {
+			continue
+		}
+		if d, err := time.ParseDuration(field.value); err != nil || d <= 0 {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 retention.%s: %s", field.name, field.value)
+		}
+	}
+	if r.MaxCount < 0 {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "retention.max_count 不能为负数: %d", r.MaxCount)
+	}
+	return nil
+}
+
+// ArtifactsConfig 任务产物收集配置
+// 启用时 Claude Code 结束后从工作树收集 diff、修改的文件列表和匹配 patterns 的文件，保存在 dir/<任务ID> 下
+type ArtifactsConfig struct {
+	Enabled      bool     `mapstructure:"enabled" yaml:"enabled"`
+	Dir          string   `mapstructure:"dir" yaml:"dir"`
+	Patterns     []string `mapstructure:"patterns" yaml:"patterns"`             // 相对工作树根目录的 glob，** 匹配任意层目录
+	MaxFileBytes int64    `mapstructure:"max_file_bytes" yaml:"max_file_bytes"` // 超过该大小的匹配文件被跳过，0 表示不限制
+	MaxFiles     int      `mapstructure:"max_files" yaml:"max_files"`           // 每个任务最多收集的匹配文件数，0 表示不限制
+}
+
+// ArtifactPath 获取产物目录，未配置时使用 ~/.auto-claude-code/artifacts
+func (a ArtifactsConfig) ArtifactPath() string {
+	if a.Dir != "" {
+		return a.Dir
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "./artifacts"
+	}
+
+	return filepath.Join(homeDir, ".auto-claude-code", "artifacts")
+}
+
+// Validate 验证产物收集配置
+func (a ArtifactsConfig) Validate() error {
+	for _, pattern := range a.Patterns {
+		if pattern == "" || path.IsAbs(pattern) || filepath.IsAbs(pattern) {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "artifacts.patterns 必须是相对工作树的路径: %q", pattern)
+		}
+		if _, err := path.Match(filepath.ToSlash(pattern), ""); err != nil {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 artifacts.patterns: %q", pattern)
+		}
+	}
+	if a.MaxFileBytes < 0 {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "artifacts.max_file_bytes 不能为负数: %d", a.MaxFileBytes)
+	}
+	if a.MaxFiles < 0 {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "artifacts.max_files 不能为负数: %d", a.MaxFiles)
+	}
+	return nil
+}
+
+// distroTagRegex 发行版名称和标签允许的字符
+var distroTagRegex = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// DistroConfig 发行版池中的 WSL 发行版，tags 为其提供的能力，如 gpu、node20
+// 未指定发行版的任务分派到具有全部所需标签、执行中任务最少的发行版
+type DistroConfig struct {
+	Name string   `mapstructure:"name" yaml:"name"`
+	Tags []string `mapstructure:"tags" yaml:"tags"`
+}
+
+// Validate 验证发行版配置
+func (d DistroConfig) Validate() error {
+	if !distroTagRegex.MatchString(d.Name) {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "无效的发行版名称: %q", d.Name)
+	}
+	for _, tag := range d.Tags {
+		if !distroTagRegex.MatchString(tag) {
+			return apperrors.Newf(apperrors.ErrConfigInvalid, "发行版 %s 的标签无效: %q", d.Name, tag)
+		}
+	}
+	return nil
+}
+
+// WebhookEvents Webhook 可订阅的任务事件
+var WebhookEvents = []string{"task.created", "task.started", "task.progress", "task.completed", "task.failed", "task.cancelled", "task.timeout"}
+
+// WebhookConfig 出站 Webhook 目标，任务事件以 JSON POST 到 url
+// 配置了 secret 时请求携带 X-Auto-Claude-Signature: sha256=<HMAC-SHA256(secret, body) 的十六进制>
+type WebhookConfig struct {
+	URL          string   `mapstructure:"url" yaml:"url"`
+	Secret       string   `mapstructure:"secret" yaml:"secret"`
+	Events       []string `mapstructure:"events" yaml:"events"`               // 订阅的事件，留空表示 task.completed、task.failed 和 task.timeout
+	Timeout      string   `mapstructure:"timeout" yaml:"timeout"`             // 单次请求超时，默认 10s
+	MaxRetries   int      `mapstructure:"max_retries" yaml:"max_retries"`     // 失败后的最大重试次数，网络错误、5xx 和 429 才会重试
+	RetryBackoff string   `mapstructure:"retry_backoff" yaml:"retry_backoff"` // 首次重试的等待时间，之后每次翻倍，默认 5s
+}
+
+// Validate 验证 Webhook 配置
+func (w WebhookConfig) Validate() error {
+	if !strings.HasPrefix(w.URL, "http://") && !strings.HasPrefix(w.URL, "https://") {
+		return &fieldError{"url", apperrors.Newf(apperrors.ErrConfigInvalid, "webhook url 必须以 http:// 或 https:// 开头: %s", w.URL)}
+	}
+	for i, event := range w.Events {
+		if !contains(WebhookEvents, event) {
+			return &fieldError{fmt.Sprintf("events[%d]", i), apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 webhook 事件: %s，支持: %s", event, strings.Join(WebhookEvents, ", "))}
+		}
+	}
+	if w.Timeout != "" {
+		if d, err := time.ParseDuration(w.Timeout); err != nil || d <= 0 {
+			return &fieldError{"timeout", apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 webhook timeout: %s", w.Timeout)}
+		}
+	}
+	if w.RetryBackoff != "" {
+		if d, err := time.ParseDuration(w.RetryBackoff); err != nil || d <= 0 {
+			return &fieldError{"retry_backoff", apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 webhook retry_backoff: %s", w.RetryBackoff)}
+		}
+	}
+	if w.MaxRetries < 0 {
+		return &fieldError{"max_retries", apperrors.Newf(apperrors.ErrConfigInvalid, "webhook max_retries 不能为负数: %d", w.MaxRetries)}
+	}
+	return nil
+}
+
+// FilePath 获取审计文件路径，未配置时使用 ~/.auto-claude-code/audit.log
+func (a AuditConfig) FilePath() string {
+	if a.File != "" {
+		return a.File
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "./audit.log"
+	}
+
+	return filepath.Join(homeDir, ".auto-claude-code", "audit.log")
+}
+
+// FilePath 获取任务模板文件路径，未配置时使用 ~/.auto-claude-code/templates.json
+func (t TemplatesConfig) FilePath() string {
+	if t.File != "" {
+		return t.File
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "./templates.json"
+	}
+
+	return filepath.Join(homeDir, ".auto-claude-code", "templates.json")
 }
 
 // MCPMonitoringConfig MCP 监控配置
 type MCPMonitoringConfig struct {
-	Enabled      bool   `mapstructure:"enabled" yaml:"enabled"`
-	MetricsPath  string `mapstructure:"metrics_path" yaml:"metrics_path"`
-	HealthPath   string `mapstructure:"health_path" yaml:"health_path"`
-	LogRequests  bool   `mapstructure:"log_requests" yaml:"log_requests"`
-	LogResponses bool   `mapstructure:"log_responses" yaml:"log_responses"`
+	Enabled       bool   `mapstructure:"enabled" yaml:"enabled"`
+	MetricsPath   string `mapstructure:"metrics_path" yaml:"metrics_path"`
+	HealthPath    string `mapstructure:"health_path" yaml:"health_path"`       // 兼容旧版本，等同于就绪探针
+	LivenessPath  string `mapstructure:"liveness_path" yaml:"liveness_path"`   // 存活探针，进程能响应即返回 200
+	ReadinessPath string `mapstructure:"readiness_path" yaml:"readiness_path"` // 就绪探针，检查各组件是否可接收任务
+	LogRequests   bool   `mapstructure:"log_requests" yaml:"log_requests"`
+	LogResponses  bool   `mapstructure:"log_responses" yaml:"log_responses"`
 }
 
 // MCPHTTPConfig MCP HTTP传输配置
 type MCPHTTPConfig struct {
-	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	Enabled      bool  `mapstructure:"enabled" yaml:"enabled"`
+	Compression  bool  `mapstructure:"compression" yaml:"compression"`       // 客户端支持时以 gzip 压缩响应
+	MaxBodyBytes int64 `mapstructure:"max_body_bytes" yaml:"max_body_bytes"` // 请求体最大字节数，0 表示不限制
+	// 同源之外允许发起 WebSocket 连接的浏览器页面来源，如 https://console.example.com
+	AllowedOrigins []string `mapstructure:"allowed_origins" yaml:"allowed_origins"`
 }
 
 // MCPStdioConfig MCP stdio传输配置
 type MCPStdioConfig struct {
-	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	Enabled bool   `mapstructure:"enabled" yaml:"enabled"`
+	Framing string `mapstructure:"framing" yaml:"framing"` // "auto", "newline", "content-length"
 	// Reader和Writer在运行时设置，不序列化
 	Reader io.Reader `mapstructure:"-" yaml:"-"`
 	Writer io.Writer `mapstructure:"-" yaml:"-"`
 }
 
+// MCPSSEConfig MCP SSE传输配置，与HTTP传输共用监听地址
+type MCPSSEConfig struct {
+	Enabled     bool   `mapstructure:"enabled" yaml:"enabled"`
+	Path        string `mapstructure:"path" yaml:"path"`                 // 事件流端点
+	MessagePath string `mapstructure:"message_path" yaml:"message_path"` // 客户端发送消息的端点
+	KeepAlive   string `mapstructure:"keep_alive" yaml:"keep_alive"`     // 心跳间隔
+}
+
+// MCPGRPCConfig gRPC 接口配置，与 REST API 共用 mcp.host 和认证配置，单独监听端口
+type MCPGRPCConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	Port    int  `mapstructure:"port" yaml:"port"`
+}
+
 // ConfigManager 配置管理器接口
 type ConfigManager interface {
 	// LoadConfig 加载配置
@@ -121,11 +1280,18 @@ type ConfigManager interface {
 
 	// SetConfigPath 设置配置文件路径
 	SetConfigPath(path string)
+
+	// ConfigFileUsed 获取 LoadConfig 实际读取的配置文件，没有找到配置文件时为空
+	ConfigFileUsed() string
+
+	// SetProfile 设置使用的配置档案，为空时使用 AUTO_CLAUDE_CODE_PROFILE 环境变量
+	SetProfile(name string)
 }
 
 // configManager 配置管理器实现
 type configManager struct {
 	configPath string
+	profile    string
 	viper      *viper.Viper
 }
 
@@ -143,6 +1309,21 @@ func NewConfigManager() ConfigManager {
 
 // LoadConfig 加载配置
 func (cm *configManager) LoadConfig() (*Config, error) {
+	config, err := cm.readConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证配置
+	if err := cm.validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// readConfig 读取并解析配置文件和环境变量，不验证配置
+func (cm *configManager) readConfig() (*Config, error) {
 	// 设置配置文件搜索路径
 	cm.setupConfigPaths()
 
@@ -156,21 +1337,28 @@ func (cm *configManager) LoadConfig() (*Config, error) {
 	// 尝试读取配置文件
 	if err := cm.viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, apperrors.Wrap(err, apperrors.ErrConfigInvalid, "配置文件读取失败")
+			return nil, apperrors.Wrap(err, apperrors.ErrConfigInvalid, "配置文件读取失败").WithDetails(err.Error())
 		}
 		// 配置文件不存在，使用默认配置
 	}
 
+	// 配置档案覆盖顶层配置
+	profile := cm.profile
+	if profile == "" {
+		profile = os.Getenv(ProfileEnv)
+	}
+	if profile != "" {
+		if err := cm.applyProfile(profile); err != nil {
+			return nil, err
+		}
+	}
+
 	// 解析配置
 	var config Config
 	if err := cm.viper.Unmarshal(&config); err != nil {
-		return nil, apperrors.Wrap(err, apperrors.ErrConfigInvalid, "配置解析失败")
-	}
-
-	// 验证配置
-	if err := cm.validateConfig(&config); err != nil {
-		return nil, err
+		return nil, apperrors.Wrap(err, apperrors.ErrConfigInvalid, "配置解析失败").WithDetails(err.Error())
 	}
+	config.Profile = profile
 
 	return &config, nil
 }
@@ -225,6 +1413,16 @@ func (cm *configManager) SetConfigPath(path string) {
 	cm.viper.SetConfigFile(path)
 }
 
+// ConfigFileUsed 获取 LoadConfig 实际读取的配置文件
+func (cm *configManager) ConfigFileUsed() string {
+	return cm.viper.ConfigFileUsed()
+}
+
+// SetProfile 设置使用的配置档案
+func (cm *configManager) SetProfile(name string) {
+	cm.profile = name
+}
+
 // setupConfigPaths 设置配置文件搜索路径
 func (cm *configManager) setupConfigPaths() {
 	if cm.configPath != "" {
@@ -256,17 +1454,37 @@ func setDefaults(v *viper.Viper) {
 	// 基础配置默认值
 	v.SetDefault("debug", false)
 	v.SetDefault("log_level", "info")
+	v.SetDefault("backend", "wsl")
 
 	// WSL 配置默认值
 	v.SetDefault("wsl.default_distro", "")
 	v.SetDefault("wsl.timeout", "30s")
 	v.SetDefault("wsl.path_mappings", map[string]string{})
+	v.SetDefault("wsl.shell", "bash")
+	v.SetDefault("wsl.login_shell", true)
+	v.SetDefault("wsl.env_passthrough", []string{})
+	v.SetDefault("wsl.env_set", []string{})
+
+	// SSH 配置默认值
+	v.SetDefault("ssh.host", "")
+	v.SetDefault("ssh.user", "")
+	v.SetDefault("ssh.port", 22)
+	v.SetDefault("ssh.key_file", "")
+	v.SetDefault("ssh.extra_args", []string{})
+
+	// 容器配置默认值
+	v.SetDefault("container.runtime", "docker")
+	v.SetDefault("container.image", "")
+	v.SetDefault("container.mounts", []string{})
+	v.SetDefault("container.extra_args", []string{})
 
 	// Claude Code 配置默认值
 	v.SetDefault("claude_code.executable", "claude-code")
 	v.SetDefault("claude_code.default_args", []string{})
 	v.SetDefault("claude_code.interactive", true)
 	v.SetDefault("claude_code.workspace_dir", "")
+	v.SetDefault("claude_code.version", "")
+	v.SetDefault("claude_code.launcher", "direct")
 
 	// MCP 配置默认值
 	v.SetDefault("mcp.enabled", false)
@@ -274,64 +1492,440 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("mcp.host", "localhost")
 	v.SetDefault("mcp.max_concurrent_tasks", 5)
 	v.SetDefault("mcp.task_timeout", "30m")
+	v.SetDefault("mcp.config_reload", true)
 	v.SetDefault("mcp.worktree_base_dir", "./worktrees")
 	v.SetDefault("mcp.cleanup_interval", "1h")
 	v.SetDefault("mcp.max_worktrees", 10)
+	v.SetDefault("mcp.branch_template", "worktree_{timestamp}")
+	v.SetDefault("mcp.worktree_cleanup.idle_ttl", "2h")
+	v.SetDefault("mcp.worktree_cleanup.eviction", "lru")
+	v.SetDefault("mcp.worktree_cleanup.keep_conflicted", true)
+	v.SetDefault("mcp.worktree_disk.quota_bytes", 0)
+	v.SetDefault("mcp.worktree_storage.mode", "host")
+	v.SetDefault("mcp.worktree_storage.distro", "")
+	v.SetDefault("mcp.worktree_storage.dir", "~/.auto-claude-code/worktrees")
+	v.SetDefault("mcp.worktree_reconcile.enabled", true)
+	v.SetDefault("mcp.worktree_reconcile.interval", "10m")
+	v.SetDefault("mcp.worktree_disk.scan_interval", "1m")
+	v.SetDefault("mcp.worktree_disk.on_exceeded", "refuse")
+	v.SetDefault("mcp.worktree_disk.wait_timeout", "10m")
+	v.SetDefault("mcp.worktree_copy.exclude", []string{"node_modules/"})
+	v.SetDefault("mcp.worktree_copy.workers", 4)
+	v.SetDefault("mcp.worktree_submodules.enabled", true)
+	v.SetDefault("mcp.worktree_submodules.recursive", true)
+	v.SetDefault("mcp.worktree_submodules.depth", 0)
+	v.SetDefault("mcp.worktree_submodules.jobs", 0)
+	v.SetDefault("mcp.worktree_lfs.enabled", true)
+	v.SetDefault("mcp.worktree_mirror.enabled", false)
+	v.SetDefault("mcp.worktree_mirror.dir", "")
+	v.SetDefault("mcp.worktree_mirror.fetch_interval", "15m")
 
 	// MCP 认证配置默认值
 	v.SetDefault("mcp.auth.enabled", false)
 	v.SetDefault("mcp.auth.method", "none")
 	v.SetDefault("mcp.auth.token_file", "")
+	v.SetDefault("mcp.auth.token_store", "")
+	v.SetDefault("mcp.auth.default_role", "viewer")
+	v.SetDefault("mcp.auth.shared_tasks", false)
 	v.SetDefault("mcp.auth.allowed_ips", []string{"127.0.0.1", "::1"})
+	v.SetDefault("mcp.auth.jwt.jwks_refresh", "1h")
+	v.SetDefault("mcp.auth.jwt.leeway", "30s")
+	v.SetDefault("mcp.auth.jwt.subject_claim", "sub")
+	v.SetDefault("mcp.auth.jwt.name_claim", "name")
+	v.SetDefault("mcp.auth.jwt.roles_claim", "roles")
+	v.SetDefault("mcp.auth.jwt.scopes_claim", "scope")
+	v.SetDefault("mcp.auth.oauth2.validation", "introspection")
+	v.SetDefault("mcp.auth.oauth2.required_scopes", []string{})
+	v.SetDefault("mcp.auth.oauth2.cache_ttl", "1m")
 
 	// MCP 队列配置默认值
 	v.SetDefault("mcp.queue.max_size", 100)
 	v.SetDefault("mcp.queue.retry_attempts", 3)
 	v.SetDefault("mcp.queue.retry_interval", "5s")
 	v.SetDefault("mcp.queue.priority_levels", 3)
+	v.SetDefault("mcp.queue.max_per_project", 0)
+	v.SetDefault("mcp.queue.aging.enabled", true)
+	v.SetDefault("mcp.queue.aging.interval", "5m")
+
+	// MCP 任务资源限制默认值
+	v.SetDefault("mcp.task_limits.nice", 0)
+	v.SetDefault("mcp.task_limits.max_memory_mb", 0)
+	v.SetDefault("mcp.task_limits.max_open_files", 0)
+	v.SetDefault("mcp.task_limits.kill_grace_period", "10s")
+
+	// run_shell_command 工具默认值
+	v.SetDefault("mcp.shell_tool.enabled", false)
+	v.SetDefault("mcp.shell_tool.allowlist", []string{})
+	v.SetDefault("mcp.shell_tool.denylist", DefaultShellDenylist)
+	v.SetDefault("mcp.shell_tool.allow_shell_operators", false)
+	v.SetDefault("mcp.shell_tool.timeout", "10m")
+
+	// 链路追踪配置默认值
+	v.SetDefault("tracing.enabled", false)
+	v.SetDefault("tracing.endpoint", "http://localhost:4318/v1/traces")
+	v.SetDefault("tracing.service_name", "auto-claude-code")
+	v.SetDefault("tracing.sample_ratio", 1.0)
+	v.SetDefault("tracing.batch_size", 512)
+	v.SetDefault("tracing.export_interval", "5s")
+
+	// 限流配置默认值
+	v.SetDefault("mcp.rate_limit.enabled", false)
+	v.SetDefault("mcp.rate_limit.rps", 10)
+	v.SetDefault("mcp.rate_limit.burst", 20)
+	v.SetDefault("mcp.rate_limit.submit_rps", 0.5)
+	v.SetDefault("mcp.rate_limit.submit_burst", 5)
+	v.SetDefault("mcp.quota.enabled", false)
+	v.SetDefault("mcp.quota.window", "24h")
+	v.SetDefault("mcp.audit.enabled", true)
+	v.SetDefault("mcp.audit.file", "")
+	v.SetDefault("mcp.storage.driver", "memory")
+	v.SetDefault("mcp.storage.path", "")
+	v.SetDefault("mcp.storage.requeue", "pending")
+	v.SetDefault("mcp.task_output.enabled", true)
+	v.SetDefault("mcp.task_output.dir", "")
+	v.SetDefault("mcp.task_output.max_bytes", 10*1024*1024)
+	v.SetDefault("mcp.task_output.max_capture_bytes", 4*1024*1024)
+	v.SetDefault("mcp.task_progress.stream_json", false)
+	v.SetDefault("mcp.task_progress.expected_turns", 10)
+	v.SetDefault("mcp.retention.max_age", "24h")
+	v.SetDefault("mcp.retention.failed_max_age", "")
+	v.SetDefault("mcp.retention.max_count", 0)
+	v.SetDefault("mcp.retention.check_interval", "1h")
+	v.SetDefault("mcp.retention.archive", false)
+	v.SetDefault("mcp.retention.archive_dir", "")
+	v.SetDefault("mcp.retention.archive_output", false)
+	v.SetDefault("mcp.retention.archive_interval", "")
+	v.SetDefault("mcp.artifacts.enabled", true)
+	v.SetDefault("mcp.artifacts.dir", "")
+	v.SetDefault("mcp.artifacts.patterns", []string{})
+	v.SetDefault("mcp.artifacts.max_file_bytes", 10*1024*1024)
+	v.SetDefault("mcp.artifacts.max_files", 100)
+	v.SetDefault("mcp.templates.file", "")
+	v.SetDefault("mcp.merge_back.enabled", false)
+	v.SetDefault("mcp.merge_back.strategy", "merge")
+	v.SetDefault("mcp.merge_back.dry_run", false)
+	v.SetDefault("mcp.merge_back.author_name", "")
+	v.SetDefault("mcp.merge_back.author_email", "")
+	v.SetDefault("mcp.merge_back.committer_name", "")
+	v.SetDefault("mcp.merge_back.committer_email", "")
+	v.SetDefault("mcp.merge_back.signing_key", "")
+	v.SetDefault("mcp.pull_requests.task_url", "")
+	v.SetDefault("mcp.pull_requests.timeout", "30s")
 
 	// MCP 传输配置默认值
 	v.SetDefault("mcp.http.enabled", true)
+	v.SetDefault("mcp.http.compression", true)
+	v.SetDefault("mcp.http.max_body_bytes", 1<<20)
 	v.SetDefault("mcp.stdio.enabled", false)
+	v.SetDefault("mcp.stdio.framing", "auto")
+	v.SetDefault("mcp.sse.enabled", true)
+	v.SetDefault("mcp.sse.path", "/sse")
+	v.SetDefault("mcp.sse.message_path", "/messages")
+	v.SetDefault("mcp.sse.keep_alive", "30s")
+	v.SetDefault("mcp.grpc.enabled", false)
+	v.SetDefault("mcp.grpc.port", 9090)
 
 	// MCP 监控配置默认值
 	v.SetDefault("mcp.monitoring.enabled", true)
 	v.SetDefault("mcp.monitoring.metrics_path", "/metrics")
 	v.SetDefault("mcp.monitoring.health_path", "/health")
+	v.SetDefault("mcp.monitoring.liveness_path", "/healthz")
+	v.SetDefault("mcp.monitoring.readiness_path", "/readyz")
 	v.SetDefault("mcp.monitoring.log_requests", true)
 	v.SetDefault("mcp.monitoring.log_responses", false)
 }
 
-// validateConfig 验证配置
+// ConfigProblem 配置中的一个问题
+type ConfigProblem struct {
+	Key string // 出错的配置项，如 mcp.task_timeout，无法确定时为空
+	Err error
+}
+
+// configProblems 收集检查配置时发现的全部问题
+type configProblems []ConfigProblem
+
+// add 记录 key 的问题，err 为 nil 时忽略；err 标明了出错的字段时拼接到 key 之后
+func (p *configProblems) add(key string, err error) {
+	if err == nil {
+		return
+	}
+	var field *fieldError
+	if errors.As(err, &field) {
+		key, err = joinKey(key, field.field), field.err
+	}
+	*p = append(*p, ConfigProblem{Key: key, Err: err})
+}
+
+// fieldError 子配置校验出错的字段，如 url、events[0]
+type fieldError struct {
+	field string
+	err   error
+}
+
+// Error 返回字段的错误信息
+func (e *fieldError) Error() string { return e.err.Error() }
+
+// Unwrap 返回字段的错误
+func (e *fieldError) Unwrap() error { return e.err }
+
+// duration 检查非空的时长配置能否解析且大于 0
+func (p *configProblems) duration(key, value string) {
+	if value == "" {
+		return
+	}
+	if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+		p.add(key, apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 %s: %s", key, value))
+	}
+}
+
+// validateConfig 验证配置，返回发现的第一个问题
 func (cm *configManager) validateConfig(config *Config) error {
+	if problems := ValidateConfig(config); len(problems) > 0 {
+		return problems[0].Err
+	}
+	return nil
+}
+
+// ValidateConfig 检查配置，按配置项的顺序返回全部问题；mcp.enabled 为 false 时不检查 MCP 配置
+func ValidateConfig(config *Config) []ConfigProblem {
+	var problems configProblems
+
 	// 验证日志级别
 	validLogLevels := []string{"debug", "info", "warn", "error", "fatal"}
 	if !contains(validLogLevels, config.LogLevel) {
-		return apperrors.Newf(apperrors.ErrConfigInvalid,
-			"无效的日志级别: %s，支持的级别: %v", config.LogLevel, validLogLevels)
+		problems.add("log_level", apperrors.Newf(apperrors.ErrConfigInvalid,
+			"无效的日志级别: %s，支持的级别: %v", config.LogLevel, validLogLevels))
+	}
+
+	// 验证执行后端配置
+	validBackends := []string{"wsl", "ssh", "container"}
+	if !contains(validBackends, config.Backend) {
+		problems.add("backend", apperrors.Newf(apperrors.ErrConfigInvalid,
+			"无效的执行后端: %s，支持的后端: %v", config.Backend, validBackends))
+	}
+	if config.Backend == "ssh" && config.SSH.Host == "" {
+		problems.add("ssh.host", apperrors.New(apperrors.ErrConfigInvalid, "使用 ssh 后端时必须配置 ssh.host"))
+	}
+	if config.Backend == "container" {
+		validRuntimes := []string{"docker", "podman"}
+		if !contains(validRuntimes, config.Container.Runtime) {
+			problems.add("container.runtime", apperrors.Newf(apperrors.ErrConfigInvalid,
+				"无效的容器运行时: %s，支持的运行时: %v", config.Container.Runtime, validRuntimes))
+		}
+		if config.Container.Image == "" {
+			problems.add("container.image", apperrors.New(apperrors.ErrConfigInvalid, "使用 container 后端时必须配置 container.image"))
+		}
+	}
+	if config.SSH.Port < 0 || config.SSH.Port > 65535 {
+		problems.add("ssh.port", apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 SSH 端口号: %d", config.SSH.Port))
+	}
+
+	// 验证 shell 配置
+	if config.WSL.Shell == "" {
+		problems.add("wsl.shell", apperrors.New(apperrors.ErrConfigInvalid, "WSL shell 不能为空"))
+	}
+
+	// 验证环境变量配置
+	for i, name := range config.WSL.EnvPassthrough {
+		if !envNameRegex.MatchString(name) {
+			problems.add(fmt.Sprintf("wsl.env_passthrough[%d]", i), apperrors.Newf(apperrors.ErrConfigInvalid, "无效的环境变量名: %s", name))
+		}
+	}
+	for i, entry := range config.WSL.EnvSet {
+		name, _, ok := strings.Cut(entry, "=")
+		if !ok || !envNameRegex.MatchString(name) {
+			problems.add(fmt.Sprintf("wsl.env_set[%d]", i), apperrors.Newf(apperrors.ErrConfigInvalid, "无效的环境变量设置: %s，格式应为 NAME=value", entry))
+		}
 	}
 
 	// 验证 Claude Code 可执行文件
 	if config.ClaudeCode.Executable == "" {
-		return apperrors.New(apperrors.ErrConfigInvalid, "Claude Code 可执行文件路径不能为空")
+		problems.add("claude_code.executable", apperrors.New(apperrors.ErrConfigInvalid, "Claude Code 可执行文件路径不能为空"))
+	}
+
+	// 验证 Claude Code 启动方式
+	validLaunchers := []string{"direct", "npx"}
+	if !contains(validLaunchers, config.ClaudeCode.Launcher) {
+		problems.add("claude_code.launcher", apperrors.Newf(apperrors.ErrConfigInvalid,
+			"无效的 Claude Code 启动方式: %s，支持: %v", config.ClaudeCode.Launcher, validLaunchers))
+	}
+
+	// 验证链路追踪配置
+	if config.Tracing.Enabled {
+		if !strings.HasPrefix(config.Tracing.Endpoint, "http://") && !strings.HasPrefix(config.Tracing.Endpoint, "https://") {
+			problems.add("tracing.endpoint", apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 tracing.endpoint: %s", config.Tracing.Endpoint))
+		}
+		if config.Tracing.SampleRatio < 0 || config.Tracing.SampleRatio > 1 {
+			problems.add("tracing.sample_ratio", apperrors.Newf(apperrors.ErrConfigInvalid, "tracing.sample_ratio 必须在 0 到 1 之间: %v", config.Tracing.SampleRatio))
+		}
+		if _, err := time.ParseDuration(config.Tracing.ExportInterval); err != nil {
+			problems.add("tracing.export_interval", apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 tracing.export_interval: %s", config.Tracing.ExportInterval))
+		}
 	}
 
 	// 验证 MCP 配置
 	if config.MCP.Enabled {
 		if config.MCP.Port <= 0 || config.MCP.Port > 65535 {
-			return apperrors.Newf(apperrors.ErrConfigInvalid,
-				"无效的 MCP 端口号: %d", config.MCP.Port)
+			problems.add("mcp.port", apperrors.Newf(apperrors.ErrConfigInvalid,
+				"无效的 MCP 端口号: %d", config.MCP.Port))
+		}
+
+		if config.MCP.HTTP.MaxBodyBytes < 0 {
+			problems.add("mcp.http.max_body_bytes", apperrors.Newf(apperrors.ErrConfigInvalid, "http.max_body_bytes 不能为负数: %d", config.MCP.HTTP.MaxBodyBytes))
+		}
+		for i, origin := range config.MCP.HTTP.AllowedOrigins {
+			if u, err := url.Parse(origin); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.Trim(u.Path, "/") != "" {
+				problems.add(fmt.Sprintf("mcp.http.allowed_origins[%d]", i), apperrors.Newf(apperrors.ErrConfigInvalid, "无效的来源: %s，格式应为 http(s)://主机[:端口]", origin))
+			}
 		}
 
 		if config.MCP.MaxConcurrentTasks <= 0 {
-			return apperrors.Newf(apperrors.ErrConfigInvalid,
-				"最大并发任务数必须大于 0: %d", config.MCP.MaxConcurrentTasks)
+			problems.add("mcp.max_concurrent_tasks", apperrors.Newf(apperrors.ErrConfigInvalid,
+				"最大并发任务数必须大于 0: %d", config.MCP.MaxConcurrentTasks))
+		}
+
+		// 时长在使用时才解析，无效时会被静默忽略
+		problems.duration("mcp.task_timeout", config.MCP.TaskTimeout)
+		problems.duration("mcp.cleanup_interval", config.MCP.CleanupInterval)
+
+		problems.add("mcp.task_limits", config.MCP.TaskLimits.Validate())
+
+		if config.MCP.BranchTemplate != "" {
+			problems.add("mcp.branch_template", ValidateBranchTemplate(config.MCP.BranchTemplate))
+		}
+
+		problems.add("mcp.worktree_storage", config.MCP.WorktreeStorage.Validate())
+		problems.add("mcp.worktree_cleanup", config.MCP.WorktreeCleanup.Validate())
+		problems.add("mcp.worktree_reconcile", config.MCP.WorktreeReconcile.Validate())
+		problems.add("mcp.worktree_disk", config.MCP.WorktreeDisk.Validate())
+		problems.add("mcp.worktree_copy", config.MCP.WorktreeCopy.Validate())
+		problems.add("mcp.worktree_submodules", config.MCP.WorktreeSubmodules.Validate())
+		problems.add("mcp.worktree_lfs", config.MCP.WorktreeLFS.Validate())
+		problems.add("mcp.worktree_context", config.MCP.WorktreeContext.Validate())
+		problems.add("mcp.worktree_mirror", config.MCP.WorktreeMirror.Validate())
+
+		worktreeProjects := make(map[string]bool, len(config.MCP.WorktreeProjects))
+		for i, project := range config.MCP.WorktreeProjects {
+			key := fmt.Sprintf("mcp.worktree_projects[%d]", i)
+			problems.add(key, project.Validate())
+			normalized := strings.TrimRight(strings.ToLower(strings.ReplaceAll(project.Path, "\\", "/")), "/")
+			if worktreeProjects[normalized] {
+				problems.add(key+".path", apperrors.Newf(apperrors.ErrConfigInvalid, "worktree_projects 中的项目重复: %s", project.Path))
+			}
+			worktreeProjects[normalized] = true
+		}
+
+		if config.MCP.Queue.RetryAttempts < 0 {
+			problems.add("mcp.queue.retry_attempts", apperrors.Newf(apperrors.ErrConfigInvalid, "queue.retry_attempts 不能为负数: %d", config.MCP.Queue.RetryAttempts))
+		}
+		if config.MCP.Queue.MaxPerProject < 0 {
+			problems.add("mcp.queue.max_per_project", apperrors.Newf(apperrors.ErrConfigInvalid, "queue.max_per_project 不能为负数: %d", config.MCP.Queue.MaxPerProject))
+		}
+		if config.MCP.Queue.RetryInterval != "" {
+			if interval, err := time.ParseDuration(config.MCP.Queue.RetryInterval); err != nil || interval <= 0 {
+				problems.add("mcp.queue.retry_interval", apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 queue.retry_interval: %s", config.MCP.Queue.RetryInterval))
+			}
+		}
+		problems.add("mcp.queue.aging", config.MCP.Queue.Aging.Validate(config.MCP.Queue.PriorityLevels))
+
+		for i, pattern := range config.MCP.ShellTool.Denylist {
+			if _, err := regexp.Compile(pattern); err != nil {
+				problems.add(fmt.Sprintf("mcp.shell_tool.denylist[%d]", i), apperrors.Wrapf(err, apperrors.ErrConfigInvalid, "无效的 shell_tool.denylist 正则表达式: %s", pattern))
+			}
+		}
+		if config.MCP.ShellTool.Timeout != "" {
+			if _, err := time.ParseDuration(config.MCP.ShellTool.Timeout); err != nil {
+				problems.add("mcp.shell_tool.timeout", apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 shell_tool.timeout: %s", config.MCP.ShellTool.Timeout))
+			}
+		}
+
+		if rl := config.MCP.RateLimit; rl.Enabled {
+			if rl.RPS <= 0 || rl.Burst <= 0 || rl.SubmitRPS <= 0 || rl.SubmitBurst <= 0 {
+				problems.add("mcp.rate_limit", apperrors.New(apperrors.ErrConfigInvalid, "rate_limit 的 rps、burst、submit_rps、submit_burst 必须大于 0"))
+			}
+		}
+
+		if config.MCP.Auth.Enabled {
+			problems.add("mcp.auth", config.MCP.Auth.Validate())
+		}
+
+		problems.add("mcp.quota", config.MCP.Quota.Validate())
+		problems.add("mcp.storage", config.MCP.Storage.Validate())
+		problems.add("mcp.retention", config.MCP.Retention.Validate())
+		problems.add("mcp.artifacts", config.MCP.Artifacts.Validate())
+
+		if config.MCP.TaskOutput.MaxBytes < 0 {
+			problems.add("mcp.task_output.max_bytes", apperrors.Newf(apperrors.ErrConfigInvalid, "task_output.max_bytes 不能为负数: %d", config.MCP.TaskOutput.MaxBytes))
+		}
+		if config.MCP.TaskOutput.MaxCaptureBytes < 0 {
+			problems.add("mcp.task_output.max_capture_bytes", apperrors.Newf(apperrors.ErrConfigInvalid, "task_output.max_capture_bytes 不能为负数: %d", config.MCP.TaskOutput.MaxCaptureBytes))
+		}
+
+		if config.MCP.TaskProgress.ExpectedTurns < 0 {
+			problems.add("mcp.task_progress.expected_turns", apperrors.Newf(apperrors.ErrConfigInvalid, "task_progress.expected_turns 不能为负数: %d", config.MCP.TaskProgress.ExpectedTurns))
+		}
+
+		problems.add("mcp.merge_back", config.MCP.MergeBack.Validate())
+		problems.add("mcp.pull_requests", config.MCP.PullRequests.Validate())
+
+		distros := make(map[string]bool, len(config.MCP.Distros))
+		for i, distro := range config.MCP.Distros {
+			key := fmt.Sprintf("mcp.distros[%d]", i)
+			problems.add(key, distro.Validate())
+			if distros[strings.ToLower(distro.Name)] {
+				problems.add(key+".name", apperrors.Newf(apperrors.ErrConfigInvalid, "发行版池中的发行版重复: %s", distro.Name))
+			}
+			distros[strings.ToLower(distro.Name)] = true
+		}
+
+		for i, webhook := range config.MCP.Webhooks {
+			problems.add(fmt.Sprintf("mcp.webhooks[%d]", i), webhook.Validate())
+		}
+
+		switch config.MCP.Stdio.Framing {
+		case "", "auto", "newline", "content-length":
+		default:
+			problems.add("mcp.stdio.framing", apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 stdio 分帧方式: %s (可选: auto, newline, content-length)", config.MCP.Stdio.Framing))
+		}
+
+		if config.MCP.SSE.Enabled {
+			if !strings.HasPrefix(config.MCP.SSE.Path, "/") || !strings.HasPrefix(config.MCP.SSE.MessagePath, "/") {
+				problems.add("mcp.sse", apperrors.New(apperrors.ErrConfigInvalid, "SSE 端点路径必须以 / 开头"))
+			}
+			if config.MCP.SSE.Path == config.MCP.SSE.MessagePath {
+				problems.add("mcp.sse", apperrors.New(apperrors.ErrConfigInvalid, "SSE 事件流端点与消息端点不能相同"))
+			}
+			if _, err := time.ParseDuration(config.MCP.SSE.KeepAlive); err != nil {
+				problems.add("mcp.sse.keep_alive", apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 SSE 心跳间隔: %s", config.MCP.SSE.KeepAlive))
+			}
+		}
+
+		if config.MCP.GRPC.Enabled {
+			if config.MCP.GRPC.Port <= 0 || config.MCP.GRPC.Port > 65535 {
+				problems.add("mcp.grpc.port", apperrors.Newf(apperrors.ErrConfigInvalid, "无效的 gRPC 端口号: %d", config.MCP.GRPC.Port))
+			} else if config.MCP.GRPC.Port == config.MCP.Port && config.MCP.HTTP.Enabled {
+				problems.add("mcp.grpc.port", apperrors.Newf(apperrors.ErrConfigInvalid, "gRPC 端口不能与 MCP 端口相同: %d", config.MCP.GRPC.Port))
+			}
 		}
 	}
 
-	return nil
+	return problems
+}
+
+// DefaultShellDenylist run_shell_command 默认禁止的命令
+var DefaultShellDenylist = []string{
+	`\bsudo\b`,
+	`\bsu\b`,
+	`\brm\s+(-[a-zA-Z]*\s+)*/(\s|$)`,
+	`\b(shutdown|reboot|halt|poweroff)\b`,
+	`\bmkfs(\.\w+)?\b`,
+	`\bdd\b.*\bof=/dev/`,
+	`:\(\)\s*\{`,
 }
 
+// envNameRegex 合法的环境变量名
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 // contains 检查字符串切片是否包含指定值
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -347,23 +1941,144 @@ func GetDefaultConfig() *Config {
 	return &Config{
 		Debug:    false,
 		LogLevel: "info",
+		Backend:  "wsl",
 		WSL: WSLConfig{
-			DefaultDistro: "",
-			PathMappings:  make(map[string]string),
-			Timeout:       "30s",
+			DefaultDistro:  "",
+			PathMappings:   make(map[string]string),
+			Timeout:        "30s",
+			Shell:          "bash",
+			LoginShell:     true,
+			EnvPassthrough: []string{},
+			EnvSet:         []string{},
+		},
+		SSH: SSHConfig{
+			Port:      22,
+			ExtraArgs: []string{},
+		},
+		Container: ContainerConfig{
+			Runtime:   "docker",
+			Mounts:    []string{},
+			ExtraArgs: []string{},
 		},
 		ClaudeCode: ClaudeCodeConfig{
 			Executable:   "claude-code",
 			DefaultArgs:  []string{},
 			Interactive:  true,
 			WorkspaceDir: "",
+			Launcher:     "direct",
 		},
 		MCP: MCPConfig{
 			Enabled:            false,
 			Port:               8080,
 			MaxConcurrentTasks: 5,
 			TaskTimeout:        "30m",
+			ConfigReload:       true,
 			WorktreeBaseDir:    "./worktrees",
+			BranchTemplate:     "worktree_{timestamp}",
+			WorktreeCleanup: WorktreeCleanupConfig{
+				IdleTTL:        "2h",
+				Eviction:       "lru",
+				KeepConflicted: true,
+			},
+			WorktreeDisk: WorktreeDiskConfig{
+				ScanInterval: "1m",
+				OnExceeded:   "refuse",
+				WaitTimeout:  "10m",
+			},
+			WorktreeCopy: WorktreeCopyConfig{
+				Exclude: []string{"node_modules/"},
+				Workers: 4,
+			},
+			WorktreeSubmodules: WorktreeSubmoduleConfig{
+				Enabled:   true,
+				Recursive: true,
+			},
+			WorktreeLFS: WorktreeLFSConfig{
+				Enabled: true,
+			},
+			WorktreeMirror: WorktreeMirrorConfig{
+				FetchInterval: "15m",
+			},
+			WorktreeReconcile: WorktreeReconcileConfig{
+				Enabled:  true,
+				Interval: "10m",
+			},
+			WorktreeStorage: WorktreeStorageConfig{
+				Mode: WorktreeStorageHost,
+				Dir:  "~/.auto-claude-code/worktrees",
+			},
+			TaskLimits: ResourceLimits{
+				KillGracePeriod: "10s",
+			},
+			Queue: MCPQueueConfig{
+				Aging: PriorityAgingConfig{
+					Enabled:  true,
+					Interval: "5m",
+				},
+			},
+			ShellTool: ShellToolConfig{
+				Allowlist: []string{},
+				Denylist:  DefaultShellDenylist,
+				Timeout:   "10m",
+			},
+			RateLimit: RateLimitConfig{
+				RPS:         10,
+				Burst:       20,
+				SubmitRPS:   0.5,
+				SubmitBurst: 5,
+			},
+			Quota: QuotaConfig{
+				Window: "24h",
+			},
+			Audit: AuditConfig{
+				Enabled: true,
+			},
+			Storage: StorageConfig{
+				Driver:  "memory",
+				Requeue: "pending",
+			},
+			TaskOutput: TaskOutputConfig{
+				Enabled:         true,
+				MaxBytes:        10 * 1024 * 1024,
+				MaxCaptureBytes: 4 * 1024 * 1024,
+			},
+			TaskProgress: TaskProgressConfig{
+				ExpectedTurns: 10,
+			},
+			Retention: RetentionConfig{
+				MaxAge:        "24h",
+				CheckInterval: "1h",
+			},
+			Artifacts: ArtifactsConfig{
+				Enabled:      true,
+				MaxFileBytes: 10 * 1024 * 1024,
+				MaxFiles:     100,
+			},
+			MergeBack: MergeBackConfig{
+				Strategy: "merge",
+			},
+			PullRequests: PullRequestConfig{
+				Timeout: "30s",
+			},
+			Stdio: MCPStdioConfig{
+				Framing: "auto",
+			},
+			SSE: MCPSSEConfig{
+				Enabled:     true,
+				Path:        "/sse",
+				MessagePath: "/messages",
+				KeepAlive:   "30s",
+			},
+			GRPC: MCPGRPCConfig{
+				Port: 9090,
+			},
+		},
+		Tracing: TracingConfig{
+			Endpoint:       "http://localhost:4318/v1/traces",
+			ServiceName:    "auto-claude-code",
+			SampleRatio:    1.0,
+			BatchSize:      512,
+			ExportInterval: "5s",
 		},
 	}
 }
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"sort"
+	"strings"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// ProfileEnv 选择配置档案的环境变量，命令行的 --profile 优先
+const ProfileEnv = "AUTO_CLAUDE_CODE_PROFILE"
+
+// profilesKey 配置文件中配置档案所在的键，如 profiles.work.wsl.default_distro
+const profilesKey = "profiles"
+
+// applyProfile 将配置档案中的配置合并到顶层配置之上，嵌套的配置按键合并，列表整体替换
+// 档案名与配置项相同不区分大小写
+func (cm *configManager) applyProfile(name string) error {
+	profiles := cm.viper.GetStringMap(profilesKey)
+	value, exists := profiles[strings.ToLower(name)]
+	if !exists {
+		names := make([]string, 0, len(profiles))
+		for profile := range profiles {
+			names = append(names, profile)
+		}
+		sort.Strings(names)
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "配置档案不存在: %s，可用的档案: %v", name, names)
+	}
+	if value == nil {
+		// 空档案
+		return nil
+	}
+	settings, ok := value.(map[string]interface{})
+	if !ok {
+		return apperrors.Newf(apperrors.ErrConfigInvalid, "配置档案 %s 必须是配置项的映射", name)
+	}
+	if err := cm.viper.MergeConfigMap(settings); err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrConfigInvalid, "应用配置档案失败: %s", name)
+	}
+	return nil
+}
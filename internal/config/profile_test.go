@@ -0,0 +1,109 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+const profileTestConfig = `
+log_level: info
+wsl:
+  default_distro: Ubuntu
+  shell: zsh
+  env_passthrough: [PATH, HOME]
+mcp:
+  port: 8080
+  max_concurrent_tasks: 3
+profiles:
+  Work:
+    log_level: debug
+    wsl:
+      default_distro: Debian
+      env_passthrough: [GOPATH]
+    mcp:
+      port: 9090
+  empty:
+`
+
+func TestApplyProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		check   func(t *testing.T, cfg *Config)
+		wantErr bool
+	}{
+		{"未选择档案时使用顶层配置", "", func(t *testing.T, cfg *Config) {
+			if cfg.LogLevel != "info" || cfg.WSL.DefaultDistro != "Ubuntu" || cfg.MCP.Port != 8080 || cfg.Profile != "" {
+				t.Errorf("配置 = %s %s %d %q", cfg.LogLevel, cfg.WSL.DefaultDistro, cfg.MCP.Port, cfg.Profile)
+			}
+		}, false},
+		{"档案覆盖顶层配置，未覆盖的配置保留", "work", func(t *testing.T, cfg *Config) {
+			if cfg.LogLevel != "debug" || cfg.WSL.DefaultDistro != "Debian" || cfg.MCP.Port != 9090 {
+				t.Errorf("覆盖后的配置 = %s %s %d", cfg.LogLevel, cfg.WSL.DefaultDistro, cfg.MCP.Port)
+			}
+			if cfg.WSL.Shell != "zsh" || cfg.MCP.MaxConcurrentTasks != 3 {
+				t.Errorf("同一层级中未覆盖的配置 = %s %d", cfg.WSL.Shell, cfg.MCP.MaxConcurrentTasks)
+			}
+		}, false},
+		{"列表整体替换", "work", func(t *testing.T, cfg *Config) {
+			if !reflect.DeepEqual(cfg.WSL.EnvPassthrough, []string{"GOPATH"}) {
+				t.Errorf("env_passthrough = %v", cfg.WSL.EnvPassthrough)
+			}
+		}, false},
+		{"档案名不区分大小写", "WORK", func(t *testing.T, cfg *Config) {
+			if cfg.MCP.Port != 9090 || cfg.Profile != "WORK" {
+				t.Errorf("port = %d, profile = %q", cfg.MCP.Port, cfg.Profile)
+			}
+		}, false},
+		{"空档案使用顶层配置", "empty", func(t *testing.T, cfg *Config) {
+			if cfg.LogLevel != "info" || cfg.MCP.Port != 8080 || cfg.Profile != "empty" {
+				t.Errorf("配置 = %s %d %q", cfg.LogLevel, cfg.MCP.Port, cfg.Profile)
+			}
+		}, false},
+		{"档案不存在", "home", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(ProfileEnv, "")
+			cm := NewConfigManager()
+			cm.SetConfigPath(writeConfigFile(t, profileTestConfig))
+			cm.SetProfile(tt.profile)
+
+			cfg, err := cm.LoadConfig()
+			if tt.wantErr {
+				if !apperrors.IsCode(err, apperrors.ErrConfigInvalid) {
+					t.Errorf("LoadConfig() error = %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestProfileFromEnv(t *testing.T) {
+	path := writeConfigFile(t, profileTestConfig)
+
+	t.Setenv(ProfileEnv, "work")
+	cfg, err := LoadConfigFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadConfigFromFile() error = %v", err)
+	}
+	if cfg.MCP.Port != 9090 || cfg.Profile != "work" {
+		t.Errorf("port = %d, profile = %q", cfg.MCP.Port, cfg.Profile)
+	}
+
+	// 显式设置的档案优先于环境变量
+	cm := NewConfigManager()
+	cm.SetConfigPath(path)
+	cm.SetProfile("empty")
+	if cfg, err = cm.LoadConfig(); err != nil || cfg.MCP.Port != 8080 {
+		t.Errorf("LoadConfig() = %+v, %v", cfg, err)
+	}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	apperrors "auto-claude-code/internal/errors"
+
+	"github.com/spf13/viper"
+)
+
+// ValidateConfigFile 按配置档案 profile 加载配置文件并检查全部问题：无法识别的配置项（包括各配置档案中的）、无法解析的值和无效的配置值
+// mcp stdio 不要求 mcp.enabled，因此无论是否启用都检查 MCP 配置；文件无法读取时返回错误
+func ValidateConfigFile(path, profile string) ([]ConfigProblem, error) {
+	raw := viper.New()
+	raw.SetConfigFile(path)
+	if err := raw.ReadInConfig(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrConfigInvalid, "配置文件读取失败").WithDetails(err.Error())
+	}
+
+	var problems configProblems
+	settings := raw.AllSettings()
+	profiles, _ := settings[profilesKey].(map[string]interface{})
+	delete(settings, profilesKey)
+	unknownKeys(&problems, "", settings, reflect.TypeOf(Config{}))
+	for _, name := range sortedKeys(profiles) {
+		unknownKeys(&problems, joinKey(profilesKey, name), profiles[name], reflect.TypeOf(Config{}))
+	}
+
+	cm := NewConfigManager().(*configManager)
+	cm.SetConfigPath(path)
+	cm.SetProfile(profile)
+	config, err := cm.readConfig()
+	if err != nil {
+		problems.add("", err)
+		return problems, nil
+	}
+	config.MCP.Enabled = true
+	return append(problems, ValidateConfig(config)...), nil
+}
+
+// unknownKeys 按配置结构检查 value，记录没有对应字段的配置项
+func unknownKeys(problems *configProblems, prefix string, value interface{}, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		settings, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := make(map[string]reflect.Type, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			fields[strings.ToLower(name)] = field.Type
+		}
+		for _, key := range sortedKeys(settings) {
+			fieldType, ok := fields[strings.ToLower(key)]
+			if !ok {
+				problems.add(joinKey(prefix, key), apperrors.New(apperrors.ErrConfigInvalid, "无法识别的配置项"))
+				continue
+			}
+			unknownKeys(problems, joinKey(prefix, key), settings[key], fieldType)
+		}
+	case reflect.Slice:
+		items, _ := value.([]interface{})
+		for i, item := range items {
+			unknownKeys(problems, fmt.Sprintf("%s[%d]", prefix, i), item, t.Elem())
+		}
+	case reflect.Map:
+		settings, _ := value.(map[string]interface{})
+		for _, key := range sortedKeys(settings) {
+			unknownKeys(problems, joinKey(prefix, key), settings[key], t.Elem())
+		}
+	}
+}
+
+// sortedKeys 按字母顺序返回 map 的键
+func sortedKeys(settings map[string]interface{}) []string {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// joinKey 拼接配置项路径
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// problemKeys 返回问题的配置项路径
+func problemKeys(problems []ConfigProblem) []string {
+	var keys []string
+	for _, problem := range problems {
+		keys = append(keys, problem.Key)
+	}
+	return keys
+}
+
+// writeConfigFile 在临时目录中写入配置文件并返回路径
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   []string
+	}{
+		{"默认配置有效", func(*Config) {}, nil},
+		{"一次收集多个问题", func(c *Config) {
+			c.LogLevel = "verbose"
+			c.Backend = "vm"
+			c.MCP.Port = 0
+		}, []string{"log_level", "backend", "mcp.port"}},
+		{"无效的 task_timeout", func(c *Config) { c.MCP.TaskTimeout = "30x" }, []string{"mcp.task_timeout"}},
+		{"cleanup_interval 不能为负数", func(c *Config) { c.MCP.CleanupInterval = "-5m" }, []string{"mcp.cleanup_interval"}},
+		{"为空的时长不检查", func(c *Config) { c.MCP.TaskTimeout, c.MCP.CleanupInterval = "", "" }, nil},
+		{"列表中出错的字段", func(c *Config) {
+			c.MCP.Webhooks = []WebhookConfig{
+				{URL: "https://example.com/hook"},
+				{URL: "ftp://example.com"},
+				{URL: "https://example.com/hook", Events: []string{"task.completed", "bogus"}},
+			}
+		}, []string{"mcp.webhooks[1].url", "mcp.webhooks[2].events[1]"}},
+		{"列表中的每一项分别检查", func(c *Config) { c.WSL.EnvPassthrough = []string{"PATH", "1BAD", "BAD-NAME"} },
+			[]string{"wsl.env_passthrough[1]", "wsl.env_passthrough[2]"}},
+		{"无效的 WebSocket 来源", func(c *Config) {
+			c.MCP.HTTP.AllowedOrigins = []string{"https://console.example.com", "console.example.com", "https://example.com/app"}
+		}, []string{"mcp.http.allowed_origins[1]", "mcp.http.allowed_origins[2]"}},
+		{"gRPC 端口与 MCP 端口相同", func(c *Config) {
+			c.MCP.HTTP.Enabled = true
+			c.MCP.GRPC.Enabled = true
+			c.MCP.GRPC.Port = c.MCP.Port
+		}, []string{"mcp.grpc.port"}},
+		{"未启用 MCP 时不检查", func(c *Config) {
+			c.MCP.Enabled = false
+			c.MCP.TaskTimeout = "30x"
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := GetDefaultConfig()
+			cfg.MCP.Enabled = true
+			tt.modify(cfg)
+
+			problems := ValidateConfig(cfg)
+			if got := problemKeys(problems); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("问题的配置项 = %v, want %v", got, tt.want)
+			}
+			for _, problem := range problems {
+				if !apperrors.IsCode(problem.Err, apperrors.ErrConfigInvalid) {
+					t.Errorf("%s 的错误 = %v", problem.Key, problem.Err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"有效的配置", "log_level: debug\nmcp:\n  port: 9090\n", nil},
+		{"顶层未知配置项", "log_levle: debug\n", []string{"log_levle"}},
+		{"嵌套配置中的未知配置项", "mcp:\n  worktree_cleanup:\n    idle_tll: 1h\n", []string{"mcp.worktree_cleanup.idle_tll"}},
+		{"列表项中的未知配置项", "mcp:\n  webhooks:\n    - url: https://example.com\n      retries: 3\n",
+			[]string{"mcp.webhooks[0].retries"}},
+		{"映射值中的未知配置项", "mcp:\n  quota:\n    clients:\n      alice:\n        max_tasks: 1\n",
+			[]string{"mcp.quota.clients.alice.max_tasks"}},
+		{"映射的键不检查", "wsl:\n  path_mappings:\n    \"D:\\\\\": /mnt/data\n", nil},
+		{"配置档案中的未知配置项", "profiles:\n  work:\n    wsl:\n      distro: Ubuntu\n", []string{"profiles.work.wsl.distro"}},
+		{"未知配置项和无效值一起报告", "mcp:\n  cleanup_intervall: 1h\n  task_timeout: 30x\n  webhooks:\n    - url: ftp://example.com\n",
+			[]string{"mcp.cleanup_intervall", "mcp.task_timeout", "mcp.webhooks[0].url"}},
+		{"未启用 MCP 时仍检查 MCP 配置", "mcp:\n  enabled: false\n  cleanup_interval: soon\n", []string{"mcp.cleanup_interval"}},
+		{"无法解析的值", "mcp:\n  port: abc\n", []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems, err := ValidateConfigFile(writeConfigFile(t, tt.content), "")
+			if err != nil {
+				t.Fatalf("ValidateConfigFile() error = %v", err)
+			}
+			if got := problemKeys(problems); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("问题的配置项 = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateConfigFileUnreadable(t *testing.T) {
+	if _, err := ValidateConfigFile(writeConfigFile(t, "mcp: [\n"), ""); !apperrors.IsCode(err, apperrors.ErrConfigInvalid) {
+		t.Errorf("ValidateConfigFile() error = %v", err)
+	}
+	if _, err := ValidateConfigFile(filepath.Join(t.TempDir(), "missing.yaml"), ""); err == nil {
+		t.Error("不存在的文件没有返回错误")
+	}
+}
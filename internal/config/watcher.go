@@ -0,0 +1,119 @@
+package config
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	apperrors "auto-claude-code/internal/errors"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce 配置文件最后一次变化后等待的时间，编辑器保存时通常产生多个事件
+const watchDebounce = 500 * time.Millisecond
+
+// Watcher 监视配置文件，修改后重新加载并校验
+type Watcher struct {
+	path     string
+	profile  string
+	watcher  *fsnotify.Watcher
+	onChange func(*Config)
+	onError  func(error)
+	reload   chan struct{}
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// WatchConfigFile 监视配置文件，修改后按配置档案 profile 重新加载，校验通过时调用 onChange，读取或校验失败时调用 onError 并保留原配置
+// 监视的是文件所在目录，编辑器以替换文件的方式保存时也能收到修改
+func WatchConfigFile(path, profile string, onChange func(*Config), onError func(error)) (*Watcher, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, apperrors.Wrapf(err, apperrors.ErrConfigInvalid, "无效的配置文件路径: %s", path)
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrConfigInvalid, "创建配置文件监视器失败")
+	}
+	if err := fsWatcher.Add(filepath.Dir(absPath)); err != nil {
+		fsWatcher.Close()
+		return nil, apperrors.Wrapf(err, apperrors.ErrConfigInvalid, "监视配置文件失败: %s", path)
+	}
+
+	w := &Watcher{
+		path:     absPath,
+		profile:  profile,
+		watcher:  fsWatcher,
+		onChange: onChange,
+		onError:  onError,
+		reload:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	w.wg.Add(1)
+	go w.run()
+	return w, nil
+}
+
+// Close 停止监视
+func (w *Watcher) Close() error {
+	close(w.done)
+	err := w.watcher.Close()
+	w.wg.Wait()
+	return err
+}
+
+// run 处理文件事件，同一时间只有一次重新加载
+func (w *Watcher) run() {
+	defer w.wg.Done()
+
+	var debounce *time.Timer
+	defer func() {
+		if debounce != nil {
+			debounce.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != w.path || !event.Has(fsnotify.Write|fsnotify.Create) {
+				continue
+			}
+			if debounce == nil {
+				debounce = time.AfterFunc(watchDebounce, w.scheduleReload)
+			} else {
+				debounce.Reset(watchDebounce)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.onError(apperrors.Wrap(err, apperrors.ErrConfigInvalid, "监视配置文件失败"))
+		case <-w.reload:
+			cm := NewConfigManager()
+			cm.SetConfigPath(w.path)
+			cm.SetProfile(w.profile)
+			cfg, err := cm.LoadConfig()
+			if err != nil {
+				w.onError(err)
+				continue
+			}
+			w.onChange(cfg)
+		}
+	}
+}
+
+// scheduleReload 请求重新加载，已有未处理的请求时忽略
+func (w *Watcher) scheduleReload() {
+	select {
+	case w.reload <- struct{}{}:
+	default:
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	apperrors "auto-claude-code/internal/errors"
@@ -33,16 +34,47 @@ type pathConverter struct {
 	windowsPathRegex *regexp.Regexp
 	// WSL 路径正则表达式
 	wslPathRegex *regexp.Regexp
+	// 自定义路径映射，按 Windows 前缀长度降序排列
+	mappings []pathMapping
+}
+
+// pathMapping 自定义路径映射项
+type pathMapping struct {
+	windowsPrefix string // 使用正斜杠、无结尾分隔符的 Windows 路径前缀
+	linuxPrefix   string // 无结尾分隔符的 Linux 路径前缀
 }
 
 // NewPathConverter 创建新的路径转换器
 func NewPathConverter() PathConverter {
-	return &pathConverter{
+	return NewPathConverterWithMappings(nil)
+}
+
+// NewPathConverterWithMappings 创建带自定义路径映射的路径转换器
+// mappings 的键为 Windows 路径前缀，值为 Linux 路径前缀；未匹配的路径仍按 /mnt/<盘符> 规则转换
+func NewPathConverterWithMappings(mappings map[string]string) PathConverter {
+	pc := &pathConverter{
 		// Windows 路径格式：C:\path\to\file 或 C:/path/to/file
 		windowsPathRegex: regexp.MustCompile(`^[A-Za-z]:[/\\].*`),
 		// WSL 路径格式：/mnt/c/path/to/file
-		wslPathRegex: regexp.MustCompile(`^/mnt/[a-z]/.*`),
+		wslPathRegex: regexp.MustCompile(`^/mnt/[a-z](/.*)?$`),
 	}
+
+	for windowsPrefix, linuxPrefix := range mappings {
+		if windowsPrefix == "" || linuxPrefix == "" {
+			continue
+		}
+		pc.mappings = append(pc.mappings, pathMapping{
+			windowsPrefix: strings.TrimRight(strings.ReplaceAll(windowsPrefix, "\\", "/"), "/"),
+			linuxPrefix:   strings.TrimRight(linuxPrefix, "/"),
+		})
+	}
+
+	// 最长前缀优先匹配
+	sort.Slice(pc.mappings, func(i, j int) bool {
+		return len(pc.mappings[i].windowsPrefix) > len(pc.mappings[j].windowsPrefix)
+	})
+
+	return pc
 }
 
 // ConvertToWSL 将 Windows 路径转换为 WSL 路径
@@ -59,6 +91,11 @@ func (pc *pathConverter) ConvertToWSL(windowsPath string) (string, error) {
 		return "", apperrors.Newf(apperrors.ErrInvalidPath, "无效的 Windows 路径格式: %s", windowsPath)
 	}
 
+	// 优先使用自定义路径映射
+	if mapped, ok := pc.mapToLinux(cleanPath); ok {
+		return mapped, nil
+	}
+
 	// 提取盘符
 	driveLetter := strings.ToLower(string(cleanPath[0]))
 
@@ -80,6 +117,11 @@ func (pc *pathConverter) ConvertToWindows(wslPath string) (string, error) {
 		return "", apperrors.New(apperrors.ErrInvalidPath, "路径不能为空")
 	}
 
+	// 优先使用自定义路径映射
+	if mapped, ok := pc.mapToWindows(wslPath); ok {
+		return mapped, nil
+	}
+
 	// 检查是否为有效的 WSL 路径
 	if !pc.IsWSLPath(wslPath) {
 		return "", apperrors.Newf(apperrors.ErrInvalidPath, "无效的 WSL 路径格式: %s", wslPath)
@@ -109,6 +151,55 @@ func (pc *pathConverter) ConvertToWindows(wslPath string) (string, error) {
 	return windowsPath, nil
 }
 
+// mapToLinux 按自定义映射转换 Windows 路径，Windows 路径不区分大小写
+func (pc *pathConverter) mapToLinux(windowsPath string) (string, bool) {
+	normalized := strings.ReplaceAll(windowsPath, "\\", "/")
+
+	for _, m := range pc.mappings {
+		if rest, ok := cutPathPrefix(normalized, m.windowsPrefix, true); ok {
+			return m.linuxPrefix + rest, true
+		}
+	}
+	return "", false
+}
+
+// mapToWindows 按自定义映射将 Linux 路径转换回 Windows 路径
+func (pc *pathConverter) mapToWindows(linuxPath string) (string, bool) {
+	var best *pathMapping
+	var bestRest string
+
+	for i := range pc.mappings {
+		m := &pc.mappings[i]
+		rest, ok := cutPathPrefix(linuxPath, m.linuxPrefix, false)
+		if ok && (best == nil || len(m.linuxPrefix) > len(best.linuxPrefix)) {
+			best, bestRest = m, rest
+		}
+	}
+
+	if best == nil {
+		return "", false
+	}
+	return strings.ReplaceAll(best.windowsPrefix+bestRest, "/", "\\"), true
+}
+
+// cutPathPrefix 在路径分隔符边界上去除前缀，返回剩余部分（以 / 开头或为空）
+func cutPathPrefix(path, prefix string, foldCase bool) (string, bool) {
+	if len(path) < len(prefix) {
+		return "", false
+	}
+
+	head := path[:len(prefix)]
+	if foldCase && !strings.EqualFold(head, prefix) || !foldCase && head != prefix {
+		return "", false
+	}
+
+	rest := path[len(prefix):]
+	if rest != "" && rest[0] != '/' {
+		return "", false
+	}
+	return rest, true
+}
+
 // ValidatePath 验证路径有效性
 func (pc *pathConverter) ValidatePath(path string) error {
 	if path == "" {
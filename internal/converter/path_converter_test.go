@@ -82,6 +82,60 @@ func TestPathConverter_ConvertToWSL(t *testing.T) {
 	}
 }
 
+func TestPathConverter_Mappings(t *testing.T) {
+	pc := NewPathConverterWithMappings(map[string]string{
+		"c:\\projects":      "/home/dev/projects",
+		"C:\\projects\\web": "/srv/web/",
+	})
+
+	tests := []struct {
+		name        string
+		windowsPath string
+		expected    string
+	}{
+		{
+			name:        "映射前缀不区分大小写",
+			windowsPath: "C:\\Projects\\api",
+			expected:    "/home/dev/projects/api",
+		},
+		{
+			name:        "最长前缀优先",
+			windowsPath: "C:\\projects\\web\\site",
+			expected:    "/srv/web/site",
+		},
+		{
+			name:        "仅在路径边界匹配",
+			windowsPath: "C:\\projects2\\app",
+			expected:    "/mnt/c/projects2/app",
+		},
+		{
+			name:        "未匹配时使用默认规则",
+			windowsPath: "D:\\work",
+			expected:    "/mnt/d/work",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := pc.ConvertToWSL(tt.windowsPath)
+			if err != nil {
+				t.Fatalf("意外的错误: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("期望 %s，但得到 %s", tt.expected, result)
+			}
+		})
+	}
+
+	windowsPath, err := pc.ConvertToWindows("/srv/web/site")
+	if err != nil {
+		t.Fatalf("意外的错误: %v", err)
+	}
+	if windowsPath != "C:\\projects\\web\\site" {
+		t.Errorf("期望 C:\\projects\\web\\site，但得到 %s", windowsPath)
+	}
+}
+
 func TestPathConverter_ConvertToWindows(t *testing.T) {
 	pc := NewPathConverter()
 
@@ -21,8 +21,12 @@ const (
 	ErrWSLCommandFailed ErrorCode = "WSL_COMMAND_FAILED"
 
 	// Claude Code 相关错误
-	ErrClaudeCodeNotFound ErrorCode = "CLAUDE_CODE_NOT_FOUND"
-	ErrClaudeCodeFailed   ErrorCode = "CLAUDE_CODE_FAILED"
+	ErrClaudeCodeNotFound     ErrorCode = "CLAUDE_CODE_NOT_FOUND"
+	ErrClaudeCodeFailed       ErrorCode = "CLAUDE_CODE_FAILED"
+	ErrClaudeCodeInstall      ErrorCode = "CLAUDE_CODE_INSTALL_FAILED"
+	ErrClaudeCodeAuthRequired ErrorCode = "CLAUDE_CODE_AUTH_REQUIRED"
+	ErrClaudeCodeVersion      ErrorCode = "CLAUDE_CODE_VERSION_MISMATCH"
+	ErrTerminalLaunch         ErrorCode = "TERMINAL_LAUNCH_FAILED"
 
 	// 任务管理错误
 	ErrTaskNotSupported ErrorCode = "TASK_NOT_SUPPORTED"
@@ -31,13 +35,33 @@ const (
 	ErrTaskNotFound     ErrorCode = "TASK_NOT_FOUND"
 	ErrTaskCancelled    ErrorCode = "TASK_CANCELLED"
 	ErrTaskTimeout      ErrorCode = "TASK_TIMEOUT"
+	ErrCommandDenied    ErrorCode = "COMMAND_DENIED"
 	ErrWorktreeNotFound ErrorCode = "WORKTREE_NOT_FOUND"
 	ErrWorktreeFailed   ErrorCode = "WORKTREE_FAILED"
+	ErrDiskQuota        ErrorCode = "DISK_QUOTA_EXCEEDED"
+	ErrQueueFull        ErrorCode = "QUEUE_FULL"
+	ErrWebhookFailed    ErrorCode = "WEBHOOK_DELIVERY_FAILED"
+	ErrPullRequest      ErrorCode = "PULL_REQUEST_FAILED"
 
 	// MCP 协议错误
 	ErrMCPProtocolError ErrorCode = "MCP_PROTOCOL_ERROR"
 	ErrMCPServerError   ErrorCode = "MCP_SERVER_ERROR"
 	ErrMCPClientError   ErrorCode = "MCP_CLIENT_ERROR"
+	ErrResourceNotFound ErrorCode = "RESOURCE_NOT_FOUND"
+
+	// 认证错误
+	ErrUnauthorized ErrorCode = "UNAUTHORIZED"
+	ErrTokenExpired ErrorCode = "TOKEN_EXPIRED"
+	ErrForbidden    ErrorCode = "FORBIDDEN"
+
+	// 请求错误
+	ErrInvalidRequest   ErrorCode = "INVALID_REQUEST"
+	ErrMethodNotAllowed ErrorCode = "METHOD_NOT_ALLOWED"
+	ErrConflict         ErrorCode = "CONFLICT"
+	ErrRateLimited      ErrorCode = "RATE_LIMITED"
+	ErrQuotaExceeded    ErrorCode = "QUOTA_EXCEEDED"
+	ErrRequestTooLarge  ErrorCode = "REQUEST_TOO_LARGE"
+	ErrInternal         ErrorCode = "INTERNAL_ERROR"
 
 	// 配置错误
 	ErrConfigInvalid  ErrorCode = "CONFIG_INVALID"
@@ -120,6 +144,21 @@ func IsCode(err error, code ErrorCode) bool {
 	return false
 }
 
+// HasCode 检查错误链中是否有任意一层为指定的错误代码
+func HasCode(err error, code ErrorCode) bool {
+	for err != nil {
+		var appErr *AppError
+		if !errors.As(err, &appErr) {
+			return false
+		}
+		if appErr.Code == code {
+			return true
+		}
+		err = appErr.Cause
+	}
+	return false
+}
+
 // GetCode 获取错误代码
 func GetCode(err error) ErrorCode {
 	var appErr *AppError
@@ -0,0 +1,75 @@
+package errors
+
+import "net/http"
+
+// httpStatusByCode 错误代码对应的标准HTTP状态码，未列出的代码视为服务器内部错误
+var httpStatusByCode = map[ErrorCode]int{
+	// 路径转换错误
+	ErrInvalidPath:    http.StatusBadRequest,
+	ErrPathNotExists:  http.StatusBadRequest,
+	ErrPathConversion: http.StatusBadRequest,
+
+	// WSL 相关错误
+	ErrWSLNotFound:      http.StatusServiceUnavailable,
+	ErrDistroNotFound:   http.StatusBadRequest,
+	ErrWSLCommandFailed: http.StatusBadGateway,
+
+	// Claude Code 相关错误
+	ErrClaudeCodeNotFound:     http.StatusServiceUnavailable,
+	ErrClaudeCodeFailed:       http.StatusBadGateway,
+	ErrClaudeCodeInstall:      http.StatusInternalServerError,
+	ErrClaudeCodeAuthRequired: http.StatusServiceUnavailable,
+	ErrClaudeCodeVersion:      http.StatusServiceUnavailable,
+	ErrTerminalLaunch:         http.StatusInternalServerError,
+
+	// 任务管理错误
+	ErrTaskNotSupported: http.StatusBadRequest,
+	ErrInstanceFailed:   http.StatusServiceUnavailable,
+	ErrGitOperation:     http.StatusInternalServerError,
+	ErrTaskNotFound:     http.StatusNotFound,
+	ErrTaskCancelled:    http.StatusConflict,
+	ErrTaskTimeout:      http.StatusGatewayTimeout,
+	ErrCommandDenied:    http.StatusForbidden,
+	ErrWorktreeNotFound: http.StatusNotFound,
+	ErrWorktreeFailed:   http.StatusInternalServerError,
+	ErrDiskQuota:        http.StatusInsufficientStorage,
+	ErrQueueFull:        http.StatusServiceUnavailable,
+	ErrPullRequest:      http.StatusBadGateway,
+
+	// MCP 协议错误
+	ErrMCPProtocolError: http.StatusBadRequest,
+	ErrMCPServerError:   http.StatusInternalServerError,
+	ErrMCPClientError:   http.StatusBadRequest,
+	ErrResourceNotFound: http.StatusNotFound,
+
+	// 认证错误
+	ErrUnauthorized: http.StatusUnauthorized,
+	ErrTokenExpired: http.StatusUnauthorized,
+	ErrForbidden:    http.StatusForbidden,
+
+	// 请求错误
+	ErrInvalidRequest:   http.StatusBadRequest,
+	ErrMethodNotAllowed: http.StatusMethodNotAllowed,
+	ErrConflict:         http.StatusConflict,
+	ErrRateLimited:      http.StatusTooManyRequests,
+	ErrQuotaExceeded:    http.StatusTooManyRequests,
+	ErrRequestTooLarge:  http.StatusRequestEntityTooLarge,
+	ErrInternal:         http.StatusInternalServerError,
+
+	// 配置错误
+	ErrConfigInvalid:  http.StatusBadRequest,
+	ErrConfigNotFound: http.StatusInternalServerError,
+}
+
+// HTTPStatus 获取错误代码对应的HTTP状态码
+func (c ErrorCode) HTTPStatus() int {
+	if status, ok := httpStatusByCode[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// HTTPStatus 获取错误对应的HTTP状态码，非应用程序错误视为服务器内部错误
+func HTTPStatus(err error) int {
+	return GetCode(err).HTTPStatus()
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"go.uber.org/zap"
+)
+
+// RequestIDHeader 请求ID的HTTP头
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern 允许客户端传入的请求ID格式
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// requestIDContextKey 请求上下文中的请求ID键
+type requestIDContextKey struct{}
+
+// NewRequestID 生成新的请求ID
+func NewRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "req-unknown"
+	}
+	return hex.EncodeToString(buf)
+}
+
+// ValidRequestID 客户端传入的请求ID是否可以沿用
+func ValidRequestID(id string) bool {
+	return requestIDPattern.MatchString(id)
+}
+
+// WithRequestID 在上下文中记录请求ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext 获取上下文中的请求ID
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// FromContext 返回带有上下文请求ID字段的日志器，上下文中没有请求ID时返回原日志器
+func FromContext(ctx context.Context, l Logger) Logger {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return l.With(zap.String("request_id", id))
+	}
+	return l
+}
@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"fmt"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -20,6 +22,7 @@ type Logger interface {
 // zapLogger zap 日志器包装
 type zapLogger struct {
 	logger *zap.Logger
+	level  *zap.AtomicLevel // 构建时的日志级别，FromZap 包装的日志器为 nil
 }
 
 // NewLogger 创建新的日志器
@@ -57,7 +60,7 @@ func NewLogger(level string, debug bool) (Logger, error) {
 		return nil, err
 	}
 
-	return &zapLogger{logger: logger}, nil
+	return &zapLogger{logger: logger, level: &config.Level}, nil
 }
 
 // NewConsoleLogger 创建控制台日志器
@@ -88,7 +91,7 @@ func NewConsoleLogger(level string) (Logger, error) {
 		return nil, err
 	}
 
-	return &zapLogger{logger: logger}, nil
+	return &zapLogger{logger: logger, level: &config.Level}, nil
 }
 
 // NewFileLogger 创建文件日志器
@@ -120,7 +123,12 @@ func NewFileLogger(level, filePath string) (Logger, error) {
 		return nil, err
 	}
 
-	return &zapLogger{logger: logger}, nil
+	return &zapLogger{logger: logger, level: &config.Level}, nil
+}
+
+// FromZap 包装已有的 zap.Logger
+func FromZap(logger *zap.Logger) Logger {
+	return &zapLogger{logger: logger}
 }
 
 // Debug 记录调试日志
@@ -150,7 +158,7 @@ func (l *zapLogger) Fatal(msg string, fields ...zap.Field) {
 
 // With 添加字段到日志器
 func (l *zapLogger) With(fields ...zap.Field) Logger {
-	return &zapLogger{logger: l.logger.With(fields...)}
+	return &zapLogger{logger: l.logger.With(fields...), level: l.level}
 }
 
 // Sync 同步日志缓冲区
@@ -163,6 +171,21 @@ func (l *zapLogger) GetZapLogger() *zap.Logger {
 	return l.logger
 }
 
+// SetLevel 在运行时调整日志器的级别，由它派生的日志器（包括经 GetZapLogger 包装的）同时生效
+// 只支持由 NewLogger、NewConsoleLogger 和 NewFileLogger 创建的日志器
+func SetLevel(l Logger, level string) error {
+	zl, ok := l.(*zapLogger)
+	if !ok || zl.level == nil {
+		return fmt.Errorf("日志器不支持调整级别")
+	}
+	parsed, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("无效的日志级别: %s", level)
+	}
+	zl.level.SetLevel(parsed)
+	return nil
+}
+
 // 全局日志器实例
 var globalLogger Logger
 
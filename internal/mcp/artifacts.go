@@ -0,0 +1,291 @@
+package mcp
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// 固定的产物名称，匹配 mcp.artifacts.patterns 的文件保存在 files/ 下并保留相对工作树的路径
+const (
+	artifactDiff         = "diff.patch"
+	artifactChangedFiles = "changed-files.txt"
+	artifactFilesDir     = "files"
+)
+
+// TaskArtifact 任务产物，name 为相对任务产物目录的路径
+type TaskArtifact struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// artifactDir 获取任务的产物目录
+func (tm *taskManager) artifactDir(taskID string) string {
+	return filepath.Join(tm.config.Artifacts.ArtifactPath(), taskID)
+}
+
+// recordArtifacts Claude Code 结束后收集工作树中的产物，并记录到任务结果的 Artifacts
+// 收集失败只记录日志，不影响任务结果
+func (tm *taskManager) recordArtifacts(ctx context.Context, status *TaskStatus, worktree *WorktreeInfo) {
+	if !tm.config.Artifacts.Enabled {
+		return
+	}
+
+	artifacts, err := tm.collectArtifacts(ctx, status.ID, worktree)
+	if err != nil {
+		logger.FromContext(ctx, tm.logger).Warn("收集任务产物失败",
+			zap.String("taskId", status.ID),
+			zap.String("worktreeId", worktree.ID),
+			zap.Error(err))
+	}
+	if len(artifacts) == 0 {
+		return
+	}
+
+	tm.tasksMutex.Lock()
+	if result, ok := status.Result.(*TaskResult); ok {
+		result.Artifacts = artifacts
+	}
+	tm.tasksMutex.Unlock()
+}
+
+// collectArtifacts 将工作树的 diff、修改的文件列表和匹配 patterns 的文件写入任务产物目录，返回已收集的产物名称
+// 重试时产物目录重新创建；工作树不是 Git 仓库时只收集匹配的文件
+func (tm *taskManager) collectArtifacts(ctx context.Context, taskID string, worktree *WorktreeInfo) ([]string, error) {
+	cfg := tm.config.Artifacts
+	dir := tm.artifactDir(taskID)
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, apperrors.Wrapf(err, apperrors.ErrInternal, "无法清空任务产物目录: %s", dir)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, apperrors.Wrapf(err, apperrors.ErrInternal, "无法创建任务产物目录: %s", dir)
+	}
+	log := logger.FromContext(ctx, tm.logger)
+
+	var artifacts []string
+	writeArtifact := func(name, content string) error {
+		if content == "" {
+			return nil
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			return apperrors.Wrapf(err, apperrors.ErrInternal, "写入任务产物失败: %s", name)
+		}
+		artifacts = append(artifacts, name)
+		return nil
+	}
+
+	if diff, err := tm.worktreeManager.GetWorktreeDiff(ctx, worktree.ID); err != nil {
+		log.Debug("跳过工作树 diff", zap.String("worktreeId", worktree.ID), zap.Error(err))
+	} else if err := writeArtifact(artifactDiff, diff); err != nil {
+		return artifacts, err
+	}
+
+	if changed, err := tm.worktreeManager.GetChangedFiles(ctx, worktree.ID); err != nil {
+		log.Debug("跳过修改的文件列表", zap.String("worktreeId", worktree.ID), zap.Error(err))
+	} else if len(changed) > 0 {
+		if err := writeArtifact(artifactChangedFiles, strings.Join(changed, "\n")+"\n"); err != nil {
+			return artifacts, err
+		}
+	}
+
+	if len(cfg.Patterns) == 0 || worktree.Path == "" {
+		return artifacts, nil
+	}
+
+	var copied int
+	err := filepath.WalkDir(worktree.Path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(worktree.Path, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !matchArtifactPatterns(cfg.Patterns, rel) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if cfg.MaxFileBytes > 0 && info.Size() > cfg.MaxFileBytes {
+			log.Warn("产物文件超过大小上限，已跳过",
+				zap.String("taskId", taskID),
+				zap.String("file", rel),
+				zap.Int64("size", info.Size()))
+			return nil
+		}
+		if cfg.MaxFiles > 0 && copied >= cfg.MaxFiles {
+			log.Warn("匹配的产物文件超过数量上限，其余文件已跳过", zap.String("taskId", taskID), zap.Int("maxFiles", cfg.MaxFiles))
+			return filepath.SkipAll
+		}
+
+		name := path.Join(artifactFilesDir, rel)
+		if err := copyArtifactFile(file, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			return err
+		}
+		artifacts = append(artifacts, name)
+		copied++
+		return nil
+	})
+	if err != nil {
+		return artifacts, apperrors.Wrapf(err, apperrors.ErrInternal, "收集匹配的产物文件失败: %s", worktree.Path)
+	}
+	return artifacts, nil
+}
+
+// matchArtifactPatterns 判断相对工作树的路径是否匹配任一 glob，** 匹配任意层目录
+func matchArtifactPatterns(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchGlobSegments(strings.Split(filepath.ToSlash(pattern), "/"), strings.Split(name, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlobSegments 按路径段匹配 glob
+func matchGlobSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchGlobSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// copyArtifactFile 复制产物文件，按需创建目标目录
+func copyArtifactFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// ListTaskArtifacts 列出任务已收集的产物，任务尚未结束或没有产物时返回空列表
+func (tm *taskManager) ListTaskArtifacts(ctx context.Context, taskID string) ([]TaskArtifact, error) {
+	if err := tm.checkArtifactTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	dir := tm.artifactDir(taskID)
+	artifacts := []TaskArtifact{}
+	err := filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && file == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, TaskArtifact{Name: filepath.ToSlash(rel), Size: info.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, apperrors.Wrapf(err, apperrors.ErrInternal, "读取任务产物目录失败: %s", dir)
+	}
+	return artifacts, nil
+}
+
+// OpenTaskArtifact 打开任务的产物文件，name 为 ListTaskArtifacts 返回的名称
+func (tm *taskManager) OpenTaskArtifact(ctx context.Context, taskID, name string) (*os.File, error) {
+	if err := tm.checkArtifactTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+	local := filepath.FromSlash(name)
+	if name == "" || strings.Contains(name, "\\") || !filepath.IsLocal(local) {
+		return nil, apperrors.Newf(apperrors.ErrInvalidRequest, "无效的产物名称: %s", name)
+	}
+
+	file, err := os.Open(filepath.Join(tm.artifactDir(taskID), local))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, apperrors.Newf(apperrors.ErrResourceNotFound, "任务产物不存在: %s", name)
+		}
+		return nil, apperrors.Wrapf(err, apperrors.ErrInternal, "读取任务产物失败: %s", name)
+	}
+	if info, err := file.Stat(); err != nil || !info.Mode().IsRegular() {
+		file.Close()
+		return nil, apperrors.Newf(apperrors.ErrResourceNotFound, "任务产物不存在: %s", name)
+	}
+	return file, nil
+}
+
+// checkArtifactTask 检查任务存在且已启用产物收集
+func (tm *taskManager) checkArtifactTask(ctx context.Context, taskID string) error {
+	tm.tasksMutex.RLock()
+	status, exists := tm.tasks[taskID]
+	exists = exists && tm.canAccessTask(ctx, status)
+	tm.tasksMutex.RUnlock()
+	if !exists {
+		return apperrors.Newf(apperrors.ErrTaskNotFound, "任务不存在: %s", taskID)
+	}
+	if !tm.config.Artifacts.Enabled {
+		return apperrors.New(apperrors.ErrResourceNotFound, "未启用任务产物收集 (mcp.artifacts.enabled)")
+	}
+	return nil
+}
+
+// removeTaskArtifacts 删除任务的产物目录
+func (tm *taskManager) removeTaskArtifacts(taskID string) {
+	if err := os.RemoveAll(tm.artifactDir(taskID)); err != nil {
+		tm.logger.Warn("删除任务产物失败", zap.String("taskId", taskID), zap.Error(err))
+	}
+}
@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// artifactWorktreeManager 返回固定 diff 和修改文件列表的 worktree 管理器
+type artifactWorktreeManager struct {
+	WorktreeManager
+}
+
+func (artifactWorktreeManager) GetWorktreeDiff(ctx context.Context, worktreeID string) (string, error) {
+	return "diff --git a/main.go b/main.go\n", nil
+}
+
+func (artifactWorktreeManager) GetChangedFiles(ctx context.Context, worktreeID string) ([]string, error) {
+	return []string{"main.go", "reports/unit/result.html"}, nil
+}
+
+func TestMatchArtifactPatterns(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"coverage.out", "coverage.out", true},
+		{"coverage.out", "sub/coverage.out", false},
+		{"*.log", "build.log", true},
+		{"reports/**/*.html", "reports/index.html", true},
+		{"reports/**/*.html", "reports/unit/a/result.html", true},
+		{"reports/**/*.html", "docs/reports/index.html", false},
+		{"**/*.xml", "junit.xml", true},
+		{"**/*.xml", "a/b/junit.xml", true},
+		{"dist/**", "dist/app/main.js", true},
+		{"dist/**", "src/main.js", false},
+	}
+	for _, tt := range tests {
+		if got := matchArtifactPatterns([]string{tt.pattern}, tt.name); got != tt.want {
+			t.Errorf("matchArtifactPatterns(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCollectArtifacts(t *testing.T) {
+	ctx := context.Background()
+	worktreeDir := t.TempDir()
+	for name, content := range map[string]string{
+		"main.go":                  "package main",
+		"reports/unit/result.html": "<html></html>",
+		"reports/big.html":         "0123456789abcdef",
+		".git/reports/x.html":      "ignored",
+	} {
+		file := filepath.Join(worktreeDir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(file), 0700)
+		os.WriteFile(file, []byte(content), 0600)
+	}
+
+	tm := newQueueTestManager()
+	tm.worktreeManager = artifactWorktreeManager{}
+	tm.config = &config.MCPConfig{Artifacts: config.ArtifactsConfig{
+		Enabled:      true,
+		Dir:          t.TempDir(),
+		Patterns:     []string{"**/*.html"},
+		MaxFileBytes: 15,
+	}}
+	status, err := tm.SubmitTask(ctx, &TaskRequest{ID: "task_1", ProjectPath: "/app"})
+	if err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	status.Result = &TaskResult{}
+
+	tm.recordArtifacts(ctx, status, &WorktreeInfo{ID: "wt_1", Path: worktreeDir})
+	// 超过大小上限的文件和 .git 目录中的文件不收集
+	want := []string{artifactDiff, artifactChangedFiles, "files/reports/unit/result.html"}
+	if got := status.Result.(*TaskResult).Artifacts; !reflect.DeepEqual(got, want) {
+		t.Errorf("Artifacts = %v, want %v", got, want)
+	}
+
+	artifacts, err := tm.ListTaskArtifacts(ctx, "task_1")
+	if err != nil || len(artifacts) != len(want) {
+		t.Fatalf("ListTaskArtifacts() = %+v, %v", artifacts, err)
+	}
+
+	file, err := tm.OpenTaskArtifact(ctx, "task_1", artifactChangedFiles)
+	if err != nil {
+		t.Fatalf("OpenTaskArtifact() error = %v", err)
+	}
+	data, _ := io.ReadAll(file)
+	file.Close()
+	if string(data) != "main.go\nreports/unit/result.html\n" {
+		t.Errorf("changed-files.txt = %q", data)
+	}
+
+	for _, name := range []string{"../task_1/diff.patch", "/etc/passwd", "files\\..\\diff.patch", ""} {
+		if _, err := tm.OpenTaskArtifact(ctx, "task_1", name); !apperrors.IsCode(err, apperrors.ErrInvalidRequest) {
+			t.Errorf("OpenTaskArtifact(%q) error = %v", name, err)
+		}
+	}
+	if _, err := tm.OpenTaskArtifact(ctx, "task_1", "files"); !apperrors.IsCode(err, apperrors.ErrResourceNotFound) {
+		t.Errorf("打开目录 error = %v", err)
+	}
+	if _, err := tm.ListTaskArtifacts(ctx, "missing"); !apperrors.IsCode(err, apperrors.ErrTaskNotFound) {
+		t.Errorf("不存在的任务 error = %v", err)
+	}
+}
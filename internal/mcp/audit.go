@@ -0,0 +1,230 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"auto-claude-code/internal/audit"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/wsl"
+)
+
+// auditedTaskManager 记录任务提交、取消、重新运行、备注和 shell 命令的审计事件
+// REST 和 MCP 工具调用共用同一个实例，两条入口都会被记录
+type auditedTaskManager struct {
+	TaskManager
+	audit *audit.Log
+}
+
+// SubmitTask 提交任务并记录审计事件
+func (m *auditedTaskManager) SubmitTask(ctx context.Context, req *TaskRequest) (*TaskStatus, error) {
+	status, err := m.TaskManager.SubmitTask(ctx, req)
+
+	params := map[string]interface{}{
+		"type":        req.Type,
+		"projectPath": req.ProjectPath,
+	}
+	if req.Command != "" {
+		params["command"] = req.Command
+	}
+	if len(req.Args) > 0 {
+		params["args"] = req.Args
+	}
+	if req.Priority != 0 {
+		params["priority"] = req.Priority
+	}
+	if req.Distro != "" {
+		params["distro"] = req.Distro
+	}
+	if len(req.DistroTags) > 0 {
+		params["distroTags"] = req.DistroTags
+	}
+	if req.Model != "" {
+		params["model"] = req.Model
+	}
+	if req.PermissionMode != "" {
+		params["permissionMode"] = req.PermissionMode
+	}
+	m.audit.Record(ctx, audit.ActionTaskSubmit, req.ID, params, err)
+
+	return status, err
+}
+
+// CancelTask 取消任务并记录审计事件
+func (m *auditedTaskManager) CancelTask(ctx context.Context, taskID string) error {
+	err := m.TaskManager.CancelTask(ctx, taskID)
+	m.audit.Record(ctx, audit.ActionTaskCancel, taskID, nil, err)
+	return err
+}
+
+// AddTaskNote 添加任务备注并记录审计事件
+func (m *auditedTaskManager) AddTaskNote(ctx context.Context, taskID string, note TaskNote) (*TaskNote, error) {
+	added, err := m.TaskManager.AddTaskNote(ctx, taskID, note)
+	m.audit.Record(ctx, audit.ActionTaskNote, taskID, map[string]interface{}{"text": note.Text}, err)
+	return added, err
+}
+
+// RerunTask 重新运行任务并记录审计事件
+func (m *auditedTaskManager) RerunTask(ctx context.Context, taskID string, overrides RerunOverrides) (*TaskStatus, error) {
+	status, err := m.TaskManager.RerunTask(ctx, taskID, overrides)
+
+	params := map[string]interface{}{}
+	if status != nil {
+		params["rerunId"] = status.ID
+	}
+	if overrides.Command != "" {
+		params["command"] = overrides.Command
+	}
+	if overrides.PromptSuffix != "" {
+		params["promptSuffix"] = overrides.PromptSuffix
+	}
+	if overrides.Model != "" {
+		params["model"] = overrides.Model
+	}
+	m.audit.Record(ctx, audit.ActionTaskRerun, taskID, params, err)
+
+	return status, err
+}
+
+// AttachTask 连接交互式任务的终端会话并记录审计事件
+func (m *auditedTaskManager) AttachTask(ctx context.Context, taskID string) (*SessionAttachment, error) {
+	attachment, err := m.TaskManager.AttachTask(ctx, taskID)
+	m.audit.Record(ctx, audit.ActionTaskAttach, taskID, nil, err)
+	return attachment, err
+}
+
+// MergeTask 合并任务并记录审计事件
+func (m *auditedTaskManager) MergeTask(ctx context.Context, taskID string, opts MergeOptions) (*MergeResult, error) {
+	result, err := m.TaskManager.MergeTask(ctx, taskID, opts)
+
+	params := map[string]interface{}{"strategy": opts.Strategy, "dryRun": opts.DryRun}
+	if result != nil {
+		params["status"] = result.Status
+		if result.MergeCommit != "" {
+			params["mergeCommit"] = result.MergeCommit
+		}
+	}
+	m.audit.Record(ctx, audit.ActionTaskMerge, taskID, params, err)
+
+	return result, err
+}
+
+// RunShellCommand 运行 shell 命令并记录审计事件
+func (m *auditedTaskManager) RunShellCommand(ctx context.Context, req *ShellCommandRequest) (*wsl.ExecResult, error) {
+	result, err := m.TaskManager.RunShellCommand(ctx, req)
+
+	params := map[string]interface{}{"command": req.Command}
+	if req.TaskID != "" {
+		params["taskId"] = req.TaskID
+	}
+	if req.WorktreeID != "" {
+		params["worktreeId"] = req.WorktreeID
+	}
+	if req.ProjectPath != "" {
+		params["projectPath"] = req.ProjectPath
+	}
+	if result != nil {
+		params["exitCode"] = result.ExitCode
+	}
+	m.audit.Record(ctx, audit.ActionShellRun, "", params, err)
+
+	return result, err
+}
+
+// auditedWorktreeManager 记录通过接口删除和固定 worktree 的审计事件
+// 任务管理器内部的清理不经过此包装，不会被记录为用户操作
+type auditedWorktreeManager struct {
+	WorktreeManager
+	audit *audit.Log
+}
+
+// DeleteWorktree 删除 worktree 并记录审计事件
+func (m *auditedWorktreeManager) DeleteWorktree(ctx context.Context, worktreeID string) error {
+	err := m.WorktreeManager.DeleteWorktree(ctx, worktreeID)
+	m.audit.Record(ctx, audit.ActionWorktreeDelete, worktreeID, nil, err)
+	return err
+}
+
+// SetWorktreePinned 固定或取消固定 worktree 并记录审计事件
+func (m *auditedWorktreeManager) SetWorktreePinned(ctx context.Context, worktreeID string, pinned bool) (*WorktreeInfo, error) {
+	worktree, err := m.WorktreeManager.SetWorktreePinned(ctx, worktreeID, pinned)
+	m.audit.Record(ctx, audit.ActionWorktreePin, worktreeID, map[string]interface{}{"pinned": pinned}, err)
+	return worktree, err
+}
+
+// auditAuthFailure 记录认证或权限检查失败
+func (s *mcpServer) auditAuthFailure(r *http.Request, reason string, err error) {
+	s.recordAuthFailure(r.Context(), s.getClientIP(r), r.URL.Path, r.Method, reason, err)
+}
+
+// recordAuthFailure 记录认证或权限检查失败，resource 为请求路径或 gRPC 方法
+func (s *mcpServer) recordAuthFailure(ctx context.Context, sourceIP, resource, method, reason string, err error) {
+	if s.auditLog == nil {
+		return
+	}
+
+	event := audit.NewEvent(ctx, audit.ActionAuthFailure)
+	event.Outcome = audit.OutcomeDenied
+	event.SourceIP = sourceIP
+	event.Resource = resource
+	event.Params = map[string]interface{}{
+		"method": method,
+		"reason": reason,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	s.auditLog.Append(event)
+}
+
+// handleAudit 查询审计事件
+// 查询参数：action（以 "." 结尾时按前缀匹配）、actor、outcome、since/until（RFC 3339）、limit
+func (s *mcpServer) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持GET方法"))
+		return
+	}
+
+	query := r.URL.Query()
+	filter := audit.Filter{
+		Action:  query.Get("action"),
+		Actor:   query.Get("actor"),
+		Outcome: query.Get("outcome"),
+	}
+
+	for name, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := query.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeProblem(w, r, apperrors.Newf(apperrors.ErrInvalidRequest, "无效的 %s 参数，需要 RFC 3339 格式: %s", name, v))
+				return
+			}
+			*target = t
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeProblem(w, r, apperrors.New(apperrors.ErrInvalidRequest, "无效的 limit 参数"))
+			return
+		}
+		filter.Limit = n
+	}
+
+	events, err := s.auditLog.Query(filter)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(auditListResponse{Events: events})
+}
+
+// auditListResponse 审计事件查询响应
+type auditListResponse struct {
+	Events []audit.Event `json:"events"`
+}
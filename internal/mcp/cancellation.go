@@ -0,0 +1,76 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// NotificationCancelled MCP请求取消通知方法
+const NotificationCancelled = "notifications/cancelled"
+
+// CancelledParams notifications/cancelled 参数
+type CancelledParams struct {
+	RequestID JSONRPCID `json:"requestId"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// inflightRequest 进行中的请求
+type inflightRequest struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// requestTracker 记录会话中进行中的请求，收到 notifications/cancelled 时取消对应请求的上下文
+type requestTracker struct {
+	mu       sync.Mutex
+	requests map[string]*inflightRequest
+}
+
+// newRequestTracker 创建请求跟踪器
+func newRequestTracker() *requestTracker {
+	return &requestTracker{requests: make(map[string]*inflightRequest)}
+}
+
+// requestKey 请求ID只在会话内唯一，数字和字符串ID视为不同请求
+func requestKey(sessionID string, id JSONRPCID) string {
+	return fmt.Sprintf("%s/%T/%v", sessionID, id, id)
+}
+
+// track 登记请求并返回可被取消的上下文，请求处理结束后必须调用 finish，返回值表示请求是否已被客户端取消
+func (rt *requestTracker) track(ctx context.Context, sessionID string, id JSONRPCID) (context.Context, func() bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	key := requestKey(sessionID, id)
+	req := &inflightRequest{cancel: cancel}
+
+	rt.mu.Lock()
+	rt.requests[key] = req
+	rt.mu.Unlock()
+
+	return ctx, func() bool {
+		rt.mu.Lock()
+		if rt.requests[key] == req {
+			delete(rt.requests, key)
+		}
+		cancelled := req.cancelled
+		rt.mu.Unlock()
+
+		cancel()
+		return cancelled
+	}
+}
+
+// cancel 取消进行中的请求，请求不存在（已完成或ID未知）时返回 false
+func (rt *requestTracker) cancel(sessionID string, id JSONRPCID) bool {
+	rt.mu.Lock()
+	req, ok := rt.requests[requestKey(sessionID, id)]
+	if ok {
+		req.cancelled = true
+	}
+	rt.mu.Unlock()
+
+	if ok {
+		req.cancel()
+	}
+	return ok
+}
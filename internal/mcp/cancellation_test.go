@@ -0,0 +1,40 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRequestTracker(t *testing.T) {
+	tracker := newRequestTracker()
+
+	ctx, finish := tracker.track(context.Background(), "s1", float64(1))
+
+	// 其他会话或ID类型不同的取消通知不影响该请求
+	if tracker.cancel("s2", float64(1)) || tracker.cancel("s1", "1") {
+		t.Fatal("不应取消其他会话或其他ID的请求")
+	}
+	if ctx.Err() != nil {
+		t.Fatal("请求上下文不应被取消")
+	}
+
+	if !tracker.cancel("s1", float64(1)) {
+		t.Fatal("应取消进行中的请求")
+	}
+	if ctx.Err() == nil {
+		t.Error("请求上下文应已取消")
+	}
+	if !finish() {
+		t.Error("finish 应报告请求已被取消")
+	}
+
+	// 请求完成后的取消通知被忽略
+	if tracker.cancel("s1", float64(1)) {
+		t.Error("已完成的请求不应再被取消")
+	}
+
+	_, finish = tracker.track(context.Background(), "s1", float64(2))
+	if finish() {
+		t.Error("未被取消的请求 finish 应返回 false")
+	}
+}
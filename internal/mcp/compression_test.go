@@ -0,0 +1,110 @@
+package mcp
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, gzip;q=0.5", true},
+		{"GZIP", true},
+		{"gzip;q=0", false},
+		{"*", true},
+		{"br, deflate", false},
+	}
+
+	for _, tt := range tests {
+		if got := acceptsGzip(tt.header); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestGzipMiddleware(t *testing.T) {
+	large := strings.Repeat(`{"id":"task"}`, 200)
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		acceptEncoding string
+		wantGzip       bool
+	}{
+		{"大JSON响应", "application/json", large, "gzip", true},
+		{"小响应不压缩", "application/json", `{"ok":true}`, "gzip", false},
+		{"客户端不支持", "application/json", large, "", false},
+		{"SSE不压缩", "text/event-stream", large, "gzip", false},
+		{"二进制不压缩", "application/octet-stream", large, "gzip", false},
+	}
+
+	server := &mcpServer{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := server.gzipMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(http.StatusCreated)
+				// 分多次写入，验证缓冲跨越压缩阈值
+				io.WriteString(w, tt.body[:len(tt.body)/2])
+				io.WriteString(w, tt.body[len(tt.body)/2:])
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Errorf("状态码 = %d", w.Code)
+			}
+			if w.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("Vary = %q", w.Header().Get("Vary"))
+			}
+
+			body := w.Body.Bytes()
+			gzipped := w.Header().Get("Content-Encoding") == "gzip"
+			if gzipped != tt.wantGzip {
+				t.Fatalf("Content-Encoding = %q", w.Header().Get("Content-Encoding"))
+			}
+			if gzipped {
+				zr, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatalf("gzip.NewReader() error = %v", err)
+				}
+				if body, err = io.ReadAll(zr); err != nil {
+					t.Fatalf("解压失败: %v", err)
+				}
+			}
+			if string(body) != tt.body {
+				t.Errorf("响应体长度 = %d, want %d", len(body), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestGzipMiddlewareNoContent(t *testing.T) {
+	server := &mcpServer{}
+	handler := server.gzipMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodDelete, "/tasks/t1", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent || w.Header().Get("Content-Encoding") != "" || w.Body.Len() != 0 {
+		t.Errorf("状态码 = %d, Content-Encoding = %q, 响应体 = %q", w.Code, w.Header().Get("Content-Encoding"), w.Body.String())
+	}
+}
@@ -0,0 +1,54 @@
+package mcp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// writeJSONWithETag 以 JSON 写出响应并附带弱 ETag，请求的 If-None-Match 匹配时返回 304
+// ETag 由响应体计算，内容不变时 TUI 等轮询客户端无需重复下载
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeProblem(w, r, apperrors.Wrap(err, apperrors.ErrInternal, "序列化响应失败"))
+		return
+	}
+	writeWithETag(w, r, "application/json", append(body, '\n'))
+}
+
+// writeWithETag 写出响应体并附带由其计算的弱 ETag，请求的 If-None-Match 匹配时返回 304
+func writeWithETag(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	sum := sha256.Sum256(body)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+
+	h := w.Header()
+	h.Set("ETag", etag)
+	h.Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+// etagMatches 按弱比较检查 If-None-Match 是否包含 etag
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
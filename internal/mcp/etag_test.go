@@ -0,0 +1,49 @@
+package mcp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteJSONWithETag(t *testing.T) {
+	value := map[string]interface{}{"worktrees": []string{"wt1"}}
+
+	w := httptest.NewRecorder()
+	writeJSONWithETag(w, httptest.NewRequest(http.MethodGet, "/worktrees", nil), value)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || w.Body.String() != "{\"worktrees\":[\"wt1\"]}\n" {
+		t.Fatalf("状态码 = %d, ETag = %q, 响应体 = %q", w.Code, etag, w.Body.String())
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        int
+	}{
+		{"匹配", etag, http.StatusNotModified},
+		{"强校验形式也匹配", etag[2:], http.StatusNotModified},
+		{"列表中匹配", `"other", ` + etag, http.StatusNotModified},
+		{"通配符", "*", http.StatusNotModified},
+		{"不匹配", `W/"other"`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/worktrees", nil)
+			req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			w := httptest.NewRecorder()
+			writeJSONWithETag(w, req, value)
+
+			if w.Code != tt.want {
+				t.Errorf("状态码 = %d, want %d", w.Code, tt.want)
+			}
+			if w.Header().Get("ETag") != etag {
+				t.Errorf("ETag = %q, want %q", w.Header().Get("ETag"), etag)
+			}
+			if tt.want == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("304 响应不应包含响应体")
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// eventsPath 任务事件流端点
+const eventsPath = "/api/v1/events"
+
+// 任务事件流的事件名称
+const (
+	streamEventCreated   = "task.created"
+	streamEventStarted   = "task.started"
+	streamEventProgress  = "task.progress"
+	streamEventCompleted = "task.completed"
+	streamEventFailed    = "task.failed"
+	streamEventCancelled = "task.cancelled"
+	streamEventTimeout   = "task.timeout"
+	streamEventStatus    = "task.status" // 其他状态变化，如重新排队
+)
+
+const (
+	// eventHistorySize 保留的最近事件数，客户端断线重连时按 Last-Event-ID 补发
+	eventHistorySize = 256
+	// eventSubscriberBuffer 每个订阅者待发送事件的缓冲数量
+	eventSubscriberBuffer = 64
+)
+
+// taskStreamEvent 事件流中的一条任务事件
+type taskStreamEvent struct {
+	ID   uint64      `json:"-"`
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Task *TaskStatus `json:"task"`
+}
+
+// eventSubscriber 事件流订阅者
+type eventSubscriber struct {
+	taskID  string // 非空时只接收该任务的事件
+	owner   string // scoped 时只接收该身份提交的任务的事件
+	scoped  bool
+	events  chan *taskStreamEvent
+	dropped bool // 缓冲已满丢弃过事件，连接将被关闭以便客户端重连补发
+}
+
+// eventBroker 将任务管理器的事件分发给 SSE 订阅者
+type eventBroker struct {
+	logger logger.Logger
+
+	mu          sync.Mutex
+	nextID      uint64
+	history     []*taskStreamEvent
+	subscribers map[*eventSubscriber]struct{}
+	closed      chan struct{}
+	closeOnce   sync.Once
+}
+
+// newEventBroker 创建事件分发器
+func newEventBroker(log logger.Logger) *eventBroker {
+	return &eventBroker{
+		logger:      log,
+		subscribers: make(map[*eventSubscriber]struct{}),
+		closed:      make(chan struct{}),
+	}
+}
+
+// streamEventType 将任务事件映射为事件流的事件名称，输出事件不进入事件流
+func streamEventType(event TaskEvent) string {
+	switch event.Type {
+	case TaskEventCreated:
+		return streamEventCreated
+	case TaskEventProgress:
+		return streamEventProgress
+	case TaskEventStatus:
+		switch event.Task.Status {
+		case "running":
+			return streamEventStarted
+		case "completed":
+			return streamEventCompleted
+		case "failed":
+			return streamEventFailed
+		case "cancelled":
+			return streamEventCancelled
+		case "timeout":
+			return streamEventTimeout
+		default:
+			return streamEventStatus
+		}
+	default:
+		return ""
+	}
+}
+
+// HandleTaskEvent 记录任务事件并推送给订阅者，作为任务监听器同步调用，不会阻塞
+func (b *eventBroker) HandleTaskEvent(event TaskEvent) {
+	eventType := streamEventType(event)
+	if eventType == "" {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	streamEvent := &taskStreamEvent{ID: b.nextID, Type: eventType, Time: time.Now(), Task: event.Task}
+
+	b.history = append(b.history, streamEvent)
+	if len(b.history) > eventHistorySize {
+		b.history = b.history[len(b.history)-eventHistorySize:]
+	}
+
+	for sub := range b.subscribers {
+		if sub.dropped || !sub.matches(streamEvent) {
+			continue
+		}
+		select {
+		case sub.events <- streamEvent:
+		default:
+			sub.dropped = true
+			close(sub.events)
+			b.logger.Warn("事件流订阅者读取过慢，断开连接", zap.String("taskId", sub.taskID))
+		}
+	}
+}
+
+// subscribe 注册订阅者，返回 lastID 之后仍保留在历史中的事件
+func (b *eventBroker) subscribe(sub *eventSubscriber, lastID uint64) []*taskStreamEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var missed []*taskStreamEvent
+	if lastID > 0 {
+		for _, event := range b.history {
+			if event.ID > lastID && sub.matches(event) {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	sub.events = make(chan *taskStreamEvent, eventSubscriberBuffer)
+	b.subscribers[sub] = struct{}{}
+	return missed
+}
+
+// matches 检查事件是否发送给订阅者
+func (sub *eventSubscriber) matches(event *taskStreamEvent) bool {
+	if sub.taskID != "" && sub.taskID != event.Task.ID {
+		return false
+	}
+	return !sub.scoped || event.Task.Owner == sub.owner
+}
+
+// unsubscribe 取消订阅
+func (b *eventBroker) unsubscribe(sub *eventSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers, sub)
+}
+
+// close 结束所有事件流，服务器停止时调用
+func (b *eventBroker) close() {
+	b.closeOnce.Do(func() { close(b.closed) })
+}
+
+// writeStreamEvent 以 SSE 格式写出一条事件
+func writeStreamEvent(w http.ResponseWriter, event *taskStreamEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// handleEvents 以 SSE 推送任务创建、开始、进度、完成和失败事件
+// 查询参数 task 只订阅指定任务；断线重连时通过 Last-Event-ID 头补发期间错过的事件
+func (s *mcpServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持GET方法"))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeProblem(w, r, apperrors.New(apperrors.ErrInternal, "不支持流式响应"))
+		return
+	}
+
+	var lastID uint64
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			writeProblem(w, r, apperrors.Newf(apperrors.ErrInvalidRequest, "无效的 Last-Event-ID: %s", v))
+			return
+		}
+		lastID = id
+	}
+
+	// 事件流是长连接，取消HTTP服务器的写超时
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		s.logger.Debug("取消事件流写超时失败", zap.Error(err))
+	}
+
+	// 只能访问自己任务的身份只接收自己任务的事件
+	sub := &eventSubscriber{taskID: r.URL.Query().Get("task")}
+	sub.owner, sub.scoped = ownerScope(r.Context(), s.config)
+	missed := s.events.subscribe(sub, lastID)
+	defer s.events.unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range missed {
+		if err := writeStreamEvent(w, event); err != nil {
+			return
+		}
+	}
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepAlive, err := time.ParseDuration(s.config.SSE.KeepAlive)
+	if err != nil || keepAlive <= 0 {
+		keepAlive = 30 * time.Second
+	}
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.events.closed:
+			return
+		case <-ticker.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case event, ok := <-sub.events:
+			if !ok {
+				// 缓冲溢出，断开后客户端会带 Last-Event-ID 重连补发
+				return
+			}
+			if err := writeStreamEvent(w, event); err != nil {
+				logger.FromContext(r.Context(), s.logger).Debug("写出任务事件失败", zap.Error(err))
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
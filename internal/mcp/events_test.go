@@ -0,0 +1,135 @@
+package mcp
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/logger"
+)
+
+func newTestBroker(t *testing.T) *eventBroker {
+	t.Helper()
+
+	log, err := logger.CreateLoggerFromConfig("info", false, "")
+	if err != nil {
+		t.Fatalf("创建日志器失败: %v", err)
+	}
+	return newEventBroker(log)
+}
+
+func TestStreamEventType(t *testing.T) {
+	tests := []struct {
+		eventType string
+		status    string
+		want      string
+	}{
+		{TaskEventCreated, "pending", streamEventCreated},
+		{TaskEventStatus, "running", streamEventStarted},
+		{TaskEventProgress, "running", streamEventProgress},
+		{TaskEventStatus, "completed", streamEventCompleted},
+		{TaskEventStatus, "failed", streamEventFailed},
+		{TaskEventStatus, "cancelled", streamEventCancelled},
+		{TaskEventStatus, "pending", streamEventStatus},
+		{TaskEventOutput, "running", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.eventType+"/"+tt.status, func(t *testing.T) {
+			got := streamEventType(TaskEvent{Type: tt.eventType, Task: &TaskStatus{Status: tt.status}})
+			if got != tt.want {
+				t.Errorf("streamEventType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventBrokerReplay(t *testing.T) {
+	broker := newTestBroker(t)
+
+	broker.HandleTaskEvent(TaskEvent{Type: TaskEventCreated, Task: &TaskStatus{ID: "t1", Status: "pending"}})
+	broker.HandleTaskEvent(TaskEvent{Type: TaskEventCreated, Task: &TaskStatus{ID: "t2", Status: "pending"}})
+	broker.HandleTaskEvent(TaskEvent{Type: TaskEventStatus, Task: &TaskStatus{ID: "t1", Status: "running"}})
+
+	sub := &eventSubscriber{taskID: "t1"}
+	missed := broker.subscribe(sub, 1)
+	defer broker.unsubscribe(sub)
+	if len(missed) != 1 || missed[0].ID != 3 || missed[0].Type != streamEventStarted {
+		t.Fatalf("补发事件 = %+v", missed)
+	}
+
+	broker.HandleTaskEvent(TaskEvent{Type: TaskEventStatus, Task: &TaskStatus{ID: "t2", Status: "completed"}})
+	broker.HandleTaskEvent(TaskEvent{Type: TaskEventStatus, Task: &TaskStatus{ID: "t1", Status: "completed"}})
+
+	select {
+	case event := <-sub.events:
+		if event.Task.ID != "t1" || event.Type != streamEventCompleted {
+			t.Errorf("event = %+v", event)
+		}
+	default:
+		t.Fatal("订阅者未收到事件")
+	}
+	if len(sub.events) != 0 {
+		t.Error("不应收到其他任务的事件")
+	}
+}
+
+func TestEventBrokerSlowSubscriber(t *testing.T) {
+	broker := newTestBroker(t)
+	sub := &eventSubscriber{}
+	broker.subscribe(sub, 0)
+
+	for i := 0; i <= eventSubscriberBuffer; i++ {
+		broker.HandleTaskEvent(TaskEvent{Type: TaskEventProgress, Task: &TaskStatus{ID: "t1", Status: "running"}})
+	}
+
+	count := 0
+	for range sub.events {
+		count++
+	}
+	if count != eventSubscriberBuffer {
+		t.Errorf("收到 %d 个事件, want %d", count, eventSubscriberBuffer)
+	}
+}
+
+func TestHandleEvents(t *testing.T) {
+	server := &mcpServer{config: &config.MCPConfig{}, events: newTestBroker(t)}
+	server.logger = server.events.logger
+
+	ts := httptest.NewServer(http.HandlerFunc(server.handleEvents))
+	defer ts.Close()
+	defer server.events.close()
+
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("连接事件流失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %s", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	if line, _ := reader.ReadString('\n'); line != ": connected\n" {
+		t.Fatalf("首行 = %q", line)
+	}
+	reader.ReadString('\n')
+
+	server.events.HandleTaskEvent(TaskEvent{Type: TaskEventStatus, Task: &TaskStatus{ID: "t1", Status: "failed", CreatedAt: time.Now()}})
+
+	var lines []string
+	for len(lines) < 3 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("读取事件失败: %v", err)
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+	if lines[0] != "id: 1" || lines[1] != "event: task.failed" || !strings.Contains(lines[2], `"id":"t1"`) {
+		t.Errorf("事件 = %q", lines)
+	}
+}
@@ -0,0 +1,584 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "auto-claude-code/api/proto/autoclaudecode/v1"
+	"auto-claude-code/internal/audit"
+	"auto-claude-code/internal/auth"
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+	"auto-claude-code/internal/websocket"
+)
+
+// grpcMethodRoles 调用各 gRPC 方法所需的角色，与对应的 REST 接口相同，未列出的方法需要 viewer
+var grpcMethodRoles = map[string]auth.Role{
+	pb.TaskService_SubmitTask_FullMethodName:         auth.RoleSubmitter,
+	pb.TaskService_CancelTask_FullMethodName:         auth.RoleSubmitter,
+	pb.WorktreeService_DeleteWorktree_FullMethodName: auth.RoleAdmin,
+}
+
+// grpcMethodRole 获取调用 gRPC 方法所需的角色
+func grpcMethodRole(method string) auth.Role {
+	if role, ok := grpcMethodRoles[method]; ok {
+		return role
+	}
+	return auth.RoleViewer
+}
+
+// GRPCTransport gRPC 接口传输，单独监听 mcp.grpc.port
+type GRPCTransport struct {
+	server  *grpc.Server
+	address string
+	logger  logger.Logger
+}
+
+// newGRPCTransport 创建 gRPC 传输并注册 TaskService 和 WorktreeService
+func newGRPCTransport(s *mcpServer) *GRPCTransport {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.grpcUnaryInterceptor),
+		grpc.ChainStreamInterceptor(s.grpcStreamInterceptor),
+	)
+	pb.RegisterTaskServiceServer(server, &grpcTaskService{server: s})
+	pb.RegisterWorktreeServiceServer(server, &grpcWorktreeService{server: s})
+
+	return &GRPCTransport{
+		server:  server,
+		address: net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.GRPC.Port)),
+		logger:  s.logger,
+	}
+}
+
+// Start 启动gRPC传输，端口被占用时返回错误
+func (t *GRPCTransport) Start(ctx context.Context) error {
+	t.logger.Info("启动gRPC传输", zap.String("address", t.address))
+
+	listener, err := net.Listen("tcp", t.address)
+	if err != nil {
+		return apperrors.Wrap(err, apperrors.ErrMCPServerError, "gRPC监听失败")
+	}
+
+	go func() {
+		if err := t.server.Serve(listener); err != nil {
+			t.logger.Error("gRPC服务器异常退出", zap.Error(err))
+		}
+	}()
+
+	return nil
+}
+
+// Stop 停止gRPC传输，等待进行中的调用结束，ctx 取消时强制关闭连接
+func (t *GRPCTransport) Stop(ctx context.Context) error {
+	t.logger.Info("停止gRPC传输")
+
+	stopped := make(chan struct{})
+	go func() {
+		t.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		t.server.Stop()
+		return ctx.Err()
+	}
+}
+
+// GetType 获取传输类型
+func (t *GRPCTransport) GetType() string {
+	return string(TransportGRPC)
+}
+
+// GetAddress 获取传输地址
+func (t *GRPCTransport) GetAddress() string {
+	return t.address
+}
+
+// grpcUnaryInterceptor 认证和授权一元调用
+func (s *mcpServer) grpcUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authenticateGRPC(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// grpcStreamInterceptor 认证和授权流式调用
+func (s *mcpServer) grpcStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticateGRPC(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &grpcServerStream{ServerStream: ss, ctx: ctx})
+}
+
+// grpcServerStream 替换上下文的服务端流
+type grpcServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (ss *grpcServerStream) Context() context.Context { return ss.ctx }
+
+// authenticateGRPC 按 REST 接口的规则检查客户端IP、令牌和角色，返回附带请求ID、来源和身份的上下文
+// 令牌通过 authorization 元数据以 Bearer 方式携带
+func (s *mcpServer) authenticateGRPC(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	requestID := firstMetadata(md, strings.ToLower(logger.RequestIDHeader))
+	if !logger.ValidRequestID(requestID) {
+		requestID = logger.NewRequestID()
+	}
+	var clientIP string
+	if p, ok := peer.FromContext(ctx); ok {
+		clientIP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(clientIP); err == nil {
+			clientIP = host
+		}
+	}
+	ctx = audit.WithSourceIP(logger.WithRequestID(ctx, requestID), clientIP)
+
+	if !s.config.Auth.Enabled {
+		return ctx, nil
+	}
+
+	log := logger.FromContext(ctx, s.logger).With(zap.String("remote_ip", clientIP), zap.String("method", method))
+
+	if !s.isAllowedIP(clientIP) {
+		log.Warn("访问被拒绝 - IP不在白名单")
+		s.recordAuthFailure(ctx, clientIP, method, "grpc", "ip_not_allowed", nil)
+		return nil, status.Error(codes.PermissionDenied, "访问被拒绝：IP地址不被允许")
+	}
+
+	token := strings.TrimPrefix(firstMetadata(md, "authorization"), "Bearer ")
+	identity, err := s.tokenValidator.Validate(ctx, token)
+	if err != nil && s.config.Auth.Method == "token" && s.authTokenFile() != "" && s.validateToken(token) {
+		// 兼容静态令牌文件，视为拥有全部权限
+		identity, err = &auth.Identity{
+			Subject: "token_file",
+			Method:  "token",
+			Scopes:  auth.ValidScopes,
+		}, nil
+	}
+	if err != nil {
+		log.Warn("访问被拒绝 - 令牌验证失败", zap.Error(err))
+		s.recordAuthFailure(ctx, clientIP, method, "grpc", "invalid_token", err)
+		if apperrors.GetCode(err) == apperrors.ErrForbidden {
+			return nil, grpcError(err)
+		}
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	ctx = auth.WithIdentity(ctx, identity)
+
+	if err := s.authorize(ctx, grpcMethodRole(method)); err != nil {
+		log.Warn("访问被拒绝 - 权限不足", zap.Error(err))
+		s.recordAuthFailure(ctx, clientIP, method, "grpc", "forbidden", err)
+		return nil, grpcError(err)
+	}
+	return ctx, nil
+}
+
+// firstMetadata 获取元数据的第一个值
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// grpcError 将应用程序错误转换为 gRPC 状态，状态码与 REST 接口的HTTP状态码对应
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	code := codes.Internal
+	switch apperrors.HTTPStatus(err) {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusMethodNotAllowed:
+		code = codes.Unimplemented
+	case http.StatusConflict:
+		code = codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	case http.StatusGatewayTimeout:
+		code = codes.DeadlineExceeded
+	}
+	return status.Error(code, err.Error())
+}
+
+// grpcTaskService TaskService 实现，调用与 REST 接口相同的任务管理器
+type grpcTaskService struct {
+	pb.UnimplementedTaskServiceServer
+	server *mcpServer
+}
+
+// SubmitTask 提交任务，与 POST /tasks 共用提交频率限制
+func (g *grpcTaskService) SubmitTask(ctx context.Context, req *pb.SubmitTaskRequest) (*pb.Task, error) {
+	s := g.server
+	if s.submitLimiter != nil {
+		key := "ip:" + audit.SourceIPFromContext(ctx)
+		if identity := auth.IdentityFromContext(ctx); identity != nil && identity.Subject != "" {
+			key = "sub:" + identity.Subject
+		}
+		if ok, wait := s.submitLimiter.allow(key); !ok {
+			return nil, status.Errorf(codes.ResourceExhausted, "请求过于频繁，请 %d 秒后重试", retryAfterSeconds(wait))
+		}
+	}
+
+	taskReq := &TaskRequest{
+		Type:        req.GetType(),
+		ProjectPath: req.GetProjectPath(),
+		Command:     req.GetCommand(),
+		Args:        req.GetArgs(),
+		Context:     req.GetContext().AsMap(),
+		Priority:    int(req.GetPriority()),
+		Timeout:     req.GetTimeout().AsDuration(),
+		GPU:         req.GetGpu(),
+		Distro:      req.GetDistro(),
+	}
+	if limits := req.GetLimits(); limits != nil {
+		taskReq.Limits = &config.ResourceLimits{
+			Nice:         int(limits.GetNice()),
+			MaxMemoryMB:  int(limits.GetMaxMemoryMb()),
+			MaxOpenFiles: int(limits.GetMaxOpenFiles()),
+		}
+		if limits.GetKillGracePeriod() != nil {
+			taskReq.Limits.KillGracePeriod = limits.GetKillGracePeriod().AsDuration().String()
+		}
+	}
+
+	task, err := s.taskManager.SubmitTask(ctx, taskReq)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return taskToProto(task), nil
+}
+
+// GetTask 获取任务状态
+func (g *grpcTaskService) GetTask(ctx context.Context, req *pb.GetTaskRequest) (*pb.Task, error) {
+	task, err := g.server.taskManager.GetTaskStatus(ctx, req.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return taskToProto(task), nil
+}
+
+// CancelTask 取消任务
+func (g *grpcTaskService) CancelTask(ctx context.Context, req *pb.CancelTaskRequest) (*pb.CancelTaskResponse, error) {
+	if err := g.server.taskManager.CancelTask(ctx, req.GetId()); err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.CancelTaskResponse{}, nil
+}
+
+// ListTasks 过滤、排序和分页列出任务，参数的检查和默认值与 GET /tasks 相同
+func (g *grpcTaskService) ListTasks(ctx context.Context, req *pb.ListTasksRequest) (*pb.ListTasksResponse, error) {
+	values := url.Values{}
+	values["status"] = req.GetStatuses()
+	values.Set("project", req.GetProject())
+	values.Set("sort", req.GetSort())
+	values.Set("cursor", req.GetCursor())
+	if req.GetSince() != nil {
+		values.Set("since", req.GetSince().AsTime().Format(time.RFC3339Nano))
+	}
+	if req.GetLimit() != 0 {
+		values.Set("limit", strconv.Itoa(int(req.GetLimit())))
+	}
+	if req.GetOffset() != 0 {
+		values.Set("offset", strconv.Itoa(int(req.GetOffset())))
+	}
+
+	query, err := parseTaskListQuery(values)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	tasks, err := g.server.taskManager.ListTasks(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	page, err := query.apply(tasks)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := &pb.ListTasksResponse{
+		Total:      int32(page.Total),
+		Offset:     int32(page.Offset),
+		NextOffset: int32(page.NextOffset),
+		NextCursor: page.NextCursor,
+	}
+	for _, task := range page.Tasks {
+		resp.Tasks = append(resp.Tasks, taskToProto(task))
+	}
+	return resp, nil
+}
+
+// GetTaskOutput 分页获取任务已捕获的输出
+func (g *grpcTaskService) GetTaskOutput(ctx context.Context, req *pb.GetTaskOutputRequest) (*pb.GetTaskOutputResponse, error) {
+	if req.GetLimit() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "无效的 limit 参数")
+	}
+	output, err := g.server.taskManager.GetTaskOutput(ctx, req.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	page := pageOutput(req.GetId(), output, int(req.GetOffset()), int(req.GetLimit()))
+	return &pb.GetTaskOutputResponse{
+		TaskId:     page.TaskID,
+		Offset:     int64(page.Offset),
+		NextOffset: int64(page.NextOffset),
+		TotalSize:  int64(page.TotalSize),
+		Data:       page.Data,
+		Eof:        page.EOF,
+	}, nil
+}
+
+// WatchTaskEvents 推送任务生命周期事件，与 /api/v1/events 共用事件分发器
+// last_event_id 非零时先补发该 ID 之后仍保留的事件；读取过慢时以 RESOURCE_EXHAUSTED 结束，客户端可带 last_event_id 重新订阅
+func (g *grpcTaskService) WatchTaskEvents(req *pb.WatchTaskEventsRequest, stream pb.TaskService_WatchTaskEventsServer) error {
+	s := g.server
+	ctx := stream.Context()
+
+	// 只能访问自己任务的身份只接收自己任务的事件
+	sub := &eventSubscriber{taskID: req.GetTaskId()}
+	sub.owner, sub.scoped = ownerScope(ctx, s.config)
+	missed := s.events.subscribe(sub, req.GetLastEventId())
+	defer s.events.unsubscribe(sub)
+
+	for _, event := range missed {
+		if err := stream.Send(taskEventToProto(event)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.events.closed:
+			return status.Error(codes.Unavailable, "服务器正在停止")
+		case event, ok := <-sub.events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "读取过慢，请使用 last_event_id 重新订阅")
+			}
+			if err := stream.Send(taskEventToProto(event)); err != nil {
+				logger.FromContext(ctx, s.logger).Debug("写出任务事件失败", zap.Error(err))
+				return err
+			}
+		}
+	}
+}
+
+// StreamTaskOutput 先发送已捕获的输出，再逐行推送新输出，任务结束时发送最终状态后结束
+func (g *grpcTaskService) StreamTaskOutput(req *pb.StreamTaskOutputRequest, stream pb.TaskService_StreamTaskOutputServer) error {
+	s := g.server
+	ctx := stream.Context()
+
+	sub, err := s.subscribeOutput(ctx, req.GetId())
+	if err != nil {
+		return grpcError(err)
+	}
+	defer sub.unregister()
+
+	conn := &grpcOutputConn{stream: stream}
+	s.streamOutput(sub, conn, int(req.GetOffset()), logger.FromContext(ctx, s.logger).With(zap.String("taskId", req.GetId())))
+	return conn.err
+}
+
+// grpcOutputConn gRPC 输出流，心跳由 HTTP/2 连接负责
+type grpcOutputConn struct {
+	stream pb.TaskService_StreamTaskOutputServer
+	err    error // 服务器结束输出流的原因
+}
+
+func (c *grpcOutputConn) send(msg *outputStreamMessage) error {
+	if msg.Type == outputMessageStatus {
+		return c.stream.Send(&pb.TaskOutputMessage{Message: &pb.TaskOutputMessage_Finished{Finished: taskToProto(msg.task)}})
+	}
+	return c.stream.Send(&pb.TaskOutputMessage{Message: &pb.TaskOutputMessage_Line{Line: &pb.OutputLine{
+		Stream: msg.Stream,
+		Line:   msg.Line,
+		Offset: int64(msg.Offset),
+	}}})
+}
+
+func (c *grpcOutputConn) ping() error { return nil }
+
+func (c *grpcOutputConn) close(code int, reason string) {
+	if code == websocket.ClosePolicyViolation {
+		c.err = status.Error(codes.ResourceExhausted, reason)
+	} else {
+		c.err = status.Error(codes.Unavailable, reason)
+	}
+}
+
+func (c *grpcOutputConn) done() <-chan struct{} { return c.stream.Context().Done() }
+
+// grpcWorktreeService WorktreeService 实现，调用与 REST 接口相同的worktree管理器
+type grpcWorktreeService struct {
+	pb.UnimplementedWorktreeServiceServer
+	server *mcpServer
+}
+
+// ListWorktrees 列出所有worktree
+func (g *grpcWorktreeService) ListWorktrees(ctx context.Context, req *pb.ListWorktreesRequest) (*pb.ListWorktreesResponse, error) {
+	worktrees, err := g.server.worktreeManager.ListWorktrees(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := &pb.ListWorktreesResponse{}
+	for _, worktree := range worktrees {
+		resp.Worktrees = append(resp.Worktrees, worktreeToProto(worktree))
+	}
+	return resp, nil
+}
+
+// GetWorktree 获取worktree信息
+func (g *grpcWorktreeService) GetWorktree(ctx context.Context, req *pb.GetWorktreeRequest) (*pb.Worktree, error) {
+	worktree, err := g.server.worktreeManager.GetWorktree(ctx, req.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return worktreeToProto(worktree), nil
+}
+
+// GetWorktreeDiff 获取worktree相对基准提交的统一diff
+func (g *grpcWorktreeService) GetWorktreeDiff(ctx context.Context, req *pb.GetWorktreeDiffRequest) (*pb.GetWorktreeDiffResponse, error) {
+	diff, err := g.server.worktreeManager.GetWorktreeDiff(ctx, req.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.GetWorktreeDiffResponse{Diff: diff}, nil
+}
+
+// DeleteWorktree 删除worktree，与 DELETE /worktrees/{id} 相同，任务使用中的worktree不能删除
+func (g *grpcWorktreeService) DeleteWorktree(ctx context.Context, req *pb.DeleteWorktreeRequest) (*pb.DeleteWorktreeResponse, error) {
+	manager := g.server.worktreeManager
+	worktree, err := manager.GetWorktree(ctx, req.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if worktree.Status == WorktreeStateInUse {
+		return nil, grpcError(apperrors.Newf(apperrors.ErrConflict, "Worktree正在被任务 %s 使用，不能删除: %s", worktree.TaskID, req.GetId()))
+	}
+	if err := manager.DeleteWorktree(ctx, req.GetId()); err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.DeleteWorktreeResponse{}, nil
+}
+
+// taskToProto 转换任务状态
+func taskToProto(task *TaskStatus) *pb.Task {
+	msg := &pb.Task{
+		Id:          task.ID,
+		Status:      task.Status,
+		ProjectPath: task.ProjectPath,
+		Progress:    task.Progress,
+		Message:     task.Message,
+		Error:       task.Error,
+		CreatedAt:   timestampProto(task.CreatedAt),
+		StartTime:   timestampProto(task.StartTime),
+		EndTime:     timestampProto(task.EndTime),
+		WorktreeId:  task.WorktreeID,
+		RequestId:   task.RequestID,
+	}
+	if task.Result != nil {
+		msg.Result = jsonValueProto(task.Result)
+	}
+	if len(task.Metadata) > 0 {
+		msg.Metadata = jsonValueProto(task.Metadata).GetStructValue()
+	}
+	return msg
+}
+
+// taskEventToProto 转换事件流中的任务事件
+func taskEventToProto(event *taskStreamEvent) *pb.TaskEvent {
+	return &pb.TaskEvent{
+		Id:   event.ID,
+		Type: event.Type,
+		Time: timestampProto(event.Time),
+		Task: taskToProto(event.Task),
+	}
+}
+
+// worktreeToProto 转换worktree信息
+func worktreeToProto(worktree *WorktreeInfo) *pb.Worktree {
+	return &pb.Worktree{
+		Id:          worktree.ID,
+		ProjectPath: worktree.ProjectPath,
+		WslPath:     worktree.WSLPath,
+		Branch:      worktree.Branch,
+		BaseCommit:  worktree.BaseCommit,
+		CreatedAt:   rfc3339Proto(worktree.CreatedAt),
+		LastUsed:    rfc3339Proto(worktree.LastUsed),
+		Status:      worktree.Status,
+	}
+}
+
+// timestampProto 转换时间，零值时为 nil
+func timestampProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// rfc3339Proto 转换 RFC 3339 格式的时间，无法解析时为 nil
+func rfc3339Proto(value string) *timestamppb.Timestamp {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// jsonValueProto 按 JSON 编码转换任意值，与 REST 接口返回的 JSON 相同
+func jsonValueProto(v interface{}) *structpb.Value {
+	var decoded interface{}
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = json.Unmarshal(data, &decoded)
+	}
+	if err != nil {
+		return structpb.NewStringValue(err.Error())
+	}
+	value, err := structpb.NewValue(decoded)
+	if err != nil {
+		return structpb.NewStringValue(err.Error())
+	}
+	return value
+}
@@ -0,0 +1,148 @@
+package mcp
+
+import (
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "auto-claude-code/api/proto/autoclaudecode/v1"
+	"auto-claude-code/internal/auth"
+	"auto-claude-code/internal/config"
+)
+
+// dialTestGRPC 在内存连接上启动服务器的 gRPC 接口，返回客户端连接
+func dialTestGRPC(t *testing.T, server *mcpServer) *grpc.ClientConn {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	transport := newGRPCTransport(server)
+	go transport.server.Serve(listener)
+	t.Cleanup(transport.server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("连接 gRPC 服务器失败: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestGRPCTaskService(t *testing.T) {
+	tm := &fakeOutputTaskManager{
+		status: TaskStatus{ID: "t1", Status: "running", Metadata: map[string]interface{}{"source": "ci"}},
+		output: "第一行\nsecond\n",
+		added:  make(chan struct{}),
+	}
+	server := &mcpServer{config: &config.MCPConfig{}, taskManager: tm, events: newTestBroker(t)}
+	server.logger = server.events.logger
+	client := pb.NewTaskServiceClient(dialTestGRPC(t, server))
+	ctx := context.Background()
+
+	task, err := client.GetTask(ctx, &pb.GetTaskRequest{Id: "t1"})
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	if task.GetStatus() != "running" || task.GetMetadata().AsMap()["source"] != "ci" {
+		t.Errorf("GetTask() = %v", task)
+	}
+	if _, err := client.GetTask(ctx, &pb.GetTaskRequest{Id: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("不存在的任务 GetTask() error = %v", err)
+	}
+
+	// 输出流先发送已捕获的输出，再推送新输出，任务结束时发送最终状态
+	stream, err := client.StreamTaskOutput(ctx, &pb.StreamTaskOutputRequest{Id: "t1"})
+	if err != nil {
+		t.Fatalf("StreamTaskOutput() error = %v", err)
+	}
+	var lines []string
+	for len(lines) < 2 {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		lines = append(lines, msg.GetLine().GetLine())
+	}
+	if lines[0] != "第一行" || lines[1] != "second" {
+		t.Errorf("已捕获的输出 = %q", lines)
+	}
+
+	<-tm.added
+	tm.emit(TaskEvent{Type: TaskEventOutput, Task: &tm.status, Output: &OutputLine{Stream: "stdout", Line: "third", Offset: 23}})
+	msg, err := stream.Recv()
+	if err != nil || msg.GetLine().GetLine() != "third" || msg.GetLine().GetStream() != "stdout" || msg.GetLine().GetOffset() != 23 {
+		t.Fatalf("新输出 = %v, %v", msg, err)
+	}
+
+	tm.emit(TaskEvent{Type: TaskEventStatus, Task: &TaskStatus{ID: "t1", Status: "completed"}})
+	msg, err = stream.Recv()
+	if err != nil || msg.GetFinished().GetStatus() != "completed" {
+		t.Fatalf("结束状态 = %v, %v", msg, err)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Errorf("任务结束后输出流应结束: %v", err)
+	}
+}
+
+func TestGRPCWatchTaskEvents(t *testing.T) {
+	server := &mcpServer{config: &config.MCPConfig{}, events: newTestBroker(t)}
+	server.logger = server.events.logger
+	client := pb.NewTaskServiceClient(dialTestGRPC(t, server))
+
+	server.events.HandleTaskEvent(TaskEvent{Type: TaskEventCreated, Task: &TaskStatus{ID: "t1", Status: "pending"}})
+	server.events.HandleTaskEvent(TaskEvent{Type: TaskEventStatus, Task: &TaskStatus{ID: "t1", Status: "running"}})
+
+	// 按 last_event_id 补发之后的事件
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.WatchTaskEvents(ctx, &pb.WatchTaskEventsRequest{TaskId: "t1", LastEventId: 1})
+	if err != nil {
+		t.Fatalf("WatchTaskEvents() error = %v", err)
+	}
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error = %v", err)
+	}
+	if event.GetId() != 2 || event.GetType() != streamEventStarted || event.GetTask().GetStatus() != "running" {
+		t.Errorf("补发的事件 = %v", event)
+	}
+}
+
+func TestGRPCAuth(t *testing.T) {
+	store, err := auth.NewTokenStore(filepath.Join(t.TempDir(), "tokens.json"), newTestBroker(t).logger)
+	if err != nil {
+		t.Fatalf("创建令牌存储失败: %v", err)
+	}
+	token, _, err := store.Create(auth.CreateTokenRequest{Name: "viewer", Scopes: []string{auth.ScopeRead}})
+	if err != nil {
+		t.Fatalf("创建令牌失败: %v", err)
+	}
+
+	tm := &fakeOutputTaskManager{status: TaskStatus{ID: "t1", Status: "running"}, added: make(chan struct{})}
+	cfg := &config.MCPConfig{Auth: config.MCPAuthConfig{Enabled: true, Method: "token"}}
+	server := &mcpServer{config: cfg, taskManager: tm, events: newTestBroker(t), tokenStore: store, tokenValidator: store}
+	server.logger = server.events.logger
+	client := pb.NewTaskServiceClient(dialTestGRPC(t, server))
+
+	if _, err := client.GetTask(context.Background(), &pb.GetTaskRequest{Id: "t1"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("未携带令牌 GetTask() error = %v", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	if _, err := client.GetTask(ctx, &pb.GetTaskRequest{Id: "t1"}); err != nil {
+		t.Errorf("只读令牌 GetTask() error = %v", err)
+	}
+	if _, err := client.CancelTask(ctx, &pb.CancelTaskRequest{Id: "t1"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("只读令牌 CancelTask() error = %v", err)
+	}
+}
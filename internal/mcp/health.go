@@ -0,0 +1,159 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"runtime"
+	"time"
+)
+
+// 健康检查状态
+const (
+	healthStatusOK    = "ok"
+	healthStatusError = "error"
+)
+
+// 就绪探针检查的组件
+const (
+	componentWSL         = "wsl"
+	componentWorkers     = "workers"
+	componentQueue       = "queue"
+	componentWorktreeDir = "worktree_dir"
+)
+
+// wslCheckTTL WSL可用性检查缓存时间，探针通常每隔几秒调用一次，避免反复启动wsl进程
+const wslCheckTTL = 10 * time.Second
+
+// componentHealth 单个组件的检查结果
+type componentHealth struct {
+	Status  string                 `json:"status"`
+	Message string                 `json:"message,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// livenessResponse 存活探针响应
+type livenessResponse struct {
+	Status     string    `json:"status"`
+	Timestamp  string    `json:"timestamp"`
+	PID        int       `json:"pid"`
+	StartedAt  time.Time `json:"startedAt"`
+	Uptime     string    `json:"uptime"`
+	Goroutines int       `json:"goroutines"`
+}
+
+// readinessResponse 就绪探针响应，任一组件异常时整体为 error
+type readinessResponse struct {
+	Status     string                     `json:"status"`
+	Timestamp  string                     `json:"timestamp"`
+	Components map[string]componentHealth `json:"components"`
+}
+
+// isProbePath 是否为存活或就绪探针路径，探针不需要认证
+func (s *mcpServer) isProbePath(path string) bool {
+	monitoring := s.config.Monitoring
+	return path == monitoring.HealthPath ||
+		(monitoring.LivenessPath != "" && path == monitoring.LivenessPath) ||
+		(monitoring.ReadinessPath != "" && path == monitoring.ReadinessPath)
+}
+
+// handleLiveness 处理存活探针，只要进程能处理请求就返回 200
+// 组件故障不影响存活状态，避免编排器因外部依赖不可用而反复重启进程
+func (s *mcpServer) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	resp := livenessResponse{
+		Status:     healthStatusOK,
+		Timestamp:  time.Now().Format(time.RFC3339),
+		PID:        os.Getpid(),
+		StartedAt:  s.startedAt,
+		Uptime:     time.Since(s.startedAt).Round(time.Second).String(),
+		Goroutines: runtime.NumGoroutine(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleReadiness 处理就绪探针，所有组件正常时返回 200，否则返回 503
+func (s *mcpServer) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	resp := s.checkReadiness(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	if resp.Status != healthStatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// checkReadiness 检查各组件是否可以接收新任务
+func (s *mcpServer) checkReadiness(ctx context.Context) readinessResponse {
+	stats := s.taskManager.Stats()
+
+	components := map[string]componentHealth{
+		componentWSL: s.checkWSLHealth(),
+		componentWorkers: newComponentHealth(stats.ActiveWorkers > 0, "没有活跃的任务工作器", map[string]interface{}{
+			"active": stats.ActiveWorkers,
+			"total":  stats.TotalWorkers,
+		}),
+		componentQueue: newComponentHealth(stats.QueueCapacity == 0 || stats.QueueLength < stats.QueueCapacity, "任务队列已满", map[string]interface{}{
+			"length":   stats.QueueLength,
+			"capacity": stats.QueueCapacity,
+			"paused":   stats.QueuePaused,
+		}),
+		componentWorktreeDir: s.checkWorktreeDirHealth(ctx),
+	}
+
+	resp := readinessResponse{
+		Status:     healthStatusOK,
+		Timestamp:  time.Now().Format(time.RFC3339),
+		Components: components,
+	}
+	for _, component := range components {
+		if component.Status != healthStatusOK {
+			resp.Status = healthStatusError
+			break
+		}
+	}
+	return resp
+}
+
+// newComponentHealth 根据检查结果创建组件状态，失败时附带 message
+func newComponentHealth(ok bool, message string, details map[string]interface{}) componentHealth {
+	if ok {
+		return componentHealth{Status: healthStatusOK, Details: details}
+	}
+	return componentHealth{Status: healthStatusError, Message: message, Details: details}
+}
+
+// checkWSLHealth 检查（可能已缓存的）执行后端可用性
+func (s *mcpServer) checkWSLHealth() componentHealth {
+	s.wslHealthLock.Lock()
+	defer s.wslHealthLock.Unlock()
+
+	if s.wslHealthAt.IsZero() || time.Since(s.wslHealthAt) > wslCheckTTL {
+		s.wslHealthErr = s.wslBridge.CheckWSL()
+		s.wslHealthAt = time.Now()
+	}
+
+	details := map[string]interface{}{
+		"backend":   s.wslBridge.Backend(),
+		"checkedAt": s.wslHealthAt.Format(time.RFC3339),
+	}
+	if s.wslHealthErr != nil {
+		return componentHealth{Status: healthStatusError, Message: s.wslHealthErr.Error(), Details: details}
+	}
+	return componentHealth{Status: healthStatusOK, Details: details}
+}
+
+// checkWorktreeDirHealth 检查 worktree 基础目录是否存在且可写
+func (s *mcpServer) checkWorktreeDirHealth(ctx context.Context) componentHealth {
+	var details map[string]interface{}
+	if s.config.WorktreeBaseDir != "" {
+		details = map[string]interface{}{"path": s.config.WorktreeBaseDir}
+	}
+
+	if err := s.worktreeManager.HealthCheck(ctx); err != nil {
+		return componentHealth{Status: healthStatusError, Message: err.Error(), Details: details}
+	}
+	return componentHealth{Status: healthStatusOK, Details: details}
+}
@@ -0,0 +1,153 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/wsl"
+)
+
+// stubBridge 只实现健康检查用到的方法
+type stubBridge struct {
+	wsl.WSLBridge
+	err    error
+	checks int
+}
+
+func (b *stubBridge) CheckWSL() error {
+	b.checks++
+	return b.err
+}
+
+func (b *stubBridge) Backend() string { return "wsl" }
+
+// stubTaskManager 返回固定的工作器和队列状态
+type stubTaskManager struct {
+	TaskManager
+	stats TaskManagerStats
+}
+
+func (m *stubTaskManager) Stats() TaskManagerStats { return m.stats }
+
+// stubWorktreeManager 返回固定的健康检查结果
+type stubWorktreeManager struct {
+	WorktreeManager
+	err error
+}
+
+func (m *stubWorktreeManager) HealthCheck(ctx context.Context) error { return m.err }
+
+func TestReadiness(t *testing.T) {
+	healthy := TaskManagerStats{ActiveWorkers: 2, TotalWorkers: 2, QueueLength: 1, QueueCapacity: 10}
+
+	tests := []struct {
+		name        string
+		wslErr      error
+		stats       TaskManagerStats
+		worktreeErr error
+		failed      string
+	}{
+		{name: "全部正常", stats: healthy},
+		{name: "队列不限长度", stats: TaskManagerStats{ActiveWorkers: 1, TotalWorkers: 1, QueueLength: 50}},
+		{name: "WSL不可用", wslErr: errors.New("wsl.exe 未找到"), stats: healthy, failed: componentWSL},
+		{name: "工作器已停止", stats: TaskManagerStats{TotalWorkers: 2, QueueCapacity: 10}, failed: componentWorkers},
+		{name: "队列已满", stats: TaskManagerStats{ActiveWorkers: 2, TotalWorkers: 2, QueueLength: 10, QueueCapacity: 10}, failed: componentQueue},
+		{
+			name:        "worktree目录不可写",
+			stats:       healthy,
+			worktreeErr: apperrors.New(apperrors.ErrWorktreeFailed, "Worktree基础目录不可写"),
+			failed:      componentWorktreeDir,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &mcpServer{
+				config:          &config.MCPConfig{},
+				wslBridge:       &stubBridge{err: tt.wslErr},
+				taskManager:     &stubTaskManager{stats: tt.stats},
+				worktreeManager: &stubWorktreeManager{err: tt.worktreeErr},
+			}
+
+			w := httptest.NewRecorder()
+			server.handleReadiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			wantCode := http.StatusOK
+			if tt.failed != "" {
+				wantCode = http.StatusServiceUnavailable
+			}
+			if w.Code != wantCode {
+				t.Errorf("状态码 = %d, want %d", w.Code, wantCode)
+			}
+
+			var resp readinessResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if len(resp.Components) != 4 {
+				t.Fatalf("components = %+v", resp.Components)
+			}
+			for name, component := range resp.Components {
+				want := healthStatusOK
+				if name == tt.failed {
+					want = healthStatusError
+				}
+				if component.Status != want {
+					t.Errorf("组件 %s 状态 = %s, want %s", name, component.Status, want)
+				}
+				if want == healthStatusError && component.Message == "" {
+					t.Errorf("异常组件 %s 缺少 message", name)
+				}
+			}
+		})
+	}
+}
+
+func TestLivenessIgnoresComponents(t *testing.T) {
+	bridge := &stubBridge{err: errors.New("wsl.exe 未找到")}
+	server := &mcpServer{
+		config:      &config.MCPConfig{},
+		wslBridge:   bridge,
+		taskManager: &stubTaskManager{},
+		startedAt:   time.Now().Add(-time.Minute),
+	}
+
+	w := httptest.NewRecorder()
+	server.handleLiveness(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("状态码 = %d, want 200", w.Code)
+	}
+	var resp livenessResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Status != healthStatusOK || resp.PID == 0 || resp.Uptime != "1m0s" {
+		t.Errorf("resp = %+v", resp)
+	}
+	if bridge.checks != 0 {
+		t.Error("存活探针不应检查WSL")
+	}
+}
+
+func TestWSLHealthCached(t *testing.T) {
+	bridge := &stubBridge{}
+	server := &mcpServer{wslBridge: bridge}
+
+	server.checkWSLHealth()
+	server.checkWSLHealth()
+	if bridge.checks != 1 {
+		t.Errorf("缓存期内检查了 %d 次，want 1", bridge.checks)
+	}
+
+	server.wslHealthAt = time.Now().Add(-wslCheckTTL - time.Second)
+	server.checkWSLHealth()
+	if bridge.checks != 2 {
+		t.Errorf("缓存过期后检查了 %d 次，want 2", bridge.checks)
+	}
+}
@@ -2,6 +2,11 @@ package mcp
 
 import (
 	"context"
+	"io"
+	"os"
+	"time"
+
+	"auto-claude-code/internal/wsl"
 )
 
 // TaskManager 任务管理器接口
@@ -9,6 +14,9 @@ type TaskManager interface {
 	// SubmitTask 提交任务
 	SubmitTask(ctx context.Context, req *TaskRequest) (*TaskStatus, error)
 
+	// PlanTask 检查任务能否执行并返回执行计划，不创建任务
+	PlanTask(ctx context.Context, req *TaskRequest) (*TaskPlan, error)
+
 	// GetTaskStatus 获取任务状态
 	GetTaskStatus(ctx context.Context, taskID string) (*TaskStatus, error)
 
@@ -18,9 +26,70 @@ type TaskManager interface {
 	// ListTasks 列出所有任务
 	ListTasks(ctx context.Context) ([]*TaskStatus, error)
 
+	// GetTaskOutput 获取任务已捕获的输出
+	GetTaskOutput(ctx context.Context, taskID string) (string, error)
+
+	// GetTaskStreamOutput 读取任务 stdout 或 stderr 的输出日志文件
+	GetTaskStreamOutput(ctx context.Context, taskID, stream string) (string, error)
+
+	// ListTaskArtifacts 列出任务已收集的产物
+	ListTaskArtifacts(ctx context.Context, taskID string) ([]TaskArtifact, error)
+
+	// OpenTaskArtifact 打开任务的产物文件，调用方负责关闭
+	OpenTaskArtifact(ctx context.Context, taskID, name string) (*os.File, error)
+
+	// AddTaskNote 为任务追加一条备注
+	AddTaskNote(ctx context.Context, taskID string, note TaskNote) (*TaskNote, error)
+
+	// RerunTask 以已结束任务的原始请求提交一个新任务，overrides 的非零字段覆盖原请求
+	RerunTask(ctx context.Context, taskID string, overrides RerunOverrides) (*TaskStatus, error)
+
+	// AttachTask 连接到正在运行的交互式任务的终端会话，调用方结束时调用 Detach
+	AttachTask(ctx context.Context, taskID string) (*SessionAttachment, error)
+
+	// MergeTask 将已成功完成的任务的工作树合并回项目
+	MergeTask(ctx context.Context, taskID string, opts MergeOptions) (*MergeResult, error)
+
+	// ExportTasks 导出 since 之后结束的任务记录，包含输出引用
+	ExportTasks(ctx context.Context, since time.Time) ([]ArchivedTask, error)
+
+	// ListDistros 列出可用的 WSL 发行版
+	ListDistros(ctx context.Context) ([]DistroInfo, error)
+
+	// ShellToolEnabled 是否启用了 run_shell_command，未启用时不列出该工具
+	ShellToolEnabled() bool
+
+	// RunShellCommand 按 mcp.shell_tool 策略在任务工作目录中运行 shell 命令
+	// 命令超时或被取消时同时返回已捕获的结果和错误
+	RunShellCommand(ctx context.Context, req *ShellCommandRequest) (*wsl.ExecResult, error)
+
+	// PauseQueue 暂停向工作器分发任务，正在执行的任务继续运行
+	PauseQueue(ctx context.Context, reason string) QueueState
+
+	// ResumeQueue 恢复向工作器分发任务
+	ResumeQueue(ctx context.Context) QueueState
+
+	// GetQueueState 获取任务队列的调度状态
+	GetQueueState(ctx context.Context) QueueState
+
+	// ListQuotaUsage 获取各客户端的配额用量，client 非空时只返回该客户端
+	ListQuotaUsage(ctx context.Context, client string) []QuotaUsage
+
 	// HealthCheck 健康检查
 	HealthCheck(ctx context.Context) error
 
+	// Stats 获取工作器和队列的当前状态
+	Stats() TaskManagerStats
+
+	// SchedulingMetrics 获取队列等待时间、执行耗时、吞吐量和失败率等调度指标
+	SchedulingMetrics() SchedulingMetrics
+
+	// GetGPUInfo 获取任务执行环境的 GPU 支持情况
+	GetGPUInfo(ctx context.Context) (*wsl.GPUInfo, error)
+
+	// AddListener 注册任务事件监听器，返回取消注册函数
+	AddListener(listener TaskListener) func()
+
 	// Start 启动任务管理器
 	Start(ctx context.Context) error
 
@@ -28,23 +97,115 @@ type TaskManager interface {
 	Stop(ctx context.Context) error
 }
 
+// TaskManagerStats 工作器和队列的当前状态
+type TaskManagerStats struct {
+	ActiveWorkers int  `json:"activeWorkers"`
+	TotalWorkers  int  `json:"totalWorkers"`
+	QueueLength   int  `json:"queueLength"`
+	QueueCapacity int  `json:"queueCapacity"` // 0 表示队列不限长度
+	QueuePaused   bool `json:"queuePaused"`
+}
+
+// 任务事件类型
+const (
+	TaskEventCreated  = "created"  // 任务已提交
+	TaskEventStatus   = "status"   // 任务状态变化
+	TaskEventProgress = "progress" // 任务进度变化
+	TaskEventOutput   = "output"   // 任务产生新的输出
+)
+
+// TaskEvent 任务事件
+type TaskEvent struct {
+	Type   string      // 事件类型
+	Task   *TaskStatus // 事件发生时的任务状态快照
+	Output *OutputLine // 输出事件捕获的一行输出，其他事件为 nil
+}
+
+// OutputLine 任务捕获的一行输出
+type OutputLine struct {
+	Stream string `json:"stream"` // "stdout" 或 "stderr"
+	Line   string `json:"line"`
+	Offset int    `json:"offset"` // 该行结束后任务已产生的输出总字节数，输出未截断时与 /tasks/{id}/output 的偏移一致
+}
+
+// TaskListener 任务事件监听器，在任务管理器的 goroutine 中同步调用，不应阻塞
+type TaskListener func(event TaskEvent)
+
+// TaskStore 任务持久化存储接口，任务管理器在任务状态变化时写入，启动时加载
+type TaskStore interface {
+	// SaveTask 保存任务记录，记录的 Request 或 OutputRef 为空时保留已保存的值
+	SaveTask(ctx context.Context, record *TaskRecord) error
+
+	// LoadTasks 加载所有已保存的任务记录
+	LoadTasks(ctx context.Context) ([]*TaskRecord, error)
+
+	// DeleteTask 删除任务记录及其输出
+	DeleteTask(ctx context.Context, taskID string) error
+
+	// SaveOutput 保存任务结束时捕获的输出，返回写入任务记录的输出引用
+	SaveOutput(ctx context.Context, taskID string, output string) (string, error)
+
+	// ReadOutput 按输出引用读取任务输出
+	ReadOutput(ctx context.Context, ref string) (string, error)
+
+	// Close 关闭存储
+	Close() error
+}
+
+// TaskRecord 持久化的任务记录
+type TaskRecord struct {
+	Status    *TaskStatus  `json:"status"`
+	Request   *TaskRequest `json:"request,omitempty"`
+	OutputRef string       `json:"outputRef,omitempty"` // 任务输出的引用，任务结束前为空
+}
+
 // WorktreeManager Git worktree管理器接口
 type WorktreeManager interface {
-	// CreateWorktree 创建新的worktree
-	CreateWorktree(ctx context.Context, projectPath string) (*WorktreeInfo, error)
+	// CreateWorktree 创建新的worktree，opts 用于生成任务分支名
+	CreateWorktree(ctx context.Context, projectPath string, opts CreateWorktreeOptions) (*WorktreeInfo, error)
+
+	// PlanWorktree 检查能否为项目创建worktree，不创建任何文件
+	PlanWorktree(ctx context.Context, projectPath string) (*WorktreePlan, error)
 
 	// DeleteWorktree 删除worktree
 	DeleteWorktree(ctx context.Context, worktreeID string) error
 
+	// SetWorktreePinned 固定或取消固定worktree，固定的worktree不会被空闲清理删除，也不能直接删除
+	SetWorktreePinned(ctx context.Context, worktreeID string, pinned bool) (*WorktreeInfo, error)
+
+	// SetWorktreeState 转换worktree的生命周期状态，不允许的转换返回 CONFLICT
+	SetWorktreeState(ctx context.Context, worktreeID, state string) (*WorktreeInfo, error)
+
 	// GetWorktree 获取worktree信息
 	GetWorktree(ctx context.Context, worktreeID string) (*WorktreeInfo, error)
 
 	// ListWorktrees 列出所有worktrees
 	ListWorktrees(ctx context.Context) ([]*WorktreeInfo, error)
 
+	// GetWorktreeDiff 获取worktree相对创建时基准提交的统一diff
+	GetWorktreeDiff(ctx context.Context, worktreeID string) (string, error)
+
+	// GetChangedFiles 获取worktree相对创建时基准提交修改、新增和删除的文件，路径相对worktree根目录
+	GetChangedFiles(ctx context.Context, worktreeID string) ([]string, error)
+
+	// GetWorktreeStatus 获取worktree的 git 状态和相对基准提交修改的文件
+	GetWorktreeStatus(ctx context.Context, worktreeID string) (*WorktreeStatus, error)
+
+	// WriteWorktreeArchive 将worktree的文件以 zip 格式写入 w，changedOnly 时只包含相对基准提交修改和新增的文件
+	WriteWorktreeArchive(ctx context.Context, worktreeID string, changedOnly bool, w io.Writer) error
+
+	// MergeWorktree 提交worktree中的修改并合并回创建时项目所在的分支
+	MergeWorktree(ctx context.Context, worktreeID string, opts MergeOptions) (*MergeResult, error)
+
+	// PushWorktree 提交worktree中的修改并将任务分支推送到远程仓库
+	PushWorktree(ctx context.Context, worktreeID string, opts PushOptions) (*PushResult, error)
+
 	// CleanupWorktrees 清理过期的worktrees
 	CleanupWorktrees(ctx context.Context) error
 
+	// DiskUsage 获取worktree基础目录的磁盘用量
+	DiskUsage(ctx context.Context) (*WorktreeDiskUsage, error)
+
 	// HealthCheck 健康检查
 	HealthCheck(ctx context.Context) error
 
@@ -60,8 +221,34 @@ type WorktreeInfo struct {
 	ID          string `json:"id"`
 	ProjectPath string `json:"projectPath"`
 	WSLPath     string `json:"wslPath"`
+	Path        string `json:"path,omitempty"`   // 服务器本地的worktree目录
+	Distro      string `json:"distro,omitempty"` // worktree_storage 为 distro 时worktree所在的 WSL 发行版
 	Branch      string `json:"branch"`
+	WorkBranch  string `json:"workBranch,omitempty"` // Git worktree 检出的任务分支
+	BaseCommit  string `json:"baseCommit,omitempty"` // 创建worktree时的提交，用于计算diff
+	Mirror      string `json:"mirror,omitempty"`     // 从项目的裸仓库镜像创建时为镜像路径
 	CreatedAt   string `json:"createdAt"`
 	LastUsed    string `json:"lastUsed"`
-	Status      string `json:"status"` // "active", "idle", "cleanup"
+	TaskID      string `json:"taskId,omitempty"`     // 创建worktree的任务
+	Status      string `json:"status"`               // provisioning、in_use、idle 或 cleanup，见 WorktreeState* 常量
+	Pinned      bool   `json:"pinned,omitempty"`     // 固定的worktree不会被空闲清理删除
+	Conflicted  bool   `json:"conflicted,omitempty"` // 最近一次合并与项目分支冲突
+	DiskUsage   int64  `json:"diskUsage,omitempty"`  // 最近一次统计的磁盘占用字节数，只在列表中返回
+
+	SparseCheckout []string `json:"sparseCheckout,omitempty"` // 以 sparse-checkout 创建时检出的模式
+	ContextFiles   []string `json:"contextFiles,omitempty"`   // 注入的上下文文件，不计入修改和提交
+}
+
+// WorktreePlan 为项目创建worktree的方式
+type WorktreePlan struct {
+	Mode           string   `json:"mode"`                     // "git" 创建 Git worktree，"copy" 复制项目目录
+	Branch         string   `json:"branch,omitempty"`         // Git 仓库的当前分支
+	SparseCheckout []string `json:"sparseCheckout,omitempty"` // 只检出匹配这些模式的文件
+	Mirror         string   `json:"mirror,omitempty"`         // 从该裸仓库镜像创建worktree
+	Submodules     bool     `json:"submodules,omitempty"`     // 创建后初始化子模块
+	LFS            bool     `json:"lfs,omitempty"`            // 创建后拉取 Git LFS 文件
+	ContextFiles   []string `json:"contextFiles,omitempty"`   // 注入的上下文文件
+	PostCreate     []string `json:"postCreate,omitempty"`     // 创建后在 WSL 中运行的命令
+	Active         int      `json:"active"`                   // 当前的worktree数量
+	MaxWorktrees   int      `json:"maxWorktrees"`
 }
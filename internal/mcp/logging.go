@@ -0,0 +1,133 @@
+package mcp
+
+import (
+	"context"
+
+	"go.uber.org/zap/zapcore"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// NotificationMessage MCP日志通知方法
+const NotificationMessage = "notifications/message"
+
+// defaultLoggerName 日志通知中未命名日志器的名称
+const defaultLoggerName = "auto-claude-code"
+
+// SetLevelRequest logging/setLevel 请求
+type SetLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// LoggingMessageParams notifications/message 参数
+type LoggingMessageParams struct {
+	Level  string      `json:"level"`
+	Logger string      `json:"logger,omitempty"`
+	Data   interface{} `json:"data"`
+}
+
+// mcpLogLevels MCP日志级别（RFC 5424）与 zap 日志级别的对应关系
+var mcpLogLevels = map[string]zapcore.Level{
+	"debug":     zapcore.DebugLevel,
+	"info":      zapcore.InfoLevel,
+	"notice":    zapcore.InfoLevel,
+	"warning":   zapcore.WarnLevel,
+	"error":     zapcore.ErrorLevel,
+	"critical":  zapcore.DPanicLevel,
+	"alert":     zapcore.PanicLevel,
+	"emergency": zapcore.FatalLevel,
+}
+
+// parseMCPLogLevel 解析MCP日志级别
+func parseMCPLogLevel(level string) (zapcore.Level, error) {
+	if l, ok := mcpLogLevels[level]; ok {
+		return l, nil
+	}
+	return zapcore.InfoLevel, apperrors.Newf(apperrors.ErrMCPProtocolError, "无效的日志级别: %s", level)
+}
+
+// mcpLogLevelName 获取 zap 日志级别对应的MCP日志级别名称
+func mcpLogLevelName(level zapcore.Level) string {
+	switch level {
+	case zapcore.DebugLevel:
+		return "debug"
+	case zapcore.InfoLevel:
+		return "info"
+	case zapcore.WarnLevel:
+		return "warning"
+	case zapcore.ErrorLevel:
+		return "error"
+	case zapcore.DPanicLevel:
+		return "critical"
+	case zapcore.PanicLevel:
+		return "alert"
+	default:
+		return "emergency"
+	}
+}
+
+// SetLogLevel 设置发起请求的会话接收日志通知的最低级别，仅支持可推送通知的会话（stdio、SSE）
+func (h *protocolHandler) SetLogLevel(ctx context.Context, level string) error {
+	sessionID := sessionFromContext(ctx)
+	if h.notifier == nil || sessionID == "" {
+		return apperrors.New(apperrors.ErrMCPProtocolError, "当前传输不支持日志通知，请使用 stdio 或 SSE 传输")
+	}
+	return h.notifier.SetLogLevel(sessionID, level)
+}
+
+// mcpLogCore 将 zap 日志转发为MCP日志通知的 zapcore.Core
+// 只有调用过 logging/setLevel 的会话才会收到日志，发送通知的路径（传输层、分发器）不能使用经过该 Core 的日志器，否则会递归
+type mcpLogCore struct {
+	dispatcher *NotificationDispatcher
+	fields     []zapcore.Field
+}
+
+// Enabled 是否有会话需要该级别的日志
+func (c *mcpLogCore) Enabled(level zapcore.Level) bool {
+	return c.dispatcher.logEnabled(level)
+}
+
+// With 添加上下文字段
+func (c *mcpLogCore) With(fields []zapcore.Field) zapcore.Core {
+	merged := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	merged = append(merged, c.fields...)
+	merged = append(merged, fields...)
+	return &mcpLogCore{dispatcher: c.dispatcher, fields: merged}
+}
+
+// Check 判断是否记录该条日志
+func (c *mcpLogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write 将日志条目作为通知发送给订阅的会话
+func (c *mcpLogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(encoder)
+	}
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+
+	data := map[string]interface{}{"message": entry.Message}
+	if len(encoder.Fields) > 0 {
+		data["fields"] = encoder.Fields
+	}
+
+	loggerName := entry.LoggerName
+	if loggerName == "" {
+		loggerName = defaultLoggerName
+	}
+
+	c.dispatcher.Log(entry.Level, loggerName, data)
+	return nil
+}
+
+// Sync 通知无需刷新
+func (c *mcpLogCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,347 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"auto-claude-code/internal/logger"
+)
+
+// MCP通知方法
+const (
+	NotificationProgress         = "notifications/progress"
+	NotificationToolsListChanged = "notifications/tools/list_changed"
+	NotificationTaskStatus       = "notifications/tasks/status"
+	NotificationResourceUpdated  = "notifications/resources/updated"
+	NotificationResourcesChanged = "notifications/resources/list_changed"
+)
+
+// logUpdateInterval 任务日志资源更新通知的最小间隔，避免逐行推送
+const logUpdateInterval = time.Second
+
+// JSONRPCNotification JSON-RPC 2.0 通知结构（没有ID，不需要响应）
+type JSONRPCNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// ProgressParams notifications/progress 参数
+type ProgressParams struct {
+	ProgressToken interface{} `json:"progressToken"`
+	Progress      float64     `json:"progress"`
+	Total         float64     `json:"total,omitempty"`
+	Message       string      `json:"message,omitempty"`
+}
+
+// SessionRegistry 为支持服务器推送的传输登记会话
+// 传输处理器实现该接口时，传输在建立会话后登记发送函数，并将会话ID放入请求上下文
+type SessionRegistry interface {
+	RegisterSession(id string, send func(msg interface{})) (unregister func())
+}
+
+// sessionContextKey 请求上下文中的会话ID键
+type sessionContextKey struct{}
+
+// withSession 在上下文中记录发起请求的会话
+func withSession(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, sessionID)
+}
+
+// sessionFromContext 获取发起请求的会话ID，没有会话（如普通HTTP请求）时返回空
+func sessionFromContext(ctx context.Context) string {
+	sessionID, _ := ctx.Value(sessionContextKey{}).(string)
+	return sessionID
+}
+
+// progressSubscription 任务进度订阅
+type progressSubscription struct {
+	sessionID string
+	token     interface{}
+}
+
+// NotificationDispatcher 通知分发器，将任务事件推送给已连接的客户端会话
+type NotificationDispatcher struct {
+	logger logger.Logger
+
+	mu            sync.RWMutex
+	sessions      map[string]func(msg interface{})
+	progress      map[string]progressSubscription // 任务ID -> 进度订阅
+	subscriptions map[string]map[string]struct{}  // 资源URI -> 订阅的会话
+	lastUpdated   map[string]time.Time            // 资源URI -> 上次发送更新通知的时间
+	logLevels     map[string]zapcore.Level        // 会话 -> 接收日志通知的最低级别
+}
+
+// NewNotificationDispatcher 创建通知分发器
+func NewNotificationDispatcher(log logger.Logger) *NotificationDispatcher {
+	return &NotificationDispatcher{
+		logger:        log,
+		sessions:      make(map[string]func(msg interface{})),
+		progress:      make(map[string]progressSubscription),
+		subscriptions: make(map[string]map[string]struct{}),
+		lastUpdated:   make(map[string]time.Time),
+		logLevels:     make(map[string]zapcore.Level),
+	}
+}
+
+// RegisterSession 登记会话，返回取消登记函数
+func (d *NotificationDispatcher) RegisterSession(id string, send func(msg interface{})) func() {
+	d.mu.Lock()
+	d.sessions[id] = send
+	d.mu.Unlock()
+
+	d.logger.Debug("通知会话已登记", zap.String("session", id))
+
+	return func() {
+		d.mu.Lock()
+		delete(d.sessions, id)
+		delete(d.logLevels, id)
+		for taskID, sub := range d.progress {
+			if sub.sessionID == id {
+				delete(d.progress, taskID)
+			}
+		}
+		for uri, sessions := range d.subscriptions {
+			delete(sessions, id)
+			if len(sessions) == 0 {
+				delete(d.subscriptions, uri)
+			}
+		}
+		d.mu.Unlock()
+	}
+}
+
+// TrackProgress 将任务进度推送给请求该任务的会话
+func (d *NotificationDispatcher) TrackProgress(taskID, sessionID string, token interface{}) {
+	if sessionID == "" || token == nil {
+		return
+	}
+
+	d.mu.Lock()
+	d.progress[taskID] = progressSubscription{sessionID: sessionID, token: token}
+	d.mu.Unlock()
+}
+
+// Subscribe 订阅资源更新
+func (d *NotificationDispatcher) Subscribe(sessionID, uri string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.subscriptions[uri] == nil {
+		d.subscriptions[uri] = make(map[string]struct{})
+	}
+	d.subscriptions[uri][sessionID] = struct{}{}
+}
+
+// Unsubscribe 取消订阅资源更新
+func (d *NotificationDispatcher) Unsubscribe(sessionID, uri string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if sessions, ok := d.subscriptions[uri]; ok {
+		delete(sessions, sessionID)
+		if len(sessions) == 0 {
+			delete(d.subscriptions, uri)
+			delete(d.lastUpdated, uri)
+		}
+	}
+}
+
+// ResourceUpdated 通知订阅者资源已更新，throttle 为 true 时按 logUpdateInterval 限流
+func (d *NotificationDispatcher) ResourceUpdated(uri string, throttle bool) {
+	d.mu.Lock()
+	sessions := d.subscriptions[uri]
+	if len(sessions) == 0 {
+		d.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	if throttle && now.Sub(d.lastUpdated[uri]) < logUpdateInterval {
+		d.mu.Unlock()
+		return
+	}
+	d.lastUpdated[uri] = now
+
+	sends := make([]func(msg interface{}), 0, len(sessions))
+	for sessionID := range sessions {
+		if send, ok := d.sessions[sessionID]; ok {
+			sends = append(sends, send)
+		}
+	}
+	d.mu.Unlock()
+
+	notification := newNotification(NotificationResourceUpdated, &ResourceUpdatedParams{URI: uri})
+	for _, send := range sends {
+		send(notification)
+	}
+}
+
+// SetLogLevel 设置会话接收日志通知的最低级别
+func (d *NotificationDispatcher) SetLogLevel(sessionID, level string) error {
+	zapLevel, err := parseMCPLogLevel(level)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.logLevels[sessionID] = zapLevel
+	d.mu.Unlock()
+	return nil
+}
+
+// LogCore 创建将日志转发为 notifications/message 的 zapcore.Core
+func (d *NotificationDispatcher) LogCore() zapcore.Core {
+	return &mcpLogCore{dispatcher: d}
+}
+
+// logEnabled 是否有会话需要该级别的日志
+func (d *NotificationDispatcher) logEnabled(level zapcore.Level) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for _, minLevel := range d.logLevels {
+		if level >= minLevel {
+			return true
+		}
+	}
+	return false
+}
+
+// Log 向日志级别满足条件的会话发送日志通知
+func (d *NotificationDispatcher) Log(level zapcore.Level, loggerName string, data interface{}) {
+	d.mu.RLock()
+	sends := make([]func(msg interface{}), 0, len(d.logLevels))
+	for sessionID, minLevel := range d.logLevels {
+		if level < minLevel {
+			continue
+		}
+		if send, ok := d.sessions[sessionID]; ok {
+			sends = append(sends, send)
+		}
+	}
+	d.mu.RUnlock()
+
+	if len(sends) == 0 {
+		return
+	}
+
+	notification := newNotification(NotificationMessage, &LoggingMessageParams{
+		Level:  mcpLogLevelName(level),
+		Logger: loggerName,
+		Data:   data,
+	})
+	for _, send := range sends {
+		send(notification)
+	}
+}
+
+// Notify 向指定会话发送通知
+func (d *NotificationDispatcher) Notify(sessionID, method string, params interface{}) {
+	d.mu.RLock()
+	send, ok := d.sessions[sessionID]
+	d.mu.RUnlock()
+
+	if ok {
+		send(newNotification(method, params))
+	}
+}
+
+// Broadcast 向所有会话发送通知
+func (d *NotificationDispatcher) Broadcast(method string, params interface{}) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	notification := newNotification(method, params)
+	for _, send := range d.sessions {
+		send(notification)
+	}
+}
+
+// ToolsListChanged 通知客户端工具列表已变化，客户端应重新调用 tools/list
+func (d *NotificationDispatcher) ToolsListChanged() {
+	d.Broadcast(NotificationToolsListChanged, nil)
+}
+
+// HandleTaskEvent 处理任务事件：向订阅者推送进度和资源更新，向所有会话广播状态变化
+func (d *NotificationDispatcher) HandleTaskEvent(event TaskEvent) {
+	task := event.Task
+
+	switch event.Type {
+	case TaskEventCreated:
+		d.Broadcast(NotificationResourcesChanged, nil)
+	case TaskEventOutput:
+		d.ResourceUpdated(taskLogResourceURI(task.ID), true)
+		d.notifyOutput(task, event.Output)
+		return
+	}
+
+	d.ResourceUpdated(taskResourceURI(task.ID), false)
+	if isTerminalStatus(task.Status) {
+		// 任务结束时确保订阅者读取到完整日志
+		d.ResourceUpdated(taskLogResourceURI(task.ID), false)
+	}
+
+	d.mu.RLock()
+	sub, tracked := d.progress[task.ID]
+	d.mu.RUnlock()
+
+	if tracked {
+		d.Notify(sub.sessionID, NotificationProgress, &ProgressParams{
+			ProgressToken: sub.token,
+			Progress:      task.Progress * 100,
+			Total:         100,
+			Message:       task.Message,
+		})
+	}
+
+	if event.Type == TaskEventProgress {
+		return
+	}
+
+	d.Broadcast(NotificationTaskStatus, task)
+
+	if isTerminalStatus(task.Status) && tracked {
+		d.mu.Lock()
+		delete(d.progress, task.ID)
+		d.mu.Unlock()
+	}
+}
+
+// notifyOutput 将新输出行作为进度通知的消息推送给请求该任务的会话，进度值保持当前进度
+func (d *NotificationDispatcher) notifyOutput(task *TaskStatus, output *OutputLine) {
+	if output == nil {
+		return
+	}
+
+	d.mu.RLock()
+	sub, tracked := d.progress[task.ID]
+	d.mu.RUnlock()
+	if !tracked {
+		return
+	}
+
+	d.Notify(sub.sessionID, NotificationProgress, &ProgressParams{
+		ProgressToken: sub.token,
+		Progress:      task.Progress * 100,
+		Total:         100,
+		Message:       output.Line,
+	})
+}
+
+// newNotification 创建JSON-RPC通知
+func newNotification(method string, params interface{}) *JSONRPCNotification {
+	return &JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	}
+}
+
+// isTerminalStatus 任务是否已结束
+func isTerminalStatus(status string) bool {
+	return status == "completed" || status == "failed" || status == "cancelled" || status == "interrupted" || status == "timeout"
+}
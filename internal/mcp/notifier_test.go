@@ -0,0 +1,145 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/logger"
+)
+
+func TestNotificationDispatcher_HandleTaskEvent(t *testing.T) {
+	log, err := logger.CreateLoggerFromConfig("info", false, "")
+	if err != nil {
+		t.Fatalf("创建日志器失败: %v", err)
+	}
+
+	dispatcher := NewNotificationDispatcher(log)
+
+	received := make(map[string][]*JSONRPCNotification)
+	for _, id := range []string{"a", "b"} {
+		id := id
+		dispatcher.RegisterSession(id, func(msg interface{}) {
+			received[id] = append(received[id], msg.(*JSONRPCNotification))
+		})
+	}
+
+	dispatcher.TrackProgress("task_1", "a", "token-1")
+
+	dispatcher.HandleTaskEvent(TaskEvent{Type: TaskEventProgress, Task: &TaskStatus{ID: "task_1", Status: "running", Progress: 0.4}})
+	dispatcher.HandleTaskEvent(TaskEvent{Type: TaskEventOutput, Task: &TaskStatus{ID: "task_1", Status: "running", Progress: 0.6}, Output: &OutputLine{Stream: "stdout", Line: "running tests"}})
+	dispatcher.HandleTaskEvent(TaskEvent{Type: TaskEventStatus, Task: &TaskStatus{ID: "task_1", Status: "completed", Progress: 1}})
+	dispatcher.HandleTaskEvent(TaskEvent{Type: TaskEventProgress, Task: &TaskStatus{ID: "task_1", Status: "completed", Progress: 1}})
+
+	// 会话 a：进度、输出行、结束时的进度（结束后不再推送）+ 一次状态
+	methods := []string{NotificationProgress, NotificationProgress, NotificationProgress, NotificationTaskStatus}
+	if len(received["a"]) != len(methods) {
+		t.Fatalf("会话 a 收到 %d 条通知, 期望 %d", len(received["a"]), len(methods))
+	}
+	for i, method := range methods {
+		if received["a"][i].Method != method {
+			t.Errorf("会话 a 第 %d 条通知 = %s, 期望 %s", i, received["a"][i].Method, method)
+		}
+	}
+
+	progress := received["a"][0].Params.(*ProgressParams)
+	if progress.ProgressToken != "token-1" || progress.Progress != 40 || progress.Total != 100 {
+		t.Errorf("进度通知参数 = %+v", progress)
+	}
+	if output := received["a"][1].Params.(*ProgressParams); output.Message != "running tests" || output.Progress != 60 {
+		t.Errorf("输出行通知参数 = %+v", output)
+	}
+
+	// 会话 b 只收到状态广播
+	if len(received["b"]) != 1 || received["b"][0].Method != NotificationTaskStatus {
+		t.Errorf("会话 b 通知 = %+v", received["b"])
+	}
+}
+
+func TestSessionContext(t *testing.T) {
+	if got := sessionFromContext(context.Background()); got != "" {
+		t.Errorf("无会话时 = %q, 期望空", got)
+	}
+	if got := sessionFromContext(withSession(context.Background(), "s1")); got != "s1" {
+		t.Errorf("会话ID = %q, 期望 s1", got)
+	}
+}
+
+func TestNotificationDispatcher_ResourceSubscription(t *testing.T) {
+	log, err := logger.CreateLoggerFromConfig("info", false, "")
+	if err != nil {
+		t.Fatalf("创建日志器失败: %v", err)
+	}
+
+	dispatcher := NewNotificationDispatcher(log)
+
+	var updated []string
+	unregister := dispatcher.RegisterSession("a", func(msg interface{}) {
+		n := msg.(*JSONRPCNotification)
+		if n.Method == NotificationResourceUpdated {
+			updated = append(updated, n.Params.(*ResourceUpdatedParams).URI)
+		}
+	})
+
+	dispatcher.Subscribe("a", taskLogResourceURI("task_1"))
+
+	// 连续输出在限流间隔内只通知一次，任务结束时总会通知
+	output := &TaskStatus{ID: "task_1", Status: "running"}
+	dispatcher.HandleTaskEvent(TaskEvent{Type: TaskEventOutput, Task: output})
+	dispatcher.HandleTaskEvent(TaskEvent{Type: TaskEventOutput, Task: output})
+	dispatcher.HandleTaskEvent(TaskEvent{Type: TaskEventStatus, Task: &TaskStatus{ID: "task_1", Status: "completed"}})
+
+	expected := []string{"task://task_1/log", "task://task_1/log"}
+	if len(updated) != len(expected) {
+		t.Fatalf("资源更新通知 = %v, 期望 %v", updated, expected)
+	}
+
+	// 会话断开后不再通知
+	unregister()
+	dispatcher.HandleTaskEvent(TaskEvent{Type: TaskEventStatus, Task: &TaskStatus{ID: "task_1", Status: "completed"}})
+	if len(updated) != len(expected) {
+		t.Errorf("会话断开后仍收到通知: %v", updated)
+	}
+}
+
+func TestNotificationDispatcher_LogCore(t *testing.T) {
+	dispatcher := NewNotificationDispatcher(logger.FromZap(zap.NewNop()))
+
+	received := make(map[string][]*LoggingMessageParams)
+	for _, id := range []string{"debug", "warn", "none"} {
+		id := id
+		dispatcher.RegisterSession(id, func(msg interface{}) {
+			received[id] = append(received[id], msg.(*JSONRPCNotification).Params.(*LoggingMessageParams))
+		})
+	}
+	if err := dispatcher.SetLogLevel("debug", "debug"); err != nil {
+		t.Fatalf("设置日志级别失败: %v", err)
+	}
+	if err := dispatcher.SetLogLevel("warn", "warning"); err != nil {
+		t.Fatalf("设置日志级别失败: %v", err)
+	}
+	if err := dispatcher.SetLogLevel("warn", "verbose"); err == nil {
+		t.Error("无效日志级别应返回错误")
+	}
+
+	log := zap.New(dispatcher.LogCore()).With(zap.String("taskId", "task_1"))
+	log.Debug("调试信息")
+	log.Warn("警告信息", zap.Int("attempt", 2))
+
+	if len(received["debug"]) != 2 {
+		t.Fatalf("debug 会话收到 %d 条日志, 期望 2", len(received["debug"]))
+	}
+	if len(received["warn"]) != 1 || received["warn"][0].Level != "warning" {
+		t.Fatalf("warn 会话日志 = %+v", received["warn"])
+	}
+	if len(received["none"]) != 0 {
+		t.Errorf("未设置日志级别的会话不应收到日志: %+v", received["none"])
+	}
+
+	data := received["warn"][0].Data.(map[string]interface{})
+	fields := data["fields"].(map[string]interface{})
+	if data["message"] != "警告信息" || fields["taskId"] != "task_1" || fields["attempt"] != int64(2) {
+		t.Errorf("日志数据 = %+v", data)
+	}
+}
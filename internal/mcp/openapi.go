@@ -0,0 +1,696 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"auto-claude-code/internal/auth"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/wsl"
+
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+const (
+	// openAPIPath OpenAPI 规范路径
+	openAPIPath = "/openapi.json"
+	// apiDocsPath Swagger UI 文档页路径
+	apiDocsPath = "/docs"
+	// apiDocsAssetsPath 内嵌的 Swagger UI 静态资源路径前缀
+	apiDocsAssetsPath = "/docs/assets/"
+	// apiVersion REST API 版本
+	apiVersion = "1.0.0"
+)
+
+// schemaRequired 请求体模式的必需字段，覆盖按 omitempty 推断的结果
+var schemaRequired = map[string][]string{
+	"TaskRequest":        {"type", "projectPath"},
+	"CreateTokenRequest": {"name", "scopes"},
+}
+
+// schemaFieldOverrides 字段模式的补充说明，键为 "类型名.JSON字段名"
+var schemaFieldOverrides = map[string]map[string]interface{}{
+	"TaskRequest.type":          {"enum": []string{"claude_code", "interactive"}},
+	"TaskRequest.timeout":       {"description": "超时时间（纳秒），0 表示使用 mcp.task_timeout"},
+	"TaskRequest.priority":      {"description": "优先级，数值越大越先执行"},
+	"TaskRequest.distroTags":    {"description": "执行任务的发行版需要具有的全部标签，未指定 distro 时从 mcp.distros 中选择具有全部标签、执行中任务最少的发行版"},
+	"TaskRequest.dependsOn":     {"description": "依赖的任务ID，全部成功完成后才入队执行，任一依赖未成功完成时任务直接失败"},
+	"TaskRequest.template":      {"description": "套用的任务模板，请求中未设置的字段取自模板，args 追加在模板的 args 之后"},
+	"TaskRequest.params":        {"description": "模板参数，替换模板中的 {{参数名}}，覆盖模板的 defaults"},
+	"TaskTemplate.timeout":      {"description": "超时时间（纳秒），0 表示使用 mcp.task_timeout"},
+	"TaskTemplate.parameters":   {"description": "模板引用的参数，保存时生成，请求中提供的值被忽略"},
+	"FollowUpTask.timeout":      {"description": "超时时间（纳秒），0 表示使用 mcp.task_timeout"},
+	"FollowUpTask.priority":     {"description": "优先级，0 表示沿用原任务"},
+	"MergeOptions.strategy":     {"enum": []string{MergeStrategyMerge, MergeStrategyRebase}},
+	"MergeResult.status":        {"enum": []string{MergeStatusMerged, MergeStatusReady, MergeStatusConflict, MergeStatusNoChanges, MergeStatusFailed}},
+	"PullRequestResult.status":  {"enum": []string{PullRequestStatusCreated, PullRequestStatusNoChanges, PullRequestStatusFailed}},
+	"TaskStatus.status":         {"enum": []string{"pending", "running", "completed", "failed", "cancelled", "interrupted", "timeout"}},
+	"TaskStatus.phase":          {"enum": []string{TaskPhaseThinking, TaskPhaseTool, TaskPhaseFinishing}},
+	"WorktreeInfo.status":       {"enum": []string{WorktreeStateProvisioning, WorktreeStateInUse, WorktreeStateIdle, WorktreeStateCleanup}},
+	"ChangedFile.status":        {"enum": []string{FileStatusAdded, FileStatusModified, FileStatusDeleted, FileStatusRenamed}},
+	"CreateTokenRequest.scopes": {"items": map[string]interface{}{"type": "string", "enum": auth.ValidScopes}},
+	"CreateTokenRequest.expires_in": {
+		"description": "有效期，如 \"720h\"，留空表示永不过期",
+		"example":     "720h",
+	},
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// schemaRegistry 根据 Go 类型生成 OpenAPI 组件模式，与 encoding/json 的编码结果一致
+type schemaRegistry struct {
+	schemas map[string]interface{}
+}
+
+// newSchemaRegistry 创建模式注册表
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{schemas: make(map[string]interface{})}
+}
+
+// ref 获取值类型的模式，具名结构体注册到 components 并返回引用
+func (g *schemaRegistry) ref(v interface{}) map[string]interface{} {
+	return g.schemaOf(reflect.TypeOf(v))
+}
+
+// schemaOf 生成类型的模式
+func (g *schemaRegistry) schemaOf(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]interface{}{"type": "integer", "format": "int64", "description": "纳秒"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schemaOf(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t, "")
+		}
+		name := exportedName(t.Name())
+		if _, ok := g.schemas[name]; !ok {
+			// 先占位，避免自引用类型无限递归
+			g.schemas[name] = nil
+			g.schemas[name] = g.structSchema(t, name)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	default:
+		// interface{} 等任意值
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema 生成结构体的对象模式，匿名嵌入的结构体字段会展开
+func (g *schemaRegistry) structSchema(t reflect.Type, name string) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	g.collectFields(t, name, properties, &required)
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if override, ok := schemaRequired[name]; ok {
+		required = override
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// collectFields 收集结构体的 JSON 字段
+func (g *schemaRegistry) collectFields(t reflect.Type, name string, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		fieldName, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && fieldName == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.collectFields(embedded, name, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+
+		schema := g.schemaOf(field.Type)
+		if override, ok := schemaFieldOverrides[name+"."+fieldName]; ok {
+			// 引用不能与其他关键字并列，有补充说明时改用 allOf
+			if _, isRef := schema["$ref"]; isRef {
+				schema = map[string]interface{}{"allOf": []interface{}{schema}}
+			}
+			for key, value := range override {
+				schema[key] = value
+			}
+		}
+		properties[fieldName] = schema
+
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, fieldName)
+		}
+	}
+}
+
+// exportedName 组件名统一使用首字母大写
+func exportedName(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+// statusCounts 总数和按状态统计的数量
+type statusCounts struct {
+	Total    int            `json:"total"`
+	ByStatus map[string]int `json:"by_status"`
+}
+
+// metricsResponse 指标响应
+type metricsResponse struct {
+	Tasks      statusCounts        `json:"tasks"`
+	Worktrees  statusCounts        `json:"worktrees"`
+	Scheduling SchedulingMetrics   `json:"scheduling"`
+	System     wsl.ResourceMetrics `json:"system"`
+	Timestamp  string              `json:"timestamp"`
+}
+
+// taskArtifactsResponse 任务产物列表响应
+type taskArtifactsResponse struct {
+	TaskID    string         `json:"taskId"`
+	Artifacts []TaskArtifact `json:"artifacts"`
+}
+
+// templateListResponse 任务模板列表响应
+type templateListResponse struct {
+	Templates []*TaskTemplate `json:"templates"`
+}
+
+// worktreeListResponse worktree列表响应
+type worktreeListResponse struct {
+	Worktrees []*WorktreeInfo `json:"worktrees"`
+}
+
+// tokenListResponse 令牌列表响应
+type tokenListResponse struct {
+	Tokens []*auth.TokenRecord `json:"tokens"`
+}
+
+// openAPISpec 生成 OpenAPI 3.0 规范，路径随配置启用的端点变化
+func (s *mcpServer) openAPISpec(baseURL string) map[string]interface{} {
+	reg := newSchemaRegistry()
+
+	jsonContent := func(schema map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+	}
+	response := func(description string, v interface{}) map[string]interface{} {
+		resp := map[string]interface{}{"description": description}
+		if v != nil {
+			resp["content"] = jsonContent(reg.ref(v))
+		}
+		return resp
+	}
+	errorResp := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"description": description,
+			"content":     map[string]interface{}{problemContentType: map[string]interface{}{"schema": reg.ref(Problem{})}},
+		}
+	}
+	operation := func(tag, summary string, responses map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"tags": []string{tag}, "summary": summary, "responses": responses}
+	}
+	withBody := func(op map[string]interface{}, v interface{}) map[string]interface{} {
+		op["requestBody"] = map[string]interface{}{"required": true, "content": jsonContent(reg.ref(v))}
+		return op
+	}
+	withParams := func(op map[string]interface{}, params ...map[string]interface{}) map[string]interface{} {
+		op["parameters"] = params
+		return op
+	}
+	pathParam := func(name, description string) map[string]interface{} {
+		return map[string]interface{}{
+			"name": name, "in": "path", "required": true, "description": description,
+			"schema": map[string]interface{}{"type": "string"},
+		}
+	}
+	queryParam := func(name, description string) map[string]interface{} {
+		return map[string]interface{}{
+			"name": name, "in": "query", "description": description,
+			"schema": map[string]interface{}{"type": "integer"},
+		}
+	}
+	stringQuery := func(name, description string) map[string]interface{} {
+		return map[string]interface{}{
+			"name": name, "in": "query", "description": description,
+			"schema": map[string]interface{}{"type": "string"},
+		}
+	}
+
+	paths := map[string]interface{}{
+		"/mcp": map[string]interface{}{
+			"post": withBody(operation("mcp", "发送 MCP JSON-RPC 请求", map[string]interface{}{
+				"200": response("JSON-RPC 响应", JSONRPCResponse{}),
+				"429": errorResp("请求过于频繁，Retry-After 头给出等待秒数"),
+			}), JSONRPCRequest{}),
+		},
+		"/tasks": map[string]interface{}{
+			"get": withParams(operation("tasks", "过滤、排序并分页列出任务", map[string]interface{}{
+				"200": response("任务列表，ETag 头可用于 If-None-Match 条件请求", taskListResponse{}),
+				"304": response("任务列表未变化", nil),
+				"400": errorResp("查询参数无效"),
+			}),
+				stringQuery("status", "任务状态，多个状态用逗号分隔"),
+				stringQuery("project", "项目路径，精确匹配"),
+				stringQuery("since", "只返回此时间及之后创建的任务（RFC 3339）"),
+				stringQuery("sort", "排序字段：createdAt、startTime、endTime、status、progress、id，\"-\" 前缀表示降序，默认 -createdAt"),
+				queryParam("limit", fmt.Sprintf("每页任务数，默认 %d，上限 %d", defaultTasksPageSize, maxTasksPageSize)),
+				queryParam("offset", "起始位置"),
+				stringQuery("cursor", "上一页返回的 nextCursor，只能与默认排序一起使用")),
+			"post": withParams(withBody(operation("tasks", "提交任务；validateOnly 时只执行检查并返回执行计划", map[string]interface{}{
+				"200": response("validateOnly 时的执行计划，不创建任务", TaskPlan{}),
+				"201": response("已提交的任务", TaskStatus{}),
+				"400": errorResp("请求格式无效、包含未知字段或字段取值无效，errors 列出每个字段的问题"),
+				"413": errorResp("请求体超过 mcp.http.max_body_bytes"),
+				"429": errorResp("任务提交过于频繁或超出客户端配额（QUOTA_EXCEEDED）"),
+				"500": errorResp("提交失败或无法创建工作树"),
+				"503": errorResp("validateOnly 时 Claude Code 不可用"),
+			}), TaskRequest{}),
+				stringQuery("validate_only", "为 true 时等同于请求体中的 validateOnly")),
+		},
+		eventsPath: map[string]interface{}{
+			"get": withParams(operation("tasks", "以 SSE 推送任务事件（task.created、task.started、task.progress、task.completed、task.failed、task.cancelled、task.timeout），断线重连时通过 Last-Event-ID 头补发错过的事件", map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "事件流，每个事件的 data 为 JSON",
+					"content":     map[string]interface{}{"text/event-stream": map[string]interface{}{"schema": reg.ref(taskStreamEvent{})}},
+				},
+				"400": errorResp("Last-Event-ID 无效"),
+			}), stringQuery("task", "只订阅指定任务的事件")),
+		},
+		"/tasks/{id}": map[string]interface{}{
+			"get": withParams(operation("tasks", "获取任务状态", map[string]interface{}{
+				"200": response("任务状态", TaskStatus{}),
+				"404": errorResp("任务不存在"),
+			}), pathParam("id", "任务ID")),
+			"delete": withParams(operation("tasks", "取消任务", map[string]interface{}{
+				"204": response("已取消", nil),
+				"404": errorResp("任务不存在"),
+			}), pathParam("id", "任务ID")),
+		},
+		"/tasks/{id}/output": map[string]interface{}{
+			"get": withParams(operation("tasks", "分页获取任务输出", map[string]interface{}{
+				"200": response("输出分页", TaskOutputPage{}),
+				"400": errorResp("分页参数无效"),
+				"404": errorResp("任务不存在"),
+			}),
+				pathParam("id", "任务ID"),
+				queryParam("stream", "stdout 或 stderr，只读取对应的输出日志文件，省略时返回合并的输出"),
+				queryParam("offset", "起始字节偏移，负数表示从末尾倒数"),
+				queryParam("limit", fmt.Sprintf("本页字节数，默认 %d，上限 %d", defaultOutputPageSize, maxOutputPageSize))),
+		},
+		"/tasks/{id}/artifacts": map[string]interface{}{
+			"get": withParams(operation("tasks", "列出任务产物：diff.patch、changed-files.txt 和 files/ 下匹配 mcp.artifacts.patterns 的文件", map[string]interface{}{
+				"200": response("产物列表，ETag 头可用于 If-None-Match 条件请求", taskArtifactsResponse{}),
+				"304": response("产物列表未变化", nil),
+				"404": errorResp("任务不存在或未启用产物收集"),
+			}), pathParam("id", "任务ID")),
+		},
+		"/tasks/{id}/notes": map[string]interface{}{
+			"get": withParams(operation("tasks", "列出任务备注", map[string]interface{}{
+				"200": response("按添加顺序排列的备注，ETag 头可用于 If-None-Match 条件请求", taskNotesResponse{}),
+				"304": response("备注未变化", nil),
+				"404": errorResp("任务不存在"),
+			}), pathParam("id", "任务ID")),
+			"post": withParams(withBody(operation("tasks", "为任务追加一条带时间戳的备注，已认证请求的作者为认证身份", map[string]interface{}{
+				"201": response("已添加的备注", TaskNote{}),
+				"400": errorResp("备注内容为空或过长"),
+				"404": errorResp("任务不存在"),
+				"409": errorResp("任务备注已达到上限"),
+			}), taskNoteRequest{}), pathParam("id", "任务ID")),
+		},
+		"/tasks/{id}/rerun": map[string]interface{}{
+			"post": withParams(withBody(operation("tasks", "以已结束任务的原始请求提交新任务，请求体可选，非零字段覆盖原请求", map[string]interface{}{
+				"201": response("新任务的状态，metadata.rerunOf 为原任务ID", TaskStatus{}),
+				"400": errorResp("覆盖字段无效"),
+				"404": errorResp("任务不存在"),
+				"409": errorResp("任务尚未结束或原始请求已不可用"),
+				"429": errorResp("超出客户端配额"),
+				"503": errorResp("任务队列已满"),
+			}), RerunOverrides{}), pathParam("id", "原任务ID")),
+		},
+		"/tasks/{id}/merge": map[string]interface{}{
+			"post": withParams(withBody(operation("tasks", "提交任务工作树中的修改并合并回项目的原分支，请求体可选；冲突时 status 为 conflict，项目不会被修改", map[string]interface{}{
+				"200": response("合并结果，同时记录在任务结果的 mergeBack 中", MergeResult{}),
+				"400": errorResp("合并参数无效"),
+				"404": errorResp("任务不存在"),
+				"409": errorResp("任务未成功完成、没有工作树，或项目未检出原分支、有未提交的修改"),
+				"500": errorResp("Git 操作失败"),
+			}), MergeOptions{}), pathParam("id", "任务ID")),
+		},
+		"/tasks/{id}/artifacts/{name}": map[string]interface{}{
+			"get": withParams(operation("tasks", "下载任务产物文件，支持 Range 请求", map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "产物文件内容",
+					"content":     map[string]interface{}{"application/octet-stream": map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}}},
+				},
+				"400": errorResp("产物名称无效"),
+				"404": errorResp("任务或产物不存在"),
+			}), pathParam("id", "任务ID"), pathParam("name", "产物名称，可包含 /")),
+		},
+		"/tasks/{id}/attach": map[string]interface{}{
+			"get": withParams(operation("tasks", "升级为 WebSocket 连接交互式任务的终端，二进制消息为终端输出和输入，文本消息为 JSON 控制消息，会话结束时发送 exit 消息并关闭连接", map[string]interface{}{
+				"101": map[string]interface{}{
+					"description": "已切换到 WebSocket，文本消息为 JSON 控制消息",
+					"content":     jsonContent(reg.ref(sessionMessage{})),
+				},
+				"400": errorResp("不是 WebSocket 升级请求"),
+				"404": errorResp("任务不存在"),
+				"409": errorResp("任务没有正在运行的交互式会话"),
+			}), pathParam("id", "任务ID")),
+		},
+		"/api/v1/tasks/{id}/output/stream": map[string]interface{}{
+			"get": withParams(operation("tasks", "升级为 WebSocket 或以 SSE（Accept: text/event-stream）先发送已捕获的输出再逐行推送新输出，任务结束时发送 status 消息并关闭连接", map[string]interface{}{
+				"101": map[string]interface{}{
+					"description": "已切换到 WebSocket，每条文本消息为 JSON",
+					"content":     jsonContent(reg.ref(outputStreamMessage{})),
+				},
+				"200": map[string]interface{}{
+					"description": "SSE 事件流，事件名为消息类型，输出行的事件ID为字节偏移",
+					"content":     map[string]interface{}{"text/event-stream": map[string]interface{}{"schema": reg.ref(outputStreamMessage{})}},
+				},
+				"400": errorResp("既不是 WebSocket 升级请求也不接受 SSE，或参数无效"),
+				"404": errorResp("任务不存在"),
+			}),
+				pathParam("id", "任务ID"),
+				queryParam("offset", "起始字节偏移，负数表示从末尾倒数，重连时传入最后收到的 offset；SSE 未指定时使用 Last-Event-ID")),
+		},
+		"/worktrees": map[string]interface{}{
+			"get": operation("worktrees", "列出 worktree", map[string]interface{}{
+				"200": response("worktree 列表，ETag 头可用于 If-None-Match 条件请求", worktreeListResponse{}),
+				"304": response("worktree 列表未变化", nil),
+			}),
+		},
+		"/queue": map[string]interface{}{
+			"get": operation("queue", "获取任务队列的调度状态", map[string]interface{}{
+				"200": response("队列状态", QueueState{}),
+			}),
+		},
+		"/queue/pause": map[string]interface{}{
+			"post": func() map[string]interface{} {
+				op := operation("queue", "暂停向工作器分发任务，正在执行的任务继续运行，新提交的任务继续排队（需要 admin 角色）", map[string]interface{}{
+					"200": response("暂停后的队列状态，已暂停时保持原来的暂停时间和原因", QueueState{}),
+					"400": errorResp("请求体无效"),
+				})
+				op["requestBody"] = map[string]interface{}{"required": false, "content": jsonContent(reg.ref(queuePauseRequest{}))}
+				return op
+			}(),
+		},
+		"/queue/resume": map[string]interface{}{
+			"post": operation("queue", "恢复向工作器分发任务（需要 admin 角色）", map[string]interface{}{
+				"200": response("恢复后的队列状态", QueueState{}),
+			}),
+		},
+		"/quotas": map[string]interface{}{
+			"get": withParams(operation("quotas", "查询客户端配额用量，admin 返回所有客户端，其他角色只返回自己的用量", map[string]interface{}{
+				"200": response("配额用量，ETag 头可用于 If-None-Match 条件请求", quotaListResponse{}),
+				"304": response("配额用量未变化", nil),
+			}),
+				stringQuery("client", "只返回该客户端的用量，仅 admin 角色生效")),
+		},
+		"/export": map[string]interface{}{
+			"get": withParams(operation("tasks", "以 JSON Lines 导出已结束的任务记录（含输出引用），格式与归档文件相同", map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "每行一条任务记录，按结束时间从早到晚排序",
+					"content":     map[string]interface{}{exportContentType: map[string]interface{}{"schema": reg.ref(ArchivedTask{})}},
+				},
+				"400": errorResp("since 参数无效"),
+			}),
+				stringQuery("since", "只导出此时间及之后结束的任务（RFC 3339）"),
+				stringQuery("status", "任务状态，多个值用逗号分隔")),
+		},
+		"/templates": map[string]interface{}{
+			"get": operation("templates", "列出任务模板", map[string]interface{}{
+				"200": response("模板列表，ETag 头可用于 If-None-Match 条件请求", templateListResponse{}),
+				"304": response("模板列表未变化", nil),
+			}),
+			"post": withBody(operation("templates", "创建任务模板，需要 admin 角色", map[string]interface{}{
+				"201": response("已创建的模板", TaskTemplate{}),
+				"400": errorResp("模板字段无效"),
+				"409": errorResp("同名模板已存在"),
+			}), TaskTemplate{}),
+		},
+		"/templates/{name}": map[string]interface{}{
+			"get": withParams(operation("templates", "获取任务模板", map[string]interface{}{
+				"200": response("任务模板", TaskTemplate{}),
+				"404": errorResp("模板不存在"),
+			}), pathParam("name", "模板名称")),
+			"put": withParams(withBody(operation("templates", "创建或替换任务模板，需要 admin 角色", map[string]interface{}{
+				"200": response("已替换的模板", TaskTemplate{}),
+				"201": response("已创建的模板", TaskTemplate{}),
+				"400": errorResp("模板字段无效"),
+			}), TaskTemplate{}), pathParam("name", "模板名称")),
+			"delete": withParams(operation("templates", "删除任务模板，需要 admin 角色", map[string]interface{}{
+				"204": response("已删除", nil),
+				"404": errorResp("模板不存在"),
+			}), pathParam("name", "模板名称")),
+		},
+		"/worktrees/{id}": map[string]interface{}{
+			"get": withParams(operation("worktrees", "获取 worktree", map[string]interface{}{
+				"200": response("worktree 信息", WorktreeInfo{}),
+				"404": errorResp("worktree 不存在"),
+			}), pathParam("id", "worktree ID")),
+			"patch": func() map[string]interface{} {
+				op := withParams(operation("worktrees", "固定或取消固定 worktree，固定的 worktree 不会被空闲清理删除（需要 admin 角色）", map[string]interface{}{
+					"200": response("更新后的 worktree 信息", WorktreeInfo{}),
+					"400": errorResp("请求体无效或缺少 pinned"),
+					"404": errorResp("worktree 不存在"),
+				}), pathParam("id", "worktree ID"))
+				op["requestBody"] = map[string]interface{}{"required": true, "content": jsonContent(reg.ref(WorktreeUpdate{}))}
+				return op
+			}(),
+			"delete": withParams(operation("worktrees", "删除 worktree", map[string]interface{}{
+				"204": response("已删除", nil),
+				"404": errorResp("worktree 不存在"),
+				"409": errorResp("worktree 已固定，需要 force=true"),
+			}), pathParam("id", "worktree ID"), queryParam("force", "为 true 时先取消固定再删除")),
+		},
+		"/worktrees/{id}/diff": map[string]interface{}{
+			"get": withParams(operation("worktrees", "获取 worktree 相对创建时基准提交的统一 diff，包含已提交、未提交的修改和未跟踪的新文件", map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "统一 diff，没有修改时为空",
+					"content":     map[string]interface{}{"text/x-diff": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}},
+				},
+				"304": response("diff 未变化", nil),
+				"404": errorResp("worktree 不存在"),
+				"500": errorResp("worktree 不是 Git 仓库或 git 命令失败"),
+			}), pathParam("id", "worktree ID")),
+		},
+		"/worktrees/{id}/archive": map[string]interface{}{
+			"get": withParams(operation("worktrees", "以 zip 下载 worktree 的文件：Git worktree 包含已跟踪和未被忽略的未跟踪文件，非 Git worktree 包含全部文件", map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "zip 压缩包",
+					"content":     map[string]interface{}{"application/zip": map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}}},
+				},
+				"400": errorResp("changed 参数无效"),
+				"404": errorResp("worktree 不存在"),
+				"500": errorResp("changed=true 时 worktree 不是 Git 仓库，或读取文件失败"),
+			}), pathParam("id", "worktree ID"), queryParam("changed", "为 true 时只包含相对基准提交修改和新增的文件")),
+		},
+		"/worktrees/{id}/status": map[string]interface{}{
+			"get": withParams(operation("worktrees", "获取 worktree 的 git status 和相对基准提交修改的文件", map[string]interface{}{
+				"200": response("worktree 状态", WorktreeStatus{}),
+				"304": response("状态未变化", nil),
+				"404": errorResp("worktree 不存在"),
+				"500": errorResp("worktree 不是 Git 仓库或 git 命令失败"),
+			}), pathParam("id", "worktree ID")),
+		},
+	}
+
+	if s.config.Monitoring.Enabled {
+		readiness := operation("monitoring", "就绪探针：WSL、工作器、队列和 worktree 目录的组件状态", map[string]interface{}{
+			"200": response("可以接收任务", readinessResponse{}),
+			"503": response("组件异常", readinessResponse{}),
+		})
+		readiness["security"] = []interface{}{}
+		paths[s.config.Monitoring.HealthPath] = map[string]interface{}{"get": readiness}
+		if s.config.Monitoring.ReadinessPath != "" {
+			paths[s.config.Monitoring.ReadinessPath] = map[string]interface{}{"get": readiness}
+		}
+		if s.config.Monitoring.LivenessPath != "" {
+			liveness := operation("monitoring", "存活探针：进程能响应即返回 200", map[string]interface{}{
+				"200": response("进程存活", livenessResponse{}),
+			})
+			liveness["security"] = []interface{}{}
+			paths[s.config.Monitoring.LivenessPath] = map[string]interface{}{"get": liveness}
+		}
+		paths[s.config.Monitoring.MetricsPath] = map[string]interface{}{
+			"get": operation("monitoring", "任务、worktree、调度和 WSL 资源指标", map[string]interface{}{
+				"200": response("指标", metricsResponse{}),
+			}),
+		}
+	}
+
+	if s.tokenStore != nil {
+		tokenID := pathParam("id", "令牌ID")
+		paths["/auth/tokens"] = map[string]interface{}{
+			"get": operation("tokens", "列出令牌", map[string]interface{}{
+				"200": response("令牌列表", tokenListResponse{}),
+			}),
+			"post": withBody(operation("tokens", "创建令牌，明文令牌只在响应中返回一次", map[string]interface{}{
+				"201": response("新令牌", createdToken{}),
+				"400": errorResp("参数无效"),
+			}), createTokenRequest{}),
+		}
+		paths["/auth/tokens/{id}"] = map[string]interface{}{
+			"get": withParams(operation("tokens", "获取令牌", map[string]interface{}{
+				"200": response("令牌信息", auth.TokenRecord{}),
+				"404": errorResp("令牌不存在"),
+			}), tokenID),
+			"delete": withParams(operation("tokens", "撤销令牌", map[string]interface{}{
+				"204": response("已撤销", nil),
+				"404": errorResp("令牌不存在"),
+			}), tokenID),
+		}
+		paths["/auth/tokens/{id}/rotate"] = map[string]interface{}{
+			"post": withParams(operation("tokens", "轮换令牌，旧令牌立即失效", map[string]interface{}{
+				"201": response("新令牌", createdToken{}),
+				"404": errorResp("令牌不存在"),
+				"409": errorResp("令牌已撤销或已过期"),
+			}), tokenID),
+		}
+	}
+
+	if s.auditLog != nil {
+		paths["/audit"] = map[string]interface{}{
+			"get": withParams(operation("audit", "查询审计事件，按时间从新到旧返回", map[string]interface{}{
+				"200": response("审计事件", auditListResponse{}),
+				"400": errorResp("查询参数无效"),
+			}),
+				stringQuery("action", "动作，以 \".\" 结尾时按前缀匹配，如 task."),
+				stringQuery("actor", "操作者"),
+				stringQuery("outcome", "结果：success、failure、denied"),
+				stringQuery("since", "起始时间（RFC 3339）"),
+				stringQuery("until", "截止时间（RFC 3339）"),
+				queryParam("limit", "最多返回的事件数，默认 100，上限 1000")),
+		}
+	}
+
+	spec := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Auto Claude Code API",
+			"version":     apiVersion,
+			"description": "任务提交、worktree 管理和监控的 REST 接口，以及 MCP JSON-RPC 端点",
+		},
+		"servers": []interface{}{map[string]interface{}{"url": baseURL}},
+		"paths":   paths,
+	}
+
+	components := map[string]interface{}{"schemas": reg.schemas}
+	if s.config.Auth.Enabled {
+		components["securitySchemes"] = map[string]interface{}{
+			"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+		}
+		spec["security"] = []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+		// 需要认证时所有响应都可能是 401/403
+		for _, item := range paths {
+			for _, op := range item.(map[string]interface{}) {
+				responses := op.(map[string]interface{})["responses"].(map[string]interface{})
+				if _, public := op.(map[string]interface{})["security"]; !public {
+					responses["401"] = errorResp("未认证或令牌无效")
+					responses["403"] = errorResp("权限不足")
+				}
+			}
+		}
+	}
+	spec["components"] = components
+
+	return spec
+}
+
+// handleOpenAPI 返回 OpenAPI 规范
+func (s *mcpServer) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持GET方法"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.openAPISpec(requestBaseURL(r)))
+}
+
+// apiDocsPage Swagger UI 页面，静态资源内嵌在程序中，不从第三方 CDN 加载
+const apiDocsPage = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+  <meta charset="utf-8">
+  <title>Auto Claude Code API</title>
+  <link rel="stylesheet" href="%[1]sswagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="%[1]sswagger-ui-bundle.js"></script>
+  <script>
+    window.onload = function () {
+      window.ui = SwaggerUIBundle({ url: %[2]q, dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
+
+// apiDocsAssets 内嵌的 Swagger UI 静态资源（swagger-ui-dist，版本由 go.mod 固定）
+var apiDocsAssets = http.StripPrefix(apiDocsAssetsPath, http.FileServer(http.FS(swaggerFiles.FS)))
+
+// handleAPIDocs 返回 Swagger UI 文档页
+func (s *mcpServer) handleAPIDocs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持GET方法"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, apiDocsPage, apiDocsAssetsPath, openAPIPath)
+}
+
+// handleAPIDocsAssets 返回 Swagger UI 静态资源
+func (s *mcpServer) handleAPIDocsAssets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持GET方法"))
+		return
+	}
+	apiDocsAssets.ServeHTTP(w, r)
+}
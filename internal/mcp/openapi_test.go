@@ -0,0 +1,114 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"auto-claude-code/internal/config"
+)
+
+func TestOpenAPISpec(t *testing.T) {
+	server := &mcpServer{config: &config.MCPConfig{
+		Auth: config.MCPAuthConfig{Enabled: true},
+		Monitoring: config.MCPMonitoringConfig{
+			Enabled:       true,
+			HealthPath:    "/health",
+			LivenessPath:  "/healthz",
+			ReadinessPath: "/readyz",
+			MetricsPath:   "/metrics",
+		},
+	}}
+
+	w := httptest.NewRecorder()
+	server.handleOpenAPI(w, httptest.NewRequest(http.MethodGet, "http://example.com/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d, want 200", w.Code)
+	}
+
+	raw := w.Body.String()
+	var spec struct {
+		Servers    []struct{ URL string } `json:"servers"`
+		Paths      map[string]map[string]json.RawMessage
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+				Required   []string                   `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+		t.Fatalf("规范不是有效的JSON: %v", err)
+	}
+
+	if len(spec.Servers) != 1 || spec.Servers[0].URL != "http://example.com" {
+		t.Errorf("servers = %+v", spec.Servers)
+	}
+
+	for _, path := range []string{"/mcp", "/tasks", "/tasks/{id}", "/tasks/{id}/output", "/worktrees", "/worktrees/{id}", "/health", "/healthz", "/readyz", "/metrics"} {
+		if _, ok := spec.Paths[path]; !ok {
+			t.Errorf("缺少路径 %s", path)
+		}
+	}
+	if _, ok := spec.Paths["/auth/tokens"]; ok {
+		t.Error("未启用令牌存储时不应描述令牌端点")
+	}
+
+	// 所有引用都应指向已生成的组件
+	for _, part := range strings.Split(raw, `"$ref":"#/components/schemas/`)[1:] {
+		name := part[:strings.Index(part, `"`)]
+		if _, ok := spec.Components.Schemas[name]; !ok {
+			t.Errorf("引用的组件 %s 不存在", name)
+		}
+	}
+
+	task, ok := spec.Components.Schemas["TaskRequest"]
+	if !ok {
+		t.Fatal("缺少 TaskRequest 组件")
+	}
+	if _, ok := task.Properties["traceContext"]; ok {
+		t.Error("未导出字段不应出现在模式中")
+	}
+	if strings.Join(task.Required, ",") != "type,projectPath" {
+		t.Errorf("TaskRequest required = %v", task.Required)
+	}
+
+	status := spec.Components.Schemas["TaskStatus"]
+	if !strings.Contains(string(status.Properties["createdAt"]), `"date-time"`) {
+		t.Errorf("createdAt 模式 = %s", status.Properties["createdAt"])
+	}
+	if strings.Join(status.Required, ",") != "id,status,createdAt" {
+		t.Errorf("TaskStatus required = %v", status.Required)
+	}
+}
+
+func TestAPIDocsPage(t *testing.T) {
+	server := &mcpServer{config: &config.MCPConfig{}}
+
+	w := httptest.NewRecorder()
+	server.handleAPIDocs(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("Content-Type = %s", w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), `url: "/openapi.json"`) {
+		t.Error("文档页未加载 OpenAPI 规范")
+	}
+	if strings.Contains(w.Body.String(), "https://") {
+		t.Error("文档页从外部地址加载资源")
+	}
+
+	// 页面引用的静态资源由服务器提供
+	for _, asset := range []string{"swagger-ui.css", "swagger-ui-bundle.js"} {
+		if !strings.Contains(w.Body.String(), apiDocsAssetsPath+asset) {
+			t.Errorf("文档页未引用 %s", asset)
+		}
+		rec := httptest.NewRecorder()
+		server.handleAPIDocsAssets(rec, httptest.NewRequest(http.MethodGet, apiDocsAssetsPath+asset, nil))
+		if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+			t.Errorf("%s 状态码 = %d, 长度 = %d", asset, rec.Code, rec.Body.Len())
+		}
+	}
+}
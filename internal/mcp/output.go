@@ -0,0 +1,73 @@
+package mcp
+
+import (
+	"unicode/utf8"
+)
+
+const (
+	// defaultOutputPageSize 默认每页输出字节数
+	defaultOutputPageSize = 64 * 1024
+	// maxOutputPageSize 单页输出字节数上限
+	maxOutputPageSize = 1024 * 1024
+)
+
+// TaskOutputPage 任务输出分页结果
+type TaskOutputPage struct {
+	TaskID     string `json:"taskId"`
+	Stream     string `json:"stream,omitempty"` // 只读取单个流的日志文件时为 stdout 或 stderr
+	Offset     int    `json:"offset"`           // 本页起始字节偏移
+	NextOffset int    `json:"nextOffset"`       // 下一页起始字节偏移，任务仍在运行时可用于继续追踪
+	TotalSize  int    `json:"totalSize"`        // 当前已捕获的输出总字节数
+	Data       string `json:"data"`
+	EOF        bool   `json:"eof"` // 本页是否已到达当前输出末尾
+}
+
+// pageOutput 按字节偏移截取输出
+// offset 为负数时表示从末尾倒数，便于获取最新输出；limit 不大于 0 时使用默认页大小
+// 截取边界会调整到 UTF-8 字符边界，避免返回残缺字符；非空的页至少包含一个字符
+func pageOutput(taskID, output string, offset, limit int) *TaskOutputPage {
+	total := len(output)
+
+	if limit <= 0 {
+		limit = defaultOutputPageSize
+	}
+	if limit > maxOutputPageSize {
+		limit = maxOutputPageSize
+	}
+
+	if offset < 0 {
+		offset = total + offset
+		if offset < 0 {
+			offset = 0
+		}
+	}
+	if offset > total {
+		offset = total
+	}
+	for offset < total && !utf8.RuneStart(output[offset]) {
+		offset++
+	}
+
+	end := offset + limit
+	if end >= total {
+		end = total
+	} else {
+		for end > offset && !utf8.RuneStart(output[end]) {
+			end--
+		}
+		// limit 小于一个字符时至少返回一个完整字符，保证翻页能前进
+		if end == offset {
+			_, size := utf8.DecodeRuneInString(output[offset:])
+			end = offset + size
+		}
+	}
+
+	return &TaskOutputPage{
+		TaskID:     taskID,
+		Offset:     offset,
+		NextOffset: end,
+		TotalSize:  total,
+		Data:       output[offset:end],
+		EOF:        end == total,
+	}
+}
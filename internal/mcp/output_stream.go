@@ -0,0 +1,320 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+	"auto-claude-code/internal/websocket"
+)
+
+// outputStreamPrefix 任务输出流端点前缀，完整路径为 /api/v1/tasks/{id}/output/stream
+const outputStreamPrefix = "/api/v1/tasks/"
+
+// outputStreamSuffix 任务输出流端点后缀
+const outputStreamSuffix = "/output/stream"
+
+// outputStreamBuffer 每个连接待发送输出行的缓冲数量
+const outputStreamBuffer = 256
+
+// outputStreamPingInterval 输出流的 ping 间隔，避免代理关闭长时间无输出的连接
+const outputStreamPingInterval = 30 * time.Second
+
+// 输出流消息类型
+const (
+	outputMessageLine   = "output" // 一行输出
+	outputMessageStatus = "status" // 任务已结束，之后服务器关闭连接
+)
+
+// outputStreamMessage 输出流中的一条 JSON 文本消息
+type outputStreamMessage struct {
+	Type   string `json:"type"`
+	Stream string `json:"stream,omitempty"` // 连接前已捕获的输出不区分 stdout/stderr，该字段为空
+	Line   string `json:"line,omitempty"`
+	Offset int    `json:"offset,omitempty"` // 该行结束后的字节偏移，重连时作为 offset 参数可避免重复
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	task *TaskStatus // status 消息对应的任务状态，gRPC 输出流返回完整状态
+}
+
+// outputStreamConn 任务输出流的传输，WebSocket 或 SSE
+type outputStreamConn interface {
+	// send 发送一条消息
+	send(msg *outputStreamMessage) error
+	// ping 发送心跳
+	ping() error
+	// close 以指定原因结束连接，SSE 只能直接断开
+	close(code int, reason string)
+	// done 客户端断开时关闭
+	done() <-chan struct{}
+}
+
+// wsOutputConn WebSocket 输出流，每条消息为一个 JSON 文本帧
+type wsOutputConn struct {
+	conn   *websocket.Conn
+	closed chan struct{}
+}
+
+// newWSOutputConn 包装 WebSocket 连接，并读取客户端消息以应答 ping 和关闭帧，客户端不需要发送数据
+func newWSOutputConn(conn *websocket.Conn) *wsOutputConn {
+	c := &wsOutputConn{conn: conn, closed: make(chan struct{})}
+	go func() {
+		defer close(c.closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	return c
+}
+
+func (c *wsOutputConn) send(msg *outputStreamMessage) error { return c.conn.WriteJSON(msg) }
+func (c *wsOutputConn) ping() error                         { return c.conn.Ping() }
+func (c *wsOutputConn) close(code int, reason string)       { c.conn.Close(code, reason) }
+func (c *wsOutputConn) done() <-chan struct{}               { return c.closed }
+
+// sseOutputConn SSE 输出流，事件名为消息类型，输出行的事件ID为字节偏移，重连时通过 Last-Event-ID 续传
+type sseOutputConn struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	ctx     context.Context
+}
+
+func (c *sseOutputConn) send(msg *outputStreamMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if msg.Type == outputMessageLine {
+		_, err = fmt.Fprintf(c.w, "id: %d\nevent: %s\ndata: %s\n\n", msg.Offset, msg.Type, data)
+	} else {
+		_, err = fmt.Fprintf(c.w, "event: %s\ndata: %s\n\n", msg.Type, data)
+	}
+	c.flusher.Flush()
+	return err
+}
+
+func (c *sseOutputConn) ping() error {
+	_, err := fmt.Fprint(c.w, ": ping\n\n")
+	c.flusher.Flush()
+	return err
+}
+
+func (c *sseOutputConn) close(code int, reason string) {}
+func (c *sseOutputConn) done() <-chan struct{}         { return c.ctx.Done() }
+
+// acceptsEventStream 请求是否接受 SSE 响应
+func acceptsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// handleTaskOutputStream 先发送已捕获的输出，再逐行推送新输出，任务结束时发送 status 消息并正常关闭连接
+// WebSocket 升级请求使用 WebSocket，Accept: text/event-stream 的请求使用 SSE
+// 查询参数 offset 为起始字节偏移，负数表示从末尾倒数；SSE 重连时 Last-Event-ID 作为 offset
+func (s *mcpServer) handleTaskOutputStream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskID, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, outputStreamPrefix), outputStreamSuffix)
+	if !ok || taskID == "" || strings.Contains(taskID, "/") {
+		writeProblem(w, r, apperrors.New(apperrors.ErrResourceNotFound, "未知的任务端点"))
+		return
+	}
+
+	useSSE := !websocket.IsUpgrade(r) && acceptsEventStream(r)
+
+	var offset int
+	v := r.URL.Query().Get("offset")
+	if v == "" && useSSE {
+		v = r.Header.Get("Last-Event-ID")
+	}
+	if v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeProblem(w, r, apperrors.New(apperrors.ErrInvalidRequest, "无效的 offset 参数"))
+			return
+		}
+		offset = n
+	}
+
+	sub, err := s.subscribeOutput(ctx, taskID)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	defer sub.unregister()
+
+	var conn outputStreamConn
+	if useSSE {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeProblem(w, r, apperrors.New(apperrors.ErrInternal, "不支持流式响应"))
+			return
+		}
+		// 输出流是长连接，取消HTTP服务器的写超时
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			s.logger.Debug("取消输出流写超时失败", zap.Error(err))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+		conn = &sseOutputConn{w: w, flusher: flusher, ctx: ctx}
+	} else {
+		wsConn, err := websocket.Upgrade(w, r, s.config.HTTP.AllowedOrigins)
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+		defer wsConn.Close(websocket.CloseNormal, "")
+		conn = newWSOutputConn(wsConn)
+	}
+
+	log := logger.FromContext(ctx, s.logger).With(zap.String("taskId", taskID), zap.Bool("sse", useSSE))
+	log.Debug("任务输出流已连接")
+	s.streamOutput(sub, conn, offset, log)
+}
+
+// outputSubscription 任务的输出订阅，连接前已捕获的输出和之后的新输出按偏移去重
+type outputSubscription struct {
+	status     *TaskStatus
+	output     string
+	events     chan TaskEvent
+	overflow   chan struct{} // 缓冲已满时关闭
+	unregister func()
+}
+
+// subscribeOutput 订阅任务的新输出和结束事件，并读取任务状态和已捕获的输出，调用方结束时调用 unregister
+// 先注册监听器再读取已捕获的输出，保证不遗漏输出
+func (s *mcpServer) subscribeOutput(ctx context.Context, taskID string) (*outputSubscription, error) {
+	sub := &outputSubscription{
+		events:   make(chan TaskEvent, outputStreamBuffer),
+		overflow: make(chan struct{}),
+	}
+	var overflowOnce sync.Once
+	sub.unregister = s.taskManager.AddListener(func(event TaskEvent) {
+		if event.Task.ID != taskID {
+			return
+		}
+		if event.Type != TaskEventOutput && !(event.Type == TaskEventStatus && isTerminalStatus(event.Task.Status)) {
+			return
+		}
+		select {
+		case sub.events <- event:
+		default:
+			overflowOnce.Do(func() { close(sub.overflow) })
+		}
+	})
+
+	var err error
+	if sub.status, err = s.taskManager.GetTaskStatus(ctx, taskID); err == nil {
+		sub.output, err = s.taskManager.GetTaskOutput(ctx, taskID)
+	}
+	if err != nil {
+		sub.unregister()
+		return nil, err
+	}
+	return sub, nil
+}
+
+// streamOutput 先按行发送 offset 之后已捕获的输出，再逐行推送新输出，任务结束时发送 status 消息后返回
+func (s *mcpServer) streamOutput(sub *outputSubscription, conn outputStreamConn, offset int, log logger.Logger) {
+	if err := writeOutputBacklog(conn, sub.output, offset); err != nil {
+		return
+	}
+	sent := len(sub.output)
+
+	if isTerminalStatus(sub.status.Status) {
+		conn.send(statusMessage(sub.status))
+		return
+	}
+
+	ticker := time.NewTicker(outputStreamPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-conn.done():
+			return
+		case <-s.events.closed:
+			conn.close(websocket.CloseGoingAway, "服务器正在停止")
+			return
+		case <-sub.overflow:
+			// 客户端读取过慢，断开后可用最后收到的 offset 重连
+			log.Warn("任务输出流读取过慢，断开连接")
+			conn.close(websocket.ClosePolicyViolation, "读取过慢")
+			return
+		case <-ticker.C:
+			if err := conn.ping(); err != nil {
+				return
+			}
+		case event := <-sub.events:
+			if event.Type != TaskEventOutput {
+				conn.send(statusMessage(event.Task))
+				return
+			}
+			if event.Output == nil || event.Output.Offset <= sent {
+				continue
+			}
+			sent = event.Output.Offset
+			err := conn.send(&outputStreamMessage{
+				Type:   outputMessageLine,
+				Stream: event.Output.Stream,
+				Line:   event.Output.Line,
+				Offset: event.Output.Offset,
+			})
+			if err != nil {
+				log.Debug("写出任务输出失败", zap.Error(err))
+				return
+			}
+		}
+	}
+}
+
+// statusMessage 任务结束时发送的 status 消息
+func statusMessage(task *TaskStatus) *outputStreamMessage {
+	return &outputStreamMessage{Type: outputMessageStatus, Status: task.Status, Error: task.Error, task: task}
+}
+
+// writeOutputBacklog 按行发送连接前已捕获的输出，offset 为负数时从末尾倒数
+func writeOutputBacklog(conn outputStreamConn, output string, offset int) error {
+	start := offset
+	if start < 0 {
+		start += len(output)
+	}
+	if start < 0 {
+		start = 0
+	}
+	if start > len(output) {
+		start = len(output)
+	}
+
+	pos := start
+	for _, line := range strings.SplitAfter(output[start:], "\n") {
+		if line == "" {
+			continue
+		}
+		pos += len(line)
+		err := conn.send(&outputStreamMessage{
+			Type:   outputMessageLine,
+			Line:   strings.TrimSuffix(line, "\n"),
+			Offset: pos,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
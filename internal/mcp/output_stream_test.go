@@ -0,0 +1,177 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/websocket"
+)
+
+// fakeOutputTaskManager 保存单个任务的状态、输出和监听器
+type fakeOutputTaskManager struct {
+	TaskManager
+
+	mu        sync.Mutex
+	status    TaskStatus
+	output    string
+	listeners []TaskListener
+	added     chan struct{}
+}
+
+func (m *fakeOutputTaskManager) GetTaskStatus(ctx context.Context, taskID string) (*TaskStatus, error) {
+	if taskID != m.status.ID {
+		return nil, apperrors.Newf(apperrors.ErrTaskNotFound, "任务不存在: %s", taskID)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := m.status
+	return &status, nil
+}
+
+func (m *fakeOutputTaskManager) GetTaskOutput(ctx context.Context, taskID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.output, nil
+}
+
+func (m *fakeOutputTaskManager) AddListener(listener TaskListener) func() {
+	m.mu.Lock()
+	m.listeners = append(m.listeners, listener)
+	m.mu.Unlock()
+	close(m.added)
+	return func() {}
+}
+
+// emit 追加输出或改变状态并通知监听器
+func (m *fakeOutputTaskManager) emit(event TaskEvent) {
+	m.mu.Lock()
+	if event.Output != nil {
+		m.output += event.Output.Line + "\n"
+	}
+	listeners := m.listeners
+	m.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+func TestTaskOutputStream(t *testing.T) {
+	tm := &fakeOutputTaskManager{
+		status: TaskStatus{ID: "t1", Status: "running"},
+		output: "第一行\nsecond\n",
+		added:  make(chan struct{}),
+	}
+	server := &mcpServer{config: &config.MCPConfig{}, taskManager: tm, events: newTestBroker(t)}
+	server.logger = server.events.logger
+
+	ts := httptest.NewServer(http.HandlerFunc(server.handleTaskOutputStream))
+	defer ts.Close()
+
+	conn, err := websocket.Dial(context.Background(), ts.URL+"/api/v1/tasks/t1/output/stream?offset=-7", nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close(websocket.CloseNormal, "")
+
+	<-tm.added
+	tm.emit(TaskEvent{Type: TaskEventOutput, Task: &tm.status, Output: &OutputLine{Stream: "stderr", Line: "third", Offset: 23}})
+	tm.emit(TaskEvent{Type: TaskEventStatus, Task: &TaskStatus{ID: "t1", Status: "failed", Error: "退出码 1"}})
+
+	var got []outputStreamMessage
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		var msg outputStreamMessage
+		json.Unmarshal(data, &msg)
+		got = append(got, msg)
+	}
+
+	want := []outputStreamMessage{
+		{Type: outputMessageLine, Line: "second", Offset: 17},
+		{Type: outputMessageLine, Stream: "stderr", Line: "third", Offset: 23},
+		{Type: outputMessageStatus, Status: "failed", Error: "退出码 1"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("收到 %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("第 %d 条消息 = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTaskOutputStreamSSE(t *testing.T) {
+	tm := &fakeOutputTaskManager{
+		status: TaskStatus{ID: "t1", Status: "running"},
+		output: "first\nsecond\n",
+		added:  make(chan struct{}),
+	}
+	server := &mcpServer{config: &config.MCPConfig{}, taskManager: tm, events: newTestBroker(t)}
+	server.logger = server.events.logger
+
+	ts := httptest.NewServer(http.HandlerFunc(server.handleTaskOutputStream))
+	defer ts.Close()
+
+	// Last-Event-ID 为上次收到的偏移，只补发之后的输出
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/v1/tasks/t1/output/stream", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Last-Event-ID", "6")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Content-Type = %s", resp.Header.Get("Content-Type"))
+	}
+
+	<-tm.added
+	tm.emit(TaskEvent{Type: TaskEventOutput, Task: &tm.status, Output: &OutputLine{Stream: "stdout", Line: "third", Offset: 19}})
+	tm.emit(TaskEvent{Type: TaskEventStatus, Task: &TaskStatus{ID: "t1", Status: "completed"}})
+
+	body, _ := io.ReadAll(resp.Body)
+	want := "id: 13\nevent: output\ndata: {\"type\":\"output\",\"line\":\"second\",\"offset\":13}\n\n" +
+		"id: 19\nevent: output\ndata: {\"type\":\"output\",\"stream\":\"stdout\",\"line\":\"third\",\"offset\":19}\n\n" +
+		"event: status\ndata: {\"type\":\"status\",\"status\":\"completed\"}\n\n"
+	if string(body) != want {
+		t.Errorf("事件流 = %q", body)
+	}
+}
+
+func TestTaskOutputStreamRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"任务不存在", "/api/v1/tasks/t2/output/stream", http.StatusNotFound},
+		{"未知端点", "/api/v1/tasks/t1/output", http.StatusNotFound},
+		{"不是升级请求", "/api/v1/tasks/t1/output/stream", http.StatusBadRequest},
+		{"无效偏移", "/api/v1/tasks/t1/output/stream?offset=x", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := &fakeOutputTaskManager{status: TaskStatus{ID: "t1", Status: "running"}, added: make(chan struct{})}
+			server := &mcpServer{config: &config.MCPConfig{}, taskManager: tm}
+
+			w := httptest.NewRecorder()
+			server.handleTaskOutputStream(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.status || !strings.HasPrefix(w.Header().Get("Content-Type"), problemContentType) {
+				t.Errorf("状态码 = %d, Content-Type = %s", w.Code, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
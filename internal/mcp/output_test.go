@@ -0,0 +1,41 @@
+package mcp
+
+import (
+	"testing"
+)
+
+func TestPageOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		offset   int
+		limit    int
+		expected string
+		next     int
+		eof      bool
+	}{
+		{"第一页", "abcdefghij", 0, 4, "abcd", 4, false},
+		{"中间页", "abcdefghij", 4, 4, "efgh", 8, false},
+		{"最后一页", "abcdefghij", 8, 4, "ij", 10, true},
+		{"偏移超出范围", "abcdefghij", 20, 4, "", 10, true},
+		{"从末尾倒数", "abcdefghij", -3, 0, "hij", 10, true},
+		{"倒数超出范围", "abc", -10, 2, "ab", 2, false},
+		{"不截断多字节字符", "任务输出", 0, 4, "任", 3, false},
+		{"起点对齐字符边界", "任务输出", 1, 6, "务输", 9, false},
+		{"页大小小于一个字符", "任务输出", 0, 1, "任", 3, false},
+		{"页大小小于一个字符时继续前进", "任务输出", 3, 2, "务", 6, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := pageOutput("task_1", tt.output, tt.offset, tt.limit)
+			if page.Data != tt.expected || page.NextOffset != tt.next || page.EOF != tt.eof {
+				t.Errorf("pageOutput() = %q next=%d eof=%v, 期望 %q next=%d eof=%v",
+					page.Data, page.NextOffset, page.EOF, tt.expected, tt.next, tt.eof)
+			}
+			if page.TotalSize != len(tt.output) {
+				t.Errorf("TotalSize = %d, 期望 %d", page.TotalSize, len(tt.output))
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// 分页大小
+const (
+	defaultToolsPageSize = 50  // tools/list 每页工具数
+	defaultTasksPageSize = 50  // list_tasks 默认每页任务数
+	maxTasksPageSize     = 500 // list_tasks 每页任务数上限
+)
+
+// PaginatedRequest 支持分页的请求参数（MCP 分页约定）
+type PaginatedRequest struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// pageCursor 分页游标内容，对客户端不透明
+// 工具列表按偏移分页；任务列表按上一页最后一个任务的 (创建时间, ID) 分页，翻页期间新增或清理任务不会导致重复或遗漏
+type pageCursor struct {
+	Offset    int    `json:"o,omitempty"`
+	CreatedAt int64  `json:"t,omitempty"`
+	TaskID    string `json:"i,omitempty"`
+}
+
+// encodeCursor 编码分页游标
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor 解码分页游标，空字符串表示第一页
+func decodeCursor(cursor string) (pageCursor, error) {
+	var c pageCursor
+	if cursor == "" {
+		return c, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || json.Unmarshal(data, &c) != nil || c.Offset < 0 {
+		return c, apperrors.Newf(apperrors.ErrMCPProtocolError, "无效的分页游标: %s", cursor)
+	}
+	return c, nil
+}
+
+// paginateTools 按偏移游标分页工具列表
+func paginateTools(tools []Tool, cursor string) ([]Tool, string, error) {
+	c, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if c.Offset > len(tools) {
+		c.Offset = len(tools)
+	}
+
+	end := c.Offset + defaultToolsPageSize
+	if end >= len(tools) {
+		return tools[c.Offset:], "", nil
+	}
+	return tools[c.Offset:end], encodeCursor(pageCursor{Offset: end}), nil
+}
+
+// paginateTasks 按创建时间从新到旧分页任务列表，limit 超出范围时使用默认值或上限
+func paginateTasks(tasks []*TaskStatus, cursor string, limit int) ([]*TaskStatus, string, error) {
+	c, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if limit <= 0 {
+		limit = defaultTasksPageSize
+	}
+	if limit > maxTasksPageSize {
+		limit = maxTasksPageSize
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return taskBefore(tasks[i], tasks[j].CreatedAt.UnixNano(), tasks[j].ID)
+	})
+
+	start := 0
+	if cursor != "" {
+		start = sort.Search(len(tasks), func(i int) bool {
+			return !taskBefore(tasks[i], c.CreatedAt, c.TaskID) && !taskAt(tasks[i], c.CreatedAt, c.TaskID)
+		})
+	}
+
+	end := start + limit
+	if end >= len(tasks) {
+		return tasks[start:], "", nil
+	}
+	last := tasks[end-1]
+	return tasks[start:end], encodeCursor(pageCursor{CreatedAt: last.CreatedAt.UnixNano(), TaskID: last.ID}), nil
+}
+
+// taskBefore 任务在列表中是否排在 (createdAt, id) 之前（较新的任务在前）
+func taskBefore(task *TaskStatus, createdAt int64, id string) bool {
+	t := task.CreatedAt.UnixNano()
+	if t != createdAt {
+		return t > createdAt
+	}
+	return task.ID > id
+}
+
+// taskAt 任务是否就是 (createdAt, id)
+func taskAt(task *TaskStatus, createdAt int64, id string) bool {
+	return task.CreatedAt.UnixNano() == createdAt && task.ID == id
+}
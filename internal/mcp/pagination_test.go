@@ -0,0 +1,97 @@
+package mcp
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPaginateTasks(t *testing.T) {
+	base := time.Now()
+	newTasks := func(n int) []*TaskStatus {
+		tasks := make([]*TaskStatus, 0, n)
+		for i := 0; i < n; i++ {
+			tasks = append(tasks, &TaskStatus{
+				ID:        fmt.Sprintf("task_%03d", i),
+				CreatedAt: base.Add(time.Duration(i) * time.Second),
+			})
+		}
+		return tasks
+	}
+
+	tests := []struct {
+		name  string
+		total int
+		limit int
+		pages []int
+	}{
+		{"空列表", 0, 10, []int{0}},
+		{"单页", 5, 10, []int{5}},
+		{"恰好整页", 10, 5, []int{5, 5}},
+		{"多页", 12, 5, []int{5, 5, 2}},
+		{"默认页大小", 120, 0, []int{50, 50, 20}},
+		{"超过上限", 600, 1000, []int{500, 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks := newTasks(tt.total)
+			seen := make(map[string]bool)
+			cursor := ""
+			var lastCreated time.Time
+
+			for i, want := range tt.pages {
+				page, next, err := paginateTasks(tasks, cursor, tt.limit)
+				if err != nil {
+					t.Fatalf("第 %d 页分页失败: %v", i+1, err)
+				}
+				if len(page) != want {
+					t.Fatalf("第 %d 页任务数不匹配: 期望 %d, 得到 %d", i+1, want, len(page))
+				}
+				for _, task := range page {
+					if seen[task.ID] {
+						t.Errorf("任务重复出现: %s", task.ID)
+					}
+					seen[task.ID] = true
+					if !lastCreated.IsZero() && task.CreatedAt.After(lastCreated) {
+						t.Errorf("任务未按创建时间从新到旧排序: %s", task.ID)
+					}
+					lastCreated = task.CreatedAt
+				}
+				if last := i == len(tt.pages)-1; last != (next == "") {
+					t.Fatalf("第 %d 页 nextCursor 不符合预期: %q", i+1, next)
+				}
+				cursor = next
+			}
+		})
+	}
+}
+
+func TestPaginateTasks_NewTasksDuringPaging(t *testing.T) {
+	base := time.Now()
+	tasks := []*TaskStatus{
+		{ID: "a", CreatedAt: base},
+		{ID: "b", CreatedAt: base.Add(time.Second)},
+		{ID: "c", CreatedAt: base.Add(2 * time.Second)},
+	}
+
+	page, next, err := paginateTasks(tasks, "", 2)
+	if err != nil || len(page) != 2 || page[0].ID != "c" || page[1].ID != "b" {
+		t.Fatalf("第一页不符合预期: %v, %v", page, err)
+	}
+
+	// 翻页期间提交的新任务不应影响后续页
+	tasks = append(tasks, &TaskStatus{ID: "d", CreatedAt: base.Add(3 * time.Second)})
+	page, next, err = paginateTasks(tasks, next, 2)
+	if err != nil || len(page) != 1 || page[0].ID != "a" || next != "" {
+		t.Fatalf("第二页不符合预期: %v, %q, %v", page, next, err)
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	for _, cursor := range []string{"!!!", "bm90LWpzb24"} {
+		if _, err := decodeCursor(cursor); err == nil {
+			t.Errorf("decodeCursor(%q) 应返回错误", cursor)
+		}
+	}
+}
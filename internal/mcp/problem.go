@@ -0,0 +1,100 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// problemContentType RFC 7807 错误响应的内容类型
+const problemContentType = "application/problem+json"
+
+// Problem RFC 7807 风格的错误响应
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"` // 出错的请求路径
+	Code      apperrors.ErrorCode `json:"code"`
+	RequestID string              `json:"requestId,omitempty"`
+	Timestamp string              `json:"timestamp"`
+
+	// Extensions 附加字段，与标准字段平铺在同一层级，同名时以标准字段为准
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON 将附加字段平铺输出
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	data, err := json.Marshal((*problem)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+
+	fields := make(map[string]interface{}, len(p.Extensions)+8)
+	for key, value := range p.Extensions {
+		fields[key] = value
+	}
+	var standard map[string]interface{}
+	if err := json.Unmarshal(data, &standard); err != nil {
+		return nil, err
+	}
+	for key, value := range standard {
+		fields[key] = value
+	}
+	return json.Marshal(fields)
+}
+
+// newProblem 根据错误创建错误响应，状态码由错误代码决定，非应用程序错误视为内部错误
+func newProblem(r *http.Request, err error) *Problem {
+	code := apperrors.GetCode(err)
+	detail := err.Error()
+
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		// 错误代码已单独返回，详情中不再重复
+		detail = appErr.Message
+		if appErr.Details != "" {
+			detail += ": " + appErr.Details
+		}
+	}
+	if code == "" {
+		code = apperrors.ErrInternal
+	}
+
+	status := code.HTTPStatus()
+	problem := &Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: logger.RequestIDFromContext(r.Context()),
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	// 请求体字段校验错误逐个列出，客户端可以定位到具体字段
+	var validationErr *RequestValidationError
+	if errors.As(err, &validationErr) {
+		problem.Extensions = map[string]interface{}{"errors": validationErr.Fields}
+	}
+	return problem
+}
+
+// writeProblem 写入错误响应
+func writeProblem(w http.ResponseWriter, r *http.Request, err error) {
+	writeProblemResponse(w, newProblem(r, err))
+}
+
+// writeProblemResponse 写入已构造的错误响应
+func writeProblemResponse(w http.ResponseWriter, problem *Problem) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
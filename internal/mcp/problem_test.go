@@ -0,0 +1,102 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+func TestWriteProblem(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   apperrors.ErrorCode
+		detail string
+	}{
+		{
+			name:   "任务不存在",
+			err:    apperrors.Newf(apperrors.ErrTaskNotFound, "任务不存在: %s", "t1"),
+			status: http.StatusNotFound,
+			code:   apperrors.ErrTaskNotFound,
+			detail: "任务不存在: t1",
+		},
+		{
+			name:   "包含详细信息",
+			err:    apperrors.New(apperrors.ErrInvalidRequest, "无效的请求格式").WithDetails("缺少 type"),
+			status: http.StatusBadRequest,
+			code:   apperrors.ErrInvalidRequest,
+			detail: "无效的请求格式: 缺少 type",
+		},
+		{
+			name:   "队列已满",
+			err:    apperrors.New(apperrors.ErrQueueFull, "任务队列已满"),
+			status: http.StatusServiceUnavailable,
+			code:   apperrors.ErrQueueFull,
+			detail: "任务队列已满",
+		},
+		{
+			name:   "非应用程序错误",
+			err:    errors.New("磁盘已满"),
+			status: http.StatusInternalServerError,
+			code:   apperrors.ErrInternal,
+			detail: "磁盘已满",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/tasks/t1", nil)
+			r = r.WithContext(logger.WithRequestID(r.Context(), "req-1"))
+			w := httptest.NewRecorder()
+
+			writeProblem(w, r, tt.err)
+
+			if w.Code != tt.status {
+				t.Errorf("状态码 = %d, want %d", w.Code, tt.status)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != problemContentType {
+				t.Errorf("Content-Type = %s", ct)
+			}
+
+			var problem Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("解析错误响应失败: %v", err)
+			}
+			if problem.Status != tt.status || problem.Code != tt.code || problem.Detail != tt.detail {
+				t.Errorf("problem = %+v", problem)
+			}
+			if problem.Title != http.StatusText(tt.status) || problem.Instance != "/tasks/t1" || problem.RequestID != "req-1" {
+				t.Errorf("problem = %+v", problem)
+			}
+		})
+	}
+}
+
+func TestProblemExtensions(t *testing.T) {
+	problem := &Problem{
+		Type:       "about:blank",
+		Status:     http.StatusUnauthorized,
+		Code:       apperrors.ErrUnauthorized,
+		Extensions: map[string]interface{}{"hint": "重新登录", "status": 200},
+	}
+
+	data, err := json.Marshal(problem)
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+
+	var fields map[string]interface{}
+	json.Unmarshal(data, &fields)
+	if fields["hint"] != "重新登录" {
+		t.Errorf("附加字段未平铺输出: %s", data)
+	}
+	if fields["status"] != float64(http.StatusUnauthorized) {
+		t.Errorf("附加字段不应覆盖标准字段: %s", data)
+	}
+}
@@ -0,0 +1,210 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Claude Code 执行期间的进度区间，之前的进度用于路径转换和创建工作树
+const (
+	progressRunStart = 0.6
+	progressRunEnd   = 0.9
+)
+
+// 任务执行阶段
+const (
+	TaskPhaseThinking  = "thinking"
+	TaskPhaseTool      = "tool"
+	TaskPhaseFinishing = "finishing"
+)
+
+// defaultExpectedTurns 未配置 mcp.task_progress.expected_turns 时预计的轮次
+const defaultExpectedTurns = 10
+
+// streamJSONArgs 以 stream-json 格式运行 Claude Code 的参数
+var streamJSONArgs = []string{"--print", "--output-format", "stream-json", "--verbose"}
+
+// TaskUsage Claude Code 执行的轮次、工具调用和 token 用量
+type TaskUsage struct {
+	Turns        int     `json:"turns"`
+	ToolCalls    int     `json:"toolCalls"`
+	LastTool     string  `json:"lastTool,omitempty"`
+	InputTokens  int64   `json:"inputTokens"`
+	OutputTokens int64   `json:"outputTokens"`
+	CostUSD      float64 `json:"costUsd,omitempty"`
+}
+
+// streamMessage Claude Code stream-json 输出中的一行
+type streamMessage struct {
+	Type    string `json:"type"`
+	Message *struct {
+		ID      string `json:"id"`
+		Content []struct {
+			Type string `json:"type"`
+			Name string `json:"name"`
+		} `json:"content"`
+		Usage *streamUsage `json:"usage"`
+	} `json:"message"`
+	NumTurns     int          `json:"num_turns"`
+	TotalCostUSD float64      `json:"total_cost_usd"`
+	Usage        *streamUsage `json:"usage"`
+}
+
+// streamUsage stream-json 输出中的 token 用量，输入 token 包含缓存读写
+type streamUsage struct {
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+}
+
+func (u *streamUsage) input() int64 {
+	return u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+}
+
+// progressEstimator 根据 Claude Code 的 stream-json 输出估算任务进度和阶段，非 JSON 行被忽略
+// 指定 --max-turns 时进度按已执行轮次线性增长，否则在 expectedTurns 轮时过半并逐渐接近上限
+type progressEstimator struct {
+	maxTurns      int
+	expectedTurns int
+	usage         TaskUsage
+	phase         string
+	messageID     string
+}
+
+// newProgressEstimator 创建进度估算器，args 为传给 Claude Code 的参数
+func newProgressEstimator(args []string, expectedTurns int) *progressEstimator {
+	if expectedTurns <= 0 {
+		expectedTurns = defaultExpectedTurns
+	}
+	return &progressEstimator{maxTurns: maxTurnsArg(args), expectedTurns: expectedTurns}
+}
+
+// observe 解析一行输出，轮次、工具调用或阶段变化时返回 true
+func (e *progressEstimator) observe(line string) bool {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return false
+	}
+	var msg streamMessage
+	if err := json.Unmarshal([]byte(line), &msg); err != nil {
+		return false
+	}
+
+	turns, toolCalls, phase := e.usage.Turns, e.usage.ToolCalls, e.phase
+	switch msg.Type {
+	case "system", "user":
+		// 会话初始化或工具结果返回，等待模型继续
+		e.phase = TaskPhaseThinking
+	case "assistant":
+		if msg.Message == nil {
+			return false
+		}
+		// 同一条消息的多个内容块分多行输出，只计一轮
+		if msg.Message.ID == "" || msg.Message.ID != e.messageID {
+			e.messageID = msg.Message.ID
+			e.usage.Turns++
+			if usage := msg.Message.Usage; usage != nil {
+				e.usage.InputTokens += usage.input()
+				e.usage.OutputTokens += usage.OutputTokens
+			}
+		}
+		e.phase = TaskPhaseThinking
+		for _, content := range msg.Message.Content {
+			if content.Type == "tool_use" {
+				e.usage.ToolCalls++
+				e.usage.LastTool = content.Name
+				e.phase = TaskPhaseTool
+			}
+		}
+	case "result":
+		// 结果行给出整个会话的准确统计
+		if msg.NumTurns > 0 {
+			e.usage.Turns = msg.NumTurns
+		}
+		if msg.Usage != nil {
+			e.usage.InputTokens = msg.Usage.input()
+			e.usage.OutputTokens = msg.Usage.OutputTokens
+		}
+		e.usage.CostUSD = msg.TotalCostUSD
+		e.phase = TaskPhaseFinishing
+	default:
+		return false
+	}
+	return e.usage.Turns != turns || e.usage.ToolCalls != toolCalls || e.phase != phase
+}
+
+// progress 当前估算的任务进度
+func (e *progressEstimator) progress() float64 {
+	if e.phase == TaskPhaseFinishing {
+		return progressRunEnd
+	}
+	turns := float64(e.usage.Turns)
+	var fraction float64
+	if e.maxTurns > 0 {
+		if e.usage.Turns >= e.maxTurns {
+			return progressRunEnd
+		}
+		fraction = turns / float64(e.maxTurns)
+	} else {
+		fraction = turns / (turns + float64(e.expectedTurns))
+	}
+	return progressRunStart + (progressRunEnd-progressRunStart)*fraction
+}
+
+// message 当前阶段的任务状态说明
+func (e *progressEstimator) message() string {
+	stats := fmt.Sprintf("工具调用 %d 次，%d tokens", e.usage.ToolCalls, e.usage.InputTokens+e.usage.OutputTokens)
+	switch e.phase {
+	case TaskPhaseTool:
+		return fmt.Sprintf("第 %d 轮：调用 %s（%s）", e.usage.Turns, e.usage.LastTool, stats)
+	case TaskPhaseFinishing:
+		return fmt.Sprintf("Claude Code 已返回结果（%d 轮，%s）", e.usage.Turns, stats)
+	default:
+		return fmt.Sprintf("第 %d 轮：思考中（%s）", e.usage.Turns+1, stats)
+	}
+}
+
+// maxTurnsArg 获取参数中 --max-turns 的值，未指定或无效时返回 0
+func maxTurnsArg(args []string) int {
+	for i, arg := range args {
+		value, ok := strings.CutPrefix(arg, "--max-turns=")
+		if !ok {
+			if arg != "--max-turns" || i+1 >= len(args) {
+				continue
+			}
+			value = args[i+1]
+		}
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// withStreamJSON 在 Claude Code 参数前添加 stream-json 输出参数，已指定 --output-format 时原样返回
+func withStreamJSON(args []string) []string {
+	for _, arg := range args {
+		if arg == "--output-format" || strings.HasPrefix(arg, "--output-format=") {
+			return args
+		}
+	}
+	return append(append([]string{}, streamJSONArgs...), args...)
+}
+
+// updateEstimate 按估算器的结果更新任务进度、阶段和用量并发送进度事件
+func (tm *taskManager) updateEstimate(status *TaskStatus, e *progressEstimator) {
+	usage := e.usage
+
+	tm.tasksMutex.Lock()
+	status.Progress = e.progress()
+	status.Phase = e.phase
+	status.Message = e.message()
+	status.Usage = &usage
+	snapshot := *status
+	tm.tasksMutex.Unlock()
+
+	tm.emitSnapshot(TaskEventProgress, &snapshot)
+}
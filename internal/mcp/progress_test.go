@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProgressEstimator(t *testing.T) {
+	e := newProgressEstimator([]string{"fix the tests"}, 0)
+	lines := []struct {
+		line    string
+		changed bool
+		phase   string
+	}{
+		{"plain text output", false, ""},
+		{`{"type":"system","subtype":"init","session_id":"s1"}`, true, TaskPhaseThinking},
+		{`{"type":"assistant","message":{"id":"msg_1","content":[{"type":"text","text":"看看测试"}],"usage":{"input_tokens":100,"cache_read_input_tokens":50,"output_tokens":20}}}`, true, TaskPhaseThinking},
+		// 同一条消息的工具调用块不重复计轮次和用量
+		{`{"type":"assistant","message":{"id":"msg_1","content":[{"type":"tool_use","name":"Bash"}],"usage":{"input_tokens":100,"cache_read_input_tokens":50,"output_tokens":20}}}`, true, TaskPhaseTool},
+		{`{"type":"user","message":{"content":[{"type":"tool_result"}]}}`, true, TaskPhaseThinking},
+		{`{"type":"assistant","message":{"id":"msg_2","content":[{"type":"tool_use","name":"Edit"}],"usage":{"input_tokens":300,"output_tokens":40}}}`, true, TaskPhaseTool},
+		{`{"type":"stream_event"}`, false, TaskPhaseTool},
+	}
+
+	last := progressRunStart
+	for _, tt := range lines {
+		if changed := e.observe(tt.line); changed != tt.changed || e.phase != tt.phase {
+			t.Errorf("observe(%s) = %v, phase = %s, want %v, %s", tt.line, changed, e.phase, tt.changed, tt.phase)
+		}
+		if p := e.progress(); p < last || p >= progressRunEnd {
+			t.Errorf("observe(%s) 后进度 = %v, 上一次 = %v", tt.line, p, last)
+		} else {
+			last = p
+		}
+	}
+
+	want := TaskUsage{Turns: 2, ToolCalls: 2, LastTool: "Edit", InputTokens: 450, OutputTokens: 60}
+	if e.usage != want {
+		t.Errorf("usage = %+v, want %+v", e.usage, want)
+	}
+	if got := e.message(); got != "第 2 轮：调用 Edit（工具调用 2 次，510 tokens）" {
+		t.Errorf("message() = %s", got)
+	}
+
+	// 结果行给出会话总计
+	e.observe(`{"type":"result","subtype":"success","num_turns":3,"total_cost_usd":0.02,"usage":{"input_tokens":900,"output_tokens":80}}`)
+	if e.usage.Turns != 3 || e.usage.InputTokens != 900 || e.usage.CostUSD != 0.02 || e.progress() != progressRunEnd {
+		t.Errorf("result 后 usage = %+v, 进度 = %v", e.usage, e.progress())
+	}
+}
+
+func TestProgressEstimatorMaxTurns(t *testing.T) {
+	tests := []struct {
+		args []string
+		max  int
+	}{
+		{[]string{"fix", "--max-turns", "4"}, 4},
+		{[]string{"fix", "--max-turns=8"}, 8},
+		{[]string{"fix", "--max-turns", "many"}, 0},
+		{[]string{"fix", "--max-turns"}, 0},
+		{[]string{"fix"}, 0},
+	}
+	for _, tt := range tests {
+		if got := maxTurnsArg(tt.args); got != tt.max {
+			t.Errorf("maxTurnsArg(%v) = %d, want %d", tt.args, got, tt.max)
+		}
+	}
+
+	// 指定 --max-turns 时进度随轮次线性增长，超过上限后不再增加
+	e := newProgressEstimator([]string{"fix", "--max-turns", "2"}, 10)
+	e.usage.Turns = 1
+	if p := e.progress(); p != progressRunStart+(progressRunEnd-progressRunStart)/2 {
+		t.Errorf("1/2 轮进度 = %v", p)
+	}
+	e.usage.Turns = 5
+	if p := e.progress(); p != progressRunEnd {
+		t.Errorf("超过上限的进度 = %v", p)
+	}
+}
+
+func TestWithStreamJSON(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"fix"}, []string{"--print", "--output-format", "stream-json", "--verbose", "fix"}},
+		{[]string{"fix", "--output-format", "json"}, []string{"fix", "--output-format", "json"}},
+		{[]string{"fix", "--output-format=text"}, []string{"fix", "--output-format=text"}},
+	}
+	for _, tt := range tests {
+		if got := withStreamJSON(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("withStreamJSON(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package mcp
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// projectKey 项目并发计数使用的键，Windows 路径不区分大小写和分隔符
+func projectKey(projectPath string) string {
+	key := strings.ToLower(strings.ReplaceAll(projectPath, "\\", "/"))
+	if trimmed := strings.TrimRight(key, "/"); trimmed != "" {
+		key = trimmed
+	}
+	return key
+}
+
+// acquireProjectSlot 为任务占用项目的执行名额，项目已达到 mcp.queue.max_per_project 时任务转入项目等待列表并返回 false
+func (tm *taskManager) acquireProjectSlot(req *TaskRequest) bool {
+	limit := tm.config.Queue.MaxPerProject
+	if limit <= 0 {
+		return true
+	}
+	key := projectKey(req.ProjectPath)
+
+	tm.tasksMutex.Lock()
+	defer tm.tasksMutex.Unlock()
+
+	if tm.projectRunning[key] < limit {
+		tm.projectRunning[key]++
+		return true
+	}
+
+	tm.projectHeld[key] = append(tm.projectHeld[key], req)
+	if status, exists := tm.tasks[req.ID]; exists {
+		status.Message = "等待同一项目的其他任务完成"
+	}
+	tm.logger.Debug("项目并发已达上限，任务等待执行",
+		zap.String("taskId", req.ID),
+		zap.String("projectPath", req.ProjectPath),
+		zap.Int("limit", limit))
+	return false
+}
+
+// releaseProjectSlot 任务执行结束后释放项目名额，返回已占用该名额的下一个等待任务，没有时返回 nil
+// 等待期间已取消的任务直接丢弃
+func (tm *taskManager) releaseProjectSlot(req *TaskRequest) *TaskRequest {
+	if tm.config.Queue.MaxPerProject <= 0 {
+		return nil
+	}
+	key := projectKey(req.ProjectPath)
+
+	tm.tasksMutex.Lock()
+	defer tm.tasksMutex.Unlock()
+
+	tm.projectRunning[key]--
+	held := tm.projectHeld[key]
+	for len(held) > 0 {
+		next := held[0]
+		held = held[1:]
+		if status, exists := tm.tasks[next.ID]; exists && status.Status == "pending" {
+			tm.projectHeld[key] = held
+			tm.projectRunning[key]++
+			return next
+		}
+	}
+
+	delete(tm.projectHeld, key)
+	if tm.projectRunning[key] <= 0 {
+		delete(tm.projectRunning, key)
+	}
+	return nil
+}
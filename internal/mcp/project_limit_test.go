@@ -0,0 +1,115 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestProjectKey(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{`C:\Work\App`, "c:/work/app"},
+		{`c:/work/app/`, "c:/work/app"},
+		{"/home/user/app", "/home/user/app"},
+		{"/", "/"},
+	}
+
+	for _, tt := range tests {
+		if got := projectKey(tt.path); got != tt.want {
+			t.Errorf("projectKey(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestProjectSlots(t *testing.T) {
+	ctx := context.Background()
+	tm := newQueueTestManager()
+	tm.config.Queue.MaxPerProject = 1
+
+	reqs := map[string]*TaskRequest{}
+	for _, task := range []struct{ id, path string }{
+		{"a1", `C:\work\app`},
+		{"a2", `c:/work/app`},
+		{"a3", `C:\Work\App\`},
+		{"b1", `C:\work\lib`},
+	} {
+		reqs[task.id] = &TaskRequest{ID: task.id, ProjectPath: task.path}
+		if _, err := tm.SubmitTask(ctx, reqs[task.id]); err != nil {
+			t.Fatalf("SubmitTask(%s) error = %v", task.id, err)
+		}
+	}
+	drainQueue(tm)
+
+	if !tm.acquireProjectSlot(reqs["a1"]) || !tm.acquireProjectSlot(reqs["b1"]) {
+		t.Fatal("不同项目的任务应能同时执行")
+	}
+	if tm.acquireProjectSlot(reqs["a2"]) || tm.acquireProjectSlot(reqs["a3"]) {
+		t.Fatal("同一项目超过上限的任务应等待")
+	}
+	if status, _ := tm.GetTaskStatus(ctx, "a2"); status.Status != "pending" {
+		t.Errorf("等待中的任务状态 = %s", status.Status)
+	}
+
+	// 按出队顺序交接名额，等待期间取消的任务被跳过
+	if next := tm.releaseProjectSlot(reqs["a1"]); next == nil || next.ID != "a2" {
+		t.Fatalf("releaseProjectSlot() = %v, want a2", next)
+	}
+	tm.CancelTask(ctx, "a3")
+	if next := tm.releaseProjectSlot(reqs["a2"]); next != nil {
+		t.Errorf("releaseProjectSlot() = %s, want nil", next.ID)
+	}
+	tm.releaseProjectSlot(reqs["b1"])
+	if len(tm.projectRunning) != 0 || len(tm.projectHeld) != 0 {
+		t.Errorf("running = %v, held = %v", tm.projectRunning, tm.projectHeld)
+	}
+
+	// 未配置上限时不限制
+	tm.config.Queue.MaxPerProject = 0
+	if !tm.acquireProjectSlot(reqs["a1"]) || !tm.acquireProjectSlot(reqs["a1"]) || tm.releaseProjectSlot(reqs["a1"]) != nil {
+		t.Error("未配置上限时不应限制")
+	}
+}
+
+func TestProjectSlotHandoff(t *testing.T) {
+	ctx := context.Background()
+	bridge := &blockingBridge{started: make(chan struct{}), stopped: make(chan error, 1)}
+	tm := newQueueTestManager()
+	tm.config.Queue.MaxPerProject = 1
+	tm.wslBridge = bridge
+	tm.pathConverter = identityConverter{}
+	tm.worktreeManager = memoryWorktreeManager{}
+	tm.workerCount = 2
+	if err := tm.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer tm.Stop(ctx)
+
+	if _, err := tm.SubmitTask(ctx, &TaskRequest{ID: "first", ProjectPath: "/app", Command: "block"}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	<-bridge.started
+	if _, err := tm.SubmitTask(ctx, &TaskRequest{ID: "second", ProjectPath: "/app", Command: "fix"}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	// 另一个工作器取出任务后转入项目等待列表
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		tm.tasksMutex.RLock()
+		held := len(tm.projectHeld[projectKey("/app")])
+		tm.tasksMutex.RUnlock()
+		if held == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("任务未进入项目等待列表")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 接替名额的任务直接执行，不会再次进入项目等待列表
+	tm.CancelTask(ctx, "first")
+	waitForStatus(t, tm, "second", "completed")
+}
@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+	"unicode/utf8"
 
+	"auto-claude-code/internal/config"
 	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/tracing"
 )
 
 // MCPVersion MCP协议版本
@@ -37,7 +40,13 @@ type CancelTaskParams struct {
 type ListTasksParams struct {
 	Status string `json:"status,omitempty"`
 	Limit  int    `json:"limit,omitempty"`
-	Offset int    `json:"offset,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// ListTasksResult 列出任务的结果，nextCursor 为空表示没有更多任务
+type ListTasksResult struct {
+	Tasks      []*TaskStatus `json:"tasks"`
+	NextCursor string        `json:"nextCursor,omitempty"`
 }
 
 // TaskResult 任务执行结果
@@ -47,6 +56,21 @@ type TaskResult struct {
 	Error     string            `json:"error,omitempty"`
 	Artifacts []string          `json:"artifacts,omitempty"`
 	Metadata  map[string]string `json:"metadata,omitempty"`
+
+	// OmittedBytes output 和 error 超过 mcp.task_output.max_capture_bytes 后中间被省略的字节数
+	OmittedBytes int64 `json:"omittedBytes,omitempty"`
+
+	// MergeBack 任务分支合并回项目的结果，未启用合并时为空
+	MergeBack *MergeResult `json:"mergeBack,omitempty"`
+
+	// PullRequest 推送任务分支并创建拉取请求的结果，项目未配置 mcp.pull_requests 时为空
+	PullRequest *PullRequestResult `json:"pullRequest,omitempty"`
+}
+
+// OutputTruncation 任务输出超过 mcp.task_output.max_capture_bytes 时的截断情况，保留开头和结尾各一半
+type OutputTruncation struct {
+	TotalBytes   int64 `json:"totalBytes"`   // 任务产生的输出字节数
+	OmittedBytes int64 `json:"omittedBytes"` // 中间被省略的字节数
 }
 
 // SchemaProperty JSON Schema属性定义
@@ -55,6 +79,9 @@ type SchemaProperty struct {
 	Description string                    `json:"description,omitempty"`
 	Enum        []string                  `json:"enum,omitempty"`
 	Default     interface{}               `json:"default,omitempty"`
+	Minimum     *float64                  `json:"minimum,omitempty"`
+	Maximum     *float64                  `json:"maximum,omitempty"`
+	Format      string                    `json:"format,omitempty"`
 	Properties  map[string]SchemaProperty `json:"properties,omitempty"`
 	Items       *SchemaProperty           `json:"items,omitempty"`
 	Required    []string                  `json:"required,omitempty"`
@@ -172,6 +199,13 @@ type ToolSchema struct {
 type CallToolRequest struct {
 	Name      string                 `json:"name"`
 	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Meta      *RequestMeta           `json:"_meta,omitempty"`
+}
+
+// RequestMeta 请求元数据
+type RequestMeta struct {
+	// ProgressToken 客户端提供时，服务器通过 notifications/progress 推送该请求的进度
+	ProgressToken interface{} `json:"progressToken,omitempty"`
 }
 
 // CallToolResult 调用工具结果
@@ -196,20 +230,114 @@ type TaskRequest struct {
 	Context     map[string]interface{} `json:"context,omitempty"`
 	Priority    int                    `json:"priority,omitempty"`
 	Timeout     time.Duration          `json:"timeout,omitempty"`
+
+	// Limits 任务级资源限制，非零字段覆盖 mcp.task_limits
+	Limits *config.ResourceLimits `json:"limits,omitempty"`
+
+	// GPU 任务需要 GPU 加速，执行时导出 CUDA/WSLg 相关环境变量
+	GPU bool `json:"gpu,omitempty"`
+
+	// Distro 执行任务的 WSL 发行版，留空时从发行版池 (mcp.distros) 中选择，未配置发行版池时使用默认发行版
+	Distro string `json:"distro,omitempty"`
+
+	// DistroTags 执行任务的发行版需要具有的全部标签，如 gpu、node20，标签在 mcp.distros 中配置
+	DistroTags []string `json:"distroTags,omitempty"`
+
+	// Model、MaxTurns、PermissionMode、AllowedTools 转换为 Claude Code 的
+	// --model、--max-turns、--permission-mode、--allowedTools 参数，留空时使用 Claude Code 的默认值
+	Model          string   `json:"model,omitempty"`
+	MaxTurns       int      `json:"maxTurns,omitempty"`
+	PermissionMode string   `json:"permissionMode,omitempty"`
+	AllowedTools   []string `json:"allowedTools,omitempty"`
+
+	// RequestID 提交任务的请求ID，留空时取自请求上下文
+	RequestID string `json:"requestId,omitempty"`
+
+	// DependsOn 依赖的任务ID，全部成功完成后才会入队执行
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// MergeBack 任务成功后提交 worktree 中的修改并合并回项目，未设置时按 mcp.merge_back 决定
+	MergeBack *MergeOptions `json:"mergeBack,omitempty"`
+
+	// OnSuccess、OnFailure 任务成功或失败后自动提交的后续任务
+	OnSuccess *FollowUpTask `json:"onSuccess,omitempty"`
+	OnFailure *FollowUpTask `json:"onFailure,omitempty"`
+
+	// Template 提交时套用的任务模板，Params 为模板参数
+	Template string            `json:"template,omitempty"`
+	Params   map[string]string `json:"params,omitempty"`
+
+	// ValidateOnly 只执行检查并返回执行计划，不创建任务
+	ValidateOnly bool `json:"validateOnly,omitempty"`
+
+	// parentID 触发该后续任务的原任务ID
+	parentID string
+
+	// rerunOf 重新运行时被复制的原任务ID
+	rerunOf string
+
+	// client 提交任务的客户端，用于配额计数
+	client string
+
+	// owner 提交任务的认证身份，后续任务沿用原任务的所有者
+	owner string
+
+	// queuedAt 最近一次入队的时间，用于统计队列等待时间
+	queuedAt time.Time
+
+	// traceContext 提交任务时的追踪 span，任务出队执行时作为父级
+	traceContext tracing.SpanContext
+}
+
+// DistroInfo WSL 发行版信息
+type DistroInfo struct {
+	Name    string `json:"name"`
+	Default bool   `json:"default"`
+
+	// Pooled 发行版在发行版池中，Tags 为配置的标签，Running 为分派到该发行版的执行中任务数
+	Pooled  bool     `json:"pooled,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	Running int      `json:"running,omitempty"`
+}
+
+// ShellCommandRequest 在任务工作目录中运行 shell 命令的请求
+// 工作目录按 WorktreeID、TaskID（任务的 worktree）、ProjectPath 的顺序确定
+type ShellCommandRequest struct {
+	Command     string        `json:"command"`
+	TaskID      string        `json:"taskId,omitempty"`
+	WorktreeID  string        `json:"worktreeId,omitempty"`
+	ProjectPath string        `json:"projectPath,omitempty"`
+	Distro      string        `json:"distro,omitempty"`
+	Timeout     time.Duration `json:"timeout,omitempty"`
 }
 
 // TaskStatus 任务状态
 type TaskStatus struct {
-	ID         string                 `json:"id"`
-	Status     string                 `json:"status"` // "pending", "running", "completed", "failed", "cancelled"
-	Progress   float64                `json:"progress,omitempty"`
-	Message    string                 `json:"message,omitempty"`
-	Result     interface{}            `json:"result,omitempty"`
-	Error      string                 `json:"error,omitempty"`
-	StartTime  time.Time              `json:"startTime,omitempty"`
-	EndTime    time.Time              `json:"endTime,omitempty"`
-	WorktreeID string                 `json:"worktreeId,omitempty"`
-	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	ID          string                 `json:"id"`
+	Status      string                 `json:"status"` // "pending", "running", "completed", "failed", "cancelled", "interrupted", "timeout"
+	ProjectPath string                 `json:"projectPath,omitempty"`
+	Owner       string                 `json:"owner,omitempty"` // 提交任务的认证身份，未认证提交的任务为空
+	Progress    float64                `json:"progress,omitempty"`
+	Phase       string                 `json:"phase,omitempty"` // Claude Code 执行阶段: thinking, tool, finishing，任务结束后清空
+	Message     string                 `json:"message,omitempty"`
+	Result      interface{}            `json:"result,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	CreatedAt   time.Time              `json:"createdAt"`
+	StartTime   time.Time              `json:"startTime,omitempty"`
+	EndTime     time.Time              `json:"endTime,omitempty"`
+	Attempts    int                    `json:"attempts,omitempty"`    // 已开始执行的次数
+	MaxAttempts int                    `json:"maxAttempts,omitempty"` // 最多执行次数，含失败后的自动重试
+	NextRetryAt time.Time              `json:"nextRetryAt,omitempty"` // 等待重试时下次执行的时间
+	WorktreeID  string                 `json:"worktreeId,omitempty"`
+	RequestID   string                 `json:"requestId,omitempty"`
+	DependsOn   []string               `json:"dependsOn,omitempty"`
+	ParentID    string                 `json:"parentId,omitempty"`   // 触发该后续任务的原任务
+	FollowUpID  string                 `json:"followUpId,omitempty"` // 任务结束后提交的后续任务
+	Output      *TaskOutputFiles       `json:"output,omitempty"`     // 启用 mcp.task_output 时的输出日志文件
+	Truncation  *OutputTruncation      `json:"truncation,omitempty"` // 捕获的输出超过上限时的截断情况
+	Usage       *TaskUsage             `json:"usage,omitempty"`      // 从 stream-json 输出解析的轮次、工具调用和 token 用量
+	Notes       []TaskNote             `json:"notes,omitempty"`      // 按添加顺序排列的备注
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // MCPProtocolHandler MCP协议处理器接口
@@ -219,6 +347,16 @@ type MCPProtocolHandler interface {
 	ListTools(ctx context.Context) ([]Tool, error)
 	CallTool(ctx context.Context, req *CallToolRequest) (*CallToolResult, error)
 
+	// 资源方法
+	ListResources(ctx context.Context) ([]Resource, error)
+	ListResourceTemplates(ctx context.Context) ([]ResourceTemplate, error)
+	ReadResource(ctx context.Context, uri string) (*ReadResourceResult, error)
+	SubscribeResource(ctx context.Context, uri string) error
+	UnsubscribeResource(ctx context.Context, uri string) error
+
+	// 日志方法
+	SetLogLevel(ctx context.Context, level string) error
+
 	// 任务管理方法
 	SubmitTask(ctx context.Context, req *TaskRequest) (*TaskStatus, error)
 	GetTaskStatus(ctx context.Context, taskID string) (*TaskStatus, error)
@@ -235,11 +373,12 @@ type protocolHandler struct {
 	capabilities    MCPCapabilities
 	taskManager     TaskManager
 	worktreeManager WorktreeManager
+	notifier        *NotificationDispatcher
 }
 
-// NewMCPProtocolHandler 创建新的MCP协议处理器
-func NewMCPProtocolHandler(taskManager TaskManager, worktreeManager WorktreeManager) MCPProtocolHandler {
-	return &protocolHandler{
+// NewMCPProtocolHandler 创建新的MCP协议处理器，notifier 为空时不推送进度和日志通知
+func NewMCPProtocolHandler(taskManager TaskManager, worktreeManager WorktreeManager, notifier *NotificationDispatcher) MCPProtocolHandler {
+	handler := &protocolHandler{
 		serverInfo: ServerInfo{
 			Name:    "auto-claude-code-mcp",
 			Version: "1.0.0",
@@ -248,11 +387,19 @@ func NewMCPProtocolHandler(taskManager TaskManager, worktreeManager WorktreeMana
 			Tools: &ToolsCapability{
 				ListChanged: true,
 			},
-			Logging: &LoggingCapability{},
+			Resources: &ResourcesCapability{
+				Subscribe:   notifier != nil,
+				ListChanged: notifier != nil,
+			},
 		},
 		taskManager:     taskManager,
 		worktreeManager: worktreeManager,
+		notifier:        notifier,
 	}
+	if notifier != nil {
+		handler.capabilities.Logging = &LoggingCapability{}
+	}
+	return handler
 }
 
 // Initialize 初始化MCP连接
@@ -263,9 +410,21 @@ func (h *protocolHandler) Initialize(ctx context.Context, req *InitializeRequest
 			"不支持的协议版本: %s，期望: %s", req.ProtocolVersion, MCPVersion)
 	}
 
+	// 将执行环境的 GPU 支持作为实验性能力告知客户端
+	capabilities := h.capabilities
+	if gpu, err := h.taskManager.GetGPUInfo(ctx); err == nil {
+		capabilities.Experimental = map[string]interface{}{
+			"gpu": map[string]interface{}{
+				"available": gpu.Available(),
+				"wslg":      gpu.WSLg,
+				"name":      gpu.GPUName,
+			},
+		}
+	}
+
 	return &InitializeResult{
 		ProtocolVersion: MCPVersion,
-		Capabilities:    h.capabilities,
+		Capabilities:    capabilities,
 		ServerInfo:      h.serverInfo,
 	}, nil
 }
@@ -279,11 +438,42 @@ func (h *protocolHandler) ListTools(ctx context.Context) ([]Tool, error) {
 			InputSchema: ToolSchema{
 				Type: "object",
 				Properties: map[string]SchemaProperty{
-					"projectPath": stringProperty("项目路径（Windows路径）"),
-					"command":     stringProperty("要执行的命令", ""),
-					"args":        arrayProperty("命令参数", "string"),
-					"priority":    integerProperty("任务优先级 (1-3)", 2, 1, 3),
-					"timeout":     stringProperty("任务超时时间 (如: 30m, 1h)", "30m"),
+					"projectPath":    stringProperty("项目路径（Windows路径）"),
+					"command":        stringProperty("要执行的命令", ""),
+					"args":           arrayProperty("命令参数", "string"),
+					"priority":       integerProperty("任务优先级 (1-3)", 2, 1, 3),
+					"distro":         stringProperty("执行任务的 WSL 发行版，留空时从发行版池中选择或使用默认发行版（可通过 list_distros 查询）"),
+					"distroTags":     arrayProperty("执行任务的发行版需要具有的全部标签，如 gpu、node20（可通过 list_distros 查询）", "string"),
+					"timeout":        durationProperty("任务超时时间 (如: 30m, 1h)", "30m"),
+					"model":          stringProperty("Claude Code 使用的模型（--model），如 sonnet、opus 或完整模型名"),
+					"maxTurns":       integerProperty("最大对话轮数（--max-turns），0 表示不限制", 0, 0, maxTaskTurns),
+					"permissionMode": enumProperty("权限模式（--permission-mode）", permissionModes),
+					"allowedTools":   arrayProperty("允许使用的工具（--allowedTools），如 Read、Edit、Bash(git diff:*)", "string"),
+					"limits": {
+						Type:        "object",
+						Description: "任务进程资源限制，未指定的字段使用服务器配置",
+						Properties: map[string]SchemaProperty{
+							"nice":            integerProperty("CPU 调度优先级 (-20 ~ 19)", 0, -20, 19),
+							"maxMemoryMB":     integerProperty("最大内存 (MB)", 0, 0, 0),
+							"maxOpenFiles":    integerProperty("最大打开文件数", 0, 0, 0),
+							"killGracePeriod": durationProperty("取消或超时后 SIGTERM 到 SIGKILL 的等待时间 (如: 10s)"),
+						},
+					},
+					"mergeBack": {
+						Type:        "object",
+						Description: "任务成功后提交工作树中的修改并合并回项目的原分支，未指定时按服务器配置决定",
+						Properties: map[string]SchemaProperty{
+							"strategy": enumProperty("合并策略：merge 创建合并提交，rebase 变基后快进", []string{MergeStrategyMerge, MergeStrategyRebase}),
+							"dryRun":   booleanProperty("只提交任务分支并检测冲突，不修改项目分支"),
+							"message":  stringProperty("任务分支的提交信息，留空时由任务命令生成"),
+						},
+					},
+					"dependsOn":    arrayProperty("依赖的任务ID，全部成功完成后才开始执行；任一依赖失败或取消时任务直接失败", "string"),
+					"onSuccess":    followUpProperty("任务成功后自动提交的后续任务"),
+					"onFailure":    followUpProperty("任务失败后自动提交的后续任务，如使用捕获的错误输出提交诊断任务"),
+					"gpu":          booleanProperty("任务是否需要 GPU 加速（执行环境不支持时拒绝提交）"),
+					"wait":         booleanProperty("等待任务结束后再返回结果；等待期间客户端取消调用（notifications/cancelled）会同时取消任务"),
+					"validateOnly": booleanProperty("只检查路径、发行版、Claude Code 和工作树能否使用并返回执行计划，不创建任务"),
 				},
 				Required: []string{"projectPath"},
 			},
@@ -299,6 +489,44 @@ func (h *protocolHandler) ListTools(ctx context.Context) ([]Tool, error) {
 				Required: []string{"taskId"},
 			},
 		},
+		{
+			Name:        "get_task_output",
+			Description: "分页获取任务已捕获的输出，按字节偏移读取，可用于追踪大日志",
+			InputSchema: ToolSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"taskId": stringProperty("任务ID"),
+					"stream": enumProperty("只读取 stdout 或 stderr 日志文件，省略时返回合并的输出", []string{"stdout", "stderr"}),
+					"offset": integerProperty("起始字节偏移，负数表示从末尾倒数", 0, 0, 0),
+					"limit":  integerProperty("本页最大字节数 (最大 1MB)", defaultOutputPageSize, 1, maxOutputPageSize),
+				},
+				Required: []string{"taskId"},
+			},
+		},
+		{
+			Name:        "list_distros",
+			Description: "列出可用于执行任务的 WSL 发行版及默认发行版",
+			InputSchema: ToolSchema{
+				Type:       "object",
+				Properties: map[string]SchemaProperty{},
+			},
+		},
+		{
+			Name:        "run_shell_command",
+			Description: "在任务的 worktree 或项目目录中运行 shell 命令（如测试、构建），受 mcp.shell_tool 允许/禁止规则约束",
+			InputSchema: ToolSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"command":     stringProperty("要运行的 shell 命令"),
+					"taskId":      stringProperty("在该任务的 worktree 中运行"),
+					"worktreeId":  stringProperty("在指定 worktree 中运行"),
+					"projectPath": stringProperty("在项目目录中运行（Windows路径）"),
+					"distro":      stringProperty("运行命令的 WSL 发行版，留空时使用任务的发行版或默认发行版"),
+					"timeout":     durationProperty("命令超时时间 (如: 30s, 5m)，默认使用 mcp.shell_tool.timeout"),
+				},
+				Required: []string{"command"},
+			},
+		},
 		{
 			Name:        "cancel_task",
 			Description: "取消正在执行的任务",
@@ -312,26 +540,57 @@ func (h *protocolHandler) ListTools(ctx context.Context) ([]Tool, error) {
 		},
 		{
 			Name:        "list_tasks",
-			Description: "列出所有任务状态",
+			Description: "按创建时间从新到旧分页列出任务状态，结果中的 nextCursor 用于获取下一页",
 			InputSchema: ToolSchema{
 				Type: "object",
 				Properties: map[string]SchemaProperty{
-					"status": enumProperty("过滤任务状态", []string{"pending", "running", "completed", "failed", "cancelled"}),
+					"status": enumProperty("过滤任务状态", []string{"pending", "running", "completed", "failed", "cancelled", "interrupted", "timeout"}),
+					"limit":  integerProperty("每页任务数", defaultTasksPageSize, 1, maxTasksPageSize),
+					"cursor": stringProperty("上一页返回的 nextCursor"),
 				},
 			},
 		},
 	}
 
+	// 未启用 run_shell_command 时不列出，重新加载配置改变启用状态时通知客户端
+	if !h.taskManager.ShellToolEnabled() {
+		for i, tool := range tools {
+			if tool.Name == "run_shell_command" {
+				tools = append(tools[:i], tools[i+1:]...)
+				break
+			}
+		}
+	}
+
 	return tools, nil
 }
 
-// CallTool 调用工具
+// CallTool 调用工具，参数不符合工具声明的输入模式时返回 *ArgumentError
 func (h *protocolHandler) CallTool(ctx context.Context, req *CallToolRequest) (*CallToolResult, error) {
+	tools, err := h.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, tool := range tools {
+		if tool.Name == req.Name {
+			if fieldErrors := validateArguments(tool.InputSchema, req.Arguments); len(fieldErrors) > 0 {
+				return nil, &ArgumentError{Tool: req.Name, Fields: fieldErrors}
+			}
+			break
+		}
+	}
+
 	switch req.Name {
 	case "execute_claude_code":
-		return h.handleExecuteClaudeCode(ctx, req.Arguments)
+		return h.handleExecuteClaudeCode(ctx, req.Arguments, req.Meta)
 	case "get_task_status":
 		return h.handleGetTaskStatus(ctx, req.Arguments)
+	case "get_task_output":
+		return h.handleGetTaskOutput(ctx, req.Arguments)
+	case "list_distros":
+		return h.handleListDistros(ctx)
+	case "run_shell_command":
+		return h.handleRunShellCommand(ctx, req.Arguments)
 	case "cancel_task":
 		return h.handleCancelTask(ctx, req.Arguments)
 	case "list_tasks":
@@ -348,7 +607,7 @@ func (h *protocolHandler) CallTool(ctx context.Context, req *CallToolRequest) (*
 }
 
 // handleExecuteClaudeCode 处理执行Claude Code工具调用
-func (h *protocolHandler) handleExecuteClaudeCode(ctx context.Context, args map[string]interface{}) (*CallToolResult, error) {
+func (h *protocolHandler) handleExecuteClaudeCode(ctx context.Context, args map[string]interface{}, meta *RequestMeta) (*CallToolResult, error) {
 	// 解析参数
 	projectPath, ok := args["projectPath"].(string)
 	if !ok || projectPath == "" {
@@ -391,6 +650,87 @@ func (h *protocolHandler) handleExecuteClaudeCode(ctx context.Context, args map[
 		}
 	}
 
+	if gpu, ok := args["gpu"].(bool); ok {
+		taskReq.GPU = gpu
+	}
+
+	if distro, ok := args["distro"].(string); ok {
+		taskReq.Distro = distro
+	}
+	if tags, ok := args["distroTags"].([]interface{}); ok {
+		for _, tag := range tags {
+			if tagStr, ok := tag.(string); ok {
+				taskReq.DistroTags = append(taskReq.DistroTags, tagStr)
+			}
+		}
+	}
+
+	taskReq.Model, _ = args["model"].(string)
+	if maxTurns, ok := args["maxTurns"].(float64); ok {
+		taskReq.MaxTurns = int(maxTurns)
+	}
+	taskReq.PermissionMode, _ = args["permissionMode"].(string)
+	if tools, ok := args["allowedTools"].([]interface{}); ok {
+		for _, tool := range tools {
+			if toolStr, ok := tool.(string); ok {
+				taskReq.AllowedTools = append(taskReq.AllowedTools, toolStr)
+			}
+		}
+	}
+
+	taskReq.OnSuccess = parseFollowUpTask(args["onSuccess"])
+	taskReq.OnFailure = parseFollowUpTask(args["onFailure"])
+
+	if mergeArg, ok := args["mergeBack"].(map[string]interface{}); ok {
+		taskReq.MergeBack = &MergeOptions{}
+		taskReq.MergeBack.Strategy, _ = mergeArg["strategy"].(string)
+		taskReq.MergeBack.DryRun, _ = mergeArg["dryRun"].(bool)
+		taskReq.MergeBack.Message, _ = mergeArg["message"].(string)
+	}
+
+	if deps, ok := args["dependsOn"].([]interface{}); ok {
+		for _, dep := range deps {
+			if depID, ok := dep.(string); ok {
+				taskReq.DependsOn = append(taskReq.DependsOn, depID)
+			}
+		}
+	}
+
+	if limitsArg, ok := args["limits"].(map[string]interface{}); ok {
+		limits, err := parseResourceLimits(limitsArg)
+		if err != nil {
+			return &CallToolResult{
+				Content: []ToolContent{{
+					Type: "text",
+					Text: fmt.Sprintf("无效的资源限制: %v", err),
+				}},
+				IsError: true,
+			}, nil
+		}
+		taskReq.Limits = limits
+	}
+
+	if validateOnly, _ := args["validateOnly"].(bool); validateOnly {
+		plan, err := h.taskManager.PlanTask(ctx, taskReq)
+		if err != nil {
+			return &CallToolResult{
+				Content: []ToolContent{{
+					Type: "text",
+					Text: fmt.Sprintf("任务检查未通过: %v", err),
+				}},
+				IsError: true,
+			}, nil
+		}
+
+		planJSON, _ := json.MarshalIndent(plan, "", "  ")
+		return &CallToolResult{
+			Content: []ToolContent{{
+				Type: "text",
+				Text: fmt.Sprintf("任务检查通过，执行计划:\n%s", string(planJSON)),
+			}},
+		}, nil
+	}
+
 	// 提交任务
 	status, err := h.SubmitTask(ctx, taskReq)
 	if err != nil {
@@ -403,6 +743,33 @@ func (h *protocolHandler) handleExecuteClaudeCode(ctx context.Context, args map[
 		}, nil
 	}
 
+	// 客户端提供了进度令牌时，将任务进度推送给发起请求的会话
+	if h.notifier != nil && meta != nil {
+		h.notifier.TrackProgress(status.ID, sessionFromContext(ctx), meta.ProgressToken)
+	}
+
+	if wait, _ := args["wait"].(bool); wait {
+		final, err := h.waitForTask(ctx, status.ID)
+		if err != nil {
+			return &CallToolResult{
+				Content: []ToolContent{{
+					Type: "text",
+					Text: fmt.Sprintf("等待任务结束失败: %v", err),
+				}},
+				IsError: true,
+			}, nil
+		}
+
+		finalJSON, _ := json.MarshalIndent(final, "", "  ")
+		return &CallToolResult{
+			Content: []ToolContent{{
+				Type: "text",
+				Text: string(finalJSON),
+			}},
+			IsError: final.Status != "completed",
+		}, nil
+	}
+
 	// 返回任务状态
 	statusJSON, _ := json.MarshalIndent(status, "", "  ")
 	return &CallToolResult{
@@ -413,6 +780,84 @@ func (h *protocolHandler) handleExecuteClaudeCode(ctx context.Context, args map[
 	}, nil
 }
 
+// waitForTask 等待任务结束，ctx 被取消（如客户端发送 notifications/cancelled）时取消任务
+func (h *protocolHandler) waitForTask(ctx context.Context, taskID string) (*TaskStatus, error) {
+	done := make(chan *TaskStatus, 1)
+	removeListener := h.taskManager.AddListener(func(event TaskEvent) {
+		if event.Task.ID == taskID && isTerminalStatus(event.Task.Status) {
+			select {
+			case done <- event.Task:
+			default:
+			}
+		}
+	})
+	defer removeListener()
+
+	// 任务可能在注册监听器之前已经结束
+	status, err := h.taskManager.GetTaskStatus(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if isTerminalStatus(status.Status) {
+		return status, nil
+	}
+
+	select {
+	case final := <-done:
+		return final, nil
+	case <-ctx.Done():
+		if err := h.taskManager.CancelTask(context.Background(), taskID); err != nil && !apperrors.IsCode(err, apperrors.ErrTaskCancelled) {
+			return nil, err
+		}
+		return nil, apperrors.Wrapf(ctx.Err(), apperrors.ErrTaskCancelled, "调用已取消，任务 %s 已取消", taskID)
+	}
+}
+
+// parseResourceLimits 解析工具参数中的资源限制
+func parseResourceLimits(arg map[string]interface{}) (*config.ResourceLimits, error) {
+	data, err := json.Marshal(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	var limits config.ResourceLimits
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return nil, err
+	}
+	if err := limits.Validate(); err != nil {
+		return nil, err
+	}
+	return &limits, nil
+}
+
+// parseFollowUpTask 解析后续任务参数，参数不是对象时返回 nil，字段在提交任务时校验
+func parseFollowUpTask(arg interface{}) *FollowUpTask {
+	fields, ok := arg.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	followUp := &FollowUpTask{}
+	followUp.Command, _ = fields["command"].(string)
+	followUp.IncludeOutput, _ = fields["includeOutput"].(bool)
+	if argsSlice, ok := fields["args"].([]interface{}); ok {
+		for _, arg := range argsSlice {
+			if argStr, ok := arg.(string); ok {
+				followUp.Args = append(followUp.Args, argStr)
+			}
+		}
+	}
+	if priority, ok := fields["priority"].(float64); ok {
+		followUp.Priority = int(priority)
+	}
+	if timeoutStr, ok := fields["timeout"].(string); ok {
+		if timeout, err := time.ParseDuration(timeoutStr); err == nil {
+			followUp.Timeout = timeout
+		}
+	}
+	return followUp
+}
+
 // handleGetTaskStatus 处理获取任务状态工具调用
 func (h *protocolHandler) handleGetTaskStatus(ctx context.Context, args map[string]interface{}) (*CallToolResult, error) {
 	taskID, ok := args["taskId"].(string)
@@ -446,6 +891,156 @@ func (h *protocolHandler) handleGetTaskStatus(ctx context.Context, args map[stri
 	}, nil
 }
 
+// handleGetTaskOutput 处理分页获取任务输出工具调用
+func (h *protocolHandler) handleGetTaskOutput(ctx context.Context, args map[string]interface{}) (*CallToolResult, error) {
+	taskID, ok := args["taskId"].(string)
+	if !ok || taskID == "" {
+		return &CallToolResult{
+			Content: []ToolContent{{
+				Type: "text",
+				Text: "缺少必需参数: taskId",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	stream, _ := args["stream"].(string)
+	var output string
+	var err error
+	if stream != "" {
+		output, err = h.taskManager.GetTaskStreamOutput(ctx, taskID, stream)
+	} else {
+		output, err = h.taskManager.GetTaskOutput(ctx, taskID)
+	}
+	if err != nil {
+		return &CallToolResult{
+			Content: []ToolContent{{
+				Type: "text",
+				Text: fmt.Sprintf("获取任务输出失败: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	var offset, limit int
+	if v, ok := args["offset"].(float64); ok {
+		offset = int(v)
+	}
+	if v, ok := args["limit"].(float64); ok {
+		limit = int(v)
+	}
+
+	page := pageOutput(taskID, output, offset, limit)
+	page.Stream = stream
+	pageJSON, _ := json.MarshalIndent(page, "", "  ")
+	return &CallToolResult{
+		Content: []ToolContent{{
+			Type: "text",
+			Text: string(pageJSON),
+		}},
+	}, nil
+}
+
+// handleListDistros 处理列出 WSL 发行版工具调用
+func (h *protocolHandler) handleListDistros(ctx context.Context) (*CallToolResult, error) {
+	distros, err := h.taskManager.ListDistros(ctx)
+	if err != nil {
+		return &CallToolResult{
+			Content: []ToolContent{{
+				Type: "text",
+				Text: fmt.Sprintf("列出发行版失败: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	distrosJSON, _ := json.MarshalIndent(distros, "", "  ")
+	return &CallToolResult{
+		Content: []ToolContent{{
+			Type: "text",
+			Text: string(distrosJSON),
+		}},
+	}, nil
+}
+
+// maxShellOutputBytes run_shell_command 返回的标准输出/标准错误的最大字节数，超出时保留末尾
+const maxShellOutputBytes = 1024 * 1024
+
+// handleRunShellCommand 处理运行 shell 命令工具调用
+func (h *protocolHandler) handleRunShellCommand(ctx context.Context, args map[string]interface{}) (*CallToolResult, error) {
+	command, ok := args["command"].(string)
+	if !ok || command == "" {
+		return &CallToolResult{
+			Content: []ToolContent{{
+				Type: "text",
+				Text: "缺少必需参数: command",
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	req := &ShellCommandRequest{Command: command}
+	req.TaskID, _ = args["taskId"].(string)
+	req.WorktreeID, _ = args["worktreeId"].(string)
+	req.ProjectPath, _ = args["projectPath"].(string)
+	req.Distro, _ = args["distro"].(string)
+	if timeoutStr, ok := args["timeout"].(string); ok && timeoutStr != "" {
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			return &CallToolResult{
+				Content: []ToolContent{{
+					Type: "text",
+					Text: fmt.Sprintf("无效的超时时间: %s", timeoutStr),
+				}},
+				IsError: true,
+			}, nil
+		}
+		req.Timeout = timeout
+	}
+
+	result, err := h.taskManager.RunShellCommand(ctx, req)
+	if err != nil && result == nil {
+		return &CallToolResult{
+			Content: []ToolContent{{
+				Type: "text",
+				Text: fmt.Sprintf("运行命令失败: %v", err),
+			}},
+			IsError: true,
+		}, nil
+	}
+
+	response := map[string]interface{}{
+		"exitCode": result.ExitCode,
+		"stdout":   tailTruncate(result.Stdout, maxShellOutputBytes),
+		"stderr":   tailTruncate(result.Stderr, maxShellOutputBytes),
+		"duration": result.Duration.String(),
+	}
+	if err != nil {
+		response["error"] = err.Error()
+	}
+
+	responseJSON, _ := json.MarshalIndent(response, "", "  ")
+	return &CallToolResult{
+		Content: []ToolContent{{
+			Type: "text",
+			Text: string(responseJSON),
+		}},
+		IsError: err != nil || result.ExitCode != 0,
+	}, nil
+}
+
+// tailTruncate 超过 limit 字节时只保留末尾部分（按 UTF-8 字符边界对齐）
+func tailTruncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	start := len(s) - limit
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++
+	}
+	return "... [输出过长，仅保留末尾部分]\n" + s[start:]
+}
+
 // handleCancelTask 处理取消任务工具调用
 func (h *protocolHandler) handleCancelTask(ctx context.Context, args map[string]interface{}) (*CallToolResult, error) {
 	taskID, ok := args["taskId"].(string)
@@ -493,7 +1088,7 @@ func (h *protocolHandler) handleListTasks(ctx context.Context, args map[string]i
 
 	// 过滤任务状态
 	if statusFilter, ok := args["status"].(string); ok && statusFilter != "" {
-		var filteredTasks []*TaskStatus
+		filteredTasks := make([]*TaskStatus, 0, len(tasks))
 		for _, task := range tasks {
 			if task.Status == statusFilter {
 				filteredTasks = append(filteredTasks, task)
@@ -502,7 +1097,27 @@ func (h *protocolHandler) handleListTasks(ctx context.Context, args map[string]i
 		tasks = filteredTasks
 	}
 
-	tasksJSON, _ := json.MarshalIndent(tasks, "", "  ")
+	var limit int
+	if v, ok := args["limit"].(float64); ok {
+		limit = int(v)
+	}
+	cursor, _ := args["cursor"].(string)
+
+	page, nextCursor, err := paginateTasks(tasks, cursor, limit)
+	if err != nil {
+		return &CallToolResult{
+			Content: []ToolContent{{
+				Type: "text",
+				Text: err.Error(),
+			}},
+			IsError: true,
+		}, nil
+	}
+	if page == nil {
+		page = []*TaskStatus{}
+	}
+
+	tasksJSON, _ := json.MarshalIndent(&ListTasksResult{Tasks: page, NextCursor: nextCursor}, "", "  ")
 	return &CallToolResult{
 		Content: []ToolContent{{
 			Type: "text",
@@ -572,12 +1187,50 @@ func arrayProperty(description string, itemType string) SchemaProperty {
 }
 
 // integerProperty 创建整数类型的属性
+// min 与 max 相等时表示不限制取值范围
 func integerProperty(description string, defaultValue int, min int, max int) SchemaProperty {
-	return SchemaProperty{
+	prop := SchemaProperty{
 		Type:        "integer",
 		Description: description,
 		Default:     defaultValue,
 	}
+	if min != max {
+		minimum, maximum := float64(min), float64(max)
+		prop.Minimum = &minimum
+		prop.Maximum = &maximum
+	}
+	return prop
+}
+
+// durationProperty 创建时间间隔类型的属性（Go duration 格式，如 30s、5m）
+func durationProperty(description string, defaultValue ...string) SchemaProperty {
+	prop := stringProperty(description, defaultValue...)
+	prop.Format = durationFormat
+	return prop
+}
+
+// followUpProperty 创建后续任务模板属性
+func followUpProperty(description string) SchemaProperty {
+	return SchemaProperty{
+		Type:        "object",
+		Description: description + "，沿用原任务的项目路径和发行版",
+		Properties: map[string]SchemaProperty{
+			"command":       stringProperty("要执行的命令"),
+			"args":          arrayProperty("命令参数", "string"),
+			"priority":      integerProperty("任务优先级，省略时沿用原任务", 0, 0, 0),
+			"timeout":       durationProperty("任务超时时间 (如: 30m, 1h)"),
+			"includeOutput": booleanProperty("将原任务的错误和输出末尾附加到命令之后"),
+		},
+		Required: []string{"command"},
+	}
+}
+
+// booleanProperty 创建布尔类型的属性
+func booleanProperty(description string) SchemaProperty {
+	return SchemaProperty{
+		Type:        "boolean",
+		Description: description,
+	}
 }
 
 // enumProperty 创建枚举类型的属性
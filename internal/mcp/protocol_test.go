@@ -29,16 +29,16 @@ func TestMCPProtocolHandler_Initialize(t *testing.T) {
 	}
 
 	// 创建模拟的WSL桥接器
-	wslBridge := wsl.NewWSLBridge(log.GetZapLogger())
+	wslBridge := wsl.NewWSLBridge(nil, log.GetZapLogger())
 
 	// 创建worktree管理器
-	worktreeManager := NewWorktreeManager(cfg, log)
+	worktreeManager := NewWorktreeManager(cfg, log, nil)
 
 	// 创建任务管理器
 	taskManager := NewTaskManager(cfg, log, wslBridge, worktreeManager)
 
 	// 创建协议处理器
-	handler := NewMCPProtocolHandler(taskManager, worktreeManager)
+	handler := NewMCPProtocolHandler(taskManager, worktreeManager, nil)
 
 	// 测试初始化
 	ctx := context.Background()
@@ -86,16 +86,16 @@ func TestMCPProtocolHandler_ListTools(t *testing.T) {
 	}
 
 	// 创建模拟的WSL桥接器
-	wslBridge := wsl.NewWSLBridge(log.GetZapLogger())
+	wslBridge := wsl.NewWSLBridge(nil, log.GetZapLogger())
 
 	// 创建worktree管理器
-	worktreeManager := NewWorktreeManager(cfg, log)
+	worktreeManager := NewWorktreeManager(cfg, log, nil)
 
 	// 创建任务管理器
 	taskManager := NewTaskManager(cfg, log, wslBridge, worktreeManager)
 
 	// 创建协议处理器
-	handler := NewMCPProtocolHandler(taskManager, worktreeManager)
+	handler := NewMCPProtocolHandler(taskManager, worktreeManager, nil)
 
 	// 测试列出工具
 	ctx := context.Background()
@@ -108,6 +108,8 @@ func TestMCPProtocolHandler_ListTools(t *testing.T) {
 	expectedTools := []string{
 		"execute_claude_code",
 		"get_task_status",
+		"get_task_output",
+		"list_distros",
 		"cancel_task",
 		"list_tasks",
 	}
@@ -126,6 +128,20 @@ func TestMCPProtocolHandler_ListTools(t *testing.T) {
 			t.Errorf("缺少工具: %s", expectedTool)
 		}
 	}
+	if toolNames["run_shell_command"] {
+		t.Error("未启用时列出了 run_shell_command")
+	}
+
+	// 启用后列出 run_shell_command
+	cfg.ShellTool.Enabled = true
+	handler = NewMCPProtocolHandler(NewTaskManager(cfg, log, wslBridge, worktreeManager), worktreeManager, nil)
+	tools, err = handler.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("列出工具失败: %v", err)
+	}
+	if len(tools) != len(expectedTools)+1 || tools[len(tools)-3].Name != "run_shell_command" {
+		t.Errorf("启用后的工具数量 = %d", len(tools))
+	}
 }
 
 func TestMCPProtocolHandler_HealthCheck(t *testing.T) {
@@ -148,10 +164,10 @@ func TestMCPProtocolHandler_HealthCheck(t *testing.T) {
 	}
 
 	// 创建模拟的WSL桥接器
-	wslBridge := wsl.NewWSLBridge(log.GetZapLogger())
+	wslBridge := wsl.NewWSLBridge(nil, log.GetZapLogger())
 
 	// 创建worktree管理器
-	worktreeManager := NewWorktreeManager(cfg, log)
+	worktreeManager := NewWorktreeManager(cfg, log, nil)
 
 	// 启动worktree管理器
 	ctx := context.Background()
@@ -170,7 +186,7 @@ func TestMCPProtocolHandler_HealthCheck(t *testing.T) {
 	defer taskManager.Stop(ctx)
 
 	// 创建协议处理器
-	handler := NewMCPProtocolHandler(taskManager, worktreeManager)
+	handler := NewMCPProtocolHandler(taskManager, worktreeManager, nil)
 
 	// 测试健康检查
 	err = handler.HealthCheck(ctx)
@@ -0,0 +1,202 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/logger"
+)
+
+// 创建拉取请求的结果状态
+const (
+	PullRequestStatusCreated   = "created"    // 已推送任务分支并创建拉取请求
+	PullRequestStatusNoChanges = "no_changes" // 任务分支没有基准分支之外的修改，未推送
+	PullRequestStatusFailed    = "failed"     // 推送或调用托管平台 API 出错
+)
+
+// pullRequestDefaultTimeout 调用托管平台 API 的默认超时
+const pullRequestDefaultTimeout = 30 * time.Second
+
+// PullRequestResult 推送任务分支并创建拉取请求的结果
+type PullRequestResult struct {
+	Status     string    `json:"status"`
+	Provider   string    `json:"provider"`
+	Repository string    `json:"repository"`
+	Number     int       `json:"number,omitempty"`     // 拉取请求的编号，GitLab 为项目内的合并请求编号 (iid)
+	URL        string    `json:"url,omitempty"`        // 拉取请求的网页地址
+	Branch     string    `json:"branch,omitempty"`     // 推送的任务分支
+	BaseBranch string    `json:"baseBranch,omitempty"` // 拉取请求的目标分支
+	Commit     string    `json:"commit,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// pullRequest 创建拉取请求的参数
+type pullRequest struct {
+	Title string
+	Body  string
+	Head  string
+	Base  string
+	Draft bool
+}
+
+// forge 代码托管平台，GitLab 的合并请求同样视为拉取请求
+type forge interface {
+	// credentials 推送任务分支使用的 用户名:令牌
+	credentials() string
+	// createPullRequest 创建拉取请求，返回编号和网页地址
+	createPullRequest(ctx context.Context, pr *pullRequest) (int, string, error)
+}
+
+// pullRequestProject 配置了拉取请求的项目
+type pullRequestProject struct {
+	config.PullRequestProjectConfig
+	forge forge
+}
+
+// pullRequestPublisher 为 mcp.pull_requests 中配置的项目推送任务分支并创建拉取请求
+type pullRequestPublisher struct {
+	taskURL  string
+	projects map[string]*pullRequestProject // 按 projectKey 索引
+}
+
+// newPullRequestPublisher 创建拉取请求发布器，没有配置项目时返回 nil
+func newPullRequestPublisher(cfg config.PullRequestConfig) *pullRequestPublisher {
+	if len(cfg.Projects) == 0 {
+		return nil
+	}
+	client := &http.Client{Timeout: parseDurationOr(cfg.Timeout, pullRequestDefaultTimeout)}
+	p := &pullRequestPublisher{
+		taskURL:  cfg.TaskURL,
+		projects: make(map[string]*pullRequestProject, len(cfg.Projects)),
+	}
+	for _, projectCfg := range cfg.Projects {
+		if projectCfg.Provider == "" {
+			projectCfg.Provider = "github"
+		}
+		p.projects[projectKey(projectCfg.Path)] = &pullRequestProject{
+			PullRequestProjectConfig: projectCfg,
+			forge:                    newForge(projectCfg, client),
+		}
+	}
+	return p
+}
+
+// newForge 按项目配置的 provider 创建代码托管平台客户端
+func newForge(cfg config.PullRequestProjectConfig, client *http.Client) forge {
+	switch cfg.Provider {
+	case "gitlab":
+		return newGitLabForge(cfg, client)
+	default:
+		return newGitHubForge(cfg, client)
+	}
+}
+
+// project 获取项目的拉取请求配置，未配置时返回 nil
+func (p *pullRequestPublisher) project(projectPath string) *pullRequestProject {
+	if p == nil {
+		return nil
+	}
+	return p.projects[projectKey(projectPath)]
+}
+
+// body 生成拉取请求的描述：任务命令、修改统计和任务链接
+func (p *pullRequestPublisher) body(req *TaskRequest, push *PushResult) string {
+	var b strings.Builder
+	if command := strings.TrimSpace(req.Command); command != "" {
+		b.WriteString(command)
+		b.WriteString("\n\n")
+	}
+	if push.DiffStat != "" {
+		fmt.Fprintf(&b, "### Changes\n\n```\n%s\n```\n\n", push.DiffStat)
+	}
+	if p.taskURL != "" {
+		link := strings.ReplaceAll(p.taskURL, "{id}", req.ID)
+		fmt.Fprintf(&b, "Task: [%s](%s)", req.ID, link)
+	} else {
+		fmt.Fprintf(&b, "Task: `%s`", req.ID)
+	}
+	return b.String()
+}
+
+// openPullRequest 任务成功后为配置了 mcp.pull_requests 的项目推送任务分支并创建拉取请求，结果记录在任务结果的 PullRequest 中
+// 任务分支已合并回项目时不再创建；推送或创建失败不影响任务结果
+func (tm *taskManager) openPullRequest(ctx context.Context, req *TaskRequest, status *TaskStatus, worktree *WorktreeInfo) {
+	project := tm.pullRequests.project(req.ProjectPath)
+	if project == nil {
+		return
+	}
+	tm.tasksMutex.RLock()
+	taskResult, _ := status.Result.(*TaskResult)
+	merged := taskResult != nil && taskResult.MergeBack != nil && taskResult.MergeBack.Status == MergeStatusMerged
+	tm.tasksMutex.RUnlock()
+	if merged {
+		return
+	}
+	tm.updateProgress(status, 0.97, "正在推送任务分支并创建拉取请求")
+
+	result := &PullRequestResult{Provider: project.Provider, Repository: project.Repository}
+	err := tm.createPullRequest(ctx, req, worktree, project, result)
+	result.Time = time.Now()
+	log := logger.FromContext(ctx, tm.logger)
+	if err != nil {
+		log.Warn("创建拉取请求失败",
+			zap.String("taskId", req.ID),
+			zap.String("repository", project.Repository),
+			zap.Error(err))
+		result.Status = PullRequestStatusFailed
+		result.Error = err.Error()
+	} else if result.Status == PullRequestStatusCreated {
+		log.Info("已创建拉取请求",
+			zap.String("taskId", req.ID),
+			zap.String("url", result.URL))
+	}
+
+	tm.tasksMutex.Lock()
+	if taskResult, ok := status.Result.(*TaskResult); ok {
+		taskResult.PullRequest = result
+	}
+	tm.tasksMutex.Unlock()
+}
+
+// createPullRequest 推送任务分支并创建拉取请求，填充 result
+func (tm *taskManager) createPullRequest(ctx context.Context, req *TaskRequest, worktree *WorktreeInfo, project *pullRequestProject, result *PullRequestResult) error {
+	message := taskCommitMessage(req.ID, req.Command)
+	push, err := tm.worktreeManager.PushWorktree(ctx, worktree.ID, PushOptions{
+		Remote:      project.Remote,
+		Message:     message,
+		Credentials: project.forge.credentials(),
+	})
+	if err != nil {
+		return err
+	}
+	result.Branch, result.Commit = push.Branch, push.Commit
+	result.BaseBranch = project.BaseBranch
+	if result.BaseBranch == "" {
+		result.BaseBranch = push.BaseBranch
+	}
+	if !push.Pushed {
+		result.Status = PullRequestStatusNoChanges
+		return nil
+	}
+
+	title, _, _ := strings.Cut(message, "\n")
+	result.Number, result.URL, err = project.forge.createPullRequest(ctx, &pullRequest{
+		Title: title,
+		Body:  tm.pullRequests.body(req, push),
+		Head:  push.Branch,
+		Base:  result.BaseBranch,
+		Draft: project.Draft,
+	})
+	if err != nil {
+		return err
+	}
+	result.Status = PullRequestStatusCreated
+	return nil
+}
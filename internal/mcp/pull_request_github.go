@@ -0,0 +1,101 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// githubDefaultAPIURL GitHub REST API 的默认地址
+const githubDefaultAPIURL = "https://api.github.com"
+
+// githubForge 通过 GitHub REST API 创建拉取请求
+type githubForge struct {
+	apiURL     string
+	repository string
+	token      string
+	client     *http.Client
+}
+
+// newGitHubForge 创建 GitHub 客户端，api_url 留空时使用 api.github.com
+func newGitHubForge(cfg config.PullRequestProjectConfig, client *http.Client) *githubForge {
+	apiURL := strings.TrimRight(cfg.APIURL, "/")
+	if apiURL == "" {
+		apiURL = githubDefaultAPIURL
+	}
+	return &githubForge{apiURL: apiURL, repository: cfg.Repository, token: cfg.Token, client: client}
+}
+
+// credentials GitHub 的令牌以 x-access-token 为用户名推送
+func (g *githubForge) credentials() string {
+	return "x-access-token:" + g.token
+}
+
+// createPullRequest 调用 POST /repos/{owner}/{repo}/pulls 创建拉取请求
+func (g *githubForge) createPullRequest(ctx context.Context, pr *pullRequest) (int, string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"title": pr.Title,
+		"body":  pr.Body,
+		"head":  pr.Head,
+		"base":  pr.Base,
+		"draft": pr.Draft,
+	})
+	if err != nil {
+		return 0, "", apperrors.Wrap(err, apperrors.ErrInternal, "序列化拉取请求失败")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.apiURL+"/repos/"+g.repository+"/pulls", bytes.NewReader(body))
+	if err != nil {
+		return 0, "", apperrors.Wrap(err, apperrors.ErrPullRequest, "创建GitHub请求失败")
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+g.token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "auto-claude-code")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return 0, "", apperrors.Wrap(err, apperrors.ErrPullRequest, "调用GitHub API失败")
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode != http.StatusCreated {
+		return 0, "", apperrors.Newf(apperrors.ErrPullRequest, "GitHub返回状态码 %d: %s", resp.StatusCode, githubErrorMessage(data))
+	}
+	var created struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.Unmarshal(data, &created); err != nil {
+		return 0, "", apperrors.Wrap(err, apperrors.ErrPullRequest, "解析GitHub响应失败")
+	}
+	return created.Number, created.HTMLURL, nil
+}
+
+// githubErrorMessage 提取 GitHub 错误响应中的说明，如 "Validation Failed: A pull request already exists"
+func githubErrorMessage(data []byte) string {
+	var body struct {
+		Message string `json:"message"`
+		Errors  []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil || body.Message == "" {
+		return strings.TrimSpace(string(data))
+	}
+	messages := []string{body.Message}
+	for _, e := range body.Errors {
+		if e.Message != "" {
+			messages = append(messages, e.Message)
+		}
+	}
+	return strings.Join(messages, ": ")
+}
@@ -0,0 +1,129 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// gitlabDefaultAPIURL GitLab.com REST API 的地址
+const gitlabDefaultAPIURL = "https://gitlab.com/api/v4"
+
+// gitlabForge 通过 GitLab REST API 创建合并请求
+type gitlabForge struct {
+	apiURL     string
+	repository string
+	token      string
+	client     *http.Client
+}
+
+// newGitLabForge 创建 GitLab 客户端，api_url 留空时使用 gitlab.com，自建实例为 https://<host>/api/v4
+func newGitLabForge(cfg config.PullRequestProjectConfig, client *http.Client) *gitlabForge {
+	apiURL := strings.TrimRight(cfg.APIURL, "/")
+	if apiURL == "" {
+		apiURL = gitlabDefaultAPIURL
+	}
+	return &gitlabForge{apiURL: apiURL, repository: cfg.Repository, token: cfg.Token, client: client}
+}
+
+// credentials GitLab 的访问令牌以 oauth2 为用户名推送
+func (g *gitlabForge) credentials() string {
+	return "oauth2:" + g.token
+}
+
+// createPullRequest 调用 POST /projects/{id}/merge_requests 创建合并请求，草稿以 "Draft: " 标题前缀表示
+func (g *gitlabForge) createPullRequest(ctx context.Context, pr *pullRequest) (int, string, error) {
+	title := pr.Title
+	if pr.Draft {
+		title = "Draft: " + title
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"title":         title,
+		"description":   pr.Body,
+		"source_branch": pr.Head,
+		"target_branch": pr.Base,
+	})
+	if err != nil {
+		return 0, "", apperrors.Wrap(err, apperrors.ErrInternal, "序列化合并请求失败")
+	}
+
+	endpoint := g.apiURL + "/projects/" + url.PathEscape(g.repository) + "/merge_requests"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", apperrors.Wrap(err, apperrors.ErrPullRequest, "创建GitLab请求失败")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("PRIVATE-TOKEN", g.token)
+	req.Header.Set("User-Agent", "auto-claude-code")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return 0, "", apperrors.Wrap(err, apperrors.ErrPullRequest, "调用GitLab API失败")
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode != http.StatusCreated {
+		return 0, "", apperrors.Newf(apperrors.ErrPullRequest, "GitLab返回状态码 %d: %s", resp.StatusCode, gitlabErrorMessage(data))
+	}
+	var created struct {
+		IID    int    `json:"iid"`
+		WebURL string `json:"web_url"`
+	}
+	if err := json.Unmarshal(data, &created); err != nil {
+		return 0, "", apperrors.Wrap(err, apperrors.ErrPullRequest, "解析GitLab响应失败")
+	}
+	return created.IID, created.WebURL, nil
+}
+
+// gitlabErrorMessage 提取 GitLab 错误响应中的说明，message 可能是字符串、字符串数组或按字段分组的对象
+func gitlabErrorMessage(data []byte) string {
+	var body struct {
+		Message interface{} `json:"message"`
+		Error   string      `json:"error"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return strings.TrimSpace(string(data))
+	}
+	var messages []string
+	switch message := body.Message.(type) {
+	case string:
+		messages = append(messages, message)
+	case []interface{}:
+		for _, m := range message {
+			if s, ok := m.(string); ok {
+				messages = append(messages, s)
+			}
+		}
+	case map[string]interface{}:
+		fields := make([]string, 0, len(message))
+		for field := range message {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			if list, ok := message[field].([]interface{}); ok {
+				for _, item := range list {
+					if s, ok := item.(string); ok {
+						messages = append(messages, field+" "+s)
+					}
+				}
+			}
+		}
+	}
+	if body.Error != "" {
+		messages = append(messages, body.Error)
+	}
+	if len(messages) == 0 {
+		return strings.TrimSpace(string(data))
+	}
+	return strings.Join(messages, ": ")
+}
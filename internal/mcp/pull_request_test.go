@@ -0,0 +1,175 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"auto-claude-code/internal/config"
+)
+
+// githubPullsServer 模拟 GitHub 创建拉取请求的接口，记录收到的请求体
+func githubPullsServer(t *testing.T, status int, response string, received *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/acme/app/pulls" {
+			t.Errorf("请求 = %s %s", r.Method, r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer secret" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		json.NewDecoder(r.Body).Decode(received)
+		w.WriteHeader(status)
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOpenPullRequest(t *testing.T) {
+	ctx := context.Background()
+	wm, project, worktree := newMergeTestRepo(t)
+	addTestRemote(t, project)
+	writeFile(t, filepath.Join(worktree.Path, "feature.txt"), "feature\n")
+
+	var received map[string]interface{}
+	server := githubPullsServer(t, http.StatusCreated, `{"number":42,"html_url":"https://github.com/acme/app/pull/42"}`, &received)
+
+	tm := newQueueTestManager()
+	tm.worktreeManager = wm
+	tm.pullRequests = newPullRequestPublisher(config.PullRequestConfig{
+		TaskURL: "https://acc.example.com/tasks/{id}",
+		Projects: []config.PullRequestProjectConfig{
+			{Path: strings.ToUpper(project) + "/", Repository: "acme/app", Token: "secret", APIURL: server.URL, BaseBranch: "develop", Draft: true},
+		},
+	})
+
+	req := &TaskRequest{ID: "t1", ProjectPath: project, Command: "实现导出功能"}
+	status := &TaskStatus{ID: "t1", Result: &TaskResult{}}
+	tm.openPullRequest(ctx, req, status, worktree)
+
+	result := status.Result.(*TaskResult).PullRequest
+	if result == nil || result.Status != PullRequestStatusCreated || result.Number != 42 || result.URL != "https://github.com/acme/app/pull/42" {
+		t.Fatalf("PullRequest = %+v", result)
+	}
+	if received["head"] != worktree.WorkBranch || received["base"] != "develop" || received["draft"] != true || received["title"] != "实现导出功能" {
+		t.Errorf("请求体 = %v", received)
+	}
+	body, _ := received["body"].(string)
+	for _, want := range []string{"实现导出功能", "feature.txt", "[t1](https://acc.example.com/tasks/t1)"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("描述中没有 %q:\n%s", want, body)
+		}
+	}
+
+	// 已合并回项目的任务不再创建拉取请求
+	merged := &TaskStatus{ID: "t1", Result: &TaskResult{MergeBack: &MergeResult{Status: MergeStatusMerged}}}
+	tm.openPullRequest(ctx, req, merged, worktree)
+	if merged.Result.(*TaskResult).PullRequest != nil {
+		t.Error("已合并的任务创建了拉取请求")
+	}
+
+	// 未配置的项目不创建拉取请求
+	other := &TaskStatus{ID: "t2", Result: &TaskResult{}}
+	tm.openPullRequest(ctx, &TaskRequest{ID: "t2", ProjectPath: "/other"}, other, worktree)
+	if other.Result.(*TaskResult).PullRequest != nil {
+		t.Error("未配置的项目创建了拉取请求")
+	}
+}
+
+func TestOpenPullRequestFailed(t *testing.T) {
+	ctx := context.Background()
+	wm, project, worktree := newMergeTestRepo(t)
+	addTestRemote(t, project)
+	writeFile(t, filepath.Join(worktree.Path, "feature.txt"), "feature\n")
+
+	var received map[string]interface{}
+	server := githubPullsServer(t, http.StatusUnprocessableEntity,
+		`{"message":"Validation Failed","errors":[{"message":"A pull request already exists"}]}`, &received)
+
+	tm := newQueueTestManager()
+	tm.worktreeManager = wm
+	tm.pullRequests = newPullRequestPublisher(config.PullRequestConfig{
+		Projects: []config.PullRequestProjectConfig{{Path: project, Repository: "acme/app", Token: "secret", APIURL: server.URL}},
+	})
+
+	status := &TaskStatus{ID: "t1", Result: &TaskResult{}}
+	tm.openPullRequest(ctx, &TaskRequest{ID: "t1", ProjectPath: project}, status, worktree)
+
+	result := status.Result.(*TaskResult).PullRequest
+	if result == nil || result.Status != PullRequestStatusFailed || !strings.Contains(result.Error, "A pull request already exists") {
+		t.Fatalf("PullRequest = %+v", result)
+	}
+	if result.BaseBranch != "main" || result.Branch != worktree.WorkBranch {
+		t.Errorf("分支 = %s -> %s", result.Branch, result.BaseBranch)
+	}
+	if body, _ := received["body"].(string); !strings.Contains(body, "Task: `t1`") {
+		t.Errorf("描述 = %q", body)
+	}
+}
+
+func TestGitLabForge(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.EscapedPath() != "/api/v4/projects/acme%2Fplatform%2Fapp/merge_requests" {
+			t.Errorf("请求 = %s %s", r.Method, r.URL.EscapedPath())
+		}
+		if token := r.Header.Get("PRIVATE-TOKEN"); token != "secret" {
+			t.Errorf("PRIVATE-TOKEN = %q", token)
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"iid":7,"web_url":"https://gitlab.example.com/acme/platform/app/-/merge_requests/7"}`))
+	}))
+	defer server.Close()
+
+	// 自建实例的 API 地址，同一发布器中的项目可以使用不同的托管平台
+	publisher := newPullRequestPublisher(config.PullRequestConfig{
+		Projects: []config.PullRequestProjectConfig{
+			{Path: "/srv/app", Provider: "gitlab", Repository: "acme/platform/app", Token: "secret", APIURL: server.URL + "/api/v4/"},
+			{Path: "/srv/web", Repository: "acme/web", Token: "secret"},
+		},
+	})
+	if _, ok := publisher.project("/srv/web").forge.(*githubForge); !ok {
+		t.Errorf("/srv/web forge = %T", publisher.project("/srv/web").forge)
+	}
+	project := publisher.project("/srv/app")
+	if project.forge.credentials() != "oauth2:secret" {
+		t.Errorf("credentials() = %q", project.forge.credentials())
+	}
+
+	number, url, err := project.forge.createPullRequest(context.Background(), &pullRequest{
+		Title: "实现导出功能", Body: "Task: `t1`", Head: "worktree_1", Base: "main", Draft: true,
+	})
+	if err != nil {
+		t.Fatalf("createPullRequest() error = %v", err)
+	}
+	if number != 7 || url != "https://gitlab.example.com/acme/platform/app/-/merge_requests/7" {
+		t.Errorf("createPullRequest() = %d, %s", number, url)
+	}
+	if received["title"] != "Draft: 实现导出功能" || received["source_branch"] != "worktree_1" || received["target_branch"] != "main" || received["description"] != "Task: `t1`" {
+		t.Errorf("请求体 = %v", received)
+	}
+}
+
+func TestGitLabErrorMessage(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"message":["Another open merge request already exists for this source branch: !3"]}`, "Another open merge request already exists for this source branch: !3"},
+		{`{"message":{"target_branch":["is invalid"],"source_branch":["is invalid"]}}`, "source_branch is invalid: target_branch is invalid"},
+		{`{"message":"401 Unauthorized"}`, "401 Unauthorized"},
+		{`{"error":"insufficient_scope"}`, "insufficient_scope"},
+		{"Bad Gateway", "Bad Gateway"},
+	}
+	for _, tt := range tests {
+		if got := gitlabErrorMessage([]byte(tt.body)); got != tt.want {
+			t.Errorf("gitlabErrorMessage(%s) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
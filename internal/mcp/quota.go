@@ -0,0 +1,253 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"auto-claude-code/internal/audit"
+	"auto-claude-code/internal/auth"
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// QuotaUsage 客户端在配额窗口内的用量和适用的配额
+type QuotaUsage struct {
+	Client        string             `json:"client"`
+	Submitted     int                `json:"submitted"`     // window 内提交的任务数
+	Active        int                `json:"active"`        // 未结束的任务数
+	ExecutionTime string             `json:"executionTime"` // window 内任务累计执行时间
+	Window        string             `json:"window"`
+	Limits        config.QuotaLimits `json:"limits"`
+}
+
+// quotaTracker 按客户端统计任务提交数、未结束任务数和执行时间，并按 mcp.quota 检查配额
+// 未启用配额时为 nil，所有方法在 nil 上调用时不做任何事
+type quotaTracker struct {
+	cfg    config.QuotaConfig
+	window time.Duration
+	now    func() time.Time
+
+	mutex       sync.Mutex
+	clients     map[string]*clientUsage
+	taskClients map[string]string // 未结束任务所属的客户端
+}
+
+// clientUsage 单个客户端的用量记录
+type clientUsage struct {
+	submissions []quotaSubmission
+	executions  []quotaExecution
+	active      map[string]bool
+}
+
+// quotaSubmission 一次任务提交
+type quotaSubmission struct {
+	taskID string
+	at     time.Time
+}
+
+// quotaExecution 任务的一次执行
+type quotaExecution struct {
+	at       time.Time
+	duration time.Duration
+}
+
+// newQuotaTracker 创建配额跟踪器，未启用配额时返回 nil
+func newQuotaTracker(cfg config.QuotaConfig) *quotaTracker {
+	if !cfg.Enabled {
+		return nil
+	}
+	window, err := time.ParseDuration(cfg.Window)
+	if err != nil || window <= 0 {
+		window = 24 * time.Hour
+	}
+	return &quotaTracker{
+		cfg:         cfg,
+		window:      window,
+		now:         time.Now,
+		clients:     make(map[string]*clientUsage),
+		taskClients: make(map[string]string),
+	}
+}
+
+// quotaClient 获取配额计数的客户端：已认证请求按身份，否则按客户端IP，本地请求返回空字符串
+func quotaClient(ctx context.Context) string {
+	if identity := auth.IdentityFromContext(ctx); identity != nil && identity.Subject != "" {
+		return identity.Subject
+	}
+	if ip := audit.SourceIPFromContext(ctx); ip != "" {
+		return "ip:" + ip
+	}
+	return ""
+}
+
+// reserve 检查客户端的配额并记录任务提交，超出配额时返回配额超限错误
+func (q *quotaTracker) reserve(client, taskID string) error {
+	if q == nil || client == "" {
+		return nil
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	usage := q.usage(client)
+	limits := q.cfg.LimitsFor(client)
+	if limits.MaxConcurrent > 0 && len(usage.active) >= limits.MaxConcurrent {
+		return apperrors.Newf(apperrors.ErrQuotaExceeded, "未结束的任务数已达到配额 %d", limits.MaxConcurrent)
+	}
+	if limits.MaxSubmitted > 0 && len(usage.submissions) >= limits.MaxSubmitted {
+		return apperrors.Newf(apperrors.ErrQuotaExceeded, "最近 %s 内提交的任务数已达到配额 %d", q.window, limits.MaxSubmitted)
+	}
+	if max, _ := time.ParseDuration(limits.MaxExecutionTime); max > 0 && usage.executionTime() >= max {
+		return apperrors.Newf(apperrors.ErrQuotaExceeded, "最近 %s 内的任务执行时间已达到配额 %s", q.window, max)
+	}
+
+	usage.submissions = append(usage.submissions, quotaSubmission{taskID: taskID, at: q.now()})
+	usage.active[taskID] = true
+	q.taskClients[taskID] = client
+	return nil
+}
+
+// release 撤销未能创建的任务的提交记录
+func (q *quotaTracker) release(taskID string) {
+	if q == nil {
+		return
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	client, ok := q.taskClients[taskID]
+	if !ok {
+		return
+	}
+	delete(q.taskClients, taskID)
+	usage := q.clients[client]
+	delete(usage.active, taskID)
+	for i, submission := range usage.submissions {
+		if submission.taskID == taskID {
+			usage.submissions = append(usage.submissions[:i], usage.submissions[i+1:]...)
+			break
+		}
+	}
+}
+
+// recordExecution 累计任务一次执行的时间
+func (q *quotaTracker) recordExecution(taskID string, duration time.Duration) {
+	if q == nil {
+		return
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if client, ok := q.taskClients[taskID]; ok {
+		usage := q.usage(client)
+		usage.executions = append(usage.executions, quotaExecution{at: q.now(), duration: duration})
+	}
+}
+
+// finish 任务结束后不再计入客户端的未结束任务数
+func (q *quotaTracker) finish(taskID string) {
+	if q == nil {
+		return
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if client, ok := q.taskClients[taskID]; ok {
+		delete(q.taskClients, taskID)
+		delete(q.clients[client].active, taskID)
+	}
+}
+
+// list 获取各客户端的用量，client 非空时只返回该客户端，按客户端排序
+func (q *quotaTracker) list(client string) []QuotaUsage {
+	if q == nil {
+		return []QuotaUsage{}
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	result := []QuotaUsage{}
+	for name := range q.clients {
+		if client != "" && name != client {
+			continue
+		}
+		usage := q.usage(name)
+		if len(usage.submissions) == 0 && len(usage.executions) == 0 && len(usage.active) == 0 {
+			delete(q.clients, name)
+			continue
+		}
+		result = append(result, QuotaUsage{
+			Client:        name,
+			Submitted:     len(usage.submissions),
+			Active:        len(usage.active),
+			ExecutionTime: usage.executionTime().String(),
+			Window:        q.window.String(),
+			Limits:        q.cfg.LimitsFor(name),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Client < result[j].Client })
+	return result
+}
+
+// usage 获取客户端的用量记录并丢弃窗口之前的记录，调用方必须持有 mutex
+func (q *quotaTracker) usage(client string) *clientUsage {
+	usage, ok := q.clients[client]
+	if !ok {
+		usage = &clientUsage{active: make(map[string]bool)}
+		q.clients[client] = usage
+	}
+
+	since := q.now().Add(-q.window)
+	for len(usage.submissions) > 0 && usage.submissions[0].at.Before(since) {
+		usage.submissions = usage.submissions[1:]
+	}
+	for len(usage.executions) > 0 && usage.executions[0].at.Before(since) {
+		usage.executions = usage.executions[1:]
+	}
+	return usage
+}
+
+// executionTime 窗口内的累计执行时间
+func (u *clientUsage) executionTime() time.Duration {
+	var total time.Duration
+	for _, execution := range u.executions {
+		total += execution.duration
+	}
+	return total
+}
+
+// ListQuotaUsage 获取各客户端的配额用量，client 非空时只返回该客户端
+func (tm *taskManager) ListQuotaUsage(ctx context.Context, client string) []QuotaUsage {
+	return tm.quotas.list(client)
+}
+
+// handleQuotas 查询配额用量，管理员返回所有客户端，其他角色只返回自己的用量
+func (s *mcpServer) handleQuotas(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持GET方法"))
+		return
+	}
+
+	ctx := r.Context()
+	resp := quotaListResponse{Enabled: s.config.Quota.Enabled, Clients: []QuotaUsage{}}
+	if auth.RoleFromContext(ctx, s.defaultRole()) == auth.RoleAdmin {
+		resp.Clients = s.taskManager.ListQuotaUsage(ctx, strings.TrimSpace(r.URL.Query().Get("client")))
+	} else if client := quotaClient(ctx); client != "" {
+		resp.Clients = s.taskManager.ListQuotaUsage(ctx, client)
+	}
+	writeJSONWithETag(w, r, resp)
+}
+
+// quotaListResponse 配额用量查询响应
+type quotaListResponse struct {
+	Enabled bool         `json:"enabled"`
+	Clients []QuotaUsage `json:"clients"`
+}
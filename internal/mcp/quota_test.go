@@ -0,0 +1,136 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auto-claude-code/internal/audit"
+	"auto-claude-code/internal/auth"
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+func TestQuotaClient(t *testing.T) {
+	ctx := context.Background()
+	if got := quotaClient(ctx); got != "" {
+		t.Errorf("本地请求 quotaClient() = %q, want 空", got)
+	}
+	if got := quotaClient(audit.WithSourceIP(ctx, "10.0.0.1")); got != "ip:10.0.0.1" {
+		t.Errorf("未认证请求 quotaClient() = %q", got)
+	}
+	identified := auth.WithIdentity(audit.WithSourceIP(ctx, "10.0.0.1"), &auth.Identity{Subject: "alice", Method: "token"})
+	if got := quotaClient(identified); got != "alice" {
+		t.Errorf("已认证请求 quotaClient() = %q, want alice", got)
+	}
+}
+
+func TestQuotaTrackerLimits(t *testing.T) {
+	if newQuotaTracker(config.QuotaConfig{}) != nil {
+		t.Fatal("未启用配额时应返回 nil")
+	}
+
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	q := newQuotaTracker(config.QuotaConfig{
+		Enabled: true,
+		Window:  "1h",
+		Default: config.QuotaLimits{MaxSubmitted: 3, MaxConcurrent: 2, MaxExecutionTime: "30m"},
+		Clients: map[string]config.QuotaLimits{"CI": {MaxConcurrent: 1}},
+	})
+	q.now = func() time.Time { return now }
+
+	if err := q.reserve("", "local"); err != nil {
+		t.Errorf("本地请求不受配额限制: %v", err)
+	}
+
+	// 未结束任务数
+	for _, id := range []string{"a1", "a2"} {
+		if err := q.reserve("alice", id); err != nil {
+			t.Fatalf("reserve(%s) error = %v", id, err)
+		}
+	}
+	if err := q.reserve("alice", "a3"); !apperrors.IsCode(err, apperrors.ErrQuotaExceeded) {
+		t.Fatalf("超出并发配额 error = %v", err)
+	}
+
+	// 窗口内提交数
+	q.finish("a1")
+	if err := q.reserve("alice", "a3"); err != nil {
+		t.Fatalf("任务结束后 reserve() error = %v", err)
+	}
+	q.finish("a2")
+	if err := q.reserve("alice", "a4"); !apperrors.IsCode(err, apperrors.ErrQuotaExceeded) {
+		t.Fatalf("超出提交数配额 error = %v", err)
+	}
+
+	// 撤销的提交不计入
+	q.release("a3")
+	if err := q.reserve("alice", "a4"); err != nil {
+		t.Fatalf("撤销提交后 reserve() error = %v", err)
+	}
+	q.finish("a4")
+
+	// 窗口之前的提交不再计入，执行时间按窗口累计
+	now = now.Add(2 * time.Hour)
+	if err := q.reserve("alice", "a5"); err != nil {
+		t.Fatalf("窗口过后 reserve() error = %v", err)
+	}
+	q.recordExecution("a5", 30*time.Minute)
+	q.finish("a5")
+	if err := q.reserve("alice", "a6"); !apperrors.IsCode(err, apperrors.ErrQuotaExceeded) {
+		t.Fatalf("超出执行时间配额 error = %v", err)
+	}
+
+	// 按客户端覆盖的配额，名称不区分大小写
+	if err := q.reserve("ci", "c1"); err != nil {
+		t.Fatalf("reserve(ci) error = %v", err)
+	}
+	if err := q.reserve("ci", "c2"); !apperrors.IsCode(err, apperrors.ErrQuotaExceeded) {
+		t.Errorf("ci 超出并发配额 error = %v", err)
+	}
+
+	usage := q.list("")
+	if len(usage) != 2 || usage[0].Client != "alice" || usage[1].Client != "ci" {
+		t.Fatalf("list() = %+v", usage)
+	}
+	if usage[0].Submitted != 1 || usage[0].Active != 0 || usage[0].ExecutionTime != "30m0s" || usage[0].Window != "1h0m0s" {
+		t.Errorf("alice 用量 = %+v", usage[0])
+	}
+	if usage[1].Active != 1 || usage[1].Limits.MaxConcurrent != 1 {
+		t.Errorf("ci 用量 = %+v", usage[1])
+	}
+	if got := q.list("ci"); len(got) != 1 || got[0].Client != "ci" {
+		t.Errorf("list(ci) = %+v", got)
+	}
+}
+
+func TestSubmitTaskQuota(t *testing.T) {
+	tm := newQueueTestManager()
+	tm.quotas = newQuotaTracker(config.QuotaConfig{Enabled: true, Window: "24h", Default: config.QuotaLimits{MaxConcurrent: 1}})
+	alice := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "alice", Method: "token"})
+	bob := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "bob", Method: "token"})
+
+	if _, err := tm.SubmitTask(alice, &TaskRequest{ID: "first", ProjectPath: "/app"}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	if _, err := tm.SubmitTask(alice, &TaskRequest{ID: "second", ProjectPath: "/app"}); !apperrors.IsCode(err, apperrors.ErrQuotaExceeded) {
+		t.Fatalf("超出配额 SubmitTask() error = %v", err)
+	}
+	if _, ok := tm.tasks["second"]; ok {
+		t.Error("超出配额的任务不应创建")
+	}
+	if _, err := tm.SubmitTask(bob, &TaskRequest{ID: "other", ProjectPath: "/app"}); err != nil {
+		t.Errorf("其他客户端 SubmitTask() error = %v", err)
+	}
+	if _, err := tm.SubmitTask(context.Background(), &TaskRequest{ID: "local", ProjectPath: "/app"}); err != nil {
+		t.Errorf("本地请求 SubmitTask() error = %v", err)
+	}
+
+	finishTask(tm, "first", "completed", "")
+	if _, err := tm.SubmitTask(alice, &TaskRequest{ID: "second", ProjectPath: "/app"}); err != nil {
+		t.Errorf("任务结束后 SubmitTask() error = %v", err)
+	}
+	if usage := tm.ListQuotaUsage(alice, "alice"); len(usage) != 1 || usage[0].Submitted != 2 || usage[0].Active != 1 {
+		t.Errorf("ListQuotaUsage() = %+v", usage)
+	}
+}
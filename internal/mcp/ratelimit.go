@@ -0,0 +1,204 @@
+package mcp
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/auth"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// jsonRPCRateLimited 请求过于频繁的JSON-RPC错误代码
+const jsonRPCRateLimited = -32029
+
+// rateLimitSweepInterval 清理空闲令牌桶的间隔
+const rateLimitSweepInterval = time.Minute
+
+// tokenBucket 令牌桶
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter 按键（身份或IP）独立计数的令牌桶限流器
+type rateLimiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// newRateLimiter 创建限流器，rate 为每秒补充的令牌数，burst 为桶容量
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow 消耗一个令牌，令牌不足时返回需要等待的时间
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep 删除已经补满的令牌桶，避免按IP计数时无限增长
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rateLimitKeyContextKey 请求上下文中的限流键
+type rateLimitKeyContextKey struct{}
+
+// withRateLimitKey 在上下文中记录限流键，供 MCP 方法级限流使用
+func withRateLimitKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, rateLimitKeyContextKey{}, key)
+}
+
+// rateLimitKeyFromContext 获取限流键，本地 stdio 请求没有限流键
+func rateLimitKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(rateLimitKeyContextKey{}).(string)
+	return key
+}
+
+// rateLimitKey 获取请求的限流键：已认证请求按身份，否则按客户端IP
+func (s *mcpServer) rateLimitKey(r *http.Request) string {
+	if identity := auth.IdentityFromContext(r.Context()); identity != nil && identity.Subject != "" {
+		return "sub:" + identity.Subject
+	}
+	return "ip:" + s.getClientIP(r)
+}
+
+// isMCPCallPath 是否为 MCP 调用端点
+func (s *mcpServer) isMCPCallPath(path string) bool {
+	return path == "/mcp" || (s.config.SSE.Enabled && path == s.config.SSE.MessagePath)
+}
+
+// rateLimitMiddleware 限流中间件：MCP 调用和 REST 任务提交分别限流，超限返回 429
+func (s *mcpServer) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var limiter *rateLimiter
+		switch {
+		case s.isMCPCallPath(r.URL.Path):
+			limiter = s.callLimiter
+		case r.URL.Path == "/tasks":
+			limiter = s.submitLimiter
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := s.rateLimitKey(r)
+		if ok, wait := limiter.allow(key); !ok {
+			logger.FromContext(r.Context(), s.logger).Warn("请求过于频繁",
+				zap.String("key", key),
+				zap.String("path", r.URL.Path),
+				zap.Duration("retry_after", wait))
+			writeRetryAfter(w, wait)
+			writeProblem(w, r, apperrors.New(apperrors.ErrRateLimited, "请求过于频繁，请稍后重试"))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(withRateLimitKey(r.Context(), key)))
+	})
+}
+
+// checkSubmitRate 检查通过 MCP 提交任务的频率，返回需要等待的时间
+func (s *mcpServer) checkSubmitRate(ctx context.Context, req *JSONRPCRequest) (bool, time.Duration) {
+	if s.submitLimiter == nil || req.Method != "tools/call" {
+		return true, 0
+	}
+
+	key := rateLimitKeyFromContext(ctx)
+	if key == "" {
+		return true, 0
+	}
+
+	var callReq CallToolRequest
+	if err := s.parseParams(req.Params, &callReq); err != nil || callReq.Name != "execute_claude_code" {
+		return true, 0
+	}
+	return s.submitLimiter.allow(key)
+}
+
+// retryAfterSeconds 等待时间换算为秒（向上取整，至少1秒）
+func retryAfterSeconds(wait time.Duration) int {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// writeRetryAfter 写入 Retry-After 头
+func writeRetryAfter(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(wait)))
+}
+
+// retryAfterFromError 从限流错误中获取等待时间
+func retryAfterFromError(rpcErr *JSONRPCError) (time.Duration, bool) {
+	if rpcErr == nil || rpcErr.Code != jsonRPCRateLimited {
+		return 0, false
+	}
+	data, ok := rpcErr.Data.(map[string]interface{})
+	if !ok {
+		return time.Second, true
+	}
+	seconds, _ := data["retryAfter"].(int)
+	return time.Duration(seconds) * time.Second, true
+}
+
+// rateLimitedError 创建限流JSON-RPC错误
+func rateLimitedError(wait time.Duration) *JSONRPCError {
+	return &JSONRPCError{
+		Code:    jsonRPCRateLimited,
+		Message: "请求过于频繁",
+		Data:    map[string]interface{}{"retryAfter": retryAfterSeconds(wait), "reason": "任务提交频率超过限制"},
+	}
+}
@@ -0,0 +1,58 @@
+package mcp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := newRateLimiter(2, 3)
+	limiter.now = func() time.Time { return now }
+
+	// 突发容量内的请求全部通过
+	for i := 0; i < 3; i++ {
+		if ok, _ := limiter.allow("a"); !ok {
+			t.Fatalf("第 %d 个请求应通过", i+1)
+		}
+	}
+
+	ok, wait := limiter.allow("a")
+	if ok {
+		t.Fatal("超过突发容量的请求应被拒绝")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("等待时间 = %v，期望 500ms", wait)
+	}
+
+	// 不同的键独立计数
+	if ok, _ := limiter.allow("b"); !ok {
+		t.Error("其他键的请求应通过")
+	}
+
+	// 按速率补充令牌
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := limiter.allow("a"); !ok {
+		t.Error("补充令牌后请求应通过")
+	}
+	if ok, _ := limiter.allow("a"); ok {
+		t.Error("令牌耗尽后请求应被拒绝")
+	}
+
+	// 补满的令牌桶会被清理
+	now = now.Add(time.Hour)
+	limiter.allow("c")
+	if _, exists := limiter.buckets["a"]; exists {
+		t.Error("空闲的令牌桶应被清理")
+	}
+}
+
+func TestRetryAfterFromError(t *testing.T) {
+	wait, limited := retryAfterFromError(rateLimitedError(1500 * time.Millisecond))
+	if !limited || wait != 2*time.Second {
+		t.Errorf("retryAfterFromError = %v, %v，期望 2s, true", wait, limited)
+	}
+	if _, limited := retryAfterFromError(&JSONRPCError{Code: -32603}); limited {
+		t.Error("其他错误不应视为限流")
+	}
+}
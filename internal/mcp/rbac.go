@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"auto-claude-code/internal/auth"
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// jsonRPCForbidden 权限不足的JSON-RPC错误代码
+const jsonRPCForbidden = -32003
+
+// toolRoles 调用各工具所需的角色，未列出的工具需要 submitter
+var toolRoles = map[string]auth.Role{
+	"execute_claude_code": auth.RoleSubmitter,
+	"cancel_task":         auth.RoleSubmitter,
+	"get_task_status":     auth.RoleViewer,
+	"get_task_output":     auth.RoleViewer,
+	"list_tasks":          auth.RoleViewer,
+	"list_distros":        auth.RoleViewer,
+	// 任意命令执行只开放给管理员
+	"run_shell_command": auth.RoleAdmin,
+}
+
+// toolRole 获取调用工具所需的角色
+func toolRole(name string) auth.Role {
+	if role, ok := toolRoles[name]; ok {
+		return role
+	}
+	return auth.RoleSubmitter
+}
+
+// methodRole 获取JSON-RPC方法所需的角色
+func methodRole(req *JSONRPCRequest) auth.Role {
+	if req.Method != "tools/call" {
+		return auth.RoleViewer
+	}
+
+	var callReq CallToolRequest
+	if data, err := json.Marshal(req.Params); err == nil {
+		json.Unmarshal(data, &callReq)
+	}
+	return toolRole(callReq.Name)
+}
+
+// restRole 获取HTTP请求所需的角色
+func (s *mcpServer) restRole(r *http.Request) auth.Role {
+	path := r.URL.Path
+	switch {
+	case strings.HasPrefix(path, "/auth/tokens") || path == "/audit":
+		return auth.RoleAdmin
+	case path == "/mcp" || (s.config.SSE.Enabled && path == s.config.SSE.MessagePath):
+		// MCP 方法在 processJSONRPCRequest 中逐个检查
+		return auth.RoleViewer
+	case strings.HasPrefix(path, "/tasks/") && strings.HasSuffix(path, "/attach"):
+		// 连接交互式会话可以向终端输入，与提交任务的权限相同
+		return auth.RoleSubmitter
+	case r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions:
+		return auth.RoleViewer
+	case path == "/tasks" || strings.HasPrefix(path, "/tasks/"):
+		return auth.RoleSubmitter
+	default:
+		return auth.RoleAdmin
+	}
+}
+
+// defaultRole 获取配置的默认角色
+func (s *mcpServer) defaultRole() auth.Role {
+	return configDefaultRole(s.config)
+}
+
+// configDefaultRole 获取 mcp.auth.default_role，无效时为 viewer
+func configDefaultRole(cfg *config.MCPConfig) auth.Role {
+	role, err := auth.ParseRole(cfg.Auth.DefaultRole)
+	if err != nil {
+		return auth.RoleViewer
+	}
+	return role
+}
+
+// authorize 检查请求上下文的角色是否满足要求
+func (s *mcpServer) authorize(ctx context.Context, required auth.Role) error {
+	role := auth.RoleFromContext(ctx, s.defaultRole())
+	if !role.Allows(required) {
+		return apperrors.Newf(apperrors.ErrForbidden, "权限不足：需要 %s 角色，当前为 %s", required, role)
+	}
+	return nil
+}
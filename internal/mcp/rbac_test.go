@@ -0,0 +1,89 @@
+package mcp
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"auto-claude-code/internal/auth"
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+func TestAuthorize(t *testing.T) {
+	server := &mcpServer{config: &config.MCPConfig{
+		Auth: config.MCPAuthConfig{DefaultRole: "viewer"},
+		SSE:  config.MCPSSEConfig{Enabled: true, MessagePath: "/message"},
+	}}
+
+	viewer := &auth.Identity{Subject: "v", Scopes: []string{auth.ScopeRead}}
+	submitter := &auth.Identity{Subject: "s", Scopes: []string{auth.ScopeRead, auth.ScopeSubmit}}
+	admin := &auth.Identity{Subject: "a", Roles: []string{"admin"}}
+	noRole := &auth.Identity{Subject: "n"}
+
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		method   string
+		path     string
+		allowed  bool
+	}{
+		{"查看者可以查询任务", viewer, "GET", "/tasks", true},
+		{"查看者不能提交任务", viewer, "POST", "/tasks", false},
+		{"查看者可以发送MCP请求", viewer, "POST", "/mcp", true},
+		{"查看者可以发送SSE消息", viewer, "POST", "/message", true},
+		{"提交者可以提交任务", submitter, "POST", "/tasks", true},
+		{"提交者可以取消任务", submitter, "DELETE", "/tasks/t1", true},
+		{"查看者不能连接交互式会话", viewer, "GET", "/tasks/t1/attach", false},
+		{"提交者可以连接交互式会话", submitter, "GET", "/tasks/t1/attach", true},
+		{"查看者不能合并任务", viewer, "POST", "/tasks/t1/merge", false},
+		{"提交者可以合并任务", submitter, "POST", "/tasks/t1/merge", true},
+		{"提交者不能删除worktree", submitter, "DELETE", "/worktrees/w1", false},
+		{"管理员可以删除worktree", admin, "DELETE", "/worktrees/w1", true},
+		{"提交者不能固定worktree", submitter, "PATCH", "/worktrees/w1", false},
+		{"管理员可以固定worktree", admin, "PATCH", "/worktrees/w1", true},
+		{"提交者不能管理令牌", submitter, "GET", "/auth/tokens", false},
+		{"提交者不能查询审计日志", submitter, "GET", "/audit", false},
+		{"管理员可以查询审计日志", admin, "GET", "/audit", true},
+		{"无角色身份使用默认角色", noRole, "GET", "/worktrees", true},
+		{"无角色身份不能提交任务", noRole, "POST", "/tasks", false},
+		{"未认证请求视为管理员", nil, "DELETE", "/worktrees/w1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			ctx := context.Background()
+			if tt.identity != nil {
+				ctx = auth.WithIdentity(ctx, tt.identity)
+			}
+
+			err := server.authorize(ctx, server.restRole(r))
+			if tt.allowed && err != nil {
+				t.Errorf("应允许访问: %v", err)
+			}
+			if !tt.allowed && !apperrors.IsCode(err, apperrors.ErrForbidden) {
+				t.Errorf("应拒绝访问，实际: %v", err)
+			}
+		})
+	}
+}
+
+func TestMethodRole(t *testing.T) {
+	tests := []struct {
+		req  *JSONRPCRequest
+		want auth.Role
+	}{
+		{&JSONRPCRequest{Method: "tools/list"}, auth.RoleViewer},
+		{&JSONRPCRequest{Method: "resources/read"}, auth.RoleViewer},
+		{&JSONRPCRequest{Method: "tools/call", Params: map[string]interface{}{"name": "list_tasks"}}, auth.RoleViewer},
+		{&JSONRPCRequest{Method: "tools/call", Params: map[string]interface{}{"name": "execute_claude_code"}}, auth.RoleSubmitter},
+		{&JSONRPCRequest{Method: "tools/call", Params: map[string]interface{}{"name": "run_shell_command"}}, auth.RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		if got := methodRole(tt.req); got != tt.want {
+			t.Errorf("methodRole(%s %v) = %s，期望 %s", tt.req.Method, tt.req.Params, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package mcp
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+)
+
+// Reload 应用修改后的配置中可在运行时生效的部分：清理间隔和空闲worktree清理策略、认证令牌文件、run_shell_command 策略、Webhook 目标
+// 运行中的任务不受影响；其余修改需要重启服务器才能生效，只记录警告。cfg 已由 config 包校验
+// run_shell_command 启用状态变化时客户端可见的工具列表随之变化，广播 tools/list_changed
+// 返回已应用且有修改的配置项，供调用方记录审计日志
+func (s *mcpServer) Reload(cfg *config.MCPConfig) []string {
+	s.configMutex.RLock()
+	applied := appliedFields(s.config, cfg)
+	s.configMutex.RUnlock()
+
+	if wm := s.reloadableWorktreeManager(); wm != nil {
+		wm.reloadCleanup(cfg)
+	}
+	if tm := s.reloadableTaskManager(); tm != nil {
+		tm.reloadShellTool(cfg.ShellTool)
+	}
+
+	added, removed := s.webhooks.update(cfg.Webhooks)
+
+	s.configMutex.Lock()
+	toolsChanged := s.config.ShellTool.Enabled != cfg.ShellTool.Enabled
+	s.config.Auth.TokenFile = cfg.Auth.TokenFile
+	s.config.ShellTool = cfg.ShellTool
+	s.config.Webhooks = cfg.Webhooks
+	s.configMutex.Unlock()
+
+	if toolsChanged && s.notifier != nil {
+		s.notifier.ToolsListChanged()
+	}
+
+	s.logger.Info("已重新加载配置",
+		zap.String("cleanupInterval", cfg.CleanupInterval),
+		zap.Any("worktreeCleanup", cfg.WorktreeCleanup),
+		zap.Int("webhooks", len(cfg.Webhooks)),
+		zap.Int("webhooksAdded", added),
+		zap.Int("webhooksRemoved", removed),
+		zap.Strings("changed", applied))
+
+	if fields := restartRequiredFields(s.config, cfg); len(fields) > 0 {
+		s.logger.Warn("以下配置的修改需要重启服务器才能生效", zap.Strings("fields", fields))
+	}
+	return applied
+}
+
+// appliedFields 比较当前配置与新配置中可在运行时应用的部分，返回有修改的配置项
+func appliedFields(current, next *config.MCPConfig) []string {
+	var fields []string
+	for _, field := range []struct {
+		name string
+		a, b interface{}
+	}{
+		{"mcp.cleanup_interval", current.CleanupInterval, next.CleanupInterval},
+		{"mcp.worktree_cleanup", current.WorktreeCleanup, next.WorktreeCleanup},
+		{"mcp.auth.token_file", current.Auth.TokenFile, next.Auth.TokenFile},
+		{"mcp.shell_tool", current.ShellTool, next.ShellTool},
+		{"mcp.webhooks", current.Webhooks, next.Webhooks},
+	} {
+		if !reflect.DeepEqual(field.a, field.b) {
+			fields = append(fields, field.name)
+		}
+	}
+	return fields
+}
+
+// reloadableWorktreeManager 获取审计包装下的worktree管理器实现
+func (s *mcpServer) reloadableWorktreeManager() *worktreeManager {
+	manager := s.worktreeManager
+	if audited, ok := manager.(*auditedWorktreeManager); ok {
+		manager = audited.WorktreeManager
+	}
+	wm, _ := manager.(*worktreeManager)
+	return wm
+}
+
+// reloadableTaskManager 获取审计包装下的任务管理器实现
+func (s *mcpServer) reloadableTaskManager() *taskManager {
+	manager := s.taskManager
+	if audited, ok := manager.(*auditedTaskManager); ok {
+		manager = audited.TaskManager
+	}
+	tm, _ := manager.(*taskManager)
+	return tm
+}
+
+// reloadCleanup 应用新的清理间隔和空闲worktree清理策略，间隔无效时保持原来的计时器
+func (wm *worktreeManager) reloadCleanup(cfg *config.MCPConfig) {
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+
+	wm.config.WorktreeCleanup = cfg.WorktreeCleanup
+	interval, err := time.ParseDuration(cfg.CleanupInterval)
+	if err != nil || interval <= 0 {
+		return
+	}
+	wm.config.CleanupInterval = cfg.CleanupInterval
+	switch {
+	case wm.cleaner != nil:
+		wm.cleaner.Reset(interval)
+	case wm.ctx != nil && wm.ctx.Err() == nil:
+		// 启动时间隔无效，未启动清理器
+		wm.startCleaner(interval)
+	}
+}
+
+// restartRequiredFields 比较当前配置与新配置中无法在运行时应用的部分，返回有修改的配置项
+func restartRequiredFields(current, next *config.MCPConfig) []string {
+	a, b := *current, *next
+	// 可在运行时应用的配置和运行时设置的字段不参与比较
+	b.CleanupInterval = a.CleanupInterval
+	b.WorktreeCleanup = a.WorktreeCleanup
+	b.Auth.TokenFile = a.Auth.TokenFile
+	b.ShellTool = a.ShellTool
+	b.Webhooks = a.Webhooks
+	b.Stdio.Reader, b.Stdio.Writer = a.Stdio.Reader, a.Stdio.Writer
+
+	var fields []string
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	for i := 0; i < va.NumField(); i++ {
+		if reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			continue
+		}
+		name := strings.Split(va.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		fields = append(fields, "mcp."+name)
+	}
+	return fields
+}
@@ -0,0 +1,119 @@
+package mcp
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/logger"
+)
+
+func TestServerReload(t *testing.T) {
+	cfg := &config.MCPConfig{
+		Port:            8080,
+		WorktreeBaseDir: t.TempDir(),
+		MaxWorktrees:    5,
+		CleanupInterval: "1h",
+		WorktreeCleanup: config.WorktreeCleanupConfig{IdleTTL: "2h", Eviction: "lru"},
+		Auth:            config.MCPAuthConfig{Method: "token", TokenFile: "old.txt"},
+		Webhooks: []config.WebhookConfig{
+			{URL: "http://127.0.0.1:1/kept"},
+			{URL: "http://127.0.0.1:1/removed"},
+		},
+	}
+	log := logger.FromZap(zap.NewNop())
+	wm := NewWorktreeManager(cfg, log, nil).(*worktreeManager)
+	if err := wm.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer wm.Stop(context.Background())
+	webhooks := newWebhookDispatcher(cfg.Webhooks, log)
+	defer webhooks.stop(context.Background())
+	kept := webhooks.targets[0]
+
+	tm := newQueueTestManager()
+	tm.shellPolicy.Store(newShellPolicy(cfg.ShellTool))
+	notifier := NewNotificationDispatcher(log)
+	var notifications []string
+	notifier.RegisterSession("a", func(msg interface{}) {
+		notifications = append(notifications, msg.(*JSONRPCNotification).Method)
+	})
+
+	server := &mcpServer{
+		config:          cfg,
+		logger:          log,
+		taskManager:     &auditedTaskManager{TaskManager: tm},
+		worktreeManager: &auditedWorktreeManager{WorktreeManager: wm},
+		webhooks:        webhooks,
+		notifier:        notifier,
+	}
+	next := *cfg
+	next.CleanupInterval = "5m"
+	next.WorktreeCleanup = config.WorktreeCleanupConfig{IdleTTL: "30m", Eviction: "none"}
+	next.Auth.TokenFile = "new.txt"
+	next.ShellTool = config.ShellToolConfig{Enabled: true, Allowlist: []string{"go"}}
+	next.Webhooks = []config.WebhookConfig{
+		{URL: "http://127.0.0.1:1/kept"},
+		{URL: "http://127.0.0.1:1/added"},
+	}
+	fields := server.Reload(&next)
+	want := []string{"mcp.cleanup_interval", "mcp.worktree_cleanup", "mcp.auth.token_file", "mcp.shell_tool", "mcp.webhooks"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Reload() = %v, want %v", fields, want)
+	}
+
+	if wm.config.CleanupInterval != "5m" || wm.idleTTL() != 30*time.Minute || wm.config.WorktreeCleanup.Eviction != "none" {
+		t.Errorf("清理配置 = %s %+v", wm.config.CleanupInterval, wm.config.WorktreeCleanup)
+	}
+	if got := server.authTokenFile(); got != "new.txt" {
+		t.Errorf("authTokenFile() = %q", got)
+	}
+	if !tm.ShellToolEnabled() || tm.shellPolicy.Load().Check("npm test") == nil {
+		t.Error("run_shell_command 策略未更新")
+	}
+	if !reflect.DeepEqual(notifications, []string{NotificationToolsListChanged}) {
+		t.Errorf("通知 = %v", notifications)
+	}
+
+	// 启用状态不变时不通知
+	next.ShellTool.Allowlist = nil
+	if fields := server.Reload(&next); !reflect.DeepEqual(fields, []string{"mcp.shell_tool"}) {
+		t.Errorf("Reload() = %v", fields)
+	}
+	if len(notifications) != 1 {
+		t.Errorf("通知 = %v", notifications)
+	}
+
+	var urls []string
+	for _, target := range webhooks.targets {
+		urls = append(urls, target.url)
+	}
+	if !reflect.DeepEqual(urls, []string{"http://127.0.0.1:1/kept", "http://127.0.0.1:1/added"}) {
+		t.Errorf("Webhook目标 = %v", urls)
+	}
+	if webhooks.targets[0] != kept {
+		t.Error("配置未变的Webhook目标被重新创建")
+	}
+}
+
+func TestRestartRequiredFields(t *testing.T) {
+	current := &config.MCPConfig{Port: 8080, CleanupInterval: "1h", Auth: config.MCPAuthConfig{TokenFile: "a"}}
+
+	next := *current
+	next.CleanupInterval = "5m"
+	next.Auth.TokenFile = "b"
+	next.ShellTool.Enabled = true
+	if fields := restartRequiredFields(current, &next); len(fields) != 0 {
+		t.Errorf("可在运行时应用的修改 = %v", fields)
+	}
+
+	next.Port = 9090
+	next.Auth.Method = "jwt"
+	if fields := restartRequiredFields(current, &next); !reflect.DeepEqual(fields, []string{"mcp.port", "mcp.auth"}) {
+		t.Errorf("需要重启的修改 = %v", fields)
+	}
+}
@@ -0,0 +1,236 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// 资源URI前缀
+const (
+	taskResourceScheme     = "task://"
+	worktreeResourceScheme = "worktree://"
+
+	// distrosResourceURI 可用 WSL 发行版列表
+	distrosResourceURI = "wsl://distros"
+)
+
+// Resource MCP资源定义
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceTemplate MCP资源模板
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents 资源内容
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+// ReadResourceRequest 读取资源请求
+type ReadResourceRequest struct {
+	URI string `json:"uri"`
+}
+
+// ReadResourceResult 读取资源结果
+type ReadResourceResult struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
+// SubscribeResourceRequest 订阅/取消订阅资源请求
+type SubscribeResourceRequest struct {
+	URI string `json:"uri"`
+}
+
+// ResourceUpdatedParams notifications/resources/updated 参数
+type ResourceUpdatedParams struct {
+	URI string `json:"uri"`
+}
+
+// taskResourceURI 任务记录资源URI
+func taskResourceURI(taskID string) string {
+	return taskResourceScheme + taskID
+}
+
+// taskLogResourceURI 任务日志资源URI
+func taskLogResourceURI(taskID string) string {
+	return taskResourceScheme + taskID + "/log"
+}
+
+// worktreeDiffResourceURI worktree diff资源URI
+func worktreeDiffResourceURI(worktreeID string) string {
+	return worktreeResourceScheme + worktreeID + "/diff"
+}
+
+// resourceTemplates 可用的资源模板
+var resourceTemplates = []ResourceTemplate{
+	{
+		URITemplate: taskResourceScheme + "{taskId}",
+		Name:        "任务记录",
+		Description: "任务的状态、进度和执行结果",
+		MimeType:    "application/json",
+	},
+	{
+		URITemplate: taskResourceScheme + "{taskId}/log",
+		Name:        "任务日志",
+		Description: "Claude Code 执行期间捕获的输出，可订阅以跟踪实时输出",
+		MimeType:    "text/plain",
+	},
+	{
+		URITemplate: worktreeResourceScheme + "{worktreeId}/diff",
+		Name:        "Worktree diff",
+		Description: "worktree 相对创建时基准提交的统一 diff",
+		MimeType:    "text/x-diff",
+	},
+}
+
+// ListResources 列出任务记录、任务日志和worktree diff资源
+func (h *protocolHandler) ListResources(ctx context.Context) ([]Resource, error) {
+	tasks, err := h.taskManager.ListTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	worktrees, err := h.worktreeManager.ListWorktrees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]Resource, 0, len(tasks)*2+len(worktrees)+1)
+	resources = append(resources, Resource{
+		URI:         distrosResourceURI,
+		Name:        "WSL 发行版",
+		Description: "可用于执行任务的 WSL 发行版及默认发行版",
+		MimeType:    "application/json",
+	})
+	for _, task := range tasks {
+		resources = append(resources,
+			Resource{
+				URI:         taskResourceURI(task.ID),
+				Name:        fmt.Sprintf("任务 %s", task.ID),
+				Description: fmt.Sprintf("状态: %s", task.Status),
+				MimeType:    "application/json",
+			},
+			Resource{
+				URI:      taskLogResourceURI(task.ID),
+				Name:     fmt.Sprintf("任务 %s 日志", task.ID),
+				MimeType: "text/plain",
+			},
+		)
+	}
+	for _, worktree := range worktrees {
+		resources = append(resources, Resource{
+			URI:         worktreeDiffResourceURI(worktree.ID),
+			Name:        fmt.Sprintf("Worktree %s diff", worktree.ID),
+			Description: worktree.ProjectPath,
+			MimeType:    "text/x-diff",
+		})
+	}
+
+	return resources, nil
+}
+
+// ListResourceTemplates 列出资源模板
+func (h *protocolHandler) ListResourceTemplates(ctx context.Context) ([]ResourceTemplate, error) {
+	return resourceTemplates, nil
+}
+
+// ReadResource 读取资源内容
+func (h *protocolHandler) ReadResource(ctx context.Context, uri string) (*ReadResourceResult, error) {
+	var contents ResourceContents
+
+	switch {
+	case uri == distrosResourceURI:
+		distros, err := h.taskManager.ListDistros(ctx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.MarshalIndent(distros, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		contents = ResourceContents{URI: uri, MimeType: "application/json", Text: string(data)}
+
+	case strings.HasPrefix(uri, taskResourceScheme):
+		taskID, sub, _ := strings.Cut(strings.TrimPrefix(uri, taskResourceScheme), "/")
+		switch sub {
+		case "":
+			status, err := h.taskManager.GetTaskStatus(ctx, taskID)
+			if err != nil {
+				return nil, err
+			}
+			data, err := json.MarshalIndent(status, "", "  ")
+			if err != nil {
+				return nil, err
+			}
+			contents = ResourceContents{URI: uri, MimeType: "application/json", Text: string(data)}
+		case "log":
+			output, err := h.taskManager.GetTaskOutput(ctx, taskID)
+			if err != nil {
+				return nil, err
+			}
+			contents = ResourceContents{URI: uri, MimeType: "text/plain", Text: output}
+		default:
+			return nil, apperrors.Newf(apperrors.ErrResourceNotFound, "资源不存在: %s", uri)
+		}
+
+	case strings.HasPrefix(uri, worktreeResourceScheme):
+		worktreeID, sub, _ := strings.Cut(strings.TrimPrefix(uri, worktreeResourceScheme), "/")
+		if sub != "diff" {
+			return nil, apperrors.Newf(apperrors.ErrResourceNotFound, "资源不存在: %s", uri)
+		}
+		diff, err := h.worktreeManager.GetWorktreeDiff(ctx, worktreeID)
+		if err != nil {
+			return nil, err
+		}
+		contents = ResourceContents{URI: uri, MimeType: "text/x-diff", Text: diff}
+
+	default:
+		return nil, apperrors.Newf(apperrors.ErrResourceNotFound, "不支持的资源URI: %s", uri)
+	}
+
+	return &ReadResourceResult{Contents: []ResourceContents{contents}}, nil
+}
+
+// SubscribeResource 订阅资源更新，仅支持可推送通知的会话（stdio、SSE）
+func (h *protocolHandler) SubscribeResource(ctx context.Context, uri string) error {
+	sessionID := sessionFromContext(ctx)
+	if h.notifier == nil || sessionID == "" {
+		return apperrors.New(apperrors.ErrMCPProtocolError, "当前传输不支持资源订阅，请使用 stdio 或 SSE 传输")
+	}
+	if !strings.HasPrefix(uri, taskResourceScheme) {
+		return apperrors.Newf(apperrors.ErrResourceNotFound, "资源不支持订阅: %s", uri)
+	}
+
+	h.notifier.Subscribe(sessionID, uri)
+	return nil
+}
+
+// UnsubscribeResource 取消订阅资源更新
+func (h *protocolHandler) UnsubscribeResource(ctx context.Context, uri string) error {
+	if sessionID := sessionFromContext(ctx); h.notifier != nil && sessionID != "" {
+		h.notifier.Unsubscribe(sessionID, uri)
+	}
+	return nil
+}
+
+// isResourceNotFound 是否为资源不存在错误
+func isResourceNotFound(err error) bool {
+	return apperrors.IsCode(err, apperrors.ErrResourceNotFound) ||
+		apperrors.IsCode(err, apperrors.ErrTaskNotFound) ||
+		apperrors.IsCode(err, apperrors.ErrWorktreeNotFound)
+}
@@ -0,0 +1,211 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+const (
+	// defaultRetentionMaxAge 未配置 mcp.retention.max_age 时已结束任务的保留时间
+	defaultRetentionMaxAge = 24 * time.Hour
+	// defaultRetentionCheckInterval 未配置 mcp.retention.check_interval 时的清理间隔
+	defaultRetentionCheckInterval = time.Hour
+	// archiveWatermarkFile 归档目录下记录定期归档进度的文件，内容为已归档任务的最晚结束时间
+	archiveWatermarkFile = ".archived-until"
+)
+
+// retentionPolicy 解析后的已结束任务保留策略
+type retentionPolicy struct {
+	maxAge       time.Duration
+	failedMaxAge time.Duration // 失败和中断任务的保留时间
+	maxCount     int           // 0 表示不限制
+}
+
+// newRetentionPolicy 解析保留策略配置，无效的时长使用默认值
+func newRetentionPolicy(cfg config.RetentionConfig) retentionPolicy {
+	policy := retentionPolicy{
+		maxAge:   parseDurationOr(cfg.MaxAge, defaultRetentionMaxAge),
+		maxCount: cfg.MaxCount,
+	}
+	policy.failedMaxAge = parseDurationOr(cfg.FailedMaxAge, policy.maxAge)
+	return policy
+}
+
+// isFailedStatus 检查任务是否以失败、中断或超时结束，这些任务按 failed_max_age 保留
+func isFailedStatus(status string) bool {
+	return status == "failed" || status == "interrupted" || status == "timeout"
+}
+
+// expired 从已结束的任务中选出需要清理的任务，按结束时间从早到晚返回
+// 先按保留时间筛选，再在剩余任务中只保留最近结束的 maxCount 个
+func (p retentionPolicy) expired(finished []*TaskStatus, now time.Time) []*TaskStatus {
+	sorted := make([]*TaskStatus, len(finished))
+	copy(sorted, finished)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].EndTime.After(sorted[j].EndTime)
+	})
+
+	var expired []*TaskStatus
+	kept := 0
+	for _, status := range sorted {
+		maxAge := p.maxAge
+		if isFailedStatus(status.Status) {
+			maxAge = p.failedMaxAge
+		}
+		if now.Sub(status.EndTime) > maxAge || (p.maxCount > 0 && kept >= p.maxCount) {
+			expired = append(expired, status)
+			continue
+		}
+		kept++
+	}
+
+	// 反转为从早到晚，归档文件按结束顺序追加
+	for i, j := 0, len(expired)-1; i < j; i, j = i+1, j-1 {
+		expired[i], expired[j] = expired[j], expired[i]
+	}
+	return expired
+}
+
+// ArchivedTask 归档文件和任务导出中的一行
+type ArchivedTask struct {
+	Task       *TaskStatus `json:"task"`
+	OutputRef  string      `json:"outputRef,omitempty"` // 已持久化输出的引用，可通过存储读取完整输出
+	Output     string      `json:"output,omitempty"`
+	ArchivedAt time.Time   `json:"archivedAt"`
+}
+
+// archiveTasks 将任务追加写入归档目录下按月分割的 tasks-YYYY-MM.jsonl 文件
+func (tm *taskManager) archiveTasks(ctx context.Context, tasks []*TaskStatus, now time.Time) error {
+	dir := tm.config.Retention.ArchivePath()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrInternal, "无法创建归档目录: %s", dir)
+	}
+
+	path := filepath.Join(dir, "tasks-"+now.Format("2006-01")+".jsonl")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrInternal, "无法打开归档文件: %s", path)
+	}
+
+	encoder := json.NewEncoder(file)
+	for _, status := range tasks {
+		entry := ArchivedTask{Task: status, OutputRef: tm.outputRef(status.ID), ArchivedAt: now}
+		if tm.config.Retention.ArchiveOutput {
+			entry.Output, err = tm.GetTaskOutput(ctx, status.ID)
+			if err != nil {
+				tm.logger.Warn("读取归档任务输出失败", zap.String("taskId", status.ID), zap.Error(err))
+			}
+		}
+		if err := encoder.Encode(entry); err != nil {
+			file.Close()
+			return apperrors.Wrapf(err, apperrors.ErrInternal, "写入归档文件失败: %s", path)
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrInternal, "写入归档文件失败: %s", path)
+	}
+	return nil
+}
+
+// outputRef 获取任务已持久化输出的引用，未持久化时为空
+func (tm *taskManager) outputRef(taskID string) string {
+	tm.outputsMutex.RLock()
+	defer tm.outputsMutex.RUnlock()
+	return tm.outputRefs[taskID]
+}
+
+// finishedTasks 获取 after 之后结束且 ctx 的身份可访问的任务快照，按结束时间从早到晚排序
+func (tm *taskManager) finishedTasks(ctx context.Context, after time.Time) []*TaskStatus {
+	tm.tasksMutex.RLock()
+	var finished []*TaskStatus
+	for _, status := range tm.tasks {
+		if isTerminalStatus(status.Status) && status.EndTime.After(after) && tm.canAccessTask(ctx, status) {
+			snapshot := *status
+			finished = append(finished, &snapshot)
+		}
+	}
+	tm.tasksMutex.RUnlock()
+
+	sort.SliceStable(finished, func(i, j int) bool {
+		return finished[i].EndTime.Before(finished[j].EndTime)
+	})
+	return finished
+}
+
+// archiveWatermark 读取定期归档的进度，尚未归档过时返回零值
+func (tm *taskManager) archiveWatermark() (time.Time, error) {
+	path := filepath.Join(tm.config.Retention.ArchivePath(), archiveWatermarkFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, apperrors.Wrapf(err, apperrors.ErrInternal, "无法读取归档进度: %s", path)
+	}
+	t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+	if err != nil {
+		return time.Time{}, apperrors.Wrapf(err, apperrors.ErrInternal, "无效的归档进度: %s", path)
+	}
+	return t, nil
+}
+
+// archiveFinishedTasks 将上次定期归档之后结束的任务追加写入归档文件并推进归档进度
+func (tm *taskManager) archiveFinishedTasks() {
+	ctx := context.Background()
+
+	watermark, err := tm.archiveWatermark()
+	if err != nil {
+		tm.logger.Warn("跳过本次定期归档", zap.Error(err))
+		return
+	}
+	finished := tm.finishedTasks(ctx, watermark)
+	if len(finished) == 0 {
+		return
+	}
+
+	if err := tm.archiveTasks(ctx, finished, time.Now()); err != nil {
+		tm.logger.Warn("定期归档任务失败", zap.Int("count", len(finished)), zap.Error(err))
+		return
+	}
+
+	path := filepath.Join(tm.config.Retention.ArchivePath(), archiveWatermarkFile)
+	until := finished[len(finished)-1].EndTime.Format(time.RFC3339Nano)
+	if err := os.WriteFile(path, []byte(until+"\n"), 0600); err != nil {
+		tm.logger.Warn("保存归档进度失败", zap.String("path", path), zap.Error(err))
+		return
+	}
+	tm.logger.Info("定期归档已结束的任务", zap.Int("count", len(finished)))
+}
+
+// archiveExpiredTasks 归档即将清理的任务，启用定期归档时跳过已定期归档过的任务
+func (tm *taskManager) archiveExpiredTasks(ctx context.Context, expired []*TaskStatus, now time.Time) error {
+	if !tm.periodicArchive() {
+		return tm.archiveTasks(ctx, expired, now)
+	}
+
+	watermark, err := tm.archiveWatermark()
+	if err != nil {
+		return err
+	}
+	var pending []*TaskStatus
+	for _, status := range expired {
+		if status.EndTime.After(watermark) {
+			pending = append(pending, status)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	return tm.archiveTasks(ctx, pending, now)
+}
@@ -0,0 +1,172 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/logger"
+)
+
+func TestRetentionPolicyExpired(t *testing.T) {
+	now := time.Now()
+	finished := []*TaskStatus{
+		{ID: "new", Status: "completed", EndTime: now.Add(-time.Hour)},
+		{ID: "old", Status: "completed", EndTime: now.Add(-30 * time.Hour)},
+		{ID: "failed", Status: "failed", EndTime: now.Add(-30 * time.Hour)},
+		{ID: "stale", Status: "interrupted", EndTime: now.Add(-100 * time.Hour)},
+		{ID: "recent", Status: "cancelled", EndTime: now.Add(-2 * time.Hour)},
+	}
+
+	tests := []struct {
+		name string
+		cfg  config.RetentionConfig
+		want string
+	}{
+		{"默认24小时", config.RetentionConfig{}, "stale,failed,old"},
+		{"失败任务保留更久", config.RetentionConfig{MaxAge: "24h", FailedMaxAge: "72h"}, "stale,old"},
+		{"数量上限", config.RetentionConfig{MaxAge: "1000h", MaxCount: 2}, "stale,failed,old"},
+		{"时间和数量", config.RetentionConfig{MaxAge: "24h", FailedMaxAge: "72h", MaxCount: 1}, "stale,failed,old,recent"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			for _, status := range newRetentionPolicy(tt.cfg).expired(finished, now) {
+				ids = append(ids, status.ID)
+			}
+			// 同一结束时间的任务顺序不固定
+			got := strings.Join(ids, ",")
+			if got != tt.want && strings.ReplaceAll(got, "old,failed", "failed,old") != tt.want {
+				t.Errorf("expired() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanupCompletedTasksArchive(t *testing.T) {
+	ctx := context.Background()
+	archiveDir := t.TempDir()
+	store := newFileTaskStore(t.TempDir(), logger.FromZap(zap.NewNop()))
+	store.LoadTasks(ctx)
+
+	now := time.Now()
+	tasks := map[string]*TaskStatus{
+		"old":     {ID: "old", Status: "completed", EndTime: now.Add(-48 * time.Hour)},
+		"new":     {ID: "new", Status: "completed", EndTime: now},
+		"running": {ID: "running", Status: "running"},
+	}
+	for _, status := range tasks {
+		store.SaveTask(ctx, &TaskRecord{Status: status})
+	}
+
+	tm := &taskManager{
+		config: &config.MCPConfig{Retention: config.RetentionConfig{
+			MaxAge: "24h", Archive: true, ArchiveDir: archiveDir, ArchiveOutput: true,
+		}},
+		logger:     logger.FromZap(zap.NewNop()),
+		store:      store,
+		tasks:      tasks,
+		outputs:    map[string]*taskOutput{"old": {}},
+		outputRefs: make(map[string]string),
+	}
+	tm.outputs["old"].append("done")
+	tm.cleanupCompletedTasks()
+
+	if _, exists := tm.tasks["old"]; exists || len(tm.tasks) != 2 {
+		t.Errorf("清理后的任务 = %v", tm.tasks)
+	}
+	if records, _ := store.LoadTasks(ctx); len(records) != 2 {
+		t.Errorf("存储中剩余 %d 条记录, want 2", len(records))
+	}
+
+	file, err := os.Open(filepath.Join(archiveDir, "tasks-"+now.Format("2006-01")+".jsonl"))
+	if err != nil {
+		t.Fatalf("归档文件不存在: %v", err)
+	}
+	defer file.Close()
+	var archived []ArchivedTask
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry ArchivedTask
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("归档行无效: %v", err)
+		}
+		archived = append(archived, entry)
+	}
+	if len(archived) != 1 || archived[0].Task.ID != "old" || !strings.Contains(archived[0].Output, "done") {
+		t.Errorf("归档内容 = %+v", archived)
+	}
+
+	// 归档失败时不删除任务
+	tm.config.Retention.ArchiveDir = filepath.Join(archiveDir, "tasks-"+now.Format("2006-01")+".jsonl", "sub")
+	tm.tasks["new"].EndTime = now.Add(-48 * time.Hour)
+	tm.cleanupCompletedTasks()
+	if _, exists := tm.tasks["new"]; !exists {
+		t.Error("归档失败后任务不应被删除")
+	}
+}
+
+func TestArchiveFinishedTasks(t *testing.T) {
+	archiveDir := t.TempDir()
+	now := time.Now()
+	tm := &taskManager{
+		config: &config.MCPConfig{Retention: config.RetentionConfig{
+			MaxAge: "24h", Archive: true, ArchiveDir: archiveDir, ArchiveInterval: "15m",
+		}},
+		logger: logger.FromZap(zap.NewNop()),
+		tasks: map[string]*TaskStatus{
+			"old":     {ID: "old", Status: "completed", EndTime: now.Add(-48 * time.Hour)},
+			"new":     {ID: "new", Status: "failed", EndTime: now.Add(-time.Hour)},
+			"running": {ID: "running", Status: "running"},
+		},
+		outputs:    make(map[string]*taskOutput),
+		outputRefs: map[string]string{"new": "outputs/new.log"},
+	}
+
+	readArchive := func() []ArchivedTask {
+		data, err := os.ReadFile(filepath.Join(archiveDir, "tasks-"+time.Now().Format("2006-01")+".jsonl"))
+		if err != nil {
+			t.Fatalf("归档文件不存在: %v", err)
+		}
+		var archived []ArchivedTask
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var entry ArchivedTask
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatalf("归档行无效: %v", err)
+			}
+			archived = append(archived, entry)
+		}
+		return archived
+	}
+
+	tm.archiveFinishedTasks()
+	archived := readArchive()
+	if len(archived) != 2 || archived[0].Task.ID != "old" || archived[1].Task.ID != "new" || archived[1].OutputRef != "outputs/new.log" {
+		t.Fatalf("归档内容 = %+v", archived)
+	}
+	if watermark, err := tm.archiveWatermark(); err != nil || !watermark.Equal(now.Add(-time.Hour)) {
+		t.Errorf("archiveWatermark() = %v, error = %v", watermark, err)
+	}
+
+	// 再次归档只写入新结束的任务，清理时不重复归档已定期归档的任务
+	tm.tasks["running"].Status = "completed"
+	tm.tasks["running"].EndTime = now
+	tm.archiveFinishedTasks()
+	tm.cleanupCompletedTasks()
+	archived = readArchive()
+	if len(archived) != 3 || archived[2].Task.ID != "running" {
+		t.Errorf("归档内容 = %+v", archived)
+	}
+	if _, exists := tm.tasks["old"]; exists {
+		t.Error("过期任务未被清理")
+	}
+}
@@ -0,0 +1,260 @@
+package mcp
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// throughputWindow 吞吐量的统计窗口
+const throughputWindow = time.Hour
+
+// 等待时间和执行时间直方图的桶上界
+var (
+	queueWaitBuckets = []time.Duration{time.Second, 5 * time.Second, 15 * time.Second, 30 * time.Second,
+		time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour}
+	executionBuckets = []time.Duration{10 * time.Second, 30 * time.Second, time.Minute, 5 * time.Minute,
+		15 * time.Minute, 30 * time.Minute, time.Hour, 2 * time.Hour}
+)
+
+// SchedulingMetrics 任务调度指标，进程启动后累计
+type SchedulingMetrics struct {
+	QueueWait   DurationHistogram `json:"queueWait"`   // 任务入队到开始执行的等待时间
+	Execution   DurationHistogram `json:"execution"`   // 每次执行的耗时，包括重试前失败的执行
+	Completed   int64             `json:"completed"`   // 执行成功结束的任务数
+	Failed      int64             `json:"failed"`      // 执行失败或超时结束的任务数
+	FailureRate float64           `json:"failureRate"` // failed / (completed + failed)
+	Throughput  int               `json:"throughput"`  // 最近一小时执行结束的任务数
+	Projects    []ProjectMetrics  `json:"projects"`
+}
+
+// ProjectMetrics 单个项目的调度指标
+type ProjectMetrics struct {
+	Project     string            `json:"project"`
+	QueueWait   DurationHistogram `json:"queueWait"`
+	Execution   DurationHistogram `json:"execution"`
+	Completed   int64             `json:"completed"`
+	Failed      int64             `json:"failed"`
+	FailureRate float64           `json:"failureRate"`
+	Throughput  int               `json:"throughput"`
+}
+
+// DurationHistogram 耗时直方图，桶的计数为累计值
+type DurationHistogram struct {
+	Count   int64             `json:"count"`
+	Sum     string            `json:"sum"`
+	Mean    string            `json:"mean,omitempty"`
+	P50     string            `json:"p50,omitempty"` // 按桶上界估算，超过最大桶时为 ">上界"
+	P95     string            `json:"p95,omitempty"`
+	Buckets []HistogramBucket `json:"buckets"`
+}
+
+// HistogramBucket 直方图的一个桶，Count 为耗时不超过 LE 的次数
+type HistogramBucket struct {
+	LE    string `json:"le"` // 最后一个桶为 "+Inf"
+	Count int64  `json:"count"`
+}
+
+// durationHistogram 按固定桶统计耗时
+type durationHistogram struct {
+	bounds []time.Duration
+	counts []int64 // 比 bounds 多一个桶，记录超过最大上界的次数
+	count  int64
+	sum    time.Duration
+}
+
+// schedulerMetrics 汇总和按项目统计的调度指标，nil 时所有方法不做任何事
+type schedulerMetrics struct {
+	mutex    sync.Mutex
+	now      func() time.Time
+	total    *outcomeMetrics
+	projects map[string]*outcomeMetrics // 按 projectKey 统计
+}
+
+// outcomeMetrics 一组任务的等待时间、执行时间和执行结果
+type outcomeMetrics struct {
+	project   string
+	queueWait *durationHistogram
+	execution *durationHistogram
+	completed int64
+	failed    int64
+	finished  []time.Time // 最近 throughputWindow 内执行结束的时间
+}
+
+// newSchedulerMetrics 创建调度指标
+func newSchedulerMetrics() *schedulerMetrics {
+	return &schedulerMetrics{
+		now:      time.Now,
+		total:    newOutcomeMetrics(""),
+		projects: make(map[string]*outcomeMetrics),
+	}
+}
+
+// newOutcomeMetrics 创建项目的调度指标
+func newOutcomeMetrics(project string) *outcomeMetrics {
+	return &outcomeMetrics{
+		project:   project,
+		queueWait: newDurationHistogram(queueWaitBuckets),
+		execution: newDurationHistogram(executionBuckets),
+	}
+}
+
+// newDurationHistogram 创建耗时直方图
+func newDurationHistogram(bounds []time.Duration) *durationHistogram {
+	return &durationHistogram{bounds: bounds, counts: make([]int64, len(bounds)+1)}
+}
+
+// observeWait 记录任务从入队到开始执行的等待时间，未经过队列的任务不记录
+func (m *schedulerMetrics) observeWait(req *TaskRequest, started time.Time) {
+	if m == nil || req.queuedAt.IsZero() {
+		return
+	}
+	wait := started.Sub(req.queuedAt)
+	if wait < 0 {
+		wait = 0
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.total.queueWait.observe(wait)
+	m.project(req.ProjectPath).queueWait.observe(wait)
+}
+
+// observeExecution 记录一次执行的耗时和结果，state 为执行后的任务状态
+// completed 计为成功，failed 和 timeout 计为失败，重新排队和已取消的执行只记录耗时
+func (m *schedulerMetrics) observeExecution(req *TaskRequest, duration time.Duration, state string) {
+	if m == nil {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := m.now()
+	for _, metrics := range []*outcomeMetrics{m.total, m.project(req.ProjectPath)} {
+		metrics.execution.observe(duration)
+		switch state {
+		case "completed":
+			metrics.completed++
+		case "failed", "timeout":
+			metrics.failed++
+		default:
+			continue
+		}
+		metrics.finished = append(metrics.finished, now)
+	}
+}
+
+// snapshot 获取当前的调度指标，项目按路径排序
+func (m *schedulerMetrics) snapshot() SchedulingMetrics {
+	if m == nil {
+		return SchedulingMetrics{
+			QueueWait: newDurationHistogram(queueWaitBuckets).snapshot(),
+			Execution: newDurationHistogram(executionBuckets).snapshot(),
+			Projects:  []ProjectMetrics{},
+		}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	since := m.now().Add(-throughputWindow)
+	total := m.total.snapshot(since)
+	result := SchedulingMetrics{
+		QueueWait:   total.QueueWait,
+		Execution:   total.Execution,
+		Completed:   total.Completed,
+		Failed:      total.Failed,
+		FailureRate: total.FailureRate,
+		Throughput:  total.Throughput,
+		Projects:    make([]ProjectMetrics, 0, len(m.projects)),
+	}
+	for _, project := range m.projects {
+		result.Projects = append(result.Projects, project.snapshot(since))
+	}
+	sort.Slice(result.Projects, func(i, j int) bool { return result.Projects[i].Project < result.Projects[j].Project })
+	return result
+}
+
+// project 获取项目的调度指标，调用方必须持有 mutex
+func (m *schedulerMetrics) project(projectPath string) *outcomeMetrics {
+	key := projectKey(projectPath)
+	metrics, ok := m.projects[key]
+	if !ok {
+		metrics = newOutcomeMetrics(projectPath)
+		m.projects[key] = metrics
+	}
+	return metrics
+}
+
+// snapshot 获取项目的调度指标并丢弃 since 之前的结束时间
+func (o *outcomeMetrics) snapshot(since time.Time) ProjectMetrics {
+	for len(o.finished) > 0 && o.finished[0].Before(since) {
+		o.finished = o.finished[1:]
+	}
+	result := ProjectMetrics{
+		Project:    o.project,
+		QueueWait:  o.queueWait.snapshot(),
+		Execution:  o.execution.snapshot(),
+		Completed:  o.completed,
+		Failed:     o.failed,
+		Throughput: len(o.finished),
+	}
+	if finished := o.completed + o.failed; finished > 0 {
+		result.FailureRate = float64(o.failed) / float64(finished)
+	}
+	return result
+}
+
+// observe 记录一次耗时
+func (h *durationHistogram) observe(d time.Duration) {
+	i := sort.Search(len(h.bounds), func(i int) bool { return d <= h.bounds[i] })
+	h.counts[i]++
+	h.count++
+	h.sum += d
+}
+
+// snapshot 获取直方图的累计桶计数和估算的分位数
+func (h *durationHistogram) snapshot() DurationHistogram {
+	result := DurationHistogram{
+		Count:   h.count,
+		Sum:     h.sum.String(),
+		Buckets: make([]HistogramBucket, 0, len(h.counts)),
+	}
+	var cumulative int64
+	for i, count := range h.counts {
+		cumulative += count
+		le := "+Inf"
+		if i < len(h.bounds) {
+			le = h.bounds[i].String()
+		}
+		result.Buckets = append(result.Buckets, HistogramBucket{LE: le, Count: cumulative})
+	}
+	if h.count > 0 {
+		result.Mean = (h.sum / time.Duration(h.count)).Round(time.Millisecond).String()
+		result.P50 = h.quantile(0.5)
+		result.P95 = h.quantile(0.95)
+	}
+	return result
+}
+
+// quantile 按桶上界估算分位数
+func (h *durationHistogram) quantile(q float64) string {
+	target := int64(q*float64(h.count) + 0.5)
+	if target < 1 {
+		target = 1
+	}
+	var cumulative int64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		if cumulative >= target {
+			return bound.String()
+		}
+	}
+	return ">" + h.bounds[len(h.bounds)-1].String()
+}
+
+// SchedulingMetrics 获取任务调度指标
+func (tm *taskManager) SchedulingMetrics() SchedulingMetrics {
+	return tm.metrics.snapshot()
+}
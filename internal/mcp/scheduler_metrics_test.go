@@ -0,0 +1,80 @@
+package mcp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDurationHistogram(t *testing.T) {
+	h := newDurationHistogram([]time.Duration{time.Second, time.Minute})
+	if got := h.snapshot(); got.Count != 0 || got.P50 != "" || len(got.Buckets) != 3 {
+		t.Fatalf("空直方图 = %+v", got)
+	}
+
+	for _, d := range []time.Duration{500 * time.Millisecond, time.Second, 30 * time.Second, 2 * time.Hour} {
+		h.observe(d)
+	}
+	got := h.snapshot()
+	if got.Count != 4 || got.Sum != "2h0m31.5s" {
+		t.Errorf("Count = %d, Sum = %s", got.Count, got.Sum)
+	}
+	want := []HistogramBucket{{"1s", 2}, {"1m0s", 3}, {"+Inf", 4}}
+	for i, bucket := range want {
+		if got.Buckets[i] != bucket {
+			t.Errorf("Buckets[%d] = %+v, want %+v", i, got.Buckets[i], bucket)
+		}
+	}
+	if got.P50 != "1s" || got.P95 != ">1m0s" {
+		t.Errorf("P50 = %s, P95 = %s", got.P50, got.P95)
+	}
+}
+
+func TestSchedulerMetrics(t *testing.T) {
+	var nilMetrics *schedulerMetrics
+	nilMetrics.observeWait(&TaskRequest{}, time.Now())
+	if got := nilMetrics.snapshot(); got.Projects == nil || len(got.QueueWait.Buckets) == 0 {
+		t.Errorf("nil 指标 snapshot() = %+v", got)
+	}
+
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	m := newSchedulerMetrics()
+	m.now = func() time.Time { return now }
+
+	app := &TaskRequest{ProjectPath: `C:\projects\app`, queuedAt: now.Add(-10 * time.Second)}
+	lib := &TaskRequest{ProjectPath: `C:\projects\lib`, queuedAt: now.Add(-2 * time.Minute)}
+	m.observeWait(app, now)
+	m.observeWait(lib, now)
+	m.observeWait(&TaskRequest{ProjectPath: `C:\projects\lib`}, now) // 未经过队列
+
+	m.observeExecution(app, time.Minute, "completed")
+	m.observeExecution(lib, 20*time.Second, "pending") // 重试前失败的执行
+	m.observeExecution(lib, 5*time.Minute, "timeout")
+
+	// 一小时前结束的任务不计入吞吐量
+	now = now.Add(30 * time.Minute)
+	m.observeExecution(&TaskRequest{ProjectPath: `c:/projects/APP/`}, time.Minute, "failed")
+	now = now.Add(45 * time.Minute)
+
+	got := m.snapshot()
+	if got.QueueWait.Count != 2 || got.Execution.Count != 4 {
+		t.Errorf("QueueWait.Count = %d, Execution.Count = %d", got.QueueWait.Count, got.Execution.Count)
+	}
+	if got.Completed != 1 || got.Failed != 2 || got.Throughput != 1 {
+		t.Errorf("汇总 = %+v", got)
+	}
+	if got.FailureRate < 0.66 || got.FailureRate > 0.67 {
+		t.Errorf("FailureRate = %v", got.FailureRate)
+	}
+
+	if len(got.Projects) != 2 {
+		t.Fatalf("Projects = %+v", got.Projects)
+	}
+	appMetrics, libMetrics := got.Projects[0], got.Projects[1]
+	if appMetrics.Project != `C:\projects\app` || appMetrics.Completed != 1 || appMetrics.Failed != 1 ||
+		appMetrics.FailureRate != 0.5 || appMetrics.Throughput != 1 || appMetrics.QueueWait.P50 != "15s" {
+		t.Errorf("app 指标 = %+v", appMetrics)
+	}
+	if libMetrics.Execution.Count != 2 || libMetrics.Failed != 1 || libMetrics.Throughput != 0 || libMetrics.QueueWait.P50 != "5m0s" {
+		t.Errorf("lib 指标 = %+v", libMetrics)
+	}
+}
@@ -0,0 +1,143 @@
+package mcp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// durationFormat 时间间隔字符串格式（time.ParseDuration 可解析）
+const durationFormat = "duration"
+
+// FieldError 单个参数的校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ArgumentError 工具参数校验错误，服务器将其作为 -32602 错误返回
+type ArgumentError struct {
+	Tool   string       `json:"tool"`
+	Fields []FieldError `json:"errors"`
+}
+
+// Error 实现 error 接口
+func (e *ArgumentError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, fmt.Sprintf("%s: %s", field.Field, field.Message))
+	}
+	return fmt.Sprintf("工具 %s 参数无效: %s", e.Tool, strings.Join(messages, "; "))
+}
+
+// validateArguments 按工具输入模式校验参数，返回按字段排序的错误列表
+// 未声明的参数不视为错误，以兼容会附带额外字段的客户端
+func validateArguments(schema ToolSchema, args map[string]interface{}) []FieldError {
+	var errs []FieldError
+	validateObject("", schema.Properties, schema.Required, args, &errs)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+// validateObject 校验对象的必需字段和已声明字段
+func validateObject(path string, properties map[string]SchemaProperty, required []string, obj map[string]interface{}, errs *[]FieldError) {
+	for _, name := range required {
+		if value, ok := obj[name]; !ok || value == nil {
+			*errs = append(*errs, FieldError{Field: joinField(path, name), Message: "缺少必需参数"})
+		}
+	}
+
+	for name, value := range obj {
+		prop, ok := properties[name]
+		if !ok || value == nil {
+			continue
+		}
+		validateValue(joinField(path, name), prop, value, errs)
+	}
+}
+
+// validateValue 校验单个值的类型、取值范围、枚举和格式
+func validateValue(field string, prop SchemaProperty, value interface{}, errs *[]FieldError) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch prop.Type {
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("应为字符串")
+			return
+		}
+		if len(prop.Enum) > 0 && !containsString(prop.Enum, str) {
+			fail("取值应为 %s 之一", strings.Join(prop.Enum, ", "))
+		}
+		if prop.Format == durationFormat && str != "" {
+			if d, err := time.ParseDuration(str); err != nil || d < 0 {
+				fail("无效的时间间隔: %q（示例: 30s, 5m, 1h）", str)
+			}
+		}
+
+	case "integer", "number":
+		num, ok := value.(float64)
+		if !ok {
+			fail("应为数字")
+			return
+		}
+		if prop.Type == "integer" && num != math.Trunc(num) {
+			fail("应为整数")
+			return
+		}
+		if prop.Minimum != nil && num < *prop.Minimum {
+			fail("不能小于 %v", *prop.Minimum)
+		}
+		if prop.Maximum != nil && num > *prop.Maximum {
+			fail("不能大于 %v", *prop.Maximum)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("应为布尔值")
+		}
+
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			fail("应为数组")
+			return
+		}
+		if prop.Items != nil {
+			for i, item := range items {
+				validateValue(fmt.Sprintf("%s[%d]", field, i), *prop.Items, item, errs)
+			}
+		}
+
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			fail("应为对象")
+			return
+		}
+		validateObject(field, prop.Properties, prop.Required, obj, errs)
+	}
+}
+
+// joinField 拼接嵌套字段路径
+func joinField(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// containsString 判断切片是否包含指定字符串
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
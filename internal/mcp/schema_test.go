@@ -0,0 +1,59 @@
+package mcp
+
+import (
+	"testing"
+)
+
+func TestValidateArguments(t *testing.T) {
+	schema := ToolSchema{
+		Type: "object",
+		Properties: map[string]SchemaProperty{
+			"projectPath": stringProperty("项目路径"),
+			"priority":    integerProperty("优先级", 2, 1, 3),
+			"offset":      integerProperty("偏移", 0, 0, 0),
+			"timeout":     durationProperty("超时"),
+			"status":      enumProperty("状态", []string{"pending", "running"}),
+			"gpu":         booleanProperty("GPU"),
+			"args":        arrayProperty("参数", "string"),
+			"limits": {
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"nice": integerProperty("nice", 0, -20, 19),
+				},
+			},
+		},
+		Required: []string{"projectPath"},
+	}
+
+	tests := []struct {
+		name   string
+		args   map[string]interface{}
+		fields []string
+	}{
+		{"有效参数", map[string]interface{}{"projectPath": "C:\\p", "priority": 3.0, "timeout": "30m", "args": []interface{}{"a"}}, nil},
+		{"缺少必需参数", map[string]interface{}{}, []string{"projectPath"}},
+		{"类型错误", map[string]interface{}{"projectPath": 1.0, "gpu": "yes"}, []string{"gpu", "projectPath"}},
+		{"超出范围", map[string]interface{}{"projectPath": "p", "priority": 5.0}, []string{"priority"}},
+		{"非整数", map[string]interface{}{"projectPath": "p", "priority": 1.5}, []string{"priority"}},
+		{"不限制范围", map[string]interface{}{"projectPath": "p", "offset": -100.0}, nil},
+		{"无效时间间隔", map[string]interface{}{"projectPath": "p", "timeout": "soon"}, []string{"timeout"}},
+		{"无效枚举值", map[string]interface{}{"projectPath": "p", "status": "done"}, []string{"status"}},
+		{"数组元素类型错误", map[string]interface{}{"projectPath": "p", "args": []interface{}{"a", 1.0}}, []string{"args[1]"}},
+		{"嵌套字段", map[string]interface{}{"projectPath": "p", "limits": map[string]interface{}{"nice": 30.0}}, []string{"limits.nice"}},
+		{"忽略未声明参数", map[string]interface{}{"projectPath": "p", "extra": true}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateArguments(schema, tt.args)
+			if len(errs) != len(tt.fields) {
+				t.Fatalf("错误数量不匹配: 期望 %v, 得到 %v", tt.fields, errs)
+			}
+			for i, field := range tt.fields {
+				if errs[i].Field != field {
+					t.Errorf("错误字段不匹配: 期望 %s, 得到 %s", field, errs[i].Field)
+				}
+			}
+		})
+	}
+}
@@ -3,18 +3,27 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
 	"net"
 	"net/http"
 	"os"
+	"path"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
+	"auto-claude-code/internal/audit"
+	"auto-claude-code/internal/auth"
 	"auto-claude-code/internal/config"
 	apperrors "auto-claude-code/internal/errors"
 	"auto-claude-code/internal/logger"
+	"auto-claude-code/internal/tracing"
 	"auto-claude-code/internal/wsl"
 )
 
@@ -28,15 +37,54 @@ type MCPServer interface {
 
 	// GetAddress 获取服务器地址
 	GetAddress() string
+
+	// Reload 应用修改后的配置中可在运行时生效的部分，返回有修改的配置项
+	Reload(cfg *config.MCPConfig) []string
 }
 
 // mcpServer MCP服务器实现
 type mcpServer struct {
 	config          *config.MCPConfig
+	configMutex     sync.RWMutex // 保护重新加载配置时修改的 config.Auth.TokenFile、config.ShellTool 和 config.Webhooks
 	logger          logger.Logger
 	protocolHandler MCPProtocolHandler
 	taskManager     TaskManager
 	worktreeManager WorktreeManager
+	wslBridge       wsl.WSLBridge
+	notifier        *NotificationDispatcher
+	events          *eventBroker
+	webhooks        *webhookDispatcher
+	requests        *requestTracker
+
+	// 认证
+	tokenValidator auth.TokenValidator
+	tokenStore     *auth.TokenStore
+	oauth2         *auth.OAuth2Validator
+	authErr        error
+
+	// 审计日志
+	auditLog *audit.Log
+	auditErr error
+
+	// 任务模板
+	templates    *templateStore
+	templatesErr error
+
+	// 限流
+	callLimiter   *rateLimiter
+	submitLimiter *rateLimiter
+
+	// WSL资源指标缓存
+	resourceMetrics     *wsl.ResourceMetrics
+	resourceMetricsErr  error
+	resourceMetricsLock sync.Mutex
+
+	// 就绪探针的WSL检查缓存
+	wslHealthErr  error
+	wslHealthAt   time.Time
+	wslHealthLock sync.Mutex
+
+	startedAt time.Time
 
 	// 传输层
 	multiTransport *MultiTransport
@@ -45,23 +93,87 @@ type mcpServer struct {
 
 // NewMCPServer 创建新的MCP服务器
 func NewMCPServer(cfg *config.MCPConfig, log logger.Logger, wslBridge wsl.WSLBridge) MCPServer {
+	// 创建通知分发器，任务事件和服务器日志推送给已连接的客户端
+	notifier := NewNotificationDispatcher(log)
+
+	// 服务器和管理器的日志同时转发给设置了日志级别的MCP会话
+	// 传输层继续使用原日志器，避免发送通知时记录的日志再次触发通知
+	serverLog := logger.FromZap(log.GetZapLogger().WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, notifier.LogCore())
+	})))
+
 	// 创建worktree管理器
-	worktreeManager := NewWorktreeManager(cfg, log)
+	worktreeManager := NewWorktreeManager(cfg, serverLog, wslBridge)
 
 	// 创建任务管理器
-	taskManager := NewTaskManager(cfg, log, wslBridge, worktreeManager)
+	var taskManager TaskManager = NewTaskManager(cfg, serverLog, wslBridge, worktreeManager)
+	taskManager.AddListener(notifier.HandleTaskEvent)
+
+	// 创建任务事件流，REST 客户端通过 SSE 接收任务状态变化
+	events := newEventBroker(serverLog)
+	taskManager.AddListener(events.HandleTaskEvent)
+
+	// 创建 Webhook 分发器，任务事件异步投递给配置的目标
+	webhooks := newWebhookDispatcher(cfg.Webhooks, serverLog)
+	taskManager.AddListener(webhooks.HandleTaskEvent)
+
+	// 打开审计日志，REST 和 MCP 入口的变更操作经包装后的管理器记录
+	var auditLog *audit.Log
+	var auditErr error
+	if cfg.Audit.Enabled {
+		auditLog, auditErr = audit.NewLog(cfg.Audit.FilePath(), log)
+		if auditErr == nil {
+			taskManager = &auditedTaskManager{TaskManager: taskManager, audit: auditLog}
+			worktreeManager = &auditedWorktreeManager{WorktreeManager: worktreeManager, audit: auditLog}
+		}
+	}
 
 	// 创建协议处理器
-	protocolHandler := NewMCPProtocolHandler(taskManager, worktreeManager)
+	protocolHandler := NewMCPProtocolHandler(taskManager, worktreeManager, notifier)
 
 	server := &mcpServer{
 		config:          cfg,
-		logger:          log,
+		logger:          serverLog,
 		protocolHandler: protocolHandler,
 		taskManager:     taskManager,
 		worktreeManager: worktreeManager,
+		wslBridge:       wslBridge,
+		notifier:        notifier,
+		events:          events,
+		webhooks:        webhooks,
+		requests:        newRequestTracker(),
+		auditLog:        auditLog,
+		auditErr:        auditErr,
 		multiTransport:  NewMultiTransport(log),
 		address:         fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		startedAt:       time.Now(),
+	}
+
+	// 创建认证器，配置错误在启动时报告
+	if cfg.Auth.Enabled {
+		switch cfg.Auth.Method {
+		case "token":
+			server.tokenStore, server.authErr = auth.NewTokenStore(cfg.Auth.TokenStorePath(), log)
+			server.tokenValidator = server.tokenStore
+			if cfg.Auth.TokenFile != "" {
+				log.Warn("token_file 已弃用，请使用 token 命令或 /auth/tokens 接口创建令牌",
+					zap.String("token_file", cfg.Auth.TokenFile))
+			}
+		case "jwt":
+			var validator *auth.JWTValidator
+			validator, server.authErr = auth.NewJWTValidator(cfg.Auth.JWT, log)
+			server.tokenValidator = validator
+		case "oauth2":
+			server.oauth2, server.authErr = auth.NewOAuth2Validator(cfg.Auth.OAuth2, log)
+			server.tokenValidator = server.oauth2
+		}
+	}
+
+	server.templates, server.templatesErr = newTemplateStore(cfg.Templates.FilePath(), serverLog)
+
+	if cfg.RateLimit.Enabled {
+		server.callLimiter = newRateLimiter(cfg.RateLimit.RPS, cfg.RateLimit.Burst)
+		server.submitLimiter = newRateLimiter(cfg.RateLimit.SubmitRPS, cfg.RateLimit.SubmitBurst)
 	}
 
 	// 创建传输处理器适配器
@@ -72,6 +184,13 @@ func NewMCPServer(cfg *config.MCPConfig, log logger.Logger, wslBridge wsl.WSLBri
 		mux := http.NewServeMux()
 		server.setupRoutes(mux)
 
+		// SSE传输与HTTP传输共用监听地址和中间件
+		if cfg.SSE.Enabled {
+			sseTransport := NewSSETransport(cfg.SSE, transportHandler, log)
+			sseTransport.RegisterRoutes(mux)
+			server.multiTransport.AddTransport(sseTransport)
+		}
+
 		httpServer := &http.Server{
 			Addr:         server.address,
 			Handler:      server.withMiddleware(mux),
@@ -84,9 +203,14 @@ func NewMCPServer(cfg *config.MCPConfig, log logger.Logger, wslBridge wsl.WSLBri
 		server.multiTransport.AddTransport(httpTransport)
 	}
 
+	// 配置gRPC传输，认证和授权与HTTP接口相同
+	if cfg.GRPC.Enabled {
+		server.multiTransport.AddTransport(newGRPCTransport(server))
+	}
+
 	// 配置stdio传输
 	if cfg.Stdio.Enabled {
-		stdioTransport := NewStdioTransport(transportHandler, log, cfg.Stdio.Reader, cfg.Stdio.Writer)
+		stdioTransport := NewStdioTransport(transportHandler, log, cfg.Stdio.Reader, cfg.Stdio.Writer, cfg.Stdio.Framing)
 		server.multiTransport.AddTransport(stdioTransport)
 	}
 
@@ -97,6 +221,16 @@ func NewMCPServer(cfg *config.MCPConfig, log logger.Logger, wslBridge wsl.WSLBri
 func (s *mcpServer) Start(ctx context.Context) error {
 	s.logger.Info("启动MCP服务器", zap.String("address", s.address))
 
+	if s.authErr != nil {
+		return apperrors.Wrap(s.authErr, apperrors.ErrMCPServerError, "初始化认证失败")
+	}
+	if s.auditErr != nil {
+		return apperrors.Wrap(s.auditErr, apperrors.ErrMCPServerError, "初始化审计日志失败")
+	}
+	if s.templatesErr != nil {
+		return apperrors.Wrap(s.templatesErr, apperrors.ErrMCPServerError, "加载任务模板失败")
+	}
+
 	// 启动worktree管理器
 	if err := s.worktreeManager.Start(ctx); err != nil {
 		return apperrors.Wrap(err, apperrors.ErrMCPServerError, "启动worktree管理器失败")
@@ -120,6 +254,9 @@ func (s *mcpServer) Start(ctx context.Context) error {
 func (s *mcpServer) Stop(ctx context.Context) error {
 	s.logger.Info("停止MCP服务器")
 
+	// 先结束任务事件流，长连接不会阻塞HTTP服务器关闭
+	s.events.close()
+
 	// 停止传输层
 	if err := s.multiTransport.Stop(ctx); err != nil {
 		s.logger.Warn("传输层停止失败", zap.Error(err))
@@ -130,11 +267,28 @@ func (s *mcpServer) Stop(ctx context.Context) error {
 		s.logger.Warn("任务管理器停止失败", zap.Error(err))
 	}
 
+	// 等待任务结束事件投递给 Webhook 目标
+	if err := s.webhooks.stop(ctx); err != nil {
+		s.logger.Warn("Webhook投递未完成", zap.Error(err))
+	}
+
 	// 停止worktree管理器
 	if err := s.worktreeManager.Stop(ctx); err != nil {
 		s.logger.Warn("worktree管理器停止失败", zap.Error(err))
 	}
 
+	// 保存令牌使用时间
+	if s.tokenStore != nil {
+		if err := s.tokenStore.Flush(); err != nil {
+			s.logger.Warn("保存令牌存储失败", zap.Error(err))
+		}
+	}
+
+	// 关闭审计日志
+	if err := s.auditLog.Close(); err != nil {
+		s.logger.Warn("关闭审计日志失败", zap.Error(err))
+	}
+
 	s.logger.Info("MCP服务器已停止")
 	return nil
 }
@@ -149,23 +303,73 @@ func (s *mcpServer) setupRoutes(mux *http.ServeMux) {
 	// MCP协议端点
 	mux.HandleFunc("/mcp", s.handleMCPRequest)
 
-	// 健康检查端点
+	// 健康检查端点，旧的 health_path 保留为就绪探针的别名
 	if s.config.Monitoring.Enabled {
-		mux.HandleFunc(s.config.Monitoring.HealthPath, s.handleHealth)
+		mux.HandleFunc(s.config.Monitoring.HealthPath, s.handleReadiness)
+		if s.config.Monitoring.LivenessPath != "" {
+			mux.HandleFunc(s.config.Monitoring.LivenessPath, s.handleLiveness)
+		}
+		if s.config.Monitoring.ReadinessPath != "" && s.config.Monitoring.ReadinessPath != s.config.Monitoring.HealthPath {
+			mux.HandleFunc(s.config.Monitoring.ReadinessPath, s.handleReadiness)
+		}
 		mux.HandleFunc(s.config.Monitoring.MetricsPath, s.handleMetrics)
 	}
 
+	// OAuth2 受保护资源元数据
+	if s.oauth2 != nil {
+		mux.HandleFunc(protectedResourcePath, s.handleProtectedResourceMetadata)
+	}
+
 	// 任务管理端点
 	mux.HandleFunc("/tasks", s.handleTasks)
+	mux.HandleFunc(eventsPath, s.handleEvents)
+	mux.HandleFunc(outputStreamPrefix, s.handleTaskOutputStream)
 	mux.HandleFunc("/tasks/", s.handleTaskDetail)
 
 	// Worktree管理端点
+	mux.HandleFunc("/queue", s.handleQueue)
+	mux.HandleFunc("/queue/", s.handleQueue)
+	mux.HandleFunc("/quotas", s.handleQuotas)
+	mux.HandleFunc("/export", s.handleExport)
+	mux.HandleFunc("/templates", s.handleTemplates)
+	mux.HandleFunc("/templates/", s.handleTemplateDetail)
 	mux.HandleFunc("/worktrees", s.handleWorktrees)
 	mux.HandleFunc("/worktrees/", s.handleWorktreeDetail)
+
+	// 令牌管理端点
+	if s.tokenStore != nil {
+		mux.HandleFunc("/auth/tokens", s.handleTokens)
+		mux.HandleFunc("/auth/tokens/", s.handleTokenDetail)
+	}
+
+	// 审计查询端点
+	if s.auditLog != nil {
+		mux.HandleFunc("/audit", s.handleAudit)
+	}
+
+	// API 文档端点
+	mux.HandleFunc(openAPIPath, s.handleOpenAPI)
+	mux.HandleFunc(apiDocsPath, s.handleAPIDocs)
+	mux.HandleFunc(apiDocsAssetsPath, s.handleAPIDocsAssets)
 }
 
 // withMiddleware 添加中间件
 func (s *mcpServer) withMiddleware(handler http.Handler) http.Handler {
+	// 请求体大小限制
+	if s.config.HTTP.MaxBodyBytes > 0 {
+		handler = s.bodyLimitMiddleware(handler)
+	}
+
+	// 压缩中间件，位于日志之内以便日志记录实际状态码
+	if s.config.HTTP.Compression {
+		handler = s.gzipMiddleware(handler)
+	}
+
+	// 限流中间件，位于认证之后以便按身份限流
+	if s.config.RateLimit.Enabled {
+		handler = s.rateLimitMiddleware(handler)
+	}
+
 	// 日志中间件
 	handler = s.loggingMiddleware(handler)
 
@@ -174,6 +378,12 @@ func (s *mcpServer) withMiddleware(handler http.Handler) http.Handler {
 		handler = s.authMiddleware(handler)
 	}
 
+	// 追踪中间件
+	handler = s.tracingMiddleware(handler)
+
+	// 请求ID中间件，位于认证之前以便拒绝访问的日志也带有请求ID
+	handler = s.requestIDMiddleware(handler)
+
 	// CORS中间件
 	handler = s.corsMiddleware(handler)
 
@@ -183,13 +393,17 @@ func (s *mcpServer) withMiddleware(handler http.Handler) http.Handler {
 // handleMCPRequest 处理MCP请求
 func (s *mcpServer) handleMCPRequest(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		s.writeError(w, http.StatusMethodNotAllowed, "只支持POST方法")
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持POST方法"))
 		return
 	}
 
 	// 解析JSON-RPC请求
 	var req JSONRPCRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if isBodyTooLarge(err) {
+			writeProblem(w, r, apperrors.Newf(apperrors.ErrRequestTooLarge, "请求体超过 %d 字节", s.config.HTTP.MaxBodyBytes))
+			return
+		}
 		s.writeJSONRPCError(w, nil, -32700, "解析错误", err.Error())
 		return
 	}
@@ -198,18 +412,79 @@ func (s *mcpServer) handleMCPRequest(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	response := s.processJSONRPCRequest(ctx, &req)
 
+	// 通知消息不需要响应
+	if req.ID == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
 	// 返回响应
 	w.Header().Set("Content-Type", "application/json")
+	if wait, limited := retryAfterFromError(response.Error); limited {
+		writeRetryAfter(w, wait)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
 	json.NewEncoder(w).Encode(response)
 }
 
-// processJSONRPCRequest 处理JSON-RPC请求
+// handleSessionRequest 处理来自会话传输（stdio、SSE）的请求
+// 登记进行中的请求以支持 notifications/cancelled，请求被客户端取消时不返回响应
+func (s *mcpServer) handleSessionRequest(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	// stdio 请求没有HTTP请求ID，为每个JSON-RPC请求生成一个
+	if logger.RequestIDFromContext(ctx) == "" {
+		ctx = logger.WithRequestID(ctx, logger.NewRequestID())
+	}
+
+	sessionID := sessionFromContext(ctx)
+	if req.ID == nil || sessionID == "" {
+		return s.processJSONRPCRequest(ctx, req)
+	}
+
+	ctx, finish := s.requests.track(ctx, sessionID, req.ID)
+	response := s.processJSONRPCRequest(ctx, req)
+	if finish() {
+		logger.FromContext(ctx, s.logger).Info("请求已被客户端取消",
+			zap.String("session", sessionID),
+			zap.Any("id", req.ID),
+			zap.String("method", req.Method))
+		return nil
+	}
+	return response
+}
+
+// processJSONRPCRequest 处理JSON-RPC请求并记录追踪 span
 func (s *mcpServer) processJSONRPCRequest(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	ctx, span := tracing.StartKind(ctx, tracing.SpanKindServer, "mcp "+req.Method,
+		tracing.String("rpc.system", "jsonrpc"),
+		tracing.String("rpc.method", req.Method))
+	defer span.End()
+
+	response := s.dispatchJSONRPCRequest(ctx, req)
+	if response != nil && response.Error != nil {
+		span.SetAttributes(tracing.Int("rpc.jsonrpc.error_code", response.Error.Code))
+		span.SetError(response.Error.Message)
+	}
+	return response
+}
+
+// dispatchJSONRPCRequest 按方法分发JSON-RPC请求
+func (s *mcpServer) dispatchJSONRPCRequest(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
 	response := &JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      req.ID,
 	}
 
+	if err := s.authorize(ctx, methodRole(req)); err != nil {
+		s.auditLog.Record(ctx, audit.ActionAuthFailure, req.Method, map[string]interface{}{"reason": "forbidden"}, err)
+		response.Error = &JSONRPCError{Code: jsonRPCForbidden, Message: "权限不足", Data: err.Error()}
+		return response
+	}
+
+	if ok, wait := s.checkSubmitRate(ctx, req); !ok {
+		response.Error = rateLimitedError(wait)
+		return response
+	}
+
 	switch req.Method {
 	case "initialize":
 		var initReq InitializeRequest
@@ -226,12 +501,37 @@ func (s *mcpServer) processJSONRPCRequest(ctx context.Context, req *JSONRPCReque
 		response.Result = result
 
 	case "tools/list":
+		var pageReq PaginatedRequest
+		if err := s.parseParams(req.Params, &pageReq); err != nil {
+			response.Error = &JSONRPCError{Code: -32602, Message: "无效参数", Data: err.Error()}
+			return response
+		}
+
 		result, err := s.protocolHandler.ListTools(ctx)
 		if err != nil {
 			response.Error = &JSONRPCError{Code: -32603, Message: "内部错误", Data: err.Error()}
 			return response
 		}
-		response.Result = map[string]interface{}{"tools": result}
+
+		// 只列出当前角色可以调用的工具
+		role := auth.RoleFromContext(ctx, s.defaultRole())
+		allowed := result[:0:0]
+		for _, tool := range result {
+			if role.Allows(toolRole(tool.Name)) {
+				allowed = append(allowed, tool)
+			}
+		}
+
+		tools, nextCursor, err := paginateTools(allowed, pageReq.Cursor)
+		if err != nil {
+			response.Error = &JSONRPCError{Code: -32602, Message: "无效参数", Data: err.Error()}
+			return response
+		}
+		toolsResult := map[string]interface{}{"tools": tools}
+		if nextCursor != "" {
+			toolsResult["nextCursor"] = nextCursor
+		}
+		response.Result = toolsResult
 
 	case "tools/call":
 		var callReq CallToolRequest
@@ -239,39 +539,107 @@ func (s *mcpServer) processJSONRPCRequest(ctx context.Context, req *JSONRPCReque
 			response.Error = &JSONRPCError{Code: -32602, Message: "无效参数", Data: err.Error()}
 			return response
 		}
+		tracing.SpanFromContext(ctx).SetAttributes(tracing.String("mcp.tool", callReq.Name))
 
 		result, err := s.protocolHandler.CallTool(ctx, &callReq)
 		if err != nil {
+			var argErr *ArgumentError
+			if errors.As(err, &argErr) {
+				response.Error = &JSONRPCError{Code: -32602, Message: "无效参数", Data: argErr}
+				return response
+			}
 			response.Error = &JSONRPCError{Code: -32603, Message: "内部错误", Data: err.Error()}
 			return response
 		}
 		response.Result = result
 
-	default:
-		response.Error = &JSONRPCError{Code: -32601, Message: "方法未找到"}
-	}
+	case "resources/list":
+		result, err := s.protocolHandler.ListResources(ctx)
+		if err != nil {
+			response.Error = &JSONRPCError{Code: -32603, Message: "内部错误", Data: err.Error()}
+			return response
+		}
+		response.Result = map[string]interface{}{"resources": result}
 
-	return response
-}
+	case "resources/templates/list":
+		result, err := s.protocolHandler.ListResourceTemplates(ctx)
+		if err != nil {
+			response.Error = &JSONRPCError{Code: -32603, Message: "内部错误", Data: err.Error()}
+			return response
+		}
+		response.Result = map[string]interface{}{"resourceTemplates": result}
 
-// handleHealth 处理健康检查
-func (s *mcpServer) handleHealth(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	case "resources/read":
+		var readReq ReadResourceRequest
+		if err := s.parseParams(req.Params, &readReq); err != nil || readReq.URI == "" {
+			response.Error = &JSONRPCError{Code: -32602, Message: "无效参数", Data: "缺少资源URI"}
+			return response
+		}
 
-	health := map[string]interface{}{
-		"status":    "ok",
-		"timestamp": time.Now().Format(time.RFC3339),
-	}
+		result, err := s.protocolHandler.ReadResource(ctx, readReq.URI)
+		if err != nil {
+			if isResourceNotFound(err) {
+				response.Error = &JSONRPCError{Code: -32002, Message: "资源不存在", Data: readReq.URI}
+			} else {
+				response.Error = &JSONRPCError{Code: -32603, Message: "内部错误", Data: err.Error()}
+			}
+			return response
+		}
+		response.Result = result
+
+	case "resources/subscribe", "resources/unsubscribe":
+		var subReq SubscribeResourceRequest
+		if err := s.parseParams(req.Params, &subReq); err != nil || subReq.URI == "" {
+			response.Error = &JSONRPCError{Code: -32602, Message: "无效参数", Data: "缺少资源URI"}
+			return response
+		}
+
+		var err error
+		if req.Method == "resources/subscribe" {
+			err = s.protocolHandler.SubscribeResource(ctx, subReq.URI)
+		} else {
+			err = s.protocolHandler.UnsubscribeResource(ctx, subReq.URI)
+		}
+		if err != nil {
+			response.Error = &JSONRPCError{Code: -32602, Message: "无效参数", Data: err.Error()}
+			return response
+		}
+		response.Result = map[string]interface{}{}
+
+	case "logging/setLevel":
+		var levelReq SetLevelRequest
+		if err := s.parseParams(req.Params, &levelReq); err != nil || levelReq.Level == "" {
+			response.Error = &JSONRPCError{Code: -32602, Message: "无效参数", Data: "缺少日志级别"}
+			return response
+		}
+
+		if err := s.protocolHandler.SetLogLevel(ctx, levelReq.Level); err != nil {
+			response.Error = &JSONRPCError{Code: -32602, Message: "无效参数", Data: err.Error()}
+			return response
+		}
+		response.Result = map[string]interface{}{}
+
+	case "notifications/initialized":
+		// 客户端完成初始化，无需处理
+
+	case NotificationCancelled:
+		var cancelled CancelledParams
+		if err := s.parseParams(req.Params, &cancelled); err != nil || cancelled.RequestID == nil {
+			logger.FromContext(ctx, s.logger).Warn("无效的取消通知", zap.Any("params", req.Params))
+			break
+		}
+		// 请求可能已经完成，此时忽略取消通知
+		if s.requests.cancel(sessionFromContext(ctx), cancelled.RequestID) {
+			logger.FromContext(ctx, s.logger).Info("取消进行中的请求",
+				zap.Any("id", cancelled.RequestID),
+				zap.String("reason", cancelled.Reason))
+		}
 
-	// 检查各组件健康状态
-	if err := s.protocolHandler.HealthCheck(ctx); err != nil {
-		health["status"] = "error"
-		health["error"] = err.Error()
-		w.WriteHeader(http.StatusServiceUnavailable)
+	default:
+		response.Error = &JSONRPCError{Code: -32601, Message: "方法未找到"}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(health)
+	return response
 }
 
 // handleMetrics 处理指标
@@ -291,48 +659,113 @@ func (s *mcpServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	for _, wt := range worktrees {
 		worktreeStats[wt.Status]++
 	}
+	worktreeMetrics := map[string]interface{}{
+		"total":     len(worktrees),
+		"by_status": worktreeStats,
+	}
+	if usage, err := s.worktreeManager.DiskUsage(ctx); err != nil {
+		worktreeMetrics["disk"] = map[string]interface{}{"error": err.Error()}
+	} else {
+		worktreeMetrics["disk"] = usage
+	}
 
 	metrics := map[string]interface{}{
 		"tasks": map[string]interface{}{
 			"total":     len(tasks),
 			"by_status": taskStats,
 		},
-		"worktrees": map[string]interface{}{
-			"total":     len(worktrees),
-			"by_status": worktreeStats,
-		},
-		"timestamp": time.Now().Format(time.RFC3339),
+		"worktrees":  worktreeMetrics,
+		"scheduling": s.taskManager.SchedulingMetrics(),
+		"system":     s.collectResourceMetrics(),
+		"timestamp":  time.Now().Format(time.RFC3339),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(metrics)
 }
 
+// resourceMetricsTTL WSL资源指标缓存时间，避免频繁抓取时反复启动wsl进程
+const resourceMetricsTTL = 5 * time.Second
+
+// protectedResourcePath OAuth2 受保护资源元数据路径
+const protectedResourcePath = "/.well-known/oauth-protected-resource"
+
+// collectResourceMetrics 获取（可能已缓存的）WSL资源指标
+func (s *mcpServer) collectResourceMetrics() interface{} {
+	s.resourceMetricsLock.Lock()
+	defer s.resourceMetricsLock.Unlock()
+
+	if s.resourceMetrics == nil || time.Since(s.resourceMetrics.SampledAt) > resourceMetricsTTL {
+		metrics, err := s.wslBridge.GetResourceMetrics("")
+		if err != nil {
+			s.logger.Warn("采集WSL资源指标失败", zap.Error(err))
+			s.resourceMetricsErr = err
+			// 失败时也记录采样时间，避免每次请求都重试
+			s.resourceMetrics = &wsl.ResourceMetrics{SampledAt: time.Now()}
+		} else {
+			s.resourceMetrics = metrics
+			s.resourceMetricsErr = nil
+		}
+	}
+
+	if s.resourceMetricsErr != nil {
+		return map[string]interface{}{"error": s.resourceMetricsErr.Error()}
+	}
+	return s.resourceMetrics
+}
+
 // handleTasks 处理任务列表
 func (s *mcpServer) handleTasks(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	switch r.Method {
 	case http.MethodGet:
+		query, err := parseTaskListQuery(r.URL.Query())
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+
 		tasks, err := s.taskManager.ListTasks(ctx)
 		if err != nil {
-			s.writeError(w, http.StatusInternalServerError, err.Error())
+			writeProblem(w, r, err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{"tasks": tasks})
+		resp, err := query.apply(tasks)
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+
+		writeJSONWithETag(w, r, resp)
 
 	case http.MethodPost:
 		var req TaskRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			s.writeError(w, http.StatusBadRequest, "无效的请求格式")
+		if err := decodeJSONBody(r, &req); err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+		if err := s.applyTemplate(&req); err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+
+		// validateOnly 只返回执行计划，不创建任务
+		if req.ValidateOnly || r.URL.Query().Get("validate_only") == "true" {
+			plan, err := s.taskManager.PlanTask(ctx, &req)
+			if err != nil {
+				writeProblem(w, r, err)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(plan)
 			return
 		}
 
 		status, err := s.taskManager.SubmitTask(ctx, &req)
 		if err != nil {
-			s.writeError(w, http.StatusInternalServerError, err.Error())
+			writeProblem(w, r, err)
 			return
 		}
 
@@ -341,24 +774,86 @@ func (s *mcpServer) handleTasks(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(status)
 
 	default:
-		s.writeError(w, http.StatusMethodNotAllowed, "不支持的方法")
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "不支持的方法"))
 	}
 }
 
+// queuePauseRequest 暂停任务队列的请求，请求体可以省略
+type queuePauseRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// handleQueue 查询任务队列状态（GET /queue），暂停（POST /queue/pause）或恢复（POST /queue/resume）任务分发
+func (s *mcpServer) handleQueue(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var state QueueState
+	switch {
+	case r.URL.Path == "/queue" && r.Method == http.MethodGet:
+		state = s.taskManager.GetQueueState(ctx)
+
+	case r.URL.Path == "/queue/pause" && r.Method == http.MethodPost:
+		var req queuePauseRequest
+		if r.ContentLength != 0 {
+			if err := decodeJSONBody(r, &req); err != nil {
+				writeProblem(w, r, err)
+				return
+			}
+		}
+		state = s.taskManager.PauseQueue(ctx, req.Reason)
+		s.auditLog.Record(ctx, audit.ActionQueuePause, "", map[string]interface{}{"reason": req.Reason}, nil)
+
+	case r.URL.Path == "/queue/resume" && r.Method == http.MethodPost:
+		state = s.taskManager.ResumeQueue(ctx)
+		s.auditLog.Record(ctx, audit.ActionQueueResume, "", nil, nil)
+
+	case r.URL.Path == "/queue" || r.URL.Path == "/queue/pause" || r.URL.Path == "/queue/resume":
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "不支持的方法"))
+		return
+
+	default:
+		writeProblem(w, r, apperrors.Newf(apperrors.ErrResourceNotFound, "资源不存在: %s", r.URL.Path))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
 // handleTaskDetail 处理任务详情
 func (s *mcpServer) handleTaskDetail(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	taskID := r.URL.Path[len("/tasks/"):]
 
+	if id, sub, ok := strings.Cut(taskID, "/"); ok {
+		switch sub {
+		case "output":
+			s.handleTaskOutput(w, r, id)
+		case "artifacts":
+			s.handleTaskArtifacts(w, r, id, "")
+		case "notes":
+			s.handleTaskNotes(w, r, id)
+		case "rerun":
+			s.handleTaskRerun(w, r, id)
+		case "attach":
+			s.handleTaskAttach(w, r, id)
+		case "merge":
+			s.handleTaskMerge(w, r, id)
+		default:
+			if name, ok := strings.CutPrefix(sub, "artifacts/"); ok {
+				s.handleTaskArtifacts(w, r, id, name)
+				return
+			}
+			writeProblem(w, r, apperrors.New(apperrors.ErrResourceNotFound, "未知的任务端点"))
+		}
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		status, err := s.taskManager.GetTaskStatus(ctx, taskID)
 		if err != nil {
-			if apperrors.IsCode(err, apperrors.ErrTaskNotFound) {
-				s.writeError(w, http.StatusNotFound, err.Error())
-			} else {
-				s.writeError(w, http.StatusInternalServerError, err.Error())
-			}
+			writeProblem(w, r, err)
 			return
 		}
 
@@ -368,38 +863,112 @@ func (s *mcpServer) handleTaskDetail(w http.ResponseWriter, r *http.Request) {
 	case http.MethodDelete:
 		err := s.taskManager.CancelTask(ctx, taskID)
 		if err != nil {
-			if apperrors.IsCode(err, apperrors.ErrTaskNotFound) {
-				s.writeError(w, http.StatusNotFound, err.Error())
-			} else {
-				s.writeError(w, http.StatusInternalServerError, err.Error())
-			}
+			writeProblem(w, r, err)
 			return
 		}
 
 		w.WriteHeader(http.StatusNoContent)
 
 	default:
-		s.writeError(w, http.StatusMethodNotAllowed, "不支持的方法")
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "不支持的方法"))
 	}
 }
 
+// handleTaskOutput 分页返回任务输出，查询参数 offset（负数从末尾倒数）和 limit 为字节数
+// stream 为 stdout 或 stderr 时读取对应的输出日志文件
+func (s *mcpServer) handleTaskOutput(w http.ResponseWriter, r *http.Request, taskID string) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持GET方法"))
+		return
+	}
+
+	var offset, limit int
+	query := r.URL.Query()
+	if v := query.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeProblem(w, r, apperrors.New(apperrors.ErrInvalidRequest, "无效的 offset 参数"))
+			return
+		}
+		offset = n
+	}
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeProblem(w, r, apperrors.New(apperrors.ErrInvalidRequest, "无效的 limit 参数"))
+			return
+		}
+		limit = n
+	}
+
+	stream := query.Get("stream")
+	var output string
+	var err error
+	if stream != "" {
+		output, err = s.taskManager.GetTaskStreamOutput(r.Context(), taskID, stream)
+	} else {
+		output, err = s.taskManager.GetTaskOutput(r.Context(), taskID)
+	}
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	page := pageOutput(taskID, output, offset, limit)
+	page.Stream = stream
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// handleTaskArtifacts 列出任务产物，name 非空时下载对应的产物文件
+func (s *mcpServer) handleTaskArtifacts(w http.ResponseWriter, r *http.Request, taskID, name string) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持GET方法"))
+		return
+	}
+
+	if name == "" {
+		artifacts, err := s.taskManager.ListTaskArtifacts(r.Context(), taskID)
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+		writeJSONWithETag(w, r, map[string]interface{}{"taskId": taskID, "artifacts": artifacts})
+		return
+	}
+
+	file, err := s.taskManager.OpenTaskArtifact(r.Context(), taskID, name)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		writeProblem(w, r, apperrors.Wrap(err, apperrors.ErrInternal, "读取任务产物失败"))
+		return
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(name)}))
+	http.ServeContent(w, r, name, info.ModTime(), file)
+}
+
 // handleWorktrees 处理worktree列表
 func (s *mcpServer) handleWorktrees(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	if r.Method != http.MethodGet {
-		s.writeError(w, http.StatusMethodNotAllowed, "只支持GET方法")
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持GET方法"))
 		return
 	}
 
 	worktrees, err := s.worktreeManager.ListWorktrees(ctx)
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		writeProblem(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{"worktrees": worktrees})
+	writeJSONWithETag(w, r, map[string]interface{}{"worktrees": worktrees})
 }
 
 // handleWorktreeDetail 处理worktree详情
@@ -407,36 +976,39 @@ func (s *mcpServer) handleWorktreeDetail(w http.ResponseWriter, r *http.Request)
 	ctx := r.Context()
 	worktreeID := r.URL.Path[len("/worktrees/"):]
 
+	if id, sub, ok := strings.Cut(worktreeID, "/"); ok {
+		switch sub {
+		case "diff":
+			s.handleWorktreeDiff(w, r, id)
+		case "status":
+			s.handleWorktreeStatus(w, r, id)
+		case "archive":
+			s.handleWorktreeArchive(w, r, id)
+		default:
+			writeProblem(w, r, apperrors.New(apperrors.ErrResourceNotFound, "未知的worktree端点"))
+		}
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		worktree, err := s.worktreeManager.GetWorktree(ctx, worktreeID)
 		if err != nil {
-			if apperrors.IsCode(err, apperrors.ErrWorktreeNotFound) {
-				s.writeError(w, http.StatusNotFound, err.Error())
-			} else {
-				s.writeError(w, http.StatusInternalServerError, err.Error())
-			}
+			writeProblem(w, r, err)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(worktree)
 
-	case http.MethodDelete:
-		err := s.worktreeManager.DeleteWorktree(ctx, worktreeID)
-		if err != nil {
-			if apperrors.IsCode(err, apperrors.ErrWorktreeNotFound) {
-				s.writeError(w, http.StatusNotFound, err.Error())
-			} else {
-				s.writeError(w, http.StatusInternalServerError, err.Error())
-			}
-			return
-		}
+	case http.MethodPatch:
+		s.handleWorktreePatch(w, r, worktreeID)
 
-		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		s.handleWorktreeDelete(w, r, worktreeID)
 
 	default:
-		s.writeError(w, http.StatusMethodNotAllowed, "不支持的方法")
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "不支持的方法"))
 	}
 }
 
@@ -448,7 +1020,7 @@ func (s *mcpServer) loggingMiddleware(next http.Handler) http.Handler {
 		start := time.Now()
 
 		if s.config.Monitoring.LogRequests {
-			s.logger.Info("HTTP请求",
+			logger.FromContext(r.Context(), s.logger).Info("HTTP请求",
 				zap.String("method", r.Method),
 				zap.String("path", r.URL.Path),
 				zap.String("remote", r.RemoteAddr))
@@ -457,7 +1029,7 @@ func (s *mcpServer) loggingMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 
 		if s.config.Monitoring.LogRequests {
-			s.logger.Info("HTTP响应",
+			logger.FromContext(r.Context(), s.logger).Info("HTTP响应",
 				zap.String("method", r.Method),
 				zap.String("path", r.URL.Path),
 				zap.Duration("duration", time.Since(start)))
@@ -465,11 +1037,78 @@ func (s *mcpServer) loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// requestIDMiddleware 请求ID中间件，沿用客户端传入的 X-Request-ID 或生成新的请求ID，并在响应头中返回
+func (s *mcpServer) requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(logger.RequestIDHeader)
+		if !logger.ValidRequestID(requestID) {
+			requestID = logger.NewRequestID()
+		}
+
+		w.Header().Set(logger.RequestIDHeader, requestID)
+
+		// 客户端地址与请求ID一起随上下文传递，供审计记录使用
+		ctx := audit.WithSourceIP(logger.WithRequestID(r.Context(), requestID), s.getClientIP(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// tracingMiddleware 追踪中间件，沿用客户端 traceparent 并为每个HTTP请求创建服务端 span
+func (s *mcpServer) tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// SSE 事件流是长连接，不记录 span
+		if s.config.SSE.Enabled && r.URL.Path == s.config.SSE.Path {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := tracing.Extract(r.Context(), r.Header)
+		ctx, span := tracing.StartKind(ctx, tracing.SpanKindServer, r.Method+" "+r.URL.Path,
+			tracing.String("http.method", r.Method),
+			tracing.String("http.target", r.URL.Path),
+			tracing.String("request_id", logger.RequestIDFromContext(ctx)))
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(tracing.Int("http.status_code", recorder.status))
+		if recorder.status >= 500 {
+			span.SetError(http.StatusText(recorder.status))
+		}
+	})
+}
+
+// statusRecorder 记录响应状态码
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader 记录状态码
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush 支持流式响应
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap 供 http.ResponseController 访问原始 ResponseWriter
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 // authMiddleware 认证中间件
 func (s *mcpServer) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// 跳过健康检查端点
-		if r.URL.Path == s.config.Monitoring.HealthPath {
+		// 跳过健康检查、受保护资源元数据和API文档端点
+		if s.isProbePath(r.URL.Path) || r.URL.Path == protectedResourcePath ||
+			r.URL.Path == openAPIPath || r.URL.Path == apiDocsPath || strings.HasPrefix(r.URL.Path, apiDocsAssetsPath) {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -482,22 +1121,48 @@ func (s *mcpServer) authMiddleware(next http.Handler) http.Handler {
 
 		// IP白名单验证
 		if !s.validateClientIP(r) {
-			s.logger.Warn("访问被拒绝 - IP不在白名单",
+			logger.FromContext(r.Context(), s.logger).Warn("访问被拒绝 - IP不在白名单",
 				zap.String("remote_ip", s.getClientIP(r)),
 				zap.String("path", r.URL.Path))
-			s.writeError(w, http.StatusForbidden, "访问被拒绝：IP地址不被允许")
+			s.auditAuthFailure(r, "ip_not_allowed", nil)
+			writeProblem(w, r, apperrors.New(apperrors.ErrForbidden, "访问被拒绝：IP地址不被允许"))
 			return
 		}
 
-		// Token验证
-		if s.config.Auth.Method == "token" {
-			if !s.validateToken(r) {
-				s.logger.Warn("访问被拒绝 - Token验证失败",
+		switch s.config.Auth.Method {
+		case "token", "jwt", "oauth2":
+			identity, err := s.tokenValidator.Validate(r.Context(), bearerToken(r))
+			if err != nil && s.config.Auth.Method == "token" && s.authTokenFile() != "" && s.validateToken(bearerToken(r)) {
+				// 兼容静态令牌文件，视为拥有全部权限
+				identity, err = &auth.Identity{
+					Subject: "token_file",
+					Method:  "token",
+					Scopes:  auth.ValidScopes,
+				}, nil
+			}
+			if err != nil {
+				logger.FromContext(r.Context(), s.logger).Warn("访问被拒绝 - 令牌验证失败",
+					zap.String("method", s.config.Auth.Method),
 					zap.String("remote_ip", s.getClientIP(r)),
-					zap.String("path", r.URL.Path))
-				s.writeError(w, http.StatusUnauthorized, "未授权访问：Token验证失败")
+					zap.String("path", r.URL.Path),
+					zap.Error(err))
+				s.auditAuthFailure(r, "invalid_token", err)
+				s.writeBearerError(w, r, err)
 				return
 			}
+			r = r.WithContext(auth.WithIdentity(r.Context(), identity))
+		}
+
+		// 角色检查，MCP 方法的角色在处理请求时检查
+		if err := s.authorize(r.Context(), s.restRole(r)); err != nil {
+			logger.FromContext(r.Context(), s.logger).Warn("访问被拒绝 - 权限不足",
+				zap.String("remote_ip", s.getClientIP(r)),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.Error(err))
+			s.auditAuthFailure(r, "forbidden", err)
+			writeProblem(w, r, err)
+			return
 		}
 
 		next.ServeHTTP(w, r)
@@ -509,7 +1174,8 @@ func (s *mcpServer) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Retry-After")
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusOK)
@@ -536,19 +1202,6 @@ func (s *mcpServer) parseParams(params interface{}, target interface{}) error {
 	return json.Unmarshal(data, target)
 }
 
-// writeError 写入错误响应
-func (s *mcpServer) writeError(w http.ResponseWriter, statusCode int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	errorResp := map[string]interface{}{
-		"error":     message,
-		"timestamp": time.Now().Format(time.RFC3339),
-	}
-
-	json.NewEncoder(w).Encode(errorResp)
-}
-
 // writeJSONRPCError 写入JSON-RPC错误响应
 func (s *mcpServer) writeJSONRPCError(w http.ResponseWriter, id interface{}, code int, message, data string) {
 	w.Header().Set("Content-Type", "application/json")
@@ -570,13 +1223,16 @@ func (s *mcpServer) writeJSONRPCError(w http.ResponseWriter, id interface{}, cod
 
 // validateClientIP 验证客户端IP是否在白名单中
 func (s *mcpServer) validateClientIP(r *http.Request) bool {
+	return s.isAllowedIP(s.getClientIP(r))
+}
+
+// isAllowedIP 检查IP是否在白名单中
+func (s *mcpServer) isAllowedIP(clientIP string) bool {
 	// 如果没有配置IP白名单，默认允许所有IP
 	if len(s.config.Auth.AllowedIPs) == 0 {
 		return true
 	}
 
-	clientIP := s.getClientIP(r)
-
 	// 检查IP是否在白名单中
 	for _, allowedIP := range s.config.Auth.AllowedIPs {
 		if allowedIP == "*" || allowedIP == clientIP {
@@ -636,22 +1292,8 @@ func (s *mcpServer) isIPInCIDR(ip, cidr string) bool {
 	return network.Contains(parsedIP)
 }
 
-// validateToken 验证Token
-func (s *mcpServer) validateToken(r *http.Request) bool {
-	// 从Authorization头获取token
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		return false
-	}
-
-	// 支持Bearer token格式
-	var token string
-	if strings.HasPrefix(authHeader, "Bearer ") {
-		token = strings.TrimPrefix(authHeader, "Bearer ")
-	} else {
-		token = authHeader
-	}
-
+// validateToken 按静态令牌文件验证Token
+func (s *mcpServer) validateToken(token string) bool {
 	if token == "" {
 		return false
 	}
@@ -673,13 +1315,238 @@ func (s *mcpServer) validateToken(r *http.Request) bool {
 	return false
 }
 
+// writeBearerError 按 RFC 6750 写入令牌验证失败响应，附带令牌刷新指引
+func (s *mcpServer) writeBearerError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusUnauthorized
+	errorCode := "invalid_token"
+	description := "访问令牌无效"
+	hint := "请从授权服务器重新获取访问令牌"
+	// WWW-Authenticate 头中的描述只能使用 ASCII 字符
+	headerDescription := "The access token is invalid"
+
+	switch apperrors.GetCode(err) {
+	case apperrors.ErrTokenExpired:
+		description = "访问令牌已过期"
+		headerDescription = "The access token expired"
+		hint = "请使用 refresh_token 向 token_endpoint 换取新的访问令牌后重试"
+	case apperrors.ErrForbidden:
+		status = http.StatusForbidden
+		errorCode = "insufficient_scope"
+		description = "访问令牌缺少所需的权限范围"
+		headerDescription = "The access token lacks the required scope"
+		hint = "请申请包含所需 scope 的访问令牌"
+	}
+	if bearerToken(r) == "" {
+		// 未携带令牌时不返回错误代码（RFC 6750 第3.1节）
+		errorCode = ""
+		description = "缺少访问令牌"
+		hint = "请在 Authorization 头中以 Bearer 方式携带访问令牌"
+	}
+
+	challenge := `Bearer realm="auto-claude-code"`
+	if errorCode != "" {
+		challenge += fmt.Sprintf(`, error=%q, error_description=%q`, errorCode, headerDescription)
+	}
+	if errorCode == "insufficient_scope" && len(s.config.Auth.OAuth2.RequiredScopes) > 0 {
+		challenge += fmt.Sprintf(`, scope=%q`, strings.Join(s.config.Auth.OAuth2.RequiredScopes, " "))
+	}
+
+	problem := newProblem(r, err)
+	if problem.Status != status {
+		// 其他验证错误（如授权服务器不可用）统一按认证失败返回
+		problem.Code = apperrors.ErrUnauthorized
+	}
+	problem.Status = status
+	problem.Title = http.StatusText(status)
+	problem.Detail = description
+	body := map[string]interface{}{
+		"error_code":        errorCode,
+		"error_description": err.Error(),
+		"hint":              hint,
+	}
+	problem.Extensions = body
+
+	if s.oauth2 != nil {
+		resourceMetadata := requestBaseURL(r) + protectedResourcePath
+		challenge += fmt.Sprintf(`, resource_metadata=%q`, resourceMetadata)
+		body["resource_metadata"] = resourceMetadata
+		if metadata, metaErr := s.oauth2.Metadata(r.Context()); metaErr == nil && metadata.TokenEndpoint != "" {
+			body["token_endpoint"] = metadata.TokenEndpoint
+		}
+		if s.config.Auth.OAuth2.IssuerURL != "" {
+			body["authorization_server"] = s.config.Auth.OAuth2.IssuerURL
+		}
+	}
+
+	w.Header().Set("WWW-Authenticate", challenge)
+	writeProblemResponse(w, problem)
+}
+
+// createTokenRequest 创建令牌请求体
+type createTokenRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresIn string   `json:"expires_in,omitempty"` // 如 "720h"，留空表示永不过期
+}
+
+// createdToken 创建或轮换令牌的响应，明文令牌只返回这一次
+type createdToken struct {
+	*auth.TokenRecord
+	Token string `json:"token"`
+}
+
+// handleTokens 处理令牌列表和创建
+func (s *mcpServer) handleTokens(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"tokens": s.tokenStore.List()})
+
+	case http.MethodPost:
+		var req createTokenRequest
+		if err := decodeJSONBody(r, &req); err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+
+		var ttl time.Duration
+		if req.ExpiresIn != "" {
+			var err error
+			if ttl, err = time.ParseDuration(req.ExpiresIn); err != nil || ttl <= 0 {
+				writeProblem(w, r, apperrors.Newf(apperrors.ErrInvalidRequest, "无效的有效期: %s", req.ExpiresIn))
+				return
+			}
+		}
+
+		token, record, err := s.tokenStore.Create(auth.CreateTokenRequest{Name: req.Name, Scopes: req.Scopes, TTL: ttl})
+		var tokenID string
+		if record != nil {
+			tokenID = record.ID
+		}
+		s.auditLog.Record(r.Context(), audit.ActionTokenCreate, tokenID, map[string]interface{}{
+			"name":      req.Name,
+			"scopes":    req.Scopes,
+			"expiresIn": req.ExpiresIn,
+		}, err)
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(createdToken{TokenRecord: record, Token: token})
+
+	default:
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "不支持的方法"))
+	}
+}
+
+// handleTokenDetail 处理令牌查询、撤销（DELETE）和轮换（POST /auth/tokens/{id}/rotate）
+func (s *mcpServer) handleTokenDetail(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/auth/tokens/")
+	tokenID, action, _ := strings.Cut(path, "/")
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		record, err := s.tokenStore.Get(tokenID)
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(record)
+
+	case action == "" && r.Method == http.MethodDelete:
+		err := s.tokenStore.Revoke(tokenID)
+		s.auditLog.Record(r.Context(), audit.ActionTokenRevoke, tokenID, nil, err)
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case action == "rotate" && r.Method == http.MethodPost:
+		token, record, err := s.tokenStore.Rotate(tokenID)
+		params := map[string]interface{}{}
+		if record != nil {
+			params["newTokenId"] = record.ID
+		}
+		s.auditLog.Record(r.Context(), audit.ActionTokenRotate, tokenID, params, err)
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(createdToken{TokenRecord: record, Token: token})
+
+	case action == "" || action == "rotate":
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "不支持的方法"))
+
+	default:
+		writeProblem(w, r, apperrors.New(apperrors.ErrResourceNotFound, "未知的令牌操作"))
+	}
+}
+
+// handleProtectedResourceMetadata 返回 OAuth2 受保护资源元数据（RFC 9728）
+func (s *mcpServer) handleProtectedResourceMetadata(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持GET方法"))
+		return
+	}
+
+	metadata := map[string]interface{}{
+		"resource":                 requestBaseURL(r),
+		"bearer_methods_supported": []string{"header"},
+	}
+	if s.config.Auth.OAuth2.IssuerURL != "" {
+		metadata["authorization_servers"] = []string{s.config.Auth.OAuth2.IssuerURL}
+	}
+	if len(s.config.Auth.OAuth2.RequiredScopes) > 0 {
+		metadata["scopes_supported"] = s.config.Auth.OAuth2.RequiredScopes
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metadata)
+}
+
+// requestBaseURL 根据请求推断服务器的外部访问地址
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
+
+// bearerToken 从Authorization头获取token，支持Bearer格式和直接传递token
+func bearerToken(r *http.Request) string {
+	authHeader := r.Header.Get("Authorization")
+	if strings.HasPrefix(authHeader, "Bearer ") {
+		return strings.TrimPrefix(authHeader, "Bearer ")
+	}
+	return authHeader
+}
+
+// authTokenFile 获取当前配置的静态令牌文件
+func (s *mcpServer) authTokenFile() string {
+	s.configMutex.RLock()
+	defer s.configMutex.RUnlock()
+	return s.config.Auth.TokenFile
+}
+
 // loadValidTokens 从文件加载有效的tokens
 func (s *mcpServer) loadValidTokens() ([]string, error) {
-	if s.config.Auth.TokenFile == "" {
+	tokenFile := s.authTokenFile()
+	if tokenFile == "" {
 		return nil, fmt.Errorf("未配置token文件")
 	}
 
-	data, err := os.ReadFile(s.config.Auth.TokenFile)
+	data, err := os.ReadFile(tokenFile)
 	if err != nil {
 		return nil, fmt.Errorf("读取token文件失败: %w", err)
 	}
@@ -0,0 +1,50 @@
+package mcp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"auto-claude-code/internal/logger"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	server := &mcpServer{}
+
+	var seen string
+	handler := server.requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logger.RequestIDFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "沿用客户端请求ID", incoming: "client-123", keep: true},
+		{name: "生成请求ID", incoming: ""},
+		{name: "拒绝非法请求ID", incoming: "bad id\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			if tt.incoming != "" {
+				r.Header.Set(logger.RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			header := w.Header().Get(logger.RequestIDHeader)
+			if header == "" || header != seen {
+				t.Fatalf("响应头 %q 与上下文中的请求ID %q 不一致", header, seen)
+			}
+			if tt.keep && header != tt.incoming {
+				t.Errorf("请求ID = %q，期望沿用 %q", header, tt.incoming)
+			}
+			if !tt.keep && header == tt.incoming {
+				t.Errorf("不应沿用请求ID %q", tt.incoming)
+			}
+		})
+	}
+}
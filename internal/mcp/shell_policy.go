@@ -0,0 +1,94 @@
+package mcp
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// shellOperators 可组合多条命令的 shell 语法，配置了允许列表时默认禁止
+var shellOperators = []string{";", "&", "|", "`", "$(", ">", "<", "\n"}
+
+// shellPolicy run_shell_command 的命令检查策略
+type shellPolicy struct {
+	enabled             bool
+	allowlist           map[string]bool
+	denylist            []*regexp.Regexp
+	allowShellOperators bool
+	timeout             time.Duration // 单条命令的默认超时，0 表示不限制
+}
+
+// newShellPolicy 根据配置创建命令检查策略，无效的正则表达式在配置验证阶段已被拒绝
+func newShellPolicy(cfg config.ShellToolConfig) *shellPolicy {
+	policy := &shellPolicy{
+		enabled:             cfg.Enabled,
+		allowlist:           make(map[string]bool),
+		allowShellOperators: cfg.AllowShellOperators,
+	}
+	policy.timeout, _ = time.ParseDuration(cfg.Timeout)
+	for _, name := range cfg.Allowlist {
+		policy.allowlist[name] = true
+	}
+	for _, pattern := range cfg.Denylist {
+		if re, err := regexp.Compile(pattern); err == nil {
+			policy.denylist = append(policy.denylist, re)
+		}
+	}
+	return policy
+}
+
+// Check 检查命令是否允许执行
+func (p *shellPolicy) Check(command string) error {
+	if !p.enabled {
+		return apperrors.New(apperrors.ErrCommandDenied, "run_shell_command 未启用").
+			WithDetails("请在配置文件中设置 mcp.shell_tool.enabled: true")
+	}
+
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return apperrors.New(apperrors.ErrCommandDenied, "命令不能为空")
+	}
+
+	for _, re := range p.denylist {
+		if re.MatchString(command) {
+			return apperrors.Newf(apperrors.ErrCommandDenied, "命令被禁止规则拦截: %s", re.String())
+		}
+	}
+
+	if len(p.allowlist) == 0 {
+		return nil
+	}
+
+	// 允许列表只检查第一个命令，组合命令可能绕过检查
+	if !p.allowShellOperators {
+		for _, op := range shellOperators {
+			if strings.Contains(command, op) {
+				return apperrors.Newf(apperrors.ErrCommandDenied, "命令包含不允许的 shell 操作符: %q", op)
+			}
+		}
+	}
+
+	name := commandName(command)
+	if !p.allowlist[name] {
+		return apperrors.Newf(apperrors.ErrCommandDenied, "命令不在允许列表中: %s", name)
+	}
+	return nil
+}
+
+// commandName 获取命令名，跳过前置的环境变量赋值并去掉路径
+func commandName(command string) string {
+	for _, field := range strings.Fields(command) {
+		if name, _, ok := strings.Cut(field, "="); ok && envNamePattern.MatchString(name) {
+			continue
+		}
+		return path.Base(field)
+	}
+	return ""
+}
+
+// envNamePattern 环境变量名
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
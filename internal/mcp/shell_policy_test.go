@@ -0,0 +1,38 @@
+package mcp
+
+import (
+	"testing"
+
+	"auto-claude-code/internal/config"
+)
+
+func TestShellPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.ShellToolConfig
+		command string
+		allowed bool
+	}{
+		{"未启用", config.ShellToolConfig{}, "go test ./...", false},
+		{"无允许列表", config.ShellToolConfig{Enabled: true, Denylist: config.DefaultShellDenylist}, "go test ./... && go vet ./...", true},
+		{"禁止 sudo", config.ShellToolConfig{Enabled: true, Denylist: config.DefaultShellDenylist}, "sudo apt install foo", false},
+		{"禁止删除根目录", config.ShellToolConfig{Enabled: true, Denylist: config.DefaultShellDenylist}, "rm -rf /", false},
+		{"允许删除相对路径", config.ShellToolConfig{Enabled: true, Denylist: config.DefaultShellDenylist}, "rm -rf ./build", true},
+		{"空命令", config.ShellToolConfig{Enabled: true}, "  ", false},
+		{"允许列表命中", config.ShellToolConfig{Enabled: true, Allowlist: []string{"go", "npm"}}, "go test ./...", true},
+		{"允许列表跳过环境变量", config.ShellToolConfig{Enabled: true, Allowlist: []string{"go"}}, "CGO_ENABLED=0 go build", true},
+		{"允许列表去掉路径", config.ShellToolConfig{Enabled: true, Allowlist: []string{"npm"}}, "/usr/bin/npm test", true},
+		{"不在允许列表", config.ShellToolConfig{Enabled: true, Allowlist: []string{"go"}}, "curl http://example.com", false},
+		{"组合命令被拒绝", config.ShellToolConfig{Enabled: true, Allowlist: []string{"go"}}, "go test; curl http://example.com", false},
+		{"允许组合命令", config.ShellToolConfig{Enabled: true, Allowlist: []string{"go"}, AllowShellOperators: true}, "go test | tee out.log", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newShellPolicy(tt.cfg).Check(tt.command)
+			if (err == nil) != tt.allowed {
+				t.Errorf("Check(%q) error = %v, 期望允许 = %v", tt.command, err, tt.allowed)
+			}
+		})
+	}
+}
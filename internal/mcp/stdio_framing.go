@@ -0,0 +1,158 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// stdio 消息分帧方式
+const (
+	StdioFramingAuto          = "auto"           // 根据客户端发送的第一条消息自动检测
+	StdioFramingNewline       = "newline"        // 每行一条 JSON 消息
+	StdioFramingContentLength = "content-length" // LSP 风格的 Content-Length 头部分帧
+)
+
+// maxStdioMessageSize 单条 Content-Length 消息的最大字节数
+const maxStdioMessageSize = 64 * 1024 * 1024
+
+// stdioCodec stdio 消息读写器
+type stdioCodec interface {
+	// ReadMessage 读取一条消息，没有更多消息时返回 io.EOF
+	ReadMessage() ([]byte, error)
+
+	// WriteMessage 编码并写入一条消息
+	WriteMessage(msg interface{}) error
+}
+
+// newStdioCodec 创建 stdio 消息读写器，auto 分帧会阻塞到客户端发送第一个字节
+func newStdioCodec(framing string, reader io.Reader, writer io.Writer) (stdioCodec, string, error) {
+	br := bufio.NewReader(reader)
+
+	if framing == "" || framing == StdioFramingAuto {
+		detected, err := detectStdioFraming(br)
+		if err != nil {
+			return nil, "", err
+		}
+		framing = detected
+	}
+
+	switch framing {
+	case StdioFramingNewline:
+		return &newlineCodec{reader: br, writer: writer}, framing, nil
+	case StdioFramingContentLength:
+		return &contentLengthCodec{reader: br, writer: writer}, framing, nil
+	default:
+		return nil, "", apperrors.Newf(apperrors.ErrMCPProtocolError, "不支持的 stdio 分帧方式: %s", framing)
+	}
+}
+
+// detectStdioFraming 根据第一个非空白字节判断分帧方式：JSON 消息以 { 或 [ 开头，否则视为 Content-Length 头部
+func detectStdioFraming(br *bufio.Reader) (string, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return "", err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			br.ReadByte()
+		case '{', '[':
+			return StdioFramingNewline, nil
+		default:
+			return StdioFramingContentLength, nil
+		}
+	}
+}
+
+// newlineCodec 按行分隔的 JSON 消息
+type newlineCodec struct {
+	reader *bufio.Reader
+	writer io.Writer
+}
+
+// ReadMessage 读取下一个非空行
+func (c *newlineCodec) ReadMessage() ([]byte, error) {
+	for {
+		line, err := c.reader.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			return line, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// WriteMessage 写入一行 JSON
+func (c *newlineCodec) WriteMessage(msg interface{}) error {
+	return json.NewEncoder(c.writer).Encode(msg)
+}
+
+// contentLengthCodec Content-Length 头部分帧的消息
+type contentLengthCodec struct {
+	reader *bufio.Reader
+	writer io.Writer
+}
+
+// ReadMessage 读取头部和 Content-Length 指定长度的消息体
+func (c *contentLengthCodec) ReadMessage() ([]byte, error) {
+	length := -1
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && strings.TrimSpace(line) == "" && length < 0 {
+				return nil, io.EOF
+			}
+			return nil, apperrors.Wrap(err, apperrors.ErrMCPProtocolError, "读取消息头部失败")
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			// 消息之间可能有多余的空行
+			if length < 0 {
+				continue
+			}
+			break
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, apperrors.Newf(apperrors.ErrMCPProtocolError, "无效的消息头部: %q", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < 0 || n > maxStdioMessageSize {
+				return nil, apperrors.Newf(apperrors.ErrMCPProtocolError, "无效的 Content-Length: %q", value)
+			}
+			length = n
+		}
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, body); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrMCPProtocolError, "读取消息体失败")
+	}
+	return body, nil
+}
+
+// WriteMessage 写入 Content-Length 头部和 JSON 消息体
+func (c *contentLengthCodec) WriteMessage(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Content-Length: %d\r\n\r\n", len(data))
+	buf.Write(data)
+	_, err = c.writer.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStdioCodec_Read(t *testing.T) {
+	tests := []struct {
+		name     string
+		framing  string
+		input    string
+		detected string
+		messages []string
+	}{
+		{
+			name:     "自动检测按行分隔",
+			framing:  StdioFramingAuto,
+			input:    "{\"id\":1}\n\n{\"id\":2}",
+			detected: StdioFramingNewline,
+			messages: []string{`{"id":1}`, `{"id":2}`},
+		},
+		{
+			name:     "自动检测Content-Length",
+			framing:  StdioFramingAuto,
+			input:    "Content-Length: 8\r\n\r\n{\"id\":1}Content-Length: 8\r\nContent-Type: application/json\r\n\r\n{\"id\":2}",
+			detected: StdioFramingContentLength,
+			messages: []string{`{"id":1}`, `{"id":2}`},
+		},
+		{
+			name:     "消息体包含换行",
+			framing:  StdioFramingContentLength,
+			input:    "content-length: 10\r\n\r\n{\n\"id\":1\n}",
+			detected: StdioFramingContentLength,
+			messages: []string{"{\n\"id\":1\n}"},
+		},
+		{
+			name:     "前导空白",
+			framing:  "",
+			input:    "\r\n  {\"id\":1}\n",
+			detected: StdioFramingNewline,
+			messages: []string{`{"id":1}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, framing, err := newStdioCodec(tt.framing, strings.NewReader(tt.input), io.Discard)
+			if err != nil {
+				t.Fatalf("创建读写器失败: %v", err)
+			}
+			if framing != tt.detected {
+				t.Errorf("分帧方式 = %s, 期望 %s", framing, tt.detected)
+			}
+
+			for i, want := range tt.messages {
+				got, err := codec.ReadMessage()
+				if err != nil {
+					t.Fatalf("读取第 %d 条消息失败: %v", i+1, err)
+				}
+				if string(got) != want {
+					t.Errorf("第 %d 条消息 = %q, 期望 %q", i+1, got, want)
+				}
+			}
+			if _, err := codec.ReadMessage(); err != io.EOF {
+				t.Errorf("读取结束后应返回 io.EOF, 得到 %v", err)
+			}
+		})
+	}
+}
+
+func TestStdioCodec_InvalidContentLength(t *testing.T) {
+	for _, input := range []string{"Content-Length: abc\r\n\r\n{}", "Content-Length: 100\r\n\r\n{}", "garbage\r\n\r\n"} {
+		codec, _, err := newStdioCodec(StdioFramingContentLength, strings.NewReader(input), io.Discard)
+		if err != nil {
+			t.Fatalf("创建读写器失败: %v", err)
+		}
+		if _, err := codec.ReadMessage(); err == nil || err == io.EOF {
+			t.Errorf("输入 %q 应返回错误, 得到 %v", input, err)
+		}
+	}
+}
+
+func TestStdioCodec_Write(t *testing.T) {
+	var buf bytes.Buffer
+	codec, _, err := newStdioCodec(StdioFramingContentLength, strings.NewReader(""), &buf)
+	if err != nil {
+		t.Fatalf("创建读写器失败: %v", err)
+	}
+	if err := codec.WriteMessage(map[string]int{"id": 1}); err != nil {
+		t.Fatalf("写入消息失败: %v", err)
+	}
+	if got, want := buf.String(), "Content-Length: 8\r\n\r\n{\"id\":1}"; got != want {
+		t.Errorf("写入内容 = %q, 期望 %q", got, want)
+	}
+}
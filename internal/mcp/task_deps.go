@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// awaitDependencies 检查任务依赖，依赖尚未全部完成时将任务加入等待列表并返回 true，调用方需持有 tasksMutex
+// 依赖不存在或已经以非成功状态结束时返回错误，任务不会加入等待列表
+func (tm *taskManager) awaitDependencies(req *TaskRequest) (bool, error) {
+	ready, err := tm.dependenciesReady(req.DependsOn)
+	if err != nil || ready {
+		return false, err
+	}
+	tm.waiting[req.ID] = req
+	return true, nil
+}
+
+// dependenciesReady 检查依赖是否全部成功完成，调用方需持有 tasksMutex
+func (tm *taskManager) dependenciesReady(deps []string) (bool, error) {
+	ready := true
+	for _, dep := range deps {
+		status, exists := tm.tasks[dep]
+		switch {
+		case !exists:
+			return false, apperrors.Newf(apperrors.ErrInvalidRequest, "依赖任务不存在: %s", dep)
+		case status.Status == "completed":
+		case isTerminalStatus(status.Status):
+			return false, apperrors.Newf(apperrors.ErrInvalidRequest, "依赖任务 %s 未成功完成 (%s)", dep, status.Status)
+		default:
+			ready = false
+		}
+	}
+	return ready, nil
+}
+
+// resolveDependents 任务结束后处理等待它的任务：依赖全部完成的入队执行，依赖未成功完成的标记为失败
+// 失败的任务同样发送结束事件，其后续任务随之级联失败
+func (tm *taskManager) resolveDependents(taskID string) {
+	var ready []*TaskRequest
+	var failed []*TaskStatus
+
+	tm.tasksMutex.Lock()
+	for id, req := range tm.waiting {
+		if !containsString(req.DependsOn, taskID) {
+			continue
+		}
+		status, exists := tm.tasks[id]
+		if !exists || status.Status != "pending" {
+			delete(tm.waiting, id)
+			continue
+		}
+
+		ok, err := tm.dependenciesReady(req.DependsOn)
+		switch {
+		case err != nil:
+			delete(tm.waiting, id)
+			status.Status = "failed"
+			status.Error = err.Error()
+			status.Message = "依赖任务未成功完成"
+			status.EndTime = time.Now()
+			failed = append(failed, status)
+		case ok:
+			delete(tm.waiting, id)
+			status.Message = "依赖任务已完成，等待执行"
+			ready = append(ready, req)
+		}
+	}
+	tm.tasksMutex.Unlock()
+
+	for _, status := range failed {
+		tm.emit(TaskEventStatus, status)
+	}
+	for _, req := range ready {
+		tm.enqueuePending(req, "任务队列已满，依赖完成后无法入队")
+	}
+}
+
+// enqueuePending 将等待中的任务放入队列，队列已满时将任务标记为失败
+func (tm *taskManager) enqueuePending(req *TaskRequest, fullMessage string) {
+	if tm.taskQueue.push(req) {
+		tm.logger.Info("任务已放入队列", zap.String("taskId", req.ID))
+		return
+	}
+
+	tm.tasksMutex.Lock()
+	status, exists := tm.tasks[req.ID]
+	if exists {
+		status.Status = "failed"
+		status.Message = fullMessage
+		status.NextRetryAt = time.Time{}
+		status.EndTime = time.Now()
+	}
+	tm.tasksMutex.Unlock()
+
+	if exists {
+		tm.emit(TaskEventStatus, status)
+	}
+	tm.logger.Warn(fullMessage, zap.String("taskId", req.ID))
+}
+
+// dependencyMessage 等待依赖的任务状态说明
+func dependencyMessage(deps []string) string {
+	return fmt.Sprintf("等待 %d 个依赖任务完成", len(deps))
+}
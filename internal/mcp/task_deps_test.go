@@ -0,0 +1,124 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// newQueueTestManager 创建不启动工作器的任务管理器，提交的任务留在队列中
+func newQueueTestManager() *taskManager {
+	return &taskManager{
+		config:         &config.MCPConfig{TaskTimeout: "30m"},
+		logger:         logger.FromZap(zap.NewNop()),
+		taskQueue:      newTaskQueue(config.MCPQueueConfig{MaxSize: 10}),
+		tasks:          make(map[string]*TaskStatus),
+		waiting:        make(map[string]*TaskRequest),
+		followUps:      make(map[string]*TaskRequest),
+		requests:       make(map[string]*TaskRequest),
+		projectRunning: make(map[string]int),
+		projectHeld:    make(map[string][]*TaskRequest),
+		listeners:      make(map[int]TaskListener),
+		outputs:        make(map[string]*taskOutput),
+		outputRefs:     make(map[string]string),
+		sessions:       make(map[string]*interactiveSession),
+		distros:        newDistroPool(nil),
+	}
+}
+
+// finishTask 模拟工作器结束任务
+func finishTask(tm *taskManager, id, state, errMessage string) {
+	tm.tasksMutex.Lock()
+	status := tm.tasks[id]
+	status.Status = state
+	status.Error = errMessage
+	status.EndTime = time.Now()
+	tm.tasksMutex.Unlock()
+	tm.emit(TaskEventStatus, status)
+}
+
+// drainQueue 取出队列中的所有任务
+func drainQueue(tm *taskManager) []*TaskRequest {
+	var reqs []*TaskRequest
+	for req := tm.taskQueue.tryPop(); req != nil; req = tm.taskQueue.tryPop() {
+		reqs = append(reqs, req)
+	}
+	return reqs
+}
+
+func TestTaskDependencies(t *testing.T) {
+	ctx := context.Background()
+	tm := newQueueTestManager()
+	submit := func(id string, deps ...string) error {
+		_, err := tm.SubmitTask(ctx, &TaskRequest{ID: id, ProjectPath: "/app", DependsOn: deps})
+		return err
+	}
+	finish := func(id, state string) {
+		finishTask(tm, id, state, "")
+	}
+	queued := func() []string {
+		var ids []string
+		for _, req := range drainQueue(tm) {
+			ids = append(ids, req.ID)
+		}
+		return ids
+	}
+
+	// generate → test → changelog，另有一个任务依赖 generate 和 lint
+	for _, task := range []struct {
+		id   string
+		deps []string
+	}{
+		{"generate", nil},
+		{"lint", nil},
+		{"test", []string{"generate"}},
+		{"changelog", []string{"test"}},
+		{"release", []string{"generate", "lint"}},
+	} {
+		if err := submit(task.id, task.deps...); err != nil {
+			t.Fatalf("提交 %s 失败: %v", task.id, err)
+		}
+	}
+	if got := queued(); len(got) != 2 {
+		t.Fatalf("依赖未完成的任务不应入队: %v", got)
+	}
+	if status, _ := tm.GetTaskStatus(ctx, "changelog"); status.Status != "pending" || len(status.DependsOn) != 1 {
+		t.Errorf("changelog 状态 = %+v", status)
+	}
+
+	if err := submit("orphan", "missing"); !apperrors.IsCode(err, apperrors.ErrInvalidRequest) {
+		t.Errorf("依赖不存在的任务 error = %v", err)
+	}
+	if err := submit("self", "self"); !apperrors.IsCode(err, apperrors.ErrInvalidRequest) {
+		t.Errorf("依赖自身的任务 error = %v", err)
+	}
+
+	finish("generate", "completed")
+	if got := queued(); len(got) != 1 || got[0] != "test" {
+		t.Errorf("generate 完成后入队 = %v, want [test]", got)
+	}
+
+	// 依赖失败时后续任务级联失败
+	finish("test", "failed")
+	if status, _ := tm.GetTaskStatus(ctx, "changelog"); status.Status != "failed" || status.EndTime.IsZero() {
+		t.Errorf("changelog 状态 = %+v", status)
+	}
+	if err := submit("docs", "test"); !apperrors.IsCode(err, apperrors.ErrInvalidRequest) {
+		t.Errorf("依赖已失败的任务 error = %v", err)
+	}
+
+	// 等待期间取消的任务在依赖完成后不再入队
+	if err := tm.CancelTask(ctx, "release"); err != nil {
+		t.Fatalf("CancelTask() error = %v", err)
+	}
+	finish("lint", "completed")
+	if got := queued(); len(got) != 0 || len(tm.waiting) != 0 {
+		t.Errorf("入队 = %v, 等待中 = %d", got, len(tm.waiting))
+	}
+}
@@ -0,0 +1,136 @@
+package mcp
+
+import (
+	"strings"
+	"sync"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// distroPool 按 mcp.distros 为任务选择执行的发行版并统计各发行版执行中的任务数
+type distroPool struct {
+	distros []config.DistroConfig
+
+	mutex   sync.Mutex
+	running map[string]int
+}
+
+// newDistroPool 创建发行版池，未配置发行版时池为空，任务使用请求指定的发行版
+func newDistroPool(distros []config.DistroConfig) *distroPool {
+	return &distroPool{
+		distros: distros,
+		running: make(map[string]int),
+	}
+}
+
+// find 按名称查找池中的发行版，名称不区分大小写
+func (p *distroPool) find(name string) (config.DistroConfig, bool) {
+	for _, d := range p.distros {
+		if strings.EqualFold(d.Name, name) {
+			return d, true
+		}
+	}
+	return config.DistroConfig{}, false
+}
+
+// hasTags 检查发行版是否具有全部标签
+func hasTags(d config.DistroConfig, tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, t := range d.Tags {
+			if strings.EqualFold(t, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// check 检查任务的发行版要求能否满足，在提交时调用
+func (p *distroPool) check(req *TaskRequest) error {
+	if len(req.DistroTags) == 0 {
+		return nil
+	}
+	tags := strings.Join(req.DistroTags, ", ")
+	if len(p.distros) == 0 {
+		return apperrors.New(apperrors.ErrTaskNotSupported, "未配置发行版池 (mcp.distros)，不能按标签选择发行版")
+	}
+	if req.Distro != "" {
+		d, ok := p.find(req.Distro)
+		if !ok {
+			return apperrors.Newf(apperrors.ErrTaskNotSupported, "发行版 %s 不在发行版池中，无法确认其具有标签: %s", req.Distro, tags)
+		}
+		if !hasTags(d, req.DistroTags) {
+			return apperrors.Newf(apperrors.ErrTaskNotSupported, "发行版 %s 不具有全部标签: %s", req.Distro, tags)
+		}
+		return nil
+	}
+	for _, d := range p.distros {
+		if hasTags(d, req.DistroTags) {
+			return nil
+		}
+	}
+	return apperrors.Newf(apperrors.ErrTaskNotSupported, "发行版池中没有具有全部标签的发行版: %s", tags)
+}
+
+// selectLocked 选择执行任务的发行版：请求指定的发行版优先，否则为具有全部所需标签、
+// 执行中任务最少的发行版，任务数相同时按配置顺序；池为空时返回空字符串，表示默认发行版
+func (p *distroPool) selectLocked(req *TaskRequest) string {
+	if req.Distro != "" {
+		if d, ok := p.find(req.Distro); ok {
+			return d.Name
+		}
+		return req.Distro
+	}
+	selected := ""
+	for _, d := range p.distros {
+		if !hasTags(d, req.DistroTags) {
+			continue
+		}
+		if selected == "" || p.running[d.Name] < p.running[selected] {
+			selected = d.Name
+		}
+	}
+	return selected
+}
+
+// pick 选择执行任务的发行版，不计入执行中的任务，用于生成执行计划
+func (p *distroPool) pick(req *TaskRequest) string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.selectLocked(req)
+}
+
+// acquire 选择执行任务的发行版并计入执行中的任务，任务结束后调用 release
+func (p *distroPool) acquire(req *TaskRequest) (distro string, release func()) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	distro = p.selectLocked(req)
+	if distro == "" {
+		return "", func() {}
+	}
+	p.running[distro]++
+	return distro, func() {
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		if p.running[distro]--; p.running[distro] <= 0 {
+			delete(p.running, distro)
+		}
+	}
+}
+
+// stats 获取发行版在池中的标签和执行中的任务数，不在池中时 ok 为 false
+func (p *distroPool) stats(name string) (tags []string, running int, ok bool) {
+	d, ok := p.find(name)
+	if !ok {
+		return nil, 0, false
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return d.Tags, p.running[d.Name], true
+}
@@ -0,0 +1,141 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/wsl"
+)
+
+var testDistros = []config.DistroConfig{
+	{Name: "Ubuntu-GPU", Tags: []string{"gpu", "node20"}},
+	{Name: "Debian", Tags: []string{"node20"}},
+	{Name: "Alpine"},
+}
+
+func TestDistroPoolAcquire(t *testing.T) {
+	pool := newDistroPool(testDistros)
+
+	steps := []struct {
+		req  TaskRequest
+		want string
+	}{
+		{TaskRequest{DistroTags: []string{"GPU"}}, "Ubuntu-GPU"},
+		{TaskRequest{DistroTags: []string{"node20"}}, "Debian"},
+		{TaskRequest{}, "Alpine"},
+		// 执行中任务数相同时按配置顺序
+		{TaskRequest{}, "Ubuntu-GPU"},
+		{TaskRequest{Distro: "debian"}, "Debian"},
+		{TaskRequest{Distro: "Arch"}, "Arch"},
+	}
+	var releases []func()
+	for i, step := range steps {
+		got, release := pool.acquire(&step.req)
+		if got != step.want {
+			t.Errorf("第 %d 次 acquire() = %q, want %q", i, got, step.want)
+		}
+		releases = append(releases, release)
+	}
+
+	if tags, running, ok := pool.stats("ubuntu-gpu"); !ok || running != 2 || len(tags) != 2 {
+		t.Errorf("stats() = %v, %d, %v", tags, running, ok)
+	}
+	for _, release := range releases {
+		release()
+	}
+	if _, running, _ := pool.stats("Debian"); running != 0 {
+		t.Errorf("释放后 running = %d", running)
+	}
+	if _, _, ok := pool.stats("Arch"); ok {
+		t.Error("不在池中的发行版 stats() ok = true")
+	}
+
+	// 未配置发行版池时使用请求指定的发行版或默认发行版
+	got, release := newDistroPool(nil).acquire(&TaskRequest{})
+	release()
+	if got != "" {
+		t.Errorf("空池 acquire() = %q", got)
+	}
+}
+
+func TestDistroPoolCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		distros []config.DistroConfig
+		req     TaskRequest
+		wantErr bool
+	}{
+		{"不要求标签", nil, TaskRequest{Distro: "Arch"}, false},
+		{"未配置发行版池", nil, TaskRequest{DistroTags: []string{"gpu"}}, true},
+		{"有满足标签的发行版", testDistros, TaskRequest{DistroTags: []string{"gpu", "node20"}}, false},
+		{"没有满足标签的发行版", testDistros, TaskRequest{DistroTags: []string{"gpu", "python"}}, true},
+		{"指定的发行版具有标签", testDistros, TaskRequest{Distro: "debian", DistroTags: []string{"node20"}}, false},
+		{"指定的发行版缺少标签", testDistros, TaskRequest{Distro: "Debian", DistroTags: []string{"gpu"}}, true},
+		{"指定的发行版不在池中", testDistros, TaskRequest{Distro: "Arch", DistroTags: []string{"gpu"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newDistroPool(tt.distros).check(&tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !apperrors.IsCode(err, apperrors.ErrTaskNotSupported) {
+				t.Errorf("错误码 = %v", err)
+			}
+		})
+	}
+}
+
+// distroBridge 记录执行任务的发行版
+type distroBridge struct {
+	wsl.WSLBridge
+	mu      sync.Mutex
+	distros []string
+}
+
+func (b *distroBridge) RunClaudeCode(ctx context.Context, distro, workingDir string, args []string, opts *wsl.RunOptions, output *wsl.OutputOptions) (*wsl.ExecResult, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.distros = append(b.distros, distro)
+	return &wsl.ExecResult{}, nil
+}
+
+func TestTaskDistroRouting(t *testing.T) {
+	bridge := &distroBridge{}
+	tm := newQueueTestManager()
+	tm.distros = newDistroPool(testDistros)
+	tm.wslBridge = bridge
+	tm.pathConverter = identityConverter{}
+	tm.worktreeManager = memoryWorktreeManager{}
+	tm.workerCount = 1
+	if err := tm.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer tm.Stop(context.Background())
+
+	ctx := context.Background()
+	if _, err := tm.SubmitTask(ctx, &TaskRequest{ID: "cuda", ProjectPath: "/app", Command: "train", DistroTags: []string{"gpu"}}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	status := waitForStatus(t, tm, "cuda", "completed")
+	if distro, _ := status.Metadata["distro"].(string); distro != "Ubuntu-GPU" {
+		t.Errorf("Metadata[distro] = %q", distro)
+	}
+	if result, ok := status.Result.(*TaskResult); !ok || result.Metadata["distro"] != "Ubuntu-GPU" {
+		t.Errorf("Result = %+v", status.Result)
+	}
+
+	_, err := tm.SubmitTask(ctx, &TaskRequest{ID: "py", ProjectPath: "/app", Command: "test", DistroTags: []string{"python"}})
+	if !apperrors.IsCode(err, apperrors.ErrTaskNotSupported) {
+		t.Errorf("没有满足标签的发行版 SubmitTask() error = %v", err)
+	}
+
+	bridge.mu.Lock()
+	defer bridge.mu.Unlock()
+	if len(bridge.distros) != 1 || bridge.distros[0] != "Ubuntu-GPU" {
+		t.Errorf("RunClaudeCode distro = %v", bridge.distros)
+	}
+}
@@ -0,0 +1,72 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// exportContentType 任务导出的响应类型，每行一个 ArchivedTask
+const exportContentType = "application/x-ndjson"
+
+// ExportTasks 导出 since 之后结束的任务记录，按结束时间从早到晚排序
+// 记录只包含输出引用，不包含输出内容；只能访问自己任务的身份只导出自己的任务
+func (tm *taskManager) ExportTasks(ctx context.Context, since time.Time) ([]ArchivedTask, error) {
+	now := time.Now()
+	finished := tm.finishedTasks(ctx, since.Add(-time.Nanosecond))
+
+	records := make([]ArchivedTask, 0, len(finished))
+	for _, status := range finished {
+		records = append(records, ArchivedTask{Task: status, OutputRef: tm.outputRef(status.ID), ArchivedAt: now})
+	}
+	return records, nil
+}
+
+// handleExport 以 JSON Lines 导出已结束的任务记录，格式与归档文件相同
+// since 为 RFC 3339 时间，按结束时间过滤；status 可重复或用逗号分隔
+func (s *mcpServer) handleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持GET方法"))
+		return
+	}
+
+	query := r.URL.Query()
+	var since time.Time
+	if v := query.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeProblem(w, r, apperrors.Newf(apperrors.ErrInvalidRequest, "无效的 since 参数，需要 RFC 3339 格式: %s", v))
+			return
+		}
+		since = t
+	}
+	statuses := make(map[string]bool)
+	for _, v := range query["status"] {
+		for _, status := range strings.Split(v, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				statuses[status] = true
+			}
+		}
+	}
+
+	records, err := s.taskManager.ExportTasks(r.Context(), since)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", exportContentType)
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		if len(statuses) > 0 && !statuses[record.Task.Status] {
+			continue
+		}
+		if err := encoder.Encode(record); err != nil {
+			return
+		}
+	}
+}
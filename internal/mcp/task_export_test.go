@@ -0,0 +1,83 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-claude-code/internal/auth"
+	"auto-claude-code/internal/config"
+)
+
+func newExportTestManager(now time.Time) *taskManager {
+	tm := newQueueTestManager()
+	tm.tasks = map[string]*TaskStatus{
+		"old":     {ID: "old", Status: "completed", Owner: "alice", EndTime: now.Add(-10 * 24 * time.Hour)},
+		"failed":  {ID: "failed", Status: "failed", Owner: "bob", EndTime: now.Add(-2 * time.Hour)},
+		"done":    {ID: "done", Status: "completed", Owner: "alice", EndTime: now.Add(-time.Hour)},
+		"running": {ID: "running", Status: "running", Owner: "alice"},
+	}
+	tm.outputRefs["done"] = "outputs/done.log"
+	return tm
+}
+
+func TestExportTasks(t *testing.T) {
+	now := time.Now()
+	tm := newExportTestManager(now)
+
+	records, err := tm.ExportTasks(context.Background(), now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatalf("ExportTasks() error = %v", err)
+	}
+	if len(records) != 2 || records[0].Task.ID != "failed" || records[1].Task.ID != "done" {
+		t.Fatalf("records = %+v", records)
+	}
+	if records[1].OutputRef != "outputs/done.log" || records[0].OutputRef != "" || records[1].Output != "" {
+		t.Errorf("输出引用 = %q, %q", records[0].OutputRef, records[1].OutputRef)
+	}
+
+	if records, _ := tm.ExportTasks(context.Background(), time.Time{}); len(records) != 3 {
+		t.Errorf("不限时间导出 %d 个任务, want 3", len(records))
+	}
+
+	alice := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "alice", Method: "token", Scopes: []string{auth.ScopeRead}})
+	records, _ = tm.ExportTasks(alice, time.Time{})
+	if len(records) != 2 || records[0].Task.ID != "old" || records[1].Task.ID != "done" {
+		t.Errorf("alice 导出的任务 = %+v", records)
+	}
+}
+
+func TestHandleExport(t *testing.T) {
+	now := time.Now()
+	server := &mcpServer{config: &config.MCPConfig{}, taskManager: newExportTestManager(now)}
+
+	w := httptest.NewRecorder()
+	query := "?status=completed&since=" + now.Add(-48*time.Hour).UTC().Format(time.RFC3339)
+	server.handleExport(w, httptest.NewRequest(http.MethodGet, "/export"+query, nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != exportContentType {
+		t.Fatalf("状态码 = %d, Content-Type = %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	var ids []string
+	scanner := bufio.NewScanner(strings.NewReader(w.Body.String()))
+	for scanner.Scan() {
+		var record ArchivedTask
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("导出行无效: %v", err)
+		}
+		ids = append(ids, record.Task.ID)
+	}
+	if strings.Join(ids, ",") != "done" {
+		t.Errorf("导出的任务 = %v", ids)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleExport(w, httptest.NewRequest(http.MethodGet, "/export?since=7d", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("无效 since 状态码 = %d", w.Code)
+	}
+}
@@ -0,0 +1,191 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/logger"
+)
+
+// followUpOutputBytes 后续任务命令中附加的前置任务输出末尾字节数
+const followUpOutputBytes = 8 * 1024
+
+// FollowUpTask 任务结束后按结果自动提交的后续任务模板
+// 后续任务使用原任务的项目路径、发行版、资源限制、GPU 设置和 Claude Code 选项，优先级为 0 时沿用原任务
+type FollowUpTask struct {
+	Command       string        `json:"command"`
+	Args          []string      `json:"args,omitempty"`
+	Priority      int           `json:"priority,omitempty"`
+	Timeout       time.Duration `json:"timeout,omitempty"`
+	IncludeOutput bool          `json:"includeOutput,omitempty"` // 将原任务的错误和输出末尾附加到命令之后
+}
+
+// request 根据原任务请求构建后续任务请求，parent 非空时附加原任务的结果
+func (f *FollowUpTask) request(origin *TaskRequest, parent *TaskStatus, output string) *TaskRequest {
+	req := &TaskRequest{
+		Type:        origin.Type,
+		ProjectPath: origin.ProjectPath,
+		Command:     f.Command,
+		Args:        append([]string{}, f.Args...),
+		Priority:    f.Priority,
+		Timeout:     f.Timeout,
+		Limits:      origin.Limits,
+		GPU:         origin.GPU,
+		Distro:      origin.Distro,
+		DistroTags:  origin.DistroTags,
+		MergeBack:   origin.MergeBack,
+		RequestID:   origin.RequestID,
+		client:      origin.client,
+
+		Model:          origin.Model,
+		MaxTurns:       origin.MaxTurns,
+		PermissionMode: origin.PermissionMode,
+		AllowedTools:   origin.AllowedTools,
+	}
+	if req.Priority == 0 {
+		req.Priority = origin.Priority
+	}
+	if parent == nil {
+		return req
+	}
+
+	req.parentID = parent.ID
+	req.owner = parent.Owner
+	req.Context = map[string]interface{}{
+		"parentTaskId": parent.ID,
+		"parentStatus": parent.Status,
+	}
+	if parent.Error != "" {
+		req.Context["parentError"] = parent.Error
+	}
+	if f.IncludeOutput {
+		var b strings.Builder
+		b.WriteString(f.Command)
+		fmt.Fprintf(&b, "\n\n--- 前置任务 %s (%s) ---\n", parent.ID, parent.Status)
+		if parent.Error != "" {
+			fmt.Fprintf(&b, "错误: %s\n", parent.Error)
+		}
+		if tail := outputTail(output, followUpOutputBytes); tail != "" {
+			b.WriteString("输出末尾:\n")
+			b.WriteString(tail)
+		}
+		req.Command = b.String()
+	}
+	return req
+}
+
+// outputTail 获取输出的最后 maxBytes 字节，从完整的 UTF-8 字符开始
+func outputTail(output string, maxBytes int) string {
+	if len(output) <= maxBytes {
+		return output
+	}
+	start := len(output) - maxBytes
+	for start < len(output) && !utf8.RuneStart(output[start]) {
+		start++
+	}
+	return output[start:]
+}
+
+// validateFollowUps 校验后续任务模板，字段错误以 onSuccess.、onFailure. 为前缀
+// 沿用原任务的字段已随原任务校验，只报告模板自身的字段
+func (req *TaskRequest) validateFollowUps(priorityLevels int) []FieldError {
+	var fields []FieldError
+	for _, followUp := range []struct {
+		name string
+		task *FollowUpTask
+	}{
+		{"onSuccess", req.OnSuccess},
+		{"onFailure", req.OnFailure},
+	} {
+		if followUp.task == nil {
+			continue
+		}
+		if strings.TrimSpace(followUp.task.Command) == "" {
+			fields = append(fields, FieldError{Field: followUp.name + ".command", Message: "不能为空"})
+		}
+
+		err := followUp.task.request(req, nil, "").Validate(priorityLevels)
+		var validationErr *RequestValidationError
+		if errors.As(err, &validationErr) {
+			for _, field := range validationErr.Fields {
+				if field.Field != "priority" && field.Field != "timeout" {
+					continue
+				}
+				fields = append(fields, FieldError{Field: followUp.name + "." + field.Field, Message: field.Message})
+			}
+		}
+	}
+	return fields
+}
+
+// followUpFor 获取任务结束状态对应的后续任务模板，成功时为 OnSuccess，失败或超时时为 OnFailure
+func followUpFor(req *TaskRequest, status string) *FollowUpTask {
+	switch status {
+	case "completed":
+		return req.OnSuccess
+	case "failed", "timeout":
+		return req.OnFailure
+	default:
+		return nil
+	}
+}
+
+// submitFollowUp 任务结束后按结果提交后续任务，并在原任务状态中记录后续任务ID
+func (tm *taskManager) submitFollowUp(finished *TaskStatus) {
+	tm.tasksMutex.Lock()
+	origin, exists := tm.followUps[finished.ID]
+	delete(tm.followUps, finished.ID)
+	tm.tasksMutex.Unlock()
+	if !exists {
+		return
+	}
+	followUp := followUpFor(origin, finished.Status)
+	if followUp == nil {
+		return
+	}
+
+	ctx := context.Background()
+	if origin.RequestID != "" {
+		ctx = logger.WithRequestID(ctx, origin.RequestID)
+	}
+	log := logger.FromContext(ctx, tm.logger)
+
+	var output string
+	if followUp.IncludeOutput {
+		var err error
+		if output, err = tm.GetTaskOutput(ctx, finished.ID); err != nil {
+			log.Warn("读取任务输出失败", zap.String("taskId", finished.ID), zap.Error(err))
+		}
+	}
+
+	req := followUp.request(origin, finished, output)
+	status, err := tm.SubmitTask(ctx, req)
+	if err != nil {
+		log.Warn("提交后续任务失败", zap.String("taskId", finished.ID), zap.Error(err))
+		return
+	}
+
+	// 只更新记录，不再发送状态事件，避免重复的任务结束通知
+	tm.tasksMutex.Lock()
+	parent, ok := tm.tasks[finished.ID]
+	var snapshot TaskStatus
+	if ok {
+		parent.FollowUpID = status.ID
+		snapshot = *parent
+	}
+	tm.tasksMutex.Unlock()
+	if ok {
+		tm.saveTask(&snapshot, nil)
+	}
+
+	log.Info("已提交后续任务",
+		zap.String("taskId", finished.ID),
+		zap.String("status", finished.Status),
+		zap.String("followUpId", status.ID))
+}
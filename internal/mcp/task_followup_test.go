@@ -0,0 +1,100 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+func TestSubmitFollowUp(t *testing.T) {
+	ctx := context.Background()
+	tm := newQueueTestManager()
+
+	_, err := tm.SubmitTask(ctx, &TaskRequest{
+		ID:          "build",
+		ProjectPath: "/app",
+		Priority:    3,
+		Model:       "opus",
+		OnSuccess:   &FollowUpTask{Command: "编写变更日志"},
+		OnFailure:   &FollowUpTask{Command: "诊断构建失败的原因", IncludeOutput: true, Priority: 1},
+	})
+	if err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	drainQueue(tm)
+
+	output := &taskOutput{}
+	output.append("compiling...")
+	output.append("error: undefined: foo")
+	tm.outputs["build"] = output
+	finishTask(tm, "build", "failed", "Claude Code退出码非零: 1")
+
+	queued := drainQueue(tm)
+	if len(queued) != 1 {
+		t.Fatalf("失败后入队 %d 个任务, want 1", len(queued))
+	}
+	followUp := queued[0]
+	if !strings.HasPrefix(followUp.Command, "诊断构建失败的原因") ||
+		!strings.Contains(followUp.Command, "Claude Code退出码非零: 1") ||
+		!strings.Contains(followUp.Command, "error: undefined: foo") {
+		t.Errorf("后续任务命令 = %q", followUp.Command)
+	}
+	if followUp.ProjectPath != "/app" || followUp.Priority != 1 || followUp.Model != "opus" ||
+		followUp.Context["parentTaskId"] != "build" || followUp.OnFailure != nil {
+		t.Errorf("后续任务请求 = %+v", followUp)
+	}
+
+	parent, _ := tm.GetTaskStatus(ctx, "build")
+	child, _ := tm.GetTaskStatus(ctx, followUp.ID)
+	if parent.FollowUpID != followUp.ID || child.ParentID != "build" {
+		t.Errorf("followUpId = %s, parentId = %s", parent.FollowUpID, child.ParentID)
+	}
+
+	// 取消的任务不提交后续任务
+	tm.SubmitTask(ctx, &TaskRequest{ID: "lint", ProjectPath: "/app", OnFailure: &FollowUpTask{Command: "修复"}})
+	drainQueue(tm)
+	tm.CancelTask(ctx, "lint")
+	if queued := drainQueue(tm); len(queued) != 0 || len(tm.followUps) != 0 {
+		t.Errorf("取消后入队 = %d, 未结束 = %d", len(queued), len(tm.followUps))
+	}
+}
+
+func TestValidateFollowUps(t *testing.T) {
+	tests := []struct {
+		name     string
+		followUp *FollowUpTask
+		field    string
+	}{
+		{"有效", &FollowUpTask{Command: "修复"}, ""},
+		{"命令为空", &FollowUpTask{}, "onFailure.command"},
+		{"优先级无效", &FollowUpTask{Command: "修复", Priority: 9}, "onFailure.priority"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &TaskRequest{ProjectPath: "/app", OnFailure: tt.followUp}
+			err := req.Validate(3)
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if !apperrors.IsCode(err, apperrors.ErrInvalidRequest) || !strings.Contains(err.Error(), tt.field) {
+				t.Errorf("Validate() error = %v, want %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestOutputTail(t *testing.T) {
+	if got := outputTail("短输出", 100); got != "短输出" {
+		t.Errorf("outputTail() = %q", got)
+	}
+	// 截断位置落在多字节字符中间时跳到下一个字符
+	if got := outputTail("错误输出", 7); got != "输出" {
+		t.Errorf("outputTail() = %q, want 输出", got)
+	}
+}
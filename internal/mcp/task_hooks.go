@@ -0,0 +1,66 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+	"auto-claude-code/internal/tracing"
+	"auto-claude-code/internal/wsl"
+)
+
+// defaultPostCreateTimeout 未配置 post_create_timeout 时每条创建后命令的超时时间
+const defaultPostCreateTimeout = 10 * time.Minute
+
+// runPostCreateHooks 在 WSL 中依次运行项目配置的 post_create 命令，工作目录为worktree
+// 运行期间任务进度保持为 progress，每条命令先以 "$ 命令" 写入一行任务输出，命令的输出通过 onLine 写入任务输出和日志；任一命令失败时停止
+func (tm *taskManager) runPostCreateHooks(ctx context.Context, req *TaskRequest, status *TaskStatus, worktree *WorktreeInfo, progress float64, onLine func(stream, line string)) error {
+	project := findProjectConfig(tm.config.WorktreeProjects, req.ProjectPath)
+	if project == nil || len(project.PostCreate) == 0 {
+		return nil
+	}
+	timeout := defaultPostCreateTimeout
+	if parsed, err := time.ParseDuration(project.PostCreateTimeout); err == nil && parsed > 0 {
+		timeout = parsed
+	}
+	distro, err := taskDistro(req, worktree)
+	if err != nil {
+		return err
+	}
+	log := logger.FromContext(ctx, tm.logger)
+
+	for i, command := range project.PostCreate {
+		tm.updateProgress(status, progress, fmt.Sprintf("正在运行创建后命令 (%d/%d): %s", i+1, len(project.PostCreate), command))
+		onLine(wsl.StreamStdout, "$ "+command)
+
+		hookCtx, span := tracing.Start(ctx, "worktree.post_create", tracing.String("hook.command", command))
+		hookCtx, cancel := context.WithTimeout(hookCtx, timeout)
+		result, err := tm.wslBridge.RunCommand(hookCtx, distro, worktree.WSLPath, command, &wsl.OutputOptions{OnLine: onLine})
+		timedOut := hookCtx.Err() == context.DeadlineExceeded
+		cancel()
+		if err == nil && result.ExitCode != 0 {
+			err = apperrors.Newf(apperrors.ErrWorktreeFailed, "创建后命令退出码非零: %d", result.ExitCode)
+		}
+		span.RecordError(err)
+		span.End()
+		if err != nil {
+			log.Warn("创建后命令失败",
+				zap.String("taskId", req.ID),
+				zap.String("command", command),
+				zap.Error(err))
+			if timedOut {
+				return apperrors.Wrapf(err, apperrors.ErrWorktreeFailed, "创建后命令超时 (%s): %s", timeout, command)
+			}
+			return apperrors.Wrapf(err, apperrors.ErrWorktreeFailed, "创建后命令失败: %s", command)
+		}
+		log.Info("创建后命令完成",
+			zap.String("taskId", req.ID),
+			zap.String("command", command),
+			zap.Duration("duration", result.Duration))
+	}
+	return nil
+}
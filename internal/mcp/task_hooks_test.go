@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/wsl"
+)
+
+// hookBridge 记录运行的命令和工作目录，命令为 "false" 时退出码为 1，其他命令输出 "ran 命令"
+type hookBridge struct {
+	wsl.WSLBridge
+	mutex    sync.Mutex
+	commands []string
+	workDirs []string
+	claude   bool
+}
+
+func (b *hookBridge) RunCommand(ctx context.Context, distro, workingDir, command string, output *wsl.OutputOptions) (*wsl.ExecResult, error) {
+	b.mutex.Lock()
+	b.commands = append(b.commands, command)
+	b.workDirs = append(b.workDirs, workingDir)
+	b.mutex.Unlock()
+	if command == "false" {
+		return &wsl.ExecResult{ExitCode: 1}, nil
+	}
+	output.OnLine(wsl.StreamStdout, "ran "+command)
+	return &wsl.ExecResult{}, nil
+}
+
+func (b *hookBridge) RunClaudeCode(ctx context.Context, distro, workingDir string, args []string, opts *wsl.RunOptions, output *wsl.OutputOptions) (*wsl.ExecResult, error) {
+	b.mutex.Lock()
+	b.claude = true
+	b.mutex.Unlock()
+	return &wsl.ExecResult{}, nil
+}
+
+func TestPostCreateHooks(t *testing.T) {
+	tests := []struct {
+		name         string
+		hooks        []string
+		wantStatus   string
+		wantCommands []string
+		wantClaude   bool
+	}{
+		{"全部成功", []string{"npm ci", "cp /secure/.env .env"}, "completed", []string{"npm ci", "cp /secure/.env .env"}, true},
+		{"失败时停止", []string{"npm ci", "false", "make"}, "failed", []string{"npm ci", "false"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bridge := &hookBridge{}
+			tm := newQueueTestManager()
+			tm.config.WorktreeProjects = []config.WorktreeProjectConfig{{Path: "/app", PostCreate: tt.hooks}}
+			tm.wslBridge = bridge
+			tm.pathConverter = identityConverter{}
+			tm.worktreeManager = memoryWorktreeManager{}
+			tm.workerCount = 1
+			if err := tm.Start(context.Background()); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			defer tm.Stop(context.Background())
+
+			if _, err := tm.SubmitTask(context.Background(), &TaskRequest{ID: "t1", ProjectPath: "/app", Command: "fix"}); err != nil {
+				t.Fatalf("SubmitTask() error = %v", err)
+			}
+			status := waitForStatus(t, tm, "t1", tt.wantStatus)
+
+			bridge.mutex.Lock()
+			defer bridge.mutex.Unlock()
+			if !reflect.DeepEqual(bridge.commands, tt.wantCommands) {
+				t.Errorf("运行的命令 = %v, want %v", bridge.commands, tt.wantCommands)
+			}
+			for _, dir := range bridge.workDirs {
+				if dir != "/app" {
+					t.Errorf("命令的工作目录 = %s, want worktree的 WSL 路径", dir)
+				}
+			}
+			if bridge.claude != tt.wantClaude {
+				t.Errorf("Claude Code 运行 = %v, want %v", bridge.claude, tt.wantClaude)
+			}
+			if !tt.wantClaude && !strings.Contains(status.Error, "false") {
+				t.Errorf("任务错误 = %q, 应包含失败的命令", status.Error)
+			}
+
+			output, err := tm.GetTaskOutput(context.Background(), "t1")
+			if err != nil {
+				t.Fatalf("GetTaskOutput() error = %v", err)
+			}
+			if !strings.Contains(output, "$ npm ci\nran npm ci") {
+				t.Errorf("任务输出中缺少创建后命令的输出:\n%s", output)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/wsl"
+)
+
+// TaskOutputFiles 任务输出日志文件，路径为服务器本地路径，字节数在任务结束时更新
+type TaskOutputFiles struct {
+	Stdout       string `json:"stdout"`
+	Stderr       string `json:"stderr"`
+	StdoutBytes  int64  `json:"stdoutBytes"`
+	StderrBytes  int64  `json:"stderrBytes"`
+	Truncated    bool   `json:"truncated,omitempty"`    // 是否有文件超过 mcp.task_output.max_bytes 后省略了中间的输出
+	OmittedBytes int64  `json:"omittedBytes,omitempty"` // 两个文件中被省略的字节数
+}
+
+// path 获取指定流的日志文件路径
+func (f *TaskOutputFiles) path(stream string) string {
+	if stream == wsl.StreamStderr {
+		return f.Stderr
+	}
+	return f.Stdout
+}
+
+// taskLogFile 单个流的日志文件
+// 设置上限时开头一半直接写入文件，之后的输出只在内存中保留最后一半，关闭时写入省略标记和结尾部分
+type taskLogFile struct {
+	path string
+	file *os.File
+	size int64
+	tail *wsl.TailBuffer // 开头部分写满后创建
+	err  error           // 首次写入错误，之后不再写入
+}
+
+// omitted 获取被省略的字节数
+func (target *taskLogFile) omitted() int64 {
+	if target.tail == nil {
+		return 0
+	}
+	return target.tail.Dropped()
+}
+
+// flushTail 将省略标记和结尾部分写入文件
+func (target *taskLogFile) flushTail() {
+	if target.tail == nil || target.err != nil {
+		return
+	}
+	var data string
+	if omitted := target.tail.Dropped(); omitted > 0 {
+		data = wsl.TruncationMarker(omitted)
+	}
+	data += string(target.tail.Bytes())
+	n, err := target.file.WriteString(data)
+	target.size += int64(n)
+	target.err = err
+}
+
+// taskLogFiles 一次任务执行的 stdout 和 stderr 日志文件，重试时重新创建
+type taskLogFiles struct {
+	mutex    sync.Mutex
+	maxBytes int64 // 0 表示不限制
+	stdout   *taskLogFile
+	stderr   *taskLogFile
+}
+
+// openTaskLogFiles 在 dir 下创建任务的日志文件，已存在的同名文件会被清空
+func openTaskLogFiles(dir, taskID string, maxBytes int64) (*taskLogFiles, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, apperrors.Wrapf(err, apperrors.ErrInternal, "无法创建输出日志目录: %s", dir)
+	}
+
+	logs := &taskLogFiles{maxBytes: maxBytes}
+	for _, target := range []struct {
+		stream string
+		file   **taskLogFile
+	}{
+		{wsl.StreamStdout, &logs.stdout},
+		{wsl.StreamStderr, &logs.stderr},
+	} {
+		path := filepath.Join(dir, taskID+"."+target.stream+".log")
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			logs.close()
+			return nil, apperrors.Wrapf(err, apperrors.ErrInternal, "无法创建输出日志文件: %s", path)
+		}
+		*target.file = &taskLogFile{path: path, file: file}
+	}
+	return logs, nil
+}
+
+// writeLine 将一行输出追加到对应流的文件，未知的流按 stdout 处理
+// 超过上限的一半后写入内存中的结尾部分，写入失败的文件不再写入，错误在 close 时返回
+func (l *taskLogFiles) writeLine(stream, line string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	target := l.stdout
+	if stream == wsl.StreamStderr {
+		target = l.stderr
+	}
+	if target == nil || target.file == nil || target.err != nil {
+		return
+	}
+
+	data := line + "\n"
+	if target.tail == nil && l.maxBytes > 0 && target.size+int64(len(data)) > l.maxBytes/2 {
+		target.tail = wsl.NewTailBuffer(int(l.maxBytes - l.maxBytes/2))
+	}
+	if target.tail != nil {
+		target.tail.Write([]byte(data))
+		return
+	}
+	n, err := target.file.WriteString(data)
+	target.size += int64(n)
+	target.err = err
+}
+
+// files 获取日志文件路径和当前大小
+func (l *taskLogFiles) files() *TaskOutputFiles {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	info := &TaskOutputFiles{}
+	if l.stdout != nil {
+		info.Stdout, info.StdoutBytes = l.stdout.path, l.stdout.size
+		info.OmittedBytes += l.stdout.omitted()
+	}
+	if l.stderr != nil {
+		info.Stderr, info.StderrBytes = l.stderr.path, l.stderr.size
+		info.OmittedBytes += l.stderr.omitted()
+	}
+	info.Truncated = info.OmittedBytes > 0
+	return info
+}
+
+// close 关闭日志文件，返回第一个写入或关闭错误
+func (l *taskLogFiles) close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var firstErr error
+	for _, target := range []*taskLogFile{l.stdout, l.stderr} {
+		if target == nil || target.file == nil {
+			continue
+		}
+		target.flushTail()
+		if err := target.file.Close(); err != nil && target.err == nil {
+			target.err = err
+		}
+		target.file = nil
+		if target.err != nil && firstErr == nil {
+			firstErr = apperrors.Wrapf(target.err, apperrors.ErrInternal, "写入输出日志文件失败: %s", target.path)
+		}
+	}
+	return firstErr
+}
+
+// removeTaskLogFiles 删除任务的输出日志文件
+func removeTaskLogFiles(files *TaskOutputFiles) {
+	if files == nil {
+		return
+	}
+	for _, path := range []string{files.Stdout, files.Stderr} {
+		if path != "" {
+			os.Remove(path)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/wsl"
+)
+
+func TestTaskLogFiles(t *testing.T) {
+	dir := t.TempDir()
+	logs, err := openTaskLogFiles(dir, "task_1", 16)
+	if err != nil {
+		t.Fatalf("openTaskLogFiles() error = %v", err)
+	}
+
+	logs.writeLine("stdout", "hello")
+	logs.writeLine("stderr", "warning")
+	// 超过上限的一半后只保留结尾，关闭时写入省略标记和结尾部分
+	logs.writeLine("", "world")
+	logs.writeLine("stdout", "this line is too long")
+	logs.writeLine("stdout", "tail")
+	if data, _ := os.ReadFile(logs.files().Stdout); string(data) != "hello\n" {
+		t.Errorf("关闭前 stdout = %q", data)
+	}
+	if err := logs.close(); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	files := logs.files()
+	stdout, _ := os.ReadFile(files.Stdout)
+	stderr, _ := os.ReadFile(files.Stderr)
+	if want := "hello\n" + wsl.TruncationMarker(28) + "tail\n"; string(stdout) != want {
+		t.Errorf("stdout = %q, want %q", stdout, want)
+	}
+	if string(stderr) != "warning\n" {
+		t.Errorf("stderr = %q", stderr)
+	}
+	if !files.Truncated || files.OmittedBytes != 28 || files.StdoutBytes != int64(len(stdout)) || files.StderrBytes != 8 {
+		t.Errorf("files = %+v", files)
+	}
+
+	// 关闭后的写入被忽略
+	logs.writeLine("stderr", "late")
+	if data, _ := os.ReadFile(files.Stderr); string(data) != "warning\n" {
+		t.Errorf("关闭后仍写入: %q", data)
+	}
+}
+
+func TestGetTaskStreamOutput(t *testing.T) {
+	logs, err := openTaskLogFiles(t.TempDir(), "task_1", 0)
+	if err != nil {
+		t.Fatalf("openTaskLogFiles() error = %v", err)
+	}
+	logs.writeLine("stderr", "boom")
+	logs.close()
+
+	tm := &taskManager{
+		config: &config.MCPConfig{TaskOutput: config.TaskOutputConfig{Enabled: true}},
+		tasks: map[string]*TaskStatus{
+			"task_1":  {ID: "task_1", Status: "failed", Output: logs.files()},
+			"pending": {ID: "pending", Status: "pending"},
+		},
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		taskID string
+		stream string
+		want   string
+		code   apperrors.ErrorCode
+	}{
+		{"stderr", "task_1", "stderr", "boom\n", ""},
+		{"stdout为空", "task_1", "stdout", "", ""},
+		{"尚未执行", "pending", "stderr", "", ""},
+		{"无效的流", "task_1", "combined", "", apperrors.ErrInvalidRequest},
+		{"任务不存在", "missing", "stdout", "", apperrors.ErrTaskNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tm.GetTaskStreamOutput(ctx, tt.taskID, tt.stream)
+			if apperrors.GetCode(err) != tt.code {
+				t.Fatalf("GetTaskStreamOutput() error = %v, want %s", err, tt.code)
+			}
+			if got != tt.want {
+				t.Errorf("GetTaskStreamOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// 日志文件被清理后返回资源不存在
+	removeTaskLogFiles(tm.tasks["task_1"].Output)
+	if _, err := tm.GetTaskStreamOutput(ctx, "task_1", "stderr"); !apperrors.IsCode(err, apperrors.ErrResourceNotFound) {
+		t.Errorf("删除后 error = %v", err)
+	}
+}
@@ -3,7 +3,12 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -12,6 +17,7 @@ import (
 	"auto-claude-code/internal/converter"
 	apperrors "auto-claude-code/internal/errors"
 	"auto-claude-code/internal/logger"
+	"auto-claude-code/internal/tracing"
 	"auto-claude-code/internal/wsl"
 )
 
@@ -22,13 +28,55 @@ type taskManager struct {
 	wslBridge       wsl.WSLBridge
 	pathConverter   converter.PathConverter
 	worktreeManager WorktreeManager
+	shellPolicy     atomic.Pointer[shellPolicy] // 重新加载配置时整体替换
 
 	// 任务管理
 	tasks       map[string]*TaskStatus
 	tasksMutex  sync.RWMutex
-	taskQueue   chan *TaskRequest
+	taskQueue   *taskQueue
 	workers     []*taskWorker
 	workerCount int
+	waiting     map[string]*TaskRequest // 等待依赖任务完成、尚未入队的任务，由 tasksMutex 保护
+	followUps   map[string]*TaskRequest // 带后续任务模板、尚未结束的任务，由 tasksMutex 保护
+	requests    map[string]*TaskRequest // 任务提交时的请求，用于重新运行，由 tasksMutex 保护
+
+	// 按项目的并发控制，由 tasksMutex 保护
+	projectRunning map[string]int            // 各项目正在执行的任务数
+	projectHeld    map[string][]*TaskRequest // 已出队、等待项目名额的任务
+
+	// 任务输出（内存中按任务保存）
+	outputs      map[string]*taskOutput
+	outputRefs   map[string]string // 已持久化输出的引用，内存中没有输出时从存储读取
+	outputsMutex sync.RWMutex
+
+	// 正在运行的交互式任务的终端会话
+	sessions      map[string]*interactiveSession
+	sessionsMutex sync.Mutex
+
+	// 按 mcp.distros 为任务选择发行版
+	distros *distroPool
+
+	// 按 mcp.pull_requests 推送任务分支并创建拉取请求，未配置项目时为 nil
+	pullRequests *pullRequestPublisher
+
+	// 任务持久化存储，memory 驱动时为 nil
+	store TaskStore
+
+	// 按客户端的任务配额，未启用时为 nil
+	quotas *quotaTracker
+
+	// 队列等待、执行耗时和执行结果的调度指标
+	metrics *schedulerMetrics
+
+	// 任务事件监听器
+	listeners      map[int]TaskListener
+	nextListenerID int
+	listenersMutex sync.RWMutex
+
+	// GPU 检测结果（首次使用时检测并缓存）
+	gpuOnce sync.Once
+	gpuInfo *wsl.GPUInfo
+	gpuErr  error
 
 	// 生命周期管理
 	ctx    context.Context
@@ -36,6 +84,50 @@ type taskManager struct {
 	wg     sync.WaitGroup
 }
 
+// taskOutput 任务输出缓冲，超过 mcp.task_output.max_capture_bytes 时保留开头和结尾
+type taskOutput struct {
+	mutex sync.RWMutex
+	buf   *wsl.CappedBuffer // 为 nil 时不限制
+}
+
+// newTaskOutput 创建最多保存 maxBytes 字节的输出缓冲，0 表示不限制
+func newTaskOutput(maxBytes int64) *taskOutput {
+	return &taskOutput{buf: wsl.NewCappedBuffer(int(maxBytes))}
+}
+
+// append 追加一行输出，返回任务已产生的输出总字节数
+// 截断前与已捕获输出的长度相同，截断后大于已捕获输出的长度
+func (o *taskOutput) append(line string) int {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.buf == nil {
+		o.buf = wsl.NewCappedBuffer(0)
+	}
+	o.buf.WriteString(line + "\n")
+	return int(o.buf.Total())
+}
+
+// String 获取输出内容，截断时开头和结尾之间为省略标记
+func (o *taskOutput) String() string {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	if o.buf == nil {
+		return ""
+	}
+	return o.buf.String()
+}
+
+// truncation 获取输出的截断情况，没有内容被省略时返回 nil
+func (o *taskOutput) truncation() *OutputTruncation {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	if o.buf == nil || o.buf.Omitted() == 0 {
+		return nil
+	}
+	return &OutputTruncation{TotalBytes: o.buf.Total(), OmittedBytes: o.buf.Omitted()}
+}
+
 // taskWorker 任务工作器
 type taskWorker struct {
 	id          int
@@ -43,27 +135,51 @@ type taskWorker struct {
 	ctx         context.Context
 	cancel      context.CancelFunc
 	currentTask *TaskStatus
+	cancelTask  context.CancelFunc // 取消当前任务，结束发行版内的任务进程，不影响工作器本身
 	mutex       sync.RWMutex
 }
 
 // NewTaskManager 创建新的任务管理器
 func NewTaskManager(cfg *config.MCPConfig, log logger.Logger, wslBridge wsl.WSLBridge, worktreeManager WorktreeManager) TaskManager {
-	return &taskManager{
+	tm := &taskManager{
 		config:          cfg,
 		logger:          log,
 		wslBridge:       wslBridge,
-		pathConverter:   converter.NewPathConverter(),
+		pathConverter:   wslBridge.PathConverter(),
 		worktreeManager: worktreeManager,
 		tasks:           make(map[string]*TaskStatus),
-		taskQueue:       make(chan *TaskRequest, cfg.Queue.MaxSize),
+		waiting:         make(map[string]*TaskRequest),
+		followUps:       make(map[string]*TaskRequest),
+		requests:        make(map[string]*TaskRequest),
+		projectRunning:  make(map[string]int),
+		projectHeld:     make(map[string][]*TaskRequest),
+		listeners:       make(map[int]TaskListener),
+		outputs:         make(map[string]*taskOutput),
+		outputRefs:      make(map[string]string),
+		sessions:        make(map[string]*interactiveSession),
+		distros:         newDistroPool(cfg.Distros),
+		pullRequests:    newPullRequestPublisher(cfg.PullRequests),
+		store:           newTaskStore(cfg.Storage, log),
+		quotas:          newQuotaTracker(cfg.Quota),
+		metrics:         newSchedulerMetrics(),
+		taskQueue:       newTaskQueue(cfg.Queue),
 		workerCount:     cfg.MaxConcurrentTasks,
 	}
+	tm.shellPolicy.Store(newShellPolicy(cfg.ShellTool))
+	return tm
 }
 
 // Start 启动任务管理器
 func (tm *taskManager) Start(ctx context.Context) error {
 	tm.ctx, tm.cancel = context.WithCancel(ctx)
 
+	// 先恢复已持久化的任务，避免新提交的任务与之冲突
+	if tm.store != nil {
+		if err := tm.restoreTasks(ctx); err != nil {
+			return err
+		}
+	}
+
 	tm.logger.Info("启动任务管理器",
 		zap.Int("workerCount", tm.workerCount),
 		zap.Int("queueSize", tm.config.Queue.MaxSize))
@@ -113,69 +229,308 @@ func (tm *taskManager) Stop(ctx context.Context) error {
 		return ctx.Err()
 	}
 
+	if tm.store != nil {
+		if err := tm.store.Close(); err != nil {
+			tm.logger.Warn("关闭任务存储失败", zap.Error(err))
+		}
+	}
+
 	return nil
 }
 
+// restoreTasks 从存储加载任务，上次运行时未结束的任务按 mcp.storage.requeue 重新排队或标记为中断
+func (tm *taskManager) restoreTasks(ctx context.Context) error {
+	records, err := tm.store.LoadTasks(ctx)
+	if err != nil {
+		return apperrors.Wrap(err, apperrors.ErrInternal, "加载已保存的任务失败")
+	}
+
+	// 按提交时间排序，重新排队的任务保持原来的先后顺序
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Status.CreatedAt.Before(records[j].Status.CreatedAt)
+	})
+
+	policy := tm.config.Storage.Requeue
+	var requeued []*TaskRequest
+	var changed []*TaskStatus
+	tm.tasksMutex.Lock()
+	tm.outputsMutex.Lock()
+	for _, record := range records {
+		status := record.Status
+		tm.tasks[status.ID] = status
+		if record.OutputRef != "" {
+			tm.outputRefs[status.ID] = record.OutputRef
+		}
+		if record.Request != nil {
+			tm.requests[status.ID] = record.Request
+		}
+		if isTerminalStatus(status.Status) {
+			continue
+		}
+
+		requeue := policy == "all" || (policy != "none" && status.Status == "pending")
+		if requeue && record.Request != nil {
+			status.Status = "pending"
+			status.Progress = 0
+			status.Error = ""
+			status.Message = "服务器重启后重新排队"
+			status.NextRetryAt = time.Time{}
+			requeued = append(requeued, record.Request)
+			if record.Request.OnSuccess != nil || record.Request.OnFailure != nil {
+				tm.followUps[status.ID] = record.Request
+			}
+		} else {
+			markInterrupted(status)
+		}
+		changed = append(changed, status)
+	}
+	tm.outputsMutex.Unlock()
+	tm.tasksMutex.Unlock()
+
+	// 工作器尚未启动，依赖未完成的任务继续等待，依赖未成功完成的任务标记为失败，队列放不下的任务同样标记为中断
+	var dropped, failed int
+	for _, req := range requeued {
+		tm.tasksMutex.Lock()
+		waiting, err := tm.awaitDependencies(req)
+		if err != nil {
+			status := tm.tasks[req.ID]
+			status.Status = "failed"
+			status.Error = err.Error()
+			status.Message = "依赖任务未成功完成"
+			status.EndTime = time.Now()
+			failed++
+		} else if waiting {
+			tm.tasks[req.ID].Message = dependencyMessage(req.DependsOn)
+		}
+		tm.tasksMutex.Unlock()
+		if err != nil || waiting {
+			continue
+		}
+
+		if !tm.taskQueue.push(req) {
+			tm.tasksMutex.Lock()
+			markInterrupted(tm.tasks[req.ID])
+			tm.tasksMutex.Unlock()
+			dropped++
+		}
+	}
+
+	for _, status := range changed {
+		tm.tasksMutex.RLock()
+		snapshot := *status
+		tm.tasksMutex.RUnlock()
+		tm.saveTask(&snapshot, nil)
+	}
+
+	tm.logger.Info("已恢复保存的任务",
+		zap.Int("count", len(records)),
+		zap.Int("requeued", len(requeued)-dropped-failed),
+		zap.Int("dependencyFailed", failed),
+		zap.Int("interrupted", len(changed)-len(requeued)+dropped),
+		zap.String("policy", policy))
+	if dropped > 0 {
+		tm.logger.Warn("任务队列已满，部分任务未能重新排队", zap.Int("count", dropped))
+	}
+	return nil
+}
+
+// markInterrupted 将服务器重启时未结束且未重新排队的任务标记为中断
+func markInterrupted(status *TaskStatus) {
+	status.Status = "interrupted"
+	status.Message = "服务器重启时任务尚未结束"
+	status.EndTime = time.Now()
+}
+
+// saveTask 持久化任务状态快照，req 非空时同时保存提交请求；写入失败只记录日志，不影响任务执行
+func (tm *taskManager) saveTask(snapshot *TaskStatus, req *TaskRequest) error {
+	if tm.store == nil {
+		return nil
+	}
+	err := tm.store.SaveTask(context.Background(), &TaskRecord{Status: snapshot, Request: req})
+	if err != nil {
+		tm.logger.Warn("保存任务状态失败", zap.String("taskId", snapshot.ID), zap.Error(err))
+	}
+	return err
+}
+
+// saveOutput 任务结束时持久化捕获的输出
+func (tm *taskManager) saveOutput(taskID string) {
+	if tm.store == nil {
+		return
+	}
+
+	tm.outputsMutex.RLock()
+	output, ok := tm.outputs[taskID]
+	tm.outputsMutex.RUnlock()
+	if !ok {
+		return
+	}
+
+	ref, err := tm.store.SaveOutput(context.Background(), taskID, output.String())
+	if err != nil {
+		tm.logger.Warn("保存任务输出失败", zap.String("taskId", taskID), zap.Error(err))
+		return
+	}
+
+	tm.outputsMutex.Lock()
+	tm.outputRefs[taskID] = ref
+	tm.outputsMutex.Unlock()
+}
+
+// outputTruncation 获取任务输出的截断情况，没有截断或没有输出时返回 nil
+func (tm *taskManager) outputTruncation(taskID string) *OutputTruncation {
+	tm.outputsMutex.RLock()
+	output, ok := tm.outputs[taskID]
+	tm.outputsMutex.RUnlock()
+	if !ok {
+		return nil
+	}
+	return output.truncation()
+}
+
 // SubmitTask 提交任务
-func (tm *taskManager) SubmitTask(ctx context.Context, req *TaskRequest) (*TaskStatus, error) {
+func (tm *taskManager) SubmitTask(ctx context.Context, req *TaskRequest) (_ *TaskStatus, err error) {
+	if err := req.Validate(tm.config.Queue.PriorityLevels); err != nil {
+		return nil, err
+	}
+
 	// 生成任务ID
 	if req.ID == "" {
 		req.ID = fmt.Sprintf("task_%d", time.Now().UnixNano())
 	}
 
-	// 设置默认超时
-	if req.Timeout == 0 {
-		if timeout, err := time.ParseDuration(tm.config.TaskTimeout); err == nil {
-			req.Timeout = timeout
-		} else {
-			req.Timeout = 30 * time.Minute
-		}
+	ctx, span := tracing.Start(ctx, "task.submit",
+		tracing.String("task.id", req.ID),
+		tracing.String("task.type", req.Type))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+	// 任务执行的 span 挂在提交 span 之下
+	req.traceContext = span.SpanContext()
+
+	if err := tm.checkRequest(ctx, req); err != nil {
+		return nil, err
+	}
+
+	// 关联提交任务的请求ID，便于跨模块排查
+	if req.RequestID == "" {
+		req.RequestID = logger.RequestIDFromContext(ctx)
+	}
+	// 后续任务沿用原任务的客户端计入配额
+	if req.client == "" {
+		req.client = quotaClient(ctx)
+	}
+	if req.owner == "" {
+		req.owner = taskOwner(ctx)
 	}
 
 	// 创建任务状态
 	status := &TaskStatus{
-		ID:       req.ID,
-		Status:   "pending",
-		Progress: 0,
-		Message:  "任务已提交，等待执行",
-		Metadata: make(map[string]interface{}),
+		ID:          req.ID,
+		RequestID:   req.RequestID,
+		Status:      "pending",
+		ProjectPath: req.ProjectPath,
+		Owner:       req.owner,
+		Progress:    0,
+		Message:     "任务已提交，等待执行",
+		CreatedAt:   time.Now(),
+		MaxAttempts: 1 + tm.config.Queue.RetryAttempts,
+		DependsOn:   req.DependsOn,
+		ParentID:    req.parentID,
+		Metadata:    make(map[string]interface{}),
+	}
+	if req.Distro != "" {
+		status.Metadata["distro"] = req.Distro
+	}
+	if req.rerunOf != "" {
+		status.Metadata["rerunOf"] = req.rerunOf
 	}
 
 	// 保存任务状态
 	tm.tasksMutex.Lock()
+	if _, exists := tm.tasks[req.ID]; exists {
+		tm.tasksMutex.Unlock()
+		return nil, apperrors.Newf(apperrors.ErrConflict, "任务ID已存在: %s", req.ID)
+	}
+	if err := tm.quotas.reserve(req.client, req.ID); err != nil {
+		tm.tasksMutex.Unlock()
+		logger.FromContext(ctx, tm.logger).Warn("任务超出客户端配额",
+			zap.String("taskId", req.ID),
+			zap.String("client", req.client),
+			zap.Error(err))
+		return nil, err
+	}
+	// 依赖尚未完成的任务不占用队列，依赖结束时再入队
+	waiting, err := tm.awaitDependencies(req)
+	if err != nil {
+		tm.tasksMutex.Unlock()
+		tm.quotas.release(req.ID)
+		return nil, err
+	}
+	if waiting {
+		status.Message = dependencyMessage(req.DependsOn)
+	}
+	if req.OnSuccess != nil || req.OnFailure != nil {
+		tm.followUps[req.ID] = req
+	}
 	tm.tasks[req.ID] = status
+	tm.requests[req.ID] = req
+	snapshot := *status
 	tm.tasksMutex.Unlock()
 
-	// 提交到队列
-	select {
-	case tm.taskQueue <- req:
-		tm.logger.Info("任务已提交到队列",
+	// 入队前持久化，避免工作器先写入的运行状态被覆盖
+	if err := tm.saveTask(&snapshot, req); err != nil {
+		tm.forgetTask(req.ID)
+		return nil, apperrors.Wrap(err, apperrors.ErrInternal, "保存任务失败")
+	}
+
+	if waiting {
+		logger.FromContext(ctx, tm.logger).Info("任务等待依赖任务完成",
 			zap.String("taskId", req.ID),
-			zap.String("type", req.Type),
-			zap.String("projectPath", req.ProjectPath))
+			zap.Strings("dependsOn", req.DependsOn))
+		tm.emit(TaskEventCreated, status)
 		return status, nil
-	case <-ctx.Done():
-		// 清理任务状态
-		tm.tasksMutex.Lock()
-		delete(tm.tasks, req.ID)
-		tm.tasksMutex.Unlock()
-		return nil, ctx.Err()
-	default:
-		// 队列已满
-		tm.tasksMutex.Lock()
-		delete(tm.tasks, req.ID)
-		tm.tasksMutex.Unlock()
-		return nil, apperrors.New(apperrors.ErrTaskNotSupported, "任务队列已满")
+	}
+
+	// 提交到队列
+	if !tm.taskQueue.push(req) {
+		tm.forgetTask(req.ID)
+		return nil, apperrors.New(apperrors.ErrQueueFull, "任务队列已满")
+	}
+	logger.FromContext(ctx, tm.logger).Info("任务已提交到队列",
+		zap.String("taskId", req.ID),
+		zap.String("type", req.Type),
+		zap.Int("priority", req.Priority),
+		zap.String("projectPath", req.ProjectPath))
+	tm.emit(TaskEventCreated, status)
+	return status, nil
+}
+
+// forgetTask 删除未能入队的任务
+func (tm *taskManager) forgetTask(taskID string) {
+	tm.tasksMutex.Lock()
+	delete(tm.tasks, taskID)
+	delete(tm.waiting, taskID)
+	delete(tm.followUps, taskID)
+	delete(tm.requests, taskID)
+	tm.tasksMutex.Unlock()
+	tm.quotas.release(taskID)
+
+	if tm.store != nil {
+		if err := tm.store.DeleteTask(context.Background(), taskID); err != nil {
+			tm.logger.Warn("删除任务记录失败", zap.String("taskId", taskID), zap.Error(err))
+		}
 	}
 }
 
 // GetTaskStatus 获取任务状态
 func (tm *taskManager) GetTaskStatus(ctx context.Context, taskID string) (*TaskStatus, error) {
 	tm.tasksMutex.RLock()
-	status, exists := tm.tasks[taskID]
-	tm.tasksMutex.RUnlock()
+	defer tm.tasksMutex.RUnlock()
 
-	if !exists {
+	status, exists := tm.tasks[taskID]
+	if !exists || !tm.canAccessTask(ctx, status) {
 		return nil, apperrors.Newf(apperrors.ErrTaskNotFound, "任务不存在: %s", taskID)
 	}
 
@@ -188,43 +543,50 @@ func (tm *taskManager) GetTaskStatus(ctx context.Context, taskID string) (*TaskS
 func (tm *taskManager) CancelTask(ctx context.Context, taskID string) error {
 	tm.tasksMutex.Lock()
 	status, exists := tm.tasks[taskID]
-	if !exists {
+	if !exists || !tm.canAccessTask(ctx, status) {
 		tm.tasksMutex.Unlock()
 		return apperrors.Newf(apperrors.ErrTaskNotFound, "任务不存在: %s", taskID)
 	}
 
 	// 检查任务状态
-	if status.Status == "completed" || status.Status == "failed" || status.Status == "cancelled" {
+	if isTerminalStatus(status.Status) {
 		tm.tasksMutex.Unlock()
 		return apperrors.Newf(apperrors.ErrTaskCancelled, "任务已完成或已取消: %s", taskID)
 	}
 
 	// 标记为取消
+	delete(tm.waiting, taskID)
 	status.Status = "cancelled"
 	status.Message = "任务已取消"
 	status.EndTime = time.Now()
+	snapshot := *status
 	tm.tasksMutex.Unlock()
 
-	// 通知工作器取消任务
+	tm.emitSnapshot(TaskEventStatus, &snapshot)
+
+	// 通知工作器取消任务，任务进程在发行版内按 SIGTERM、SIGKILL 逐级结束
 	for _, worker := range tm.workers {
 		worker.mutex.RLock()
-		if worker.currentTask != nil && worker.currentTask.ID == taskID {
-			worker.cancel()
+		if worker.currentTask != nil && worker.currentTask.ID == taskID && worker.cancelTask != nil {
+			worker.cancelTask()
 		}
 		worker.mutex.RUnlock()
 	}
 
-	tm.logger.Info("任务已取消", zap.String("taskId", taskID))
+	logger.FromContext(ctx, tm.logger).Info("任务已取消", zap.String("taskId", taskID))
 	return nil
 }
 
-// ListTasks 列出所有任务
+// ListTasks 列出请求可以访问的任务
 func (tm *taskManager) ListTasks(ctx context.Context) ([]*TaskStatus, error) {
 	tm.tasksMutex.RLock()
 	defer tm.tasksMutex.RUnlock()
 
 	tasks := make([]*TaskStatus, 0, len(tm.tasks))
 	for _, status := range tm.tasks {
+		if !tm.canAccessTask(ctx, status) {
+			continue
+		}
 		statusCopy := *status
 		tasks = append(tasks, &statusCopy)
 	}
@@ -232,41 +594,377 @@ func (tm *taskManager) ListTasks(ctx context.Context) ([]*TaskStatus, error) {
 	return tasks, nil
 }
 
-// HealthCheck 健康检查
-func (tm *taskManager) HealthCheck(ctx context.Context) error {
-	// 检查工作器状态
-	activeWorkers := 0
-	for _, worker := range tm.workers {
-		select {
-		case <-worker.ctx.Done():
-			// 工作器已停止
-		default:
-			activeWorkers++
+// GetTaskOutput 获取任务已捕获的输出
+func (tm *taskManager) GetTaskOutput(ctx context.Context, taskID string) (string, error) {
+	tm.tasksMutex.RLock()
+	status, exists := tm.tasks[taskID]
+	exists = exists && tm.canAccessTask(ctx, status)
+	tm.tasksMutex.RUnlock()
+	if !exists {
+		return "", apperrors.Newf(apperrors.ErrTaskNotFound, "任务不存在: %s", taskID)
+	}
+
+	tm.outputsMutex.RLock()
+	output, ok := tm.outputs[taskID]
+	ref := tm.outputRefs[taskID]
+	tm.outputsMutex.RUnlock()
+	if ok {
+		return output.String(), nil
+	}
+	if ref != "" && tm.store != nil {
+		return tm.store.ReadOutput(ctx, ref)
+	}
+	return "", nil
+}
+
+// GetTaskStreamOutput 读取任务 stdout 或 stderr 的输出日志文件，任务尚未开始执行时返回空输出
+func (tm *taskManager) GetTaskStreamOutput(ctx context.Context, taskID, stream string) (string, error) {
+	if stream != wsl.StreamStdout && stream != wsl.StreamStderr {
+		return "", apperrors.Newf(apperrors.ErrInvalidRequest, "无效的输出流: %s，支持: stdout, stderr", stream)
+	}
+
+	tm.tasksMutex.RLock()
+	status, exists := tm.tasks[taskID]
+	exists = exists && tm.canAccessTask(ctx, status)
+	var files *TaskOutputFiles
+	if exists {
+		files = status.Output
+	}
+	tm.tasksMutex.RUnlock()
+	if !exists {
+		return "", apperrors.Newf(apperrors.ErrTaskNotFound, "任务不存在: %s", taskID)
+	}
+	if files == nil {
+		if !tm.config.TaskOutput.Enabled {
+			return "", apperrors.New(apperrors.ErrResourceNotFound, "未启用任务输出日志文件 (mcp.task_output.enabled)")
+		}
+		return "", nil
+	}
+
+	data, err := os.ReadFile(files.path(stream))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", apperrors.Newf(apperrors.ErrResourceNotFound, "任务输出日志文件已删除: %s", files.path(stream))
+		}
+		return "", apperrors.Wrap(err, apperrors.ErrInternal, "读取任务输出日志文件失败")
+	}
+	return string(data), nil
+}
+
+// ListDistros 列出可用的 WSL 发行版并标记默认发行版
+func (tm *taskManager) ListDistros(ctx context.Context) ([]DistroInfo, error) {
+	names, err := tm.wslBridge.ListDistros()
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取默认发行版失败不影响列出结果
+	defaultDistro, _ := tm.wslBridge.GetDefaultDistro()
+
+	distros := make([]DistroInfo, 0, len(names))
+	for _, name := range names {
+		info := DistroInfo{
+			Name:    name,
+			Default: name == defaultDistro,
+		}
+		info.Tags, info.Running, info.Pooled = tm.distros.stats(name)
+		distros = append(distros, info)
+	}
+	return distros, nil
+}
+
+// checkRequest 检查任务的 GPU 和发行版要求并设置默认超时，避免任务排队后才失败
+func (tm *taskManager) checkRequest(ctx context.Context, req *TaskRequest) error {
+	// 需要 GPU 的任务在执行环境不支持时直接拒绝
+	if req.GPU {
+		gpu, err := tm.GetGPUInfo(ctx)
+		if err != nil {
+			return apperrors.Wrap(err, apperrors.ErrTaskNotSupported, "无法检测 GPU 支持")
+		}
+		if !gpu.Available() {
+			return apperrors.New(apperrors.ErrTaskNotSupported, "任务需要 GPU，但执行环境中 CUDA 不可用")
+		}
+	}
+
+	// 指定发行版时确认其存在，按标签选择时确认发行版池中有满足要求的发行版
+	if req.Distro != "" {
+		if err := tm.checkDistro(ctx, req.Distro); err != nil {
+			return err
 		}
 	}
+	if err := tm.distros.check(req); err != nil {
+		return err
+	}
 
-	if activeWorkers == 0 {
+	// 设置默认超时
+	if req.Timeout == 0 {
+		if timeout, err := time.ParseDuration(tm.config.TaskTimeout); err == nil {
+			req.Timeout = timeout
+		} else {
+			req.Timeout = 30 * time.Minute
+		}
+	}
+	return nil
+}
+
+// checkDistro 检查发行版是否存在
+func (tm *taskManager) checkDistro(ctx context.Context, distro string) error {
+	distros, err := tm.ListDistros(ctx)
+	if err != nil {
+		return err
+	}
+	for _, d := range distros {
+		if strings.EqualFold(d.Name, distro) {
+			return nil
+		}
+	}
+	return apperrors.Newf(apperrors.ErrDistroNotFound, "WSL 发行版不存在: %s", distro)
+}
+
+// ShellToolEnabled 是否启用了 run_shell_command
+func (tm *taskManager) ShellToolEnabled() bool {
+	return tm.shellPolicy.Load().enabled
+}
+
+// reloadShellTool 应用新的 run_shell_command 配置，之后运行的命令按新策略检查
+func (tm *taskManager) reloadShellTool(cfg config.ShellToolConfig) {
+	tm.shellPolicy.Store(newShellPolicy(cfg))
+}
+
+// RunShellCommand 在任务的 worktree 或项目目录中运行 shell 命令
+func (tm *taskManager) RunShellCommand(ctx context.Context, req *ShellCommandRequest) (*wsl.ExecResult, error) {
+	policy := tm.shellPolicy.Load()
+	if err := policy.Check(req.Command); err != nil {
+		logger.FromContext(ctx, tm.logger).Warn("拒绝运行 shell 命令", zap.String("command", req.Command), zap.Error(err))
+		return nil, err
+	}
+
+	workDir, worktreeDistro, err := tm.resolveShellWorkDir(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// worktree位于发行版中时只能在该发行版中运行，未指定发行版时使用任务所在的发行版
+	distro := req.Distro
+	if worktreeDistro != "" {
+		if distro != "" && distro != worktreeDistro {
+			return nil, apperrors.Newf(apperrors.ErrInvalidRequest, "worktree位于发行版 %s 中，不能在发行版 %s 中运行命令", worktreeDistro, distro)
+		}
+		distro = worktreeDistro
+	}
+	if distro == "" && req.TaskID != "" {
+		tm.tasksMutex.RLock()
+		if status, ok := tm.tasks[req.TaskID]; ok {
+			distro, _ = status.Metadata["distro"].(string)
+		}
+		tm.tasksMutex.RUnlock()
+	}
+	if distro != "" {
+		if err := tm.checkDistro(ctx, distro); err != nil {
+			return nil, err
+		}
+	}
+
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = policy.timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	logger.FromContext(ctx, tm.logger).Info("运行 shell 命令",
+		zap.String("command", req.Command),
+		zap.String("workDir", workDir),
+		zap.String("distro", distro),
+		zap.Duration("timeout", timeout))
+
+	return tm.wslBridge.RunCommand(ctx, distro, workDir, req.Command, nil)
+}
+
+// resolveShellWorkDir 确定 shell 命令的 WSL 工作目录，worktree位于发行版中时同时返回该发行版
+func (tm *taskManager) resolveShellWorkDir(ctx context.Context, req *ShellCommandRequest) (string, string, error) {
+	worktreeID := req.WorktreeID
+	if worktreeID == "" && req.TaskID != "" {
+		status, err := tm.GetTaskStatus(ctx, req.TaskID)
+		if err != nil {
+			return "", "", err
+		}
+		if status.WorktreeID == "" {
+			return "", "", apperrors.Newf(apperrors.ErrWorktreeNotFound, "任务尚未创建工作树: %s", req.TaskID)
+		}
+		worktreeID = status.WorktreeID
+	}
+
+	if worktreeID != "" {
+		worktree, err := tm.worktreeManager.GetWorktree(ctx, worktreeID)
+		if err != nil {
+			return "", "", err
+		}
+		return worktree.WSLPath, worktree.Distro, nil
+	}
+
+	if req.ProjectPath == "" {
+		return "", "", apperrors.New(apperrors.ErrInvalidPath, "必须指定 taskId、worktreeId 或 projectPath 之一")
+	}
+	if err := tm.pathConverter.ValidatePath(req.ProjectPath); err != nil {
+		return "", "", apperrors.Wrap(err, apperrors.ErrInvalidPath, "项目路径验证失败")
+	}
+	wslPath, err := tm.pathConverter.ConvertToWSL(req.ProjectPath)
+	if err != nil {
+		return "", "", apperrors.Wrap(err, apperrors.ErrPathConversion, "路径转换失败")
+	}
+	return wslPath, "", nil
+}
+
+// AddListener 注册任务事件监听器
+func (tm *taskManager) AddListener(listener TaskListener) func() {
+	tm.listenersMutex.Lock()
+	id := tm.nextListenerID
+	tm.nextListenerID++
+	tm.listeners[id] = listener
+	tm.listenersMutex.Unlock()
+
+	return func() {
+		tm.listenersMutex.Lock()
+		delete(tm.listeners, id)
+		tm.listenersMutex.Unlock()
+	}
+}
+
+// emit 发送任务事件，调用方不能持有 tasksMutex
+func (tm *taskManager) emit(eventType string, status *TaskStatus) {
+	tm.tasksMutex.RLock()
+	snapshot := *status
+	tm.tasksMutex.RUnlock()
+
+	tm.emitSnapshot(eventType, &snapshot)
+}
+
+// emitOutput 发送包含新输出行的输出事件，调用方不能持有 tasksMutex
+func (tm *taskManager) emitOutput(status *TaskStatus, output *OutputLine) {
+	tm.tasksMutex.RLock()
+	snapshot := *status
+	tm.tasksMutex.RUnlock()
+
+	tm.emitEvent(TaskEvent{Type: TaskEventOutput, Task: &snapshot, Output: output})
+}
+
+// emitSnapshot 将任务状态快照发送给所有监听器
+func (tm *taskManager) emitSnapshot(eventType string, snapshot *TaskStatus) {
+	tm.emitEvent(TaskEvent{Type: eventType, Task: snapshot})
+}
+
+// emitEvent 将任务事件发送给所有监听器，状态变化同时写入存储
+func (tm *taskManager) emitEvent(event TaskEvent) {
+	if event.Type == TaskEventStatus {
+		tm.saveTask(event.Task, nil)
+	}
+
+	tm.listenersMutex.RLock()
+	for _, listener := range tm.listeners {
+		listener(event)
+	}
+	tm.listenersMutex.RUnlock()
+
+	// 任务结束后释放或级联失败依赖它的任务，并按结果提交后续任务
+	if event.Type == TaskEventStatus && isTerminalStatus(event.Task.Status) {
+		tm.quotas.finish(event.Task.ID)
+		tm.resolveDependents(event.Task.ID)
+		tm.submitFollowUp(event.Task)
+	}
+}
+
+// worktreeOptions 创建任务worktree的选项，创建进度映射到任务进度的 from 到 to 之间
+// 任务的参数和 context 中的值作为上下文文件模板的变量，context 中的键加 "context." 前缀
+func (tm *taskManager) worktreeOptions(req *TaskRequest, status *TaskStatus, from, to float64) CreateWorktreeOptions {
+	vars := map[string]string{"model": req.Model, "distro": req.Distro}
+	for name, value := range req.Params {
+		vars[name] = value
+	}
+	for name, value := range req.Context {
+		vars["context."+name] = fmt.Sprint(value)
+	}
+	return CreateWorktreeOptions{
+		TaskID:      req.ID,
+		Description: req.Command,
+		Vars:        vars,
+		Progress: func(fraction float64, message string) {
+			tm.updateProgress(status, from+(to-from)*fraction, message)
+		},
+	}
+}
+
+// updateProgress 更新任务进度并发送进度事件
+func (tm *taskManager) updateProgress(status *TaskStatus, progress float64, message string) {
+	tm.tasksMutex.Lock()
+	status.Progress = progress
+	status.Message = message
+	snapshot := *status
+	tm.tasksMutex.Unlock()
+
+	tm.emitSnapshot(TaskEventProgress, &snapshot)
+}
+
+// HealthCheck 健康检查
+func (tm *taskManager) HealthCheck(ctx context.Context) error {
+	stats := tm.Stats()
+
+	if stats.ActiveWorkers == 0 {
 		return apperrors.New(apperrors.ErrInstanceFailed, "没有活跃的任务工作器")
 	}
 
 	// 检查队列状态
-	queueLen := len(tm.taskQueue)
-	if queueLen >= tm.config.Queue.MaxSize {
-		return apperrors.New(apperrors.ErrTaskNotSupported, "任务队列已满")
+	if stats.QueueCapacity > 0 && stats.QueueLength >= stats.QueueCapacity {
+		return apperrors.New(apperrors.ErrQueueFull, "任务队列已满")
 	}
 
 	tm.logger.Debug("任务管理器健康检查通过",
-		zap.Int("activeWorkers", activeWorkers),
-		zap.Int("queueLength", queueLen))
+		zap.Int("activeWorkers", stats.ActiveWorkers),
+		zap.Int("queueLength", stats.QueueLength))
 
 	return nil
 }
 
-// runTaskCleaner 运行任务清理器
+// Stats 获取工作器和队列的当前状态
+func (tm *taskManager) Stats() TaskManagerStats {
+	stats := TaskManagerStats{
+		TotalWorkers:  len(tm.workers),
+		QueueLength:   tm.taskQueue.len(),
+		QueueCapacity: tm.config.Queue.MaxSize,
+		QueuePaused:   tm.taskQueue.state().Paused,
+	}
+
+	for _, worker := range tm.workers {
+		select {
+		case <-worker.ctx.Done():
+			// 工作器已停止
+		default:
+			stats.ActiveWorkers++
+		}
+	}
+
+	return stats
+}
+
+// runTaskCleaner 运行任务清理器，启动时先清理一次，之后按 mcp.retention.check_interval 定期清理
+// 配置 mcp.retention.archive_interval 时同时定期归档新结束的任务，与清理在同一协程中执行，避免重复归档
 func (tm *taskManager) runTaskCleaner() {
 	defer tm.wg.Done()
 
-	ticker := time.NewTicker(time.Hour)
+	var archiveTick <-chan time.Time
+	if tm.periodicArchive() {
+		archiveTicker := time.NewTicker(parseDurationOr(tm.config.Retention.ArchiveInterval, defaultRetentionCheckInterval))
+		defer archiveTicker.Stop()
+		archiveTick = archiveTicker.C
+		tm.archiveFinishedTasks()
+	}
+
+	tm.cleanupCompletedTasks()
+
+	ticker := time.NewTicker(parseDurationOr(tm.config.Retention.CheckInterval, defaultRetentionCheckInterval))
 	defer ticker.Stop()
 
 	for {
@@ -275,32 +973,58 @@ func (tm *taskManager) runTaskCleaner() {
 			return
 		case <-ticker.C:
 			tm.cleanupCompletedTasks()
+		case <-archiveTick:
+			tm.archiveFinishedTasks()
 		}
 	}
 }
 
-// cleanupCompletedTasks 清理已完成的任务
+// periodicArchive 是否启用定期归档
+func (tm *taskManager) periodicArchive() bool {
+	return tm.config.Retention.Archive && tm.config.Retention.ArchiveInterval != ""
+}
+
+// cleanupCompletedTasks 按保留策略清理已结束的任务，同时删除输出日志文件和持久化记录
+// 启用归档时先写入归档文件，归档失败则本轮不删除任何任务
 func (tm *taskManager) cleanupCompletedTasks() {
-	tm.tasksMutex.Lock()
-	defer tm.tasksMutex.Unlock()
+	ctx := context.Background()
+	now := time.Now()
 
-	cutoff := time.Now().Add(-24 * time.Hour) // 保留24小时内的任务
-	var toDelete []string
+	finished := tm.finishedTasks(ctx, time.Time{})
+	expired := newRetentionPolicy(tm.config.Retention).expired(finished, now)
+	if len(expired) == 0 {
+		return
+	}
 
-	for taskID, status := range tm.tasks {
-		if (status.Status == "completed" || status.Status == "failed" || status.Status == "cancelled") &&
-			!status.EndTime.IsZero() && status.EndTime.Before(cutoff) {
-			toDelete = append(toDelete, taskID)
+	if tm.config.Retention.Archive {
+		if err := tm.archiveExpiredTasks(ctx, expired, now); err != nil {
+			tm.logger.Warn("归档任务失败，跳过本次清理", zap.Int("count", len(expired)), zap.Error(err))
+			return
 		}
 	}
 
-	for _, taskID := range toDelete {
-		delete(tm.tasks, taskID)
+	tm.tasksMutex.Lock()
+	tm.outputsMutex.Lock()
+	for _, status := range expired {
+		removeTaskLogFiles(status.Output)
+		tm.removeTaskArtifacts(status.ID)
+		delete(tm.tasks, status.ID)
+		delete(tm.requests, status.ID)
+		delete(tm.outputs, status.ID)
+		delete(tm.outputRefs, status.ID)
 	}
+	tm.outputsMutex.Unlock()
+	tm.tasksMutex.Unlock()
 
-	if len(toDelete) > 0 {
-		tm.logger.Info("清理已完成的任务", zap.Int("count", len(toDelete)))
+	if tm.store != nil {
+		for _, status := range expired {
+			if err := tm.store.DeleteTask(ctx, status.ID); err != nil {
+				tm.logger.Warn("删除任务记录失败", zap.String("taskId", status.ID), zap.Error(err))
+			}
+		}
 	}
+
+	tm.logger.Info("清理已结束的任务", zap.Int("count", len(expired)), zap.Bool("archived", tm.config.Retention.Archive))
 }
 
 // run 工作器运行循环
@@ -310,19 +1034,40 @@ func (w *taskWorker) run() {
 	w.manager.logger.Debug("任务工作器启动", zap.Int("workerId", w.id))
 
 	for {
-		select {
-		case <-w.ctx.Done():
+		req := w.manager.taskQueue.pop(w.ctx)
+		if req == nil {
 			w.manager.logger.Debug("任务工作器停止", zap.Int("workerId", w.id))
 			return
-		case req := <-w.manager.taskQueue:
+		}
+		if !w.manager.acquireProjectSlot(req) {
+			continue
+		}
+		// 同一项目的任务结束后直接执行已接替名额的下一个等待任务，队列暂停时等到恢复再执行，停止时等待的任务保持 pending
+		for req != nil && w.ctx.Err() == nil {
 			w.executeTask(req)
+			req = w.manager.releaseProjectSlot(req)
+			if req != nil && !w.manager.taskQueue.waitResumed(w.ctx) {
+				break
+			}
 		}
 	}
 }
 
 // executeTask 执行任务
 func (w *taskWorker) executeTask(req *TaskRequest) {
-	w.manager.logger.Info("开始执行任务",
+	// 任务执行期间的日志沿用提交任务的请求ID，span 沿用提交任务的追踪链路
+	baseCtx := tracing.ContextWithRemoteSpanContext(w.ctx, req.traceContext)
+	if req.RequestID != "" {
+		baseCtx = logger.WithRequestID(baseCtx, req.RequestID)
+	}
+	baseCtx, span := tracing.Start(baseCtx, "task.execute",
+		tracing.String("task.id", req.ID),
+		tracing.String("task.type", req.Type),
+		tracing.Int("worker.id", w.id))
+	defer span.End()
+	log := logger.FromContext(baseCtx, w.manager.logger)
+
+	log.Info("开始执行任务",
 		zap.Int("workerId", w.id),
 		zap.String("taskId", req.ID),
 		zap.String("type", req.Type))
@@ -341,84 +1086,208 @@ func (w *taskWorker) executeTask(req *TaskRequest) {
 		return
 	}
 
+	// 选择执行的发行版，未指定时由发行版池分派，记录到任务状态供 shell 命令使用
+	distro, releaseDistro := w.manager.distros.acquire(req)
+	defer releaseDistro()
+	if distro != "" && status.Metadata["distro"] != distro {
+		// 复制 metadata，避免修改已返回的状态快照
+		metadata := make(map[string]interface{}, len(status.Metadata)+1)
+		for key, value := range status.Metadata {
+			metadata[key] = value
+		}
+		metadata["distro"] = distro
+		status.Metadata = metadata
+	}
+
 	// 更新任务状态
 	status.Status = "running"
 	status.Message = "任务正在执行"
 	status.StartTime = time.Now()
 	status.Progress = 0.1
+	status.Phase = ""
+	status.Usage = nil
+	status.Attempts++
+	status.NextRetryAt = time.Time{}
 	w.manager.tasksMutex.Unlock()
 
-	// 设置当前任务
+	w.manager.metrics.observeWait(req, status.StartTime)
+	w.manager.emit(TaskEventStatus, status)
+	if distro != "" {
+		log.Info("任务分派到发行版", zap.String("taskId", req.ID), zap.String("distro", distro))
+	}
+
+	// 创建任务上下文并设置当前任务
+	taskCtx, taskCancel := context.WithTimeout(baseCtx, req.Timeout)
+	defer taskCancel()
+
 	w.mutex.Lock()
 	w.currentTask = status
+	w.cancelTask = taskCancel
 	w.mutex.Unlock()
 
-	// 创建任务上下文
-	taskCtx, taskCancel := context.WithTimeout(w.ctx, req.Timeout)
-	defer taskCancel()
-
-	// 执行任务
+	// 执行任务，执行时使用选择的发行版，保留原请求供重试时重新选择
+	runReq := req
+	if distro != req.Distro {
+		clone := *req
+		clone.Distro = distro
+		runReq = &clone
+	}
 	var err error
 	switch req.Type {
 	case "claude_code":
-		err = w.executeClaudeCodeTask(taskCtx, req, status)
+		err = w.executeClaudeCodeTask(taskCtx, runReq, status)
+	case "interactive":
+		err = w.executeInteractiveTask(taskCtx, runReq, status)
 	default:
 		err = apperrors.Newf(apperrors.ErrTaskNotSupported, "不支持的任务类型: %s", req.Type)
 	}
 
-	// 更新最终状态
+	// 超过任务超时的错误统一标记为超时，作为暂时性故障重试
+	if err != nil && taskCtx.Err() == context.DeadlineExceeded {
+		err = apperrors.Wrap(err, apperrors.ErrTaskTimeout, "任务执行超时")
+	}
+	span.RecordError(err)
+	w.manager.quotas.recordExecution(req.ID, time.Since(status.StartTime))
+
+	// 先保存输出，最终状态的记录才会带上输出引用
+	w.manager.saveOutput(req.ID)
+	truncation := w.manager.outputTruncation(req.ID)
+
+	// 更新最终状态，暂时性故障在次数用尽前重新排队
+	var retryDelay time.Duration
 	w.manager.tasksMutex.Lock()
-	if err != nil {
+	retry := err != nil && status.Status != "cancelled" && w.ctx.Err() == nil &&
+		status.Attempts < status.MaxAttempts && isRetryableTaskError(err)
+	switch {
+	case status.Status == "cancelled":
+		// 执行期间被取消，保留取消状态，取消导致的执行错误不再视为失败
+		status.Message = "任务已取消，任务进程已终止"
+	case retry:
+		retryDelay = w.manager.retryDelay(status.Attempts)
+		status.Status = "pending"
+		status.Error = err.Error()
+		status.Message = fmt.Sprintf("第 %d 次执行失败，%s 后重试", status.Attempts, retryDelay)
+		status.NextRetryAt = time.Now().Add(retryDelay)
+	case apperrors.HasCode(err, apperrors.ErrTaskTimeout):
+		// 任务进程已在超时时终止，已捕获的输出保留在任务输出和 result 中
+		status.Status = "timeout"
+		status.Error = err.Error()
+		status.Message = fmt.Sprintf("任务执行超过 %s，已终止", req.Timeout)
+		status.EndTime = time.Now()
+	case err != nil:
 		status.Status = "failed"
 		status.Error = err.Error()
 		status.Message = "任务执行失败"
-	} else {
+		status.EndTime = time.Now()
+	default:
 		status.Status = "completed"
+		status.Error = ""
 		status.Message = "任务执行成功"
 		status.Progress = 1.0
+		status.EndTime = time.Now()
 	}
-	status.EndTime = time.Now()
+	status.Phase = ""
+	status.Truncation = truncation
+	cancelled := status.Status == "cancelled"
+	snapshot := *status
 	w.manager.tasksMutex.Unlock()
 
+	w.manager.metrics.observeExecution(req, time.Since(snapshot.StartTime), snapshot.Status)
+
+	// 取消事件已由 CancelTask 发送，这里只更新记录
+	if cancelled {
+		w.manager.saveTask(&snapshot, nil)
+	} else {
+		w.manager.emitSnapshot(TaskEventStatus, &snapshot)
+	}
+	if retry {
+		w.manager.scheduleRetry(req, retryDelay)
+	}
+
 	// 清除当前任务
 	w.mutex.Lock()
 	w.currentTask = nil
+	w.cancelTask = nil
 	w.mutex.Unlock()
 
-	w.manager.logger.Info("任务执行完成",
+	log.Info("任务执行完成",
 		zap.Int("workerId", w.id),
 		zap.String("taskId", req.ID),
 		zap.String("status", status.Status),
 		zap.Error(err))
 }
 
+// openTaskLogs 按 mcp.task_output 创建任务的输出日志文件并记录到任务状态，未启用或创建失败时返回 nil
+func (tm *taskManager) openTaskLogs(ctx context.Context, taskID string, status *TaskStatus) *taskLogFiles {
+	cfg := tm.config.TaskOutput
+	if !cfg.Enabled {
+		return nil
+	}
+
+	logs, err := openTaskLogFiles(cfg.LogDir(), taskID, cfg.MaxBytes)
+	if err != nil {
+		logger.FromContext(ctx, tm.logger).Warn("创建任务输出日志失败", zap.String("taskId", taskID), zap.Error(err))
+		return nil
+	}
+
+	tm.tasksMutex.Lock()
+	status.Output = logs.files()
+	tm.tasksMutex.Unlock()
+	return logs
+}
+
+// closeTaskLogs 关闭任务的输出日志文件，并将最终大小和截断情况写入任务状态
+func (tm *taskManager) closeTaskLogs(ctx context.Context, taskID string, status *TaskStatus, logs *taskLogFiles) {
+	if err := logs.close(); err != nil {
+		logger.FromContext(ctx, tm.logger).Warn("写入任务输出日志失败", zap.String("taskId", taskID), zap.Error(err))
+	}
+
+	tm.tasksMutex.Lock()
+	status.Output = logs.files()
+	tm.tasksMutex.Unlock()
+}
+
+// GetGPUInfo 获取任务执行环境的 GPU 支持情况，检测结果在进程生命周期内缓存
+func (tm *taskManager) GetGPUInfo(ctx context.Context) (*wsl.GPUInfo, error) {
+	tm.gpuOnce.Do(func() {
+		tm.gpuInfo, tm.gpuErr = tm.wslBridge.DetectGPU("")
+		if tm.gpuErr == nil {
+			tm.logger.Info("GPU 支持检测完成",
+				zap.Bool("available", tm.gpuInfo.Available()),
+				zap.Bool("wslg", tm.gpuInfo.WSLg),
+				zap.String("gpu", tm.gpuInfo.GPUName))
+		}
+	})
+	return tm.gpuInfo, tm.gpuErr
+}
+
 // executeClaudeCodeTask 执行Claude Code任务
 func (w *taskWorker) executeClaudeCodeTask(ctx context.Context, req *TaskRequest, status *TaskStatus) error {
-	// 验证路径
+	// 验证并转换路径
+	_, pathSpan := tracing.Start(ctx, "path.convert", tracing.String("path.windows", req.ProjectPath))
 	if err := w.manager.pathConverter.ValidatePath(req.ProjectPath); err != nil {
+		pathSpan.RecordError(err)
+		pathSpan.End()
 		return apperrors.Wrap(err, apperrors.ErrInvalidPath, "项目路径验证失败")
 	}
 
 	// 更新进度
-	w.manager.tasksMutex.Lock()
-	status.Progress = 0.2
-	status.Message = "正在转换路径"
-	w.manager.tasksMutex.Unlock()
+	w.manager.updateProgress(status, 0.2, "正在转换路径")
 
 	// 转换路径
 	wslPath, err := w.manager.pathConverter.ConvertToWSL(req.ProjectPath)
+	pathSpan.RecordError(err)
+	pathSpan.SetAttributes(tracing.String("path.wsl", wslPath))
+	pathSpan.End()
 	if err != nil {
 		return apperrors.Wrap(err, apperrors.ErrPathConversion, "路径转换失败")
 	}
 
 	// 更新进度
-	w.manager.tasksMutex.Lock()
-	status.Progress = 0.4
-	status.Message = "正在创建工作树"
-	w.manager.tasksMutex.Unlock()
+	w.manager.updateProgress(status, 0.4, "正在创建工作树")
 
 	// 创建worktree
-	worktree, err := w.manager.worktreeManager.CreateWorktree(ctx, req.ProjectPath)
+	worktree, err := w.manager.worktreeManager.CreateWorktree(ctx, req.ProjectPath, w.manager.worktreeOptions(req, status, 0.4, 0.6))
 	if err != nil {
 		return apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "创建工作树失败")
 	}
@@ -426,34 +1295,140 @@ func (w *taskWorker) executeClaudeCodeTask(ctx context.Context, req *TaskRequest
 	// 记录worktree ID
 	w.manager.tasksMutex.Lock()
 	status.WorktreeID = worktree.ID
-	status.Progress = 0.6
-	status.Message = "正在启动Claude Code"
 	w.manager.tasksMutex.Unlock()
+	defer w.manager.useWorktree(ctx, worktree.ID)()
 
-	// 构建Claude Code参数
-	args := append([]string{}, req.Args...)
-	if req.Command != "" {
-		args = append([]string{req.Command}, args...)
+	args := w.manager.claudeArgs(req)
+	estimator := newProgressEstimator(args, w.manager.config.TaskProgress.ExpectedTurns)
+
+	// 捕获创建后命令和Claude Code的输出
+	taskOut := newTaskOutput(w.manager.config.TaskOutput.MaxCaptureBytes)
+	w.manager.outputsMutex.Lock()
+	w.manager.outputs[req.ID] = taskOut
+	w.manager.outputsMutex.Unlock()
+
+	log := logger.FromContext(ctx, w.manager.logger)
+
+	// 同时按流写入输出日志文件，创建失败时只保留内存中的输出
+	logs := w.manager.openTaskLogs(ctx, req.ID, status)
+	if logs != nil {
+		defer w.manager.closeTaskLogs(ctx, req.ID, status, logs)
+	}
+	writeLine := func(stream, line string) {
+		log.Debug("任务输出",
+			zap.String("taskId", req.ID),
+			zap.String("stream", stream),
+			zap.String("line", line))
+		if logs != nil {
+			logs.writeLine(stream, line)
+		}
+		offset := taskOut.append(line)
+		w.manager.emitOutput(status, &OutputLine{Stream: stream, Line: line, Offset: offset})
+	}
+
+	// worktree位于发行版中时在该发行版中的worktree运行
+	distro, err := taskDistro(req, worktree)
+	if err != nil {
+		w.manager.worktreeManager.DeleteWorktree(context.Background(), worktree.ID)
+		return err
 	}
+	wslPath = taskWorkDir(worktree, wslPath)
 
-	// 启动Claude Code
-	err = w.manager.wslBridge.StartClaudeCode("", wslPath, args)
+	// 运行项目配置的创建后命令，失败时删除worktree
+	if err := w.manager.runPostCreateHooks(ctx, req, status, worktree, 0.6, writeLine); err != nil {
+		w.manager.worktreeManager.DeleteWorktree(context.Background(), worktree.ID)
+		return err
+	}
+	w.manager.updateProgress(status, 0.6, "正在启动Claude Code")
+
+	// 运行Claude Code
+	output := &wsl.OutputOptions{
+		MaxCaptureBytes: int(w.manager.config.TaskOutput.MaxCaptureBytes),
+		OnLine: func(stream, line string) {
+			writeLine(stream, line)
+			// stream-json 输出按轮次和工具调用更新进度
+			if stream == wsl.StreamStdout && estimator.observe(line) {
+				w.manager.updateEstimate(status, estimator)
+			}
+		},
+	}
+	limits := w.manager.config.TaskLimits.Merge(req.Limits)
+	runOpts := &wsl.RunOptions{
+		Limits: &limits,
+		GPU:    req.GPU,
+		TaskID: req.ID,
+	}
+	execResult, err := w.manager.wslBridge.RunClaudeCode(ctx, distro, wslPath, args, runOpts, output)
 	if err != nil {
+		// 超时或取消时进程已被终止，保留已捕获的部分结果
+		if execResult != nil {
+			w.manager.recordResult(req, status, execResult, wslPath, worktree.ID)
+		}
 		// 清理worktree
 		w.manager.worktreeManager.DeleteWorktree(context.Background(), worktree.ID)
 		return apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "Claude Code启动失败")
 	}
 
-	// 更新进度
-	w.manager.tasksMutex.Lock()
-	status.Progress = 0.9
-	status.Message = "Claude Code执行完成"
-	status.Result = map[string]interface{}{
-		"wslPath":     wslPath,
-		"worktreeId":  worktree.ID,
-		"projectPath": req.ProjectPath,
+	w.manager.recordResult(req, status, execResult, wslPath, worktree.ID)
+	w.manager.recordArtifacts(ctx, status, worktree)
+	w.manager.updateProgress(status, 0.9, "Claude Code执行完成")
+
+	if execResult.ExitCode != 0 {
+		return apperrors.Newf(apperrors.ErrClaudeCodeFailed, "Claude Code退出码非零: %d", execResult.ExitCode)
 	}
-	w.manager.tasksMutex.Unlock()
 
+	w.manager.mergeBack(ctx, req, status, worktree)
+	w.manager.openPullRequest(ctx, req, status, worktree)
 	return nil
 }
+
+// claudeArgs 构建传给 Claude Code 的参数，命令作为第一个参数，任务的模型、权限等选项转换为前置参数
+func (tm *taskManager) claudeArgs(req *TaskRequest) []string {
+	args := append([]string{}, req.Args...)
+	if req.Command != "" {
+		args = append([]string{req.Command}, args...)
+	}
+	args = append(claudeFlags(req), args...)
+	if tm.config.TaskProgress.StreamJSON {
+		args = withStreamJSON(args)
+	}
+	return args
+}
+
+// claudeFlags 将任务的模型、最大轮数、权限模式和允许的工具转换为 Claude Code 参数
+// allowedTools 以逗号连接为一个参数，避免可变参数吞掉其后的命令
+func claudeFlags(req *TaskRequest) []string {
+	var flags []string
+	if req.Model != "" {
+		flags = append(flags, "--model", req.Model)
+	}
+	if req.MaxTurns > 0 {
+		flags = append(flags, "--max-turns", strconv.Itoa(req.MaxTurns))
+	}
+	if req.PermissionMode != "" {
+		flags = append(flags, "--permission-mode", req.PermissionMode)
+	}
+	if len(req.AllowedTools) > 0 {
+		flags = append(flags, "--allowedTools", strings.Join(req.AllowedTools, ","))
+	}
+	return flags
+}
+
+// recordResult 记录 Claude Code 的执行结果
+func (tm *taskManager) recordResult(req *TaskRequest, status *TaskStatus, execResult *wsl.ExecResult, wslPath, worktreeID string) {
+	tm.tasksMutex.Lock()
+	status.Result = &TaskResult{
+		Output:       execResult.Stdout,
+		ExitCode:     execResult.ExitCode,
+		Error:        execResult.Stderr,
+		OmittedBytes: execResult.OmittedBytes,
+		Metadata: map[string]string{
+			"wslPath":     wslPath,
+			"worktreeId":  worktreeID,
+			"projectPath": req.ProjectPath,
+			"distro":      req.Distro,
+			"duration":    execResult.Duration.String(),
+		},
+	}
+	tm.tasksMutex.Unlock()
+}
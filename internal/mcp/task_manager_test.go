@@ -0,0 +1,199 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"auto-claude-code/internal/wsl"
+)
+
+// blockingBridge 命令为 "block" 时运行到 ctx 取消为止，其他命令立即成功
+type blockingBridge struct {
+	wsl.WSLBridge
+	started chan struct{}
+	stopped chan error
+}
+
+func (b *blockingBridge) RunClaudeCode(ctx context.Context, distro, workingDir string, args []string, opts *wsl.RunOptions, output *wsl.OutputOptions) (*wsl.ExecResult, error) {
+	if len(args) == 0 || args[0] != "block" {
+		return &wsl.ExecResult{}, nil
+	}
+	close(b.started)
+	<-ctx.Done()
+	b.stopped <- ctx.Err()
+	return &wsl.ExecResult{ExitCode: -1, Stdout: "partial output"}, ctx.Err()
+}
+
+// identityConverter 原样返回路径
+type identityConverter struct{}
+
+func (identityConverter) ConvertToWSL(path string) (string, error)     { return path, nil }
+func (identityConverter) ConvertToWindows(path string) (string, error) { return path, nil }
+func (identityConverter) ValidatePath(path string) error               { return nil }
+func (identityConverter) IsWindowsPath(path string) bool               { return false }
+func (identityConverter) IsWSLPath(path string) bool                   { return true }
+
+// memoryWorktreeManager 不创建真实 worktree
+type memoryWorktreeManager struct {
+	WorktreeManager
+}
+
+func (memoryWorktreeManager) CreateWorktree(ctx context.Context, projectPath string, opts CreateWorktreeOptions) (*WorktreeInfo, error) {
+	return &WorktreeInfo{ID: "wt_1", ProjectPath: projectPath, WSLPath: projectPath}, nil
+}
+
+func (memoryWorktreeManager) DeleteWorktree(ctx context.Context, worktreeID string) error { return nil }
+
+func (memoryWorktreeManager) SetWorktreeState(ctx context.Context, worktreeID, state string) (*WorktreeInfo, error) {
+	return &WorktreeInfo{ID: worktreeID, Status: state}, nil
+}
+
+// waitForStatus 等待任务进入指定状态
+func waitForStatus(t *testing.T, tm *taskManager, taskID, want string) *TaskStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, err := tm.GetTaskStatus(context.Background(), taskID)
+		if err == nil && status.Status == want {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("任务 %s 状态 = %+v, want %s", taskID, status, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// startBlockingManager 启动使用 blockingBridge 和单个工作器的任务管理器
+func startBlockingManager(t *testing.T) (*taskManager, *blockingBridge) {
+	t.Helper()
+	bridge := &blockingBridge{started: make(chan struct{}), stopped: make(chan error, 1)}
+	tm := newQueueTestManager()
+	tm.wslBridge = bridge
+	tm.pathConverter = identityConverter{}
+	tm.worktreeManager = memoryWorktreeManager{}
+	tm.workerCount = 1
+	if err := tm.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { tm.Stop(context.Background()) })
+	return tm, bridge
+}
+
+func TestCancelRunningTask(t *testing.T) {
+	ctx := context.Background()
+	tm, bridge := startBlockingManager(t)
+
+	if _, err := tm.SubmitTask(ctx, &TaskRequest{ID: "long", ProjectPath: "/app", Command: "block"}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	<-bridge.started
+
+	if err := tm.CancelTask(ctx, "long"); err != nil {
+		t.Fatalf("CancelTask() error = %v", err)
+	}
+	select {
+	case err := <-bridge.stopped:
+		if err != context.Canceled {
+			t.Errorf("任务进程的 ctx 错误 = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("取消后任务进程未被终止")
+	}
+
+	// 取消状态不会被执行错误覆盖，工作器继续处理后续任务
+	if _, err := tm.SubmitTask(ctx, &TaskRequest{ID: "next", ProjectPath: "/app", Command: "fix"}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	waitForStatus(t, tm, "next", "completed")
+	if status := waitForStatus(t, tm, "long", "cancelled"); status.Error != "" {
+		t.Errorf("取消的任务 error = %s", status.Error)
+	}
+}
+
+func TestTaskTimeout(t *testing.T) {
+	ctx := context.Background()
+	tm, bridge := startBlockingManager(t)
+
+	if _, err := tm.SubmitTask(ctx, &TaskRequest{ID: "slow", ProjectPath: "/app", Command: "block", Timeout: time.Second}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	<-bridge.started
+	if err := <-bridge.stopped; err != context.DeadlineExceeded {
+		t.Errorf("任务进程的 ctx 错误 = %v", err)
+	}
+
+	// 超时的任务以 timeout 结束，保留已捕获的部分结果
+	status := waitForStatus(t, tm, "slow", "timeout")
+	result, ok := status.Result.(*TaskResult)
+	if !ok || result.Output != "partial output" || status.Error == "" || status.EndTime.IsZero() {
+		t.Errorf("超时任务状态 = %+v, 结果 = %+v", status, status.Result)
+	}
+}
+
+func TestClaudeArgs(t *testing.T) {
+	tm := newQueueTestManager()
+	req := &TaskRequest{
+		Command:        "fix the tests",
+		Args:           []string{"--verbose"},
+		Model:          "sonnet",
+		MaxTurns:       20,
+		PermissionMode: "acceptEdits",
+		AllowedTools:   []string{"Read", "Bash(git diff:*)"},
+	}
+	want := []string{"--model", "sonnet", "--max-turns", "20", "--permission-mode", "acceptEdits",
+		"--allowedTools", "Read,Bash(git diff:*)", "fix the tests", "--verbose"}
+	if got := tm.claudeArgs(req); !reflect.DeepEqual(got, want) {
+		t.Errorf("claudeArgs() = %v, want %v", got, want)
+	}
+
+	if got := tm.claudeArgs(&TaskRequest{Command: "fix"}); !reflect.DeepEqual(got, []string{"fix"}) {
+		t.Errorf("未设置选项时 claudeArgs() = %v", got)
+	}
+}
+
+// chattyBridge 输出 lines 行后成功退出
+type chattyBridge struct {
+	wsl.WSLBridge
+	lines int
+}
+
+func (b *chattyBridge) RunClaudeCode(ctx context.Context, distro, workingDir string, args []string, opts *wsl.RunOptions, output *wsl.OutputOptions) (*wsl.ExecResult, error) {
+	for i := 0; i < b.lines; i++ {
+		output.OnLine(wsl.StreamStdout, fmt.Sprintf("line %04d", i))
+	}
+	return &wsl.ExecResult{}, nil
+}
+
+func TestTaskOutputTruncation(t *testing.T) {
+	tm := newQueueTestManager()
+	tm.config.TaskOutput.MaxCaptureBytes = 100
+	tm.wslBridge = &chattyBridge{lines: 100}
+	tm.pathConverter = identityConverter{}
+	tm.worktreeManager = memoryWorktreeManager{}
+	tm.workerCount = 1
+	if err := tm.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer tm.Stop(context.Background())
+
+	ctx := context.Background()
+	if _, err := tm.SubmitTask(ctx, &TaskRequest{ID: "noisy", ProjectPath: "/app", Command: "fix"}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+
+	// 保留开头和结尾各 50 字节，任务记录给出总字节数和省略的字节数
+	status := waitForStatus(t, tm, "noisy", "completed")
+	if status.Truncation == nil || status.Truncation.TotalBytes != 1000 || status.Truncation.OmittedBytes != 900 {
+		t.Errorf("Truncation = %+v", status.Truncation)
+	}
+	output, _ := tm.GetTaskOutput(ctx, "noisy")
+	want := "line 0000\nline 0001\nline 0002\nline 0003\nline 0004\n" + wsl.TruncationMarker(900) +
+		"line 0095\nline 0096\nline 0097\nline 0098\nline 0099\n"
+	if output != want {
+		t.Errorf("输出 = %q", output)
+	}
+}
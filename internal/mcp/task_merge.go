@@ -0,0 +1,168 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// maxCommitSubjectRunes 由任务命令生成的提交标题的最大字符数
+const maxCommitSubjectRunes = 72
+
+// mergeOptions 获取任务成功后合并回项目的选项，任务未设置 mergeBack 且 mcp.merge_back 未启用时返回 nil
+func (tm *taskManager) mergeOptions(req *TaskRequest) *MergeOptions {
+	cfg := tm.config.MergeBack
+	var opts MergeOptions
+	switch {
+	case req.MergeBack != nil:
+		opts = *req.MergeBack
+	case cfg.Enabled:
+		opts.DryRun = cfg.DryRun
+	default:
+		return nil
+	}
+	if opts.Strategy == "" {
+		opts.Strategy = cfg.Strategy
+	}
+	if opts.Message == "" {
+		opts.Message = taskCommitMessage(req.ID, req.Command)
+	}
+	return &opts
+}
+
+// taskCommitMessage 由任务命令生成任务分支的提交信息，标题为命令的第一行
+func taskCommitMessage(taskID, command string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(command), "\n")
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		subject = "Apply changes from task " + taskID
+	}
+	if runes := []rune(subject); len(runes) > maxCommitSubjectRunes {
+		subject = string(runes[:maxCommitSubjectRunes-3]) + "..."
+	}
+	return fmt.Sprintf("%s\n\nTask: %s", subject, taskID)
+}
+
+// mergeBack 任务成功后提交 worktree 中的修改并合并回项目，结果记录在任务结果的 MergeBack 中
+// 冲突和合并失败不影响任务结果
+func (tm *taskManager) mergeBack(ctx context.Context, req *TaskRequest, status *TaskStatus, worktree *WorktreeInfo) {
+	opts := tm.mergeOptions(req)
+	if opts == nil {
+		return
+	}
+	tm.updateProgress(status, 0.95, "正在将任务分支合并回项目")
+
+	result, err := tm.worktreeManager.MergeWorktree(ctx, worktree.ID, *opts)
+	log := logger.FromContext(ctx, tm.logger)
+	if err != nil {
+		log.Warn("任务分支合并回项目失败",
+			zap.String("taskId", req.ID),
+			zap.String("worktreeId", worktree.ID),
+			zap.Error(err))
+		result = &MergeResult{Status: MergeStatusFailed, Strategy: opts.Strategy, DryRun: opts.DryRun, Error: err.Error()}
+	} else if result.Status == MergeStatusConflict {
+		log.Warn("任务分支与项目分支冲突，未合并",
+			zap.String("taskId", req.ID),
+			zap.Strings("conflicts", result.Conflicts))
+	}
+
+	tm.tasksMutex.Lock()
+	if taskResult, ok := status.Result.(*TaskResult); ok {
+		taskResult.MergeBack = result
+	}
+	tm.tasksMutex.Unlock()
+}
+
+// MergeTask 将已成功完成的任务的 worktree 合并回项目，结果同时记录在任务结果的 MergeBack 中
+// 冲突时返回 conflict 状态的结果，项目不会被修改
+func (tm *taskManager) MergeTask(ctx context.Context, taskID string, opts MergeOptions) (*MergeResult, error) {
+	if err := newValidationError("合并参数无效", opts.validate("")); err != nil {
+		return nil, err
+	}
+
+	tm.tasksMutex.RLock()
+	status, exists := tm.tasks[taskID]
+	exists = exists && tm.canAccessTask(ctx, status)
+	var state, worktreeID, command string
+	if exists {
+		state, worktreeID = status.Status, status.WorktreeID
+		if origin := tm.requests[taskID]; origin != nil {
+			command = origin.Command
+		}
+	}
+	tm.tasksMutex.RUnlock()
+
+	if !exists {
+		return nil, apperrors.Newf(apperrors.ErrTaskNotFound, "任务不存在: %s", taskID)
+	}
+	if state != "completed" {
+		return nil, apperrors.Newf(apperrors.ErrConflict, "只能合并已成功完成的任务: %s (%s)", taskID, state)
+	}
+	if worktreeID == "" {
+		return nil, apperrors.Newf(apperrors.ErrConflict, "任务没有工作树: %s", taskID)
+	}
+
+	if opts.Strategy == "" {
+		opts.Strategy = tm.config.MergeBack.Strategy
+	}
+	if opts.Message == "" {
+		opts.Message = taskCommitMessage(taskID, command)
+	}
+	result, err := tm.worktreeManager.MergeWorktree(ctx, worktreeID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// 复制任务结果，避免修改已返回的状态快照
+	tm.tasksMutex.Lock()
+	var snapshot TaskStatus
+	taskResult, ok := status.Result.(*TaskResult)
+	if ok {
+		clone := *taskResult
+		clone.MergeBack = result
+		status.Result = &clone
+		snapshot = *status
+	}
+	tm.tasksMutex.Unlock()
+	if ok {
+		tm.saveTask(&snapshot, nil)
+	}
+
+	logger.FromContext(ctx, tm.logger).Info("已合并任务",
+		zap.String("taskId", taskID),
+		zap.String("worktreeId", worktreeID),
+		zap.String("status", result.Status))
+	return result, nil
+}
+
+// handleTaskMerge 将任务的 worktree 合并回项目，请求体为可选的 MergeOptions
+func (s *mcpServer) handleTaskMerge(w http.ResponseWriter, r *http.Request, taskID string) {
+	if r.Method != http.MethodPost {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持POST方法"))
+		return
+	}
+
+	var opts MergeOptions
+	if r.ContentLength != 0 {
+		if err := decodeJSONBody(r, &opts); err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+	}
+
+	result, err := s.taskManager.MergeTask(r.Context(), taskID, opts)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
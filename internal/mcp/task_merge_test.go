@@ -0,0 +1,102 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+func TestMergeOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.MergeBackConfig
+		req  TaskRequest
+		want *MergeOptions
+	}{
+		{"未启用", config.MergeBackConfig{Strategy: "merge"}, TaskRequest{}, nil},
+		{"配置启用", config.MergeBackConfig{Enabled: true, Strategy: "rebase", DryRun: true}, TaskRequest{ID: "t1", Command: "fix"},
+			&MergeOptions{Strategy: "rebase", DryRun: true, Message: "fix\n\nTask: t1"}},
+		{"任务启用", config.MergeBackConfig{Strategy: "merge", DryRun: true}, TaskRequest{ID: "t1", MergeBack: &MergeOptions{Message: "msg"}},
+			&MergeOptions{Strategy: "merge", Message: "msg"}},
+		{"任务覆盖策略", config.MergeBackConfig{Enabled: true, Strategy: "merge"}, TaskRequest{ID: "t1", MergeBack: &MergeOptions{Strategy: "rebase", DryRun: true}},
+			&MergeOptions{Strategy: "rebase", DryRun: true, Message: "Apply changes from task t1\n\nTask: t1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := &taskManager{config: &config.MCPConfig{MergeBack: tt.cfg}}
+			got := tm.mergeOptions(&tt.req)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("mergeOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskCommitMessage(t *testing.T) {
+	if got := taskCommitMessage("t1", "  修复登录\n\n详细说明"); got != "修复登录\n\nTask: t1" {
+		t.Errorf("taskCommitMessage() = %q", got)
+	}
+	long := taskCommitMessage("t1", strings.Repeat("a", 100))
+	if subject, _, _ := strings.Cut(long, "\n"); len([]rune(subject)) != maxCommitSubjectRunes || !strings.HasSuffix(subject, "...") {
+		t.Errorf("过长的标题 = %q", subject)
+	}
+}
+
+// mergeWorktreeManager 返回固定的合并结果并记录合并选项
+type mergeWorktreeManager struct {
+	WorktreeManager
+	opts *MergeOptions
+}
+
+func (m *mergeWorktreeManager) MergeWorktree(ctx context.Context, worktreeID string, opts MergeOptions) (*MergeResult, error) {
+	m.opts = &opts
+	return &MergeResult{Status: MergeStatusMerged, Strategy: opts.Strategy, Branch: "worktree_1", TargetBranch: "main"}, nil
+}
+
+func TestMergeTask(t *testing.T) {
+	ctx := context.Background()
+	worktrees := &mergeWorktreeManager{}
+	tm := newQueueTestManager()
+	tm.config.MergeBack.Strategy = "rebase"
+	tm.worktreeManager = worktrees
+	if _, err := tm.SubmitTask(ctx, &TaskRequest{ID: "t1", ProjectPath: "/app", Command: "实现导出"}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+
+	if _, err := tm.MergeTask(ctx, "t1", MergeOptions{}); !apperrors.IsCode(err, apperrors.ErrConflict) {
+		t.Errorf("未完成的任务 MergeTask() error = %v", err)
+	}
+	if _, err := tm.MergeTask(ctx, "missing", MergeOptions{}); !apperrors.IsCode(err, apperrors.ErrTaskNotFound) {
+		t.Errorf("任务不存在 MergeTask() error = %v", err)
+	}
+	if _, err := tm.MergeTask(ctx, "t1", MergeOptions{Strategy: "squash"}); !apperrors.IsCode(err, apperrors.ErrInvalidRequest) {
+		t.Errorf("无效的策略 MergeTask() error = %v", err)
+	}
+
+	tm.tasksMutex.Lock()
+	tm.tasks["t1"].Status = "completed"
+	tm.tasks["t1"].WorktreeID = "wt_1"
+	tm.tasks["t1"].Result = &TaskResult{Output: "done"}
+	tm.tasksMutex.Unlock()
+
+	before, _ := tm.GetTaskStatus(ctx, "t1")
+	result, err := tm.MergeTask(ctx, "t1", MergeOptions{})
+	if err != nil {
+		t.Fatalf("MergeTask() error = %v", err)
+	}
+	if result.Status != MergeStatusMerged || worktrees.opts.Strategy != "rebase" || worktrees.opts.Message != "实现导出\n\nTask: t1" {
+		t.Errorf("结果 = %+v, 选项 = %+v", result, worktrees.opts)
+	}
+
+	status, _ := tm.GetTaskStatus(ctx, "t1")
+	if taskResult, ok := status.Result.(*TaskResult); !ok || taskResult.MergeBack != result || taskResult.Output != "done" {
+		t.Errorf("Result = %+v", status.Result)
+	}
+	// 已返回的状态快照不受影响
+	if before.Result.(*TaskResult).MergeBack != nil {
+		t.Error("合并修改了已返回的状态快照")
+	}
+}
@@ -0,0 +1,110 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+const (
+	// maxTaskNoteLength 单条备注的最大字符数
+	maxTaskNoteLength = 4096
+	// maxTaskNotes 每个任务最多保存的备注数
+	maxTaskNotes = 100
+)
+
+// TaskNote 附加在任务上的备注，如人工审查意见或分诊状态
+type TaskNote struct {
+	Time   time.Time `json:"time"`
+	Author string    `json:"author,omitempty"` // 已认证请求为提交备注的身份
+	Text   string    `json:"text"`
+}
+
+// taskNoteRequest 添加任务备注的请求体
+type taskNoteRequest struct {
+	Text   string `json:"text"`
+	Author string `json:"author,omitempty"` // 未认证请求的备注作者，已认证请求使用认证身份
+}
+
+// taskNotesResponse 任务备注列表响应
+type taskNotesResponse struct {
+	TaskID string     `json:"taskId"`
+	Notes  []TaskNote `json:"notes"`
+}
+
+// AddTaskNote 为任务追加一条带时间戳的备注并保存，已认证请求的作者为认证身份
+func (tm *taskManager) AddTaskNote(ctx context.Context, taskID string, note TaskNote) (*TaskNote, error) {
+	note.Text = strings.TrimSpace(note.Text)
+	if note.Text == "" {
+		return nil, apperrors.New(apperrors.ErrInvalidRequest, "备注内容不能为空")
+	}
+	if utf8.RuneCountInString(note.Text) > maxTaskNoteLength {
+		return nil, apperrors.Newf(apperrors.ErrInvalidRequest, "备注内容不能超过 %d 个字符", maxTaskNoteLength)
+	}
+	if owner := taskOwner(ctx); owner != "" {
+		note.Author = owner
+	}
+	note.Author = strings.TrimSpace(note.Author)
+	note.Time = time.Now()
+
+	tm.tasksMutex.Lock()
+	status, exists := tm.tasks[taskID]
+	if !exists || !tm.canAccessTask(ctx, status) {
+		tm.tasksMutex.Unlock()
+		return nil, apperrors.Newf(apperrors.ErrTaskNotFound, "任务不存在: %s", taskID)
+	}
+	if len(status.Notes) >= maxTaskNotes {
+		tm.tasksMutex.Unlock()
+		return nil, apperrors.Newf(apperrors.ErrConflict, "任务备注已达到上限 %d 条", maxTaskNotes)
+	}
+	// 复制切片，避免与已返回的状态快照共享底层数组
+	status.Notes = append(status.Notes[:len(status.Notes):len(status.Notes)], note)
+	snapshot := *status
+	tm.tasksMutex.Unlock()
+
+	tm.saveTask(&snapshot, nil)
+	return &note, nil
+}
+
+// handleTaskNotes 列出或添加任务备注
+func (s *mcpServer) handleTaskNotes(w http.ResponseWriter, r *http.Request, taskID string) {
+	ctx := r.Context()
+
+	switch r.Method {
+	case http.MethodGet:
+		status, err := s.taskManager.GetTaskStatus(ctx, taskID)
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+		notes := status.Notes
+		if notes == nil {
+			notes = []TaskNote{}
+		}
+		writeJSONWithETag(w, r, taskNotesResponse{TaskID: taskID, Notes: notes})
+
+	case http.MethodPost:
+		var req taskNoteRequest
+		if err := decodeJSONBody(r, &req); err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+		note, err := s.taskManager.AddTaskNote(ctx, taskID, TaskNote{Text: req.Text, Author: req.Author})
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(note)
+
+	default:
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "不支持的方法"))
+	}
+}
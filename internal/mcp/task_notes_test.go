@@ -0,0 +1,89 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"auto-claude-code/internal/auth"
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+func TestAddTaskNote(t *testing.T) {
+	ctx := context.Background()
+	tm := newQueueTestManager()
+	if _, err := tm.SubmitTask(ctx, &TaskRequest{ID: "t1", ProjectPath: "/app"}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+
+	if _, err := tm.AddTaskNote(ctx, "t1", TaskNote{Text: "  "}); !apperrors.IsCode(err, apperrors.ErrInvalidRequest) {
+		t.Errorf("空备注 error = %v", err)
+	}
+	if _, err := tm.AddTaskNote(ctx, "t1", TaskNote{Text: strings.Repeat("长", maxTaskNoteLength+1)}); !apperrors.IsCode(err, apperrors.ErrInvalidRequest) {
+		t.Errorf("过长备注 error = %v", err)
+	}
+	if _, err := tm.AddTaskNote(ctx, "missing", TaskNote{Text: "x"}); !apperrors.IsCode(err, apperrors.ErrTaskNotFound) {
+		t.Errorf("任务不存在 error = %v", err)
+	}
+
+	// 未认证请求使用请求中的作者，已认证请求使用认证身份
+	before, _ := tm.GetTaskStatus(ctx, "t1")
+	if _, err := tm.AddTaskNote(ctx, "t1", TaskNote{Text: " 已审查 ", Author: "alice"}); err != nil {
+		t.Fatalf("AddTaskNote() error = %v", err)
+	}
+	bob := auth.WithIdentity(ctx, &auth.Identity{Subject: "bob", Method: "token", Scopes: []string{auth.ScopeAdmin}})
+	if note, err := tm.AddTaskNote(bob, "t1", TaskNote{Text: "待合并", Author: "mallory"}); err != nil || note.Author != "bob" {
+		t.Fatalf("AddTaskNote() = %+v, error = %v", note, err)
+	}
+
+	status, _ := tm.GetTaskStatus(ctx, "t1")
+	if len(status.Notes) != 2 || status.Notes[0].Text != "已审查" || status.Notes[0].Author != "alice" ||
+		status.Notes[1].Author != "bob" || status.Notes[0].Time.IsZero() {
+		t.Errorf("Notes = %+v", status.Notes)
+	}
+	if len(before.Notes) != 0 {
+		t.Errorf("已返回的状态快照被修改: %+v", before.Notes)
+	}
+
+	for len(status.Notes) < maxTaskNotes {
+		status.Notes = append(status.Notes, TaskNote{Text: "x"})
+	}
+	tm.tasks["t1"].Notes = status.Notes
+	if _, err := tm.AddTaskNote(ctx, "t1", TaskNote{Text: "x"}); !apperrors.IsCode(err, apperrors.ErrConflict) {
+		t.Errorf("超过备注上限 error = %v", err)
+	}
+}
+
+func TestHandleTaskNotes(t *testing.T) {
+	tm := newQueueTestManager()
+	if _, err := tm.SubmitTask(context.Background(), &TaskRequest{ID: "t1", ProjectPath: "/app"}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	server := &mcpServer{config: &config.MCPConfig{}, taskManager: tm}
+
+	w := httptest.NewRecorder()
+	server.handleTaskDetail(w, httptest.NewRequest(http.MethodPost, "/tasks/t1/notes", strings.NewReader(`{"text":"需要补充测试"}`)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST 状态码 = %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	server.handleTaskDetail(w, httptest.NewRequest(http.MethodPost, "/tasks/t1/notes", strings.NewReader(`{"note":"x"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("未知字段状态码 = %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleTaskDetail(w, httptest.NewRequest(http.MethodGet, "/tasks/t1/notes", nil))
+	var resp taskNotesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("GET 状态码 = %d, error = %v", w.Code, err)
+	}
+	if resp.TaskID != "t1" || len(resp.Notes) != 1 || resp.Notes[0].Text != "需要补充测试" {
+		t.Errorf("响应 = %+v", resp)
+	}
+}
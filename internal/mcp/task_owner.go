@@ -0,0 +1,35 @@
+package mcp
+
+import (
+	"context"
+
+	"auto-claude-code/internal/auth"
+	"auto-claude-code/internal/config"
+)
+
+// taskOwner 获取提交任务的身份，未认证的请求返回空字符串
+func taskOwner(ctx context.Context) string {
+	if identity := auth.IdentityFromContext(ctx); identity != nil {
+		return identity.Subject
+	}
+	return ""
+}
+
+// ownerScope 获取请求只能访问的任务所有者
+// 未认证的请求、admin 角色或启用 mcp.auth.shared_tasks 时可以访问所有任务，返回 false
+func ownerScope(ctx context.Context, cfg *config.MCPConfig) (string, bool) {
+	identity := auth.IdentityFromContext(ctx)
+	if identity == nil || cfg.Auth.SharedTasks {
+		return "", false
+	}
+	if identity.Role(configDefaultRole(cfg)) == auth.RoleAdmin {
+		return "", false
+	}
+	return identity.Subject, true
+}
+
+// canAccessTask 检查请求能否访问任务，其他身份的任务视为不存在
+func (tm *taskManager) canAccessTask(ctx context.Context, status *TaskStatus) bool {
+	owner, scoped := ownerScope(ctx, tm.config)
+	return !scoped || status.Owner == owner
+}
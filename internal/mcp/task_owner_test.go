@@ -0,0 +1,100 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"auto-claude-code/internal/auth"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+func TestTaskOwnership(t *testing.T) {
+	tm := newQueueTestManager()
+	alice := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "alice", Method: "token", Scopes: []string{auth.ScopeSubmit}})
+	bob := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "bob", Method: "token", Scopes: []string{auth.ScopeSubmit}})
+	admin := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "root", Method: "token", Scopes: []string{auth.ScopeAdmin}})
+	local := context.Background()
+
+	status, err := tm.SubmitTask(alice, &TaskRequest{ID: "a1", ProjectPath: "/app", OnSuccess: &FollowUpTask{Command: "写变更日志"}})
+	if err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	if status.Owner != "alice" {
+		t.Errorf("Owner = %q, want alice", status.Owner)
+	}
+	if _, err := tm.SubmitTask(bob, &TaskRequest{ID: "b1", ProjectPath: "/app"}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	if _, err := tm.SubmitTask(local, &TaskRequest{ID: "l1", ProjectPath: "/app"}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	drainQueue(tm)
+
+	ids := func(ctx context.Context) map[string]bool {
+		tasks, _ := tm.ListTasks(ctx)
+		result := make(map[string]bool, len(tasks))
+		for _, task := range tasks {
+			result[task.ID] = true
+		}
+		return result
+	}
+	if got := ids(alice); len(got) != 1 || !got["a1"] {
+		t.Errorf("alice 的任务列表 = %v", got)
+	}
+	if got := ids(admin); len(got) != 3 {
+		t.Errorf("admin 的任务列表 = %v", got)
+	}
+	if got := ids(local); len(got) != 3 {
+		t.Errorf("未认证请求的任务列表 = %v", got)
+	}
+
+	// 其他身份的任务视为不存在
+	if _, err := tm.GetTaskStatus(bob, "a1"); !apperrors.IsCode(err, apperrors.ErrTaskNotFound) {
+		t.Errorf("bob 查看 alice 的任务 error = %v", err)
+	}
+	if _, err := tm.GetTaskOutput(bob, "a1"); !apperrors.IsCode(err, apperrors.ErrTaskNotFound) {
+		t.Errorf("bob 读取 alice 的任务输出 error = %v", err)
+	}
+	if err := tm.CancelTask(bob, "a1"); !apperrors.IsCode(err, apperrors.ErrTaskNotFound) {
+		t.Errorf("bob 取消 alice 的任务 error = %v", err)
+	}
+	if _, err := tm.GetTaskStatus(alice, "l1"); !apperrors.IsCode(err, apperrors.ErrTaskNotFound) {
+		t.Errorf("alice 查看未认证提交的任务 error = %v", err)
+	}
+	if err := tm.CancelTask(admin, "b1"); err != nil {
+		t.Errorf("admin 取消 bob 的任务 error = %v", err)
+	}
+
+	// 后续任务沿用原任务的所有者
+	finishTask(tm, "a1", "completed", "")
+	queued := drainQueue(tm)
+	if len(queued) != 1 {
+		t.Fatalf("后续任务入队 %d 个, want 1", len(queued))
+	}
+	if followUp, err := tm.GetTaskStatus(alice, queued[0].ID); err != nil || followUp.Owner != "alice" {
+		t.Errorf("后续任务 = %+v, error = %v", followUp, err)
+	}
+
+	// shared_tasks 时所有身份共享任务列表
+	tm.config.Auth.SharedTasks = true
+	if got := ids(bob); len(got) != 4 {
+		t.Errorf("shared_tasks 时 bob 的任务列表 = %v", got)
+	}
+}
+
+func TestEventSubscriberOwnerScope(t *testing.T) {
+	broker := newTestBroker(t)
+	sub := &eventSubscriber{owner: "alice", scoped: true}
+	broker.subscribe(sub, 0)
+	defer broker.unsubscribe(sub)
+
+	broker.HandleTaskEvent(TaskEvent{Type: TaskEventCreated, Task: &TaskStatus{ID: "b1", Owner: "bob", Status: "pending"}})
+	broker.HandleTaskEvent(TaskEvent{Type: TaskEventCreated, Task: &TaskStatus{ID: "a1", Owner: "alice", Status: "pending"}})
+
+	if len(sub.events) != 1 {
+		t.Fatalf("收到 %d 个事件, want 1", len(sub.events))
+	}
+	if event := <-sub.events; event.Task.ID != "a1" || event.Task.Owner != "alice" {
+		t.Errorf("event = %+v", event.Task)
+	}
+}
@@ -0,0 +1,78 @@
+package mcp
+
+import (
+	"context"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// TaskPlan 任务的执行计划，由 validateOnly 提交返回，不创建任务
+type TaskPlan struct {
+	ProjectPath string                `json:"projectPath"`
+	WSLPath     string                `json:"wslPath"`
+	Distro      string                `json:"distro"` // 未指定发行版时为发行版池选择的发行版或默认发行版
+	Args        []string              `json:"args"`   // 传给 Claude Code 的完整参数
+	Priority    int                   `json:"priority"`
+	Timeout     string                `json:"timeout"`
+	GPU         bool                  `json:"gpu,omitempty"`
+	Limits      config.ResourceLimits `json:"limits"`
+	Worktree    *WorktreePlan         `json:"worktree"`
+
+	// WaitsForDependencies 依赖尚未完成，提交后任务会先等待依赖
+	WaitsForDependencies bool `json:"waitsForDependencies,omitempty"`
+}
+
+// PlanTask 执行提交和运行任务前的全部检查并返回执行计划，不创建任务、不创建工作树也不运行 Claude Code
+// 检查依次为：请求校验、GPU 和发行版、依赖、路径验证和转换、Claude Code 可用性、工作树可行性
+func (tm *taskManager) PlanTask(ctx context.Context, req *TaskRequest) (*TaskPlan, error) {
+	if err := req.Validate(tm.config.Queue.PriorityLevels); err != nil {
+		return nil, err
+	}
+	if err := tm.checkRequest(ctx, req); err != nil {
+		return nil, err
+	}
+
+	tm.tasksMutex.RLock()
+	ready, err := tm.dependenciesReady(req.DependsOn)
+	tm.tasksMutex.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tm.pathConverter.ValidatePath(req.ProjectPath); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrInvalidPath, "项目路径验证失败")
+	}
+	wslPath, err := tm.pathConverter.ConvertToWSL(req.ProjectPath)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrPathConversion, "路径转换失败")
+	}
+
+	distro := tm.distros.pick(req)
+	if distro == "" {
+		if distro, err = tm.wslBridge.GetDefaultDistro(); err != nil {
+			return nil, err
+		}
+	}
+	if err := tm.wslBridge.CheckClaudeCode(distro); err != nil {
+		return nil, err
+	}
+
+	worktree, err := tm.worktreeManager.PlanWorktree(ctx, req.ProjectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TaskPlan{
+		ProjectPath:          req.ProjectPath,
+		WSLPath:              wslPath,
+		Distro:               distro,
+		Args:                 tm.claudeArgs(req),
+		Priority:             req.Priority,
+		Timeout:              req.Timeout.String(),
+		GPU:                  req.GPU,
+		Limits:               tm.config.TaskLimits.Merge(req.Limits),
+		Worktree:             worktree,
+		WaitsForDependencies: !ready,
+	}, nil
+}
@@ -0,0 +1,122 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+	"auto-claude-code/internal/wsl"
+)
+
+// planBridge 只有 Ubuntu 发行版，claudeMissing 时 Claude Code 不可用
+type planBridge struct {
+	wsl.WSLBridge
+	claudeMissing bool
+	checked       string
+}
+
+func (b *planBridge) ListDistros() ([]string, error)    { return []string{"Ubuntu"}, nil }
+func (b *planBridge) GetDefaultDistro() (string, error) { return "Ubuntu", nil }
+
+func (b *planBridge) CheckClaudeCode(distro string) error {
+	b.checked = distro
+	if b.claudeMissing {
+		return apperrors.New(apperrors.ErrClaudeCodeNotFound, "Claude Code 未安装")
+	}
+	return nil
+}
+
+func newPlanTestManager(t *testing.T) (*taskManager, *planBridge) {
+	bridge := &planBridge{}
+	tm := newQueueTestManager()
+	tm.config = &config.MCPConfig{TaskTimeout: "30m", MaxWorktrees: 5, TaskLimits: config.ResourceLimits{MaxOpenFiles: 1024}}
+	tm.wslBridge = bridge
+	tm.pathConverter = identityConverter{}
+	tm.worktreeManager = NewWorktreeManager(&config.MCPConfig{MaxWorktrees: 5, WorktreeBaseDir: t.TempDir()}, logger.FromZap(zap.NewNop()), nil)
+	return tm, bridge
+}
+
+func TestPlanTask(t *testing.T) {
+	ctx := context.Background()
+	project := t.TempDir()
+	tm, bridge := newPlanTestManager(t)
+
+	plan, err := tm.PlanTask(ctx, &TaskRequest{
+		ProjectPath: project,
+		Command:     "修复测试",
+		Args:        []string{"--max-turns", "5"},
+		Limits:      &config.ResourceLimits{Nice: 10},
+	})
+	if err != nil {
+		t.Fatalf("PlanTask() error = %v", err)
+	}
+	want := &TaskPlan{
+		ProjectPath: project,
+		WSLPath:     project,
+		Distro:      "Ubuntu",
+		Args:        []string{"修复测试", "--max-turns", "5"},
+		Timeout:     "30m0s",
+		Limits:      config.ResourceLimits{Nice: 10, MaxOpenFiles: 1024},
+		Worktree:    &WorktreePlan{Mode: "copy", MaxWorktrees: 5},
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Errorf("PlanTask() = %+v, want %+v", plan, want)
+	}
+	if bridge.checked != "Ubuntu" {
+		t.Errorf("检查 Claude Code 的发行版 = %q", bridge.checked)
+	}
+	if len(tm.tasks) != 0 || tm.taskQueue.len() != 0 {
+		t.Errorf("预检创建了任务: %d 个任务，队列长度 %d", len(tm.tasks), tm.taskQueue.len())
+	}
+
+	tests := []struct {
+		name  string
+		req   TaskRequest
+		setup func()
+		code  apperrors.ErrorCode
+	}{
+		{"发行版不存在", TaskRequest{ProjectPath: project, Distro: "Debian"}, nil, apperrors.ErrDistroNotFound},
+		{"依赖不存在", TaskRequest{ProjectPath: project, DependsOn: []string{"missing"}}, nil, apperrors.ErrInvalidRequest},
+		{"项目目录不存在", TaskRequest{ProjectPath: filepath.Join(project, "missing")}, nil, apperrors.ErrWorktreeFailed},
+		{"Claude Code 不可用", TaskRequest{ProjectPath: project}, func() { bridge.claudeMissing = true }, apperrors.ErrClaudeCodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+			if _, err := tm.PlanTask(ctx, &tt.req); !apperrors.IsCode(err, tt.code) {
+				t.Errorf("PlanTask() error = %v, want %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestSubmitTaskValidateOnly(t *testing.T) {
+	tm, _ := newPlanTestManager(t)
+	s := &mcpServer{taskManager: tm}
+	body, _ := json.Marshal(map[string]string{"projectPath": t.TempDir(), "command": "修复测试"})
+
+	rec := httptest.NewRecorder()
+	s.handleTasks(rec, httptest.NewRequest(http.MethodPost, "/tasks?validate_only=true", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d: %s", rec.Code, rec.Body)
+	}
+	var plan TaskPlan
+	if err := json.Unmarshal(rec.Body.Bytes(), &plan); err != nil || plan.Distro != "Ubuntu" || plan.Worktree == nil {
+		t.Errorf("执行计划 = %+v, %v", plan, err)
+	}
+	if len(tm.tasks) != 0 {
+		t.Errorf("预检创建了 %d 个任务", len(tm.tasks))
+	}
+}
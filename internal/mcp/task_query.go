@@ -0,0 +1,181 @@
+package mcp
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// defaultTaskSort GET /tasks 的默认排序：按创建时间从新到旧，与 list_tasks 的游标分页一致
+const defaultTaskSort = "-createdAt"
+
+// taskSortFields GET /tasks 支持的排序字段，值为升序比较函数
+var taskSortFields = map[string]func(a, b *TaskStatus) bool{
+	"createdAt": func(a, b *TaskStatus) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	"startTime": func(a, b *TaskStatus) bool { return a.StartTime.Before(b.StartTime) },
+	"endTime":   func(a, b *TaskStatus) bool { return a.EndTime.Before(b.EndTime) },
+	"status":    func(a, b *TaskStatus) bool { return a.Status < b.Status },
+	"progress":  func(a, b *TaskStatus) bool { return a.Progress < b.Progress },
+	"id":        func(a, b *TaskStatus) bool { return a.ID < b.ID },
+}
+
+// taskListQuery GET /tasks 的过滤、排序和分页参数
+type taskListQuery struct {
+	Statuses []string  // 任务状态，多个值之间为或关系
+	Project  string    // 项目路径，精确匹配
+	Since    time.Time // 只返回此时间及之后创建的任务
+	Sort     string    // 排序字段，"-" 前缀表示降序
+	Limit    int
+	Offset   int
+	Cursor   string // list_tasks 的游标，只能与默认排序一起使用
+}
+
+// taskListResponse 任务列表响应
+type taskListResponse struct {
+	Tasks      []*TaskStatus `json:"tasks"`
+	Total      int           `json:"total"`                // 满足过滤条件的任务总数
+	Offset     int           `json:"offset"`               // 本页起始位置，游标分页时为 0
+	NextOffset int           `json:"nextOffset,omitempty"` // 下一页起始位置，没有更多任务时省略
+	NextCursor string        `json:"nextCursor,omitempty"` // 默认排序下可用于下一页的游标
+}
+
+// parseTaskListQuery 解析 GET /tasks 的查询参数
+// status 可重复或用逗号分隔；since 为 RFC 3339 时间；sort 为字段名，"-" 前缀表示降序
+func parseTaskListQuery(values url.Values) (*taskListQuery, error) {
+	q := &taskListQuery{
+		Project: values.Get("project"),
+		Sort:    values.Get("sort"),
+		Cursor:  values.Get("cursor"),
+	}
+
+	for _, v := range values["status"] {
+		for _, status := range strings.Split(v, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				q.Statuses = append(q.Statuses, status)
+			}
+		}
+	}
+
+	if v := values.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, apperrors.Newf(apperrors.ErrInvalidRequest, "无效的 since 参数，需要 RFC 3339 格式: %s", v)
+		}
+		q.Since = t
+	}
+
+	if q.Sort == "" {
+		q.Sort = defaultTaskSort
+	}
+	if _, ok := taskSortFields[strings.TrimPrefix(q.Sort, "-")]; !ok {
+		return nil, apperrors.Newf(apperrors.ErrInvalidRequest, "不支持的排序字段: %s", q.Sort)
+	}
+
+	for name, target := range map[string]*int{"limit": &q.Limit, "offset": &q.Offset} {
+		if v := values.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return nil, apperrors.Newf(apperrors.ErrInvalidRequest, "无效的 %s 参数", name)
+			}
+			*target = n
+		}
+	}
+	if q.Limit == 0 {
+		q.Limit = defaultTasksPageSize
+	}
+	if q.Limit > maxTasksPageSize {
+		q.Limit = maxTasksPageSize
+	}
+
+	if q.Cursor != "" && (q.Sort != defaultTaskSort || q.Offset != 0) {
+		return nil, apperrors.New(apperrors.ErrInvalidRequest, "cursor 只能与默认排序一起使用，且不能同时指定 offset")
+	}
+
+	return q, nil
+}
+
+// matches 任务是否满足过滤条件
+func (q *taskListQuery) matches(task *TaskStatus) bool {
+	if len(q.Statuses) > 0 {
+		found := false
+		for _, status := range q.Statuses {
+			if task.Status == status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Project != "" && task.ProjectPath != q.Project {
+		return false
+	}
+	if !q.Since.IsZero() && task.CreatedAt.Before(q.Since) {
+		return false
+	}
+	return true
+}
+
+// apply 过滤、排序并截取一页任务
+func (q *taskListQuery) apply(tasks []*TaskStatus) (*taskListResponse, error) {
+	filtered := make([]*TaskStatus, 0, len(tasks))
+	for _, task := range tasks {
+		if q.matches(task) {
+			filtered = append(filtered, task)
+		}
+	}
+
+	resp := &taskListResponse{Total: len(filtered)}
+
+	if q.Cursor != "" {
+		page, nextCursor, err := paginateTasks(filtered, q.Cursor, q.Limit)
+		if err != nil {
+			return nil, apperrors.Wrap(err, apperrors.ErrInvalidRequest, "无效的 cursor 参数")
+		}
+		resp.Tasks = page
+		resp.NextCursor = nextCursor
+		return resp, nil
+	}
+
+	desc := strings.HasPrefix(q.Sort, "-")
+	less := taskSortFields[strings.TrimPrefix(q.Sort, "-")]
+	sort.SliceStable(filtered, func(i, j int) bool {
+		a, b := filtered[i], filtered[j]
+		if desc {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		// 相同值按ID排序，保证翻页顺序稳定
+		return a.ID < b.ID
+	})
+
+	start := q.Offset
+	if start > len(filtered) {
+		start = len(filtered)
+	}
+	end := start + q.Limit
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+
+	resp.Tasks = filtered[start:end]
+	resp.Offset = start
+	if end < len(filtered) {
+		resp.NextOffset = end
+		if q.Sort == defaultTaskSort {
+			last := filtered[end-1]
+			resp.NextCursor = encodeCursor(pageCursor{CreatedAt: last.CreatedAt.UnixNano(), TaskID: last.ID})
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,107 @@
+package mcp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+func TestTaskListQuery(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tasks := []*TaskStatus{
+		{ID: "t1", Status: "completed", ProjectPath: "/a", CreatedAt: base, Progress: 1},
+		{ID: "t2", Status: "running", ProjectPath: "/b", CreatedAt: base.Add(time.Hour), Progress: 0.5},
+		{ID: "t3", Status: "failed", ProjectPath: "/a", CreatedAt: base.Add(2 * time.Hour)},
+		{ID: "t4", Status: "pending", ProjectPath: "/a", CreatedAt: base.Add(3 * time.Hour)},
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		ids        string
+		total      int
+		nextOffset int
+	}{
+		{name: "默认按创建时间从新到旧", query: "", ids: "t4,t3,t2,t1", total: 4},
+		{name: "多个状态", query: "status=running,failed", ids: "t3,t2", total: 2},
+		{name: "重复状态参数", query: "status=running&status=pending", ids: "t4,t2", total: 2},
+		{name: "项目", query: "project=/a", ids: "t4,t3,t1", total: 3},
+		{name: "创建时间下限", query: "since=2024-01-01T02:00:00Z", ids: "t4,t3", total: 2},
+		{name: "升序排序", query: "sort=createdAt", ids: "t1,t2,t3,t4", total: 4},
+		{name: "按进度降序", query: "sort=-progress", ids: "t1,t2,t4,t3", total: 4},
+		{name: "分页", query: "limit=2&offset=1", ids: "t3,t2", total: 4, nextOffset: 3},
+		{name: "最后一页", query: "limit=2&offset=2", ids: "t2,t1", total: 4},
+		{name: "偏移超出范围", query: "offset=10", ids: "", total: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			q, err := parseTaskListQuery(values)
+			if err != nil {
+				t.Fatalf("parseTaskListQuery() error = %v", err)
+			}
+			resp, err := q.apply(append([]*TaskStatus(nil), tasks...))
+			if err != nil {
+				t.Fatalf("apply() error = %v", err)
+			}
+
+			ids := make([]string, 0, len(resp.Tasks))
+			for _, task := range resp.Tasks {
+				ids = append(ids, task.ID)
+			}
+			if got := strings.Join(ids, ","); got != tt.ids {
+				t.Errorf("任务 = %s, want %s", got, tt.ids)
+			}
+			if resp.Total != tt.total || resp.NextOffset != tt.nextOffset {
+				t.Errorf("total = %d, nextOffset = %d", resp.Total, resp.NextOffset)
+			}
+		})
+	}
+}
+
+func TestTaskListQueryCursor(t *testing.T) {
+	base := time.Now()
+	tasks := []*TaskStatus{
+		{ID: "t1", CreatedAt: base},
+		{ID: "t2", CreatedAt: base.Add(time.Second)},
+		{ID: "t3", CreatedAt: base.Add(2 * time.Second)},
+	}
+
+	q, _ := parseTaskListQuery(url.Values{"limit": {"2"}})
+	first, _ := q.apply(tasks)
+	if first.NextCursor == "" {
+		t.Fatal("默认排序下应返回 nextCursor")
+	}
+
+	q, _ = parseTaskListQuery(url.Values{"limit": {"2"}, "cursor": {first.NextCursor}})
+	second, err := q.apply(tasks)
+	if err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+	if len(second.Tasks) != 1 || second.Tasks[0].ID != "t1" || second.NextCursor != "" {
+		t.Errorf("第二页 = %+v", second)
+	}
+}
+
+func TestParseTaskListQueryInvalid(t *testing.T) {
+	tests := []string{
+		"since=yesterday",
+		"sort=priority",
+		"limit=-1",
+		"offset=abc",
+		"cursor=abc&sort=status",
+	}
+
+	for _, query := range tests {
+		t.Run(query, func(t *testing.T) {
+			values, _ := url.ParseQuery(query)
+			if _, err := parseTaskListQuery(values); !apperrors.IsCode(err, apperrors.ErrInvalidRequest) {
+				t.Errorf("parseTaskListQuery(%s) error = %v", query, err)
+			}
+		})
+	}
+}
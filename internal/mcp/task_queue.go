@@ -0,0 +1,237 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/logger"
+)
+
+// taskQueue 按有效优先级出队的任务队列，有效优先级相同时先入队的任务先出队
+// 启用 mcp.queue.aging 时任务的有效优先级随等待时间提升，避免低优先级任务在高优先级任务持续提交时饿死
+type taskQueue struct {
+	mutex    sync.Mutex
+	items    []*queuedTask
+	seq      uint64
+	capacity int // 0 表示不限长度
+	levels   int
+	aging    config.PriorityAgingConfig
+
+	// ready 有任务入队或队列恢复时发出通知，缓冲为 1
+	ready chan struct{}
+	now   func() time.Time
+
+	// 暂停期间不出队，resumed 在恢复时关闭
+	paused      bool
+	pausedAt    time.Time
+	pauseReason string
+	resumed     chan struct{}
+}
+
+// QueueState 任务队列的调度状态
+type QueueState struct {
+	Paused   bool      `json:"paused"`
+	PausedAt time.Time `json:"pausedAt,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	Length   int       `json:"length"`
+	Capacity int       `json:"capacity"` // 0 表示队列不限长度
+}
+
+// queuedTask 队列中的任务及其入队时间
+type queuedTask struct {
+	req        *TaskRequest
+	seq        uint64
+	enqueuedAt time.Time
+}
+
+// newTaskQueue 创建任务队列
+func newTaskQueue(cfg config.MCPQueueConfig) *taskQueue {
+	levels := cfg.PriorityLevels
+	if levels <= 0 {
+		levels = defaultPriorityLevels
+	}
+	return &taskQueue{
+		capacity: cfg.MaxSize,
+		levels:   levels,
+		aging:    cfg.Aging,
+		ready:    make(chan struct{}, 1),
+		now:      time.Now,
+	}
+}
+
+// push 任务入队，队列已满时返回 false
+func (q *taskQueue) push(req *TaskRequest) bool {
+	q.mutex.Lock()
+	if q.capacity > 0 && len(q.items) >= q.capacity {
+		q.mutex.Unlock()
+		return false
+	}
+	q.seq++
+	req.queuedAt = q.now()
+	q.items = append(q.items, &queuedTask{req: req, seq: q.seq, enqueuedAt: req.queuedAt})
+	q.mutex.Unlock()
+
+	q.signal()
+	return true
+}
+
+// pop 取出有效优先级最高的任务，队列为空时等待，ctx 取消时返回 nil
+func (q *taskQueue) pop(ctx context.Context) *TaskRequest {
+	for ctx.Err() == nil {
+		if req := q.tryPop(); req != nil {
+			return req
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-q.ready:
+		}
+	}
+	return nil
+}
+
+// tryPop 取出有效优先级最高的任务，队列为空或已暂停时返回 nil
+func (q *taskQueue) tryPop() *TaskRequest {
+	q.mutex.Lock()
+	if len(q.items) == 0 || q.paused {
+		q.mutex.Unlock()
+		return nil
+	}
+
+	now := q.now()
+	best, bestPriority := 0, q.effectivePriority(q.items[0], now)
+	for i, item := range q.items[1:] {
+		if p := q.effectivePriority(item, now); p > bestPriority {
+			best, bestPriority = i+1, p
+		}
+	}
+	req := q.items[best].req
+	q.items = append(q.items[:best], q.items[best+1:]...)
+	remaining := len(q.items)
+	q.mutex.Unlock()
+
+	// 还有任务时唤醒其他等待的工作器
+	if remaining > 0 {
+		q.signal()
+	}
+	return req
+}
+
+// len 队列中的任务数
+func (q *taskQueue) len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.items)
+}
+
+// pause 暂停出队，已暂停时返回 false
+func (q *taskQueue) pause(reason string) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.paused {
+		return false
+	}
+	q.paused = true
+	q.pausedAt = q.now()
+	q.pauseReason = reason
+	q.resumed = make(chan struct{})
+	return true
+}
+
+// resume 恢复出队并唤醒等待的工作器，未暂停时返回 false
+func (q *taskQueue) resume() bool {
+	q.mutex.Lock()
+	if !q.paused {
+		q.mutex.Unlock()
+		return false
+	}
+	q.paused = false
+	q.pausedAt = time.Time{}
+	q.pauseReason = ""
+	close(q.resumed)
+	q.mutex.Unlock()
+
+	q.signal()
+	return true
+}
+
+// waitResumed 队列暂停时等待恢复，ctx 取消时返回 false
+func (q *taskQueue) waitResumed(ctx context.Context) bool {
+	q.mutex.Lock()
+	if !q.paused {
+		q.mutex.Unlock()
+		return true
+	}
+	resumed := q.resumed
+	q.mutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-resumed:
+		return true
+	}
+}
+
+// state 获取队列的调度状态
+func (q *taskQueue) state() QueueState {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return QueueState{
+		Paused:   q.paused,
+		PausedAt: q.pausedAt,
+		Reason:   q.pauseReason,
+		Length:   len(q.items),
+		Capacity: q.capacity,
+	}
+}
+
+// signal 通知等待的工作器有任务可取
+func (q *taskQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// effectivePriority 任务的有效优先级，未指定优先级的任务按中间级别计算
+func (q *taskQueue) effectivePriority(item *queuedTask, now time.Time) int {
+	priority := item.req.Priority
+	if priority <= 0 {
+		priority = (q.levels + 1) / 2
+	}
+	if interval := q.aging.IntervalFor(priority); interval > 0 {
+		priority += int(now.Sub(item.enqueuedAt) / interval)
+	}
+	if priority > q.levels {
+		priority = q.levels
+	}
+	return priority
+}
+
+// PauseQueue 暂停向工作器分发任务，正在执行的任务不受影响，新提交的任务继续排队
+func (tm *taskManager) PauseQueue(ctx context.Context, reason string) QueueState {
+	if tm.taskQueue.pause(reason) {
+		logger.FromContext(ctx, tm.logger).Info("任务队列已暂停",
+			zap.String("reason", reason),
+			zap.Int("queueLength", tm.taskQueue.len()))
+	}
+	return tm.taskQueue.state()
+}
+
+// ResumeQueue 恢复向工作器分发任务
+func (tm *taskManager) ResumeQueue(ctx context.Context) QueueState {
+	if tm.taskQueue.resume() {
+		logger.FromContext(ctx, tm.logger).Info("任务队列已恢复", zap.Int("queueLength", tm.taskQueue.len()))
+	}
+	return tm.taskQueue.state()
+}
+
+// GetQueueState 获取任务队列的调度状态
+func (tm *taskManager) GetQueueState(ctx context.Context) QueueState {
+	return tm.taskQueue.state()
+}
@@ -0,0 +1,130 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-claude-code/internal/config"
+)
+
+func TestTaskQueuePriorityAging(t *testing.T) {
+	tests := []struct {
+		name  string
+		aging config.PriorityAgingConfig
+		wait  time.Duration
+		want  string
+	}{
+		{"按优先级出队，同级先进先出", config.PriorityAgingConfig{}, time.Hour, "high,default,low1,low2"},
+		{"等待时间不足一个间隔时不提升", config.PriorityAgingConfig{Enabled: true, Interval: "10m"}, 9 * time.Minute, "high,default,low1,low2"},
+		{"低优先级任务老化后先于新提交的任务", config.PriorityAgingConfig{Enabled: true, Interval: "10m"}, 20 * time.Minute, "low1,high,default,low2"},
+		{"按级别覆盖间隔", config.PriorityAgingConfig{Enabled: true, Interval: "1h", Levels: map[string]string{"1": "5m"}}, 5 * time.Minute, "high,low1,default,low2"},
+		{"间隔为 0 的级别不老化", config.PriorityAgingConfig{Enabled: true, Interval: "1m", Levels: map[string]string{"1": "0"}}, time.Hour, "high,default,low1,low2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			q := newTaskQueue(config.MCPQueueConfig{PriorityLevels: 3, Aging: tt.aging})
+			q.now = func() time.Time { return now }
+
+			q.push(&TaskRequest{ID: "low1", Priority: 1})
+			now = now.Add(tt.wait)
+			q.push(&TaskRequest{ID: "default"})
+			q.push(&TaskRequest{ID: "high", Priority: 3})
+			q.push(&TaskRequest{ID: "low2", Priority: 1})
+
+			var got []string
+			for req := q.tryPop(); req != nil; req = q.tryPop() {
+				got = append(got, req.ID)
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("出队顺序 = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskQueueCapacity(t *testing.T) {
+	q := newTaskQueue(config.MCPQueueConfig{MaxSize: 1})
+	if !q.push(&TaskRequest{ID: "a"}) || q.push(&TaskRequest{ID: "b"}) {
+		t.Fatal("队列容量为 1 时第二个任务应入队失败")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if req := q.pop(ctx); req == nil || req.ID != "a" {
+		t.Errorf("pop() = %+v", req)
+	}
+	cancel()
+	if req := q.pop(ctx); req != nil {
+		t.Errorf("ctx 取消后 pop() = %+v", req)
+	}
+}
+
+func TestTaskQueuePause(t *testing.T) {
+	q := newTaskQueue(config.MCPQueueConfig{})
+	q.push(&TaskRequest{ID: "a"})
+
+	if !q.pause("升级发行版") || q.pause("再次暂停") {
+		t.Fatal("pause() 应只在首次暂停时返回 true")
+	}
+	if state := q.state(); !state.Paused || state.Reason != "升级发行版" || state.Length != 1 {
+		t.Errorf("暂停后 state() = %+v", state)
+	}
+	if req := q.tryPop(); req != nil {
+		t.Fatalf("暂停时 tryPop() = %s", req.ID)
+	}
+
+	popped := make(chan *TaskRequest)
+	go func() { popped <- q.pop(context.Background()) }()
+	select {
+	case req := <-popped:
+		t.Fatalf("暂停时 pop() = %s", req.ID)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	q.resume()
+	select {
+	case req := <-popped:
+		if req.ID != "a" {
+			t.Errorf("恢复后 pop() = %s", req.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("恢复后 pop() 未返回")
+	}
+	if state := q.state(); state.Paused || !state.PausedAt.IsZero() || state.Reason != "" {
+		t.Errorf("恢复后 state() = %+v", state)
+	}
+}
+
+func TestPauseQueueKeepsRunningTasks(t *testing.T) {
+	ctx := context.Background()
+	tm, bridge := startBlockingManager(t)
+
+	if _, err := tm.SubmitTask(ctx, &TaskRequest{ID: "running", ProjectPath: "/app", Command: "block"}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	<-bridge.started
+
+	tm.PauseQueue(ctx, "维护")
+	if _, err := tm.SubmitTask(ctx, &TaskRequest{ID: "queued", ProjectPath: "/app", Command: "fix"}); err != nil {
+		t.Fatalf("暂停期间 SubmitTask() error = %v", err)
+	}
+	if status, _ := tm.GetTaskStatus(ctx, "running"); status.Status != "running" {
+		t.Errorf("暂停后执行中的任务状态 = %s", status.Status)
+	}
+
+	// 执行中的任务结束后，暂停期间不再分发排队的任务
+	tm.CancelTask(ctx, "running")
+	waitForStatus(t, tm, "running", "cancelled")
+	time.Sleep(20 * time.Millisecond)
+	if status, _ := tm.GetTaskStatus(ctx, "queued"); status.Status != "pending" {
+		t.Errorf("暂停期间排队的任务状态 = %s", status.Status)
+	}
+	if stats := tm.Stats(); !stats.QueuePaused || stats.QueueLength != 1 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+
+	tm.ResumeQueue(ctx)
+	waitForStatus(t, tm, "queued", "completed")
+}
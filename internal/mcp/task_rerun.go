@@ -0,0 +1,179 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// RerunOverrides 重新运行任务时覆盖原请求的字段，零值表示沿用原任务
+type RerunOverrides struct {
+	ID           string        `json:"id,omitempty"`           // 新任务的ID，留空自动生成
+	Command      string        `json:"command,omitempty"`      // 替换原任务的命令
+	PromptSuffix string        `json:"promptSuffix,omitempty"` // 附加在命令之后的补充说明，如上次失败的原因
+	Priority     int           `json:"priority,omitempty"`
+	Timeout      time.Duration `json:"timeout,omitempty"`
+	Model        string        `json:"model,omitempty"`
+	MaxTurns     int           `json:"maxTurns,omitempty"`
+}
+
+// rerun 复制原任务请求并应用覆盖字段，不复制ID、依赖、模板和请求ID
+// 新任务的所有者和配额客户端取自重新运行的请求
+func (req *TaskRequest) rerun(o RerunOverrides, originID string) *TaskRequest {
+	clone := &TaskRequest{
+		ID:          o.ID,
+		Type:        req.Type,
+		ProjectPath: req.ProjectPath,
+		Command:     req.Command,
+		Args:        append([]string(nil), req.Args...),
+		Priority:    req.Priority,
+		Timeout:     req.Timeout,
+		Limits:      req.Limits,
+		GPU:         req.GPU,
+		Distro:      req.Distro,
+		DistroTags:  append([]string(nil), req.DistroTags...),
+		OnSuccess:   req.OnSuccess,
+		OnFailure:   req.OnFailure,
+		MergeBack:   req.MergeBack,
+		rerunOf:     originID,
+
+		Model:          req.Model,
+		MaxTurns:       req.MaxTurns,
+		PermissionMode: req.PermissionMode,
+		AllowedTools:   append([]string(nil), req.AllowedTools...),
+	}
+	if req.Context != nil {
+		clone.Context = make(map[string]interface{}, len(req.Context))
+		for key, value := range req.Context {
+			clone.Context[key] = value
+		}
+	}
+
+	if o.Command != "" {
+		clone.Command = o.Command
+	}
+	if suffix := strings.TrimSpace(o.PromptSuffix); suffix != "" {
+		if clone.Command == "" {
+			clone.Command = suffix
+		} else {
+			clone.Command += "\n\n" + suffix
+		}
+	}
+	if o.Priority != 0 {
+		clone.Priority = o.Priority
+	}
+	if o.Timeout != 0 {
+		clone.Timeout = o.Timeout
+	}
+	if o.Model != "" {
+		clone.Model = o.Model
+	}
+	if o.MaxTurns != 0 {
+		clone.MaxTurns = o.MaxTurns
+	}
+	return clone
+}
+
+// RerunTask 以已结束任务的原始请求提交一个新任务
+// 新任务的 metadata.rerunOf 为原任务ID，原任务的 metadata.reruns 按提交顺序记录重新运行的任务ID
+func (tm *taskManager) RerunTask(ctx context.Context, taskID string, overrides RerunOverrides) (*TaskStatus, error) {
+	tm.tasksMutex.RLock()
+	status, exists := tm.tasks[taskID]
+	exists = exists && tm.canAccessTask(ctx, status)
+	var state string
+	var origin *TaskRequest
+	if exists {
+		state = status.Status
+		origin = tm.requests[taskID]
+	}
+	tm.tasksMutex.RUnlock()
+
+	if !exists {
+		return nil, apperrors.Newf(apperrors.ErrTaskNotFound, "任务不存在: %s", taskID)
+	}
+	if !isTerminalStatus(state) {
+		return nil, apperrors.Newf(apperrors.ErrConflict, "任务尚未结束，不能重新运行: %s (%s)", taskID, state)
+	}
+	if origin == nil {
+		return nil, apperrors.Newf(apperrors.ErrConflict, "任务的原始请求已不可用，不能重新运行: %s", taskID)
+	}
+
+	rerun, err := tm.SubmitTask(ctx, origin.rerun(overrides, taskID))
+	if err != nil {
+		return nil, err
+	}
+
+	// 复制 metadata，避免修改已返回的状态快照
+	tm.tasksMutex.Lock()
+	parent, ok := tm.tasks[taskID]
+	var snapshot TaskStatus
+	if ok {
+		metadata := make(map[string]interface{}, len(parent.Metadata)+1)
+		for key, value := range parent.Metadata {
+			metadata[key] = value
+		}
+		metadata["reruns"] = append(rerunIDs(parent.Metadata["reruns"]), rerun.ID)
+		parent.Metadata = metadata
+		snapshot = *parent
+	}
+	tm.tasksMutex.Unlock()
+	if ok {
+		tm.saveTask(&snapshot, nil)
+	}
+
+	logger.FromContext(ctx, tm.logger).Info("已重新运行任务",
+		zap.String("taskId", taskID),
+		zap.String("rerunId", rerun.ID))
+	return rerun, nil
+}
+
+// rerunIDs 读取 metadata.reruns，从存储加载的记录中为 []interface{}
+func rerunIDs(value interface{}) []string {
+	switch ids := value.(type) {
+	case []string:
+		return append([]string(nil), ids...)
+	case []interface{}:
+		result := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if s, ok := id.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// handleTaskRerun 重新运行已结束的任务，请求体为可选的 RerunOverrides
+func (s *mcpServer) handleTaskRerun(w http.ResponseWriter, r *http.Request, taskID string) {
+	if r.Method != http.MethodPost {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持POST方法"))
+		return
+	}
+
+	var overrides RerunOverrides
+	if r.ContentLength != 0 {
+		if err := decodeJSONBody(r, &overrides); err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+	}
+
+	status, err := s.taskManager.RerunTask(r.Context(), taskID, overrides)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(status)
+}
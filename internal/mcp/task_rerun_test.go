@@ -0,0 +1,115 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"auto-claude-code/internal/auth"
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+func TestRerunTask(t *testing.T) {
+	ctx := context.Background()
+	tm := newQueueTestManager()
+	origin := &TaskRequest{
+		ID: "t1", ProjectPath: "/app", Command: "修复登录", Args: []string{"--verbose"}, Priority: 3,
+		Model: "sonnet", AllowedTools: []string{"Read"}, Context: map[string]interface{}{"ticket": "BUG-1"},
+	}
+	if _, err := tm.SubmitTask(ctx, origin); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	drainQueue(tm)
+
+	if _, err := tm.RerunTask(ctx, "t1", RerunOverrides{}); !apperrors.IsCode(err, apperrors.ErrConflict) {
+		t.Errorf("未结束任务 error = %v", err)
+	}
+	if _, err := tm.RerunTask(ctx, "missing", RerunOverrides{}); !apperrors.IsCode(err, apperrors.ErrTaskNotFound) {
+		t.Errorf("任务不存在 error = %v", err)
+	}
+
+	finishTask(tm, "t1", "failed", "测试失败")
+	before, _ := tm.GetTaskStatus(ctx, "t1")
+	rerun, err := tm.RerunTask(ctx, "t1", RerunOverrides{ID: "t2", PromptSuffix: " 先补充测试 ", Model: "opus"})
+	if err != nil {
+		t.Fatalf("RerunTask() error = %v", err)
+	}
+	if rerun.ID != "t2" || rerun.Status != "pending" || rerun.Metadata["rerunOf"] != "t1" {
+		t.Errorf("新任务 = %+v", rerun)
+	}
+
+	queued := drainQueue(tm)
+	if len(queued) != 1 {
+		t.Fatalf("入队 %d 个任务, want 1", len(queued))
+	}
+	req := queued[0]
+	if req.Command != "修复登录\n\n先补充测试" || req.Model != "opus" || req.Priority != 3 ||
+		len(req.Args) != 1 || req.AllowedTools[0] != "Read" || req.Context["ticket"] != "BUG-1" {
+		t.Errorf("新任务请求 = %+v", req)
+	}
+
+	if _, err := tm.RerunTask(ctx, "t1", RerunOverrides{ID: "t3", Command: "重新实现登录"}); err != nil {
+		t.Fatalf("RerunTask() error = %v", err)
+	}
+	status, _ := tm.GetTaskStatus(ctx, "t1")
+	if ids := rerunIDs(status.Metadata["reruns"]); strings.Join(ids, ",") != "t2,t3" {
+		t.Errorf("reruns = %v", ids)
+	}
+	if before.Metadata["reruns"] != nil {
+		t.Errorf("已返回的状态快照被修改: %+v", before.Metadata)
+	}
+	if queued := drainQueue(tm); len(queued) != 1 || queued[0].Command != "重新实现登录" {
+		t.Errorf("覆盖命令后的请求 = %+v", queued)
+	}
+
+	// 只能访问自己任务的身份不能重新运行其他身份的任务
+	bob := auth.WithIdentity(ctx, &auth.Identity{Subject: "bob", Method: "token", Scopes: []string{auth.ScopeSubmit}})
+	if _, err := tm.RerunTask(bob, "t1", RerunOverrides{}); !apperrors.IsCode(err, apperrors.ErrTaskNotFound) {
+		t.Errorf("bob 重新运行未认证提交的任务 error = %v", err)
+	}
+}
+
+func TestRerunIDs(t *testing.T) {
+	if got := rerunIDs([]interface{}{"a", 1, "b"}); strings.Join(got, ",") != "a,b" {
+		t.Errorf("rerunIDs([]interface{}) = %v", got)
+	}
+	if got := rerunIDs(nil); got != nil {
+		t.Errorf("rerunIDs(nil) = %v", got)
+	}
+}
+
+func TestHandleTaskRerun(t *testing.T) {
+	tm := newQueueTestManager()
+	if _, err := tm.SubmitTask(context.Background(), &TaskRequest{ID: "t1", ProjectPath: "/app", Command: "x"}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	drainQueue(tm)
+	finishTask(tm, "t1", "completed", "")
+	server := &mcpServer{config: &config.MCPConfig{}, taskManager: tm}
+
+	w := httptest.NewRecorder()
+	server.handleTaskDetail(w, httptest.NewRequest(http.MethodPost, "/tasks/t1/rerun", nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("空请求体状态码 = %d: %s", w.Code, w.Body.String())
+	}
+	var status TaskStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil || status.Metadata["rerunOf"] != "t1" {
+		t.Errorf("响应 = %+v, error = %v", status, err)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleTaskDetail(w, httptest.NewRequest(http.MethodPost, "/tasks/t1/rerun", strings.NewReader(`{"prompt":"x"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("未知字段状态码 = %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleTaskDetail(w, httptest.NewRequest(http.MethodGet, "/tasks/t1/rerun", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET 状态码 = %d", w.Code)
+	}
+}
@@ -0,0 +1,78 @@
+package mcp
+
+import (
+	"time"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+const (
+	// defaultRetryInterval 未配置 mcp.queue.retry_interval 时首次重试前的等待时间
+	defaultRetryInterval = 5 * time.Second
+	// maxRetryInterval 指数退避的等待上限
+	maxRetryInterval = 10 * time.Minute
+)
+
+// retryableTaskErrors 视为暂时性故障的错误代码：WSL 调用失败、执行超时和 worktree 磁盘配额不足重试后可能成功，
+// Claude Code 非零退出、路径无效等错误重试也不会改变结果
+var retryableTaskErrors = []apperrors.ErrorCode{
+	apperrors.ErrWSLNotFound,
+	apperrors.ErrWSLCommandFailed,
+	apperrors.ErrTaskTimeout,
+	apperrors.ErrDiskQuota,
+}
+
+// isRetryableTaskError 检查任务错误是否值得自动重试
+func isRetryableTaskError(err error) bool {
+	for _, code := range retryableTaskErrors {
+		if apperrors.HasCode(err, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryDelay 计算第 attempt 次执行失败后的等待时间，从 mcp.queue.retry_interval 开始每次翻倍
+func (tm *taskManager) retryDelay(attempt int) time.Duration {
+	interval, err := time.ParseDuration(tm.config.Queue.RetryInterval)
+	if err != nil || interval <= 0 {
+		interval = defaultRetryInterval
+	}
+
+	delay := interval
+	for i := 1; i < attempt && delay < maxRetryInterval; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryInterval {
+		delay = maxRetryInterval
+	}
+	return delay
+}
+
+// scheduleRetry 等待 delay 后将任务重新放入队列，不占用工作器
+// 任务管理器停止时放弃等待，任务保持 pending，启用持久化存储时重启后按 requeue 策略处理
+func (tm *taskManager) scheduleRetry(req *TaskRequest, delay time.Duration) {
+	tm.wg.Add(1)
+	go func() {
+		defer tm.wg.Done()
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-tm.ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		// 等待期间被取消的任务不再执行
+		tm.tasksMutex.RLock()
+		status, exists := tm.tasks[req.ID]
+		waiting := exists && status.Status == "pending"
+		tm.tasksMutex.RUnlock()
+		if !waiting {
+			return
+		}
+
+		tm.enqueuePending(req, "任务队列已满，无法重试")
+	}()
+}
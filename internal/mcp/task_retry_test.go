@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+func TestIsRetryableTaskError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"WSL命令失败", apperrors.Wrap(apperrors.New(apperrors.ErrWSLCommandFailed, "wsl.exe 退出"), apperrors.ErrClaudeCodeFailed, "Claude Code启动失败"), true},
+		{"执行超时", apperrors.Wrap(errors.New("signal: killed"), apperrors.ErrTaskTimeout, "任务执行超时"), true},
+		{"磁盘配额不足", apperrors.Wrap(apperrors.New(apperrors.ErrDiskQuota, "已达到配额"), apperrors.ErrWorktreeFailed, "创建工作树失败"), true},
+		{"非零退出码", apperrors.Newf(apperrors.ErrClaudeCodeFailed, "Claude Code退出码非零: %d", 1), false},
+		{"路径无效", apperrors.Wrap(errors.New("bad path"), apperrors.ErrInvalidPath, "项目路径验证失败"), false},
+		{"普通错误", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableTaskError(tt.err); got != tt.want {
+				t.Errorf("isRetryableTaskError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		interval string
+		attempt  int
+		want     time.Duration
+	}{
+		{"5s", 1, 5 * time.Second},
+		{"5s", 2, 10 * time.Second},
+		{"5s", 4, 40 * time.Second},
+		{"5m", 5, maxRetryInterval},
+		{"", 1, defaultRetryInterval},
+	}
+
+	for _, tt := range tests {
+		tm := &taskManager{config: &config.MCPConfig{Queue: config.MCPQueueConfig{RetryInterval: tt.interval}}}
+		if got := tm.retryDelay(tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(%q, %d) = %s, want %s", tt.interval, tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestScheduleRetry(t *testing.T) {
+	tm := &taskManager{
+		logger:    logger.FromZap(zap.NewNop()),
+		taskQueue: newTaskQueue(config.MCPQueueConfig{MaxSize: 1}),
+		tasks: map[string]*TaskStatus{
+			"waiting":   {ID: "waiting", Status: "pending"},
+			"cancelled": {ID: "cancelled", Status: "cancelled"},
+		},
+		listeners: make(map[int]TaskListener),
+	}
+	tm.ctx, tm.cancel = context.WithCancel(context.Background())
+	defer tm.cancel()
+
+	// 等待期间被取消的任务不再入队
+	tm.scheduleRetry(&TaskRequest{ID: "cancelled"}, time.Millisecond)
+	tm.scheduleRetry(&TaskRequest{ID: "waiting"}, time.Millisecond)
+	tm.wg.Wait()
+
+	if n := tm.taskQueue.len(); n != 1 {
+		t.Fatalf("队列长度 = %d, want 1", n)
+	}
+	if req := tm.taskQueue.tryPop(); req.ID != "waiting" {
+		t.Errorf("重新排队的任务 = %s", req.ID)
+	}
+
+	// 任务管理器停止时放弃等待
+	tm.scheduleRetry(&TaskRequest{ID: "waiting"}, time.Hour)
+	tm.cancel()
+	tm.wg.Wait()
+	if tm.taskQueue.len() != 0 {
+		t.Error("停止后不应重新排队")
+	}
+}
@@ -0,0 +1,507 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+	"auto-claude-code/internal/tracing"
+	"auto-claude-code/internal/websocket"
+	"auto-claude-code/internal/wsl"
+)
+
+const (
+	// sessionScrollbackBytes 客户端连接时先回放的最近终端输出字节数
+	sessionScrollbackBytes = 64 * 1024
+	// sessionClientBuffer 每个客户端待发送的输出块数，超出时断开该客户端
+	sessionClientBuffer = 256
+	// sessionReadBuffer 每次从伪终端读取的最大字节数
+	sessionReadBuffer = 32 * 1024
+	// sessionDrainTimeout 进程退出后等待剩余输出读完的时间，后台进程仍持有终端时强制关闭
+	sessionDrainTimeout = 2 * time.Second
+)
+
+// ansiEscapeRegex 终端控制序列，写入任务输出时去除，客户端收到的是原始输出
+var ansiEscapeRegex = regexp.MustCompile(`\x1b(\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(\x07|\x1b\\)|[@-Z\\-_])`)
+
+// interactiveSession 交互式任务的伪终端会话，终端输出转发给所有已连接的客户端，客户端的输入都写入同一个终端
+type interactiveSession struct {
+	taskID string
+	pty    wsl.PTY
+
+	mutex      sync.Mutex
+	scrollback []byte
+	clients    map[*SessionAttachment]struct{}
+	exitCode   int
+	done       chan struct{}
+}
+
+// SessionAttachment 连接到交互式任务会话的一个客户端
+type SessionAttachment struct {
+	session *interactiveSession
+	output  chan []byte
+	closed  bool // 由 session.mutex 保护
+}
+
+// newInteractiveSession 创建伪终端会话
+func newInteractiveSession(taskID string, pty wsl.PTY) *interactiveSession {
+	return &interactiveSession{
+		taskID:  taskID,
+		pty:     pty,
+		clients: make(map[*SessionAttachment]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// attach 连接新客户端，输出通道的第一块为最近的终端输出
+func (s *interactiveSession) attach() (*SessionAttachment, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	select {
+	case <-s.done:
+		return nil, apperrors.Newf(apperrors.ErrConflict, "任务的交互式会话已结束: %s", s.taskID)
+	default:
+	}
+
+	a := &SessionAttachment{session: s, output: make(chan []byte, sessionClientBuffer)}
+	if len(s.scrollback) > 0 {
+		a.output <- append([]byte(nil), s.scrollback...)
+	}
+	s.clients[a] = struct{}{}
+	return a, nil
+}
+
+// broadcast 记录终端输出并发送给所有客户端，读取过慢的客户端被断开
+func (s *interactiveSession) broadcast(chunk []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.scrollback = append(s.scrollback, chunk...)
+	if len(s.scrollback) > sessionScrollbackBytes {
+		s.scrollback = append([]byte(nil), s.scrollback[len(s.scrollback)-sessionScrollbackBytes:]...)
+	}
+	for a := range s.clients {
+		select {
+		case a.output <- append([]byte(nil), chunk...):
+		default:
+			s.detachLocked(a)
+		}
+	}
+}
+
+// finish 记录退出码并断开所有客户端
+func (s *interactiveSession) finish(exitCode int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.exitCode = exitCode
+	close(s.done)
+	for a := range s.clients {
+		s.detachLocked(a)
+	}
+}
+
+// detachLocked 断开客户端并关闭其输出通道，调用方必须持有 mutex
+func (s *interactiveSession) detachLocked(a *SessionAttachment) {
+	if a.closed {
+		return
+	}
+	a.closed = true
+	delete(s.clients, a)
+	close(a.output)
+}
+
+// pump 读取终端输出直到结束，onOutput 接收每块原始输出
+func (s *interactiveSession) pump(onOutput func([]byte)) {
+	buf := make([]byte, sessionReadBuffer)
+	for {
+		n, err := s.pty.Read(buf)
+		if n > 0 {
+			chunk := append([]byte(nil), buf[:n]...)
+			s.broadcast(chunk)
+			onOutput(chunk)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Output 终端输出，会话结束、客户端断开或读取过慢时关闭
+func (a *SessionAttachment) Output() <-chan []byte {
+	return a.output
+}
+
+// Write 将客户端输入写入终端
+func (a *SessionAttachment) Write(p []byte) (int, error) {
+	select {
+	case <-a.session.done:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+	return a.session.pty.Write(p)
+}
+
+// Resize 调整终端尺寸，多个客户端时以最后一次调整为准
+func (a *SessionAttachment) Resize(size wsl.PTYSize) error {
+	return a.session.pty.Resize(size)
+}
+
+// Done 会话结束时关闭
+func (a *SessionAttachment) Done() <-chan struct{} {
+	return a.session.done
+}
+
+// ExitCode 会话结束后 Claude Code 的退出码
+func (a *SessionAttachment) ExitCode() int {
+	a.session.mutex.Lock()
+	defer a.session.mutex.Unlock()
+	return a.session.exitCode
+}
+
+// Detach 断开客户端，会话继续运行
+func (a *SessionAttachment) Detach() {
+	a.session.mutex.Lock()
+	defer a.session.mutex.Unlock()
+	a.session.detachLocked(a)
+}
+
+// AttachTask 连接到正在运行的交互式任务的终端会话
+func (tm *taskManager) AttachTask(ctx context.Context, taskID string) (*SessionAttachment, error) {
+	tm.tasksMutex.RLock()
+	status, exists := tm.tasks[taskID]
+	exists = exists && tm.canAccessTask(ctx, status)
+	tm.tasksMutex.RUnlock()
+	if !exists {
+		return nil, apperrors.Newf(apperrors.ErrTaskNotFound, "任务不存在: %s", taskID)
+	}
+
+	tm.sessionsMutex.Lock()
+	session, ok := tm.sessions[taskID]
+	tm.sessionsMutex.Unlock()
+	if !ok {
+		return nil, apperrors.Newf(apperrors.ErrConflict, "任务没有正在运行的交互式会话: %s", taskID)
+	}
+	return session.attach()
+}
+
+// executeInteractiveTask 在工作树中以伪终端启动 Claude Code，等待远程客户端连接和交互直到进程退出
+// 任务超时或取消时结束进程，终端输出去除控制序列后按行写入任务输出
+func (w *taskWorker) executeInteractiveTask(ctx context.Context, req *TaskRequest, status *TaskStatus) error {
+	_, pathSpan := tracing.Start(ctx, "path.convert", tracing.String("path.windows", req.ProjectPath))
+	if err := w.manager.pathConverter.ValidatePath(req.ProjectPath); err != nil {
+		pathSpan.RecordError(err)
+		pathSpan.End()
+		return apperrors.Wrap(err, apperrors.ErrInvalidPath, "项目路径验证失败")
+	}
+	wslPath, err := w.manager.pathConverter.ConvertToWSL(req.ProjectPath)
+	pathSpan.RecordError(err)
+	pathSpan.End()
+	if err != nil {
+		return apperrors.Wrap(err, apperrors.ErrPathConversion, "路径转换失败")
+	}
+
+	w.manager.updateProgress(status, 0.3, "正在创建工作树")
+	worktree, err := w.manager.worktreeManager.CreateWorktree(ctx, req.ProjectPath, w.manager.worktreeOptions(req, status, 0.3, 0.5))
+	if err != nil {
+		return apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "创建工作树失败")
+	}
+	w.manager.tasksMutex.Lock()
+	status.WorktreeID = worktree.ID
+	w.manager.tasksMutex.Unlock()
+	defer w.manager.useWorktree(ctx, worktree.ID)()
+
+	taskOut := newTaskOutput(w.manager.config.TaskOutput.MaxCaptureBytes)
+	w.manager.outputsMutex.Lock()
+	w.manager.outputs[req.ID] = taskOut
+	w.manager.outputsMutex.Unlock()
+
+	logs := w.manager.openTaskLogs(ctx, req.ID, status)
+	if logs != nil {
+		defer w.manager.closeTaskLogs(ctx, req.ID, status, logs)
+	}
+	writeLine := func(stream, line string) {
+		if logs != nil {
+			logs.writeLine(stream, line)
+		}
+		offset := taskOut.append(line)
+		w.manager.emitOutput(status, &OutputLine{Stream: stream, Line: line, Offset: offset})
+	}
+
+	// worktree位于发行版中时在该发行版中的worktree运行
+	distro, err := taskDistro(req, worktree)
+	if err != nil {
+		w.manager.worktreeManager.DeleteWorktree(context.Background(), worktree.ID)
+		return err
+	}
+	wslPath = taskWorkDir(worktree, wslPath)
+
+	// 运行项目配置的创建后命令，失败时删除worktree
+	if err := w.manager.runPostCreateHooks(ctx, req, status, worktree, 0.5, writeLine); err != nil {
+		w.manager.worktreeManager.DeleteWorktree(context.Background(), worktree.ID)
+		return err
+	}
+
+	limits := w.manager.config.TaskLimits.Merge(req.Limits)
+	runOpts := &wsl.RunOptions{Limits: &limits, GPU: req.GPU, TaskID: req.ID}
+	pty, err := w.manager.wslBridge.StartClaudeCodePTY(ctx, distro, wslPath, interactiveArgs(req), runOpts, wsl.DefaultPTYSize)
+	if err != nil {
+		w.manager.worktreeManager.DeleteWorktree(context.Background(), worktree.ID)
+		return apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "Claude Code启动失败")
+	}
+	defer pty.Close()
+
+	lines := &terminalLines{onLine: func(line string) { writeLine(wsl.StreamStdout, line) }}
+
+	session := newInteractiveSession(req.ID, pty)
+	w.manager.sessionsMutex.Lock()
+	w.manager.sessions[req.ID] = session
+	w.manager.sessionsMutex.Unlock()
+	defer func() {
+		w.manager.sessionsMutex.Lock()
+		delete(w.manager.sessions, req.ID)
+		w.manager.sessionsMutex.Unlock()
+	}()
+
+	pumpDone := make(chan struct{})
+	go func() {
+		session.pump(lines.write)
+		close(pumpDone)
+	}()
+	w.manager.updateProgress(status, 0.5, "交互式会话已启动，等待客户端连接")
+	logger.FromContext(ctx, w.manager.logger).Info("交互式会话已启动", zap.String("taskId", req.ID))
+
+	start := time.Now()
+	type exitResult struct {
+		code int
+		err  error
+	}
+	exited := make(chan exitResult, 1)
+	go func() {
+		code, err := pty.Wait()
+		exited <- exitResult{code, err}
+	}()
+
+	var result exitResult
+	select {
+	case result = <-exited:
+	case <-ctx.Done():
+		pty.Close()
+		result = <-exited
+	}
+
+	// 进程退出后读完剩余输出，后台进程仍持有终端时关闭终端结束读取
+	select {
+	case <-pumpDone:
+	case <-time.After(sessionDrainTimeout):
+		pty.Close()
+		<-pumpDone
+	}
+	lines.flush()
+	session.finish(result.code)
+
+	execResult := &wsl.ExecResult{ExitCode: result.code, Stdout: taskOut.String(), Duration: time.Since(start)}
+	w.manager.recordResult(req, status, execResult, wslPath, worktree.ID)
+
+	if err := ctx.Err(); err != nil {
+		w.manager.worktreeManager.DeleteWorktree(context.Background(), worktree.ID)
+		return apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "交互式会话被中止")
+	}
+	if result.err != nil {
+		w.manager.worktreeManager.DeleteWorktree(context.Background(), worktree.ID)
+		return apperrors.Wrap(result.err, apperrors.ErrClaudeCodeFailed, "等待 Claude Code 进程失败")
+	}
+
+	w.manager.recordArtifacts(ctx, status, worktree)
+	w.manager.updateProgress(status, 0.9, "交互式会话已结束")
+	if result.code != 0 {
+		return apperrors.Newf(apperrors.ErrClaudeCodeFailed, "Claude Code退出码非零: %d", result.code)
+	}
+	w.manager.mergeBack(ctx, req, status, worktree)
+	w.manager.openPullRequest(ctx, req, status, worktree)
+	return nil
+}
+
+// interactiveArgs 构建交互式会话的 Claude Code 参数，命令作为会话的第一条提示，不使用 stream-json 输出
+func interactiveArgs(req *TaskRequest) []string {
+	args := claudeFlags(req)
+	if req.Command != "" {
+		args = append(args, req.Command)
+	}
+	return append(args, req.Args...)
+}
+
+// terminalLines 将原始终端输出按行拆分并去除控制序列
+type terminalLines struct {
+	pending []byte
+	onLine  func(line string)
+}
+
+// write 追加一块终端输出，每个完整行调用一次 onLine
+func (t *terminalLines) write(chunk []byte) {
+	t.pending = append(t.pending, chunk...)
+	for {
+		i := bytes.IndexByte(t.pending, '\n')
+		if i < 0 {
+			return
+		}
+		t.emit(t.pending[:i])
+		t.pending = t.pending[i+1:]
+	}
+}
+
+// flush 输出最后一个不完整的行
+func (t *terminalLines) flush() {
+	if len(t.pending) > 0 {
+		t.emit(t.pending)
+		t.pending = nil
+	}
+}
+
+// emit 去除控制序列和回车后输出一行，空行被忽略
+func (t *terminalLines) emit(raw []byte) {
+	line := ansiEscapeRegex.ReplaceAllString(string(raw), "")
+	// 回车用于覆盖同一行，只保留最后一次写入的内容
+	if i := strings.LastIndex(strings.TrimRight(line, "\r"), "\r"); i >= 0 {
+		line = line[i+1:]
+	}
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	t.onLine(line)
+}
+
+// 交互式会话的控制消息类型，客户端和服务器之间的 JSON 文本消息
+const (
+	sessionMessageInput  = "input"  // 客户端输入，等同于发送二进制消息
+	sessionMessageResize = "resize" // 客户端调整终端尺寸
+	sessionMessageExit   = "exit"   // 会话已结束，之后服务器正常关闭连接
+)
+
+// sessionMessage 交互式会话的 JSON 控制消息
+type sessionMessage struct {
+	Type     string `json:"type"`
+	Data     string `json:"data,omitempty"`
+	Cols     int    `json:"cols,omitempty"`
+	Rows     int    `json:"rows,omitempty"`
+	ExitCode *int   `json:"exitCode,omitempty"`
+}
+
+// handleTaskAttach 以 WebSocket 连接到交互式任务的终端
+// 服务器以二进制消息发送终端输出，连接时先回放最近的输出；客户端的二进制消息写入终端，文本消息为 JSON 控制消息
+// 会话结束时发送 exit 消息并正常关闭连接；客户端断开不影响会话，可重新连接
+func (s *mcpServer) handleTaskAttach(w http.ResponseWriter, r *http.Request, taskID string) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持GET方法"))
+		return
+	}
+	if !websocket.IsUpgrade(r) {
+		writeProblem(w, r, apperrors.New(apperrors.ErrInvalidRequest, "需要 WebSocket 升级请求"))
+		return
+	}
+	// 会话可以在服务器上执行任意命令，未启用认证时任何能访问端口的客户端都能操作，因此拒绝连接
+	if !s.config.Auth.Enabled {
+		writeProblem(w, r, apperrors.New(apperrors.ErrForbidden, "连接交互式会话需要启用认证 (mcp.auth.enabled)"))
+		return
+	}
+
+	attachment, err := s.taskManager.AttachTask(r.Context(), taskID)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	defer attachment.Detach()
+
+	conn, err := websocket.Upgrade(w, r, s.config.HTTP.AllowedOrigins)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	defer conn.Close(websocket.CloseNormal, "")
+
+	log := logger.FromContext(r.Context(), s.logger).With(zap.String("taskId", taskID))
+	log.Info("客户端已连接交互式会话")
+
+	clientGone := make(chan struct{})
+	go func() {
+		defer close(clientGone)
+		for {
+			op, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := handleSessionMessage(attachment, op, data); err != nil {
+				log.Debug("处理交互式会话消息失败", zap.Error(err))
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(outputStreamPingInterval)
+	defer ticker.Stop()
+
+	output := attachment.Output()
+	for {
+		select {
+		case <-clientGone:
+			log.Info("客户端已断开交互式会话")
+			return
+		case <-s.events.closed:
+			conn.Close(websocket.CloseGoingAway, "服务器正在停止")
+			return
+		case <-ticker.C:
+			if err := conn.Ping(); err != nil {
+				return
+			}
+		case chunk, ok := <-output:
+			if ok {
+				if err := conn.WriteMessage(websocket.OpBinary, chunk); err != nil {
+					return
+				}
+				continue
+			}
+			select {
+			case <-attachment.Done():
+				exitCode := attachment.ExitCode()
+				conn.WriteJSON(&sessionMessage{Type: sessionMessageExit, ExitCode: &exitCode})
+			default:
+				log.Warn("交互式会话读取过慢，断开连接")
+				conn.Close(websocket.ClosePolicyViolation, "读取过慢")
+			}
+			return
+		}
+	}
+}
+
+// handleSessionMessage 处理客户端消息，二进制消息为终端输入
+func handleSessionMessage(attachment *SessionAttachment, op int, data []byte) error {
+	if op == websocket.OpBinary {
+		_, err := attachment.Write(data)
+		return err
+	}
+
+	var msg sessionMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrInvalidRequest, "无效的控制消息")
+	}
+	switch msg.Type {
+	case sessionMessageInput:
+		_, err := attachment.Write([]byte(msg.Data))
+		return err
+	case sessionMessageResize:
+		return attachment.Resize(wsl.PTYSize{Cols: msg.Cols, Rows: msg.Rows})
+	default:
+		return apperrors.Newf(apperrors.ErrInvalidRequest, "未知的控制消息类型: %s", msg.Type)
+	}
+}
@@ -0,0 +1,258 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/websocket"
+	"auto-claude-code/internal/wsl"
+)
+
+// fakePTY 测试写入 output 作为终端输出，exit 结束进程
+type fakePTY struct {
+	output *io.PipeWriter
+	reader *io.PipeReader
+	exit   chan int
+
+	mu     sync.Mutex
+	input  bytes.Buffer
+	size   wsl.PTYSize
+	closed bool
+}
+
+func newFakePTY() *fakePTY {
+	r, w := io.Pipe()
+	return &fakePTY{output: w, reader: r, exit: make(chan int, 1)}
+}
+
+func (p *fakePTY) Read(b []byte) (int, error) { return p.reader.Read(b) }
+
+func (p *fakePTY) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.input.Write(b)
+}
+
+func (p *fakePTY) Resize(size wsl.PTYSize) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.size = size
+	return nil
+}
+
+func (p *fakePTY) Wait() (int, error) { return <-p.exit, nil }
+
+func (p *fakePTY) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		p.closed = true
+		p.output.Close()
+		select {
+		case p.exit <- -1:
+		default:
+		}
+	}
+	return nil
+}
+
+// state 返回已写入的输入和当前尺寸
+func (p *fakePTY) state() (string, wsl.PTYSize) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.input.String(), p.size
+}
+
+// ptyBridge 启动交互式会话时返回 fakePTY
+type ptyBridge struct {
+	wsl.WSLBridge
+	pty  *fakePTY
+	args []string
+}
+
+func (b *ptyBridge) StartClaudeCodePTY(ctx context.Context, distro, workingDir string, args []string, opts *wsl.RunOptions, size wsl.PTYSize) (wsl.PTY, error) {
+	b.args = args
+	return b.pty, nil
+}
+
+// waitForAttach 等待任务的交互式会话启动
+func waitForAttach(t *testing.T, tm *taskManager, taskID string) *SessionAttachment {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		attachment, err := tm.AttachTask(context.Background(), taskID)
+		if err == nil {
+			return attachment
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("AttachTask() error = %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestInteractiveTask(t *testing.T) {
+	bridge := &ptyBridge{pty: newFakePTY()}
+	tm := newQueueTestManager()
+	tm.wslBridge = bridge
+	tm.pathConverter = identityConverter{}
+	tm.worktreeManager = memoryWorktreeManager{}
+	tm.workerCount = 1
+	if err := tm.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer tm.Stop(context.Background())
+
+	ctx := context.Background()
+	if _, err := tm.SubmitTask(ctx, &TaskRequest{ID: "t1", Type: "interactive", ProjectPath: "/app", Command: "重构", Model: "opus"}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	first := waitForAttach(t, tm, "t1")
+	if want := []string{"--model", "opus", "重构"}; !reflect.DeepEqual(bridge.args, want) {
+		t.Errorf("args = %v, want %v", bridge.args, want)
+	}
+
+	bridge.pty.output.Write([]byte("\x1b[32mhello\x1b[0m\r\nprompt> "))
+	if chunk := <-first.Output(); string(chunk) != "\x1b[32mhello\x1b[0m\r\nprompt> " {
+		t.Errorf("输出 = %q", chunk)
+	}
+
+	// 之后连接的客户端先收到最近的输出
+	second := waitForAttach(t, tm, "t1")
+	if chunk := <-second.Output(); !strings.HasPrefix(string(chunk), "\x1b[32mhello") {
+		t.Errorf("回放 = %q", chunk)
+	}
+	second.Detach()
+	if _, ok := <-second.Output(); ok {
+		t.Error("断开后输出通道未关闭")
+	}
+
+	first.Write([]byte("y\r"))
+	first.Resize(wsl.PTYSize{Cols: 80, Rows: 24})
+	if input, size := bridge.pty.state(); input != "y\r" || size != (wsl.PTYSize{Cols: 80, Rows: 24}) {
+		t.Errorf("输入 = %q, 尺寸 = %+v", input, size)
+	}
+
+	bridge.pty.output.Write([]byte("done\n"))
+	<-first.Output()
+	bridge.pty.output.Close()
+	bridge.pty.exit <- 0
+
+	status := waitForStatus(t, tm, "t1", "completed")
+	if result, ok := status.Result.(*TaskResult); !ok || result.Output != "hello\nprompt> done\n" {
+		t.Errorf("Result = %+v", status.Result)
+	}
+	<-first.Done()
+	if _, ok := <-first.Output(); ok || first.ExitCode() != 0 {
+		t.Errorf("会话结束后 ExitCode = %d", first.ExitCode())
+	}
+	if _, err := tm.AttachTask(ctx, "t1"); !apperrors.IsCode(err, apperrors.ErrConflict) {
+		t.Errorf("会话结束后 AttachTask() error = %v", err)
+	}
+	if _, err := tm.AttachTask(ctx, "missing"); !apperrors.IsCode(err, apperrors.ErrTaskNotFound) {
+		t.Errorf("任务不存在 AttachTask() error = %v", err)
+	}
+}
+
+func TestHandleTaskAttach(t *testing.T) {
+	tm := newQueueTestManager()
+	if _, err := tm.SubmitTask(context.Background(), &TaskRequest{ID: "t1", Type: "interactive", ProjectPath: "/app"}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	pty := newFakePTY()
+	session := newInteractiveSession("t1", pty)
+	tm.sessions["t1"] = session
+	go session.pump(func([]byte) {})
+
+	server := &mcpServer{config: &config.MCPConfig{}, taskManager: tm, events: newTestBroker(t)}
+	server.logger = server.events.logger
+	ts := httptest.NewServer(http.HandlerFunc(server.handleTaskDetail))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/tasks/t1/attach")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("非升级请求状态码 = %d", resp.StatusCode)
+	}
+
+	// 未启用认证时拒绝连接会话
+	var handshakeErr *websocket.HandshakeError
+	if _, err := websocket.Dial(context.Background(), ts.URL+"/tasks/t1/attach", nil); !errors.As(err, &handshakeErr) || handshakeErr.Response.StatusCode != http.StatusForbidden {
+		t.Fatalf("未启用认证 Dial() error = %v", err)
+	}
+	handshakeErr.Response.Body.Close()
+
+	// 认证由中间件完成，这里只打开开关；跨域页面发起的连接被拒绝
+	server.config.Auth.Enabled = true
+	header := http.Header{"Origin": {"https://evil.example.com"}}
+	if _, err := websocket.Dial(context.Background(), ts.URL+"/tasks/t1/attach", header); !errors.As(err, &handshakeErr) || handshakeErr.Response.StatusCode != http.StatusForbidden {
+		t.Fatalf("跨域 Dial() error = %v", err)
+	}
+	handshakeErr.Response.Body.Close()
+
+	conn, err := websocket.Dial(context.Background(), ts.URL+"/tasks/t1/attach", nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close(websocket.CloseNormal, "")
+
+	pty.output.Write([]byte("Allow edit? (y/n) "))
+	if op, data, err := conn.ReadMessage(); err != nil || op != websocket.OpBinary || string(data) != "Allow edit? (y/n) " {
+		t.Fatalf("ReadMessage() = %d %q, error = %v", op, data, err)
+	}
+
+	conn.WriteMessage(websocket.OpBinary, []byte("y"))
+	conn.WriteJSON(&sessionMessage{Type: sessionMessageInput, Data: "\r"})
+	conn.WriteJSON(&sessionMessage{Type: sessionMessageResize, Cols: 100, Rows: 40})
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		input, size := pty.state()
+		if input == "y\r" && size == (wsl.PTYSize{Cols: 100, Rows: 40}) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("输入 = %q, 尺寸 = %+v", input, size)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	session.finish(3)
+	_, data, err := conn.ReadMessage()
+	var msg sessionMessage
+	if err != nil || json.Unmarshal(data, &msg) != nil || msg.Type != sessionMessageExit || msg.ExitCode == nil || *msg.ExitCode != 3 {
+		t.Fatalf("结束消息 = %s, error = %v", data, err)
+	}
+	var closeErr *websocket.CloseError
+	if _, _, err := conn.ReadMessage(); !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseNormal {
+		t.Errorf("关闭 = %v", err)
+	}
+}
+
+func TestTerminalLines(t *testing.T) {
+	var got []string
+	lines := &terminalLines{onLine: func(line string) { got = append(got, line) }}
+	lines.write([]byte("\x1b[1mBold\x1b[0m te"))
+	lines.write([]byte("xt\r\n\x1b]0;title\x07\r\n进度 10%\r进度 100%\r\n\x1b[2K"))
+	lines.write([]byte("tail"))
+	lines.flush()
+
+	want := []string{"Bold text", "进度 100%", "tail"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("行 = %q, want %q", got, want)
+	}
+}
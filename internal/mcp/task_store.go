@@ -0,0 +1,199 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// 文件存储中任务记录和输出的文件扩展名
+const (
+	taskRecordExt = ".json"
+	taskOutputExt = ".output"
+)
+
+// newTaskStore 按配置创建任务存储，memory 驱动返回 nil，任务只保存在任务管理器的内存中
+func newTaskStore(cfg config.StorageConfig, log logger.Logger) TaskStore {
+	switch cfg.Driver {
+	case "file":
+		return newFileTaskStore(cfg.DataDir(), log)
+	case "bbolt":
+		return newBoltTaskStore(cfg.DBPath(), log)
+	default:
+		return nil
+	}
+}
+
+// fileTaskStore 基于文件的任务存储，每个任务一个 JSON 记录文件，输出单独保存
+// 写入先写临时文件再重命名，进程中途退出不会留下不完整的记录
+type fileTaskStore struct {
+	dir    string
+	logger logger.Logger
+
+	mu      sync.Mutex
+	records map[string]*TaskRecord // 已保存的记录，用于合并只更新状态的写入
+}
+
+// newFileTaskStore 创建文件任务存储，目录在首次加载时创建
+func newFileTaskStore(dir string, log logger.Logger) *fileTaskStore {
+	return &fileTaskStore{
+		dir:     dir,
+		logger:  log,
+		records: make(map[string]*TaskRecord),
+	}
+}
+
+// LoadTasks 加载目录中的所有任务记录，损坏的记录文件跳过并记录警告
+func (s *fileTaskStore) LoadTasks(ctx context.Context) ([]*TaskRecord, error) {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, apperrors.Wrapf(err, apperrors.ErrInternal, "无法创建任务存储目录: %s", s.dir)
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, apperrors.Wrapf(err, apperrors.ErrInternal, "无法读取任务存储目录: %s", s.dir)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []*TaskRecord
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), taskRecordExt) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			s.logger.Warn("读取任务记录失败", zap.String("file", entry.Name()), zap.Error(err))
+			continue
+		}
+		var record TaskRecord
+		if err := json.Unmarshal(data, &record); err != nil || record.Status == nil || record.Status.ID == "" {
+			s.logger.Warn("跳过无效的任务记录", zap.String("file", entry.Name()), zap.Error(err))
+			continue
+		}
+
+		s.records[record.Status.ID] = &record
+		records = append(records, &record)
+	}
+
+	return records, nil
+}
+
+// SaveTask 保存任务记录，未提供的提交请求和输出引用沿用已保存的值
+func (s *fileTaskStore) SaveTask(ctx context.Context, record *TaskRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	merged := *record
+	if existing, ok := s.records[record.Status.ID]; ok {
+		if merged.Request == nil {
+			merged.Request = existing.Request
+		}
+		if merged.OutputRef == "" {
+			merged.OutputRef = existing.OutputRef
+		}
+	}
+
+	if err := s.writeRecord(&merged); err != nil {
+		return err
+	}
+	s.records[record.Status.ID] = &merged
+	return nil
+}
+
+// DeleteTask 删除任务记录和输出文件
+func (s *fileTaskStore) DeleteTask(ctx context.Context, taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, taskID)
+	for _, ext := range []string{taskRecordExt, taskOutputExt} {
+		if err := os.Remove(filepath.Join(s.dir, taskID+ext)); err != nil && !os.IsNotExist(err) {
+			return apperrors.Wrapf(err, apperrors.ErrInternal, "删除任务记录失败: %s", taskID)
+		}
+	}
+	return nil
+}
+
+// SaveOutput 将任务输出写入单独的文件，并更新任务记录的输出引用
+func (s *fileTaskStore) SaveOutput(ctx context.Context, taskID string, output string) (string, error) {
+	ref := taskID + taskOutputExt
+	if err := writeFileAtomic(filepath.Join(s.dir, ref), []byte(output)); err != nil {
+		return "", apperrors.Wrapf(err, apperrors.ErrInternal, "保存任务输出失败: %s", taskID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[taskID]; ok && record.OutputRef != ref {
+		updated := *record
+		updated.OutputRef = ref
+		if err := s.writeRecord(&updated); err != nil {
+			return "", err
+		}
+		s.records[taskID] = &updated
+	}
+	return ref, nil
+}
+
+// ReadOutput 读取输出引用对应的文件，引用只能是存储目录下的文件名
+func (s *fileTaskStore) ReadOutput(ctx context.Context, ref string) (string, error) {
+	if ref == "" || ref != filepath.Base(ref) || !strings.HasSuffix(ref, taskOutputExt) {
+		return "", apperrors.Newf(apperrors.ErrInvalidRequest, "无效的输出引用: %s", ref)
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, ref))
+	if err != nil {
+		return "", apperrors.Wrapf(err, apperrors.ErrInternal, "读取任务输出失败: %s", ref)
+	}
+	return string(data), nil
+}
+
+// Close 关闭存储，文件存储每次写入都已落盘，无需额外操作
+func (s *fileTaskStore) Close() error {
+	return nil
+}
+
+// writeRecord 写入任务记录文件，调用方需持有 mu
+func (s *fileTaskStore) writeRecord(record *TaskRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrInternal, "序列化任务记录失败: %s", record.Status.ID)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dir, record.Status.ID+taskRecordExt), data); err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrInternal, "保存任务记录失败: %s", record.Status.ID)
+	}
+	return nil
+}
+
+// writeFileAtomic 先写入同目录的临时文件再重命名，替换时不会出现写了一半的文件
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
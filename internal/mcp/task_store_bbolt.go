@@ -0,0 +1,228 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// bbolt 存储中任务记录和输出的 bucket
+var (
+	boltTasksBucket   = []byte("tasks")
+	boltOutputsBucket = []byte("outputs")
+)
+
+// boltOpenTimeout 等待数据库文件锁的时间，同一数据库只能由一个服务器进程打开
+const boltOpenTimeout = time.Second
+
+// boltTaskStore 基于 bbolt 的任务存储，所有任务保存在一个数据库文件中
+// 任务记录以 JSON 保存在 tasks bucket，输出保存在 outputs bucket，输出引用即任务ID
+type boltTaskStore struct {
+	path   string
+	logger logger.Logger
+
+	mu sync.Mutex
+	db *bolt.DB
+}
+
+// newBoltTaskStore 创建 bbolt 任务存储，数据库在首次加载时打开
+func newBoltTaskStore(path string, log logger.Logger) *boltTaskStore {
+	return &boltTaskStore{path: path, logger: log}
+}
+
+// open 打开数据库并创建 bucket，已打开时直接返回
+func (s *boltTaskStore) open() (*bolt.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db != nil {
+		return s.db, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return nil, apperrors.Wrapf(err, apperrors.ErrInternal, "无法创建任务存储目录: %s", filepath.Dir(s.path))
+	}
+	db, err := bolt.Open(s.path, 0600, &bolt.Options{Timeout: boltOpenTimeout})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, apperrors.Newf(apperrors.ErrInternal, "任务存储已被其他进程打开: %s", s.path)
+	}
+	if err != nil {
+		return nil, apperrors.Wrapf(err, apperrors.ErrInternal, "无法打开任务存储: %s", s.path)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltTasksBucket, boltOutputsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, apperrors.Wrapf(err, apperrors.ErrInternal, "无法初始化任务存储: %s", s.path)
+	}
+
+	s.db = db
+	return db, nil
+}
+
+// LoadTasks 加载数据库中的所有任务记录，无法解析的记录跳过并记录警告
+func (s *boltTaskStore) LoadTasks(ctx context.Context) ([]*TaskRecord, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*TaskRecord
+	err = db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltTasksBucket).ForEach(func(key, value []byte) error {
+			var record TaskRecord
+			if err := json.Unmarshal(value, &record); err != nil || record.Status == nil || record.Status.ID == "" {
+				s.logger.Warn("跳过无效的任务记录", zap.String("key", string(key)), zap.Error(err))
+				return nil
+			}
+			records = append(records, &record)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrInternal, "读取任务存储失败")
+	}
+	return records, nil
+}
+
+// SaveTask 保存任务记录，未提供的提交请求和输出引用沿用已保存的值
+func (s *boltTaskStore) SaveTask(ctx context.Context, record *TaskRecord) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltTasksBucket)
+		key := []byte(record.Status.ID)
+
+		merged := *record
+		if data := bucket.Get(key); data != nil && (merged.Request == nil || merged.OutputRef == "") {
+			var existing TaskRecord
+			if err := json.Unmarshal(data, &existing); err == nil {
+				if merged.Request == nil {
+					merged.Request = existing.Request
+				}
+				if merged.OutputRef == "" {
+					merged.OutputRef = existing.OutputRef
+				}
+			}
+		}
+
+		data, err := json.Marshal(&merged)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, data)
+	})
+	if err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrInternal, "保存任务记录失败: %s", record.Status.ID)
+	}
+	return nil
+}
+
+// DeleteTask 删除任务记录和输出
+func (s *boltTaskStore) DeleteTask(ctx context.Context, taskID string) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(boltTasksBucket).Delete([]byte(taskID)); err != nil {
+			return err
+		}
+		return tx.Bucket(boltOutputsBucket).Delete([]byte(taskID))
+	})
+	if err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrInternal, "删除任务记录失败: %s", taskID)
+	}
+	return nil
+}
+
+// SaveOutput 保存任务输出，并在同一事务中更新任务记录的输出引用
+func (s *boltTaskStore) SaveOutput(ctx context.Context, taskID string, output string) (string, error) {
+	db, err := s.open()
+	if err != nil {
+		return "", err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(boltOutputsBucket).Put([]byte(taskID), []byte(output)); err != nil {
+			return err
+		}
+
+		tasks := tx.Bucket(boltTasksBucket)
+		data := tasks.Get([]byte(taskID))
+		if data == nil {
+			return nil
+		}
+		var record TaskRecord
+		if err := json.Unmarshal(data, &record); err != nil || record.OutputRef == taskID {
+			return nil
+		}
+		record.OutputRef = taskID
+		if data, err = json.Marshal(&record); err != nil {
+			return err
+		}
+		return tasks.Put([]byte(taskID), data)
+	})
+	if err != nil {
+		return "", apperrors.Wrapf(err, apperrors.ErrInternal, "保存任务输出失败: %s", taskID)
+	}
+	return taskID, nil
+}
+
+// ReadOutput 按输出引用读取任务输出
+func (s *boltTaskStore) ReadOutput(ctx context.Context, ref string) (string, error) {
+	db, err := s.open()
+	if err != nil {
+		return "", err
+	}
+
+	var output string
+	found := false
+	err = db.View(func(tx *bolt.Tx) error {
+		if data := tx.Bucket(boltOutputsBucket).Get([]byte(ref)); data != nil {
+			output, found = string(data), true
+		}
+		return nil
+	})
+	if err != nil {
+		return "", apperrors.Wrapf(err, apperrors.ErrInternal, "读取任务输出失败: %s", ref)
+	}
+	if !found {
+		return "", apperrors.Newf(apperrors.ErrResourceNotFound, "任务输出不存在: %s", ref)
+	}
+	return output, nil
+}
+
+// Close 关闭数据库，释放文件锁
+func (s *boltTaskStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db == nil {
+		return nil
+	}
+	err := s.db.Close()
+	s.db = nil
+	return err
+}
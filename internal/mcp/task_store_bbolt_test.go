@@ -0,0 +1,62 @@
+package mcp
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+func TestBoltTaskStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "data", "tasks.db")
+	store := newBoltTaskStore(path, logger.FromZap(zap.NewNop()))
+
+	if records, err := store.LoadTasks(ctx); err != nil || len(records) != 0 {
+		t.Fatalf("LoadTasks() = %v, %v", records, err)
+	}
+
+	req := &TaskRequest{ID: "task_1", Type: "claude_code", ProjectPath: "/work/app", Command: "fix"}
+	if err := store.SaveTask(ctx, &TaskRecord{Status: &TaskStatus{ID: "task_1", Status: "pending"}, Request: req}); err != nil {
+		t.Fatalf("SaveTask() error = %v", err)
+	}
+	ref, err := store.SaveOutput(ctx, "task_1", "hello\n")
+	if err != nil {
+		t.Fatalf("SaveOutput() error = %v", err)
+	}
+	// 只更新状态的写入保留已保存的请求和输出引用
+	if err := store.SaveTask(ctx, &TaskRecord{Status: &TaskStatus{ID: "task_1", Status: "completed"}}); err != nil {
+		t.Fatalf("SaveTask() error = %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	reopened := newBoltTaskStore(path, logger.FromZap(zap.NewNop()))
+	defer reopened.Close()
+	records, err := reopened.LoadTasks(ctx)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("LoadTasks() = %v, %v", records, err)
+	}
+	record := records[0]
+	if record.Status.Status != "completed" || record.Request == nil || record.Request.Command != "fix" || record.OutputRef != ref {
+		t.Errorf("记录 = %+v, 请求 = %+v", record, record.Request)
+	}
+	if output, err := reopened.ReadOutput(ctx, ref); err != nil || output != "hello\n" {
+		t.Errorf("ReadOutput() = %q, %v", output, err)
+	}
+
+	if err := reopened.DeleteTask(ctx, "task_1"); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	if records, _ := reopened.LoadTasks(ctx); len(records) != 0 {
+		t.Errorf("删除后仍加载到 %d 条记录", len(records))
+	}
+	if _, err := reopened.ReadOutput(ctx, ref); !apperrors.IsCode(err, apperrors.ErrResourceNotFound) {
+		t.Errorf("删除后读取输出 error = %v", err)
+	}
+}
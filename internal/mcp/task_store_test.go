@@ -0,0 +1,135 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/logger"
+)
+
+func TestFileTaskStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := newFileTaskStore(dir, logger.FromZap(zap.NewNop()))
+
+	if records, err := store.LoadTasks(ctx); err != nil || len(records) != 0 {
+		t.Fatalf("LoadTasks() = %v, %v", records, err)
+	}
+
+	req := &TaskRequest{ID: "task_1", Type: "claude_code", ProjectPath: "/work/app", Command: "fix"}
+	if err := store.SaveTask(ctx, &TaskRecord{Status: &TaskStatus{ID: "task_1", Status: "pending"}, Request: req}); err != nil {
+		t.Fatalf("SaveTask() error = %v", err)
+	}
+	ref, err := store.SaveOutput(ctx, "task_1", "hello\n")
+	if err != nil {
+		t.Fatalf("SaveOutput() error = %v", err)
+	}
+	// 只更新状态的写入保留已保存的请求和输出引用
+	if err := store.SaveTask(ctx, &TaskRecord{Status: &TaskStatus{ID: "task_1", Status: "completed"}}); err != nil {
+		t.Fatalf("SaveTask() error = %v", err)
+	}
+
+	reopened := newFileTaskStore(dir, logger.FromZap(zap.NewNop()))
+	records, err := reopened.LoadTasks(ctx)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("LoadTasks() = %v, %v", records, err)
+	}
+	record := records[0]
+	if record.Status.Status != "completed" || record.Request == nil || record.Request.Command != "fix" || record.OutputRef != ref {
+		t.Errorf("记录 = %+v, 请求 = %+v", record, record.Request)
+	}
+	if output, err := reopened.ReadOutput(ctx, ref); err != nil || output != "hello\n" {
+		t.Errorf("ReadOutput() = %q, %v", output, err)
+	}
+	if _, err := reopened.ReadOutput(ctx, "../task_1.output"); err == nil {
+		t.Error("存储目录外的输出引用应被拒绝")
+	}
+
+	if err := reopened.DeleteTask(ctx, "task_1"); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	if records, _ := newFileTaskStore(dir, logger.FromZap(zap.NewNop())).LoadTasks(ctx); len(records) != 0 {
+		t.Errorf("删除后仍加载到 %d 条记录", len(records))
+	}
+	if _, err := reopened.ReadOutput(ctx, ref); err == nil {
+		t.Error("删除后不应再读取到输出")
+	}
+}
+
+func TestTaskManagerRestoreTasks(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   map[string]string
+		queued []string
+	}{
+		{"none", map[string]string{"done": "completed", "queued": "interrupted", "running": "interrupted", "legacy": "interrupted"}, nil},
+		{"pending", map[string]string{"done": "completed", "queued": "pending", "running": "interrupted", "legacy": "interrupted"}, []string{"queued"}},
+		{"all", map[string]string{"done": "completed", "queued": "pending", "running": "pending", "legacy": "interrupted"}, []string{"running", "queued"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			ctx := context.Background()
+			store := newFileTaskStore(t.TempDir(), logger.FromZap(zap.NewNop()))
+			store.LoadTasks(ctx)
+			now := time.Now()
+			store.SaveTask(ctx, &TaskRecord{Status: &TaskStatus{ID: "done", Status: "completed", CreatedAt: now.Add(-3 * time.Minute)}})
+			store.SaveTask(ctx, &TaskRecord{
+				Status:  &TaskStatus{ID: "running", Status: "running", CreatedAt: now.Add(-2 * time.Minute), Progress: 0.5},
+				Request: &TaskRequest{ID: "running", ProjectPath: "/app"},
+			})
+			store.SaveTask(ctx, &TaskRecord{
+				Status:  &TaskStatus{ID: "queued", Status: "pending", CreatedAt: now.Add(-time.Minute)},
+				Request: &TaskRequest{ID: "queued", ProjectPath: "/app"},
+			})
+			// 没有保存提交请求的任务无法重新排队
+			store.SaveTask(ctx, &TaskRecord{Status: &TaskStatus{ID: "legacy", Status: "pending", CreatedAt: now}})
+			store.SaveOutput(ctx, "done", "output")
+
+			tm := &taskManager{
+				config:     &config.MCPConfig{Storage: config.StorageConfig{Requeue: tt.policy}},
+				logger:     logger.FromZap(zap.NewNop()),
+				store:      store,
+				taskQueue:  newTaskQueue(config.MCPQueueConfig{MaxSize: 10}),
+				tasks:      make(map[string]*TaskStatus),
+				requests:   make(map[string]*TaskRequest),
+				listeners:  make(map[int]TaskListener),
+				outputs:    make(map[string]*taskOutput),
+				outputRefs: make(map[string]string),
+			}
+			if err := tm.restoreTasks(ctx); err != nil {
+				t.Fatalf("restoreTasks() error = %v", err)
+			}
+
+			if output, err := tm.GetTaskOutput(ctx, "done"); err != nil || output != "output" {
+				t.Errorf("GetTaskOutput() = %q, %v", output, err)
+			}
+
+			// 状态同时写回存储
+			records, _ := newFileTaskStore(store.dir, logger.FromZap(zap.NewNop())).LoadTasks(ctx)
+			saved := make(map[string]string)
+			for _, record := range records {
+				saved[record.Status.ID] = record.Status.Status
+			}
+			for id, want := range tt.want {
+				status, err := tm.GetTaskStatus(ctx, id)
+				if err != nil || status.Status != want || saved[id] != want {
+					t.Errorf("%s 状态 = %+v, 存储 = %s, want %s", id, status, saved[id], want)
+				}
+			}
+
+			var queued []string
+			for req := tm.taskQueue.tryPop(); req != nil; req = tm.taskQueue.tryPop() {
+				queued = append(queued, req.ID)
+			}
+			if strings.Join(queued, ",") != strings.Join(tt.queued, ",") {
+				t.Errorf("重新排队 = %v, want %v", queued, tt.queued)
+			}
+		})
+	}
+}
@@ -0,0 +1,399 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/audit"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// templateParamProjectPath 提交时的项目路径，可在模板中以 {{projectPath}} 引用
+const templateParamProjectPath = "projectPath"
+
+var (
+	// templateNamePattern 模板名称只允许字母、数字、下划线、点和短横线，以便直接用在 URL 中
+	templateNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+	// templatePlaceholder 模板中的参数占位符 {{name}}
+	templatePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+)
+
+// TaskTemplate 可复用的任务模板
+// projectPath、command、args 中的 {{参数名}} 在提交时替换为 params 或 defaults 中的值
+type TaskTemplate struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	ProjectPath string            `json:"projectPath,omitempty"`
+	Command     string            `json:"command"`
+	Args        []string          `json:"args,omitempty"`
+	Priority    int               `json:"priority,omitempty"`
+	Timeout     time.Duration     `json:"timeout,omitempty"`
+	Distro      string            `json:"distro,omitempty"`
+	Defaults    map[string]string `json:"defaults,omitempty"`   // 参数默认值
+	Parameters  []string          `json:"parameters,omitempty"` // 模板引用的参数，保存时生成
+	CreatedAt   time.Time         `json:"createdAt"`
+	UpdatedAt   time.Time         `json:"updatedAt"`
+}
+
+// validate 校验模板字段，错误以字段列出
+func (t *TaskTemplate) validate() error {
+	var fields []FieldError
+	if !templateNamePattern.MatchString(t.Name) {
+		fields = append(fields, FieldError{Field: "name", Message: "只能包含字母、数字、下划线、点和短横线，最长 64 个字符"})
+	}
+	if strings.TrimSpace(t.Command) == "" {
+		fields = append(fields, FieldError{Field: "command", Message: "不能为空"})
+	}
+	if t.Priority < 0 {
+		fields = append(fields, FieldError{Field: "priority", Message: "不能为负数"})
+	}
+	if t.Timeout < 0 {
+		fields = append(fields, FieldError{Field: "timeout", Message: "不能为负数"})
+	}
+	return newValidationError("任务模板无效", fields)
+}
+
+// parameters 获取模板引用的参数，按名称排序
+func (t *TaskTemplate) parameters() []string {
+	seen := make(map[string]bool)
+	for _, value := range append([]string{t.ProjectPath, t.Command}, t.Args...) {
+		for _, match := range templatePlaceholder.FindAllStringSubmatch(value, -1) {
+			seen[match[1]] = true
+		}
+	}
+	params := make([]string, 0, len(seen))
+	for name := range seen {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	return params
+}
+
+// Apply 用模板填充任务请求：请求中未设置的字段取自模板，请求的 args 追加在模板的 args 之后
+// params 覆盖模板的 defaults，请求设置了 projectPath 时还可通过 {{projectPath}} 引用
+func (t *TaskTemplate) Apply(req *TaskRequest) error {
+	params := make(map[string]string, len(t.Defaults)+len(req.Params)+1)
+	for name, value := range t.Defaults {
+		params[name] = value
+	}
+	if req.ProjectPath != "" {
+		params[templateParamProjectPath] = req.ProjectPath
+	}
+	for name, value := range req.Params {
+		params[name] = value
+	}
+
+	missing := make(map[string]bool)
+	render := func(value string) string {
+		return templatePlaceholder.ReplaceAllStringFunc(value, func(placeholder string) string {
+			name := templatePlaceholder.FindStringSubmatch(placeholder)[1]
+			value, ok := params[name]
+			if !ok {
+				missing[name] = true
+			}
+			return value
+		})
+	}
+
+	if req.ProjectPath == "" {
+		req.ProjectPath = render(t.ProjectPath)
+	}
+	if req.Command == "" {
+		req.Command = render(t.Command)
+	}
+	args := make([]string, 0, len(t.Args)+len(req.Args))
+	for _, arg := range t.Args {
+		args = append(args, render(arg))
+	}
+	req.Args = append(args, req.Args...)
+	if req.Priority == 0 {
+		req.Priority = t.Priority
+	}
+	if req.Timeout == 0 {
+		req.Timeout = t.Timeout
+	}
+	if req.Distro == "" {
+		req.Distro = t.Distro
+	}
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return newValidationError("缺少模板参数", []FieldError{{Field: "params", Message: "缺少 " + strings.Join(names, ", ")}})
+	}
+	return nil
+}
+
+// templateStore 以 JSON 文件持久化的任务模板存储
+type templateStore struct {
+	path   string
+	logger logger.Logger
+
+	mutex     sync.RWMutex
+	templates map[string]*TaskTemplate
+}
+
+// newTemplateStore 创建模板存储并加载已有模板，文件不存在时在首次保存时创建
+func newTemplateStore(path string, log logger.Logger) (*templateStore, error) {
+	store := &templateStore{
+		path:      path,
+		logger:    log,
+		templates: make(map[string]*TaskTemplate),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, apperrors.Wrapf(err, apperrors.ErrConfigInvalid, "读取任务模板文件失败: %s", path)
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return store, nil
+	}
+
+	var templates []*TaskTemplate
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, apperrors.Wrapf(err, apperrors.ErrConfigInvalid, "解析任务模板文件失败: %s", path)
+	}
+	for _, template := range templates {
+		store.templates[template.Name] = template
+	}
+	return store, nil
+}
+
+// List 列出全部模板，按名称排序
+func (s *templateStore) List() []*TaskTemplate {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	templates := make([]*TaskTemplate, 0, len(s.templates))
+	for _, template := range s.templates {
+		templates = append(templates, copyTemplate(template))
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates
+}
+
+// Get 获取模板
+func (s *templateStore) Get(name string) (*TaskTemplate, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	template, ok := s.templates[name]
+	if !ok {
+		return nil, apperrors.Newf(apperrors.ErrResourceNotFound, "任务模板不存在: %s", name)
+	}
+	return copyTemplate(template), nil
+}
+
+// Save 创建或替换模板，create 为 true 时同名模板已存在返回冲突错误；返回保存后的模板和是否为新建
+func (s *templateStore) Save(template *TaskTemplate, create bool) (*TaskTemplate, bool, error) {
+	if err := template.validate(); err != nil {
+		return nil, false, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	saved := copyTemplate(template)
+	saved.Parameters = saved.parameters()
+	saved.UpdatedAt = time.Now()
+	old, exists := s.templates[saved.Name]
+	switch {
+	case exists && create:
+		return nil, false, apperrors.Newf(apperrors.ErrConflict, "任务模板已存在: %s", saved.Name)
+	case exists:
+		saved.CreatedAt = old.CreatedAt
+	default:
+		saved.CreatedAt = saved.UpdatedAt
+	}
+
+	s.templates[saved.Name] = saved
+	if err := s.saveLocked(); err != nil {
+		if exists {
+			s.templates[saved.Name] = old
+		} else {
+			delete(s.templates, saved.Name)
+		}
+		return nil, false, err
+	}
+
+	s.logger.Info("已保存任务模板", zap.String("name", saved.Name), zap.Strings("parameters", saved.Parameters))
+	return copyTemplate(saved), !exists, nil
+}
+
+// Delete 删除模板
+func (s *templateStore) Delete(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	template, ok := s.templates[name]
+	if !ok {
+		return apperrors.Newf(apperrors.ErrResourceNotFound, "任务模板不存在: %s", name)
+	}
+
+	delete(s.templates, name)
+	if err := s.saveLocked(); err != nil {
+		s.templates[name] = template
+		return err
+	}
+
+	s.logger.Info("已删除任务模板", zap.String("name", name))
+	return nil
+}
+
+// saveLocked 将全部模板写入文件，调用方需持有写锁
+func (s *templateStore) saveLocked() error {
+	templates := make([]*TaskTemplate, 0, len(s.templates))
+	for _, template := range s.templates {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+
+	data, err := json.MarshalIndent(templates, "", "  ")
+	if err != nil {
+		return apperrors.Wrap(err, apperrors.ErrInternal, "序列化任务模板失败")
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrInternal, "无法创建任务模板目录: %s", filepath.Dir(s.path))
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrInternal, "写入任务模板文件失败: %s", s.path)
+	}
+	return nil
+}
+
+// copyTemplate 复制模板，避免调用方修改存储中的切片和映射
+func copyTemplate(template *TaskTemplate) *TaskTemplate {
+	c := *template
+	c.Args = append([]string(nil), template.Args...)
+	c.Parameters = append([]string(nil), template.Parameters...)
+	if template.Defaults != nil {
+		c.Defaults = make(map[string]string, len(template.Defaults))
+		for name, value := range template.Defaults {
+			c.Defaults[name] = value
+		}
+	}
+	return &c
+}
+
+// applyTemplate 按请求的 template 字段填充任务请求，未引用模板时不做修改
+func (s *mcpServer) applyTemplate(req *TaskRequest) error {
+	if req.Template == "" {
+		return nil
+	}
+	if s.templates == nil {
+		return apperrors.Wrap(s.templatesErr, apperrors.ErrInternal, "任务模板存储不可用")
+	}
+	template, err := s.templates.Get(req.Template)
+	if err != nil {
+		return err
+	}
+	return template.Apply(req)
+}
+
+// handleTemplates 处理模板列表（GET）和创建（POST）
+func (s *mcpServer) handleTemplates(w http.ResponseWriter, r *http.Request) {
+	if s.templates == nil {
+		writeProblem(w, r, apperrors.Wrap(s.templatesErr, apperrors.ErrInternal, "任务模板存储不可用"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSONWithETag(w, r, map[string]interface{}{"templates": s.templates.List()})
+
+	case http.MethodPost:
+		var template TaskTemplate
+		if err := decodeJSONBody(r, &template); err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+		s.saveTemplate(w, r, &template, true)
+
+	default:
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "不支持的方法"))
+	}
+}
+
+// handleTemplateDetail 处理单个模板的查询（GET）、创建或替换（PUT）和删除（DELETE）
+func (s *mcpServer) handleTemplateDetail(w http.ResponseWriter, r *http.Request) {
+	if s.templates == nil {
+		writeProblem(w, r, apperrors.Wrap(s.templatesErr, apperrors.ErrInternal, "任务模板存储不可用"))
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/templates/")
+
+	switch r.Method {
+	case http.MethodGet:
+		template, err := s.templates.Get(name)
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+		writeJSONWithETag(w, r, template)
+
+	case http.MethodPut:
+		var template TaskTemplate
+		if err := decodeJSONBody(r, &template); err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+		if template.Name != "" && template.Name != name {
+			writeProblem(w, r, apperrors.Newf(apperrors.ErrInvalidRequest, "请求体中的模板名称 %s 与路径不一致", template.Name))
+			return
+		}
+		template.Name = name
+		s.saveTemplate(w, r, &template, false)
+
+	case http.MethodDelete:
+		err := s.templates.Delete(name)
+		s.auditLog.Record(r.Context(), audit.ActionTemplateDelete, name, nil, err)
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "不支持的方法"))
+	}
+}
+
+// saveTemplate 保存模板并记录审计日志，新建时返回 201
+func (s *mcpServer) saveTemplate(w http.ResponseWriter, r *http.Request, template *TaskTemplate, create bool) {
+	saved, created, err := s.templates.Save(template, create)
+	s.auditLog.Record(r.Context(), audit.ActionTemplateSave, template.Name, map[string]interface{}{
+		"command":     template.Command,
+		"projectPath": template.ProjectPath,
+	}, err)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(saved)
+}
@@ -0,0 +1,154 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+func TestTaskTemplateApply(t *testing.T) {
+	template := &TaskTemplate{
+		Name:     "fix-tests",
+		Command:  "修复 {{package}} 中失败的测试，项目位于 {{ projectPath }}",
+		Args:     []string{"--max-turns", "{{turns}}"},
+		Priority: 3,
+		Timeout:  time.Hour,
+		Defaults: map[string]string{"turns": "20", "package": "./..."},
+	}
+
+	tests := []struct {
+		name    string
+		req     TaskRequest
+		want    TaskRequest
+		wantErr bool
+	}{
+		{
+			name: "使用默认值",
+			req:  TaskRequest{ProjectPath: `C:\proj`},
+			want: TaskRequest{
+				ProjectPath: `C:\proj`,
+				Command:     `修复 ./... 中失败的测试，项目位于 C:\proj`,
+				Args:        []string{"--max-turns", "20"},
+				Priority:    3,
+				Timeout:     time.Hour,
+			},
+		},
+		{
+			name: "请求参数覆盖默认值，请求字段优先",
+			req: TaskRequest{
+				ProjectPath: "/app",
+				Args:        []string{"--verbose"},
+				Priority:    1,
+				Params:      map[string]string{"package": "internal/mcp"},
+			},
+			want: TaskRequest{
+				ProjectPath: "/app",
+				Command:     "修复 internal/mcp 中失败的测试，项目位于 /app",
+				Args:        []string{"--max-turns", "20", "--verbose"},
+				Priority:    1,
+				Timeout:     time.Hour,
+				Params:      map[string]string{"package": "internal/mcp"},
+			},
+		},
+		{
+			name:    "缺少项目路径参数",
+			req:     TaskRequest{Params: map[string]string{"package": "./cmd/..."}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			err := template.Apply(&req)
+			if tt.wantErr {
+				if !apperrors.IsCode(err, apperrors.ErrInvalidRequest) || !strings.Contains(err.Error(), "projectPath") {
+					t.Errorf("Apply() error = %v", err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(req, tt.want) {
+				t.Errorf("Apply() = %+v, %v, want %+v", req, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templates.json")
+	log := logger.FromZap(zap.NewNop())
+	store, err := newTemplateStore(path, log)
+	if err != nil {
+		t.Fatalf("newTemplateStore() error = %v", err)
+	}
+
+	saved, created, err := store.Save(&TaskTemplate{Name: "review", ProjectPath: "{{repo}}", Command: "审查 {{branch}}"}, true)
+	if err != nil || !created || !reflect.DeepEqual(saved.Parameters, []string{"branch", "repo"}) {
+		t.Fatalf("Save() = %+v, %v, %v", saved, created, err)
+	}
+	if _, _, err := store.Save(&TaskTemplate{Name: "review", Command: "x"}, true); !apperrors.IsCode(err, apperrors.ErrConflict) {
+		t.Errorf("重复创建 error = %v", err)
+	}
+	if _, _, err := store.Save(&TaskTemplate{Name: "bad name", Command: ""}, false); !apperrors.IsCode(err, apperrors.ErrInvalidRequest) {
+		t.Errorf("无效模板 error = %v", err)
+	}
+
+	// 替换时保留创建时间，重新加载后模板仍然存在
+	replaced, created, err := store.Save(&TaskTemplate{Name: "review", Command: "审查 main"}, false)
+	if err != nil || created || !replaced.CreatedAt.Equal(saved.CreatedAt) || len(replaced.Parameters) != 0 {
+		t.Errorf("替换模板 = %+v, %v, %v", replaced, created, err)
+	}
+	reloaded, err := newTemplateStore(path, log)
+	if err != nil {
+		t.Fatalf("重新加载 error = %v", err)
+	}
+	if got, err := reloaded.Get("review"); err != nil || got.Command != "审查 main" {
+		t.Errorf("重新加载后 Get() = %+v, %v", got, err)
+	}
+
+	if err := reloaded.Delete("review"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := reloaded.Get("review"); !apperrors.IsCode(err, apperrors.ErrResourceNotFound) {
+		t.Errorf("删除后 Get() error = %v", err)
+	}
+}
+
+func TestSubmitTaskWithTemplate(t *testing.T) {
+	store, _ := newTemplateStore(filepath.Join(t.TempDir(), "templates.json"), logger.FromZap(zap.NewNop()))
+	if _, _, err := store.Save(&TaskTemplate{Name: "fix-tests", Command: "修复测试", Args: []string{"--max-turns", "10"}}, true); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	tm := newQueueTestManager()
+	s := &mcpServer{taskManager: tm, templates: store}
+
+	submit := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.handleTasks(rec, httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := submit(`{"template": "fix-tests", "projectPath": "C:\\proj"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("提交状态码 = %d: %s", rec.Code, rec.Body)
+	}
+	var status TaskStatus
+	json.Unmarshal(rec.Body.Bytes(), &status)
+	req := tm.taskQueue.tryPop()
+	if req.ID != status.ID || req.Command != "修复测试" || !reflect.DeepEqual(req.Args, []string{"--max-turns", "10"}) {
+		t.Errorf("入队的请求 = %+v", req)
+	}
+
+	if rec := submit(`{"template": "missing", "projectPath": "C:\\proj"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("模板不存在时状态码 = %d", rec.Code)
+	}
+}
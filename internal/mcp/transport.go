@@ -1,16 +1,22 @@
 package mcp
 
 import (
-	"bufio"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
+	"auto-claude-code/internal/audit"
+	"auto-claude-code/internal/auth"
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
 	"auto-claude-code/internal/logger"
 )
 
@@ -35,6 +41,8 @@ type TransportType string
 const (
 	TransportHTTP  TransportType = "http"
 	TransportStdio TransportType = "stdio"
+	TransportSSE   TransportType = "sse"
+	TransportGRPC  TransportType = "grpc"
 )
 
 // TransportHandler 传输处理器
@@ -47,21 +55,24 @@ type StdioTransport struct {
 	logger  logger.Logger
 	handler TransportHandler
 
-	reader io.Reader
-	writer io.Writer
+	reader  io.Reader
+	writer  io.Writer
+	framing string
+	writeMu sync.Mutex // 响应和通知可能并发写入
 
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 }
 
-// NewStdioTransport 创建stdio传输
-func NewStdioTransport(handler TransportHandler, logger logger.Logger, reader io.Reader, writer io.Writer) Transport {
+// NewStdioTransport 创建stdio传输，framing 为消息分帧方式（auto、newline、content-length）
+func NewStdioTransport(handler TransportHandler, logger logger.Logger, reader io.Reader, writer io.Writer, framing string) Transport {
 	return &StdioTransport{
 		logger:  logger,
 		handler: handler,
 		reader:  reader,
 		writer:  writer,
+		framing: framing,
 	}
 }
 
@@ -111,36 +122,61 @@ func (t *StdioTransport) GetAddress() string {
 	return "stdio"
 }
 
+// stdioSessionID stdio传输只有一个会话
+const stdioSessionID = "stdio"
+
+// write 写入一条JSON消息
+func (t *StdioTransport) write(codec stdioCodec, msg interface{}) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	return codec.WriteMessage(msg)
+}
+
 // messageLoop 消息处理循环
 func (t *StdioTransport) messageLoop() {
 	defer t.wg.Done()
 
-	scanner := bufio.NewScanner(t.reader)
-	encoder := json.NewEncoder(t.writer)
+	// 响应使用与客户端相同的分帧方式
+	codec, framing, err := newStdioCodec(t.framing, t.reader, t.writer)
+	if err != nil {
+		if err != io.EOF {
+			t.logger.Error("初始化stdio消息分帧失败", zap.Error(err))
+		}
+		return
+	}
+	t.logger.Info("stdio消息分帧方式", zap.String("framing", framing))
+
+	ctx := withSession(t.ctx, stdioSessionID)
+
+	// 登记会话以接收服务器推送的通知
+	if registry, ok := t.handler.(SessionRegistry); ok {
+		unregister := registry.RegisterSession(stdioSessionID, func(msg interface{}) {
+			if err := t.write(codec, msg); err != nil {
+				t.logger.Error("发送通知失败", zap.Error(err))
+			}
+		})
+		defer unregister()
+	}
 
 	for {
 		select {
 		case <-t.ctx.Done():
 			return
 		default:
-			if !scanner.Scan() {
-				if err := scanner.Err(); err != nil {
+			data, err := codec.ReadMessage()
+			if err != nil {
+				if err != io.EOF {
 					t.logger.Error("读取stdin失败", zap.Error(err))
 				}
 				return
 			}
 
-			line := scanner.Text()
-			if line == "" {
-				continue
-			}
-
 			// 解析JSON-RPC请求
 			var req JSONRPCRequest
-			if err := json.Unmarshal([]byte(line), &req); err != nil {
+			if err := json.Unmarshal(data, &req); err != nil {
 				t.logger.Error("解析JSON-RPC请求失败",
 					zap.Error(err),
-					zap.String("data", line))
+					zap.ByteString("data", data))
 
 				// 发送错误响应
 				errorResp := &JSONRPCResponse{
@@ -152,7 +188,7 @@ func (t *StdioTransport) messageLoop() {
 						Data:    err.Error(),
 					},
 				}
-				encoder.Encode(errorResp)
+				t.write(codec, errorResp)
 				continue
 			}
 
@@ -160,17 +196,30 @@ func (t *StdioTransport) messageLoop() {
 				zap.String("method", req.Method),
 				zap.Any("id", req.ID))
 
-			// 处理请求
-			resp := t.handler.HandleRequest(t.ctx, &req)
-
-			// 发送响应
-			if err := encoder.Encode(resp); err != nil {
-				t.logger.Error("发送JSON-RPC响应失败", zap.Error(err))
+			// 通知消息（如 notifications/cancelled）同步处理且不需要响应
+			if req.ID == nil {
+				t.handler.HandleRequest(ctx, &req)
+				continue
 			}
 
-			t.logger.Debug("发送JSON-RPC响应",
-				zap.Any("id", resp.ID),
-				zap.Bool("hasError", resp.Error != nil))
+			// 请求并发处理，耗时的工具调用不阻塞后续消息（包括对它的取消通知）
+			t.wg.Add(1)
+			go func(req JSONRPCRequest) {
+				defer t.wg.Done()
+
+				resp := t.handler.HandleRequest(ctx, &req)
+				if resp == nil {
+					return
+				}
+
+				if err := t.write(codec, resp); err != nil {
+					t.logger.Error("发送JSON-RPC响应失败", zap.Error(err))
+				}
+
+				t.logger.Debug("发送JSON-RPC响应",
+					zap.Any("id", resp.ID),
+					zap.Bool("hasError", resp.Error != nil))
+			}(req)
 		}
 	}
 }
@@ -222,6 +271,313 @@ func (t *HTTPTransport) GetAddress() string {
 	return t.address
 }
 
+// sseSessionBuffer 每个SSE会话待发送消息的缓冲数量
+const sseSessionBuffer = 64
+
+// SSETransport SSE传输实现
+// 客户端通过 GET 建立事件流，服务器首先发送 endpoint 事件告知消息端点，
+// 之后客户端将 JSON-RPC 请求 POST 到该端点，响应通过事件流以 message 事件返回
+type SSETransport struct {
+	config    config.MCPSSEConfig
+	logger    logger.Logger
+	handler   TransportHandler
+	keepAlive time.Duration
+
+	sessions     map[string]*sseSession
+	sessionsLock sync.RWMutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// sseSession SSE会话
+type sseSession struct {
+	id        string
+	messages  chan interface{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSSETransport 创建SSE传输，路由需通过 RegisterRoutes 挂载到HTTP服务器
+func NewSSETransport(cfg config.MCPSSEConfig, handler TransportHandler, logger logger.Logger) *SSETransport {
+	keepAlive, err := time.ParseDuration(cfg.KeepAlive)
+	if err != nil || keepAlive <= 0 {
+		keepAlive = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SSETransport{
+		config:    cfg,
+		logger:    logger,
+		handler:   handler,
+		keepAlive: keepAlive,
+		sessions:  make(map[string]*sseSession),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// RegisterRoutes 注册事件流和消息端点
+func (t *SSETransport) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc(t.config.Path, t.handleStream)
+	mux.HandleFunc(t.config.MessagePath, t.handleMessage)
+}
+
+// Start 启动SSE传输，监听由HTTP传输负责
+func (t *SSETransport) Start(ctx context.Context) error {
+	t.logger.Info("启动MCP SSE传输",
+		zap.String("path", t.config.Path),
+		zap.String("messagePath", t.config.MessagePath))
+	return nil
+}
+
+// Stop 停止SSE传输并关闭所有会话
+func (t *SSETransport) Stop(ctx context.Context) error {
+	t.logger.Info("停止MCP SSE传输")
+
+	t.cancel()
+
+	t.sessionsLock.Lock()
+	for _, session := range t.sessions {
+		session.close()
+	}
+	t.sessionsLock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// GetType 获取传输类型
+func (t *SSETransport) GetType() string {
+	return string(TransportSSE)
+}
+
+// GetAddress 获取传输地址
+func (t *SSETransport) GetAddress() string {
+	return t.config.Path
+}
+
+// handleStream 处理事件流连接
+func (t *SSETransport) handleStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持GET方法"))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeProblem(w, r, apperrors.New(apperrors.ErrInternal, "不支持流式响应"))
+		return
+	}
+
+	// 事件流是长连接，取消HTTP服务器的写超时
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		t.logger.Debug("取消SSE写超时失败", zap.Error(err))
+	}
+
+	session, err := t.newSession()
+	if err != nil {
+		writeProblem(w, r, apperrors.Wrap(err, apperrors.ErrInternal, "创建会话失败"))
+		return
+	}
+	defer t.removeSession(session)
+
+	// 登记会话以接收服务器推送的通知
+	if registry, ok := t.handler.(SessionRegistry); ok {
+		defer registry.RegisterSession(session.id, func(msg interface{}) {
+			// 通知在任务管理器中同步发出，客户端读取过慢时丢弃而不阻塞任务
+			if !session.trySend(msg) {
+				t.logger.Warn("SSE会话缓冲已满，丢弃通知", zap.String("session", session.id))
+			}
+		})()
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	t.logger.Info("SSE会话已建立",
+		zap.String("session", session.id),
+		zap.String("remote", r.RemoteAddr))
+
+	fmt.Fprintf(w, "event: endpoint\ndata: %s?sessionId=%s\n\n", t.config.MessagePath, session.id)
+	flusher.Flush()
+
+	ticker := time.NewTicker(t.keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			t.logger.Info("SSE会话已断开", zap.String("session", session.id))
+			return
+		case <-session.done:
+			return
+		case <-ticker.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case msg := <-session.messages:
+			data, err := json.Marshal(msg)
+			if err != nil {
+				t.logger.Error("序列化SSE消息失败", zap.Error(err))
+				continue
+			}
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
+// handleMessage 处理客户端发送的JSON-RPC消息
+func (t *SSETransport) handleMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持POST方法"))
+		return
+	}
+
+	session := t.getSession(r.URL.Query().Get("sessionId"))
+	if session == nil {
+		writeProblem(w, r, apperrors.New(apperrors.ErrResourceNotFound, "会话不存在或已关闭"))
+		return
+	}
+
+	var req JSONRPCRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if isBodyTooLarge(err) {
+			writeProblem(w, r, apperrors.New(apperrors.ErrRequestTooLarge, "请求体过大"))
+			return
+		}
+		writeProblem(w, r, apperrors.New(apperrors.ErrInvalidRequest, "解析JSON-RPC请求失败"))
+		session.send(&JSONRPCResponse{
+			JSONRPC: "2.0",
+			Error: &JSONRPCError{
+				Code:    -32700,
+				Message: "Parse error",
+				Data:    err.Error(),
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context(), t.logger).Debug("收到SSE JSON-RPC请求",
+		zap.String("session", session.id),
+		zap.String("method", req.Method),
+		zap.Any("id", req.ID))
+
+	w.WriteHeader(http.StatusAccepted)
+
+	// 请求可能耗时较长，异步处理后通过事件流返回响应，沿用消息请求的请求ID、客户端地址、认证身份和限流键
+	ctx := logger.WithRequestID(withSession(t.ctx, session.id), logger.RequestIDFromContext(r.Context()))
+	if ip := audit.SourceIPFromContext(r.Context()); ip != "" {
+		ctx = audit.WithSourceIP(ctx, ip)
+	}
+	if identity := auth.IdentityFromContext(r.Context()); identity != nil {
+		ctx = auth.WithIdentity(ctx, identity)
+	}
+	if key := rateLimitKeyFromContext(r.Context()); key != "" {
+		ctx = withRateLimitKey(ctx, key)
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		resp := t.handler.HandleRequest(ctx, &req)
+
+		// 通知消息和已被取消的请求不需要响应
+		if req.ID == nil || resp == nil {
+			return
+		}
+		if !session.send(resp) {
+			t.logger.Warn("SSE会话已关闭，丢弃响应",
+				zap.String("session", session.id),
+				zap.Any("id", req.ID))
+		}
+	}()
+}
+
+// newSession 创建并登记新会话
+func (t *SSETransport) newSession() (*sseSession, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+
+	session := &sseSession{
+		id:       hex.EncodeToString(buf),
+		messages: make(chan interface{}, sseSessionBuffer),
+		done:     make(chan struct{}),
+	}
+
+	t.sessionsLock.Lock()
+	t.sessions[session.id] = session
+	t.sessionsLock.Unlock()
+
+	return session, nil
+}
+
+// getSession 获取会话
+func (t *SSETransport) getSession(id string) *sseSession {
+	t.sessionsLock.RLock()
+	defer t.sessionsLock.RUnlock()
+	return t.sessions[id]
+}
+
+// removeSession 移除并关闭会话
+func (t *SSETransport) removeSession(session *sseSession) {
+	t.sessionsLock.Lock()
+	delete(t.sessions, session.id)
+	t.sessionsLock.Unlock()
+
+	session.close()
+}
+
+// send 向会话发送消息，会话已关闭时返回 false
+func (s *sseSession) send(msg interface{}) bool {
+	select {
+	case s.messages <- msg:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// trySend 非阻塞地向会话发送消息，缓冲已满或会话已关闭时返回 false
+func (s *sseSession) trySend(msg interface{}) bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+
+	select {
+	case s.messages <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+// close 关闭会话
+func (s *sseSession) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
 // MultiTransport 多传输支持
 type MultiTransport struct {
 	transports []Transport
@@ -288,5 +644,10 @@ type transportHandlerAdapter struct {
 
 // HandleRequest 处理传输请求
 func (t *transportHandlerAdapter) HandleRequest(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
-	return t.server.processJSONRPCRequest(ctx, req)
+	return t.server.handleSessionRequest(ctx, req)
+}
+
+// RegisterSession 将传输会话登记到通知分发器
+func (t *transportHandlerAdapter) RegisterSession(id string, send func(msg interface{})) func() {
+	return t.server.notifier.RegisterSession(id, send)
 }
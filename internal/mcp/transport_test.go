@@ -0,0 +1,102 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/logger"
+)
+
+// echoHandler 返回请求方法名的测试处理器
+type echoHandler struct{}
+
+func (echoHandler) HandleRequest(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	return &JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: req.Method}
+}
+
+func TestSSETransport_RoundTrip(t *testing.T) {
+	log, err := logger.CreateLoggerFromConfig("info", false, "")
+	if err != nil {
+		t.Fatalf("创建日志器失败: %v", err)
+	}
+
+	transport := NewSSETransport(config.MCPSSEConfig{
+		Path:        "/sse",
+		MessagePath: "/messages",
+		KeepAlive:   "1m",
+	}, echoHandler{}, log)
+
+	mux := http.NewServeMux()
+	transport.RegisterRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	defer transport.Stop(context.Background())
+
+	// 客户端超时同时限制事件流的读取时间
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(server.URL + "/sse")
+	if err != nil {
+		t.Fatalf("建立事件流失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %s, 期望 text/event-stream", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() (string, string) {
+		var event, data string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("读取事件失败: %v", err)
+			}
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case line == "" && event != "":
+				return event, data
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}
+
+	event, endpoint := readEvent()
+	if event != "endpoint" || !strings.HasPrefix(endpoint, "/messages?sessionId=") {
+		t.Fatalf("endpoint 事件 = %s %s", event, endpoint)
+	}
+
+	// 未知会话应返回 404
+	badResp, err := http.Post(server.URL+"/messages?sessionId=unknown", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("发送消息失败: %v", err)
+	}
+	badResp.Body.Close()
+	if badResp.StatusCode != http.StatusNotFound {
+		t.Errorf("未知会话状态码 = %d, 期望 404", badResp.StatusCode)
+	}
+
+	postResp, err := http.Post(server.URL+endpoint, "application/json",
+		strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	if err != nil {
+		t.Fatalf("发送消息失败: %v", err)
+	}
+	postResp.Body.Close()
+	if postResp.StatusCode != http.StatusAccepted {
+		t.Fatalf("消息状态码 = %d, 期望 202", postResp.StatusCode)
+	}
+
+	event, data := readEvent()
+	if event != "message" || !strings.Contains(data, `"result":"tools/list"`) {
+		t.Errorf("message 事件 = %s %s", event, data)
+	}
+}
@@ -0,0 +1,227 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+const (
+	// defaultPriorityLevels 未配置 mcp.queue.priority_levels 时的优先级级数
+	defaultPriorityLevels = 3
+	// minTaskTimeout 任务超时下限，timeout 以纳秒为单位，过小的值通常是误用了秒或毫秒
+	minTaskTimeout = time.Second
+	// maxTaskTimeout 任务超时上限
+	maxTaskTimeout = 24 * time.Hour
+	// maxTaskTurns maxTurns 上限
+	maxTaskTurns = 1000
+)
+
+// permissionModes Claude Code --permission-mode 支持的取值
+var permissionModes = []string{"default", "acceptEdits", "plan", "bypassPermissions"}
+
+// isPermissionMode 检查是否为支持的权限模式
+func isPermissionMode(mode string) bool {
+	for _, m := range permissionModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// identifierRegex 任务ID和发行版名称允许的字符，任务ID会出现在 URL 路径中
+var identifierRegex = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// absolutePathRegex Windows 盘符路径、UNC 路径或 Unix 绝对路径
+var absolutePathRegex = regexp.MustCompile(`^([A-Za-z]:[\\/]|\\\\|/)`)
+
+// RequestValidationError 请求体字段校验错误，错误响应的 errors 字段逐个列出
+type RequestValidationError struct {
+	Fields []FieldError `json:"errors"`
+}
+
+// Error 实现 error 接口
+func (e *RequestValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, fmt.Sprintf("%s: %s", field.Field, field.Message))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// newValidationError 将字段错误包装为请求无效错误，没有字段错误时返回 nil
+func newValidationError(message string, fields []FieldError) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+	validationErr := &RequestValidationError{Fields: fields}
+	return apperrors.Wrap(validationErr, apperrors.ErrInvalidRequest, message).WithDetails(validationErr.Error())
+}
+
+// Validate 校验任务请求，priorityLevels 为允许的最高优先级，未指定的优先级和超时使用默认值
+// 空的任务类型视为 claude_code
+func (req *TaskRequest) Validate(priorityLevels int) error {
+	if priorityLevels <= 0 {
+		priorityLevels = defaultPriorityLevels
+	}
+
+	var fields []FieldError
+	add := func(field, format string, args ...interface{}) {
+		fields = append(fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if req.ID != "" && !identifierRegex.MatchString(req.ID) {
+		add("id", "只能包含字母、数字、.、_、-，长度不超过 128")
+	}
+
+	switch req.Type {
+	case "":
+		req.Type = "claude_code"
+	case "claude_code", "interactive":
+	default:
+		add("type", "不支持的任务类型 %q，支持: claude_code、interactive", req.Type)
+	}
+
+	switch {
+	case strings.TrimSpace(req.ProjectPath) == "":
+		add("projectPath", "不能为空")
+	case strings.ContainsAny(req.ProjectPath, "\x00\r\n"):
+		add("projectPath", "不能包含控制字符")
+	case !absolutePathRegex.MatchString(req.ProjectPath):
+		add("projectPath", "必须是绝对路径")
+	}
+
+	if req.Priority < 0 || req.Priority > priorityLevels {
+		add("priority", "必须在 1 到 %d 之间", priorityLevels)
+	}
+
+	if req.Timeout != 0 && (req.Timeout < minTaskTimeout || req.Timeout > maxTaskTimeout) {
+		add("timeout", "必须在 %s 到 %s 之间（单位为纳秒）", minTaskTimeout, maxTaskTimeout)
+	}
+
+	if req.Distro != "" && !identifierRegex.MatchString(req.Distro) {
+		add("distro", "无效的发行版名称")
+	}
+	for i, tag := range req.DistroTags {
+		if !identifierRegex.MatchString(tag) {
+			add(fmt.Sprintf("distroTags[%d]", i), "无效的标签")
+		}
+	}
+
+	if req.Model != "" && !identifierRegex.MatchString(req.Model) {
+		add("model", "无效的模型名称")
+	}
+	if req.MaxTurns < 0 || req.MaxTurns > maxTaskTurns {
+		add("maxTurns", "必须在 1 到 %d 之间", maxTaskTurns)
+	}
+	if req.PermissionMode != "" && !isPermissionMode(req.PermissionMode) {
+		add("permissionMode", "不支持的权限模式 %q，支持: %s", req.PermissionMode, strings.Join(permissionModes, "、"))
+	}
+	for i, tool := range req.AllowedTools {
+		if strings.TrimSpace(tool) == "" || strings.ContainsAny(tool, ",\x00\r\n") {
+			add(fmt.Sprintf("allowedTools[%d]", i), "不能为空或包含逗号、控制字符")
+		}
+	}
+
+	seen := make(map[string]bool, len(req.DependsOn))
+	for i, dep := range req.DependsOn {
+		switch {
+		case !identifierRegex.MatchString(dep):
+			add(fmt.Sprintf("dependsOn[%d]", i), "无效的任务ID")
+		case dep == req.ID:
+			add(fmt.Sprintf("dependsOn[%d]", i), "任务不能依赖自身")
+		case seen[dep]:
+			add(fmt.Sprintf("dependsOn[%d]", i), "重复的任务ID %s", dep)
+		}
+		seen[dep] = true
+	}
+
+	if req.Limits != nil {
+		if err := req.Limits.Validate(); err != nil {
+			var appErr *apperrors.AppError
+			if errors.As(err, &appErr) {
+				add("limits", "%s", appErr.Message)
+			} else {
+				add("limits", "%v", err)
+			}
+		}
+	}
+
+	if req.MergeBack != nil {
+		fields = append(fields, req.MergeBack.validate("mergeBack.")...)
+	}
+
+	fields = append(fields, req.validateFollowUps(priorityLevels)...)
+
+	return newValidationError("任务请求参数无效", fields)
+}
+
+// decodeJSONBody 严格解析 JSON 请求体：拒绝未知字段和多余内容，类型错误按字段返回
+// 请求体超过 mcp.http.max_body_bytes 时返回请求体过大错误
+func decodeJSONBody(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(v)
+	if err == nil {
+		if decoder.Decode(&struct{}{}) != io.EOF {
+			return apperrors.New(apperrors.ErrInvalidRequest, "请求体只能包含一个 JSON 对象")
+		}
+		return nil
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return apperrors.Newf(apperrors.ErrRequestTooLarge, "请求体超过 %d 字节", maxBytesErr.Limit)
+	case errors.As(err, &typeErr):
+		return newValidationError("请求参数类型错误", []FieldError{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("类型应为 %s，实际为 %s", typeErr.Type, typeErr.Value),
+		}})
+	case errors.As(err, &syntaxErr):
+		return apperrors.Newf(apperrors.ErrInvalidRequest, "JSON 格式错误（第 %d 字节）", syntaxErr.Offset)
+	case errors.Is(err, io.EOF):
+		return apperrors.New(apperrors.ErrInvalidRequest, "请求体不能为空")
+	}
+
+	// encoding/json 对未知字段返回 json: unknown field "name" 形式的错误
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return newValidationError("请求包含未知字段", []FieldError{{
+			Field:   strings.Trim(field, `"`),
+			Message: "未知字段",
+		}})
+	}
+	return apperrors.Wrap(err, apperrors.ErrInvalidRequest, "无效的请求格式")
+}
+
+// isBodyTooLarge 检查错误是否由请求体超过大小限制引起
+func isBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// bodyLimitMiddleware 限制请求体大小，Content-Length 已超限的请求直接返回 413
+func (s *mcpServer) bodyLimitMiddleware(next http.Handler) http.Handler {
+	limit := s.config.HTTP.MaxBodyBytes
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			writeProblem(w, r, apperrors.Newf(apperrors.ErrRequestTooLarge, "请求体超过 %d 字节", limit))
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
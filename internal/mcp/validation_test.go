@@ -0,0 +1,126 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+func TestTaskRequestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    TaskRequest
+		fields []string
+	}{
+		{"Windows路径", TaskRequest{ProjectPath: `C:\work\app`, Priority: 2}, nil},
+		{"Unix路径和完整参数", TaskRequest{ID: "task_1", Type: "claude_code", ProjectPath: "/work/app", Timeout: time.Hour, Distro: "Ubuntu-22.04"}, nil},
+		{"缺少项目路径", TaskRequest{}, []string{"projectPath"}},
+		{"相对路径", TaskRequest{ProjectPath: "app"}, []string{"projectPath"}},
+		{"未知类型", TaskRequest{Type: "shell", ProjectPath: "/app"}, []string{"type"}},
+		{"优先级越界", TaskRequest{ProjectPath: "/app", Priority: 4}, []string{"priority"}},
+		{"超时按秒误填", TaskRequest{ProjectPath: "/app", Timeout: 1800}, []string{"timeout"}},
+		{"负超时", TaskRequest{ProjectPath: "/app", Timeout: -time.Minute}, []string{"timeout"}},
+		{"无效ID和发行版", TaskRequest{ID: "../x", ProjectPath: "/app", Distro: "a b"}, []string{"distro", "id"}},
+		{"无效资源限制", TaskRequest{ProjectPath: "/app", Limits: &config.ResourceLimits{Nice: 30}}, []string{"limits"}},
+		{"Claude Code 选项", TaskRequest{ProjectPath: "/app", Model: "claude-sonnet-4-5", MaxTurns: 20, PermissionMode: "acceptEdits", AllowedTools: []string{"Read", "Bash(git diff:*)"}}, nil},
+		{"无效 Claude Code 选项", TaskRequest{ProjectPath: "/app", Model: "a b", MaxTurns: -1, PermissionMode: "yolo", AllowedTools: []string{"Read,Edit", " "}}, []string{"allowedTools[0]", "allowedTools[1]", "maxTurns", "model", "permissionMode"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate(3)
+
+			var got []string
+			if validationErr := validationFields(err); validationErr != nil {
+				for _, field := range validationErr.Fields {
+					got = append(got, field.Field)
+				}
+			} else if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("错误字段 = %v, want %v", got, tt.fields)
+			}
+			if err != nil && !apperrors.IsCode(err, apperrors.ErrInvalidRequest) {
+				t.Errorf("错误代码 = %s", apperrors.GetCode(err))
+			}
+		})
+	}
+}
+
+// validationFields 取出错误链中的字段校验错误
+func validationFields(err error) *RequestValidationError {
+	var validationErr *RequestValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr
+	}
+	return nil
+}
+
+func TestHandleTasksRejectsInvalidBody(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		field  string
+	}{
+		{"未知字段", `{"projectPath":"/app","project_path":"/app"}`, http.StatusBadRequest, "project_path"},
+		{"类型错误", `{"projectPath":"/app","priority":"high"}`, http.StatusBadRequest, "priority"},
+		{"取值无效", `{"projectPath":"app"}`, http.StatusBadRequest, "projectPath"},
+		{"多余内容", `{"projectPath":"/app"} {}`, http.StatusBadRequest, ""},
+		{"空请求体", ``, http.StatusBadRequest, ""},
+		{"请求体过大", `{"projectPath":"/app","command":"` + strings.Repeat("x", 200) + `"}`, http.StatusRequestEntityTooLarge, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &mcpServer{
+				config:      &config.MCPConfig{HTTP: config.MCPHTTPConfig{MaxBodyBytes: 128}},
+				taskManager: &validatingTaskManager{},
+			}
+			handler := server.bodyLimitMiddleware(http.HandlerFunc(server.handleTasks))
+
+			req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("状态码 = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+
+			var problem struct {
+				Errors []FieldError `json:"errors"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &problem)
+			if tt.field == "" {
+				if len(problem.Errors) != 0 {
+					t.Errorf("errors = %+v", problem.Errors)
+				}
+				return
+			}
+			if len(problem.Errors) != 1 || problem.Errors[0].Field != tt.field {
+				t.Errorf("errors = %+v, want 字段 %s", problem.Errors, tt.field)
+			}
+		})
+	}
+}
+
+// validatingTaskManager 只做请求校验的任务管理器
+type validatingTaskManager struct {
+	TaskManager
+}
+
+func (m *validatingTaskManager) SubmitTask(ctx context.Context, req *TaskRequest) (*TaskStatus, error) {
+	if err := req.Validate(0); err != nil {
+		return nil, err
+	}
+	return &TaskStatus{ID: "t1", Status: "pending"}, nil
+}
@@ -0,0 +1,333 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// Webhook 请求头
+const (
+	webhookEventHeader     = "X-Auto-Claude-Event"
+	webhookDeliveryHeader  = "X-Auto-Claude-Delivery"
+	webhookSignatureHeader = "X-Auto-Claude-Signature"
+)
+
+const (
+	// webhookQueueSize 每个目标待投递事件的缓冲数量，已满时丢弃新事件
+	webhookQueueSize = 100
+	// webhookDefaultTimeout 单次请求的默认超时
+	webhookDefaultTimeout = 10 * time.Second
+	// webhookDefaultBackoff 首次重试的默认等待时间
+	webhookDefaultBackoff = 5 * time.Second
+)
+
+// webhookDefaultEvents 未配置 events 时订阅的事件
+var webhookDefaultEvents = []string{streamEventCompleted, streamEventFailed, streamEventTimeout}
+
+// webhookPayload Webhook 请求体
+type webhookPayload struct {
+	ID    string      `json:"id"`
+	Event string      `json:"event"`
+	Time  time.Time   `json:"time"`
+	Task  *TaskStatus `json:"task"`
+}
+
+// webhookDelivery 一次待投递的事件
+type webhookDelivery struct {
+	id    string
+	event string
+	body  []byte
+}
+
+// webhookTarget 单个 Webhook 目标及其投递队列
+type webhookTarget struct {
+	config     config.WebhookConfig
+	url        string
+	secret     string
+	events     map[string]bool
+	timeout    time.Duration
+	maxRetries int
+	backoff    time.Duration
+	queue      chan *webhookDelivery
+}
+
+// webhookDispatcher 将任务事件异步投递给配置的 Webhook 目标，每个目标由独立的协程按顺序投递
+type webhookDispatcher struct {
+	logger  logger.Logger
+	client  *http.Client
+	targets []*webhookTarget
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.RWMutex
+	stopped bool
+}
+
+// newWebhookDispatcher 创建 Webhook 分发器并启动投递协程，配置已由 config.WebhookConfig.Validate 验证
+func newWebhookDispatcher(cfgs []config.WebhookConfig, log logger.Logger) *webhookDispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &webhookDispatcher{
+		logger: log,
+		client: &http.Client{},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	for _, cfg := range cfgs {
+		d.startTarget(cfg)
+	}
+
+	return d
+}
+
+// startTarget 添加目标并启动它的投递协程，调用方需持有 d.mu 或在分发器创建时调用
+func (d *webhookDispatcher) startTarget(cfg config.WebhookConfig) {
+	target := &webhookTarget{
+		config:     cfg,
+		url:        cfg.URL,
+		secret:     cfg.Secret,
+		events:     make(map[string]bool),
+		timeout:    parseDurationOr(cfg.Timeout, webhookDefaultTimeout),
+		maxRetries: cfg.MaxRetries,
+		backoff:    parseDurationOr(cfg.RetryBackoff, webhookDefaultBackoff),
+		queue:      make(chan *webhookDelivery, webhookQueueSize),
+	}
+	events := cfg.Events
+	if len(events) == 0 {
+		events = webhookDefaultEvents
+	}
+	for _, event := range events {
+		target.events[event] = true
+	}
+
+	d.targets = append(d.targets, target)
+	d.wg.Add(1)
+	go d.run(target)
+}
+
+// update 按新配置替换目标，配置未变的目标保留投递队列，移除的目标投递完队列中的事件后结束
+// 返回新增和移除的目标数
+func (d *webhookDispatcher) update(cfgs []config.WebhookConfig) (added, removed int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return 0, 0
+	}
+
+	old := d.targets
+	d.targets = nil
+	var pending []config.WebhookConfig
+	for _, cfg := range cfgs {
+		kept := false
+		for i, target := range old {
+			if target != nil && reflect.DeepEqual(target.config, cfg) {
+				d.targets = append(d.targets, target)
+				old[i] = nil
+				kept = true
+				break
+			}
+		}
+		if !kept {
+			pending = append(pending, cfg)
+		}
+	}
+	for _, target := range old {
+		if target != nil {
+			close(target.queue)
+			removed++
+		}
+	}
+	for _, cfg := range pending {
+		d.startTarget(cfg)
+	}
+	return len(pending), removed
+}
+
+// parseDurationOr 解析时长，为空或无效时返回默认值
+func parseDurationOr(value string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// HandleTaskEvent 任务事件监听器，将订阅的事件放入各目标的投递队列，不阻塞任务管理器
+func (d *webhookDispatcher) HandleTaskEvent(event TaskEvent) {
+	eventType := streamEventType(event)
+	if eventType == "" {
+		return
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.stopped {
+		return
+	}
+
+	var delivery *webhookDelivery
+	for _, target := range d.targets {
+		if !target.events[eventType] {
+			continue
+		}
+		if delivery == nil {
+			var err error
+			if delivery, err = newWebhookDelivery(eventType, event.Task); err != nil {
+				d.logger.Warn("生成Webhook请求体失败", zap.String("event", eventType), zap.Error(err))
+				return
+			}
+		}
+
+		select {
+		case target.queue <- delivery:
+		default:
+			d.logger.Warn("Webhook投递队列已满，丢弃事件",
+				zap.String("url", target.url),
+				zap.String("event", eventType),
+				zap.String("taskId", event.Task.ID))
+		}
+	}
+}
+
+// newWebhookDelivery 为任务事件生成投递 ID 和请求体
+func newWebhookDelivery(eventType string, task *TaskStatus) (*webhookDelivery, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	id := hex.EncodeToString(buf)
+
+	body, err := json.Marshal(&webhookPayload{
+		ID:    id,
+		Event: eventType,
+		Time:  time.Now().UTC(),
+		Task:  task,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhookDelivery{id: id, event: eventType, body: body}, nil
+}
+
+// run 按顺序投递目标队列中的事件，直到队列关闭
+func (d *webhookDispatcher) run(target *webhookTarget) {
+	defer d.wg.Done()
+
+	for delivery := range target.queue {
+		log := d.logger.With(
+			zap.String("url", target.url),
+			zap.String("event", delivery.event),
+			zap.String("delivery", delivery.id))
+
+		if err := d.deliver(target, delivery, log); err != nil {
+			log.Warn("Webhook投递失败", zap.Error(err))
+		}
+	}
+}
+
+// deliver 投递单个事件，网络错误、5xx 和 429 按指数退避重试
+func (d *webhookDispatcher) deliver(target *webhookTarget, delivery *webhookDelivery, log logger.Logger) error {
+	backoff := target.backoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := d.send(target, delivery)
+		if err == nil {
+			log.Debug("Webhook投递成功", zap.Int("attempt", attempt+1))
+			return nil
+		}
+		if !retryable || attempt >= target.maxRetries {
+			return err
+		}
+
+		log.Debug("Webhook投递失败，稍后重试", zap.Int("attempt", attempt+1), zap.Duration("backoff", backoff), zap.Error(err))
+		select {
+		case <-d.ctx.Done():
+			return apperrors.Wrap(err, apperrors.ErrWebhookFailed, "服务器停止，放弃重试")
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// send 发送一次请求，返回失败是否可重试
+func (d *webhookDispatcher) send(target *webhookTarget, delivery *webhookDelivery) (bool, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, target.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.url, bytes.NewReader(delivery.body))
+	if err != nil {
+		return false, apperrors.Wrap(err, apperrors.ErrInvalidRequest, "创建Webhook请求失败")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "auto-claude-code-webhook")
+	req.Header.Set(webhookEventHeader, delivery.event)
+	req.Header.Set(webhookDeliveryHeader, delivery.id)
+	if target.secret != "" {
+		req.Header.Set(webhookSignatureHeader, webhookSignature(target.secret, delivery.body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, apperrors.Wrap(err, apperrors.ErrWebhookFailed, "发送Webhook请求失败")
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retryable, apperrors.Newf(apperrors.ErrWebhookFailed, "Webhook返回状态码 %d", resp.StatusCode)
+}
+
+// webhookSignature 计算请求体的 HMAC-SHA256 签名，格式为 sha256=<十六进制>
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// stop 停止接收新事件，等待队列中的事件投递完成；ctx 结束时中止仍在进行的投递
+func (d *webhookDispatcher) stop(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.stopped {
+		d.stopped = true
+		for _, target := range d.targets {
+			close(target.queue)
+		}
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		d.cancel()
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-done
+		return apperrors.Wrap(ctx.Err(), apperrors.ErrWebhookFailed, "等待Webhook投递超时")
+	}
+}
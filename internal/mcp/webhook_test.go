@@ -0,0 +1,115 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"auto-claude-code/internal/config"
+)
+
+// webhookRequest 测试接收端收到的请求
+type webhookRequest struct {
+	event     string
+	signature string
+	body      []byte
+}
+
+func TestWebhookDispatcher(t *testing.T) {
+	var mu sync.Mutex
+	var received []webhookRequest
+	attempts := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts == 1 {
+			// 首次请求失败，验证重试
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received = append(received, webhookRequest{
+			event:     r.Header.Get(webhookEventHeader),
+			signature: r.Header.Get(webhookSignatureHeader),
+			body:      body,
+		})
+	}))
+	defer ts.Close()
+
+	d := newWebhookDispatcher([]config.WebhookConfig{
+		{URL: ts.URL, Secret: "s3cret", MaxRetries: 2, RetryBackoff: "10ms"},
+	}, newTestBroker(t).logger)
+
+	d.HandleTaskEvent(TaskEvent{Type: TaskEventStatus, Task: &TaskStatus{ID: "t1", Status: "running"}})
+	d.HandleTaskEvent(TaskEvent{Type: TaskEventStatus, Task: &TaskStatus{ID: "t1", Status: "completed"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.stop(ctx); err != nil {
+		t.Fatalf("stop() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 2 || len(received) != 1 {
+		t.Fatalf("请求次数 = %d, 成功投递 = %d, 默认只订阅结束事件且应重试一次", attempts, len(received))
+	}
+
+	got := received[0]
+	if got.event != streamEventCompleted {
+		t.Errorf("事件头 = %s", got.event)
+	}
+	if got.signature != webhookSignature("s3cret", got.body) {
+		t.Errorf("签名 = %s", got.signature)
+	}
+	var payload webhookPayload
+	if err := json.Unmarshal(got.body, &payload); err != nil || payload.Task.ID != "t1" || payload.Event != streamEventCompleted {
+		t.Errorf("请求体 = %s", got.body)
+	}
+}
+
+func TestWebhookNoRetryOnClientError(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	d := newWebhookDispatcher([]config.WebhookConfig{
+		{URL: ts.URL, Events: []string{streamEventFailed}, MaxRetries: 3, RetryBackoff: "10ms"},
+	}, newTestBroker(t).logger)
+
+	d.HandleTaskEvent(TaskEvent{Type: TaskEventStatus, Task: &TaskStatus{ID: "t1", Status: "failed"}})
+	if err := d.stop(context.Background()); err != nil {
+		t.Fatalf("stop() error = %v", err)
+	}
+
+	// 停止后的事件不再投递
+	d.HandleTaskEvent(TaskEvent{Type: TaskEventStatus, Task: &TaskStatus{ID: "t2", Status: "failed"}})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 1 {
+		t.Errorf("请求次数 = %d, 4xx 不应重试", attempts)
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	// printf '{}' | openssl dgst -sha256 -hmac key
+	want := "sha256=a777724d943eb48dc69bca8a4a6d57a04db3f9ec7e1de4e581e860265bdf3032"
+	if got := webhookSignature("key", []byte("{}")); got != want {
+		t.Errorf("webhookSignature() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,205 @@
+package mcp
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// WriteWorktreeArchive 将worktree的文件以 zip 格式写入 w，列出文件失败时不写入 w
+// Git worktree包含已跟踪和未被忽略的未跟踪文件，非 Git worktree包含全部文件；changedOnly 时只包含相对基准提交修改和新增的文件
+func (wm *worktreeManager) WriteWorktreeArchive(ctx context.Context, worktreeID string, changedOnly bool, w io.Writer) error {
+	wm.mutex.RLock()
+	_, exists := wm.worktrees[worktreeID]
+	wm.mutex.RUnlock()
+	if !exists {
+		return apperrors.Newf(apperrors.ErrWorktreeNotFound, "Worktree不存在: %s", worktreeID)
+	}
+
+	worktreePath := filepath.Join(wm.baseDir, worktreeID)
+	var files []string
+	var err error
+	switch {
+	case changedOnly:
+		files, err = wm.GetChangedFiles(ctx, worktreeID)
+	case wm.isGitRepository(worktreePath):
+		files, err = wm.listGitFiles(ctx, worktreePath)
+	default:
+		files, err = listArchiveFiles(worktreePath)
+	}
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	zw := zip.NewWriter(w)
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := addArchiveEntry(zw, worktreePath, file); err != nil {
+			return apperrors.Wrapf(err, apperrors.ErrWorktreeFailed, "打包worktree文件失败: %s", file)
+		}
+	}
+	return zw.Close()
+}
+
+// listGitFiles 列出 Git worktree中已跟踪和未被忽略的未跟踪文件
+func (wm *worktreeManager) listGitFiles(ctx context.Context, worktreePath string) ([]string, error) {
+	output, err := wm.git(ctx, worktreePath, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrGitOperation, "列出worktree的文件失败")
+	}
+	var files []string
+	for _, file := range strings.Split(output, "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// listArchiveFiles 列出非 Git worktree中的全部文件，路径以 / 分隔
+func listArchiveFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "列出worktree的文件失败")
+	}
+	return files, nil
+}
+
+// addArchiveEntry 将文件加入 zip，目录（如已初始化的子模块）跳过 .git 后递归加入，不存在的文件（已删除）跳过
+func addArchiveEntry(zw *zip.Writer, root, name string) error {
+	file := filepath.Join(root, filepath.FromSlash(name))
+	info, err := os.Lstat(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		entries, err := os.ReadDir(file)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.Name() == ".git" {
+				continue
+			}
+			if err := addArchiveEntry(zw, root, name+"/"+entry.Name()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	writer, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	// 符号链接以链接目标作为内容，解压后恢复为链接
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(file)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(writer, filepath.ToSlash(target))
+		return err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(writer, f)
+	return err
+}
+
+// archiveResponse 第一次写入时才发送 zip 响应头，之前出错时仍可返回错误响应
+type archiveResponse struct {
+	w        http.ResponseWriter
+	filename string
+	started  bool
+}
+
+// Write 写入 zip 内容
+func (a *archiveResponse) Write(p []byte) (int, error) {
+	if !a.started {
+		a.started = true
+		a.w.Header().Set("Content-Type", "application/zip")
+		a.w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.filename}))
+		a.w.WriteHeader(http.StatusOK)
+	}
+	return a.w.Write(p)
+}
+
+// handleWorktreeArchive 以 zip 下载worktree的文件，changed=true 时只包含相对基准提交修改和新增的文件
+func (s *mcpServer) handleWorktreeArchive(w http.ResponseWriter, r *http.Request, worktreeID string) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持GET方法"))
+		return
+	}
+
+	var changedOnly bool
+	if value := r.URL.Query().Get("changed"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			writeProblem(w, r, apperrors.Newf(apperrors.ErrInvalidRequest, "无效的 changed 参数: %s", value))
+			return
+		}
+		changedOnly = parsed
+	}
+
+	filename := worktreeID + ".zip"
+	if changedOnly {
+		filename = worktreeID + "-changed.zip"
+	}
+	archive := &archiveResponse{w: w, filename: filename}
+	if err := s.worktreeManager.WriteWorktreeArchive(r.Context(), worktreeID, changedOnly, archive); err != nil {
+		if !archive.started {
+			writeProblem(w, r, err)
+			return
+		}
+		// 已开始发送时无法返回错误响应，客户端得到不完整的 zip
+		logger.FromContext(r.Context(), s.logger).Warn("发送worktree压缩包失败",
+			zap.String("worktreeId", worktreeID),
+			zap.Error(err))
+	}
+}
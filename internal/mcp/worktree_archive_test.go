@@ -0,0 +1,124 @@
+package mcp
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/logger"
+)
+
+// readArchive 解压响应中的 zip，返回文件名到内容的映射
+func readArchive(t *testing.T, body []byte) map[string]string {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("读取 zip 失败: %v", err)
+	}
+	files := make(map[string]string)
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[file.Name] = string(content)
+	}
+	return files
+}
+
+func TestWorktreeArchive(t *testing.T) {
+	wm, project, worktree := newMergeTestRepo(t)
+	writeFile(t, filepath.Join(project, "docs.md"), "docs\n")
+	writeFile(t, filepath.Join(project, ".gitignore"), "*.log\n")
+	runGit(t, project, "add", "-A")
+	runGit(t, project, "commit", "-q", "-m", "docs")
+	runGit(t, worktree.Path, "merge", "-q", "main")
+	runGit(t, worktree.Path, "rm", "-q", "docs.md")
+	if err := os.MkdirAll(filepath.Join(worktree.Path, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(worktree.Path, "src", "main.go"), "package main\n")
+	writeFile(t, filepath.Join(worktree.Path, "debug.log"), "ignored\n")
+
+	server := &mcpServer{logger: logger.FromZap(zap.NewNop()), worktreeManager: wm}
+	tests := []struct {
+		name         string
+		query        string
+		wantFilename string
+		wantFiles    []string
+	}{
+		{"全部文件", "", worktree.ID + ".zip", []string{".gitignore", "README.md", "src/main.go"}},
+		{"只包含修改的文件", "?changed=true", worktree.ID + "-changed.zip", []string{".gitignore", "src/main.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			server.handleWorktreeDetail(w, httptest.NewRequest(http.MethodGet, "/worktrees/"+worktree.ID+"/archive"+tt.query, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("状态码 = %d: %s", w.Code, w.Body.String())
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/zip" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename=`+tt.wantFilename {
+				t.Errorf("Content-Disposition = %q", cd)
+			}
+
+			files := readArchive(t, w.Body.Bytes())
+			var names []string
+			for name := range files {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.wantFiles) {
+				t.Errorf("压缩包中的文件 = %v, want %v", names, tt.wantFiles)
+			}
+			if files["src/main.go"] != "package main\n" {
+				t.Errorf("src/main.go = %q", files["src/main.go"])
+			}
+		})
+	}
+}
+
+func TestWorktreeArchiveNonGit(t *testing.T) {
+	project := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(project, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(project, "src", "index.js"), "console.log(1)\n")
+	wm := NewWorktreeManager(&config.MCPConfig{WorktreeBaseDir: t.TempDir(), MaxWorktrees: 5}, logger.FromZap(zap.NewNop()), nil).(*worktreeManager)
+	worktree, err := wm.CreateWorktree(context.Background(), project, CreateWorktreeOptions{})
+	if err != nil {
+		t.Fatalf("CreateWorktree() error = %v", err)
+	}
+
+	server := &mcpServer{logger: logger.FromZap(zap.NewNop()), worktreeManager: wm}
+	w := httptest.NewRecorder()
+	server.handleWorktreeDetail(w, httptest.NewRequest(http.MethodGet, "/worktrees/"+worktree.ID+"/archive", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d: %s", w.Code, w.Body.String())
+	}
+	if files := readArchive(t, w.Body.Bytes()); !reflect.DeepEqual(files, map[string]string{"src/index.js": "console.log(1)\n"}) {
+		t.Errorf("压缩包中的文件 = %v", files)
+	}
+
+	// 非 Git worktree无法确定修改的文件，在发送前返回错误
+	w = httptest.NewRecorder()
+	server.handleWorktreeDetail(w, httptest.NewRequest(http.MethodGet, "/worktrees/"+worktree.ID+"/archive?changed=true", nil))
+	if w.Code != http.StatusInternalServerError || w.Header().Get("Content-Type") != problemContentType {
+		t.Errorf("状态码 = %d, Content-Type = %q", w.Code, w.Header().Get("Content-Type"))
+	}
+}
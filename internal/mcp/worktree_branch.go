@@ -0,0 +1,138 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// defaultBranchTemplate 未配置 mcp.branch_template 时的任务分支名
+const defaultBranchTemplate = "worktree_{timestamp}"
+
+// maxBranchSlugRunes slug 变量的最大长度
+const maxBranchSlugRunes = 40
+
+// maxBranchSuffix 分支名已存在时最多尝试的序号
+const maxBranchSuffix = 100
+
+// CreateWorktreeOptions 创建worktree的选项，用于生成任务分支名和报告创建进度
+type CreateWorktreeOptions struct {
+	TaskID      string // 任务ID
+	Description string // 任务描述，通常为任务命令
+
+	// Vars 上下文文件模板的额外变量，如任务参数
+	Vars map[string]string
+
+	// Progress 报告耗时步骤的进度，fraction 为 0 到 1，可以为 nil
+	Progress func(fraction float64, message string)
+}
+
+// branchName 按 mcp.branch_template 生成任务分支名，与项目或镜像 mirrorPath 中已有的分支重名时追加 -2、-3 等序号，调用方需持有 wm.mutex
+func (wm *worktreeManager) branchName(ctx context.Context, projectPath, mirrorPath, worktreeID string, opts CreateWorktreeOptions) (string, error) {
+	template := wm.config.BranchTemplate
+	if template == "" {
+		template = defaultBranchTemplate
+	}
+	now := time.Now()
+	base := renderBranchTemplate(template, map[string]string{
+		"task_id":     opts.TaskID,
+		"worktree_id": worktreeID,
+		"timestamp":   fmt.Sprint(now.UnixNano()),
+		"date":        now.Format("20060102"),
+		"project":     filepath.Base(filepath.Clean(projectPath)),
+		"description": opts.Description,
+	})
+	if base == "" {
+		base = worktreeID
+	}
+	if _, err := wm.git(ctx, projectPath, "check-ref-format", "--branch", base); err != nil {
+		return "", apperrors.Wrapf(err, apperrors.ErrGitOperation, "任务分支名无效: %s", base)
+	}
+
+	for i := 1; i <= maxBranchSuffix; i++ {
+		name := base
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		if wm.branchReserved(projectPath, name) {
+			continue
+		}
+		if _, err := wm.git(ctx, projectPath, "show-ref", "--verify", "--quiet", "refs/heads/"+name); err == nil {
+			continue
+		}
+		if mirrorPath == "" {
+			return name, nil
+		}
+		if _, err := wm.git(ctx, mirrorPath, "show-ref", "--verify", "--quiet", "refs/heads/"+name); err != nil {
+			return name, nil
+		}
+	}
+	return "", apperrors.Newf(apperrors.ErrGitOperation, "任务分支名 %s 及其序号均已存在", base)
+}
+
+// branchReserved 分支名是否已被同一项目中正在创建的worktree使用，调用方需持有 wm.mutex
+func (wm *worktreeManager) branchReserved(projectPath, name string) bool {
+	for _, worktree := range wm.worktrees {
+		if worktree.WorkBranch == name && projectKey(worktree.ProjectPath) == projectKey(projectPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderBranchTemplate 替换分支名模板中的变量，变量的值去除分支名中不允许的字符，{slug(name)} 转为小写并以 - 连接单词
+// 变量为空时去除其两侧多余的分隔符
+func renderBranchTemplate(template string, vars map[string]string) string {
+	name := config.BranchTemplatePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		match := config.BranchTemplatePlaceholder.FindStringSubmatch(placeholder)
+		if match[1] != "" {
+			return slugify(vars[match[1]])
+		}
+		return sanitizeRefComponent(vars[match[2]])
+	})
+
+	parts := strings.Split(name, "/")
+	kept := parts[:0]
+	for _, part := range parts {
+		if part = strings.Trim(part, "-_."); part != "" {
+			kept = append(kept, part)
+		}
+	}
+	name = strings.Join(kept, "/")
+	for strings.HasSuffix(name, ".lock") {
+		name = strings.TrimSuffix(name, ".lock")
+	}
+	return name
+}
+
+// slugify 将文本转为小写，字母和数字以外的字符合并为 -，最长 maxBranchSlugRunes 个字符
+func slugify(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	slug := []rune(strings.Join(words, "-"))
+	if len(slug) > maxBranchSlugRunes {
+		slug = slug[:maxBranchSlugRunes]
+	}
+	return strings.TrimSuffix(string(slug), "-")
+}
+
+// sanitizeRefComponent 将分支名中不允许的字符替换为 -，并去除 .. 和 /
+func sanitizeRefComponent(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f || strings.ContainsRune("~^:?*[\\/{}@", r) {
+			return '-'
+		}
+		return r
+	}, value)
+	for strings.Contains(value, "..") {
+		value = strings.ReplaceAll(value, "..", ".")
+	}
+	return value
+}
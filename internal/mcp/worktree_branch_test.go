@@ -0,0 +1,96 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"auto-claude-code/internal/config"
+)
+
+func TestRenderBranchTemplate(t *testing.T) {
+	vars := map[string]string{
+		"task_id":     "task_42",
+		"worktree_id": "wt_1",
+		"project":     "My App",
+		"description": "Fix the login page: handle expired tokens!",
+	}
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"worktree_{timestamp}", "worktree"},
+		{"acc/{task_id}/{slug(description)}", "acc/task_42/fix-the-login-page-handle-expired-tokens"},
+		{"acc/{project}/{worktree_id}", "acc/My-App/wt_1"},
+		{"acc/{slug(project)}-{task_id}", "acc/my-app-task_42"},
+		// 变量为空时不留下多余的分隔符
+		{"acc/{slug(missing)}/{task_id}", "acc/task_42"},
+		{"{task_id}.lock", "task_42"},
+	}
+	for _, tt := range tests {
+		if got := renderBranchTemplate(tt.template, vars); got != tt.want {
+			t.Errorf("renderBranchTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+
+	// 变量的值不能引入路径或非法字符
+	got := renderBranchTemplate("acc/{task_id}", map[string]string{"task_id": "../a b~c/../d"})
+	if got != "acc/a-b-c-.-d" {
+		t.Errorf("renderBranchTemplate() = %q", got)
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"  Add CSV export  ":       "add-csv-export",
+		"修复 登录页面":                  "修复-登录页面",
+		"!!!":                      "",
+		strings.Repeat("a", 50):    strings.Repeat("a", maxBranchSlugRunes),
+		strings.Repeat("abc ", 20): strings.TrimSuffix(strings.Repeat("abc-", 10), "-"),
+	}
+	for text, want := range tests {
+		if got := slugify(text); got != want {
+			t.Errorf("slugify(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestValidateBranchTemplate(t *testing.T) {
+	for _, template := range []string{"worktree_{timestamp}", "acc/{task_id}/{slug(description)}", "{date}-{slug(project)}"} {
+		if err := config.ValidateBranchTemplate(template); err != nil {
+			t.Errorf("ValidateBranchTemplate(%q) error = %v", template, err)
+		}
+	}
+	for _, template := range []string{"acc/{owner}", "acc/{slug(owner)}", "acc//{task_id}", "acc {task_id}", "acc/{task_id}/", "acc..{task_id}"} {
+		if err := config.ValidateBranchTemplate(template); err == nil {
+			t.Errorf("ValidateBranchTemplate(%q) 应返回错误", template)
+		}
+	}
+}
+
+func TestCreateWorktreeBranchTemplate(t *testing.T) {
+	ctx := context.Background()
+	wm, project, _ := newMergeTestRepo(t)
+	wm.config.BranchTemplate = "acc/{task_id}/{slug(description)}"
+
+	opts := CreateWorktreeOptions{TaskID: "t2", Description: "Add CSV export"}
+	first, err := wm.CreateWorktree(ctx, project, opts)
+	if err != nil {
+		t.Fatalf("CreateWorktree() error = %v", err)
+	}
+	if first.WorkBranch != "acc/t2/add-csv-export" {
+		t.Errorf("WorkBranch = %q", first.WorkBranch)
+	}
+	if branch := runGit(t, first.Path, "branch", "--show-current"); branch != first.WorkBranch {
+		t.Errorf("worktree 检出的分支 = %q", branch)
+	}
+
+	// 分支名已存在时追加序号
+	second, err := wm.CreateWorktree(ctx, project, opts)
+	if err != nil {
+		t.Fatalf("再次 CreateWorktree() error = %v", err)
+	}
+	if second.WorkBranch != "acc/t2/add-csv-export-2" {
+		t.Errorf("重名时 WorkBranch = %q", second.WorkBranch)
+	}
+}
@@ -0,0 +1,41 @@
+package mcp
+
+import (
+	"context"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// projectConfig 获取项目在 mcp.worktree_projects 中的配置，未配置时返回 nil
+func (wm *worktreeManager) projectConfig(projectPath string) *config.WorktreeProjectConfig {
+	return findProjectConfig(wm.config.WorktreeProjects, projectPath)
+}
+
+// findProjectConfig 在 projects 中查找与 projectPath 匹配的配置，未配置时返回 nil
+func findProjectConfig(projects []config.WorktreeProjectConfig, projectPath string) *config.WorktreeProjectConfig {
+	key := projectKey(projectPath)
+	for i := range projects {
+		if projectKey(projects[i].Path) == key {
+			return &projects[i]
+		}
+	}
+	return nil
+}
+
+// sparseCheckout 在以 --no-checkout 创建的worktree中设置 sparse-checkout 模式并检出匹配的文件
+// 模式写入worktree自己的配置（git 会为项目开启 extensions.worktreeConfig），不影响项目和其他worktree
+func (wm *worktreeManager) sparseCheckout(ctx context.Context, worktreePath string, project *config.WorktreeProjectConfig) error {
+	mode := "--no-cone"
+	if project.SparseCone {
+		mode = "--cone"
+	}
+	args := append([]string{"sparse-checkout", "set", mode, "--"}, project.SparseCheckout...)
+	if _, err := wm.git(ctx, worktreePath, args...); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrGitOperation, "设置 sparse-checkout 失败")
+	}
+	if _, err := wm.git(ctx, worktreePath, "reset", "--hard", "--quiet"); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrGitOperation, "检出 sparse-checkout 文件失败")
+	}
+	return nil
+}
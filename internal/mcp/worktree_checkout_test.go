@@ -0,0 +1,83 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"auto-claude-code/internal/config"
+)
+
+func TestProjectConfig(t *testing.T) {
+	wm := &worktreeManager{config: &config.MCPConfig{WorktreeProjects: []config.WorktreeProjectConfig{
+		{Path: `C:\Projects\App\`, SparseCheckout: []string{"/src/"}},
+	}}}
+	if project := wm.projectConfig("c:/projects/app"); project == nil || project.SparseCheckout[0] != "/src/" {
+		t.Errorf("projectConfig() = %+v", project)
+	}
+	if project := wm.projectConfig(`C:\Projects\Other`); project != nil {
+		t.Errorf("未配置的项目 projectConfig() = %+v", project)
+	}
+}
+
+func TestCreateWorktreeSparseCheckout(t *testing.T) {
+	ctx := context.Background()
+	wm, project, _ := newMergeTestRepo(t)
+	for _, dir := range []string{"docs", "src", "src/vendor"} {
+		if err := os.MkdirAll(filepath.Join(project, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(project, dir, "file.txt"), dir+"\n")
+	}
+	runGit(t, project, "add", "-A")
+	runGit(t, project, "commit", "-q", "-m", "dirs")
+
+	tests := []struct {
+		name    string
+		project config.WorktreeProjectConfig
+		present []string
+		absent  []string
+	}{
+		{"模式", config.WorktreeProjectConfig{SparseCheckout: []string{"/docs/", "/README.md"}},
+			[]string{"docs/file.txt", "README.md"}, []string{"src"}},
+		// cone 模式总是包含根目录的文件
+		{"cone", config.WorktreeProjectConfig{SparseCheckout: []string{"src"}, SparseCone: true},
+			[]string{"src/file.txt", "src/vendor/file.txt", "README.md"}, []string{"docs"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.project.Path = project
+			wm.config.WorktreeProjects = []config.WorktreeProjectConfig{tt.project}
+
+			worktree, err := wm.CreateWorktree(ctx, project, CreateWorktreeOptions{TaskID: tt.name})
+			if err != nil {
+				t.Fatalf("CreateWorktree() error = %v", err)
+			}
+			for _, path := range tt.present {
+				if _, err := os.Stat(filepath.Join(worktree.Path, path)); err != nil {
+					t.Errorf("未检出 %s: %v", path, err)
+				}
+			}
+			for _, path := range tt.absent {
+				if _, err := os.Stat(filepath.Join(worktree.Path, path)); !os.IsNotExist(err) {
+					t.Errorf("检出了 %s", path)
+				}
+			}
+			if len(worktree.SparseCheckout) == 0 {
+				t.Error("WorktreeInfo 未记录 sparse-checkout 模式")
+			}
+
+			// 未检出的文件不视为删除
+			status, err := wm.GetWorktreeStatus(ctx, worktree.ID)
+			if err != nil || !status.Clean || len(status.Files) != 0 {
+				t.Errorf("状态 = %+v, error = %v", status, err)
+			}
+		})
+	}
+
+	// 项目本身仍检出所有文件
+	if _, err := os.Stat(filepath.Join(project, "src", "vendor", "file.txt")); err != nil {
+		t.Errorf("sparse-checkout 影响了项目: %v", err)
+	}
+}
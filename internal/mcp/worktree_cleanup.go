@@ -0,0 +1,193 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/logger"
+)
+
+// defaultIdleTTL 未配置 mcp.worktree_cleanup.idle_ttl 时空闲worktree的保留时间
+const defaultIdleTTL = 2 * time.Hour
+
+// conflictedMarkerSuffix 合并冲突标记文件的后缀，与固定标记相同保存在worktree目录旁
+const conflictedMarkerSuffix = ".conflicted"
+
+// 触发清理的原因
+const (
+	cleanupTriggerScheduled = "scheduled" // 定期清理
+	cleanupTriggerLimit     = "limit"     // 达到 max_worktrees
+	cleanupTriggerDisk      = "disk"      // 达到磁盘配额
+)
+
+// 空闲清理保留worktree的原因
+const (
+	cleanupKeepPinned     = "pinned"
+	cleanupKeepConflicted = "conflicted"
+	cleanupKeepRecent     = "recent" // 未超过 idle_ttl 且不需要腾出空间
+)
+
+// cleanupReport 一次清理的结果
+type cleanupReport struct {
+	Trigger    string
+	Removed    []string
+	Failed     []string
+	FreedBytes int64          // 按最近一次磁盘统计估算的释放空间
+	Kept       map[string]int // 保留的worktree数量，按原因或状态统计
+}
+
+// keep 记录一个被保留的worktree
+func (r *cleanupReport) keep(reason string) {
+	r.Kept[reason]++
+}
+
+// runCleanup 删除超过 idle_ttl 的空闲worktrees，need 不为 nil 且返回 true 时按 eviction 策略
+// 继续从最久未使用的开始删除，直到 need 返回 false；固定的和按配置保留的冲突worktree不会被删除
+// 调用方需持有 wm.mutex
+func (wm *worktreeManager) runCleanup(ctx context.Context, trigger string, need func(*cleanupReport) bool) *cleanupReport {
+	report := &cleanupReport{Trigger: trigger, Kept: make(map[string]int)}
+	candidates := wm.cleanupCandidates(report)
+	sizes := wm.cachedWorktreeSizes()
+
+	remove := func(worktree *WorktreeInfo) {
+		if err := wm.removeWorktree(ctx, worktree); err != nil {
+			logger.FromContext(ctx, wm.logger).Warn("删除空闲worktree失败",
+				zap.String("worktreeId", worktree.ID),
+				zap.Error(err))
+			report.Failed = append(report.Failed, worktree.ID)
+			return
+		}
+		report.Removed = append(report.Removed, worktree.ID)
+		report.FreedBytes += sizes[worktree.ID]
+	}
+
+	ttl := wm.idleTTL()
+	cutoff := time.Now().Add(-ttl)
+	var recent []*WorktreeInfo
+	for _, worktree := range candidates {
+		if ttl > 0 && !lastUsedTime(worktree).After(cutoff) {
+			remove(worktree)
+		} else {
+			recent = append(recent, worktree)
+		}
+	}
+
+	if need != nil && wm.config.WorktreeCleanup.Eviction != "none" {
+		for len(recent) > 0 && need(report) {
+			remove(recent[0])
+			recent = recent[1:]
+		}
+	}
+	for range recent {
+		report.keep(cleanupKeepRecent)
+	}
+
+	wm.logCleanupReport(ctx, report)
+	return report
+}
+
+// cleanupCandidates 返回可以删除的空闲worktrees，按最后使用时间从早到晚排序，其余的计入 report.Kept
+func (wm *worktreeManager) cleanupCandidates(report *cleanupReport) []*WorktreeInfo {
+	var candidates []*WorktreeInfo
+	for _, worktree := range wm.worktrees {
+		switch {
+		case worktree.Status != WorktreeStateIdle:
+			report.keep(worktree.Status)
+		case worktree.Pinned:
+			report.keep(cleanupKeepPinned)
+		case worktree.Conflicted && wm.config.WorktreeCleanup.KeepConflicted:
+			report.keep(cleanupKeepConflicted)
+		default:
+			candidates = append(candidates, worktree)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return lastUsedTime(candidates[i]).Before(lastUsedTime(candidates[j]))
+	})
+	return candidates
+}
+
+// logCleanupReport 记录清理报告，没有删除任何worktree时只记录调试日志
+func (wm *worktreeManager) logCleanupReport(ctx context.Context, report *cleanupReport) {
+	fields := []zap.Field{
+		zap.String("trigger", report.Trigger),
+		zap.Strings("removed", report.Removed),
+		zap.Strings("failed", report.Failed),
+		zap.Int64("freedBytes", report.FreedBytes),
+		zap.Any("kept", report.Kept),
+		zap.Int("remaining", len(wm.worktrees)),
+	}
+	log := logger.FromContext(ctx, wm.logger)
+	if len(report.Removed) == 0 && len(report.Failed) == 0 {
+		log.Debug("worktree清理报告", fields...)
+		return
+	}
+	log.Info("worktree清理报告", fields...)
+}
+
+// idleTTL 获取空闲worktree的保留时间，0 表示不按空闲时间清理
+func (wm *worktreeManager) idleTTL() time.Duration {
+	ttl, err := time.ParseDuration(wm.config.WorktreeCleanup.IdleTTL)
+	if err != nil || ttl < 0 {
+		return defaultIdleTTL
+	}
+	return ttl
+}
+
+// cachedWorktreeSizes 获取最近一次磁盘统计中各worktree的大小，没有统计时返回 nil
+func (wm *worktreeManager) cachedWorktreeSizes() map[string]int64 {
+	wm.diskMutex.Lock()
+	defer wm.diskMutex.Unlock()
+	if wm.disk == nil {
+		return nil
+	}
+	return wm.disk.Worktrees
+}
+
+// lastUsedTime 解析worktree的最后使用时间，无法解析时视为最早
+func lastUsedTime(worktree *WorktreeInfo) time.Time {
+	lastUsed, err := time.Parse(time.RFC3339, worktree.LastUsed)
+	if err != nil {
+		return time.Time{}
+	}
+	return lastUsed
+}
+
+// conflictedMarker 获取worktree的合并冲突标记文件路径
+func (wm *worktreeManager) conflictedMarker(worktreeID string) string {
+	return filepath.Join(wm.baseDir, worktreeID+conflictedMarkerSuffix)
+}
+
+// recordMergeStatus 按合并结果更新worktree的冲突状态，冲突状态以标记文件保存，重启后恢复
+func (wm *worktreeManager) recordMergeStatus(worktreeID string, result *MergeResult) {
+	if result == nil || result.Status == "" {
+		return
+	}
+	conflicted := result.Status == MergeStatusConflict
+
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+	worktree, exists := wm.worktrees[worktreeID]
+	if !exists || worktree.Conflicted == conflicted {
+		return
+	}
+	worktree.Conflicted = conflicted
+
+	marker := wm.conflictedMarker(worktreeID)
+	var err error
+	if conflicted {
+		err = os.WriteFile(marker, nil, 0644)
+	} else if err = os.Remove(marker); os.IsNotExist(err) {
+		err = nil
+	}
+	if err != nil {
+		wm.logger.Warn("更新worktree冲突标记失败",
+			zap.String("worktreeId", worktreeID),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,142 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/logger"
+)
+
+// newCleanupTestManager 创建带有各种状态worktree的管理器，idle_ttl 为 1 小时
+func newCleanupTestManager(t *testing.T, cleanup config.WorktreeCleanupConfig) *worktreeManager {
+	t.Helper()
+	cleanup.IdleTTL = "1h"
+	cfg := &config.MCPConfig{WorktreeBaseDir: t.TempDir(), MaxWorktrees: 10, WorktreeCleanup: cleanup}
+	wm := NewWorktreeManager(cfg, logger.FromZap(zap.NewNop()), nil).(*worktreeManager)
+
+	ago := func(d time.Duration) string { return time.Now().Add(-d).Format(time.RFC3339) }
+	for _, worktree := range []*WorktreeInfo{
+		{ID: "wt_expired", Status: WorktreeStateIdle, LastUsed: ago(3 * time.Hour)},
+		{ID: "wt_older", Status: WorktreeStateIdle, LastUsed: ago(30 * time.Minute)},
+		{ID: "wt_newer", Status: WorktreeStateIdle, LastUsed: ago(time.Minute)},
+		{ID: "wt_pinned", Status: WorktreeStateIdle, LastUsed: ago(3 * time.Hour), Pinned: true},
+		{ID: "wt_conflicted", Status: WorktreeStateIdle, LastUsed: ago(3 * time.Hour), Conflicted: true},
+		{ID: "wt_running", Status: WorktreeStateInUse, LastUsed: ago(3 * time.Hour)},
+	} {
+		if err := os.MkdirAll(filepath.Join(cfg.WorktreeBaseDir, worktree.ID), 0755); err != nil {
+			t.Fatal(err)
+		}
+		wm.worktrees[worktree.ID] = worktree
+	}
+	return wm
+}
+
+func remainingWorktrees(wm *worktreeManager) []string {
+	var ids []string
+	for id := range wm.worktrees {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestRunCleanup(t *testing.T) {
+	ctx := context.Background()
+
+	// 定期清理只删除超过 idle_ttl 的空闲worktree
+	wm := newCleanupTestManager(t, config.WorktreeCleanupConfig{Eviction: "lru", KeepConflicted: true})
+	report := wm.runCleanup(ctx, cleanupTriggerScheduled, nil)
+	if !reflect.DeepEqual(report.Removed, []string{"wt_expired"}) {
+		t.Errorf("Removed = %v", report.Removed)
+	}
+	wantKept := map[string]int{cleanupKeepPinned: 1, cleanupKeepConflicted: 1, WorktreeStateInUse: 1, cleanupKeepRecent: 2}
+	if !reflect.DeepEqual(report.Kept, wantKept) {
+		t.Errorf("Kept = %v, want %v", report.Kept, wantKept)
+	}
+	if _, err := os.Stat(filepath.Join(wm.baseDir, "wt_expired")); !os.IsNotExist(err) {
+		t.Errorf("过期的worktree目录仍存在: %v", err)
+	}
+
+	// 需要腾出空间时从最久未使用的开始删除
+	report = wm.runCleanup(ctx, cleanupTriggerLimit, func(*cleanupReport) bool { return len(wm.worktrees) >= 5 })
+	if !reflect.DeepEqual(report.Removed, []string{"wt_older"}) {
+		t.Errorf("LRU Removed = %v", report.Removed)
+	}
+	if got, want := remainingWorktrees(wm), []string{"wt_conflicted", "wt_newer", "wt_pinned", "wt_running"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("剩余 = %v, want %v", got, want)
+	}
+}
+
+func TestRunCleanupPolicies(t *testing.T) {
+	ctx := context.Background()
+
+	// eviction 为 none 时只删除过期的
+	wm := newCleanupTestManager(t, config.WorktreeCleanupConfig{Eviction: "none", KeepConflicted: true})
+	report := wm.runCleanup(ctx, cleanupTriggerLimit, func(*cleanupReport) bool { return true })
+	if !reflect.DeepEqual(report.Removed, []string{"wt_expired"}) {
+		t.Errorf("eviction none Removed = %v", report.Removed)
+	}
+
+	// 不保留冲突的worktree时按空闲时间清理
+	wm = newCleanupTestManager(t, config.WorktreeCleanupConfig{Eviction: "lru"})
+	report = wm.runCleanup(ctx, cleanupTriggerScheduled, nil)
+	sort.Strings(report.Removed)
+	if !reflect.DeepEqual(report.Removed, []string{"wt_conflicted", "wt_expired"}) {
+		t.Errorf("keep_conflicted false Removed = %v", report.Removed)
+	}
+
+	// idle_ttl 为 0 时不按空闲时间清理
+	wm = newCleanupTestManager(t, config.WorktreeCleanupConfig{Eviction: "lru", KeepConflicted: true})
+	wm.config.WorktreeCleanup.IdleTTL = "0"
+	if report = wm.runCleanup(ctx, cleanupTriggerScheduled, nil); len(report.Removed) != 0 {
+		t.Errorf("idle_ttl 0 Removed = %v", report.Removed)
+	}
+}
+
+func TestMergeConflictMarksWorktree(t *testing.T) {
+	ctx := context.Background()
+	wm, project, worktree := newMergeTestRepo(t)
+	writeFile(t, filepath.Join(project, "README.md"), "project\n")
+	runGit(t, project, "commit", "-q", "-am", "edit")
+	writeFile(t, filepath.Join(worktree.Path, "README.md"), "task\n")
+
+	if result, err := wm.MergeWorktree(ctx, worktree.ID, MergeOptions{DryRun: true}); err != nil || result.Status != MergeStatusConflict {
+		t.Fatalf("MergeWorktree() = %+v, error = %v", result, err)
+	}
+	if !wm.worktrees[worktree.ID].Conflicted {
+		t.Fatal("合并冲突后未标记worktree")
+	}
+
+	// 重启后从标记文件恢复
+	wm.worktrees = make(map[string]*WorktreeInfo)
+	if err := wm.scanExistingWorktrees(); err != nil {
+		t.Fatal(err)
+	}
+	restored := wm.worktrees[worktree.ID]
+	if restored == nil || !restored.Conflicted {
+		t.Fatalf("扫描后的worktree = %+v", restored)
+	}
+
+	// 解决冲突后清除标记
+	restored.ProjectPath = project
+	restored.WorkBranch = worktree.WorkBranch
+	restored.Branch = worktree.Branch
+	writeFile(t, filepath.Join(worktree.Path, "README.md"), "project\n")
+	if result, err := wm.MergeWorktree(ctx, worktree.ID, MergeOptions{}); err != nil || result.Status == MergeStatusConflict {
+		t.Fatalf("解决冲突后 MergeWorktree() = %+v, error = %v", result, err)
+	}
+	if wm.worktrees[worktree.ID].Conflicted {
+		t.Error("合并成功后仍标记为冲突")
+	}
+	if _, err := os.Stat(wm.conflictedMarker(worktree.ID)); !os.IsNotExist(err) {
+		t.Errorf("合并成功后冲突标记仍存在: %v", err)
+	}
+}
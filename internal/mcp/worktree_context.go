@@ -0,0 +1,177 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// defaultContextTarget 未配置 target 时上下文文件在worktree中的路径
+const defaultContextTarget = "CLAUDE.md"
+
+// contextFiles 获取项目要注入的上下文文件，mcp.worktree_context.files 在前，项目的 context_files 在后
+func (wm *worktreeManager) contextFiles(projectPath string) []config.WorktreeContextFile {
+	files := append([]config.WorktreeContextFile{}, wm.config.WorktreeContext.Files...)
+	if project := wm.projectConfig(projectPath); project != nil {
+		files = append(files, project.ContextFiles...)
+	}
+	return files
+}
+
+// contextTarget 上下文文件在worktree中以 / 分隔的相对路径
+func contextTarget(file config.WorktreeContextFile) string {
+	if file.Target == "" {
+		return defaultContextTarget
+	}
+	return path.Clean(filepath.ToSlash(file.Target))
+}
+
+// contextVars 上下文模板的变量，opts.Vars 中任务提供的变量优先
+func contextVars(worktree *WorktreeInfo, opts CreateWorktreeOptions) map[string]string {
+	vars := map[string]string{
+		"taskId":      opts.TaskID,
+		"command":     opts.Description,
+		"worktreeId":  worktree.ID,
+		"projectPath": worktree.ProjectPath,
+		"project":     filepath.Base(filepath.Clean(worktree.ProjectPath)),
+		"branch":      worktree.Branch,
+		"workBranch":  worktree.WorkBranch,
+		"date":        time.Now().Format("2006-01-02"),
+	}
+	for name, value := range opts.Vars {
+		vars[name] = value
+	}
+	return vars
+}
+
+// renderContextTemplate 替换模板中的 {{变量}}，未定义的变量替换为空
+func renderContextTemplate(template string, vars map[string]string) string {
+	return templatePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		return vars[templatePlaceholder.FindStringSubmatch(placeholder)[1]]
+	})
+}
+
+// injectContextFiles 按模板将上下文文件写入worktree，写入的文件记录在 worktree.ContextFiles
+// Git worktree中写入的文件不计入状态、diff 和提交：已跟踪的文件标记为 skip-worktree，未跟踪的文件加入仓库的 info/exclude
+func (wm *worktreeManager) injectContextFiles(ctx context.Context, worktree *WorktreeInfo, isGit bool, opts CreateWorktreeOptions) error {
+	files := wm.contextFiles(worktree.ProjectPath)
+	if len(files) == 0 {
+		return nil
+	}
+	if opts.Progress != nil {
+		opts.Progress(1, "正在注入上下文文件")
+	}
+	vars := contextVars(worktree, opts)
+
+	var injected []string
+	for _, file := range files {
+		target := contextTarget(file)
+		template, err := os.ReadFile(file.Template)
+		if err != nil {
+			return apperrors.Wrapf(err, apperrors.ErrWorktreeFailed, "读取上下文模板失败: %s", file.Template)
+		}
+		written, err := writeContextFile(filepath.Join(worktree.Path, filepath.FromSlash(target)), file.Mode, renderContextTemplate(string(template), vars))
+		if err != nil {
+			return apperrors.Wrapf(err, apperrors.ErrWorktreeFailed, "写入上下文文件失败: %s", target)
+		}
+		if !written {
+			continue
+		}
+		if isGit {
+			if err := wm.hideContextFile(ctx, worktree.Path, target); err != nil {
+				return err
+			}
+		}
+		injected = append(injected, target)
+	}
+
+	wm.mutex.Lock()
+	worktree.ContextFiles = injected
+	wm.mutex.Unlock()
+	return nil
+}
+
+// writeContextFile 按 mode 写入上下文文件，create 模式下文件已存在时不写入并返回 false
+func writeContextFile(file, mode, content string) (bool, error) {
+	existing, err := os.ReadFile(file)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	switch {
+	case exists && mode == config.ContextModeCreate:
+		return false, nil
+	case exists && mode != config.ContextModeReplace && len(existing) > 0:
+		// 追加时与原内容之间空一行
+		separator := "\n"
+		if !strings.HasSuffix(string(existing), "\n") {
+			separator = "\n\n"
+		}
+		content = string(existing) + separator + content
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(file, []byte(content), 0644)
+}
+
+// hideContextFile 使worktree中注入的文件不出现在 git status 中，不会被任务提交或合并回项目
+func (wm *worktreeManager) hideContextFile(ctx context.Context, worktreePath, target string) error {
+	if _, err := wm.git(ctx, worktreePath, "ls-files", "--error-unmatch", "--", target); err == nil {
+		if _, err := wm.git(ctx, worktreePath, "update-index", "--skip-worktree", "--", target); err != nil {
+			return apperrors.Wrapf(err, apperrors.ErrWorktreeFailed, "隐藏上下文文件失败: %s", target)
+		}
+		return nil
+	}
+	if _, err := wm.git(ctx, worktreePath, "check-ignore", "-q", "--", target); err == nil {
+		return nil
+	}
+
+	exclude, err := wm.git(ctx, worktreePath, "rev-parse", "--git-path", "info/exclude")
+	if err != nil {
+		return apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "定位 info/exclude 失败")
+	}
+	if !filepath.IsAbs(exclude) {
+		exclude = filepath.Join(worktreePath, exclude)
+	}
+	if err := appendExcludePattern(exclude, "/"+target); err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrWorktreeFailed, "隐藏上下文文件失败: %s", target)
+	}
+	return nil
+}
+
+// appendExcludePattern 将模式追加到 exclude 文件，已存在时不重复添加
+func appendExcludePattern(exclude, pattern string) error {
+	content, err := os.ReadFile(exclude)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return nil
+		}
+	}
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		pattern = "\n" + pattern
+	}
+	if err := os.MkdirAll(filepath.Dir(exclude), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(exclude, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(pattern + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,111 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"auto-claude-code/internal/config"
+)
+
+func TestWriteContextFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    string
+		mode        string
+		want        string
+		wantWritten bool
+	}{
+		{"创建", "", config.ContextModeAppend, "rules\n", true},
+		{"追加", "# Project\n", "", "# Project\n\nrules\n", true},
+		{"追加到无换行的文件", "# Project", config.ContextModeAppend, "# Project\n\nrules\n", true},
+		{"覆盖", "# Project\n", config.ContextModeReplace, "rules\n", true},
+		{"已存在时不创建", "# Project\n", config.ContextModeCreate, "# Project\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "docs", "CLAUDE.md")
+			if tt.existing != "" {
+				if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+					t.Fatal(err)
+				}
+				writeFile(t, file, tt.existing)
+			}
+			written, err := writeContextFile(file, tt.mode, "rules\n")
+			if err != nil {
+				t.Fatalf("writeContextFile() error = %v", err)
+			}
+			if written != tt.wantWritten {
+				t.Errorf("writeContextFile() = %v, want %v", written, tt.wantWritten)
+			}
+			if got, _ := os.ReadFile(file); string(got) != tt.want {
+				t.Errorf("文件内容 = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderContextTemplate(t *testing.T) {
+	got := renderContextTemplate("Task {{ taskId }} on {{branch}} for {{context.ticket}}{{missing}}.", map[string]string{
+		"taskId":         "t1",
+		"branch":         "main",
+		"context.ticket": "ABC-1",
+	})
+	if want := "Task t1 on main for ABC-1."; got != want {
+		t.Errorf("renderContextTemplate() = %q, want %q", got, want)
+	}
+}
+
+func TestCreateWorktreeContextFiles(t *testing.T) {
+	ctx := context.Background()
+	wm, project, _ := newMergeTestRepo(t)
+	writeFile(t, filepath.Join(project, "CLAUDE.md"), "# Project\n")
+	runGit(t, project, "add", "-A")
+	runGit(t, project, "commit", "-q", "-m", "claude")
+
+	templates := t.TempDir()
+	writeFile(t, filepath.Join(templates, "guardrails.md"), "Task {{taskId}} works on {{workBranch}} from {{branch}}.\n")
+	writeFile(t, filepath.Join(templates, "ticket.md"), "Ticket: {{context.ticket}}\n")
+	wm.config.WorktreeContext.Files = []config.WorktreeContextFile{{Template: filepath.Join(templates, "guardrails.md")}}
+	wm.config.WorktreeProjects = []config.WorktreeProjectConfig{{
+		Path:         project,
+		ContextFiles: []config.WorktreeContextFile{{Template: filepath.Join(templates, "ticket.md"), Target: ".claude/ticket.md"}},
+	}}
+
+	plan, err := wm.PlanWorktree(ctx, project)
+	if err != nil {
+		t.Fatalf("PlanWorktree() error = %v", err)
+	}
+	if want := []string{"CLAUDE.md", ".claude/ticket.md"}; !reflect.DeepEqual(plan.ContextFiles, want) {
+		t.Errorf("计划中的上下文文件 = %v, want %v", plan.ContextFiles, want)
+	}
+
+	worktree, err := wm.CreateWorktree(ctx, project, CreateWorktreeOptions{TaskID: "t2", Vars: map[string]string{"context.ticket": "ABC-1"}})
+	if err != nil {
+		t.Fatalf("CreateWorktree() error = %v", err)
+	}
+	if want := []string{"CLAUDE.md", ".claude/ticket.md"}; !reflect.DeepEqual(worktree.ContextFiles, want) {
+		t.Errorf("ContextFiles = %v, want %v", worktree.ContextFiles, want)
+	}
+	got, _ := os.ReadFile(filepath.Join(worktree.Path, "CLAUDE.md"))
+	if want := "# Project\n\nTask t2 works on " + worktree.WorkBranch + " from main.\n"; string(got) != want {
+		t.Errorf("CLAUDE.md = %q, want %q", got, want)
+	}
+	got, _ = os.ReadFile(filepath.Join(worktree.Path, ".claude", "ticket.md"))
+	if string(got) != "Ticket: ABC-1\n" {
+		t.Errorf("ticket.md = %q", got)
+	}
+
+	// 注入的文件不计入修改，也不会被提交
+	if status := runGit(t, worktree.Path, "status", "--porcelain"); status != "" {
+		t.Errorf("注入后worktree有修改:\n%s", status)
+	}
+	if files, err := wm.GetChangedFiles(ctx, worktree.ID); err != nil || len(files) != 0 {
+		t.Errorf("GetChangedFiles() = %v, %v", files, err)
+	}
+	if status := runGit(t, project, "status", "--porcelain"); status != "" {
+		t.Errorf("项目有修改:\n%s", status)
+	}
+}
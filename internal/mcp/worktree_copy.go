@@ -0,0 +1,263 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+const (
+	// defaultCopyWorkers 未配置 mcp.worktree_copy.workers 时并行复制的文件数
+	defaultCopyWorkers = 4
+	// copyProgressInterval 复制进度的报告间隔
+	copyProgressInterval = 500 * time.Millisecond
+)
+
+// ignoreRule 一条 .gitignore 风格的排除模式
+type ignoreRule struct {
+	segments []string // 按 / 拆分的 glob，** 匹配任意层目录
+	negate   bool     // ! 开头，重新包含之前排除的路径
+	dirOnly  bool     // / 结尾，只匹配目录
+}
+
+// parseIgnorePatterns 解析 .gitignore 风格的模式：不含 / 的模式匹配任意层的文件名，
+// 以 / 开头或中间含 / 的模式相对项目根目录，忽略空行和 # 开头的注释
+func parseIgnorePatterns(patterns []string) []ignoreRule {
+	rules := make([]ignoreRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(filepath.ToSlash(pattern))
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		var rule ignoreRule
+		if strings.HasPrefix(pattern, "!") {
+			rule.negate = true
+			pattern = pattern[1:]
+		}
+		if strings.HasSuffix(pattern, "/") {
+			rule.dirOnly = true
+			pattern = strings.TrimRight(pattern, "/")
+		}
+		if !strings.Contains(pattern, "/") {
+			pattern = "**/" + pattern
+		}
+		rule.segments = strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// ignored 判断相对项目根目录的路径是否被排除，以最后一条匹配的模式为准
+func ignored(rules []ignoreRule, rel string, isDir bool) bool {
+	excluded := false
+	segments := strings.Split(rel, "/")
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if matchGlobSegments(rule.segments, segments) {
+			excluded = !rule.negate
+		}
+	}
+	return excluded
+}
+
+// copyProgress 复制进度
+type copyProgress struct {
+	Files      int64
+	TotalFiles int64
+	Bytes      int64
+	TotalBytes int64
+}
+
+// copyJob 待复制的文件
+type copyJob struct {
+	src, dst string
+	mode     os.FileMode
+	size     int64
+}
+
+// copyDirectory 将非Git项目复制到worktree，跳过 .git 目录和 mcp.worktree_copy.exclude 及项目 copy_exclude 排除的路径
+// 先遍历得到文件总数和大小，再以 workers 个协程并行复制，progress 不为 nil 时定期报告进度
+func (wm *worktreeManager) copyDirectory(ctx context.Context, src, dst string, progress func(copyProgress)) error {
+	patterns := append([]string{".git/"}, wm.config.WorktreeCopy.Exclude...)
+	if project := wm.projectConfig(src); project != nil {
+		patterns = append(patterns, project.CopyExclude...)
+	}
+	rules := parseIgnorePatterns(patterns)
+
+	var jobs []copyJob
+	var total copyProgress
+	skipped := 0
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		dstPath := filepath.Join(dst, relPath)
+		if relPath == "." {
+			return os.MkdirAll(dstPath, info.Mode().Perm())
+		}
+
+		if ignored(rules, filepath.ToSlash(relPath), info.IsDir()) {
+			skipped++
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return os.MkdirAll(dstPath, info.Mode().Perm())
+		}
+
+		jobs = append(jobs, copyJob{src: path, dst: dstPath, mode: info.Mode().Perm(), size: info.Size()})
+		total.TotalFiles++
+		total.TotalBytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	workers := wm.config.WorktreeCopy.Workers
+	if workers <= 0 {
+		workers = defaultCopyWorkers
+	}
+	var files, copied atomic.Int64
+	snapshot := func() copyProgress {
+		return copyProgress{Files: files.Load(), TotalFiles: total.TotalFiles, Bytes: copied.Load(), TotalBytes: total.TotalBytes}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	queue := make(chan copyJob)
+	var wg sync.WaitGroup
+	var firstErr error
+	var errOnce sync.Once
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				if err := copyFileMode(job.src, job.dst, job.mode); err != nil {
+					errOnce.Do(func() {
+						firstErr = apperrors.Wrapf(err, apperrors.ErrWorktreeFailed, "复制文件失败: %s", job.src)
+						cancel()
+					})
+					continue
+				}
+				files.Add(1)
+				copied.Add(job.size)
+			}
+		}()
+	}
+
+	// 定期报告进度，直到复制结束
+	done := make(chan struct{})
+	if progress != nil {
+		go func() {
+			ticker := time.NewTicker(copyProgressInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					progress(snapshot())
+				}
+			}
+		}()
+	}
+
+send:
+	for _, job := range jobs {
+		select {
+		case queue <- job:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(queue)
+	wg.Wait()
+	close(done)
+
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "复制项目目录被取消")
+	}
+	if progress != nil {
+		progress(snapshot())
+	}
+
+	logger.FromContext(ctx, wm.logger).Debug("项目目录复制完成",
+		zap.String("src", src),
+		zap.Int64("files", total.TotalFiles),
+		zap.Int64("bytes", total.TotalBytes),
+		zap.Int("skipped", skipped),
+		zap.Int("workers", workers))
+	return nil
+}
+
+// copyFileMode 复制文件并保留权限位
+func copyFileMode(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := out.ReadFrom(in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// String 格式化复制进度，如 "120/300 个文件，1.2 MB/3.5 MB"
+func (p copyProgress) String() string {
+	return fmt.Sprintf("%d/%d 个文件，%s/%s", p.Files, p.TotalFiles, formatByteSize(p.Bytes), formatByteSize(p.TotalBytes))
+}
+
+// Fraction 已复制的字节比例，没有文件时为 1
+func (p copyProgress) Fraction() float64 {
+	if p.TotalBytes == 0 {
+		if p.TotalFiles == 0 {
+			return 1
+		}
+		return float64(p.Files) / float64(p.TotalFiles)
+	}
+	return float64(p.Bytes) / float64(p.TotalBytes)
+}
+
+// formatByteSize 以 1024 进制格式化字节数，如 1.5 GB
+func formatByteSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 4; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTP"[exp])
+}
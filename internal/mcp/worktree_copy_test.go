@@ -0,0 +1,114 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/logger"
+)
+
+func TestIgnored(t *testing.T) {
+	rules := parseIgnorePatterns([]string{
+		"# 注释",
+		"node_modules/",
+		"*.log",
+		"/build",
+		"docs/**/*.pdf",
+		"!keep.log",
+	})
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"node_modules", true, true},
+		{"web/node_modules", true, true},
+		{"node_modules", false, false}, // / 结尾只匹配目录
+		{"app.log", false, true},
+		{"logs/keep.log", false, false},
+		{"build", true, true},
+		{"src/build", true, false}, // / 开头只匹配根目录
+		{"docs/a/b/manual.pdf", false, true},
+		{"docs/manual.pdf", false, true},
+		{"src/main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := ignored(rules, tt.path, tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestCopyDirectory(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{
+		"main.go":                   "package main\n",
+		".github/workflows/ci.yml":  "on: push\n",
+		".git/HEAD":                 "ref: refs/heads/main\n",
+		"node_modules/lib/index.js": "module.exports = 1\n",
+		"dist/app.js":               "built\n",
+		"dist/keep.txt":             "keep\n",
+		"scripts/run.sh":            "#!/bin/sh\n",
+	}
+	for path, content := range files {
+		path = filepath.Join(src, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, path, content)
+	}
+	if err := os.Chmod(filepath.Join(src, "scripts", "run.sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.MCPConfig{
+		WorktreeCopy:     config.WorktreeCopyConfig{Exclude: []string{"node_modules/"}, Workers: 2},
+		WorktreeProjects: []config.WorktreeProjectConfig{{Path: src, CopyExclude: []string{"dist/*", "!dist/keep.txt"}}},
+	}
+	wm := NewWorktreeManager(cfg, logger.FromZap(zap.NewNop()), nil).(*worktreeManager)
+
+	dst := filepath.Join(t.TempDir(), "wt_1")
+	var last copyProgress
+	if err := wm.copyDirectory(context.Background(), src, dst, func(p copyProgress) { last = p }); err != nil {
+		t.Fatalf("copyDirectory() error = %v", err)
+	}
+
+	for _, path := range []string{"main.go", ".github/workflows/ci.yml", "dist/keep.txt", "scripts/run.sh"} {
+		if _, err := os.Stat(filepath.Join(dst, path)); err != nil {
+			t.Errorf("未复制 %s: %v", path, err)
+		}
+	}
+	for _, path := range []string{".git", "node_modules", "dist/app.js"} {
+		if _, err := os.Stat(filepath.Join(dst, path)); !os.IsNotExist(err) {
+			t.Errorf("复制了排除的 %s", path)
+		}
+	}
+	if last.Files != 4 || last.TotalFiles != 4 || last.Bytes != last.TotalBytes || last.Fraction() != 1 {
+		t.Errorf("最终进度 = %+v", last)
+	}
+	if runtime.GOOS != "windows" {
+		if info, err := os.Stat(filepath.Join(dst, "scripts", "run.sh")); err != nil || info.Mode().Perm()&0100 == 0 {
+			t.Errorf("未保留可执行权限: %v", info.Mode())
+		}
+	}
+
+	// 已取消时不复制
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := wm.copyDirectory(ctx, src, filepath.Join(t.TempDir(), "wt_2"), nil); err == nil {
+		t.Error("取消后 copyDirectory() 应返回错误")
+	}
+}
+
+func TestCopyProgressString(t *testing.T) {
+	p := copyProgress{Files: 3, TotalFiles: 10, Bytes: 1536, TotalBytes: 3 * 1024 * 1024}
+	if got := p.String(); got != "3/10 个文件，1.5 KB/3.0 MB" {
+		t.Errorf("String() = %q", got)
+	}
+}
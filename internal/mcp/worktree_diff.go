@@ -0,0 +1,24 @@
+package mcp
+
+import (
+	"net/http"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// handleWorktreeDiff 返回worktree相对基准提交的统一diff，与 MCP 资源 worktree://{id}/diff 内容相同
+// 响应附带 ETag，worktree 没有新的修改时条件请求返回 304
+func (s *mcpServer) handleWorktreeDiff(w http.ResponseWriter, r *http.Request, worktreeID string) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持GET方法"))
+		return
+	}
+
+	diff, err := s.worktreeManager.GetWorktreeDiff(r.Context(), worktreeID)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	writeWithETag(w, r, "text/x-diff; charset=utf-8", []byte(diff))
+}
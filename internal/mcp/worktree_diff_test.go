@@ -0,0 +1,77 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetWorktreeDiff(t *testing.T) {
+	ctx := context.Background()
+	wm, _, worktree := newMergeTestRepo(t)
+	writeFile(t, filepath.Join(worktree.Path, "committed.txt"), "committed\n")
+	runGit(t, worktree.Path, "add", "-A")
+	runGit(t, worktree.Path, "commit", "-q", "-m", "commit")
+	writeFile(t, filepath.Join(worktree.Path, "README.md"), "changed\n")
+	writeFile(t, filepath.Join(worktree.Path, "new.txt"), "new\n")
+
+	diff, err := wm.GetWorktreeDiff(ctx, worktree.ID)
+	if err != nil {
+		t.Fatalf("GetWorktreeDiff() error = %v", err)
+	}
+	for _, want := range []string{"+++ b/committed.txt", "+changed", "+++ b/new.txt", "+new"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff 中没有 %q:\n%s", want, diff)
+		}
+	}
+	// 未跟踪的新文件不会被加入worktree的暂存区
+	if staged := runGit(t, worktree.Path, "diff", "--cached", "--name-only"); staged != "" {
+		t.Errorf("生成 diff 后暂存区 = %q", staged)
+	}
+	if status := runGit(t, worktree.Path, "status", "--porcelain"); !strings.Contains(status, "?? new.txt") {
+		t.Errorf("生成 diff 后 worktree 状态 = %q", status)
+	}
+
+	if _, err := wm.GetWorktreeDiff(ctx, "missing"); err == nil {
+		t.Error("worktree 不存在时 GetWorktreeDiff() 未返回错误")
+	}
+}
+
+func TestHandleWorktreeDiff(t *testing.T) {
+	wm, _, worktree := newMergeTestRepo(t)
+	writeFile(t, filepath.Join(worktree.Path, "new.txt"), "new\n")
+	server := &mcpServer{worktreeManager: wm}
+
+	rec := httptest.NewRecorder()
+	server.handleWorktreeDetail(rec, httptest.NewRequest(http.MethodGet, "/worktrees/"+worktree.ID+"/diff", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/x-diff") || !strings.Contains(rec.Body.String(), "+++ b/new.txt") {
+		t.Fatalf("响应 = %d %s\n%s", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+
+	// 没有新的修改时条件请求返回 304
+	req := httptest.NewRequest(http.MethodGet, "/worktrees/"+worktree.ID+"/diff", nil)
+	req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+	rec = httptest.NewRecorder()
+	server.handleWorktreeDetail(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("条件请求状态码 = %d", rec.Code)
+	}
+
+	for _, tt := range []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/worktrees/missing/diff", http.StatusNotFound},
+		{http.MethodPost, "/worktrees/" + worktree.ID + "/diff", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/worktrees/" + worktree.ID + "/unknown", http.StatusNotFound},
+	} {
+		rec := httptest.NewRecorder()
+		server.handleWorktreeDetail(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s 状态码 = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+}
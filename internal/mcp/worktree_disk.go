@@ -0,0 +1,153 @@
+package mcp
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+const (
+	// defaultDiskScanInterval 未配置 mcp.worktree_disk.scan_interval 时磁盘用量的缓存时间
+	defaultDiskScanInterval = time.Minute
+	// defaultDiskWaitTimeout 未配置 mcp.worktree_disk.wait_timeout 时等待磁盘空间的最长时间
+	defaultDiskWaitTimeout = 10 * time.Minute
+	// diskWaitInterval 等待磁盘空间时重新统计用量的间隔
+	diskWaitInterval = 5 * time.Second
+)
+
+// WorktreeDiskUsage worktree 基础目录的磁盘用量
+type WorktreeDiskUsage struct {
+	TotalBytes int64            `json:"totalBytes"`
+	QuotaBytes int64            `json:"quotaBytes,omitempty"` // 0 表示不限制
+	Worktrees  map[string]int64 `json:"worktrees"`            // 各worktree占用的字节数
+	ScannedAt  time.Time        `json:"scannedAt"`
+}
+
+// Exceeded 用量是否达到配额
+func (u *WorktreeDiskUsage) Exceeded() bool {
+	return u.QuotaBytes > 0 && u.TotalBytes >= u.QuotaBytes
+}
+
+// MeasureWorktreeDisk 统计 baseDir 下各worktree目录占用的磁盘空间，目录不存在时用量为 0
+func MeasureWorktreeDisk(baseDir string) (*WorktreeDiskUsage, error) {
+	usage := &WorktreeDiskUsage{Worktrees: make(map[string]int64), ScannedAt: time.Now()}
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return usage, nil
+		}
+		return nil, apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "读取worktree基础目录失败")
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), "wt_") {
+			continue
+		}
+		size := dirSize(filepath.Join(baseDir, entry.Name()))
+		usage.Worktrees[entry.Name()] = size
+		usage.TotalBytes += size
+	}
+	return usage, nil
+}
+
+// dirSize 统计目录中普通文件的大小之和，不跟随符号链接，忽略扫描期间被删除的文件
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// DiskUsage 获取worktree基础目录的磁盘用量，缓存 mcp.worktree_disk.scan_interval 内的统计结果
+func (wm *worktreeManager) DiskUsage(ctx context.Context) (*WorktreeDiskUsage, error) {
+	return wm.diskUsage(false)
+}
+
+// diskUsage 获取磁盘用量，refresh 为 true 或缓存过期时重新统计
+func (wm *worktreeManager) diskUsage(refresh bool) (*WorktreeDiskUsage, error) {
+	wm.diskMutex.Lock()
+	defer wm.diskMutex.Unlock()
+
+	interval, err := time.ParseDuration(wm.config.WorktreeDisk.ScanInterval)
+	if err != nil || interval <= 0 {
+		interval = defaultDiskScanInterval
+	}
+	if refresh || wm.disk == nil || time.Since(wm.disk.ScannedAt) > interval {
+		usage, err := MeasureWorktreeDisk(wm.baseDir)
+		if err != nil {
+			return nil, err
+		}
+		wm.disk = usage
+	}
+
+	usage := *wm.disk
+	usage.QuotaBytes = wm.config.WorktreeDisk.QuotaBytes
+	usage.Worktrees = make(map[string]int64, len(wm.disk.Worktrees))
+	for id, size := range wm.disk.Worktrees {
+		usage.Worktrees[id] = size
+	}
+	return &usage, nil
+}
+
+// reserveDiskSpace 创建worktree前检查磁盘配额，超出时先按 mcp.worktree_cleanup 删除空闲的worktree
+// 仍然超出时按 mcp.worktree_disk.on_exceeded 拒绝或等待用量降到配额以下
+func (wm *worktreeManager) reserveDiskSpace(ctx context.Context) error {
+	cfg := wm.config.WorktreeDisk
+	if cfg.QuotaBytes <= 0 {
+		return nil
+	}
+
+	timeout, err := time.ParseDuration(cfg.WaitTimeout)
+	if err != nil || timeout <= 0 {
+		timeout = defaultDiskWaitTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	log := logger.FromContext(ctx, wm.logger)
+
+	for {
+		usage, err := wm.diskUsage(true)
+		if err != nil {
+			return err
+		}
+		if !usage.Exceeded() {
+			return nil
+		}
+
+		wm.mutex.Lock()
+		report := wm.runCleanup(ctx, cleanupTriggerDisk, func(r *cleanupReport) bool {
+			return usage.TotalBytes-r.FreedBytes >= usage.QuotaBytes
+		})
+		wm.mutex.Unlock()
+		if len(report.Removed) > 0 {
+			continue
+		}
+
+		if cfg.OnExceeded != "wait" || time.Now().After(deadline) {
+			return apperrors.Newf(apperrors.ErrDiskQuota, "worktree磁盘用量 %d 字节已达到配额 %d 字节", usage.TotalBytes, usage.QuotaBytes)
+		}
+
+		log.Info("worktree磁盘用量超出配额，等待空间释放",
+			zap.Int64("totalBytes", usage.TotalBytes),
+			zap.Int64("quotaBytes", usage.QuotaBytes))
+		select {
+		case <-ctx.Done():
+			return apperrors.Wrap(ctx.Err(), apperrors.ErrDiskQuota, "等待worktree磁盘空间时被取消")
+		case <-time.After(diskWaitInterval):
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+func TestMeasureWorktreeDisk(t *testing.T) {
+	baseDir := t.TempDir()
+	for path, size := range map[string]int{
+		"wt_1/a.txt":       100,
+		"wt_1/sub/b.txt":   50,
+		"wt_2/c.txt":       10,
+		"other/ignored.go": 1000,
+	} {
+		path = filepath.Join(baseDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, path, strings.Repeat("x", size))
+	}
+
+	usage, err := MeasureWorktreeDisk(baseDir)
+	if err != nil {
+		t.Fatalf("MeasureWorktreeDisk() error = %v", err)
+	}
+	if usage.TotalBytes != 160 || usage.Worktrees["wt_1"] != 150 || usage.Worktrees["wt_2"] != 10 || len(usage.Worktrees) != 2 {
+		t.Errorf("用量 = %+v", usage)
+	}
+
+	if usage, err := MeasureWorktreeDisk(filepath.Join(baseDir, "missing")); err != nil || usage.TotalBytes != 0 {
+		t.Errorf("目录不存在时 = %+v, error = %v", usage, err)
+	}
+}
+
+// newDiskTestManager 创建磁盘配额为 quota 字节的worktree管理器，基础目录中有一个空闲的和一个使用中的worktree
+func newDiskTestManager(t *testing.T, quota int64, onExceeded string) *worktreeManager {
+	t.Helper()
+	cfg := &config.MCPConfig{
+		WorktreeBaseDir: t.TempDir(),
+		MaxWorktrees:    5,
+		WorktreeDisk:    config.WorktreeDiskConfig{QuotaBytes: quota, OnExceeded: onExceeded, WaitTimeout: "1s"},
+	}
+	wm := NewWorktreeManager(cfg, logger.FromZap(zap.NewNop()), nil).(*worktreeManager)
+	old := time.Now().Add(-time.Minute).Format(time.RFC3339)
+	for id, status := range map[string]string{"wt_idle": WorktreeStateIdle, "wt_active": WorktreeStateInUse} {
+		dir := filepath.Join(cfg.WorktreeBaseDir, id)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(dir, "data"), strings.Repeat("x", 100))
+		wm.worktrees[id] = &WorktreeInfo{ID: id, Status: status, LastUsed: old}
+	}
+	return wm
+}
+
+func TestReserveDiskSpace(t *testing.T) {
+	ctx := context.Background()
+
+	// 删除空闲的worktree后用量降到配额以下
+	wm := newDiskTestManager(t, 150, "refuse")
+	if err := wm.reserveDiskSpace(ctx); err != nil {
+		t.Fatalf("reserveDiskSpace() error = %v", err)
+	}
+	if _, exists := wm.worktrees["wt_idle"]; exists {
+		t.Error("超出配额时未清理空闲的worktree")
+	}
+	if _, exists := wm.worktrees["wt_active"]; !exists {
+		t.Error("清理了使用中的worktree")
+	}
+
+	// 清理后仍超出配额时拒绝
+	wm = newDiskTestManager(t, 50, "refuse")
+	if err := wm.reserveDiskSpace(ctx); !apperrors.IsCode(err, apperrors.ErrDiskQuota) {
+		t.Errorf("超出配额 reserveDiskSpace() error = %v", err)
+	}
+
+	// 固定的worktree不会被清理
+	wm = newDiskTestManager(t, 150, "refuse")
+	wm.worktrees["wt_idle"].Pinned = true
+	if err := wm.reserveDiskSpace(ctx); !apperrors.IsCode(err, apperrors.ErrDiskQuota) {
+		t.Errorf("固定的worktree超出配额 reserveDiskSpace() error = %v", err)
+	}
+
+	// 等待时任务取消后返回
+	wm = newDiskTestManager(t, 50, "wait")
+	cancelCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := wm.reserveDiskSpace(cancelCtx); !apperrors.IsCode(err, apperrors.ErrDiskQuota) {
+		t.Errorf("等待时取消 reserveDiskSpace() error = %v", err)
+	}
+
+	// 未设置配额时不统计用量
+	wm = newDiskTestManager(t, 0, "refuse")
+	if err := wm.reserveDiskSpace(ctx); err != nil || wm.disk != nil {
+		t.Errorf("未设置配额 reserveDiskSpace() error = %v", err)
+	}
+}
+
+func TestDiskUsageCache(t *testing.T) {
+	wm := newDiskTestManager(t, 1000, "refuse")
+	usage, err := wm.DiskUsage(context.Background())
+	if err != nil || usage.TotalBytes != 200 || usage.QuotaBytes != 1000 {
+		t.Fatalf("DiskUsage() = %+v, error = %v", usage, err)
+	}
+
+	// 缓存期间不重新统计
+	writeFile(t, filepath.Join(wm.baseDir, "wt_idle", "more"), strings.Repeat("x", 100))
+	if usage, _ := wm.DiskUsage(context.Background()); usage.TotalBytes != 200 {
+		t.Errorf("缓存的用量 = %d", usage.TotalBytes)
+	}
+
+	worktrees, _ := wm.ListWorktrees(context.Background())
+	for _, worktree := range worktrees {
+		if worktree.DiskUsage != 100 {
+			t.Errorf("%s DiskUsage = %d", worktree.ID, worktree.DiskUsage)
+		}
+	}
+}
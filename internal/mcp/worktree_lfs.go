@@ -0,0 +1,90 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/tracing"
+)
+
+// usesLFS 目录根部的 .gitattributes 是否有 filter=lfs 规则
+func usesLFS(dir string) bool {
+	file, err := os.Open(filepath.Join(dir, ".gitattributes"))
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		for _, attr := range fields[1:] {
+			if attr == "filter=lfs" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lfsPullArgs 生成 git lfs pull 的参数，项目的 lfs_include 和 lfs_exclude 非空时覆盖全局的 include 和 exclude
+func (wm *worktreeManager) lfsPullArgs(projectPath string) []string {
+	include := wm.config.WorktreeLFS.Include
+	exclude := wm.config.WorktreeLFS.Exclude
+	if project := wm.projectConfig(projectPath); project != nil {
+		if len(project.LFSInclude) > 0 {
+			include = project.LFSInclude
+		}
+		if len(project.LFSExclude) > 0 {
+			exclude = project.LFSExclude
+		}
+	}
+
+	args := []string{"lfs", "pull"}
+	if len(include) > 0 {
+		args = append(args, "--include", strings.Join(include, ","))
+	}
+	if len(exclude) > 0 {
+		args = append(args, "--exclude", strings.Join(exclude, ","))
+	}
+	return args
+}
+
+// checkLFS 确认 git-lfs 已安装
+func (wm *worktreeManager) checkLFS(ctx context.Context, dir string) error {
+	if _, err := wm.git(ctx, dir, "lfs", "version"); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrGitOperation, "项目使用 Git LFS，但未安装 git-lfs")
+	}
+	return nil
+}
+
+// pullLFS 按 mcp.worktree_lfs 在worktree中安装 LFS 过滤器并拉取 LFS 文件，使任务处理的是文件内容而不是指针文件
+// 未启用或worktree中没有 filter=lfs 规则时不做任何事
+func (wm *worktreeManager) pullLFS(ctx context.Context, worktree *WorktreeInfo, progress func(float64, string)) error {
+	if !wm.config.WorktreeLFS.Enabled || !usesLFS(worktree.Path) {
+		return nil
+	}
+	if err := wm.checkLFS(ctx, worktree.Path); err != nil {
+		return err
+	}
+
+	_, span := tracing.Start(ctx, "git.lfs_pull", tracing.String("worktree.path", worktree.Path))
+	defer span.End()
+
+	if _, err := wm.git(ctx, worktree.Path, "lfs", "install", "--local"); err != nil {
+		span.RecordError(err)
+		return apperrors.Wrap(err, apperrors.ErrGitOperation, "安装 Git LFS 过滤器失败")
+	}
+	if _, err := wm.gitWithProgress(ctx, worktree.Path, "正在拉取 Git LFS 文件", progress, wm.lfsPullArgs(worktree.ProjectPath)...); err != nil {
+		span.RecordError(err)
+		return apperrors.Wrap(err, apperrors.ErrGitOperation, "拉取 Git LFS 文件失败")
+	}
+	return nil
+}
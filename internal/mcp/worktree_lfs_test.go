@@ -0,0 +1,94 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+func TestUsesLFS(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes string
+		want       bool
+	}{
+		{"LFS规则", "*.psd filter=lfs diff=lfs merge=lfs -text\n", true},
+		{"注释", "# *.psd filter=lfs\n", false},
+		{"其他属性", "*.go text eol=lf\n", false},
+		{"无文件", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.attributes != "" {
+				writeFile(t, filepath.Join(dir, ".gitattributes"), tt.attributes)
+			}
+			if got := usesLFS(dir); got != tt.want {
+				t.Errorf("usesLFS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLFSPullArgs(t *testing.T) {
+	wm := &worktreeManager{config: &config.MCPConfig{
+		WorktreeLFS: config.WorktreeLFSConfig{
+			Include: []string{"assets/**", "docs/*.png"},
+			Exclude: []string{"*.psd"},
+		},
+		WorktreeProjects: []config.WorktreeProjectConfig{
+			{Path: "/projects/game", LFSInclude: []string{"textures/ui/**"}},
+		},
+	}}
+
+	if got, want := wm.lfsPullArgs("/projects/app"), []string{"lfs", "pull", "--include", "assets/**,docs/*.png", "--exclude", "*.psd"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("全局配置 lfsPullArgs() = %v, want %v", got, want)
+	}
+	if got, want := wm.lfsPullArgs("/projects/game"), []string{"lfs", "pull", "--include", "textures/ui/**", "--exclude", "*.psd"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("项目配置 lfsPullArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestCreateWorktreeLFSMissing(t *testing.T) {
+	if err := exec.Command("git", "lfs", "version").Run(); err == nil {
+		t.Skip("已安装 git-lfs")
+	}
+	ctx := context.Background()
+	wm, project, _ := newMergeTestRepo(t)
+	writeFile(t, filepath.Join(project, ".gitattributes"), "*.bin filter=lfs diff=lfs merge=lfs -text\n")
+	runGit(t, project, "add", "-A")
+	runGit(t, project, "commit", "-q", "-m", "lfs")
+
+	wm.config.WorktreeLFS.Enabled = true
+	if _, err := wm.PlanWorktree(ctx, project); !apperrors.IsCode(err, apperrors.ErrGitOperation) {
+		t.Errorf("未安装 git-lfs 时 PlanWorktree() error = %v", err)
+	}
+
+	before := len(wm.worktrees)
+	branches := runGit(t, project, "branch", "--list")
+	if _, err := wm.CreateWorktree(ctx, project, CreateWorktreeOptions{}); !apperrors.IsCode(err, apperrors.ErrWorktreeFailed) {
+		t.Fatalf("未安装 git-lfs 时 CreateWorktree() error = %v", err)
+	}
+	if len(wm.worktrees) != before {
+		t.Errorf("失败后仍登记了worktree")
+	}
+	if after := runGit(t, project, "branch", "--list"); after != branches {
+		t.Errorf("失败后残留任务分支:\n%s", after)
+	}
+
+	// 未启用时保留指针文件，正常创建
+	wm.config.WorktreeLFS.Enabled = false
+	worktree, err := wm.CreateWorktree(ctx, project, CreateWorktreeOptions{})
+	if err != nil {
+		t.Fatalf("未启用时 CreateWorktree() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(worktree.Path, ".gitattributes")); err != nil {
+		t.Errorf("worktree中缺少 .gitattributes: %v", err)
+	}
+}
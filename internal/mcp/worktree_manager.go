@@ -3,6 +3,7 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -13,8 +14,11 @@ import (
 	"go.uber.org/zap"
 
 	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/converter"
 	apperrors "auto-claude-code/internal/errors"
 	"auto-claude-code/internal/logger"
+	"auto-claude-code/internal/tracing"
+	"auto-claude-code/internal/wsl"
 )
 
 // worktreeManager Git worktree管理器实现
@@ -25,24 +29,51 @@ type worktreeManager struct {
 	worktrees map[string]*WorktreeInfo
 	mutex     sync.RWMutex
 
+	// worktree 的 WSL 路径转换和 distro 存放模式下发行版中的操作
+	wslBridge     wsl.WSLBridge
+	pathConverter converter.PathConverter
+	distro        string // distro 模式下worktree所在的发行版
+	distroDir     string // distro 模式下发行版中的基础目录
+
+	// 同一时间只合并一个worktree，避免并发修改项目分支
+	mergeMutex sync.Mutex
+
+	// 缓存的磁盘用量
+	disk      *WorktreeDiskUsage
+	diskMutex sync.Mutex
+
+	// 按项目的裸仓库镜像
+	mirrors      map[string]*projectMirror
+	mirrorsMutex sync.Mutex
+
+	// 定期清理的计时器，重新加载配置时调整间隔，由 mutex 保护
+	cleaner *time.Ticker
+
 	// 生命周期管理
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 }
 
-// NewWorktreeManager 创建新的worktree管理器
-func NewWorktreeManager(cfg *config.MCPConfig, log logger.Logger) WorktreeManager {
+// NewWorktreeManager 创建新的worktree管理器，wslBridge 为 nil 时只支持存放在服务器本地
+func NewWorktreeManager(cfg *config.MCPConfig, log logger.Logger, wslBridge wsl.WSLBridge) WorktreeManager {
 	baseDir := cfg.WorktreeBaseDir
 	if baseDir == "" {
 		baseDir = "./worktrees"
 	}
+	pathConverter := converter.NewPathConverter()
+	if wslBridge != nil {
+		pathConverter = wslBridge.PathConverter()
+	}
 
 	return &worktreeManager{
-		config:    cfg,
-		logger:    log,
-		baseDir:   baseDir,
-		worktrees: make(map[string]*WorktreeInfo),
+		config:        cfg,
+		logger:        log,
+		baseDir:       baseDir,
+		worktrees:     make(map[string]*WorktreeInfo),
+		mirrors:       make(map[string]*projectMirror),
+		wslBridge:     wslBridge,
+		pathConverter: pathConverter,
 	}
 }
 
@@ -50,8 +81,13 @@ func NewWorktreeManager(cfg *config.MCPConfig, log logger.Logger) WorktreeManage
 func (wm *worktreeManager) Start(ctx context.Context) error {
 	wm.ctx, wm.cancel = context.WithCancel(ctx)
 
+	if err := wm.prepareStorage(); err != nil {
+		return err
+	}
+
 	wm.logger.Info("启动Worktree管理器",
 		zap.String("baseDir", wm.baseDir),
+		zap.String("distro", wm.distro),
 		zap.Int("maxWorktrees", wm.config.MaxWorktrees))
 
 	// 确保基础目录存在
@@ -65,9 +101,24 @@ func (wm *worktreeManager) Start(ctx context.Context) error {
 	}
 
 	// 启动清理器
-	if cleanupInterval, err := time.ParseDuration(wm.config.CleanupInterval); err == nil {
+	if cleanupInterval, err := time.ParseDuration(wm.config.CleanupInterval); err == nil && cleanupInterval > 0 {
+		wm.startCleaner(cleanupInterval)
+	}
+
+	// 定期拉取项目镜像
+	if wm.config.WorktreeMirror.Enabled {
+		wm.scanMirrors()
+		wm.wg.Add(1)
+		go wm.runMirrorFetcher()
+	}
+
+	// 先对账一次，补全扫描到的worktree的项目和分支，之后定期对账
+	if wm.config.WorktreeReconcile.Enabled {
 		wm.wg.Add(1)
-		go wm.runCleaner(cleanupInterval)
+		go func() {
+			wm.logReconcileReport(wm.ctx, wm.reconcileWorktrees(wm.ctx))
+			wm.runReconciler()
+		}()
 	}
 
 	return nil
@@ -99,20 +150,47 @@ func (wm *worktreeManager) Stop(ctx context.Context) error {
 	return nil
 }
 
-// CreateWorktree 创建新的worktree
-func (wm *worktreeManager) CreateWorktree(ctx context.Context, projectPath string) (*WorktreeInfo, error) {
+// CreateWorktree 创建新的worktree，Git 项目的任务分支按 mcp.branch_template 命名
+func (wm *worktreeManager) CreateWorktree(ctx context.Context, projectPath string, opts CreateWorktreeOptions) (_ *WorktreeInfo, err error) {
+	ctx, span := tracing.Start(ctx, "worktree.create", tracing.String("worktree.project_path", projectPath))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	// 等待磁盘空间时不持有锁，不影响其他worktree操作
+	if err := wm.reserveDiskSpace(ctx); err != nil {
+		return nil, err
+	}
+
+	// 启用 mcp.worktree_mirror 时从项目的镜像创建，镜像不可用时回退到直接在项目中创建
+	isGit := wm.isGitRepository(projectPath)
+	var mirror *projectMirror
+	if isGit {
+		if mirror, err = wm.mirrorFor(ctx, projectPath, stageProgress(opts.Progress, provisionStageMirror)); err != nil {
+			logger.FromContext(ctx, wm.logger).Warn("项目镜像不可用，直接在项目中创建worktree",
+				zap.String("projectPath", projectPath),
+				zap.Error(err))
+			mirror = nil
+		}
+	}
+	var mirrorPath string
+	if mirror != nil {
+		mirrorPath = mirror.path
+	}
+
 	wm.mutex.Lock()
-	defer wm.mutex.Unlock()
 
 	// 检查worktree数量限制
 	if len(wm.worktrees) >= wm.config.MaxWorktrees {
-		// 尝试清理空闲的worktrees
-		if err := wm.cleanupIdleWorktrees(); err != nil {
-			wm.logger.Warn("清理空闲worktrees失败", zap.Error(err))
-		}
+		// 清理过期的空闲worktrees，仍然达到上限时按 mcp.worktree_cleanup.eviction 删除最久未使用的
+		wm.runCleanup(ctx, cleanupTriggerLimit, func(*cleanupReport) bool {
+			return len(wm.worktrees) >= wm.config.MaxWorktrees
+		})
 
 		// 再次检查
 		if len(wm.worktrees) >= wm.config.MaxWorktrees {
+			wm.mutex.Unlock()
 			return nil, apperrors.New(apperrors.ErrWorktreeFailed, "已达到最大worktree数量限制")
 		}
 	}
@@ -121,54 +199,183 @@ func (wm *worktreeManager) CreateWorktree(ctx context.Context, projectPath strin
 	worktreeID := fmt.Sprintf("wt_%d", time.Now().UnixNano())
 	worktreePath := filepath.Join(wm.baseDir, worktreeID)
 
-	wm.logger.Info("创建新的worktree",
+	// Git 项目在锁内生成任务分支名，避免并发创建的worktree使用同一个分支
+	var workBranch string
+	if isGit {
+		if workBranch, err = wm.branchName(ctx, projectPath, mirrorPath, worktreeID, opts); err != nil {
+			wm.mutex.Unlock()
+			return nil, apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "生成任务分支名失败")
+		}
+	}
+
+	// 先以 provisioning 状态占用名额，复制或检出期间不持有锁
+	now := time.Now().Format(time.RFC3339)
+	worktree := &WorktreeInfo{
+		ID:          worktreeID,
+		ProjectPath: projectPath,
+		WSLPath:     wm.worktreeWSLPath(worktreePath),
+		Path:        worktreePath,
+		Distro:      wm.distro,
+		Branch:      "main", // 默认分支
+		WorkBranch:  workBranch,
+		Mirror:      mirrorPath,
+		TaskID:      opts.TaskID,
+		CreatedAt:   now,
+		LastUsed:    now,
+		Status:      WorktreeStateProvisioning,
+	}
+	wm.worktrees[worktreeID] = worktree
+	wm.mutex.Unlock()
+
+	log := logger.FromContext(ctx, wm.logger)
+	log.Info("创建新的worktree",
 		zap.String("worktreeId", worktreeID),
 		zap.String("projectPath", projectPath),
 		zap.String("worktreePath", worktreePath))
 
-	// 检查项目是否为Git仓库
-	if !wm.isGitRepository(projectPath) {
+	if err := wm.provisionWorktree(ctx, worktree, isGit, mirror, opts); err != nil {
+		wm.mutex.Lock()
+		delete(wm.worktrees, worktreeID)
+		wm.mutex.Unlock()
+		return nil, err
+	}
+
+	log.Info("Worktree创建成功",
+		zap.String("worktreeId", worktreeID),
+		zap.String("branch", worktree.Branch))
+
+	wm.mutex.RLock()
+	defer wm.mutex.RUnlock()
+	worktreeCopy := *worktree
+	return &worktreeCopy, nil
+}
+
+// provisionWorktree 复制项目目录或创建 Git worktree，mirror 不为 nil 时从镜像创建，完成后在锁内填写分支和基准提交
+func (wm *worktreeManager) provisionWorktree(ctx context.Context, worktree *WorktreeInfo, isGit bool, mirror *projectMirror, opts CreateWorktreeOptions) error {
+	if !isGit {
 		// 如果不是Git仓库，直接复制目录
-		if err := wm.copyDirectory(projectPath, worktreePath); err != nil {
-			return nil, apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "复制项目目录失败")
+		var progress func(copyProgress)
+		if opts.Progress != nil {
+			progress = func(p copyProgress) { opts.Progress(p.Fraction(), "正在复制项目文件: "+p.String()) }
 		}
-	} else {
-		// 创建Git worktree
-		if err := wm.createGitWorktree(ctx, projectPath, worktreePath); err != nil {
-			return nil, apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "创建Git worktree失败")
+		if err := wm.copyDirectory(ctx, worktree.ProjectPath, worktree.Path, progress); err != nil {
+			wm.removeDir(ctx, worktree.Path)
+			return apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "复制项目目录失败")
+		}
+		if err := wm.injectContextFiles(ctx, worktree, false, opts); err != nil {
+			wm.removeDir(ctx, worktree.Path)
+			return err
 		}
+		return nil
 	}
 
-	// 创建worktree信息
-	worktree := &WorktreeInfo{
-		ID:          worktreeID,
-		ProjectPath: projectPath,
-		WSLPath:     "/mnt/" + strings.ToLower(string(worktreePath[0])) + strings.ReplaceAll(worktreePath[2:], "\\", "/"),
-		Branch:      "main", // 默认分支
-		CreatedAt:   time.Now().Format(time.RFC3339),
-		LastUsed:    time.Now().Format(time.RFC3339),
-		Status:      "active",
+	// 创建Git worktree
+	if err := wm.createGitWorktree(ctx, worktree.ProjectPath, mirror, worktree.Path, worktree.WorkBranch, stageProgress(opts.Progress, provisionStageCheckout)); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "创建Git worktree失败")
+	}
+
+	// 初始化子模块并拉取 LFS 文件，失败时删除已创建的worktree和任务分支
+	err := wm.initSubmodules(ctx, worktree.Path, stageProgress(opts.Progress, provisionStageSubmodules))
+	if err == nil {
+		err = wm.pullLFS(ctx, worktree, stageProgress(opts.Progress, provisionStageLFS))
+	}
+	if err != nil {
+		wm.discardGitWorktree(ctx, worktree, mirror)
+		return apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "创建Git worktree失败")
+	}
+
+	// 获取当前分支和基准提交
+	branch, branchErr := wm.getCurrentBranch(worktree.ProjectPath)
+	commit, commitErr := wm.getHeadCommit(worktree.Path)
+
+	wm.mutex.Lock()
+	if branchErr == nil {
+		worktree.Branch = branch
+	}
+	if commitErr == nil {
+		worktree.BaseCommit = commit
+	}
+	if project := wm.projectConfig(worktree.ProjectPath); project != nil {
+		worktree.SparseCheckout = project.SparseCheckout
 	}
+	wm.mutex.Unlock()
 
-	// 如果是Git仓库，获取当前分支
+	// 注入上下文文件，模板中可以使用上面得到的分支
+	if err := wm.injectContextFiles(ctx, worktree, true, opts); err != nil {
+		wm.discardGitWorktree(ctx, worktree, mirror)
+		return err
+	}
+	return nil
+}
+
+// discardGitWorktree 删除创建失败的Git worktree和任务分支
+func (wm *worktreeManager) discardGitWorktree(ctx context.Context, worktree *WorktreeInfo, mirror *projectMirror) {
+	repoPath := worktree.ProjectPath
+	if mirror != nil {
+		repoPath = mirror.path
+	}
+	wm.removeGitWorktree(ctx, repoPath, worktree.Path)
+	wm.git(ctx, repoPath, "branch", "-D", worktree.WorkBranch)
+}
+
+// PlanWorktree 检查项目目录和 git 命令是否可用，返回创建worktree的方式
+// 达到数量上限时不报错，执行时仍会先尝试清理空闲的worktrees
+func (wm *worktreeManager) PlanWorktree(ctx context.Context, projectPath string) (*WorktreePlan, error) {
+	info, err := os.Stat(projectPath)
+	if err != nil {
+		return nil, apperrors.Wrapf(err, apperrors.ErrWorktreeFailed, "无法访问项目目录: %s", projectPath)
+	}
+	if !info.IsDir() {
+		return nil, apperrors.Newf(apperrors.ErrWorktreeFailed, "项目路径不是目录: %s", projectPath)
+	}
+
+	plan := &WorktreePlan{Mode: "copy", MaxWorktrees: wm.config.MaxWorktrees}
+	if project := wm.projectConfig(projectPath); project != nil {
+		plan.PostCreate = project.PostCreate
+	}
+	for _, file := range wm.contextFiles(projectPath) {
+		if _, err := os.Stat(file.Template); err != nil {
+			return nil, apperrors.Wrapf(err, apperrors.ErrWorktreeFailed, "无法读取上下文模板: %s", file.Template)
+		}
+		plan.ContextFiles = append(plan.ContextFiles, contextTarget(file))
+	}
 	if wm.isGitRepository(projectPath) {
+		if _, err := exec.LookPath("git"); err != nil {
+			return nil, apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "项目是Git仓库，但未找到git命令")
+		}
+		plan.Mode = "git"
 		if branch, err := wm.getCurrentBranch(projectPath); err == nil {
-			worktree.Branch = branch
+			plan.Branch = branch
+		}
+		if project := wm.projectConfig(projectPath); project != nil {
+			plan.SparseCheckout = project.SparseCheckout
+		}
+		if wm.config.WorktreeMirror.Enabled {
+			plan.Mirror = wm.mirrorPath(projectPath)
+		}
+		plan.Submodules = wm.config.WorktreeSubmodules.Enabled && hasSubmodules(projectPath)
+		if wm.config.WorktreeLFS.Enabled && usesLFS(projectPath) {
+			if err := wm.checkLFS(ctx, projectPath); err != nil {
+				return nil, err
+			}
+			plan.LFS = true
 		}
 	}
 
-	// 保存worktree信息
-	wm.worktrees[worktreeID] = worktree
-
-	wm.logger.Info("Worktree创建成功",
-		zap.String("worktreeId", worktreeID),
-		zap.String("branch", worktree.Branch))
-
-	return worktree, nil
+	wm.mutex.RLock()
+	plan.Active = len(wm.worktrees)
+	wm.mutex.RUnlock()
+	return plan, nil
 }
 
 // DeleteWorktree 删除worktree
-func (wm *worktreeManager) DeleteWorktree(ctx context.Context, worktreeID string) error {
+func (wm *worktreeManager) DeleteWorktree(ctx context.Context, worktreeID string) (err error) {
+	ctx, span := tracing.Start(ctx, "worktree.delete", tracing.String("worktree.id", worktreeID))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
 	wm.mutex.Lock()
 	defer wm.mutex.Unlock()
 
@@ -176,27 +383,60 @@ func (wm *worktreeManager) DeleteWorktree(ctx context.Context, worktreeID string
 	if !exists {
 		return apperrors.Newf(apperrors.ErrWorktreeNotFound, "Worktree不存在: %s", worktreeID)
 	}
+	if worktree.Pinned {
+		return apperrors.Newf(apperrors.ErrConflict, "Worktree已固定，取消固定后才能删除: %s", worktreeID)
+	}
+	if worktree.Status == WorktreeStateProvisioning {
+		return apperrors.Newf(apperrors.ErrConflict, "Worktree正在创建，不能删除: %s", worktreeID)
+	}
 
-	wm.logger.Info("删除worktree", zap.String("worktreeId", worktreeID))
+	log := logger.FromContext(ctx, wm.logger)
+	log.Info("删除worktree", zap.String("worktreeId", worktreeID))
 
-	worktreePath := filepath.Join(wm.baseDir, worktreeID)
+	if err := wm.removeWorktree(ctx, worktree); err != nil {
+		return err
+	}
 
-	// 如果是Git worktree，使用git worktree remove
+	log.Info("Worktree删除成功", zap.String("worktreeId", worktreeID))
+	return nil
+}
+
+// removeWorktree 删除worktree目录和标记文件并从映射中移除，调用方需持有 wm.mutex
+// 删除期间状态为 cleanup，删除目录失败时回到空闲状态，由之后的清理重试
+func (wm *worktreeManager) removeWorktree(ctx context.Context, worktree *WorktreeInfo) error {
+	worktree.Status = WorktreeStateCleanup
+	worktreePath := filepath.Join(wm.baseDir, worktree.ID)
+
+	// 如果是Git worktree，使用git worktree remove；从镜像创建的先将任务分支保留到项目中，再从镜像删除
+	log := logger.FromContext(ctx, wm.logger)
 	if wm.isGitRepository(worktree.ProjectPath) {
-		if err := wm.removeGitWorktree(ctx, worktree.ProjectPath, worktreePath); err != nil {
-			wm.logger.Warn("Git worktree删除失败，尝试直接删除目录", zap.Error(err))
+		repoPath := worktree.ProjectPath
+		if worktree.Mirror != "" {
+			if err := wm.exportTaskBranch(ctx, *worktree); err != nil {
+				log.Warn("将任务分支保留到项目失败",
+					zap.String("worktreeId", worktree.ID),
+					zap.Error(err))
+			}
+			repoPath = worktree.Mirror
+		}
+		if err := wm.removeGitWorktree(ctx, repoPath, worktreePath); err != nil {
+			log.Warn("Git worktree删除失败，尝试直接删除目录",
+				zap.String("worktreeId", worktree.ID),
+				zap.Error(err))
+		}
+		if worktree.Mirror != "" && worktree.WorkBranch != "" {
+			wm.git(ctx, repoPath, "branch", "-D", worktree.WorkBranch)
 		}
 	}
 
-	// 删除目录
-	if err := os.RemoveAll(worktreePath); err != nil {
+	if err := wm.removeDir(ctx, worktreePath); err != nil {
+		worktree.Status = WorktreeStateIdle
 		return apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "删除worktree目录失败")
 	}
+	os.Remove(wm.pinnedMarker(worktree.ID))
+	os.Remove(wm.conflictedMarker(worktree.ID))
 
-	// 从映射中删除
-	delete(wm.worktrees, worktreeID)
-
-	wm.logger.Info("Worktree删除成功", zap.String("worktreeId", worktreeID))
+	delete(wm.worktrees, worktree.ID)
 	return nil
 }
 
@@ -223,21 +463,136 @@ func (wm *worktreeManager) ListWorktrees(ctx context.Context) ([]*WorktreeInfo,
 	wm.mutex.RLock()
 	defer wm.mutex.RUnlock()
 
+	wm.diskMutex.Lock()
+	disk := wm.disk
+	wm.diskMutex.Unlock()
+
 	worktrees := make([]*WorktreeInfo, 0, len(wm.worktrees))
 	for _, worktree := range wm.worktrees {
 		worktreeCopy := *worktree
+		if disk != nil {
+			worktreeCopy.DiskUsage = disk.Worktrees[worktree.ID]
+		}
 		worktrees = append(worktrees, &worktreeCopy)
 	}
 
 	return worktrees, nil
 }
 
+// GetWorktreeDiff 获取worktree相对基准提交的统一diff，包含已提交、未提交的修改和未跟踪且未被忽略的新文件
+// 新文件通过临时索引加入diff，不修改worktree的暂存区
+func (wm *worktreeManager) GetWorktreeDiff(ctx context.Context, worktreeID string) (string, error) {
+	wm.mutex.RLock()
+	worktree, exists := wm.worktrees[worktreeID]
+	var baseCommit string
+	if exists {
+		baseCommit = worktree.BaseCommit
+	}
+	wm.mutex.RUnlock()
+
+	if !exists {
+		return "", apperrors.Newf(apperrors.ErrWorktreeNotFound, "Worktree不存在: %s", worktreeID)
+	}
+
+	worktreePath := filepath.Join(wm.baseDir, worktreeID)
+	if !wm.isGitRepository(worktreePath) {
+		return "", apperrors.Newf(apperrors.ErrGitOperation, "Worktree不是Git仓库，无法生成diff: %s", worktreeID)
+	}
+	if baseCommit == "" {
+		baseCommit = "HEAD"
+	}
+
+	var diff []byte
+	err := wm.withTempIndex(ctx, worktreePath, func(env []string) (err error) {
+		diff, err = wm.gitOutput(ctx, worktreePath, env, "diff", "--cached", baseCommit)
+		return err
+	})
+	if err != nil {
+		return "", apperrors.Wrapf(err, apperrors.ErrGitOperation, "生成worktree diff失败: %s", worktreeID)
+	}
+
+	return string(diff), nil
+}
+
+// withTempIndex 将worktree当前的全部文件（含未跟踪且未被忽略的文件）加入临时索引，以使用该索引的环境变量调用 fn
+// 临时索引从worktree的索引复制以保留文件状态缓存，不修改worktree的暂存区
+func (wm *worktreeManager) withTempIndex(ctx context.Context, worktreePath string, fn func(env []string) error) error {
+	tempDir, err := os.MkdirTemp("", "worktree-index-")
+	if err != nil {
+		return apperrors.Wrap(err, apperrors.ErrGitOperation, "创建临时索引失败")
+	}
+	defer os.RemoveAll(tempDir)
+
+	index := filepath.Join(tempDir, "index")
+	if indexPath, err := wm.git(ctx, worktreePath, "rev-parse", "--git-path", "index"); err == nil {
+		if !filepath.IsAbs(indexPath) {
+			indexPath = filepath.Join(worktreePath, indexPath)
+		}
+		if err := wm.copyFile(indexPath, index); err != nil {
+			os.Remove(index)
+		}
+	}
+
+	env := []string{"GIT_INDEX_FILE=" + index}
+	if _, err := wm.gitOutput(ctx, worktreePath, env, "add", "-A"); err != nil {
+		return err
+	}
+	return fn(env)
+}
+
+// GetChangedFiles 获取worktree相对基准提交修改、新增和删除的文件，包含未跟踪且未被忽略的新文件
+func (wm *worktreeManager) GetChangedFiles(ctx context.Context, worktreeID string) ([]string, error) {
+	wm.mutex.RLock()
+	worktree, exists := wm.worktrees[worktreeID]
+	var baseCommit string
+	if exists {
+		baseCommit = worktree.BaseCommit
+	}
+	wm.mutex.RUnlock()
+
+	if !exists {
+		return nil, apperrors.Newf(apperrors.ErrWorktreeNotFound, "Worktree不存在: %s", worktreeID)
+	}
+
+	worktreePath := filepath.Join(wm.baseDir, worktreeID)
+	if !wm.isGitRepository(worktreePath) {
+		return nil, apperrors.Newf(apperrors.ErrGitOperation, "Worktree不是Git仓库，无法获取修改的文件: %s", worktreeID)
+	}
+	if baseCommit == "" {
+		baseCommit = "HEAD"
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, args := range [][]string{
+		{"diff", "--name-only", baseCommit},
+		{"ls-files", "--others", "--exclude-standard"},
+	} {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = worktreePath
+
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, apperrors.Wrapf(err, apperrors.ErrGitOperation, "获取worktree修改的文件失败: %s", worktreeID)
+		}
+		for _, line := range strings.Split(string(output), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !seen[line] {
+				seen[line] = true
+				files = append(files, line)
+			}
+		}
+	}
+
+	return files, nil
+}
+
 // CleanupWorktrees 清理过期的worktrees
 func (wm *worktreeManager) CleanupWorktrees(ctx context.Context) error {
 	wm.mutex.Lock()
 	defer wm.mutex.Unlock()
 
-	return wm.cleanupIdleWorktrees()
+	wm.runCleanup(ctx, cleanupTriggerScheduled, nil)
+	return nil
 }
 
 // HealthCheck 健康检查
@@ -247,6 +602,14 @@ func (wm *worktreeManager) HealthCheck(ctx context.Context) error {
 		return apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "Worktree基础目录不存在")
 	}
 
+	// 检查基础目录是否可写，只读挂载或权限变化时无法创建新的worktree
+	probe, err := os.CreateTemp(wm.baseDir, ".health-*")
+	if err != nil {
+		return apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "Worktree基础目录不可写")
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
 	// 检查worktree数量
 	wm.mutex.RLock()
 	worktreeCount := len(wm.worktrees)
@@ -268,45 +631,79 @@ func (wm *worktreeManager) isGitRepository(path string) bool {
 	return false
 }
 
-// createGitWorktree 创建Git worktree
-func (wm *worktreeManager) createGitWorktree(ctx context.Context, projectPath, worktreePath string) error {
+// createGitWorktree 创建Git worktree，检出基于项目当前分支新建的任务分支 workBranch
+// mirror 不为 nil 时先将当前分支更新到镜像，再在镜像中创建worktree
+// worktree 先以 --no-checkout 创建，再检出文件并以 progress 报告检出进度；项目配置了 sparse_checkout 时设置模式后只检出匹配的文件
+func (wm *worktreeManager) createGitWorktree(ctx context.Context, projectPath string, mirror *projectMirror, worktreePath, workBranch string, progress func(float64, string)) error {
 	// 获取当前分支
 	branch, err := wm.getCurrentBranch(projectPath)
 	if err != nil {
 		branch = "main" // 默认分支
 	}
 
-	// 创建唯一的分支名
-	uniqueBranch := fmt.Sprintf("worktree_%d", time.Now().UnixNano())
+	// 在项目目录或镜像中执行git worktree add
+	_, span := tracing.Start(ctx, "git.worktree_add", tracing.String("git.branch", workBranch))
+	defer span.End()
 
-	// 在项目目录中执行git worktree add
-	cmd := exec.CommandContext(ctx, "git", "worktree", "add", "-b", uniqueBranch, worktreePath, branch)
-	cmd.Dir = projectPath
+	repoPath := projectPath
+	if mirror != nil {
+		mirror.mutex.Lock()
+		defer mirror.mutex.Unlock()
+		if err := wm.updateMirrorBranch(ctx, mirror, branch); err != nil {
+			span.RecordError(err)
+			return err
+		}
+		repoPath = mirror.path
+	}
+
+	project := wm.projectConfig(projectPath)
+	sparse := project != nil && len(project.SparseCheckout) > 0
+	cmd := exec.CommandContext(ctx, "git", "worktree", "add", "--no-checkout", "-b", workBranch, worktreePath, branch)
+	cmd.Dir = repoPath
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
+		span.RecordError(err)
 		return apperrors.Wrapf(err, apperrors.ErrGitOperation, "Git worktree创建失败: %s", string(output))
 	}
 
-	wm.logger.Debug("Git worktree创建成功",
+	// git worktree add 不支持 --progress，由 git checkout 检出文件以便报告进度
+	if sparse {
+		err = wm.sparseCheckout(ctx, worktreePath, project)
+	} else {
+		_, err = wm.gitWithProgress(ctx, worktreePath, "正在检出文件", progress, "checkout", "--progress", "--force")
+	}
+	if err != nil {
+		span.RecordError(err)
+		wm.removeGitWorktree(ctx, repoPath, worktreePath)
+		wm.git(ctx, repoPath, "branch", "-D", workBranch)
+		return err
+	}
+
+	logger.FromContext(ctx, wm.logger).Debug("Git worktree创建成功",
 		zap.String("projectPath", projectPath),
 		zap.String("worktreePath", worktreePath),
-		zap.String("branch", uniqueBranch))
+		zap.String("branch", workBranch))
 
 	return nil
 }
 
 // removeGitWorktree 删除Git worktree
+// git 拒绝删除含已初始化子模块的worktree，此时直接删除目录并清理仓库中的worktree记录
 func (wm *worktreeManager) removeGitWorktree(ctx context.Context, projectPath, worktreePath string) error {
 	cmd := exec.CommandContext(ctx, "git", "worktree", "remove", worktreePath, "--force")
 	cmd.Dir = projectPath
 
 	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return apperrors.Wrapf(err, apperrors.ErrGitOperation, "Git worktree删除失败: %s", string(output))
+	if err == nil {
+		return nil
 	}
-
-	return nil
+	if removeErr := wm.removeDir(ctx, worktreePath); removeErr == nil {
+		if _, pruneErr := wm.git(ctx, projectPath, "worktree", "prune"); pruneErr == nil {
+			return nil
+		}
+	}
+	return apperrors.Wrapf(err, apperrors.ErrGitOperation, "Git worktree删除失败: %s", string(output))
 }
 
 // getCurrentBranch 获取当前分支
@@ -327,35 +724,17 @@ func (wm *worktreeManager) getCurrentBranch(projectPath string) (string, error)
 	return branch, nil
 }
 
-// copyDirectory 复制目录（用于非Git项目）
-func (wm *worktreeManager) copyDirectory(src, dst string) error {
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+// getHeadCommit 获取当前提交
+func (wm *worktreeManager) getHeadCommit(repoPath string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = repoPath
 
-		// 计算目标路径
-		relPath, err := filepath.Rel(src, path)
-		if err != nil {
-			return err
-		}
-		dstPath := filepath.Join(dst, relPath)
-
-		// 跳过.git目录
-		if strings.Contains(relPath, ".git") {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		if info.IsDir() {
-			return os.MkdirAll(dstPath, info.Mode())
-		}
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
 
-		// 复制文件
-		return wm.copyFile(path, dstPath)
-	})
+	return strings.TrimSpace(string(output)), nil
 }
 
 // copyFile 复制文件
@@ -378,7 +757,7 @@ func (wm *worktreeManager) copyFile(src, dst string) error {
 	defer dstFile.Close()
 
 	// 复制内容
-	_, err = srcFile.WriteTo(dstFile)
+	_, err = io.Copy(dstFile, srcFile)
 	return err
 }
 
@@ -402,14 +781,7 @@ func (wm *worktreeManager) scanExistingWorktrees() error {
 				continue
 			}
 
-			worktree := &WorktreeInfo{
-				ID:        worktreeID,
-				CreatedAt: info.ModTime().Format(time.RFC3339),
-				LastUsed:  info.ModTime().Format(time.RFC3339),
-				Status:    "idle",
-			}
-
-			wm.worktrees[worktreeID] = worktree
+			wm.worktrees[worktreeID] = wm.scannedWorktree(worktreeID, info)
 		}
 	}
 
@@ -417,45 +789,36 @@ func (wm *worktreeManager) scanExistingWorktrees() error {
 	return nil
 }
 
-// cleanupIdleWorktrees 清理空闲的worktrees
-func (wm *worktreeManager) cleanupIdleWorktrees() error {
-	cutoff := time.Now().Add(-2 * time.Hour) // 2小时未使用的worktrees
-
-	var toDelete []string
-	for worktreeID, worktree := range wm.worktrees {
-		if worktree.Status == "idle" {
-			if lastUsed, err := time.Parse(time.RFC3339, worktree.LastUsed); err == nil {
-				if lastUsed.Before(cutoff) {
-					toDelete = append(toDelete, worktreeID)
-				}
-			}
-		}
+// scannedWorktree 由基础目录中的worktree目录构造空闲的worktree信息，项目和分支由对账补全
+func (wm *worktreeManager) scannedWorktree(worktreeID string, info os.FileInfo) *WorktreeInfo {
+	worktree := &WorktreeInfo{
+		ID:        worktreeID,
+		Path:      filepath.Join(wm.baseDir, worktreeID),
+		WSLPath:   wm.worktreeWSLPath(filepath.Join(wm.baseDir, worktreeID)),
+		Distro:    wm.distro,
+		CreatedAt: info.ModTime().Format(time.RFC3339),
+		LastUsed:  info.ModTime().Format(time.RFC3339),
+		Status:    WorktreeStateIdle,
 	}
-
-	// 删除空闲的worktrees
-	for _, worktreeID := range toDelete {
-		worktreePath := filepath.Join(wm.baseDir, worktreeID)
-		if err := os.RemoveAll(worktreePath); err != nil {
-			wm.logger.Warn("删除空闲worktree失败",
-				zap.String("worktreeId", worktreeID),
-				zap.Error(err))
-			continue
-		}
-		delete(wm.worktrees, worktreeID)
+	if _, err := os.Stat(wm.pinnedMarker(worktreeID)); err == nil {
+		worktree.Pinned = true
 	}
-
-	if len(toDelete) > 0 {
-		wm.logger.Info("清理空闲worktrees", zap.Int("count", len(toDelete)))
+	if _, err := os.Stat(wm.conflictedMarker(worktreeID)); err == nil {
+		worktree.Conflicted = true
 	}
+	return worktree
+}
 
-	return nil
+// startCleaner 启动定期清理
+func (wm *worktreeManager) startCleaner(interval time.Duration) {
+	wm.cleaner = time.NewTicker(interval)
+	wm.wg.Add(1)
+	go wm.runCleaner(wm.cleaner)
 }
 
 // runCleaner 运行清理器
-func (wm *worktreeManager) runCleaner(interval time.Duration) {
+func (wm *worktreeManager) runCleaner(ticker *time.Ticker) {
 	defer wm.wg.Done()
-
-	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
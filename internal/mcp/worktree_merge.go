@@ -0,0 +1,337 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+	"auto-claude-code/internal/tracing"
+)
+
+// 合并回项目的策略
+const (
+	MergeStrategyMerge  = "merge"  // 在项目分支上创建合并提交
+	MergeStrategyRebase = "rebase" // 任务分支变基到项目分支后快进
+)
+
+// 合并回项目的结果状态
+const (
+	MergeStatusMerged    = "merged"     // 已合并到项目分支
+	MergeStatusReady     = "ready"      // 试运行：可以无冲突合并
+	MergeStatusConflict  = "conflict"   // 与项目分支冲突，项目分支未被修改
+	MergeStatusNoChanges = "no_changes" // 任务分支没有项目分支之外的修改
+	MergeStatusFailed    = "failed"     // 提交或合并出错
+)
+
+// MergeOptions 将任务分支合并回项目的选项
+type MergeOptions struct {
+	Strategy string `json:"strategy,omitempty"` // merge（默认）或 rebase
+	DryRun   bool   `json:"dryRun,omitempty"`   // 只在任务分支提交并检测冲突，不修改项目分支
+	Message  string `json:"message,omitempty"`  // 任务分支的提交信息，留空时由任务命令生成
+}
+
+// MergeResult 将任务分支合并回项目的结果
+type MergeResult struct {
+	Status       string    `json:"status"`
+	Strategy     string    `json:"strategy"`
+	DryRun       bool      `json:"dryRun,omitempty"`
+	Branch       string    `json:"branch,omitempty"`       // 任务分支
+	TargetBranch string    `json:"targetBranch,omitempty"` // 合并到的项目分支
+	Commit       string    `json:"commit,omitempty"`       // 任务分支的最新提交
+	MergeCommit  string    `json:"mergeCommit,omitempty"`  // 合并后项目分支的提交
+	Conflicts    []string  `json:"conflicts,omitempty"`    // 冲突的文件
+	Error        string    `json:"error,omitempty"`
+	Time         time.Time `json:"time"`
+}
+
+// validate 校验合并选项，prefix 为字段名前缀
+func (o *MergeOptions) validate(prefix string) []FieldError {
+	var fields []FieldError
+	switch o.Strategy {
+	case "", MergeStrategyMerge, MergeStrategyRebase:
+	default:
+		fields = append(fields, FieldError{Field: prefix + "strategy", Message: "不支持的合并策略 " + o.Strategy + "，支持: merge、rebase"})
+	}
+	if strings.ContainsRune(o.Message, 0) {
+		fields = append(fields, FieldError{Field: prefix + "message", Message: "不能包含空字符"})
+	}
+	return fields
+}
+
+// MergeWorktree 提交worktree中的修改并合并回创建时项目所在的分支
+// 先以 git merge-tree 检测冲突，冲突或试运行时不修改项目；合并要求项目当前检出该分支且没有未提交的修改
+func (wm *worktreeManager) MergeWorktree(ctx context.Context, worktreeID string, opts MergeOptions) (_ *MergeResult, err error) {
+	ctx, span := tracing.Start(ctx, "worktree.merge",
+		tracing.String("worktree.id", worktreeID),
+		tracing.String("merge.strategy", opts.Strategy))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	info, worktreePath, err := wm.gitWorktree(ctx, worktreeID)
+	if err != nil {
+		return nil, err
+	}
+
+	wm.mergeMutex.Lock()
+	defer wm.mergeMutex.Unlock()
+
+	result := &MergeResult{
+		Strategy:     opts.Strategy,
+		DryRun:       opts.DryRun,
+		Branch:       info.WorkBranch,
+		TargetBranch: info.Branch,
+		Time:         time.Now(),
+	}
+	if result.Strategy == "" {
+		result.Strategy = MergeStrategyMerge
+	}
+	defer wm.recordMergeStatus(worktreeID, result)
+
+	if result.Commit, err = wm.commitWorktree(ctx, worktreePath, opts.Message); err != nil {
+		return nil, err
+	}
+
+	// 从镜像创建的worktree先更新镜像中的基准分支，再将任务分支拉取到项目
+	if err := wm.refreshMirrorBranch(ctx, info); err != nil {
+		return nil, err
+	}
+	if err := wm.exportTaskBranch(ctx, info); err != nil {
+		return nil, err
+	}
+
+	// 任务分支已包含在项目分支中时没有需要合并的修改
+	if _, err := wm.git(ctx, info.ProjectPath, "merge-base", "--is-ancestor", result.Branch, result.TargetBranch); err == nil {
+		result.Status = MergeStatusNoChanges
+		return result, nil
+	}
+
+	conflicts, err := wm.mergeConflicts(ctx, info.ProjectPath, result.TargetBranch, result.Branch)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(conflicts) > 0:
+		result.Status = MergeStatusConflict
+		result.Conflicts = conflicts
+		return result, nil
+	case opts.DryRun:
+		result.Status = MergeStatusReady
+		return result, nil
+	}
+
+	// 合并会更新项目的工作区，要求项目检出了目标分支且没有未提交的修改
+	current, err := wm.git(ctx, info.ProjectPath, "branch", "--show-current")
+	if err != nil {
+		return nil, err
+	}
+	if current != result.TargetBranch {
+		return nil, apperrors.Newf(apperrors.ErrConflict, "项目当前分支为 %s，不是任务的基准分支 %s", current, result.TargetBranch)
+	}
+	if dirty, err := wm.statusPorcelain(ctx, info.ProjectPath, false); err != nil {
+		return nil, err
+	} else if dirty != "" {
+		return nil, apperrors.New(apperrors.ErrConflict, "项目工作区有未提交的修改，无法合并")
+	}
+
+	log := logger.FromContext(ctx, wm.logger)
+	switch result.Strategy {
+	case MergeStrategyRebase:
+		if _, err := wm.git(ctx, worktreePath, wm.withAuthor("rebase", result.TargetBranch)...); err != nil {
+			conflicts, _ := wm.git(ctx, worktreePath, "diff", "--name-only", "--diff-filter=U")
+			wm.git(ctx, worktreePath, "rebase", "--abort")
+			if conflicts == "" {
+				return nil, err
+			}
+			result.Status = MergeStatusConflict
+			result.Conflicts = strings.Split(conflicts, "\n")
+			return result, nil
+		}
+		if result.Commit, err = wm.git(ctx, worktreePath, "rev-parse", "HEAD"); err != nil {
+			return nil, err
+		}
+		if err := wm.exportTaskBranch(ctx, info); err != nil {
+			return nil, err
+		}
+		if _, err := wm.git(ctx, info.ProjectPath, "merge", "--ff-only", result.Branch); err != nil {
+			return nil, err
+		}
+	default:
+		message := "Merge branch '" + result.Branch + "'"
+		if _, err := wm.git(ctx, info.ProjectPath, wm.withAuthor("merge", "--no-ff", "-m", message, result.Branch)...); err != nil {
+			wm.git(ctx, info.ProjectPath, "merge", "--abort")
+			return nil, err
+		}
+	}
+
+	if result.MergeCommit, err = wm.git(ctx, info.ProjectPath, "rev-parse", "HEAD"); err != nil {
+		return nil, err
+	}
+	result.Status = MergeStatusMerged
+
+	log.Info("任务分支已合并回项目",
+		zap.String("worktreeId", worktreeID),
+		zap.String("branch", result.Branch),
+		zap.String("targetBranch", result.TargetBranch),
+		zap.String("strategy", result.Strategy),
+		zap.String("mergeCommit", result.MergeCommit))
+	return result, nil
+}
+
+// gitWorktree 获取Git项目的worktree信息和路径，重启后扫描到的worktree没有记录任务分支时从worktree中读取
+func (wm *worktreeManager) gitWorktree(ctx context.Context, worktreeID string) (WorktreeInfo, string, error) {
+	wm.mutex.RLock()
+	worktree, exists := wm.worktrees[worktreeID]
+	var info WorktreeInfo
+	if exists {
+		info = *worktree
+	}
+	wm.mutex.RUnlock()
+
+	if !exists {
+		return info, "", apperrors.Newf(apperrors.ErrWorktreeNotFound, "Worktree不存在: %s", worktreeID)
+	}
+
+	worktreePath := filepath.Join(wm.baseDir, worktreeID)
+	if info.ProjectPath == "" || !wm.isGitRepository(info.ProjectPath) || !wm.isGitRepository(worktreePath) {
+		return info, "", apperrors.Newf(apperrors.ErrGitOperation, "项目不是Git仓库: %s", worktreeID)
+	}
+	if info.WorkBranch == "" {
+		branch, err := wm.git(ctx, worktreePath, "branch", "--show-current")
+		if err != nil || branch == "" {
+			return info, "", apperrors.Newf(apperrors.ErrGitOperation, "Worktree未检出任务分支: %s", worktreeID)
+		}
+		info.WorkBranch = branch
+	}
+	if info.Branch == "" {
+		return info, "", apperrors.Newf(apperrors.ErrGitOperation, "Worktree未记录项目分支: %s", worktreeID)
+	}
+	return info, worktreePath, nil
+}
+
+// commitWorktree 提交worktree中未提交的修改，返回任务分支的最新提交
+func (wm *worktreeManager) commitWorktree(ctx context.Context, worktreePath, message string) (string, error) {
+	changes, err := wm.statusPorcelain(ctx, worktreePath, true)
+	if err != nil {
+		return "", err
+	}
+	if changes != "" {
+		if _, err := wm.git(ctx, worktreePath, "add", "-A"); err != nil {
+			return "", err
+		}
+		if message == "" {
+			message = "Apply task changes"
+		}
+		if _, err := wm.git(ctx, worktreePath, wm.withAuthor("commit", "-m", message)...); err != nil {
+			return "", err
+		}
+	}
+	return wm.git(ctx, worktreePath, "rev-parse", "HEAD")
+}
+
+// withAuthor 配置了 mcp.merge_back 的作者、提交者或签名密钥时以 -c 参数覆盖 git 配置中的身份和签名设置，用于创建提交的命令
+func (wm *worktreeManager) withAuthor(args ...string) []string {
+	cfg := wm.config.MergeBack
+	if !cfg.HasIdentity() {
+		return args
+	}
+	var flags []string
+	set := func(key, value string) {
+		flags = append(flags, "-c", key+"="+value)
+	}
+
+	if cfg.AuthorName != "" || cfg.AuthorEmail != "" {
+		set("user.name", cfg.AuthorName)
+		set("user.email", cfg.AuthorEmail)
+	}
+	if cfg.CommitterName != "" {
+		set("committer.name", cfg.CommitterName)
+	}
+	if cfg.CommitterEmail != "" {
+		set("committer.email", cfg.CommitterEmail)
+	}
+
+	if cfg.SigningKey == "" {
+		set("commit.gpgsign", "false")
+		return append(flags, args...)
+	}
+	format := cfg.SigningFormat
+	if format == "" {
+		format = config.SigningFormatOpenPGP
+	}
+	set("commit.gpgsign", "true")
+	set("user.signingkey", cfg.SigningKey)
+	set("gpg.format", format)
+	if cfg.SigningProgram != "" {
+		// openpgp 的程序为 gpg.program，其他格式为 gpg.<format>.program
+		key := "gpg.program"
+		if format != config.SigningFormatOpenPGP {
+			key = "gpg." + format + ".program"
+		}
+		set(key, cfg.SigningProgram)
+	}
+	return append(flags, args...)
+}
+
+// mergeConflicts 以 git merge-tree 检测合并冲突，不修改任何分支和工作区，返回冲突的文件
+func (wm *worktreeManager) mergeConflicts(ctx context.Context, repoPath, target, branch string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", "merge-tree", "--write-tree", "--name-only", "--no-messages", target, branch)
+	cmd.Dir = repoPath
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return nil, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		// 第一行为合并结果的树对象，之后为冲突的文件
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		return lines[1:], nil
+	default:
+		return nil, apperrors.Wrapf(err, apperrors.ErrGitOperation, "检测合并冲突失败（需要 git 2.38 或更高版本）: %s", strings.TrimSpace(stderr.String()))
+	}
+}
+
+// git 在 dir 中运行 git 命令，返回去除首尾空白的标准输出，失败时错误包含命令的错误输出
+func (wm *worktreeManager) git(ctx context.Context, dir string, args ...string) (string, error) {
+	output, err := wm.gitOutput(ctx, dir, nil, args...)
+	return strings.TrimSpace(string(output)), err
+}
+
+// gitOutput 在 dir 中以附加的环境变量运行 git 命令，返回原始的标准输出
+func (wm *worktreeManager) gitOutput(ctx context.Context, dir string, env []string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// 推送需要认证时直接失败，不等待终端输入
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = strings.TrimSpace(string(output))
+		}
+		// 错误中只给出子命令，不包含 -c 参数和提交信息
+		name := args[0]
+		for i := 0; i+2 < len(args) && args[i] == "-c"; i += 2 {
+			name = args[i+2]
+		}
+		return nil, apperrors.Wrapf(err, apperrors.ErrGitOperation, "git %s 失败: %s", name, message)
+	}
+	return output, nil
+}
@@ -0,0 +1,217 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// runGit 在 dir 中运行 git 命令并返回输出
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// writeFile 写入测试文件
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newMergeTestRepo 创建只有一个提交的项目仓库，并为其创建 worktree
+func newMergeTestRepo(t *testing.T) (*worktreeManager, string, *WorktreeInfo) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("未找到 git 命令")
+	}
+
+	project := t.TempDir()
+	runGit(t, project, "init", "-q", "-b", "main")
+	writeFile(t, filepath.Join(project, "README.md"), "hello\n")
+	runGit(t, project, "add", "-A")
+	runGit(t, project, "commit", "-q", "-m", "init")
+
+	cfg := &config.MCPConfig{
+		WorktreeBaseDir: t.TempDir(),
+		MaxWorktrees:    5,
+		MergeBack:       config.MergeBackConfig{AuthorName: "auto-claude-code", AuthorEmail: "bot@example.com"},
+	}
+	wm := NewWorktreeManager(cfg, logger.FromZap(zap.NewNop()), nil).(*worktreeManager)
+	worktree, err := wm.CreateWorktree(context.Background(), project, CreateWorktreeOptions{TaskID: "t1"})
+	if err != nil {
+		t.Fatalf("CreateWorktree() error = %v", err)
+	}
+	if worktree.Branch != "main" || worktree.WorkBranch == "" {
+		t.Fatalf("Branch = %q, WorkBranch = %q", worktree.Branch, worktree.WorkBranch)
+	}
+	// 与任务结束后相同，worktree处于空闲状态
+	if worktree, err = wm.SetWorktreeState(context.Background(), worktree.ID, WorktreeStateIdle); err != nil {
+		t.Fatalf("SetWorktreeState() error = %v", err)
+	}
+	return wm, project, worktree
+}
+
+func TestMergeWorktree(t *testing.T) {
+	ctx := context.Background()
+	wm, project, worktree := newMergeTestRepo(t)
+	writeFile(t, filepath.Join(worktree.Path, "feature.txt"), "feature\n")
+
+	// 试运行只在任务分支提交
+	before := runGit(t, project, "rev-parse", "HEAD")
+	result, err := wm.MergeWorktree(ctx, worktree.ID, MergeOptions{DryRun: true, Message: "Add feature"})
+	if err != nil {
+		t.Fatalf("试运行 MergeWorktree() error = %v", err)
+	}
+	if result.Status != MergeStatusReady || result.Strategy != MergeStrategyMerge || result.Commit == "" {
+		t.Errorf("试运行结果 = %+v", result)
+	}
+	if after := runGit(t, project, "rev-parse", "HEAD"); after != before {
+		t.Error("试运行修改了项目分支")
+	}
+	if author := runGit(t, worktree.Path, "log", "-1", "--format=%an <%ae> %s"); author != "auto-claude-code <bot@example.com> Add feature" {
+		t.Errorf("任务分支提交 = %q", author)
+	}
+
+	result, err = wm.MergeWorktree(ctx, worktree.ID, MergeOptions{})
+	if err != nil {
+		t.Fatalf("MergeWorktree() error = %v", err)
+	}
+	if result.Status != MergeStatusMerged || result.MergeCommit != runGit(t, project, "rev-parse", "HEAD") {
+		t.Errorf("合并结果 = %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(project, "feature.txt")); err != nil {
+		t.Errorf("合并后项目中没有任务的文件: %v", err)
+	}
+
+	// 已合并的任务分支没有新的修改
+	if result, err := wm.MergeWorktree(ctx, worktree.ID, MergeOptions{}); err != nil || result.Status != MergeStatusNoChanges {
+		t.Errorf("再次合并 = %+v, error = %v", result, err)
+	}
+}
+
+func TestMergeWorktreeRebase(t *testing.T) {
+	ctx := context.Background()
+	wm, project, worktree := newMergeTestRepo(t)
+	writeFile(t, filepath.Join(project, "other.txt"), "other\n")
+	runGit(t, project, "add", "-A")
+	runGit(t, project, "commit", "-q", "-m", "other")
+	writeFile(t, filepath.Join(worktree.Path, "feature.txt"), "feature\n")
+
+	result, err := wm.MergeWorktree(ctx, worktree.ID, MergeOptions{Strategy: MergeStrategyRebase})
+	if err != nil {
+		t.Fatalf("MergeWorktree() error = %v", err)
+	}
+	// 变基后快进，项目分支保持线性历史
+	if result.Status != MergeStatusMerged || result.MergeCommit != result.Commit {
+		t.Errorf("结果 = %+v", result)
+	}
+	if parents := runGit(t, project, "log", "-1", "--format=%P"); strings.Contains(parents, " ") {
+		t.Errorf("变基合并产生了合并提交: %s", parents)
+	}
+}
+
+func TestMergeWorktreeConflict(t *testing.T) {
+	ctx := context.Background()
+	wm, project, worktree := newMergeTestRepo(t)
+	writeFile(t, filepath.Join(project, "README.md"), "project\n")
+	runGit(t, project, "commit", "-q", "-am", "edit")
+	writeFile(t, filepath.Join(worktree.Path, "README.md"), "task\n")
+
+	before := runGit(t, project, "rev-parse", "HEAD")
+	for _, strategy := range []string{MergeStrategyMerge, MergeStrategyRebase} {
+		result, err := wm.MergeWorktree(ctx, worktree.ID, MergeOptions{Strategy: strategy})
+		if err != nil {
+			t.Fatalf("%s MergeWorktree() error = %v", strategy, err)
+		}
+		if result.Status != MergeStatusConflict || !reflect.DeepEqual(result.Conflicts, []string{"README.md"}) {
+			t.Errorf("%s 结果 = %+v", strategy, result)
+		}
+	}
+	if after := runGit(t, project, "rev-parse", "HEAD"); after != before {
+		t.Error("冲突时修改了项目分支")
+	}
+}
+
+func TestMergeWorktreeDirtyProject(t *testing.T) {
+	wm, project, worktree := newMergeTestRepo(t)
+	writeFile(t, filepath.Join(worktree.Path, "feature.txt"), "feature\n")
+	writeFile(t, filepath.Join(project, "README.md"), "uncommitted\n")
+
+	_, err := wm.MergeWorktree(context.Background(), worktree.ID, MergeOptions{})
+	if !apperrors.IsCode(err, apperrors.ErrConflict) {
+		t.Errorf("项目有未提交的修改 MergeWorktree() error = %v", err)
+	}
+
+	runGit(t, project, "checkout", "-q", "-b", "other")
+	runGit(t, project, "checkout", "-q", "README.md")
+	_, err = wm.MergeWorktree(context.Background(), worktree.ID, MergeOptions{})
+	if !apperrors.IsCode(err, apperrors.ErrConflict) {
+		t.Errorf("项目未检出原分支 MergeWorktree() error = %v", err)
+	}
+}
+
+func TestMergeWorktreeSigning(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("未找到 ssh-keygen")
+	}
+	ctx := context.Background()
+	wm, project, worktree := newMergeTestRepo(t)
+	key := filepath.Join(t.TempDir(), "id_ed25519")
+	if output, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen: %v\n%s", err, output)
+	}
+	wm.config.MergeBack.CommitterName = "acc-server"
+	wm.config.MergeBack.CommitterEmail = "server@example.com"
+	wm.config.MergeBack.SigningKey = key
+	wm.config.MergeBack.SigningFormat = config.SigningFormatSSH
+
+	writeFile(t, filepath.Join(worktree.Path, "feature.txt"), "feature\n")
+	result, err := wm.MergeWorktree(ctx, worktree.ID, MergeOptions{Message: "Add feature"})
+	if err != nil || result.Status != MergeStatusMerged {
+		t.Fatalf("MergeWorktree() = %+v, error = %v", result, err)
+	}
+	if identity := runGit(t, worktree.Path, "log", "-1", "--format=%an <%ae> / %cn <%ce>"); identity != "auto-claude-code <bot@example.com> / acc-server <server@example.com>" {
+		t.Errorf("任务分支提交的身份 = %q", identity)
+	}
+	for name, dir := range map[string]string{"任务分支提交": worktree.Path, "合并提交": project} {
+		if commit := runGit(t, dir, "cat-file", "commit", "HEAD"); !strings.Contains(commit, "-----BEGIN SSH SIGNATURE-----") {
+			t.Errorf("%s未签名:\n%s", name, commit)
+		}
+	}
+}
+
+func TestMergeWorktreeIgnoresGitSigningConfig(t *testing.T) {
+	ctx := context.Background()
+	wm, project, worktree := newMergeTestRepo(t)
+	// 项目配置了无法使用的签名密钥，配置了作者时提交不受其影响
+	runGit(t, project, "config", "commit.gpgsign", "true")
+	runGit(t, project, "config", "gpg.format", "ssh")
+	runGit(t, project, "config", "user.signingkey", filepath.Join(t.TempDir(), "missing"))
+
+	writeFile(t, filepath.Join(worktree.Path, "feature.txt"), "feature\n")
+	result, err := wm.MergeWorktree(ctx, worktree.ID, MergeOptions{})
+	if err != nil || result.Status != MergeStatusMerged {
+		t.Fatalf("MergeWorktree() = %+v, error = %v", result, err)
+	}
+	if commit := runGit(t, project, "cat-file", "commit", "HEAD"); strings.Contains(commit, "gpgsig") {
+		t.Errorf("合并提交被签名:\n%s", commit)
+	}
+}
@@ -0,0 +1,235 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+const (
+	// mirrorRemote 镜像中指向项目目录的远程名
+	mirrorRemote = "project"
+	// defaultMirrorFetchInterval 未配置 mcp.worktree_mirror.fetch_interval 时的后台拉取间隔
+	defaultMirrorFetchInterval = 15 * time.Minute
+)
+
+// projectMirror 一个 Git 项目的本地裸仓库镜像
+// 项目的分支克隆为镜像的本地分支，后台拉取只更新 refs/remotes/project/*，
+// 不会改动worktree检出的任务分支；创建worktree和合并前再更新需要的基准分支
+type projectMirror struct {
+	path        string
+	projectPath string
+	mutex       sync.Mutex // 串行化同一镜像的拉取和worktree创建
+}
+
+// mirrorDir 镜像的根目录
+func (wm *worktreeManager) mirrorDir() string {
+	if wm.config.WorktreeMirror.Dir != "" {
+		return wm.config.WorktreeMirror.Dir
+	}
+	return filepath.Join(wm.baseDir, ".mirrors")
+}
+
+// mirrorPath 项目镜像的路径，以项目路径的哈希区分同名的项目
+func (wm *worktreeManager) mirrorPath(projectPath string) string {
+	sum := sha1.Sum([]byte(projectKey(projectPath)))
+	name := slugify(filepath.Base(filepath.Clean(projectPath)))
+	return filepath.Join(wm.mirrorDir(), fmt.Sprintf("%s-%x.git", name, sum[:6]))
+}
+
+// mirrorFor 获取项目的镜像，不存在时从项目克隆并以 progress 报告克隆进度；未启用 mcp.worktree_mirror 时返回 nil
+func (wm *worktreeManager) mirrorFor(ctx context.Context, projectPath string, progress func(float64, string)) (*projectMirror, error) {
+	if !wm.config.WorktreeMirror.Enabled {
+		return nil, nil
+	}
+
+	key := projectKey(projectPath)
+	wm.mirrorsMutex.Lock()
+	mirror, exists := wm.mirrors[key]
+	if !exists {
+		mirror = &projectMirror{path: wm.mirrorPath(projectPath), projectPath: projectPath}
+		wm.mirrors[key] = mirror
+	}
+	wm.mirrorsMutex.Unlock()
+
+	mirror.mutex.Lock()
+	defer mirror.mutex.Unlock()
+	if _, err := os.Stat(filepath.Join(mirror.path, "HEAD")); err == nil {
+		return mirror, nil
+	}
+
+	log := logger.FromContext(ctx, wm.logger)
+	log.Info("创建项目镜像",
+		zap.String("projectPath", projectPath),
+		zap.String("mirror", mirror.path))
+	if err := os.MkdirAll(filepath.Dir(mirror.path), 0755); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "无法创建镜像目录")
+	}
+	if _, err := wm.gitWithProgress(ctx, filepath.Dir(mirror.path), "正在克隆项目镜像", progress, "clone", "--bare", "--progress", "--origin", mirrorRemote, "--", projectPath, mirror.path); err != nil {
+		os.RemoveAll(mirror.path)
+		return nil, err
+	}
+	if _, err := wm.git(ctx, mirror.path, "config", "remote."+mirrorRemote+".fetch", "+refs/heads/*:refs/remotes/"+mirrorRemote+"/*"); err != nil {
+		os.RemoveAll(mirror.path)
+		return nil, err
+	}
+	if err := wm.syncMirrorRemotes(ctx, mirror); err != nil {
+		log.Warn("同步项目远程仓库到镜像失败", zap.String("mirror", mirror.path), zap.Error(err))
+	}
+	return mirror, nil
+}
+
+// syncMirrorRemotes 将项目的远程仓库复制到镜像，推送任务分支时使用与项目相同的远程地址
+func (wm *worktreeManager) syncMirrorRemotes(ctx context.Context, mirror *projectMirror) error {
+	remotes, err := wm.git(ctx, mirror.projectPath, "remote")
+	if err != nil {
+		return err
+	}
+	for _, remote := range strings.Fields(remotes) {
+		if remote == mirrorRemote {
+			continue
+		}
+		url, err := wm.git(ctx, mirror.projectPath, "remote", "get-url", remote)
+		if err != nil {
+			return err
+		}
+		if current, err := wm.git(ctx, mirror.path, "remote", "get-url", remote); err != nil {
+			_, err = wm.git(ctx, mirror.path, "remote", "add", remote, url)
+			if err != nil {
+				return err
+			}
+		} else if current != url {
+			if _, err := wm.git(ctx, mirror.path, "remote", "set-url", remote, url); err != nil {
+				return err
+			}
+		}
+		if pushURL, err := wm.git(ctx, mirror.projectPath, "remote", "get-url", "--push", remote); err == nil && pushURL != url {
+			if _, err := wm.git(ctx, mirror.path, "remote", "set-url", "--push", remote, pushURL); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// updateMirrorBranch 将项目的分支更新到镜像的同名分支，调用方需持有 mirror.mutex
+func (wm *worktreeManager) updateMirrorBranch(ctx context.Context, mirror *projectMirror, branch string) error {
+	ref := "refs/heads/" + branch
+	_, err := wm.git(ctx, mirror.path, "fetch", "--quiet", mirrorRemote, "+"+ref+":"+ref)
+	return err
+}
+
+// refreshMirrorBranch 合并或推送前将项目的基准分支更新到worktree所在的镜像，不是从镜像创建的worktree时不做任何事
+func (wm *worktreeManager) refreshMirrorBranch(ctx context.Context, info WorktreeInfo) error {
+	if info.Mirror == "" {
+		return nil
+	}
+	mirror, err := wm.mirrorFor(ctx, info.ProjectPath, nil)
+	if err != nil {
+		return err
+	}
+	if mirror == nil || mirror.path != info.Mirror {
+		mirror = &projectMirror{path: info.Mirror, projectPath: info.ProjectPath}
+	}
+	mirror.mutex.Lock()
+	defer mirror.mutex.Unlock()
+	return wm.updateMirrorBranch(ctx, mirror, info.Branch)
+}
+
+// exportTaskBranch 将镜像中的任务分支拉取到项目的同名分支，合并和删除worktree前调用，
+// 使任务分支与直接在项目中创建worktree时一样保留在项目中
+func (wm *worktreeManager) exportTaskBranch(ctx context.Context, info WorktreeInfo) error {
+	if info.Mirror == "" || info.WorkBranch == "" {
+		return nil
+	}
+	ref := "refs/heads/" + info.WorkBranch
+	_, err := wm.git(ctx, info.ProjectPath, "fetch", "--quiet", info.Mirror, "+"+ref+":"+ref)
+	return err
+}
+
+// scanMirrors 登记镜像目录中已有的镜像，后台拉取时一并更新
+func (wm *worktreeManager) scanMirrors() {
+	entries, err := os.ReadDir(wm.mirrorDir())
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasSuffix(entry.Name(), ".git") {
+			continue
+		}
+		path := filepath.Join(wm.mirrorDir(), entry.Name())
+		projectPath, err := wm.git(context.Background(), path, "config", "--get", "remote."+mirrorRemote+".url")
+		if err != nil || projectPath == "" {
+			continue
+		}
+		wm.mirrorsMutex.Lock()
+		if _, exists := wm.mirrors[projectKey(projectPath)]; !exists {
+			wm.mirrors[projectKey(projectPath)] = &projectMirror{path: path, projectPath: projectPath}
+		}
+		wm.mirrorsMutex.Unlock()
+	}
+}
+
+// fetchMirrors 从项目拉取所有镜像，清理已删除worktree的记录并同步远程仓库
+func (wm *worktreeManager) fetchMirrors(ctx context.Context) {
+	wm.mirrorsMutex.Lock()
+	mirrors := make([]*projectMirror, 0, len(wm.mirrors))
+	for _, mirror := range wm.mirrors {
+		mirrors = append(mirrors, mirror)
+	}
+	wm.mirrorsMutex.Unlock()
+
+	for _, mirror := range mirrors {
+		if err := wm.fetchMirror(ctx, mirror); err != nil {
+			wm.logger.Warn("拉取项目镜像失败",
+				zap.String("projectPath", mirror.projectPath),
+				zap.String("mirror", mirror.path),
+				zap.Error(err))
+		}
+	}
+}
+
+// fetchMirror 从项目拉取一个镜像
+func (wm *worktreeManager) fetchMirror(ctx context.Context, mirror *projectMirror) error {
+	mirror.mutex.Lock()
+	defer mirror.mutex.Unlock()
+
+	if _, err := wm.git(ctx, mirror.path, "fetch", "--quiet", "--prune", mirrorRemote); err != nil {
+		return err
+	}
+	if _, err := wm.git(ctx, mirror.path, "worktree", "prune"); err != nil {
+		return err
+	}
+	return wm.syncMirrorRemotes(ctx, mirror)
+}
+
+// runMirrorFetcher 定期从项目拉取镜像
+func (wm *worktreeManager) runMirrorFetcher() {
+	defer wm.wg.Done()
+
+	interval, err := time.ParseDuration(wm.config.WorktreeMirror.FetchInterval)
+	if err != nil || interval <= 0 {
+		interval = defaultMirrorFetchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-wm.ctx.Done():
+			return
+		case <-ticker.C:
+			wm.fetchMirrors(wm.ctx)
+		}
+	}
+}
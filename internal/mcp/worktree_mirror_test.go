@@ -0,0 +1,99 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/logger"
+)
+
+func TestCreateWorktreeFromMirror(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("未找到 git 命令")
+	}
+	ctx := context.Background()
+	project := t.TempDir()
+	runGit(t, project, "init", "-q", "-b", "main")
+	runGit(t, project, "remote", "add", "origin", "https://example.com/app.git")
+	writeFile(t, filepath.Join(project, "README.md"), "hello\n")
+	runGit(t, project, "add", "-A")
+	runGit(t, project, "commit", "-q", "-m", "init")
+
+	cfg := &config.MCPConfig{
+		WorktreeBaseDir: t.TempDir(),
+		MaxWorktrees:    5,
+		MergeBack:       config.MergeBackConfig{AuthorName: "auto-claude-code", AuthorEmail: "bot@example.com"},
+		WorktreeMirror:  config.WorktreeMirrorConfig{Enabled: true},
+	}
+	wm := NewWorktreeManager(cfg, logger.FromZap(zap.NewNop()), nil).(*worktreeManager)
+
+	first, err := wm.CreateWorktree(ctx, project, CreateWorktreeOptions{TaskID: "t1"})
+	if err != nil {
+		t.Fatalf("CreateWorktree() error = %v", err)
+	}
+	if first.Mirror != wm.mirrorPath(project) {
+		t.Fatalf("Mirror = %q", first.Mirror)
+	}
+	if url := runGit(t, first.Mirror, "remote", "get-url", "origin"); url != "https://example.com/app.git" {
+		t.Errorf("镜像的 origin = %q", url)
+	}
+	// 任务分支只在镜像中创建
+	if err := exec.Command("git", "-C", project, "show-ref", "--verify", "--quiet", "refs/heads/"+first.WorkBranch).Run(); err == nil {
+		t.Error("从镜像创建时在项目中创建了任务分支")
+	}
+
+	// 项目的新提交在创建下一个worktree时更新到镜像
+	writeFile(t, filepath.Join(project, "NEW.md"), "new\n")
+	runGit(t, project, "add", "-A")
+	runGit(t, project, "commit", "-q", "-m", "new")
+	second, err := wm.CreateWorktree(ctx, project, CreateWorktreeOptions{TaskID: "t2"})
+	if err != nil {
+		t.Fatalf("再次 CreateWorktree() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(second.Path, "NEW.md")); err != nil {
+		t.Errorf("worktree 未基于项目的最新提交: %v", err)
+	}
+	if second.BaseCommit != runGit(t, project, "rev-parse", "HEAD") {
+		t.Errorf("BaseCommit = %s", second.BaseCommit)
+	}
+
+	// 合并时将任务分支拉取到项目
+	writeFile(t, filepath.Join(first.Path, "task.txt"), "task\n")
+	result, err := wm.MergeWorktree(ctx, first.ID, MergeOptions{})
+	if err != nil || result.Status != MergeStatusMerged {
+		t.Fatalf("MergeWorktree() = %+v, error = %v", result, err)
+	}
+	if _, err := os.Stat(filepath.Join(project, "task.txt")); err != nil {
+		t.Errorf("合并后项目中没有任务的修改: %v", err)
+	}
+
+	// 删除worktree时任务分支保留到项目，并从镜像中删除
+	wm.worktrees[second.ID].Status = WorktreeStateIdle
+	if err := wm.DeleteWorktree(ctx, second.ID); err != nil {
+		t.Fatalf("DeleteWorktree() error = %v", err)
+	}
+	if err := exec.Command("git", "-C", project, "show-ref", "--verify", "--quiet", "refs/heads/"+second.WorkBranch).Run(); err != nil {
+		t.Error("删除worktree后任务分支未保留到项目")
+	}
+	if err := exec.Command("git", "-C", second.Mirror, "show-ref", "--verify", "--quiet", "refs/heads/"+second.WorkBranch).Run(); err == nil {
+		t.Error("删除worktree后镜像中仍有任务分支")
+	}
+
+	// 重启后登记已有的镜像并在后台拉取
+	wm.mirrors = make(map[string]*projectMirror)
+	wm.scanMirrors()
+	if len(wm.mirrors) != 1 {
+		t.Fatalf("扫描到的镜像 = %d", len(wm.mirrors))
+	}
+	for _, mirror := range wm.mirrors {
+		if err := wm.fetchMirror(ctx, mirror); err != nil {
+			t.Errorf("fetchMirror() error = %v", err)
+		}
+	}
+}
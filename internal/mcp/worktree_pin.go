@@ -0,0 +1,106 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// pinnedMarkerSuffix 固定标记文件的后缀，标记文件与worktree目录同级，重启后扫描时恢复固定状态
+const pinnedMarkerSuffix = ".pinned"
+
+// WorktreeUpdate PATCH /worktrees/{id} 的请求体
+type WorktreeUpdate struct {
+	Pinned *bool `json:"pinned"` // 固定后空闲清理不会删除该worktree，删除时需要 force
+}
+
+// SetWorktreePinned 固定或取消固定worktree
+func (wm *worktreeManager) SetWorktreePinned(ctx context.Context, worktreeID string, pinned bool) (*WorktreeInfo, error) {
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+
+	worktree, exists := wm.worktrees[worktreeID]
+	if !exists {
+		return nil, apperrors.Newf(apperrors.ErrWorktreeNotFound, "Worktree不存在: %s", worktreeID)
+	}
+
+	marker := wm.pinnedMarker(worktreeID)
+	if pinned {
+		if err := os.WriteFile(marker, nil, 0644); err != nil {
+			return nil, apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "写入worktree固定标记失败")
+		}
+	} else if err := os.Remove(marker); err != nil && !os.IsNotExist(err) {
+		return nil, apperrors.Wrap(err, apperrors.ErrWorktreeFailed, "删除worktree固定标记失败")
+	}
+	worktree.Pinned = pinned
+
+	logger.FromContext(ctx, wm.logger).Info("更新worktree固定状态",
+		zap.String("worktreeId", worktreeID),
+		zap.Bool("pinned", pinned))
+
+	worktreeCopy := *worktree
+	return &worktreeCopy, nil
+}
+
+// pinnedMarker 获取worktree的固定标记文件路径
+func (wm *worktreeManager) pinnedMarker(worktreeID string) string {
+	return filepath.Join(wm.baseDir, worktreeID+pinnedMarkerSuffix)
+}
+
+// handleWorktreePatch 更新worktree的固定状态
+func (s *mcpServer) handleWorktreePatch(w http.ResponseWriter, r *http.Request, worktreeID string) {
+	var update WorktreeUpdate
+	if err := decodeJSONBody(r, &update); err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	if update.Pinned == nil {
+		writeProblem(w, r, apperrors.New(apperrors.ErrInvalidRequest, "请求体缺少 pinned 字段"))
+		return
+	}
+
+	worktree, err := s.worktreeManager.SetWorktreePinned(r.Context(), worktreeID, *update.Pinned)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(worktree)
+}
+
+// handleWorktreeDelete 删除worktree，固定的worktree需要 force=true 才会先取消固定再删除，任务使用中的worktree不能删除
+func (s *mcpServer) handleWorktreeDelete(w http.ResponseWriter, r *http.Request, worktreeID string) {
+	ctx := r.Context()
+	worktree, err := s.worktreeManager.GetWorktree(ctx, worktreeID)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	if worktree.Status == WorktreeStateInUse {
+		writeProblem(w, r, apperrors.Newf(apperrors.ErrConflict, "Worktree正在被任务 %s 使用，不能删除: %s", worktree.TaskID, worktreeID))
+		return
+	}
+	if r.URL.Query().Get("force") == "true" {
+		if worktree.Pinned {
+			if _, err := s.worktreeManager.SetWorktreePinned(ctx, worktreeID, false); err != nil {
+				writeProblem(w, r, err)
+				return
+			}
+		}
+	}
+
+	if err := s.worktreeManager.DeleteWorktree(ctx, worktreeID); err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,90 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+func TestSetWorktreePinned(t *testing.T) {
+	ctx := context.Background()
+	wm, _, worktree := newMergeTestRepo(t)
+
+	info, err := wm.SetWorktreePinned(ctx, worktree.ID, true)
+	if err != nil || !info.Pinned {
+		t.Fatalf("SetWorktreePinned() = %+v, error = %v", info, err)
+	}
+	if _, err := wm.SetWorktreePinned(ctx, "missing", true); !apperrors.IsCode(err, apperrors.ErrWorktreeNotFound) {
+		t.Errorf("worktree 不存在 SetWorktreePinned() error = %v", err)
+	}
+
+	// 空闲清理跳过固定的worktree
+	wm.worktrees[worktree.ID].Status = WorktreeStateIdle
+	wm.worktrees[worktree.ID].LastUsed = time.Now().Add(-3 * time.Hour).Format(time.RFC3339)
+	if err := wm.CleanupWorktrees(ctx); err != nil {
+		t.Fatalf("CleanupWorktrees() error = %v", err)
+	}
+	if _, err := os.Stat(worktree.Path); err != nil {
+		t.Errorf("固定的worktree被清理: %v", err)
+	}
+
+	if err := wm.DeleteWorktree(ctx, worktree.ID); !apperrors.IsCode(err, apperrors.ErrConflict) {
+		t.Errorf("删除固定的worktree error = %v", err)
+	}
+
+	// 重启后从标记文件恢复固定状态
+	wm.worktrees = make(map[string]*WorktreeInfo)
+	if err := wm.scanExistingWorktrees(); err != nil {
+		t.Fatal(err)
+	}
+	if restored := wm.worktrees[worktree.ID]; restored == nil || !restored.Pinned {
+		t.Fatalf("扫描后的worktree = %+v", restored)
+	}
+
+	if info, err := wm.SetWorktreePinned(ctx, worktree.ID, false); err != nil || info.Pinned {
+		t.Fatalf("取消固定 = %+v, error = %v", info, err)
+	}
+	if _, err := os.Stat(wm.pinnedMarker(worktree.ID)); !os.IsNotExist(err) {
+		t.Errorf("取消固定后标记文件仍存在: %v", err)
+	}
+	wm.worktrees[worktree.ID].LastUsed = time.Now().Add(-3 * time.Hour).Format(time.RFC3339)
+	if err := wm.CleanupWorktrees(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := wm.worktrees[worktree.ID]; exists {
+		t.Error("取消固定后空闲清理未删除worktree")
+	}
+}
+
+func TestHandleWorktreePin(t *testing.T) {
+	wm, _, worktree := newMergeTestRepo(t)
+	server := &mcpServer{worktreeManager: wm}
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.handleWorktreeDetail(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := do(http.MethodPatch, "/worktrees/"+worktree.ID, `{"pinned":true}`); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"pinned":true`) {
+		t.Fatalf("固定响应 = %d\n%s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPatch, "/worktrees/"+worktree.ID, `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("缺少 pinned 时状态码 = %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/worktrees/"+worktree.ID, ""); rec.Code != http.StatusConflict {
+		t.Errorf("删除固定的worktree状态码 = %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/worktrees/"+worktree.ID+"?force=true", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("强制删除状态码 = %d\n%s", rec.Code, rec.Body.String())
+	}
+	if _, err := os.Stat(worktree.Path); !os.IsNotExist(err) {
+		t.Errorf("强制删除后worktree目录仍存在: %v", err)
+	}
+}
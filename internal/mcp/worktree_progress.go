@@ -0,0 +1,123 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// gitProgressInterval git 进度的最小报告间隔，完成时总是报告
+const gitProgressInterval = 500 * time.Millisecond
+
+// 创建 Git worktree 各步骤在创建进度中所占的区间
+var (
+	provisionStageMirror     = [2]float64{0, 0.2}
+	provisionStageCheckout   = [2]float64{0.2, 0.7}
+	provisionStageSubmodules = [2]float64{0.7, 0.9}
+	provisionStageLFS        = [2]float64{0.9, 1}
+)
+
+// gitProgressLine 匹配 git 和 git-lfs 的进度行，如 "Receiving objects:  45% (123/270), 1.20 MiB | 2.00 MiB/s"
+var gitProgressLine = regexp.MustCompile(`^\s*([A-Za-z][A-Za-z ]*):\s+(\d+)% \((\d+)/(\d+)\)(.*)$`)
+
+// stageProgress 将步骤内 0 到 1 的进度映射到创建进度的 stage 区间，progress 为 nil 时返回 nil
+func stageProgress(progress func(float64, string), stage [2]float64) func(float64, string) {
+	if progress == nil {
+		return nil
+	}
+	return func(fraction float64, message string) {
+		progress(stage[0]+(stage[1]-stage[0])*fraction, message)
+	}
+}
+
+// gitProgressWriter 解析 git 写到标准错误的进度行并报告，其余输出保留用于错误信息
+// 同一命令的多个阶段（如 Receiving、Resolving）各自从 0% 开始，报告的进度只增不减
+type gitProgressWriter struct {
+	label    string
+	progress func(float64, string)
+	output   bytes.Buffer
+	line     []byte
+	stage    string
+	percent  int
+	fraction float64
+	last     time.Time
+}
+
+func (w *gitProgressWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b == '\r' || b == '\n' {
+			w.flush()
+			continue
+		}
+		w.line = append(w.line, b)
+	}
+	return len(p), nil
+}
+
+// flush 处理一行输出
+func (w *gitProgressWriter) flush() {
+	line := string(w.line)
+	w.line = w.line[:0]
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	match := gitProgressLine.FindStringSubmatch(line)
+	if match == nil {
+		w.output.WriteString(line)
+		w.output.WriteByte('\n')
+		return
+	}
+	if w.progress == nil {
+		return
+	}
+
+	stage := strings.TrimSpace(match[1])
+	percent, _ := strconv.Atoi(match[2])
+	now := time.Now()
+	if (stage == w.stage && percent == w.percent) || (percent < 100 && now.Sub(w.last) < gitProgressInterval) {
+		return
+	}
+	w.stage = stage
+	w.percent = percent
+	w.last = now
+	if fraction := float64(percent) / 100; fraction > w.fraction {
+		w.fraction = fraction
+	}
+	detail := strings.TrimSuffix(strings.TrimSpace(match[5]), ", done.")
+	message := fmt.Sprintf("%s: %s %d%% (%s/%s)%s", w.label, stage, percent, match[3], match[4], detail)
+	w.progress(w.fraction, message)
+}
+
+// gitWithProgress 运行输出进度的 git 命令（调用方在 args 中加入 --progress），progress 不为 nil 时
+// 以 "label: 阶段 百分比 (已处理/总数)" 的消息报告进度，开始时先报告 label
+func (wm *worktreeManager) gitWithProgress(ctx context.Context, dir, label string, progress func(float64, string), args ...string) (string, error) {
+	if progress != nil {
+		progress(0, label)
+	}
+	writer := &gitProgressWriter{label: label, progress: progress}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// git-lfs 只在终端中显示进度，GIT_LFS_FORCE_PROGRESS 使其同样写到管道
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_LFS_FORCE_PROGRESS=1")
+	cmd.Stderr = writer
+
+	output, err := cmd.Output()
+	writer.flush()
+	if err != nil {
+		message := strings.TrimSpace(writer.output.String())
+		if message == "" {
+			message = strings.TrimSpace(string(output))
+		}
+		return "", apperrors.Wrapf(err, apperrors.ErrGitOperation, "git %s 失败: %s", args[0], message)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,72 @@
+package mcp
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+func TestGitProgressWriter(t *testing.T) {
+	type report struct {
+		fraction float64
+		message  string
+	}
+	var reports []report
+	writer := &gitProgressWriter{label: "正在克隆项目镜像", progress: func(fraction float64, message string) {
+		reports = append(reports, report{fraction, message})
+	}}
+
+	// git 以 \r 刷新同一行进度，间隔内的中间进度被合并，完成时总是报告
+	output := "Cloning into bare repository 'm.git'...\n" +
+		"Receiving objects:  10% (10/100)\rReceiving objects:  20% (20/100), 1.00 MiB | 2.00 MiB/s\r" +
+		"Receiving objects: 100% (100/100), 5.00 MiB | 2.00 MiB/s, done.\n" +
+		"Resolving deltas:  50% (5/10)\rResolving deltas: 100% (10/10), done.\n"
+	for _, chunk := range []string{output[:30], output[30:75], output[75:]} {
+		if _, err := writer.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writer.flush()
+
+	want := []report{
+		{0.1, "正在克隆项目镜像: Receiving objects 10% (10/100)"},
+		{1, "正在克隆项目镜像: Receiving objects 100% (100/100), 5.00 MiB | 2.00 MiB/s"},
+		{1, "正在克隆项目镜像: Resolving deltas 100% (10/10)"},
+	}
+	if !reflect.DeepEqual(reports, want) {
+		t.Errorf("进度报告 = %v, want %v", reports, want)
+	}
+	if got := strings.TrimSpace(writer.output.String()); got != "Cloning into bare repository 'm.git'..." {
+		t.Errorf("保留的输出 = %q", got)
+	}
+}
+
+func TestStageProgress(t *testing.T) {
+	if stageProgress(nil, provisionStageCheckout) != nil {
+		t.Error("progress 为 nil 时应返回 nil")
+	}
+	var got float64
+	progress := stageProgress(func(fraction float64, message string) { got = fraction }, provisionStageCheckout)
+	progress(0.5, "")
+	if math.Abs(got-0.45) > 1e-9 {
+		t.Errorf("检出进度 50%% 映射为 %v, want 0.45", got)
+	}
+}
+
+func TestGitWithProgressError(t *testing.T) {
+	wm := &worktreeManager{}
+	var messages []string
+	_, err := wm.gitWithProgress(context.Background(), t.TempDir(), "正在检出文件", func(fraction float64, message string) {
+		messages = append(messages, message)
+	}, "checkout", "--progress", "--force")
+	if !apperrors.IsCode(err, apperrors.ErrGitOperation) || !strings.Contains(err.Error(), "not a git repository") {
+		t.Errorf("gitWithProgress() error = %v", err)
+	}
+	if len(messages) != 1 || messages[0] != "正在检出文件" {
+		t.Errorf("进度消息 = %v", messages)
+	}
+}
@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/logger"
+	"auto-claude-code/internal/tracing"
+)
+
+// PushOptions 推送任务分支的选项
+type PushOptions struct {
+	Remote      string // git 远程，默认 origin
+	Message     string // 任务分支的提交信息
+	Credentials string // HTTPS 远程的 用户名:令牌，只在本次推送中通过环境变量以 Authorization 头传给 git，不写入 git 配置
+}
+
+// PushResult 推送任务分支的结果
+type PushResult struct {
+	Pushed     bool   // 任务分支没有基准分支之外的修改时为 false，不推送
+	Branch     string // 任务分支
+	BaseBranch string // 创建worktree时项目所在的分支
+	Commit     string // 任务分支的最新提交
+	DiffStat   string // 任务分支相对基准分支的 git diff --stat
+}
+
+// PushWorktree 提交worktree中的修改并将任务分支推送到远程仓库的同名分支
+func (wm *worktreeManager) PushWorktree(ctx context.Context, worktreeID string, opts PushOptions) (_ *PushResult, err error) {
+	ctx, span := tracing.Start(ctx, "worktree.push",
+		tracing.String("worktree.id", worktreeID),
+		tracing.String("push.remote", opts.Remote))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	info, worktreePath, err := wm.gitWorktree(ctx, worktreeID)
+	if err != nil {
+		return nil, err
+	}
+	remote := opts.Remote
+	if remote == "" {
+		remote = "origin"
+	}
+
+	wm.mergeMutex.Lock()
+	defer wm.mergeMutex.Unlock()
+
+	result := &PushResult{Branch: info.WorkBranch, BaseBranch: info.Branch}
+	if result.Commit, err = wm.commitWorktree(ctx, worktreePath, opts.Message); err != nil {
+		return nil, err
+	}
+	if err := wm.refreshMirrorBranch(ctx, info); err != nil {
+		return nil, err
+	}
+	if _, err := wm.git(ctx, worktreePath, "merge-base", "--is-ancestor", "HEAD", info.Branch); err == nil {
+		return result, nil
+	}
+
+	base, err := wm.git(ctx, worktreePath, "merge-base", "HEAD", info.Branch)
+	if err != nil {
+		return nil, err
+	}
+	if result.DiffStat, err = wm.git(ctx, worktreePath, "diff", "--stat", base, "HEAD"); err != nil {
+		return nil, err
+	}
+
+	// 凭据通过环境变量传给 git（需要 git 2.31 或更高版本），不出现在命令行中，其他进程无法从进程列表读取
+	var env []string
+	if opts.Credentials != "" {
+		header := "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(opts.Credentials))
+		env = []string{"GIT_CONFIG_COUNT=1", "GIT_CONFIG_KEY_0=http.extraHeader", "GIT_CONFIG_VALUE_0=" + header}
+	}
+	if _, err := wm.gitOutput(ctx, worktreePath, env, "push", remote, "HEAD:refs/heads/"+result.Branch); err != nil {
+		return nil, err
+	}
+	result.Pushed = true
+
+	logger.FromContext(ctx, wm.logger).Info("任务分支已推送",
+		zap.String("worktreeId", worktreeID),
+		zap.String("remote", remote),
+		zap.String("branch", result.Branch),
+		zap.String("commit", result.Commit))
+	return result, nil
+}
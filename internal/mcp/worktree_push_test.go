@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// addTestRemote 为项目添加本地裸仓库作为 origin
+func addTestRemote(t *testing.T, project string) string {
+	t.Helper()
+	remote := t.TempDir()
+	runGit(t, remote, "init", "-q", "--bare")
+	runGit(t, project, "remote", "add", "origin", remote)
+	return remote
+}
+
+func TestPushWorktree(t *testing.T) {
+	ctx := context.Background()
+	wm, project, worktree := newMergeTestRepo(t)
+	remote := addTestRemote(t, project)
+
+	// 没有修改时不推送
+	result, err := wm.PushWorktree(ctx, worktree.ID, PushOptions{Credentials: "x-access-token:secret"})
+	if err != nil {
+		t.Fatalf("PushWorktree() error = %v", err)
+	}
+	if result.Pushed || result.Branch != worktree.WorkBranch || result.BaseBranch != "main" {
+		t.Errorf("没有修改时结果 = %+v", result)
+	}
+
+	writeFile(t, filepath.Join(worktree.Path, "feature.txt"), "feature\n")
+	result, err = wm.PushWorktree(ctx, worktree.ID, PushOptions{Message: "Add feature", Credentials: "x-access-token:secret"})
+	if err != nil {
+		t.Fatalf("PushWorktree() error = %v", err)
+	}
+	if !result.Pushed || !strings.Contains(result.DiffStat, "feature.txt") {
+		t.Errorf("结果 = %+v", result)
+	}
+	if pushed := runGit(t, remote, "rev-parse", "refs/heads/"+result.Branch); pushed != result.Commit {
+		t.Errorf("远程分支 = %s, want %s", pushed, result.Commit)
+	}
+	// 凭据只用于本次推送，不写入 git 配置
+	if config := runGit(t, project, "config", "--list", "--local"); strings.Contains(config, "extraheader") {
+		t.Errorf("git 配置中包含凭据: %s", config)
+	}
+
+	if _, err := wm.PushWorktree(ctx, worktree.ID, PushOptions{Remote: "missing"}); err == nil {
+		t.Error("远程不存在时 PushWorktree() 未返回错误")
+	}
+}
+
+func TestPushWorktreeCredentials(t *testing.T) {
+	ctx := context.Background()
+	wm, project, worktree := newMergeTestRepo(t)
+
+	// HTTPS 远程收到凭据生成的 Authorization 头，拒绝后推送失败
+	headers := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case headers <- r.Header.Get("Authorization"):
+		default:
+		}
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer ts.Close()
+	runGit(t, project, "remote", "add", "forge", ts.URL+"/app.git")
+
+	writeFile(t, filepath.Join(worktree.Path, "feature.txt"), "feature\n")
+	if _, err := wm.PushWorktree(ctx, worktree.ID, PushOptions{Remote: "forge", Credentials: "x-access-token:secret"}); err == nil {
+		t.Fatal("远程拒绝时 PushWorktree() 未返回错误")
+	} else if strings.Contains(err.Error(), "secret") {
+		t.Errorf("错误中包含凭据: %v", err)
+	}
+	select {
+	case header := <-headers:
+		if header != "Basic eC1hY2Nlc3MtdG9rZW46c2VjcmV0" {
+			t.Errorf("Authorization = %q", header)
+		}
+	default:
+		t.Error("远程没有收到请求")
+	}
+}
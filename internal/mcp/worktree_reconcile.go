@@ -0,0 +1,249 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/logger"
+)
+
+// defaultReconcileInterval 未配置 mcp.worktree_reconcile.interval 时的对账间隔
+const defaultReconcileInterval = 10 * time.Minute
+
+// reconcileReport 一次对账的结果
+type reconcileReport struct {
+	Removed  []string // 目录已被删除，不再跟踪的worktree
+	Repaired []string // 补全了项目、分支和基准提交的worktree
+	Adopted  []string // 源仓库中登记且目录存在、但未被跟踪的worktree
+	Broken   []string // 目录存在但源仓库中已没有登记的 Git worktree
+	Repos    int      // 执行了 git worktree prune 的源仓库数
+}
+
+// changed 对账是否改动了跟踪的worktree或发现了异常
+func (r *reconcileReport) changed() bool {
+	return len(r.Removed) > 0 || len(r.Repaired) > 0 || len(r.Adopted) > 0 || len(r.Broken) > 0
+}
+
+// reconcileWorktrees 将跟踪的worktree与目录和源仓库的 git worktree 记录对账
+// 只处理空闲的worktree：目录已被删除的不再跟踪；Git worktree先以 git worktree repair 修复仓库指向它的链接，
+// 启动时扫描到的补全项目路径、镜像、分支和基准提交；之后在各源仓库中清理失效的登记，并跟踪基础目录中登记了但未被跟踪的worktree
+func (wm *worktreeManager) reconcileWorktrees(ctx context.Context) *reconcileReport {
+	report := &reconcileReport{}
+
+	wm.mutex.RLock()
+	snapshot := make([]WorktreeInfo, 0, len(wm.worktrees))
+	for _, worktree := range wm.worktrees {
+		snapshot = append(snapshot, *worktree)
+	}
+	wm.mutex.RUnlock()
+
+	repos := make(map[string]string)
+	addRepo := func(repo string) {
+		if repo != "" {
+			repos[projectKey(repo)] = repo
+		}
+	}
+
+	for i := range snapshot {
+		worktree := &snapshot[i]
+		addRepo(worktreeRepo(worktree))
+		// 正在创建、使用或删除的worktree由对应的操作负责
+		if worktree.Status != WorktreeStateIdle {
+			continue
+		}
+
+		worktreePath := filepath.Join(wm.baseDir, worktree.ID)
+		if _, err := os.Stat(worktreePath); os.IsNotExist(err) {
+			if wm.forgetWorktree(worktree.ID) {
+				report.Removed = append(report.Removed, worktree.ID)
+			}
+			continue
+		}
+		if !wm.isGitRepository(worktreePath) {
+			continue
+		}
+		if _, err := wm.git(ctx, worktreePath, "rev-parse", "--git-common-dir"); err != nil {
+			report.Broken = append(report.Broken, worktree.ID)
+			continue
+		}
+		wm.git(ctx, worktreePath, "worktree", "repair")
+		if worktree.ProjectPath == "" {
+			if repaired := wm.repairWorktree(ctx, worktree.ID); repaired != nil {
+				report.Repaired = append(report.Repaired, worktree.ID)
+				addRepo(worktreeRepo(repaired))
+			}
+		}
+	}
+
+	wm.mirrorsMutex.Lock()
+	for _, mirror := range wm.mirrors {
+		addRepo(mirror.path)
+	}
+	wm.mirrorsMutex.Unlock()
+
+	for _, repo := range repos {
+		if _, err := wm.git(ctx, repo, "worktree", "prune"); err != nil {
+			continue
+		}
+		report.Repos++
+		list, err := wm.git(ctx, repo, "worktree", "list", "--porcelain")
+		if err != nil {
+			continue
+		}
+		for _, worktreePath := range parseWorktreeList(list) {
+			if id, ok := wm.adoptWorktree(ctx, worktreePath); ok {
+				report.Adopted = append(report.Adopted, id)
+			}
+		}
+	}
+	return report
+}
+
+// worktreeRepo worktree登记所在的仓库：从镜像创建的为镜像，否则为 Git 项目
+func worktreeRepo(worktree *WorktreeInfo) string {
+	if worktree.Mirror != "" {
+		return worktree.Mirror
+	}
+	return worktree.ProjectPath
+}
+
+// parseWorktreeList 解析 git worktree list --porcelain 输出中的worktree路径
+func parseWorktreeList(output string) []string {
+	var paths []string
+	for _, line := range strings.Split(output, "\n") {
+		if path, ok := strings.CutPrefix(strings.TrimSpace(line), "worktree "); ok {
+			paths = append(paths, filepath.Clean(filepath.FromSlash(path)))
+		}
+	}
+	return paths
+}
+
+// forgetWorktree 不再跟踪目录已被删除的空闲worktree并删除其标记文件，期间状态已改变时返回 false
+func (wm *worktreeManager) forgetWorktree(worktreeID string) bool {
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+	worktree, exists := wm.worktrees[worktreeID]
+	if !exists || worktree.Status != WorktreeStateIdle {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(wm.baseDir, worktreeID)); !os.IsNotExist(err) {
+		return false
+	}
+	os.Remove(wm.pinnedMarker(worktreeID))
+	os.Remove(wm.conflictedMarker(worktreeID))
+	delete(wm.worktrees, worktreeID)
+	return true
+}
+
+// repairWorktree 按 Git worktree 的仓库补全项目路径、镜像、分支和基准提交，无法确定项目时返回 nil
+// 基准分支取项目当前的分支，基准提交为任务分支与它的合并基础
+func (wm *worktreeManager) repairWorktree(ctx context.Context, worktreeID string) *WorktreeInfo {
+	worktreePath := filepath.Join(wm.baseDir, worktreeID)
+	commonDir, err := wm.git(ctx, worktreePath, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return nil
+	}
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(worktreePath, commonDir)
+	}
+	commonDir = filepath.Clean(commonDir)
+
+	var projectPath, mirror string
+	if filepath.Base(commonDir) == ".git" {
+		projectPath = filepath.Dir(commonDir)
+	} else {
+		// 从镜像创建的worktree，镜像的 project 远程指向项目
+		mirror = commonDir
+		if projectPath, err = wm.git(ctx, mirror, "config", "--get", "remote."+mirrorRemote+".url"); err != nil || projectPath == "" {
+			return nil
+		}
+	}
+
+	workBranch, _ := wm.git(ctx, worktreePath, "branch", "--show-current")
+	branch, err := wm.getCurrentBranch(projectPath)
+	if err != nil {
+		return nil
+	}
+	baseCommit, _ := wm.git(ctx, worktreePath, "merge-base", "HEAD", branch)
+
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+	worktree, exists := wm.worktrees[worktreeID]
+	if !exists || worktree.ProjectPath != "" {
+		return nil
+	}
+	worktree.ProjectPath = projectPath
+	worktree.Mirror = mirror
+	worktree.Branch = branch
+	worktree.WorkBranch = workBranch
+	worktree.BaseCommit = baseCommit
+	repaired := *worktree
+	return &repaired
+}
+
+// adoptWorktree 跟踪源仓库中登记在基础目录下、目录存在但未被跟踪的worktree
+func (wm *worktreeManager) adoptWorktree(ctx context.Context, worktreePath string) (string, bool) {
+	worktreeID := filepath.Base(worktreePath)
+	baseDir, err := filepath.Abs(wm.baseDir)
+	if err != nil || !strings.HasPrefix(worktreeID, "wt_") || projectKey(filepath.Dir(worktreePath)) != projectKey(baseDir) {
+		return "", false
+	}
+	info, err := os.Stat(filepath.Join(wm.baseDir, worktreeID))
+	if err != nil || !info.IsDir() {
+		return "", false
+	}
+
+	wm.mutex.Lock()
+	if _, exists := wm.worktrees[worktreeID]; exists {
+		wm.mutex.Unlock()
+		return "", false
+	}
+	wm.worktrees[worktreeID] = wm.scannedWorktree(worktreeID, info)
+	wm.mutex.Unlock()
+
+	wm.repairWorktree(ctx, worktreeID)
+	return worktreeID, true
+}
+
+// logReconcileReport 记录对账报告，没有改动时只记录调试日志
+func (wm *worktreeManager) logReconcileReport(ctx context.Context, report *reconcileReport) {
+	fields := []zap.Field{
+		zap.Strings("removed", report.Removed),
+		zap.Strings("repaired", report.Repaired),
+		zap.Strings("adopted", report.Adopted),
+		zap.Strings("broken", report.Broken),
+		zap.Int("repos", report.Repos),
+	}
+	log := logger.FromContext(ctx, wm.logger)
+	if !report.changed() {
+		log.Debug("worktree对账报告", fields...)
+		return
+	}
+	log.Info("worktree对账报告", fields...)
+}
+
+// runReconciler 定期对账
+func (wm *worktreeManager) runReconciler() {
+	defer wm.wg.Done()
+
+	interval, err := time.ParseDuration(wm.config.WorktreeReconcile.Interval)
+	if err != nil || interval <= 0 {
+		interval = defaultReconcileInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-wm.ctx.Done():
+			return
+		case <-ticker.C:
+			wm.logReconcileReport(wm.ctx, wm.reconcileWorktrees(wm.ctx))
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+func TestReconcileRemovesDeletedWorktrees(t *testing.T) {
+	ctx := context.Background()
+	wm, project, worktree := newMergeTestRepo(t)
+	inUse, err := wm.CreateWorktree(ctx, project, CreateWorktreeOptions{TaskID: "t2"})
+	if err != nil {
+		t.Fatalf("CreateWorktree() error = %v", err)
+	}
+	if _, err := wm.SetWorktreeState(ctx, inUse.ID, WorktreeStateInUse); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{worktree.Path, inUse.Path} {
+		if err := os.RemoveAll(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := wm.reconcileWorktrees(ctx)
+	if !reflect.DeepEqual(report.Removed, []string{worktree.ID}) || report.Repos != 1 {
+		t.Errorf("对账报告 = %+v", report)
+	}
+	if _, err := wm.GetWorktree(ctx, worktree.ID); !apperrors.IsCode(err, apperrors.ErrWorktreeNotFound) {
+		t.Errorf("目录已删除的worktree仍被跟踪: %v", err)
+	}
+	// 使用中的worktree由任务负责，不会被移除
+	if _, err := wm.GetWorktree(ctx, inUse.ID); err != nil {
+		t.Errorf("使用中的worktree被移除: %v", err)
+	}
+	// 源仓库中失效的登记已清理
+	if list := runGit(t, project, "worktree", "list", "--porcelain"); strings.Contains(list, worktree.ID) {
+		t.Errorf("项目中仍登记已删除的worktree:\n%s", list)
+	}
+}
+
+func TestReconcileRepairsScannedWorktrees(t *testing.T) {
+	ctx := context.Background()
+	wm, project, worktree := newMergeTestRepo(t)
+	writeFile(t, filepath.Join(worktree.Path, "new.txt"), "new\n")
+	runGit(t, worktree.Path, "add", "-A")
+	runGit(t, worktree.Path, "commit", "-q", "-m", "new")
+
+	// 重启后扫描到的worktree没有项目和分支信息
+	restarted := NewWorktreeManager(wm.config, logger.FromZap(zap.NewNop()), nil).(*worktreeManager)
+	if err := restarted.scanExistingWorktrees(); err != nil {
+		t.Fatal(err)
+	}
+	report := restarted.reconcileWorktrees(ctx)
+	if !reflect.DeepEqual(report.Repaired, []string{worktree.ID}) || report.Repos != 1 {
+		t.Errorf("对账报告 = %+v", report)
+	}
+
+	got, err := restarted.GetWorktree(ctx, worktree.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projectKey(got.ProjectPath) != projectKey(project) || got.Branch != "main" ||
+		got.WorkBranch != worktree.WorkBranch || got.BaseCommit != worktree.BaseCommit {
+		t.Errorf("补全后的worktree = %+v, want 项目 %s、分支 main、任务分支 %s、基准提交 %s", got, project, worktree.WorkBranch, worktree.BaseCommit)
+	}
+	if files, err := restarted.GetChangedFiles(ctx, worktree.ID); err != nil || !reflect.DeepEqual(files, []string{"new.txt"}) {
+		t.Errorf("GetChangedFiles() = %v, %v", files, err)
+	}
+}
+
+func TestReconcileAdoptsAndReportsBroken(t *testing.T) {
+	ctx := context.Background()
+	wm, project, worktree := newMergeTestRepo(t)
+	broken, err := wm.CreateWorktree(ctx, project, CreateWorktreeOptions{TaskID: "t2"})
+	if err != nil {
+		t.Fatalf("CreateWorktree() error = %v", err)
+	}
+	if _, err := wm.SetWorktreeState(ctx, broken.ID, WorktreeStateIdle); err != nil {
+		t.Fatal(err)
+	}
+
+	// 项目中登记了但未被跟踪的worktree
+	wm.mutex.Lock()
+	delete(wm.worktrees, worktree.ID)
+	wm.mutex.Unlock()
+	// 登记被手动删除的worktree
+	if err := os.RemoveAll(filepath.Join(project, ".git", "worktrees", broken.ID)); err != nil {
+		t.Fatal(err)
+	}
+
+	report := wm.reconcileWorktrees(ctx)
+	if !reflect.DeepEqual(report.Adopted, []string{worktree.ID}) || !reflect.DeepEqual(report.Broken, []string{broken.ID}) {
+		t.Errorf("对账报告 = %+v", report)
+	}
+	adopted, err := wm.GetWorktree(ctx, worktree.ID)
+	if err != nil {
+		t.Fatalf("未跟踪的worktree没有被跟踪: %v", err)
+	}
+	if adopted.Status != WorktreeStateIdle || adopted.WorkBranch != worktree.WorkBranch {
+		t.Errorf("跟踪的worktree = %+v", adopted)
+	}
+	// 异常的worktree只报告，保留目录中的修改
+	if _, err := wm.GetWorktree(ctx, broken.ID); err != nil {
+		t.Errorf("异常的worktree被移除: %v", err)
+	}
+}
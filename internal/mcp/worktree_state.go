@@ -0,0 +1,85 @@
+package mcp
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// Worktree 的生命周期状态
+const (
+	WorktreeStateProvisioning = "provisioning" // 正在创建，或已创建但任务尚未启动
+	WorktreeStateInUse        = "in_use"       // 任务正在使用
+	WorktreeStateIdle         = "idle"         // 任务已结束，可以被空闲清理删除
+	WorktreeStateCleanup      = "cleanup"      // 正在删除
+)
+
+// worktreeTransitions 允许的状态转换，cleanup 只由删除和清理进入
+var worktreeTransitions = map[string][]string{
+	WorktreeStateProvisioning: {WorktreeStateInUse, WorktreeStateIdle},
+	WorktreeStateInUse:        {WorktreeStateIdle},
+	WorktreeStateIdle:         {WorktreeStateInUse},
+}
+
+// SetWorktreeState 转换worktree的生命周期状态，进入 idle 时刷新最后使用时间，空闲清理从此时开始计时
+func (wm *worktreeManager) SetWorktreeState(ctx context.Context, worktreeID, state string) (*WorktreeInfo, error) {
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+
+	worktree, exists := wm.worktrees[worktreeID]
+	if !exists {
+		return nil, apperrors.Newf(apperrors.ErrWorktreeNotFound, "Worktree不存在: %s", worktreeID)
+	}
+	if !canTransitionWorktree(worktree.Status, state) {
+		return nil, apperrors.Newf(apperrors.ErrConflict, "Worktree %s 不能从 %s 转换为 %s", worktreeID, worktree.Status, state)
+	}
+
+	from := worktree.Status
+	worktree.Status = state
+	if state == WorktreeStateIdle {
+		worktree.LastUsed = time.Now().Format(time.RFC3339)
+	}
+
+	logger.FromContext(ctx, wm.logger).Debug("worktree状态转换",
+		zap.String("worktreeId", worktreeID),
+		zap.String("from", from),
+		zap.String("to", state))
+
+	worktreeCopy := *worktree
+	return &worktreeCopy, nil
+}
+
+// canTransitionWorktree 判断是否允许从 from 转换为 to，状态不变时允许
+func canTransitionWorktree(from, to string) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range worktreeTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// useWorktree 任务启动 Claude Code 前将worktree标记为使用中，返回的函数在任务结束时将其标记为空闲
+// 任务结束前worktree已被删除时忽略
+func (tm *taskManager) useWorktree(ctx context.Context, worktreeID string) func() {
+	if _, err := tm.worktreeManager.SetWorktreeState(ctx, worktreeID, WorktreeStateInUse); err != nil {
+		logger.FromContext(ctx, tm.logger).Warn("标记worktree为使用中失败",
+			zap.String("worktreeId", worktreeID),
+			zap.Error(err))
+	}
+	return func() {
+		_, err := tm.worktreeManager.SetWorktreeState(context.Background(), worktreeID, WorktreeStateIdle)
+		if err != nil && !apperrors.IsCode(err, apperrors.ErrWorktreeNotFound) {
+			logger.FromContext(ctx, tm.logger).Warn("标记worktree为空闲失败",
+				zap.String("worktreeId", worktreeID),
+				zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+func TestWorktreeLifecycle(t *testing.T) {
+	ctx := context.Background()
+	wm, project, _ := newMergeTestRepo(t)
+
+	worktree, err := wm.CreateWorktree(ctx, project, CreateWorktreeOptions{TaskID: "t2"})
+	if err != nil {
+		t.Fatalf("CreateWorktree() error = %v", err)
+	}
+	if worktree.Status != WorktreeStateProvisioning || worktree.TaskID != "t2" {
+		t.Fatalf("创建后的worktree = %+v", worktree)
+	}
+	if err := wm.DeleteWorktree(ctx, worktree.ID); !apperrors.IsCode(err, apperrors.ErrConflict) {
+		t.Errorf("删除创建中的worktree error = %v", err)
+	}
+
+	if _, err := wm.SetWorktreeState(ctx, worktree.ID, WorktreeStateInUse); err != nil {
+		t.Fatalf("SetWorktreeState(in_use) error = %v", err)
+	}
+	if _, err := wm.SetWorktreeState(ctx, worktree.ID, WorktreeStateProvisioning); !apperrors.IsCode(err, apperrors.ErrConflict) {
+		t.Errorf("in_use -> provisioning error = %v", err)
+	}
+
+	// 空闲清理只删除空闲的worktree
+	old := time.Now().Add(-3 * time.Hour).Format(time.RFC3339)
+	wm.worktrees[worktree.ID].LastUsed = old
+	if err := wm.CleanupWorktrees(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := wm.worktrees[worktree.ID]; !exists {
+		t.Fatal("空闲清理删除了使用中的worktree")
+	}
+
+	// 任务结束后进入空闲状态，从此时开始计时
+	idle, err := wm.SetWorktreeState(ctx, worktree.ID, WorktreeStateIdle)
+	if err != nil {
+		t.Fatalf("SetWorktreeState(idle) error = %v", err)
+	}
+	if idle.LastUsed == old {
+		t.Error("进入空闲状态时未刷新最后使用时间")
+	}
+	wm.worktrees[worktree.ID].LastUsed = old
+	if err := wm.CleanupWorktrees(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := wm.worktrees[worktree.ID]; exists {
+		t.Error("空闲清理未删除过期的空闲worktree")
+	}
+	if _, err := os.Stat(worktree.Path); !os.IsNotExist(err) {
+		t.Errorf("清理后worktree目录仍存在: %v", err)
+	}
+	if _, err := wm.SetWorktreeState(ctx, worktree.ID, WorktreeStateIdle); !apperrors.IsCode(err, apperrors.ErrWorktreeNotFound) {
+		t.Errorf("worktree 不存在 SetWorktreeState() error = %v", err)
+	}
+}
+
+func TestCreateWorktreeConcurrentBranches(t *testing.T) {
+	ctx := context.Background()
+	wm, project, _ := newMergeTestRepo(t)
+	wm.config.BranchTemplate = "acc/{task_id}"
+
+	var wg sync.WaitGroup
+	results := make([]*WorktreeInfo, 3)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = wm.CreateWorktree(ctx, project, CreateWorktreeOptions{TaskID: "same"})
+		}(i)
+	}
+	wg.Wait()
+
+	branches := make(map[string]bool)
+	for i, worktree := range results {
+		if errs[i] != nil {
+			t.Fatalf("CreateWorktree() error = %v", errs[i])
+		}
+		if branches[worktree.WorkBranch] {
+			t.Errorf("并发创建的worktree使用了同一个分支 %s", worktree.WorkBranch)
+		}
+		branches[worktree.WorkBranch] = true
+	}
+}
+
+func TestHandleWorktreeDeleteInUse(t *testing.T) {
+	ctx := context.Background()
+	wm, _, worktree := newMergeTestRepo(t)
+	server := &mcpServer{worktreeManager: wm}
+	if _, err := wm.SetWorktreeState(ctx, worktree.ID, WorktreeStateInUse); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.handleWorktreeDetail(rec, httptest.NewRequest(http.MethodDelete, "/worktrees/"+worktree.ID+"?force=true", nil))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("删除使用中的worktree状态码 = %d\n%s", rec.Code, rec.Body.String())
+	}
+}
+
+// stateRecordingWorktreeManager 记录任务对worktree的状态转换
+type stateRecordingWorktreeManager struct {
+	memoryWorktreeManager
+	mutex  sync.Mutex
+	states []string
+}
+
+func (m *stateRecordingWorktreeManager) SetWorktreeState(ctx context.Context, worktreeID, state string) (*WorktreeInfo, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.states = append(m.states, state)
+	return &WorktreeInfo{ID: worktreeID, Status: state}, nil
+}
+
+func TestTaskWorktreeStates(t *testing.T) {
+	tm := newQueueTestManager()
+	tm.wslBridge = &blockingBridge{}
+	tm.pathConverter = identityConverter{}
+	worktrees := &stateRecordingWorktreeManager{}
+	tm.worktreeManager = worktrees
+	tm.workerCount = 1
+	if err := tm.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer tm.Stop(context.Background())
+
+	if _, err := tm.SubmitTask(context.Background(), &TaskRequest{ID: "t1", ProjectPath: "/app", Command: "fix"}); err != nil {
+		t.Fatalf("SubmitTask() error = %v", err)
+	}
+	waitForStatus(t, tm, "t1", "completed")
+
+	worktrees.mutex.Lock()
+	defer worktrees.mutex.Unlock()
+	if want := []string{WorktreeStateInUse, WorktreeStateIdle}; !reflect.DeepEqual(worktrees.states, want) {
+		t.Errorf("状态转换 = %v, want %v", worktrees.states, want)
+	}
+}
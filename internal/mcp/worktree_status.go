@@ -0,0 +1,237 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// 修改的文件相对基准提交的状态
+const (
+	FileStatusAdded    = "added"
+	FileStatusModified = "modified"
+	FileStatusDeleted  = "deleted"
+	FileStatusRenamed  = "renamed"
+)
+
+// WorktreeStatus worktree的 git 状态和相对基准提交修改的文件
+type WorktreeStatus struct {
+	WorktreeID string        `json:"worktreeId"`
+	Branch     string        `json:"branch,omitempty"`     // 检出的分支
+	Head       string        `json:"head,omitempty"`       // 当前提交
+	BaseCommit string        `json:"baseCommit,omitempty"` // 创建worktree时的提交
+	Clean      bool          `json:"clean"`                // 没有未提交的修改和未跟踪的文件
+	Porcelain  string        `json:"porcelain"`            // git status --porcelain 的输出
+	Files      []ChangedFile `json:"files"`                // 相对基准提交修改的文件，包含已提交、未提交和未跟踪的文件
+	Additions  int           `json:"additions"`            // 文本文件增加的行数合计
+	Deletions  int           `json:"deletions"`            // 文本文件删除的行数合计
+}
+
+// ChangedFile 相对基准提交修改的文件
+type ChangedFile struct {
+	Path        string `json:"path"`
+	OldPath     string `json:"oldPath,omitempty"` // 重命名前的路径
+	Status      string `json:"status"`            // added、modified、deleted 或 renamed
+	Additions   int    `json:"additions"`
+	Deletions   int    `json:"deletions"`
+	Binary      bool   `json:"binary,omitempty"`
+	Uncommitted bool   `json:"uncommitted,omitempty"` // 有未提交的修改
+	Untracked   bool   `json:"untracked,omitempty"`   // 未被 git 跟踪的新文件
+}
+
+// GetWorktreeStatus 获取worktree的 git status 和相对基准提交修改的文件，未提交和未跟踪的文件通过临时索引统计，不修改暂存区
+func (wm *worktreeManager) GetWorktreeStatus(ctx context.Context, worktreeID string) (*WorktreeStatus, error) {
+	wm.mutex.RLock()
+	worktree, exists := wm.worktrees[worktreeID]
+	var baseCommit string
+	if exists {
+		baseCommit = worktree.BaseCommit
+	}
+	wm.mutex.RUnlock()
+
+	if !exists {
+		return nil, apperrors.Newf(apperrors.ErrWorktreeNotFound, "Worktree不存在: %s", worktreeID)
+	}
+
+	worktreePath := filepath.Join(wm.baseDir, worktreeID)
+	if !wm.isGitRepository(worktreePath) {
+		return nil, apperrors.Newf(apperrors.ErrGitOperation, "Worktree不是Git仓库，无法获取状态: %s", worktreeID)
+	}
+	if baseCommit == "" {
+		baseCommit = "HEAD"
+	}
+
+	status := &WorktreeStatus{WorktreeID: worktreeID, BaseCommit: baseCommit, Files: []ChangedFile{}}
+	status.Branch, _ = wm.git(ctx, worktreePath, "branch", "--show-current")
+	status.Head, _ = wm.git(ctx, worktreePath, "rev-parse", "HEAD")
+
+	porcelain, err := wm.statusPorcelain(ctx, worktreePath, true)
+	if err != nil {
+		return nil, err
+	}
+	status.Porcelain = porcelain
+	status.Clean = porcelain == ""
+	uncommitted, untracked := parsePorcelain(porcelain)
+
+	var nameStatus, numstat []byte
+	err = wm.withTempIndex(ctx, worktreePath, func(env []string) (err error) {
+		if nameStatus, err = wm.gitOutput(ctx, worktreePath, env, "diff", "--cached", "-M", "--name-status", "-z", baseCommit); err != nil {
+			return err
+		}
+		numstat, err = wm.gitOutput(ctx, worktreePath, env, "diff", "--cached", "-M", "--numstat", "-z", baseCommit)
+		return err
+	})
+	if err != nil {
+		return nil, apperrors.Wrapf(err, apperrors.ErrGitOperation, "获取worktree修改的文件失败: %s", worktreeID)
+	}
+
+	status.Files = parseNameStatus(nameStatus)
+	stats := parseNumstat(numstat)
+	for i := range status.Files {
+		file := &status.Files[i]
+		if stat, ok := stats[file.Path]; ok {
+			file.Additions, file.Deletions, file.Binary = stat.Additions, stat.Deletions, stat.Binary
+		}
+		file.Uncommitted = uncommitted[file.Path] || (file.OldPath != "" && uncommitted[file.OldPath])
+		file.Untracked = untracked[file.Path]
+		status.Additions += file.Additions
+		status.Deletions += file.Deletions
+	}
+	return status, nil
+}
+
+// statusPorcelain 返回 git status --porcelain 的输出，没有修改时为空；untracked 为 false 时忽略未跟踪的文件
+func (wm *worktreeManager) statusPorcelain(ctx context.Context, dir string, untracked bool) (string, error) {
+	args := []string{"status", "--porcelain"}
+	if !untracked {
+		args = append(args, "--untracked-files=no")
+	}
+	output, err := wm.gitOutput(ctx, dir, nil, args...)
+	// 保留第一行开头表示暂存区状态的空格
+	return strings.TrimRight(string(output), "\n"), err
+}
+
+// parsePorcelain 解析 git status --porcelain 的输出，返回有未提交修改的路径和未跟踪的路径
+func parsePorcelain(porcelain string) (uncommitted, untracked map[string]bool) {
+	uncommitted, untracked = make(map[string]bool), make(map[string]bool)
+	for _, line := range strings.Split(porcelain, "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		code, path := line[:2], line[3:]
+		if from, to, ok := strings.Cut(path, " -> "); ok {
+			uncommitted[unquoteGitPath(from)] = true
+			path = to
+		}
+		path = unquoteGitPath(path)
+		uncommitted[path] = true
+		if code == "??" {
+			untracked[path] = true
+		}
+	}
+	return uncommitted, untracked
+}
+
+// unquoteGitPath 还原 git 对包含特殊字符的路径加的引号
+func unquoteGitPath(path string) string {
+	if strings.HasPrefix(path, `"`) {
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			return unquoted
+		}
+	}
+	return path
+}
+
+// parseNameStatus 解析 git diff --name-status -z 的输出
+func parseNameStatus(output []byte) []ChangedFile {
+	fields := splitNul(output)
+	files := []ChangedFile{}
+	for i := 0; i < len(fields); i++ {
+		code := fields[i]
+		if code == "" || i+1 >= len(fields) {
+			continue
+		}
+		file := ChangedFile{Path: fields[i+1]}
+		i++
+		switch code[0] {
+		case 'A':
+			file.Status = FileStatusAdded
+		case 'D':
+			file.Status = FileStatusDeleted
+		case 'R':
+			if i+1 < len(fields) {
+				file.OldPath, file.Path = file.Path, fields[i+1]
+				i++
+			}
+			file.Status = FileStatusRenamed
+		case 'C':
+			// 复制的文件视为新增，跳过来源路径
+			if i+1 < len(fields) {
+				file.Path = fields[i+1]
+				i++
+			}
+			file.Status = FileStatusAdded
+		default:
+			file.Status = FileStatusModified
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// parseNumstat 解析 git diff --numstat -z 的输出，按文件的新路径索引，二进制文件没有行数
+func parseNumstat(output []byte) map[string]ChangedFile {
+	fields := splitNul(output)
+	stats := make(map[string]ChangedFile, len(fields))
+	for i := 0; i < len(fields); i++ {
+		parts := strings.SplitN(fields[i], "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		path := parts[2]
+		// 重命名时路径为空，随后的两个字段为原路径和新路径
+		if path == "" && i+2 < len(fields) {
+			path = fields[i+2]
+			i += 2
+		}
+		stat := ChangedFile{Path: path}
+		if parts[0] == "-" {
+			stat.Binary = true
+		} else {
+			stat.Additions, _ = strconv.Atoi(parts[0])
+			stat.Deletions, _ = strconv.Atoi(parts[1])
+		}
+		stats[path] = stat
+	}
+	return stats
+}
+
+// splitNul 按 NUL 拆分 git -z 输出
+func splitNul(output []byte) []string {
+	output = bytes.TrimRight(output, "\x00")
+	if len(output) == 0 {
+		return nil
+	}
+	return strings.Split(string(output), "\x00")
+}
+
+// handleWorktreeStatus 返回worktree的 git 状态和修改的文件
+func (s *mcpServer) handleWorktreeStatus(w http.ResponseWriter, r *http.Request, worktreeID string) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, r, apperrors.New(apperrors.ErrMethodNotAllowed, "只支持GET方法"))
+		return
+	}
+
+	status, err := s.worktreeManager.GetWorktreeStatus(r.Context(), worktreeID)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	writeJSONWithETag(w, r, status)
+}
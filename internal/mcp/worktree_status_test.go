@@ -0,0 +1,100 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetWorktreeStatus(t *testing.T) {
+	ctx := context.Background()
+	wm, _, worktree := newMergeTestRepo(t)
+
+	status, err := wm.GetWorktreeStatus(ctx, worktree.ID)
+	if err != nil {
+		t.Fatalf("GetWorktreeStatus() error = %v", err)
+	}
+	if !status.Clean || status.Porcelain != "" || len(status.Files) != 0 || status.Branch != worktree.WorkBranch || status.Head != worktree.BaseCommit {
+		t.Errorf("未修改时状态 = %+v", status)
+	}
+
+	writeFile(t, filepath.Join(worktree.Path, "committed.txt"), "a\nb\n")
+	runGit(t, worktree.Path, "add", "-A")
+	runGit(t, worktree.Path, "commit", "-q", "-m", "commit")
+	writeFile(t, filepath.Join(worktree.Path, "README.md"), "changed\n")
+	writeFile(t, filepath.Join(worktree.Path, "new file.txt"), "new\n")
+	if err := os.WriteFile(filepath.Join(worktree.Path, "logo.bin"), []byte{0, 1, 2}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err = wm.GetWorktreeStatus(ctx, worktree.ID)
+	if err != nil {
+		t.Fatalf("GetWorktreeStatus() error = %v", err)
+	}
+	want := []ChangedFile{
+		{Path: "README.md", Status: FileStatusModified, Additions: 1, Deletions: 1, Uncommitted: true},
+		{Path: "committed.txt", Status: FileStatusAdded, Additions: 2},
+		{Path: "logo.bin", Status: FileStatusAdded, Binary: true, Uncommitted: true, Untracked: true},
+		{Path: "new file.txt", Status: FileStatusAdded, Additions: 1, Uncommitted: true, Untracked: true},
+	}
+	if !reflect.DeepEqual(status.Files, want) {
+		t.Errorf("Files = %+v\nwant %+v", status.Files, want)
+	}
+	if status.Clean || status.Additions != 4 || status.Deletions != 1 || !strings.Contains(status.Porcelain, ` M README.md`) {
+		t.Errorf("状态 = %+v", status)
+	}
+	// 统计未跟踪的文件不修改暂存区
+	if staged := runGit(t, worktree.Path, "diff", "--cached", "--name-only"); staged != "" {
+		t.Errorf("获取状态后暂存区 = %q", staged)
+	}
+}
+
+func TestParsePorcelain(t *testing.T) {
+	uncommitted, untracked := parsePorcelain(" M a.go\nR  old.go -> new.go\n?? \"dir/\\344\\270\\255.txt\"\nA  b.go")
+	wantUncommitted := map[string]bool{"a.go": true, "old.go": true, "new.go": true, "dir/中.txt": true, "b.go": true}
+	if !reflect.DeepEqual(uncommitted, wantUncommitted) {
+		t.Errorf("uncommitted = %v", uncommitted)
+	}
+	if !reflect.DeepEqual(untracked, map[string]bool{"dir/中.txt": true}) {
+		t.Errorf("untracked = %v", untracked)
+	}
+}
+
+func TestParseRenames(t *testing.T) {
+	files := parseNameStatus([]byte("R090\x00old.go\x00new.go\x00D\x00gone.go\x00"))
+	want := []ChangedFile{
+		{Path: "new.go", OldPath: "old.go", Status: FileStatusRenamed},
+		{Path: "gone.go", Status: FileStatusDeleted},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("parseNameStatus() = %+v", files)
+	}
+
+	stats := parseNumstat([]byte("3\t1\t\x00old.go\x00new.go\x000\t5\tgone.go\x00"))
+	if stats["new.go"].Additions != 3 || stats["new.go"].Deletions != 1 || stats["gone.go"].Deletions != 5 {
+		t.Errorf("parseNumstat() = %+v", stats)
+	}
+}
+
+func TestHandleWorktreeStatus(t *testing.T) {
+	wm, _, worktree := newMergeTestRepo(t)
+	writeFile(t, filepath.Join(worktree.Path, "new.txt"), "new\n")
+	server := &mcpServer{worktreeManager: wm}
+
+	rec := httptest.NewRecorder()
+	server.handleWorktreeDetail(rec, httptest.NewRequest(http.MethodGet, "/worktrees/"+worktree.ID+"/status", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"path":"new.txt"`) || rec.Header().Get("ETag") == "" {
+		t.Fatalf("响应 = %d\n%s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	server.handleWorktreeDetail(rec, httptest.NewRequest(http.MethodGet, "/worktrees/missing/status", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("worktree 不存在时状态码 = %d", rec.Code)
+	}
+}
@@ -0,0 +1,134 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/wsl"
+)
+
+// distroSharePrefix 服务器访问 WSL 发行版文件系统的共享路径前缀
+const distroSharePrefix = `\\wsl$\`
+
+// prepareStorage 按 mcp.worktree_storage 确定worktree基础目录
+// distro 模式下通过 WSL 桥接在发行版中创建目录并解析 ~，基础目录改为该目录在 \\wsl$ 共享中的路径
+func (wm *worktreeManager) prepareStorage() error {
+	storage := wm.config.WorktreeStorage
+	if storage.Mode != config.WorktreeStorageDistro {
+		return nil
+	}
+	if wm.wslBridge == nil {
+		return apperrors.New(apperrors.ErrWSLNotFound, "worktree_storage.mode 为 distro 时需要 WSL")
+	}
+
+	distro := storage.Distro
+	if distro == "" {
+		defaultDistro, err := wm.wslBridge.GetDefaultDistro()
+		if err != nil {
+			return apperrors.Wrap(err, apperrors.ErrDistroNotFound, "获取默认 WSL 发行版失败")
+		}
+		distro = defaultDistro
+	}
+	dir := storage.Dir
+	if dir == "" {
+		dir = "~/.auto-claude-code/worktrees"
+	}
+
+	quoted := distroShellPath(dir)
+	output, err := wm.wslBridge.ExecuteCommandWithOutput(distro, "mkdir -p "+quoted+" && cd "+quoted+" && pwd -P")
+	if err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrWSLCommandFailed, "在发行版 %s 中创建worktree目录失败", distro)
+	}
+	resolved := strings.TrimSpace(output)
+	if !strings.HasPrefix(resolved, "/") {
+		return apperrors.Newf(apperrors.ErrWSLCommandFailed, "无法解析发行版 %s 中的worktree目录: %s", distro, dir)
+	}
+
+	wm.distro = distro
+	wm.distroDir = resolved
+	wm.baseDir = distroHostPath(distro, resolved)
+	return nil
+}
+
+// distroShellPath 引用发行版中的路径，开头的 ~ 保留给 shell 展开
+func distroShellPath(dir string) string {
+	if dir == "~" {
+		return dir
+	}
+	if rest, ok := strings.CutPrefix(dir, "~/"); ok {
+		return "~/" + wsl.ShellQuote(rest)
+	}
+	return wsl.ShellQuote(dir)
+}
+
+// distroHostPath 发行版中的绝对路径在服务器上经 \\wsl$ 共享访问的路径
+func distroHostPath(distro, linuxPath string) string {
+	return distroSharePrefix + distro + strings.ReplaceAll(linuxPath, "/", `\`)
+}
+
+// worktreeWSLPath worktree目录在 WSL 中的路径，distro 模式下为发行版中的路径，无法转换时为空
+func (wm *worktreeManager) worktreeWSLPath(worktreePath string) string {
+	if wm.distroDir != "" {
+		return path.Join(wm.distroDir, filepath.Base(worktreePath))
+	}
+	if wm.pathConverter == nil {
+		return ""
+	}
+	absPath, err := filepath.Abs(worktreePath)
+	if err != nil {
+		absPath = worktreePath
+	}
+	wslPath, err := wm.pathConverter.ConvertToWSL(absPath)
+	if err != nil {
+		wm.logger.Debug("无法转换worktree的 WSL 路径",
+			zap.String("worktreePath", worktreePath),
+			zap.Error(err))
+		return ""
+	}
+	return wslPath
+}
+
+// removeDir 删除基础目录下的目录，distro 模式下在发行版中删除，避免经 \\wsl$ 共享逐个删除文件
+// 发行版中删除失败时改为直接删除
+func (wm *worktreeManager) removeDir(ctx context.Context, dir string) error {
+	if wm.distroDir != "" {
+		if rel, err := filepath.Rel(wm.baseDir, dir); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			target := path.Join(wm.distroDir, filepath.ToSlash(rel))
+			result, err := wm.wslBridge.RunCommand(ctx, wm.distro, "/", "rm -rf -- "+wsl.ShellQuote(target), nil)
+			if err == nil && result.ExitCode == 0 {
+				return nil
+			}
+			wm.logger.Warn("在发行版中删除目录失败",
+				zap.String("distro", wm.distro),
+				zap.String("dir", target),
+				zap.Error(err))
+		}
+	}
+	return os.RemoveAll(dir)
+}
+
+// taskDistro 任务运行的发行版，worktree位于发行版中时任务必须在该发行版中运行
+func taskDistro(req *TaskRequest, worktree *WorktreeInfo) (string, error) {
+	if worktree.Distro == "" {
+		return req.Distro, nil
+	}
+	if req.Distro != "" && req.Distro != worktree.Distro {
+		return "", apperrors.Newf(apperrors.ErrInvalidRequest, "worktree位于发行版 %s 中，任务不能在发行版 %s 中运行", worktree.Distro, req.Distro)
+	}
+	return worktree.Distro, nil
+}
+
+// taskWorkDir Claude Code 的工作目录，worktree位于发行版中时直接在worktree中运行，否则为项目的 WSL 路径
+func taskWorkDir(worktree *WorktreeInfo, projectWSLPath string) string {
+	if worktree.Distro != "" && worktree.WSLPath != "" {
+		return worktree.WSLPath
+	}
+	return projectWSLPath
+}
@@ -0,0 +1,137 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/converter"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+	"auto-claude-code/internal/wsl"
+)
+
+// storageBridge 模拟发行版 Ubuntu，主目录为 /home/dev，记录运行的命令
+type storageBridge struct {
+	wsl.WSLBridge
+	commands []string
+	exitCode int
+}
+
+func (b *storageBridge) GetDefaultDistro() (string, error) {
+	return "Ubuntu", nil
+}
+
+func (b *storageBridge) ExecuteCommandWithOutput(distro, command string) (string, error) {
+	b.commands = append(b.commands, distro+": "+command)
+	return "/home/dev/.auto-claude-code/worktrees\n", nil
+}
+
+func (b *storageBridge) RunCommand(ctx context.Context, distro, workingDir, command string, output *wsl.OutputOptions) (*wsl.ExecResult, error) {
+	b.commands = append(b.commands, distro+": "+command)
+	return &wsl.ExecResult{ExitCode: b.exitCode}, nil
+}
+
+func (b *storageBridge) PathConverter() converter.PathConverter {
+	return converter.NewPathConverter()
+}
+
+func TestPrepareStorageDistro(t *testing.T) {
+	bridge := &storageBridge{}
+	cfg := &config.MCPConfig{WorktreeStorage: config.WorktreeStorageConfig{Mode: config.WorktreeStorageDistro}}
+	wm := NewWorktreeManager(cfg, logger.FromZap(zap.NewNop()), bridge).(*worktreeManager)
+	if err := wm.prepareStorage(); err != nil {
+		t.Fatalf("prepareStorage() error = %v", err)
+	}
+
+	want := "Ubuntu: mkdir -p ~/'.auto-claude-code/worktrees' && cd ~/'.auto-claude-code/worktrees' && pwd -P"
+	if len(bridge.commands) != 1 || bridge.commands[0] != want {
+		t.Errorf("运行的命令 = %v, want %q", bridge.commands, want)
+	}
+	if want := `\\wsl$\Ubuntu\home\dev\.auto-claude-code\worktrees`; wm.baseDir != want {
+		t.Errorf("baseDir = %q, want %q", wm.baseDir, want)
+	}
+	if got := wm.worktreeWSLPath(filepath.Join(wm.baseDir, "wt_1")); got != "/home/dev/.auto-claude-code/worktrees/wt_1" {
+		t.Errorf("worktreeWSLPath() = %q", got)
+	}
+}
+
+func TestPrepareStorageDistroWithoutWSL(t *testing.T) {
+	cfg := &config.MCPConfig{WorktreeStorage: config.WorktreeStorageConfig{Mode: config.WorktreeStorageDistro}}
+	wm := NewWorktreeManager(cfg, logger.FromZap(zap.NewNop()), nil).(*worktreeManager)
+	if err := wm.prepareStorage(); !apperrors.IsCode(err, apperrors.ErrWSLNotFound) {
+		t.Errorf("prepareStorage() error = %v", err)
+	}
+}
+
+func TestRemoveDirInDistro(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		exitCode int
+	}{
+		{"在发行版中删除", 0},
+		{"失败时直接删除", 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			bridge := &storageBridge{exitCode: tt.exitCode}
+			wm := &worktreeManager{
+				logger:    logger.FromZap(zap.NewNop()),
+				baseDir:   t.TempDir(),
+				wslBridge: bridge,
+				distro:    "Ubuntu",
+				distroDir: "/home/dev/worktrees",
+			}
+			dir := filepath.Join(wm.baseDir, "wt_1")
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			writeFile(t, filepath.Join(dir, "a.txt"), "a")
+
+			if err := wm.removeDir(context.Background(), dir); err != nil {
+				t.Fatalf("removeDir() error = %v", err)
+			}
+			if want := "Ubuntu: rm -rf -- '/home/dev/worktrees/wt_1'"; len(bridge.commands) != 1 || bridge.commands[0] != want {
+				t.Errorf("运行的命令 = %v, want %q", bridge.commands, want)
+			}
+			_, err := os.Stat(dir)
+			if tt.exitCode != 0 && !os.IsNotExist(err) {
+				t.Errorf("发行版中删除失败后目录仍存在: %v", err)
+			}
+		})
+	}
+}
+
+func TestTaskDistro(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested string
+		worktree  string
+		want      string
+		wantErr   bool
+	}{
+		{"本地worktree使用请求的发行版", "Debian", "", "Debian", false},
+		{"未指定时使用worktree所在的发行版", "", "Ubuntu", "Ubuntu", false},
+		{"与worktree所在的发行版一致", "Ubuntu", "Ubuntu", "Ubuntu", false},
+		{"与worktree所在的发行版不同", "Debian", "Ubuntu", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worktree := &WorktreeInfo{Distro: tt.worktree, WSLPath: "/home/dev/worktrees/wt_1"}
+			got, err := taskDistro(&TaskRequest{Distro: tt.requested}, worktree)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("taskDistro() = %q, %v, want %q", got, err, tt.want)
+			}
+			wantDir := "/mnt/c/app"
+			if tt.worktree != "" {
+				wantDir = worktree.WSLPath
+			}
+			if dir := taskWorkDir(worktree, "/mnt/c/app"); dir != wantDir {
+				t.Errorf("taskWorkDir() = %q, want %q", dir, wantDir)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/tracing"
+)
+
+// hasSubmodules 目录中是否有 .gitmodules
+func hasSubmodules(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".gitmodules"))
+	return err == nil
+}
+
+// initSubmodules 按 mcp.worktree_submodules 初始化worktree中的子模块，未启用或没有 .gitmodules 时不做任何事
+func (wm *worktreeManager) initSubmodules(ctx context.Context, worktreePath string, progress func(float64, string)) error {
+	cfg := wm.config.WorktreeSubmodules
+	if !cfg.Enabled || !hasSubmodules(worktreePath) {
+		return nil
+	}
+	_, span := tracing.Start(ctx, "git.submodule_update", tracing.String("worktree.path", worktreePath))
+	defer span.End()
+
+	args := []string{"submodule", "update", "--init", "--progress"}
+	if cfg.Recursive {
+		args = append(args, "--recursive")
+	}
+	if cfg.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(cfg.Depth))
+	}
+	if cfg.Jobs > 0 {
+		args = append(args, "--jobs", strconv.Itoa(cfg.Jobs))
+	}
+	if _, err := wm.gitWithProgress(ctx, worktreePath, "正在初始化子模块", progress, args...); err != nil {
+		span.RecordError(err)
+		return apperrors.Wrap(err, apperrors.ErrGitOperation, "初始化子模块失败")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// newSubmoduleTestRepo 在项目中添加指向本地仓库的子模块 lib
+func newSubmoduleTestRepo(t *testing.T) (*worktreeManager, string, string) {
+	t.Helper()
+	// 测试使用本地路径的子模块，新版本 git 默认禁止 file 协议
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+
+	wm, project, _ := newMergeTestRepo(t)
+	lib := t.TempDir()
+	runGit(t, lib, "init", "-q", "-b", "main")
+	writeFile(t, filepath.Join(lib, "lib.go"), "package lib\n")
+	runGit(t, lib, "add", "-A")
+	runGit(t, lib, "-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "-m", "lib")
+
+	runGit(t, project, "submodule", "add", "-q", lib, "lib")
+	runGit(t, project, "commit", "-q", "-m", "add lib")
+	wm.config.WorktreeSubmodules.Enabled = true
+	wm.config.WorktreeSubmodules.Recursive = true
+	return wm, project, lib
+}
+
+func TestCreateWorktreeSubmodules(t *testing.T) {
+	ctx := context.Background()
+	wm, project, _ := newSubmoduleTestRepo(t)
+
+	var messages []string
+	worktree, err := wm.CreateWorktree(ctx, project, CreateWorktreeOptions{
+		Progress: func(fraction float64, message string) { messages = append(messages, message) },
+	})
+	if err != nil {
+		t.Fatalf("CreateWorktree() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(worktree.Path, "lib", "lib.go")); err != nil {
+		t.Errorf("子模块未初始化: %v", err)
+	}
+	if len(messages) < 2 || messages[0] != "正在检出文件" || !strings.HasPrefix(messages[len(messages)-1], "正在初始化子模块") {
+		t.Errorf("进度消息 = %v", messages)
+	}
+
+	// 含子模块的worktree同样可以删除
+	wm.worktrees[worktree.ID].Status = WorktreeStateIdle
+	if err := wm.DeleteWorktree(ctx, worktree.ID); err != nil {
+		t.Fatalf("DeleteWorktree() error = %v", err)
+	}
+	if _, err := os.Stat(worktree.Path); !os.IsNotExist(err) {
+		t.Errorf("删除后worktree目录仍存在: %v", err)
+	}
+	if list := runGit(t, project, "worktree", "list", "--porcelain"); strings.Contains(list, worktree.Path) {
+		t.Errorf("项目中仍有worktree记录:\n%s", list)
+	}
+}
+
+func TestCreateWorktreeSubmoduleFailure(t *testing.T) {
+	ctx := context.Background()
+	wm, project, lib := newSubmoduleTestRepo(t)
+	if err := os.RemoveAll(lib); err != nil {
+		t.Fatal(err)
+	}
+
+	before := len(wm.worktrees)
+	branches := runGit(t, project, "branch", "--list")
+	_, err := wm.CreateWorktree(ctx, project, CreateWorktreeOptions{})
+	if !apperrors.IsCode(err, apperrors.ErrWorktreeFailed) {
+		t.Fatalf("子模块无法拉取时 CreateWorktree() error = %v", err)
+	}
+	if len(wm.worktrees) != before {
+		t.Errorf("失败后仍登记了worktree")
+	}
+	if after := runGit(t, project, "branch", "--list"); after != branches {
+		t.Errorf("失败后任务分支未删除:\n%s", after)
+	}
+}
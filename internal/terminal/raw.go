@@ -0,0 +1,30 @@
+package terminal
+
+import (
+	"context"
+	"time"
+)
+
+// resizePollInterval 不支持尺寸变化信号的平台上检查终端尺寸的间隔
+const resizePollInterval = 500 * time.Millisecond
+
+// pollResize 定期检查终端尺寸，变化时调用 onResize，ctx 取消时返回
+func pollResize(ctx context.Context, fd int, onResize func(cols, rows int)) {
+	lastCols, lastRows, _ := Size(fd)
+	ticker := time.NewTicker(resizePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cols, rows, err := Size(fd)
+			if err != nil || (cols == lastCols && rows == lastRows) {
+				continue
+			}
+			lastCols, lastRows = cols, rows
+			onResize(cols, rows)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+//go:build !linux && !darwin && !windows
+
+package terminal
+
+import (
+	"context"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// State 进入原始模式前的终端设置
+type State struct{}
+
+// IsTerminal 当前平台不支持终端模式切换，始终返回 false
+func IsTerminal(fd int) bool {
+	return false
+}
+
+// MakeRaw 当前平台不支持原始模式
+func MakeRaw(fd int) (*State, error) {
+	return nil, apperrors.New(apperrors.ErrInternal, "当前平台不支持终端原始模式")
+}
+
+// Restore 当前平台不支持原始模式
+func Restore(fd int, state *State) error {
+	return nil
+}
+
+// EnableVirtualTerminal 当前平台无需设置
+func EnableVirtualTerminal(fd int) error {
+	return nil
+}
+
+// Size 当前平台不支持获取终端尺寸
+func Size(fd int) (cols, rows int, err error) {
+	return 0, 0, apperrors.New(apperrors.ErrInternal, "当前平台不支持获取终端尺寸")
+}
+
+// NotifyResize 定期检查终端尺寸，直到 ctx 取消
+func NotifyResize(ctx context.Context, fd int, onResize func(cols, rows int)) {
+	pollResize(ctx, fd, onResize)
+}
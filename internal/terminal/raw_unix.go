@@ -0,0 +1,82 @@
+//go:build linux || darwin
+
+package terminal
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"golang.org/x/sys/unix"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// State 进入原始模式前的终端设置，用于恢复
+type State struct {
+	termios unix.Termios
+}
+
+// IsTerminal fd 是否为终端
+func IsTerminal(fd int) bool {
+	_, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	return err == nil
+}
+
+// MakeRaw 将终端切换为原始模式，按键不回显、不按行缓冲，Ctrl+C 等按键作为输入传递
+func MakeRaw(fd int) (*State, error) {
+	termios, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrInternal, "读取终端设置失败")
+	}
+	state := &State{termios: *termios}
+
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, termios); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrInternal, "设置终端原始模式失败")
+	}
+	return state, nil
+}
+
+// Restore 恢复 MakeRaw 之前的终端设置
+func Restore(fd int, state *State) error {
+	return unix.IoctlSetTermios(fd, ioctlSetTermios, &state.termios)
+}
+
+// EnableVirtualTerminal 终端本身解释 VT 控制序列，无需设置
+func EnableVirtualTerminal(fd int) error {
+	return nil
+}
+
+// Size 获取终端的列数和行数
+func Size(fd int) (cols, rows int, err error) {
+	ws, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, 0, apperrors.Wrap(err, apperrors.ErrInternal, "获取终端尺寸失败")
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
+
+// NotifyResize 在终端尺寸变化时调用 onResize，直到 ctx 取消
+func NotifyResize(ctx context.Context, fd int, onResize func(cols, rows int)) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, unix.SIGWINCH)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			if cols, rows, err := Size(fd); err == nil {
+				onResize(cols, rows)
+			}
+		}
+	}
+}
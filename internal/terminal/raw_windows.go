@@ -0,0 +1,63 @@
+package terminal
+
+import (
+	"context"
+
+	"golang.org/x/sys/windows"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// State 进入原始模式前的控制台模式，用于恢复
+type State struct {
+	mode uint32
+}
+
+// IsTerminal fd 是否为控制台
+func IsTerminal(fd int) bool {
+	var mode uint32
+	return windows.GetConsoleMode(windows.Handle(fd), &mode) == nil
+}
+
+// MakeRaw 将控制台输入切换为原始模式，按键以 VT 序列传递，不回显、不按行缓冲
+func MakeRaw(fd int) (*State, error) {
+	var mode uint32
+	if err := windows.GetConsoleMode(windows.Handle(fd), &mode); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrInternal, "读取控制台模式失败")
+	}
+	raw := mode &^ (windows.ENABLE_ECHO_INPUT | windows.ENABLE_PROCESSED_INPUT | windows.ENABLE_LINE_INPUT)
+	raw |= windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+	if err := windows.SetConsoleMode(windows.Handle(fd), raw); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrInternal, "设置控制台原始模式失败")
+	}
+	return &State{mode: mode}, nil
+}
+
+// Restore 恢复 MakeRaw 之前的控制台模式
+func Restore(fd int, state *State) error {
+	return windows.SetConsoleMode(windows.Handle(fd), state.mode)
+}
+
+// EnableVirtualTerminal 让控制台输出解释 VT 控制序列，远程终端的输出才能正确显示
+func EnableVirtualTerminal(fd int) error {
+	var mode uint32
+	if err := windows.GetConsoleMode(windows.Handle(fd), &mode); err != nil {
+		return err
+	}
+	return windows.SetConsoleMode(windows.Handle(fd), mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+}
+
+// Size 获取控制台窗口的列数和行数
+func Size(fd int) (cols, rows int, err error) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(fd), &info); err != nil {
+		return 0, 0, apperrors.Wrap(err, apperrors.ErrInternal, "获取控制台尺寸失败")
+	}
+	return int(info.Window.Right-info.Window.Left) + 1, int(info.Window.Bottom-info.Window.Top) + 1, nil
+}
+
+// NotifyResize 在控制台尺寸变化时调用 onResize，直到 ctx 取消
+// 控制台没有尺寸变化信号，定期检查
+func NotifyResize(ctx context.Context, fd int, onResize func(cols, rows int)) {
+	pollResize(ctx, fd, onResize)
+}
@@ -0,0 +1,66 @@
+package terminal
+
+import (
+	"os/exec"
+	"strings"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// windowsTerminalExecutable Windows Terminal 命令行程序
+const windowsTerminalExecutable = "wt.exe"
+
+// LaunchOptions Windows Terminal 启动选项
+type LaunchOptions struct {
+	Profile    string   // Windows Terminal 配置文件名称，留空使用默认配置
+	SplitPane  bool     // 在当前窗口中拆分窗格，而不是新建标签页
+	WorkingDir string   // 标签页的 Windows 工作目录
+	Title      string   // 标签页标题
+	Command    []string // 在标签页中执行的命令及参数
+}
+
+// BuildArgs 构建 wt.exe 参数
+// -w 0 表示在最近使用的窗口中打开，避免每次都创建新窗口
+func BuildArgs(opts LaunchOptions) []string {
+	action := "new-tab"
+	if opts.SplitPane {
+		action = "split-pane"
+	}
+
+	args := []string{"-w", "0", action}
+	if opts.Profile != "" {
+		args = append(args, "-p", opts.Profile)
+	}
+	if opts.WorkingDir != "" {
+		args = append(args, "-d", opts.WorkingDir)
+	}
+	if opts.Title != "" {
+		// 禁止应用程序修改标题，保持显示项目名称
+		args = append(args, "--title", opts.Title, "--suppressApplicationTitle")
+	}
+
+	for _, arg := range opts.Command {
+		args = append(args, escapeArg(arg))
+	}
+	return args
+}
+
+// Launch 在 Windows Terminal 中启动命令，不等待命令结束
+// wt.exe 会把请求转交给终端进程后立即退出
+func Launch(opts LaunchOptions) error {
+	wtPath, err := exec.LookPath(windowsTerminalExecutable)
+	if err != nil {
+		return apperrors.Wrap(err, apperrors.ErrTerminalLaunch, "未找到 Windows Terminal (wt.exe)").
+			WithDetails("请从 Microsoft Store 安装 Windows Terminal，或不使用 --terminal 参数")
+	}
+
+	if err := exec.Command(wtPath, BuildArgs(opts)...).Run(); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrTerminalLaunch, "启动 Windows Terminal 失败")
+	}
+	return nil
+}
+
+// escapeArg 转义 wt.exe 命令中的分号，分号在 wt.exe 中用于分隔子命令
+func escapeArg(arg string) string {
+	return strings.ReplaceAll(arg, ";", `\;`)
+}
@@ -0,0 +1,44 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     LaunchOptions
+		expected []string
+	}{
+		{
+			name: "新标签页",
+			opts: LaunchOptions{
+				Profile:    "Ubuntu",
+				WorkingDir: `C:\work\app`,
+				Title:      "app",
+				Command:    []string{`C:\bin\auto-claude-code.exe`, "--dir", `C:\work\app`},
+			},
+			expected: []string{"-w", "0", "new-tab", "-p", "Ubuntu", "-d", `C:\work\app`,
+				"--title", "app", "--suppressApplicationTitle",
+				`C:\bin\auto-claude-code.exe`, "--dir", `C:\work\app`},
+		},
+		{
+			name: "拆分窗格并转义分号",
+			opts: LaunchOptions{
+				SplitPane: true,
+				Command:   []string{"acc", "--", "-p", "a;b"},
+			},
+			expected: []string{"-w", "0", "split-pane", "acc", "--", "-p", `a\;b`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := BuildArgs(tt.opts)
+			if strings.Join(result, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("期望 %v，但得到 %v", tt.expected, result)
+			}
+		})
+	}
+}
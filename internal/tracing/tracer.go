@@ -0,0 +1,314 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/logger"
+)
+
+// instrumentationScope 导出时使用的 instrumentation scope 名称
+const instrumentationScope = "auto-claude-code"
+
+// globalTracer 全局追踪器，未启用追踪时为 nil
+var globalTracer atomic.Pointer[Tracer]
+
+// SetTracer 设置全局追踪器，传入 nil 关闭追踪
+func SetTracer(t *Tracer) {
+	globalTracer.Store(t)
+}
+
+// getTracer 获取全局追踪器
+func getTracer() *Tracer {
+	return globalTracer.Load()
+}
+
+// Tracer 将 span 批量导出到 OTLP/HTTP（JSON 编码）接收端
+type Tracer struct {
+	config     config.TracingConfig
+	logger     logger.Logger
+	httpClient *http.Client
+	resource   []otlpKeyValue
+	interval   time.Duration
+
+	mu      sync.Mutex
+	pending []*Span
+	dropped int
+
+	flushCh chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewTracer 创建追踪器并启动后台导出
+func NewTracer(cfg config.TracingConfig, log logger.Logger) (*Tracer, error) {
+	if cfg.Endpoint == "" {
+		return nil, apperrors.New(apperrors.ErrConfigInvalid, "未配置 tracing.endpoint")
+	}
+
+	interval, err := time.ParseDuration(cfg.ExportInterval)
+	if err != nil || interval <= 0 {
+		interval = 5 * time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 512
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "auto-claude-code"
+	}
+
+	t := &Tracer{
+		config:     cfg,
+		logger:     log,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		resource: []otlpKeyValue{
+			keyValue(String("service.name", cfg.ServiceName)),
+		},
+		interval: interval,
+		flushCh:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+
+	t.wg.Add(1)
+	go t.exportLoop()
+
+	log.Info("已启用 OpenTelemetry 追踪",
+		zap.String("endpoint", cfg.Endpoint),
+		zap.String("service", cfg.ServiceName),
+		zap.Float64("sampleRatio", cfg.SampleRatio))
+
+	return t, nil
+}
+
+// Shutdown 停止后台导出并导出剩余的 span
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	close(t.done)
+
+	finished := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sample 按采样率决定根 span 是否采样
+func (t *Tracer) sample() bool {
+	ratio := t.config.SampleRatio
+	return ratio >= 1 || (ratio > 0 && rand.Float64() < ratio)
+}
+
+// enqueue 加入待导出队列，积压超过4个批次时丢弃
+func (t *Tracer) enqueue(span *Span) {
+	t.mu.Lock()
+	if len(t.pending) >= t.config.BatchSize*4 {
+		t.dropped++
+		t.mu.Unlock()
+		return
+	}
+	t.pending = append(t.pending, span)
+	full := len(t.pending) >= t.config.BatchSize
+	t.mu.Unlock()
+
+	if full {
+		select {
+		case t.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// exportLoop 定时或批次已满时导出
+func (t *Tracer) exportLoop() {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.flush()
+		case <-t.flushCh:
+			t.flush()
+		case <-t.done:
+			t.flush()
+			return
+		}
+	}
+}
+
+// flush 导出全部待导出的 span
+func (t *Tracer) flush() {
+	for {
+		t.mu.Lock()
+		if len(t.pending) == 0 {
+			dropped := t.dropped
+			t.dropped = 0
+			t.mu.Unlock()
+			if dropped > 0 {
+				t.logger.Warn("追踪数据积压，已丢弃部分 span", zap.Int("dropped", dropped))
+			}
+			return
+		}
+		n := len(t.pending)
+		if n > t.config.BatchSize {
+			n = t.config.BatchSize
+		}
+		batch := t.pending[:n]
+		t.pending = append([]*Span(nil), t.pending[n:]...)
+		t.mu.Unlock()
+
+		if err := t.export(batch); err != nil {
+			t.logger.Warn("导出追踪数据失败", zap.Int("spans", len(batch)), zap.Error(err))
+			return
+		}
+	}
+}
+
+// export 以 OTLP/HTTP JSON 格式发送一批 span
+func (t *Tracer) export(batch []*Span) error {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, span := range batch {
+		spans = append(spans, span.toOTLP())
+	}
+
+	body, err := json.Marshal(otlpExportRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{Attributes: t.resource},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: instrumentationScope},
+				Spans: spans,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("OTLP 接收端返回 HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// OTLP/HTTP JSON 编码结构（opentelemetry-proto 的 JSON 映射）
+
+type otlpExportRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+// keyValue 转换属性，整数按 OTLP JSON 映射编码为字符串
+func keyValue(attr Attribute) otlpKeyValue {
+	var value map[string]interface{}
+	switch v := attr.Value.(type) {
+	case string:
+		value = map[string]interface{}{"stringValue": v}
+	case int64:
+		value = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+	case bool:
+		value = map[string]interface{}{"boolValue": v}
+	case float64:
+		value = map[string]interface{}{"doubleValue": v}
+	default:
+		value = map[string]interface{}{"stringValue": fmt.Sprint(v)}
+	}
+	return otlpKeyValue{Key: attr.Key, Value: value}
+}
+
+// toOTLP 转换为 OTLP span
+func (s *Span) toOTLP() otlpSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	span := otlpSpan{
+		TraceID:           hex.EncodeToString(s.sc.TraceID[:]),
+		SpanID:            hex.EncodeToString(s.sc.SpanID[:]),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		Status:            otlpStatus{Code: s.statusCode, Message: s.statusMsg},
+	}
+	if s.parentID != [8]byte{} {
+		span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+	for _, attr := range s.attrs {
+		span.Attributes = append(span.Attributes, keyValue(attr))
+	}
+	return span
+}
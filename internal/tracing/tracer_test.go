@@ -0,0 +1,183 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/logger"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		valid   bool
+		sampled bool
+	}{
+		{"采样", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"未采样", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"全零 trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"非法版本", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"长度错误", "00-4bf92f35-00f067aa0ba902b7-01", false, false},
+		{"非十六进制", "00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"空值", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := ParseTraceParent(tt.value)
+			if ok != tt.valid {
+				t.Fatalf("ParseTraceParent(%q) ok = %v, want %v", tt.value, ok, tt.valid)
+			}
+			if !ok {
+				return
+			}
+			if sc.Sampled != tt.sampled {
+				t.Errorf("Sampled = %v, want %v", sc.Sampled, tt.sampled)
+			}
+			if got := sc.TraceParent(); got != tt.value {
+				t.Errorf("TraceParent() = %q, want %q", got, tt.value)
+			}
+		})
+	}
+}
+
+func TestStartWithoutTracer(t *testing.T) {
+	SetTracer(nil)
+
+	ctx, span := Start(context.Background(), "noop")
+	if span != nil {
+		t.Fatal("未启用追踪时应返回 nil span")
+	}
+	if SpanFromContext(ctx) != nil {
+		t.Error("未启用追踪时上下文中不应有 span")
+	}
+
+	// nil span 的方法均为空操作
+	span.SetAttributes(String("k", "v"))
+	span.RecordError(errors.New("boom"))
+	span.End()
+}
+
+func TestTracerExport(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []otlpSpan
+		headers  http.Header
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req otlpExportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		headers = r.Header.Clone()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				received = append(received, ss.Spans...)
+			}
+		}
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	log, err := logger.CreateLoggerFromConfig("info", false, "")
+	if err != nil {
+		t.Fatalf("创建日志器失败: %v", err)
+	}
+
+	tracer, err := NewTracer(config.TracingConfig{
+		Enabled:        true,
+		Endpoint:       receiver.URL,
+		SampleRatio:    1,
+		Headers:        map[string]string{"X-Api-Key": "secret"},
+		BatchSize:      10,
+		ExportInterval: "1h",
+	}, log)
+	if err != nil {
+		t.Fatalf("创建追踪器失败: %v", err)
+	}
+	SetTracer(tracer)
+	defer SetTracer(nil)
+
+	// 远程父级 -> 根 span -> 子 span
+	remote, _ := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithRemoteSpanContext(context.Background(), remote)
+
+	ctx, parent := StartKind(ctx, SpanKindServer, "task.submit", String("task.id", "t1"))
+	_, child := Start(ctx, "wsl.run_command", Int("exit_code", 1))
+	child.RecordError(errors.New("命令失败"))
+	child.End()
+	parent.End()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracer.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("Shutdown 失败: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(received) != 2 {
+		t.Fatalf("导出 span 数量 = %d, want 2", len(received))
+	}
+	if headers.Get("X-Api-Key") != "secret" {
+		t.Error("导出请求未携带配置的HTTP头")
+	}
+
+	spans := make(map[string]otlpSpan)
+	for _, span := range received {
+		spans[span.Name] = span
+	}
+	submit, run := spans["task.submit"], spans["wsl.run_command"]
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	if submit.TraceID != traceID || run.TraceID != traceID {
+		t.Errorf("trace id = %s/%s, want %s", submit.TraceID, run.TraceID, traceID)
+	}
+	if submit.ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("根 span 父级 = %s, want 远程 span", submit.ParentSpanID)
+	}
+	if run.ParentSpanID != submit.SpanID {
+		t.Errorf("子 span 父级 = %s, want %s", run.ParentSpanID, submit.SpanID)
+	}
+	if submit.Kind != SpanKindServer || submit.Status.Code != statusOK {
+		t.Errorf("根 span kind/status = %d/%d", submit.Kind, submit.Status.Code)
+	}
+	if run.Status.Code != statusError || run.Status.Message != "命令失败" {
+		t.Errorf("子 span status = %+v", run.Status)
+	}
+	if len(run.Attributes) != 1 || run.Attributes[0].Value["intValue"] != "1" {
+		t.Errorf("子 span 属性 = %+v", run.Attributes)
+	}
+}
+
+func TestTracerSampling(t *testing.T) {
+	tracer := &Tracer{config: config.TracingConfig{SampleRatio: 0, BatchSize: 10}}
+	SetTracer(tracer)
+	defer SetTracer(nil)
+
+	ctx, span := Start(context.Background(), "root")
+	if span.SpanContext().Sampled {
+		t.Error("采样率为 0 时根 span 不应采样")
+	}
+	_, child := Start(ctx, "child")
+	if child.SpanContext().Sampled {
+		t.Error("子 span 应沿用父级的采样决定")
+	}
+	child.End()
+	span.End()
+
+	if len(tracer.pending) != 0 {
+		t.Errorf("未采样的 span 不应进入导出队列，pending = %d", len(tracer.pending))
+	}
+}
@@ -0,0 +1,440 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// 消息类型
+const (
+	OpText   = 1
+	OpBinary = 2
+	opClose  = 8
+	opPing   = 9
+	opPong   = 10
+	opCont   = 0
+)
+
+// 关闭代码
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+// MaxMessageSize 读取的单条消息上限
+const MaxMessageSize = 1 << 20
+
+// websocketGUID 计算 Sec-WebSocket-Accept 使用的固定 GUID
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// closeWriteTimeout 发送关闭帧的超时时间
+const closeWriteTimeout = 5 * time.Second
+
+// CloseError 对端发送的关闭帧
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+// Error 实现 error 接口
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket 已关闭: %d %s", e.Code, e.Reason)
+}
+
+// Conn WebSocket 连接，实现 RFC 6455 中文本/二进制消息、分片、ping/pong 和关闭握手，不支持扩展
+// 写操作可并发调用，读操作只能在一个 goroutine 中调用
+type Conn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	client bool // 客户端发送的帧必须加掩码
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+	closeSent bool
+}
+
+// IsUpgrade 请求是否要求升级为 WebSocket
+func IsUpgrade(r *http.Request) bool {
+	return headerContains(r.Header, "Connection", "upgrade") && headerContains(r.Header, "Upgrade", "websocket")
+}
+
+// Upgrade 完成服务端握手并接管连接，allowedOrigins 为同源之外允许跨站连接的来源（如 https://console.example.com）
+// 握手参数无效时返回 ErrInvalidRequest，来源不允许时返回 ErrForbidden，此时尚未写出任何响应，调用方可以正常返回错误
+func Upgrade(w http.ResponseWriter, r *http.Request, allowedOrigins []string) (*Conn, error) {
+	if r.Method != http.MethodGet {
+		return nil, apperrors.New(apperrors.ErrMethodNotAllowed, "WebSocket 握手只支持GET方法")
+	}
+	if !IsUpgrade(r) {
+		return nil, apperrors.New(apperrors.ErrInvalidRequest, "需要 WebSocket 升级请求")
+	}
+	if err := checkOrigin(r, allowedOrigins); err != nil {
+		return nil, err
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, apperrors.New(apperrors.ErrInvalidRequest, "只支持 WebSocket 协议版本 13")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, apperrors.New(apperrors.ErrInvalidRequest, "缺少 Sec-WebSocket-Key")
+	}
+
+	netConn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrInternal, "无法接管连接")
+	}
+
+	// 清除 HTTP 服务器设置的超时，连接的生命周期由调用方管理
+	netConn.SetDeadline(time.Time{})
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	if _, err := rw.WriteString(response); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return &Conn{conn: netConn, reader: rw.Reader}, nil
+}
+
+// checkOrigin 检查浏览器发起握手的页面来源，防止用户浏览器中打开的任意网页连接本机服务
+// 没有 Origin 头（非浏览器客户端）、与请求的 Host 相同或在 allowedOrigins 中时允许
+func checkOrigin(r *http.Request, allowedOrigins []string) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		// 旧版协议草案使用的头部
+		origin = r.Header.Get("Sec-WebSocket-Origin")
+	}
+	if origin == "" {
+		return nil
+	}
+
+	if u, err := url.Parse(origin); err == nil && u.Host != "" && strings.EqualFold(u.Host, r.Host) {
+		return nil
+	}
+	for _, allowed := range allowedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return nil
+		}
+	}
+	return apperrors.Newf(apperrors.ErrForbidden, "不允许的 WebSocket 来源: %s", origin)
+}
+
+// Dial 建立客户端连接，rawURL 可以使用 ws/wss 或 http/https 协议
+func Dial(ctx context.Context, rawURL string, header http.Header) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, &HandshakeError{Response: resp}
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		resp.Body.Close()
+		return nil, fmt.Errorf("WebSocket 握手响应无效")
+	}
+
+	// 101 响应的 Body 即为底层连接
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return nil, fmt.Errorf("WebSocket 握手响应不可写")
+	}
+	return &Conn{conn: &bodyConn{ReadWriteCloser: rwc}, reader: bufio.NewReader(rwc), client: true}, nil
+}
+
+// HandshakeError 服务器拒绝升级，Response 的 Body 由调用方读取并关闭
+type HandshakeError struct {
+	Response *http.Response
+}
+
+// Error 实现 error 接口
+func (e *HandshakeError) Error() string {
+	return fmt.Sprintf("WebSocket 握手失败: %s", e.Response.Status)
+}
+
+// WriteMessage 发送一条完整消息
+func (c *Conn) WriteMessage(op int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return net.ErrClosed
+	}
+	return c.writeFrame(op, data)
+}
+
+// WriteJSON 以文本消息发送 JSON
+func (c *Conn) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.WriteMessage(OpText, data)
+}
+
+// Ping 发送 ping 帧，用于保持经过代理的空闲连接
+func (c *Conn) Ping() error {
+	return c.WriteMessage(opPing, nil)
+}
+
+// ReadMessage 读取下一条数据消息，自动应答 ping 并合并分片
+// 对端关闭时返回 *CloseError
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	var (
+		messageOp int
+		message   []byte
+	)
+
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case opPing:
+			c.writeMu.Lock()
+			if !c.closeSent {
+				c.writeFrame(opPong, payload)
+			}
+			c.writeMu.Unlock()
+			continue
+		case opPong:
+			continue
+		case opClose:
+			closeErr := &CloseError{Code: CloseNormal}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+			}
+			c.Close(closeErr.Code, "")
+			return 0, nil, closeErr
+		case opCont:
+			if messageOp == 0 {
+				c.Close(CloseProtocolError, "意外的分片")
+				return 0, nil, fmt.Errorf("WebSocket 协议错误: 意外的分片")
+			}
+		case OpText, OpBinary:
+			if messageOp != 0 {
+				c.Close(CloseProtocolError, "分片未结束")
+				return 0, nil, fmt.Errorf("WebSocket 协议错误: 分片未结束")
+			}
+			messageOp = op
+		default:
+			c.Close(CloseProtocolError, "未知的帧类型")
+			return 0, nil, fmt.Errorf("WebSocket 协议错误: 未知的帧类型 %d", op)
+		}
+
+		if len(message)+len(payload) > MaxMessageSize {
+			c.Close(CloseMessageTooBig, "消息过大")
+			return 0, nil, fmt.Errorf("WebSocket 消息超过 %d 字节", MaxMessageSize)
+		}
+		message = append(message, payload...)
+		if fin {
+			return messageOp, message, nil
+		}
+	}
+}
+
+// Close 发送关闭帧并关闭底层连接，可重复调用
+func (c *Conn) Close(code int, reason string) error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.writeMu.Lock()
+		payload := make([]byte, 2, 2+len(reason))
+		binary.BigEndian.PutUint16(payload, uint16(code))
+		payload = append(payload, reason...)
+		if len(payload) > 125 {
+			payload = payload[:125]
+		}
+		c.conn.SetWriteDeadline(time.Now().Add(closeWriteTimeout))
+		c.writeFrame(opClose, payload)
+		c.closeSent = true
+		c.writeMu.Unlock()
+
+		err = c.conn.Close()
+	})
+	return err
+}
+
+// writeFrame 写出一个未分片的帧，调用方必须持有 writeMu
+func (c *Conn) writeFrame(op int, payload []byte) error {
+	header := make([]byte, 2, 14)
+	header[0] = 0x80 | byte(op)
+
+	length := len(payload)
+	switch {
+	case length <= 125:
+		header[1] = byte(length)
+	case length <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+
+	if c.client {
+		header[1] |= 0x80
+		var mask [4]byte
+		rand.Read(mask[:])
+		header = append(header, mask[:]...)
+		masked := make([]byte, length)
+		for i := range payload {
+			masked[i] = payload[i] ^ mask[i%4]
+		}
+		payload = masked
+	}
+
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readFrame 读取一个帧并去除掩码
+func (c *Conn) readFrame() (fin bool, op int, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.reader, head[:]); err != nil {
+		return
+	}
+
+	fin = head[0]&0x80 != 0
+	op = int(head[0] & 0x0F)
+	if head[0]&0x70 != 0 {
+		c.Close(CloseProtocolError, "不支持扩展")
+		return false, 0, nil, fmt.Errorf("WebSocket 协议错误: 设置了保留位")
+	}
+
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.reader, ext[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.reader, ext[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	// 客户端发送的帧必须加掩码，服务器发送的帧不能加掩码（RFC 6455 第 5.1 节）
+	if masked == c.client {
+		c.Close(CloseProtocolError, "帧掩码无效")
+		return false, 0, nil, fmt.Errorf("WebSocket 协议错误: 帧掩码无效")
+	}
+	// 控制帧不能分片，载荷不超过 125 字节（RFC 6455 第 5.5 节）
+	if op >= opClose && (!fin || length > 125) {
+		c.Close(CloseProtocolError, "控制帧无效")
+		return false, 0, nil, fmt.Errorf("WebSocket 协议错误: 控制帧分片或超过 125 字节")
+	}
+	if length > MaxMessageSize {
+		c.Close(CloseMessageTooBig, "消息过大")
+		return false, 0, nil, fmt.Errorf("WebSocket 帧超过 %d 字节", MaxMessageSize)
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.reader, mask[:]); err != nil {
+			return
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.reader, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, op, payload, nil
+}
+
+// acceptKey 根据客户端的 Sec-WebSocket-Key 计算 Sec-WebSocket-Accept
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerContains 逗号分隔的头部是否包含指定值（不区分大小写）
+func headerContains(header http.Header, name, value string) bool {
+	for _, v := range header.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// bodyConn 将客户端握手响应的 Body 适配为 net.Conn，只用于读写和关闭
+type bodyConn struct {
+	io.ReadWriteCloser
+}
+
+func (b *bodyConn) LocalAddr() net.Addr                { return nil }
+func (b *bodyConn) RemoteAddr() net.Addr               { return nil }
+func (b *bodyConn) SetDeadline(t time.Time) error      { return nil }
+func (b *bodyConn) SetReadDeadline(t time.Time) error  { return nil }
+func (b *bodyConn) SetWriteDeadline(t time.Time) error { return nil }
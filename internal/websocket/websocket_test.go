@@ -0,0 +1,215 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// newEchoServer 创建回显收到消息的测试服务器
+func newEchoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r, []string{"https://console.example.com"})
+		if err != nil {
+			http.Error(w, err.Error(), apperrors.HTTPStatus(err))
+			return
+		}
+		defer conn.Close(CloseNormal, "")
+
+		for {
+			op, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if string(data) == "bye" {
+				conn.Close(CloseNormal, "再见")
+				return
+			}
+			conn.WriteMessage(op, data)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestEcho(t *testing.T) {
+	ts := newEchoServer(t)
+
+	conn, err := Dial(context.Background(), "ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close(CloseNormal, "")
+
+	tests := []struct {
+		name string
+		op   int
+		data string
+	}{
+		{"短文本", OpText, "你好"},
+		{"16位长度", OpText, strings.Repeat("a", 1000)},
+		{"64位长度", OpBinary, strings.Repeat("b", 70000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := conn.Ping(); err != nil {
+				t.Fatalf("Ping() error = %v", err)
+			}
+			if err := conn.WriteMessage(tt.op, []byte(tt.data)); err != nil {
+				t.Fatalf("WriteMessage() error = %v", err)
+			}
+			op, data, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("ReadMessage() error = %v", err)
+			}
+			if op != tt.op || string(data) != tt.data {
+				t.Errorf("收到 op=%d len=%d", op, len(data))
+			}
+		})
+	}
+
+	conn.WriteMessage(OpText, []byte("bye"))
+	_, _, err = conn.ReadMessage()
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != CloseNormal || closeErr.Reason != "再见" {
+		t.Errorf("关闭错误 = %v", err)
+	}
+}
+
+func TestUpgradeRejected(t *testing.T) {
+	ts := newEchoServer(t)
+
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("普通请求状态码 = %d, want 400", resp.StatusCode)
+	}
+
+	forbidden := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer forbidden.Close()
+
+	_, err = Dial(context.Background(), forbidden.URL, nil)
+	var handshakeErr *HandshakeError
+	if !errors.As(err, &handshakeErr) || handshakeErr.Response.StatusCode != http.StatusForbidden {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	handshakeErr.Response.Body.Close()
+}
+
+func TestAcceptKey(t *testing.T) {
+	// RFC 6455 第 1.3 节的示例
+	if got := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("acceptKey() = %s", got)
+	}
+}
+
+func TestUpgradeOrigin(t *testing.T) {
+	ts := newEchoServer(t)
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   bool
+	}{
+		{"非浏览器客户端", nil, true},
+		{"同源页面", http.Header{"Origin": {"http://" + host}}, true},
+		{"允许的来源", http.Header{"Origin": {"https://console.example.com"}}, true},
+		{"其他网站", http.Header{"Origin": {"https://evil.example.com"}}, false},
+		{"同主机名不同端口", http.Header{"Origin": {"http://127.0.0.1:1"}}, false},
+		{"null 来源", http.Header{"Origin": {"null"}}, false},
+		{"旧版协议的来源头", http.Header{"Sec-WebSocket-Origin": {"https://evil.example.com"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := Dial(context.Background(), ts.URL, tt.header)
+			if tt.want {
+				if err != nil {
+					t.Fatalf("Dial() error = %v", err)
+				}
+				conn.Close(CloseNormal, "")
+				return
+			}
+			var handshakeErr *HandshakeError
+			if !errors.As(err, &handshakeErr) || handshakeErr.Response.StatusCode != http.StatusForbidden {
+				t.Fatalf("Dial() error = %v", err)
+			}
+			handshakeErr.Response.Body.Close()
+		})
+	}
+}
+
+// rawDial 完成握手并返回底层连接，用于发送不符合协议的帧
+func rawDial(t *testing.T, ts *httptest.Server) (net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("握手失败: %v", err)
+	}
+	return conn, reader
+}
+
+func TestReadFrameProtocolErrors(t *testing.T) {
+	ts := newEchoServer(t)
+
+	tests := []struct {
+		name  string
+		frame []byte
+	}{
+		{"未加掩码的客户端帧", []byte{0x81, 0x02, 'h', 'i'}},
+		{"超过 125 字节的控制帧", append([]byte{0x89, 0x80 | 126, 0x00, 126, 0, 0, 0, 0}, make([]byte, 126)...)},
+		{"分片的控制帧", []byte{0x09, 0x80, 0, 0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, reader := rawDial(t, ts)
+			if _, err := conn.Write(tt.frame); err != nil {
+				t.Fatal(err)
+			}
+
+			// 服务器以 1002 关闭连接
+			var head [4]byte
+			if _, err := io.ReadFull(reader, head[:]); err != nil {
+				t.Fatalf("读取关闭帧失败: %v", err)
+			}
+			if head[0] != 0x80|opClose || binary.BigEndian.Uint16(head[2:]) != CloseProtocolError {
+				t.Errorf("关闭帧 = % x", head)
+			}
+		})
+	}
+}
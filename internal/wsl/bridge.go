@@ -8,10 +8,15 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
+	"time"
 	"unicode"
 	"unicode/utf16"
 
+	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/converter"
 	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/tracing"
 
 	"go.uber.org/zap"
 )
@@ -33,45 +38,122 @@ type WSLBridge interface {
 	// ExecuteCommandWithOutput 在 WSL 中执行命令并返回输出
 	ExecuteCommandWithOutput(distro, command string) (string, error)
 
-	// StartClaudeCode 启动 Claude Code
-	StartClaudeCode(distro, workingDir string, args []string) error
+	// StartClaudeCode 启动 Claude Code，output 为 nil 时连接到当前终端
+	StartClaudeCode(distro, workingDir string, args []string, output *OutputOptions) error
+
+	// RunClaudeCode 运行 Claude Code 并捕获输出和退出码，opts 可为 nil，output 可额外接收实时输出
+	RunClaudeCode(ctx context.Context, distro, workingDir string, args []string, opts *RunOptions, output *OutputOptions) (*ExecResult, error)
+
+	// StartClaudeCodePTY 在伪终端中启动交互式 Claude Code，调用方负责读写终端并在结束后关闭
+	StartClaudeCodePTY(ctx context.Context, distro, workingDir string, args []string, opts *RunOptions, size PTYSize) (PTY, error)
+
+	// RunCommand 在指定目录中运行 shell 命令并捕获输出和退出码，ctx 取消时终止进程
+	RunCommand(ctx context.Context, distro, workingDir, command string, output *OutputOptions) (*ExecResult, error)
 
 	// CheckClaudeCode 检查 Claude Code 是否可用
 	CheckClaudeCode(distro string) error
+
+	// InstallClaudeCode 在 WSL 中安装或更新 Claude Code
+	InstallClaudeCode(distro, method string) error
+
+	// AuthenticateClaudeCode 在当前终端中运行 Claude Code 登录流程
+	AuthenticateClaudeCode(distro string) error
+
+	// GetResourceMetrics 采样发行版的资源使用情况
+	GetResourceMetrics(distro string) (*ResourceMetrics, error)
+
+	// CheckDistroHealth 在发行版内检查网络、时钟和磁盘状态
+	CheckDistroHealth(distro string) ([]HealthCheck, error)
+
+	// DiagnoseNode 诊断 Claude Code 依赖的 Node.js 运行时
+	DiagnoseNode(distro string) (*NodeDiagnostics, error)
+
+	// DetectGPU 检测发行版中的 WSLg 和 CUDA 支持
+	DetectGPU(distro string) (*GPUInfo, error)
+
+	// Backend 获取当前执行后端名称
+	Backend() string
+
+	// PathConverter 获取与执行后端匹配的路径转换器
+	PathConverter() converter.PathConverter
 }
 
+// RunOptions 任务运行选项
+type RunOptions struct {
+	// Limits 进程资源限制，为 nil 时不限制
+	Limits *config.ResourceLimits
+	// GPU 是否导出 GPU 加速所需的环境变量
+	GPU bool
+	// TaskID 所属任务，用于命名发行版内记录进程 PID 的文件
+	TaskID string
+}
+
+// ExecResult 命令执行结果
+type ExecResult struct {
+	ExitCode int           `json:"exitCode"`
+	Stdout   string        `json:"stdout"`
+	Stderr   string        `json:"stderr"`
+	Duration time.Duration `json:"duration"`
+
+	// OmittedBytes 超过 OutputOptions.MaxCaptureBytes 后 stdout 和 stderr 中间被省略的字节数
+	OmittedBytes int64 `json:"omittedBytes,omitempty"`
+}
+
+// Claude Code 安装方式
+const (
+	InstallMethodNPM    = "npm"
+	InstallMethodNative = "native"
+)
+
+const (
+	// claudeCodeNPMPackage Claude Code 的 npm 包名
+	claudeCodeNPMPackage = "@anthropic-ai/claude-code"
+	// claudeCodeInstallScript Claude Code 原生安装脚本地址
+	claudeCodeInstallScript = "https://claude.ai/install.sh"
+)
+
 // wslBridge WSL 桥接器实现
 type wslBridge struct {
-	logger *zap.Logger
+	config   *config.Config
+	logger   *zap.Logger
+	executor Executor
 }
 
 // NewWSLBridge 创建新的 WSL 桥接器
-func NewWSLBridge(logger *zap.Logger) WSLBridge {
+func NewWSLBridge(cfg *config.Config, logger *zap.Logger) WSLBridge {
+	if cfg == nil {
+		cfg = config.GetDefaultConfig()
+	}
+
 	return &wslBridge{
-		logger: logger,
+		config:   cfg,
+		logger:   logger,
+		executor: &wslExecutor{},
 	}
 }
 
-// CheckWSL 检查 WSL 环境是否可用
+// CheckWSL 检查执行后端是否可用
 func (wb *wslBridge) CheckWSL() error {
-	wb.logger.Debug("检查 WSL 环境")
+	wb.logger.Debug("检查执行环境", zap.String("backend", wb.executor.Name()))
 
-	// 检查 wsl.exe 是否存在
-	_, err := exec.LookPath("wsl")
-	if err != nil {
-		return apperrors.Wrap(err, apperrors.ErrWSLNotFound, "WSL 命令不可用")
-	}
-
-	// 尝试执行简单的 WSL 命令
-	cmd := exec.Command("wsl", "--status")
-	if err := cmd.Run(); err != nil {
-		return apperrors.Wrap(err, apperrors.ErrWSLNotFound, "WSL 服务不可用")
+	if err := wb.executor.Check(); err != nil {
+		return err
 	}
 
-	wb.logger.Debug("WSL 环境检查通过")
+	wb.logger.Debug("执行环境检查通过")
 	return nil
 }
 
+// Backend 获取当前执行后端名称
+func (wb *wslBridge) Backend() string {
+	return wb.executor.Name()
+}
+
+// PathConverter 获取路径转换器，wsl.path_mappings 中的映射优先于 /mnt/<盘符> 规则
+func (wb *wslBridge) PathConverter() converter.PathConverter {
+	return converter.NewPathConverterWithMappings(wb.config.WSL.PathMappings)
+}
+
 // cleanWSLOutput 清理 WSL 命令的输出，正确处理 UTF-16LE 编码
 func cleanWSLOutput(output []byte) string {
 	if len(output) == 0 {
@@ -139,6 +221,11 @@ func cleanWSLOutput(output []byte) string {
 func (wb *wslBridge) ListDistros() ([]string, error) {
 	wb.logger.Debug("列出 WSL 发行版")
 
+	// 远程主机和容器没有发行版概念，以主机名或镜像名代替
+	if target, ok := wb.nonWSLTarget(); ok {
+		return []string{target}, nil
+	}
+
 	cmd := exec.Command("wsl", "--list", "--quiet")
 	output, err := cmd.Output()
 	if err != nil {
@@ -167,6 +254,10 @@ func (wb *wslBridge) ListDistros() ([]string, error) {
 func (wb *wslBridge) GetDefaultDistro() (string, error) {
 	wb.logger.Debug("获取默认 WSL 发行版")
 
+	if target, ok := wb.nonWSLTarget(); ok {
+		return target, nil
+	}
+
 	cmd := exec.Command("wsl", "--list", "--verbose")
 	output, err := cmd.Output()
 	if err != nil {
@@ -220,18 +311,25 @@ func (wb *wslBridge) GetDefaultDistro() (string, error) {
 	return defaultDistro, nil
 }
 
+// nonWSLTarget 获取非 WSL 后端的执行目标名称，WSL 后端返回 false
+func (wb *wslBridge) nonWSLTarget() (string, bool) {
+	switch wb.executor.Name() {
+	case BackendSSH:
+		return wb.config.SSH.Host, true
+	case BackendContainer:
+		return wb.config.Container.Image, true
+	default:
+		return "", false
+	}
+}
+
 // ExecuteCommand 在 WSL 中执行命令
 func (wb *wslBridge) ExecuteCommand(distro, command string) error {
 	wb.logger.Debug("在 WSL 中执行命令",
 		zap.String("distro", distro),
 		zap.String("command", command))
 
-	var cmd *exec.Cmd
-	if distro != "" {
-		cmd = exec.Command("wsl", "-d", distro, "bash", "-l", "-c", command)
-	} else {
-		cmd = exec.Command("wsl", "bash", "-l", "-c", command)
-	}
+	cmd := wb.newWSLCommand(distro, command)
 
 	// 连接标准输入输出
 	cmd.Stdin = os.Stdin
@@ -251,12 +349,7 @@ func (wb *wslBridge) ExecuteCommandWithOutput(distro, command string) (string, e
 		zap.String("distro", distro),
 		zap.String("command", command))
 
-	var cmd *exec.Cmd
-	if distro != "" {
-		cmd = exec.Command("wsl", "-d", distro, "bash", "-l", "-c", command)
-	} else {
-		cmd = exec.Command("wsl", "bash", "-l", "-c", command)
-	}
+	cmd := wb.newWSLCommand(distro, command)
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -269,7 +362,7 @@ func (wb *wslBridge) ExecuteCommandWithOutput(distro, command string) (string, e
 }
 
 // StartClaudeCode 启动 Claude Code
-func (wb *wslBridge) StartClaudeCode(distro, workingDir string, args []string) error {
+func (wb *wslBridge) StartClaudeCode(distro, workingDir string, args []string, output *OutputOptions) error {
 	wb.logger.Info("启动 Claude Code",
 		zap.String("distro", distro),
 		zap.String("workingDir", workingDir),
@@ -280,32 +373,194 @@ func (wb *wslBridge) StartClaudeCode(distro, workingDir string, args []string) e
 		return err
 	}
 
-	// 构建命令
-	claudeArgs := []string{"claude-code"}
-	claudeArgs = append(claudeArgs, args...)
+	argv := wb.claudeArgv(args)
+	wb.logger.Debug("执行 Claude Code 命令", zap.Strings("argv", argv))
 
-	// 构建完整的命令字符串
-	command := fmt.Sprintf("cd %s && %s",
-		escapeShellArg(workingDir),
-		strings.Join(claudeArgs, " "))
+	if output == nil {
+		return wb.runAttached(distro, workingDir, argv)
+	}
+	return wb.runWithOutput(distro, workingDir, argv, output)
+}
 
-	wb.logger.Debug("执行 Claude Code 命令", zap.String("command", command))
+// RunClaudeCode 运行 Claude Code 并捕获标准输出、标准错误和退出码
+// 非零退出码不视为错误，由调用方根据 ExitCode 判断；ctx 取消时会终止进程
+// 进程 PID 记录在发行版内，ctx 取消时向整个进程树先发送 SIGTERM，Limits.KillGracePeriod 后再发送 SIGKILL；
+// 宽限期为 0 时直接发送 SIGKILL
+func (wb *wslBridge) RunClaudeCode(ctx context.Context, distro, workingDir string, args []string, opts *RunOptions, output *OutputOptions) (_ *ExecResult, err error) {
+	ctx, span := tracing.StartKind(ctx, tracing.SpanKindClient, "wsl.run_claude_code",
+		tracing.String("wsl.distro", distro),
+		tracing.String("wsl.working_dir", workingDir))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	wb.logger.Info("运行 Claude Code（捕获输出）",
+		zap.String("distro", distro),
+		zap.String("workingDir", workingDir),
+		zap.Strings("args", args))
 
-	// 创建命令
-	var cmd *exec.Cmd
-	if distro != "" {
-		cmd = exec.Command("wsl", "-d", distro, "bash", "-l", "-c", command)
-	} else {
-		cmd = exec.Command("wsl", "bash", "-l", "-c", command)
+	if err := wb.CheckClaudeCode(distro); err != nil {
+		return nil, err
+	}
+
+	if opts == nil {
+		opts = &RunOptions{}
+	}
+
+	// 仅结束本地的 wsl.exe 不会结束发行版内的进程，因此总是记录 PID
+	grace := killGracePeriod(opts.Limits)
+	pidFile := newPIDFile(opts.TaskID)
+
+	script := limitedExecScript(opts.Limits, pidFile)
+	if opts.GPU {
+		script = gpuEnvExports + script
+	}
+	wslArgs := wb.executor.Command(distro, workingDir, false, wb.shellArgs(script, wb.claudeArgv(args)...))
+	cmd := exec.CommandContext(ctx, wslArgs[0], wslArgs[1:]...)
+
+	var killTimer *time.Timer
+	var timerMutex sync.Mutex
+	cmd.Cancel = func() error {
+		wb.logger.Info("终止 Claude Code 进程",
+			zap.String("taskId", opts.TaskID),
+			zap.Duration("gracePeriod", grace))
+		if grace <= 0 {
+			wb.signalProcess(distro, pidFile, "KILL")
+			return nil
+		}
+		wb.signalProcess(distro, pidFile, "TERM")
+		timerMutex.Lock()
+		killTimer = time.AfterFunc(grace, func() {
+			wb.signalProcess(distro, pidFile, "KILL")
+		})
+		timerMutex.Unlock()
+		return nil
+	}
+	// 发行版内的信号无效时，最终仍会强制结束本地进程
+	cmd.WaitDelay = grace + 5*time.Second
+	defer func() {
+		timerMutex.Lock()
+		if killTimer != nil {
+			killTimer.Stop()
+		}
+		timerMutex.Unlock()
+		wb.ExecuteCommandWithOutput(distro, "rm -f "+ShellQuote(pidFile))
+	}()
+
+	result, err := runCaptured(ctx, cmd, output)
+	if err != nil {
+		if ctx.Err() != nil {
+			return result, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "Claude Code 执行被中止")
+		}
+		return nil, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "Claude Code 启动失败")
+	}
+
+	wb.logger.Info("Claude Code 运行结束",
+		zap.Int("exitCode", result.ExitCode),
+		zap.Duration("duration", result.Duration))
+	span.SetAttributes(tracing.Int("process.exit_code", result.ExitCode))
+
+	return result, nil
+}
+
+// RunCommand 在指定目录中运行 shell 命令并捕获输出，非零退出码不视为错误
+func (wb *wslBridge) RunCommand(ctx context.Context, distro, workingDir, command string, output *OutputOptions) (_ *ExecResult, err error) {
+	ctx, span := tracing.StartKind(ctx, tracing.SpanKindClient, "wsl.run_command",
+		tracing.String("wsl.distro", distro),
+		tracing.String("wsl.working_dir", workingDir))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	wb.logger.Info("运行命令（捕获输出）",
+		zap.String("distro", distro),
+		zap.String("workingDir", workingDir),
+		zap.String("command", command))
+
+	wslArgs := wb.buildWSLArgs(distro, workingDir, command, false)
+	cmd := exec.CommandContext(ctx, wslArgs[0], wslArgs[1:]...)
+
+	result, err := runCaptured(ctx, cmd, output)
+	if err != nil {
+		if ctx.Err() != nil {
+			return result, apperrors.Wrap(err, apperrors.ErrWSLCommandFailed, "命令执行被中止")
+		}
+		return nil, apperrors.Wrapf(err, apperrors.ErrWSLCommandFailed, "命令启动失败: %s", command)
+	}
+
+	return result, nil
+}
+
+// runCaptured 运行命令并捕获标准输出和标准错误
+// 非零退出码记录在结果中而不作为错误返回；ctx 取消时返回退出码为 -1 的结果和 ctx 错误
+func runCaptured(ctx context.Context, cmd *exec.Cmd, output *OutputOptions) (*ExecResult, error) {
+	if output == nil {
+		output = &OutputOptions{}
+	}
+
+	stdout, stderr := NewCappedBuffer(output.MaxCaptureBytes), NewCappedBuffer(output.MaxCaptureBytes)
+	var flush func()
+	cmd.Stdin = output.Stdin
+	cmd.Stdout, cmd.Stderr, flush = output.writers(stdout, stderr)
+
+	start := time.Now()
+	err := cmd.Run()
+	flush()
+	result := &ExecResult{
+		ExitCode:     0,
+		Stdout:       stdout.String(),
+		Stderr:       stderr.String(),
+		Duration:     time.Since(start),
+		OmittedBytes: stdout.Omitted() + stderr.Omitted(),
+	}
+
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			result.ExitCode = -1
+			return result, ctxErr
+		}
+		exitError, ok := err.(*exec.ExitError)
+		if !ok {
+			return nil, err
+		}
+		result.ExitCode = exitError.ExitCode()
+	}
+
+	return result, nil
+}
+
+// claudeArgv 构建启动 Claude Code 的参数数组，参数原样传递，不经过 shell 解析
+func (wb *wslBridge) claudeArgv(args []string) []string {
+	return append(wb.claudeBaseArgv(), args...)
+}
+
+// runAttached 在当前终端上运行程序，输入输出直接连接到用户
+func (wb *wslBridge) runAttached(distro, workDir string, argv []string) error {
+	// 连接到真实控制台时使用 ConPTY，提供完整的终端能力
+	if isTerminal() {
+		env := append(os.Environ(), "TERM=xterm-256color")
+		return wb.startClaudeCodeInPTY(wb.buildExecArgs(distro, workDir, argv, true), env)
 	}
 
+	return wb.runWithOutput(distro, workDir, argv, defaultOutputOptions())
+}
+
+// runWithOutput 运行程序并将输入输出转发到指定的读写器
+func (wb *wslBridge) runWithOutput(distro, workDir string, argv []string, output *OutputOptions) error {
+	// 创建命令
+	args := wb.buildExecArgs(distro, workDir, argv, false)
+	cmd := exec.Command(args[0], args[1:]...)
+
 	// 设置环境变量
 	cmd.Env = append(os.Environ(), "TERM=xterm-256color")
 
-	// 连接标准输入输出，实现 stdio 转发
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// 连接输入输出，实现 stdio 转发
+	var flush func()
+	cmd.Stdin = output.Stdin
+	cmd.Stdout, cmd.Stderr, flush = output.writers(nil, nil)
+	defer flush()
 
 	// 启动命令
 	if err := cmd.Start(); err != nil {
@@ -330,19 +585,56 @@ func (wb *wslBridge) StartClaudeCode(distro, workingDir string, args []string) e
 	return nil
 }
 
+// startClaudeCodeInPTY 在伪终端中运行 Claude Code
+func (wb *wslBridge) startClaudeCodeInPTY(argv []string, env []string) error {
+	wslPath, err := exec.LookPath(argv[0])
+	if err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrWSLNotFound, "%s 命令不可用", argv[0])
+	}
+	argv[0] = wslPath
+
+	exitCode, err := runInPTY(wb.logger, argv, env)
+	if err != nil {
+		return err
+	}
+
+	switch exitCode {
+	case 0:
+		wb.logger.Info("Claude Code 执行完成")
+		return nil
+	case 130: // Ctrl+C
+		wb.logger.Info("Claude Code 被用户中断")
+		return nil
+	default:
+		return apperrors.Newf(apperrors.ErrClaudeCodeFailed, "Claude Code 执行失败，退出码: %d", exitCode)
+	}
+}
+
 // CheckClaudeCode 检查 Claude Code 是否可用
 func (wb *wslBridge) CheckClaudeCode(distro string) error {
 	wb.logger.Debug("检查 Claude Code 可用性", zap.String("distro", distro))
 
-	// 首先检查 claude-code 命令是否存在
-	output, err := wb.ExecuteCommandWithOutput(distro, "which claude-code")
+	executable := wb.claudeBaseArgv()[0]
+
+	// 首先检查可执行文件是否存在（支持 PATH 中的命令名和发行版内的绝对路径）
+	output, err := wb.ExecuteCommandWithOutput(distro, "command -v "+ShellQuote(executable))
 	if err != nil || output == "" {
+		if wb.config.ClaudeCode.Launcher == LauncherNPX {
+			return wb.withNodeDiagnostics(distro, apperrors.New(apperrors.ErrClaudeCodeNotFound,
+				"WSL 中未找到 npx，请先安装 Node.js 18+ 或将 claude_code.launcher 设置为 direct"))
+		}
+
+		if strings.Contains(executable, "/") {
+			return apperrors.Newf(apperrors.ErrClaudeCodeNotFound,
+				"Claude Code 可执行文件不存在或不可执行: %s", executable)
+		}
+
 		// 尝试检查常见的安装位置
 		commonPaths := []string{
-			"~/.local/bin/claude-code",
-			"/usr/local/bin/claude-code",
-			"/usr/bin/claude-code",
-			"~/bin/claude-code",
+			"~/.local/bin/" + executable,
+			"/usr/local/bin/" + executable,
+			"/usr/bin/" + executable,
+			"~/bin/" + executable,
 		}
 
 		for _, path := range commonPaths {
@@ -350,31 +642,110 @@ func (wb *wslBridge) CheckClaudeCode(distro string) error {
 			if result, err := wb.ExecuteCommandWithOutput(distro, checkCmd); err == nil && result == "found" {
 				wb.logger.Debug("在非标准位置找到 Claude Code", zap.String("path", path))
 				return apperrors.New(apperrors.ErrClaudeCodeNotFound,
-					fmt.Sprintf("Claude Code 已安装在 %s 但不在 PATH 中，请将其添加到 PATH", path))
+					fmt.Sprintf("Claude Code 已安装在 %s 但不在 PATH 中，请将其添加到 PATH 或在配置中设置 claude_code.executable", path))
 			}
 		}
 
-		return apperrors.New(apperrors.ErrClaudeCodeNotFound,
-			"Claude Code 未安装或不在 PATH 中，请在 WSL 中安装 Claude Code")
+		return wb.withNodeDiagnostics(distro, apperrors.Newf(apperrors.ErrClaudeCodeNotFound,
+			"Claude Code (%s) 未安装或不在 PATH 中，请在 WSL 中安装 Claude Code", executable))
 	}
 
 	wb.logger.Debug("Claude Code 已找到", zap.String("path", output))
 
 	// 尝试获取版本信息来验证是否正常工作
-	versionOutput, err := wb.ExecuteCommandWithOutput(distro, "claude-code --version 2>/dev/null || echo 'auth_required'")
+	versionOutput, err := wb.ExecuteCommandWithOutput(distro, wb.claudeShellCommand()+" --version 2>/dev/null || echo 'auth_required'")
 	if err != nil {
 		wb.logger.Warn("无法获取 Claude Code 版本信息", zap.Error(err))
 		return apperrors.New(apperrors.ErrClaudeCodeNotFound,
 			"Claude Code 已安装但无法执行，可能需要登录或配置")
 	}
 
+	if strings.Contains(versionOutput, "auth_required") {
+		// npm 安装的 Claude Code 在 node 缺失时同样无法输出版本，此时不应提示登录
+		if diag, err := wb.DiagnoseNode(distro); err == nil && diag.NodePath == "" {
+			return wb.withNodeDiagnostics(distro, apperrors.New(apperrors.ErrClaudeCodeNotFound,
+				"Claude Code 已安装但无法执行，Node.js 运行时不可用"))
+		}
+	}
+
 	if strings.Contains(versionOutput, "auth_required") || strings.Contains(versionOutput, "login") || strings.Contains(versionOutput, "authentication") {
 		wb.logger.Info("Claude Code 需要登录")
-		return apperrors.New(apperrors.ErrClaudeCodeNotFound,
-			"Claude Code 已安装但需要登录，请先运行: claude-code auth login")
+		return apperrors.New(apperrors.ErrClaudeCodeAuthRequired,
+			"Claude Code 已安装但需要登录，请先运行: auto-claude-code auth")
 	}
 
 	wb.logger.Debug("Claude Code 版本", zap.String("version", versionOutput))
+
+	// 校验固定版本，任务在启动前即可因版本不匹配而失败
+	return wb.verifyVersion(versionOutput)
+}
+
+// withNodeDiagnostics 将 Node.js 环境诊断出的问题附加到错误详情中
+// 许多“不在 PATH 中”的问题实际是 nvm 未加载或 node 缺失导致的
+func (wb *wslBridge) withNodeDiagnostics(distro string, err *apperrors.AppError) *apperrors.AppError {
+	diag, diagErr := wb.DiagnoseNode(distro)
+	if diagErr != nil {
+		wb.logger.Debug("Node.js 环境诊断失败", zap.Error(diagErr))
+		return err
+	}
+
+	if issues := diag.Issues(); len(issues) > 0 {
+		return err.WithDetails(strings.Join(issues, "；"))
+	}
+	return err
+}
+
+// InstallClaudeCode 在 WSL 中安装或更新 Claude Code，安装输出直接转发到终端
+func (wb *wslBridge) InstallClaudeCode(distro, method string) error {
+	wb.logger.Info("安装 Claude Code",
+		zap.String("distro", distro),
+		zap.String("method", method))
+
+	var command string
+	switch method {
+	case InstallMethodNPM, "":
+		if output, err := wb.ExecuteCommandWithOutput(distro, "command -v npm"); err != nil || output == "" {
+			return apperrors.New(apperrors.ErrClaudeCodeInstall,
+				"WSL 中未找到 npm，请先安装 Node.js 18+，或使用 --method native")
+		}
+		pkg := claudeCodeNPMPackage
+		if version := wb.config.ClaudeCode.Version; version != "" {
+			pkg += "@" + version
+		}
+		command = "npm install -g " + ShellQuote(pkg)
+	case InstallMethodNative:
+		if output, err := wb.ExecuteCommandWithOutput(distro, "command -v curl"); err != nil || output == "" {
+			return apperrors.New(apperrors.ErrClaudeCodeInstall, "WSL 中未找到 curl，无法下载安装脚本")
+		}
+		command = "curl -fsSL " + claudeCodeInstallScript + " | bash"
+		if version := wb.config.ClaudeCode.Version; version != "" {
+			command += " -s " + ShellQuote(version)
+		}
+	default:
+		return apperrors.Newf(apperrors.ErrClaudeCodeInstall,
+			"不支持的安装方式: %s，支持: %s, %s", method, InstallMethodNPM, InstallMethodNative)
+	}
+
+	if err := wb.ExecuteCommand(distro, command); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrClaudeCodeInstall, "Claude Code 安装失败").
+			WithDetails("如果是权限问题，请配置 npm 的用户级全局目录或使用 --method native")
+	}
+
+	wb.logger.Info("Claude Code 安装命令执行完成")
+	return nil
+}
+
+// AuthenticateClaudeCode 在当前终端中运行 Claude Code 登录流程
+// 登录过程中的设备码链接和令牌输入都通过桥接器直接转发
+func (wb *wslBridge) AuthenticateClaudeCode(distro string) error {
+	wb.logger.Info("启动 Claude Code 登录流程", zap.String("distro", distro))
+
+	argv := wb.claudeArgv([]string{"auth", "login"})
+	if err := wb.runAttached(distro, "", argv); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrClaudeCodeAuthRequired, "Claude Code 登录失败")
+	}
+
+	wb.logger.Info("Claude Code 登录流程结束")
 	return nil
 }
 
@@ -389,21 +760,9 @@ func (wb *wslBridge) StartClaudeCodeInteractive(distro, workingDir string, args
 		return err
 	}
 
-	// 构建命令
-	claudeArgs := []string{"claude-code"}
-	claudeArgs = append(claudeArgs, args...)
-
-	command := fmt.Sprintf("cd %s && %s",
-		escapeShellArg(workingDir),
-		strings.Join(claudeArgs, " "))
-
 	// 创建命令
-	var cmd *exec.Cmd
-	if distro != "" {
-		cmd = exec.Command("wsl", "-d", distro, "bash", "-l", "-c", command)
-	} else {
-		cmd = exec.Command("wsl", "bash", "-l", "-c", command)
-	}
+	execArgs := wb.buildExecArgs(distro, workingDir, wb.claudeArgv(args), false)
+	cmd := exec.Command(execArgs[0], execArgs[1:]...)
 
 	// 创建管道
 	stdout, err := cmd.StdoutPipe()
@@ -467,14 +826,81 @@ func (wb *wslBridge) streamOutput(ctx context.Context, src io.Reader, dst io.Wri
 	}
 }
 
-// escapeShellArg 转义 shell 参数
-func escapeShellArg(arg string) string {
-	if strings.Contains(arg, " ") || strings.Contains(arg, "'") || strings.Contains(arg, "\"") {
-		// 使用单引号包围，并转义内部的单引号
-		escaped := strings.ReplaceAll(arg, "'", "'\"'\"'")
-		return "'" + escaped + "'"
+// executable 获取配置的 Claude Code 可执行文件
+func (wb *wslBridge) executable() string {
+	if wb.config.ClaudeCode.Executable != "" {
+		return wb.config.ClaudeCode.Executable
 	}
-	return arg
+	return "claude-code"
+}
+
+// buildWSLArgs 构建通过配置的 shell 执行脚本的进程参数
+// workDir 为命令的工作目录（可为空），tty 表示调用方会提供真实终端
+func (wb *wslBridge) buildWSLArgs(distro, workDir, command string, tty bool) []string {
+	return wb.executor.Command(distro, workDir, tty, wb.shellArgs(command))
+}
+
+// buildExecArgs 构建执行指定程序的进程参数
+// argv 作为 shell 的位置参数传入并通过 exec "$@" 执行，任何元素都不会被 shell 解析
+func (wb *wslBridge) buildExecArgs(distro, workDir string, argv []string, tty bool) []string {
+	return wb.executor.Command(distro, workDir, tty, wb.shellArgs(`exec "$@"`, argv...))
+}
+
+// shellArgs 构建 shell 调用参数，positional 依次成为脚本中的 $1、$2 ...
+func (wb *wslBridge) shellArgs(script string, positional ...string) []string {
+	shell := wb.config.WSL.Shell
+	if shell == "" {
+		shell = "bash"
+	}
+	args := []string{shell}
+
+	// 非登录模式跳过 profile 加载，启动更快，但 PATH 可能不包含用户目录
+	if wb.config.WSL.LoginShell {
+		args = append(args, "-l")
+	}
+
+	args = append(args, "-c", wb.envExports()+script)
+	if len(positional) > 0 {
+		// 第一个参数成为 $0
+		args = append(args, shell)
+		args = append(args, positional...)
+	}
+
+	return args
+}
+
+// newWSLCommand 创建在发行版中执行命令的 exec.Cmd
+func (wb *wslBridge) newWSLCommand(distro, command string) *exec.Cmd {
+	args := wb.buildWSLArgs(distro, "", command, false)
+	return exec.Command(args[0], args[1:]...)
+}
+
+// envExports 根据 wsl.env_passthrough 和 wsl.env_set 生成 export 语句前缀
+func (wb *wslBridge) envExports() string {
+	var b strings.Builder
+
+	for _, name := range wb.config.WSL.EnvPassthrough {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, "export %s=%s; ", name, ShellQuote(value))
+	}
+
+	for _, entry := range wb.config.WSL.EnvSet {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, "export %s=%s; ", name, ShellQuote(value))
+	}
+
+	return b.String()
+}
+
+// ShellQuote 使用单引号包围字符串，使其在 shell 中按字面量处理
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "'\"'\"'") + "'"
 }
 
 // GetWSLVersion 获取 WSL 版本信息
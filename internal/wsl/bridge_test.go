@@ -0,0 +1,196 @@
+package wsl
+
+import (
+	"strings"
+	"testing"
+
+	"auto-claude-code/internal/config"
+
+	"go.uber.org/zap"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "普通字符串",
+			input:    "value",
+			expected: "'value'",
+		},
+		{
+			name:     "包含空格",
+			input:    "a b",
+			expected: "'a b'",
+		},
+		{
+			name:     "包含单引号",
+			input:    "it's",
+			expected: `'it'"'"'s'`,
+		},
+		{
+			name:     "包含shell元字符",
+			input:    "$HOME; rm -rf /",
+			expected: "'$HOME; rm -rf /'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := ShellQuote(tt.input); result != tt.expected {
+				t.Errorf("期望 %s，但得到 %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestWSLBridge_EnvExports(t *testing.T) {
+	t.Setenv("ACC_TEST_PASSTHROUGH", "secret value")
+
+	cfg := config.GetDefaultConfig()
+	cfg.WSL.EnvPassthrough = []string{"ACC_TEST_PASSTHROUGH", "ACC_TEST_UNSET"}
+	cfg.WSL.EnvSet = []string{"NODE_OPTIONS=--max-old-space-size=4096"}
+
+	wb := NewWSLBridge(cfg, zap.NewNop()).(*wslBridge)
+
+	expected := "export ACC_TEST_PASSTHROUGH='secret value'; export NODE_OPTIONS='--max-old-space-size=4096'; "
+	if result := wb.envExports(); result != expected {
+		t.Errorf("期望 %q，但得到 %q", expected, result)
+	}
+}
+
+func TestOutputOptions_Writers(t *testing.T) {
+	var lines []string
+	opts := &OutputOptions{
+		OnLine: func(stream, line string) {
+			lines = append(lines, stream+":"+line)
+		},
+	}
+
+	stdout, stderr, flush := opts.writers(nil, nil)
+	stdout.Write([]byte("first\r\nsec"))
+	stdout.Write([]byte("ond\npartial"))
+	stderr.Write([]byte("oops\n"))
+	flush()
+
+	expected := []string{"stdout:first", "stdout:second", "stderr:oops", "stdout:partial"}
+	if len(lines) != len(expected) {
+		t.Fatalf("期望 %v，但得到 %v", expected, lines)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("第 %d 行期望 %s，但得到 %s", i, expected[i], lines[i])
+		}
+	}
+}
+
+func TestWSLBridge_BuildWSLArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		shell      string
+		loginShell bool
+		distro     string
+		expected   []string
+	}{
+		{
+			name:       "默认登录bash",
+			shell:      "bash",
+			loginShell: true,
+			distro:     "Ubuntu",
+			expected:   []string{"wsl", "-d", "Ubuntu", "--exec", "bash", "-l", "-c", "echo hi"},
+		},
+		{
+			name:       "非登录zsh",
+			shell:      "zsh",
+			loginShell: false,
+			expected:   []string{"wsl", "--exec", "zsh", "-c", "echo hi"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.GetDefaultConfig()
+			cfg.WSL.Shell = tt.shell
+			cfg.WSL.LoginShell = tt.loginShell
+
+			wb := NewWSLBridge(cfg, zap.NewNop()).(*wslBridge)
+			result := wb.buildWSLArgs(tt.distro, "", "echo hi", false)
+
+			if strings.Join(result, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("期望 %v，但得到 %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestSSHExecutor_Command(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.Backend = BackendSSH
+	cfg.SSH = config.SSHConfig{
+		Host:    "build.example.com",
+		User:    "dev",
+		Port:    2222,
+		KeyFile: "C:\\Users\\dev\\.ssh\\id_ed25519",
+	}
+
+	bridge, err := NewBridge(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("创建桥接器失败: %v", err)
+	}
+	wb := bridge.(*wslBridge)
+
+	expected := []string{"ssh", "-T", "-o", "BatchMode=yes", "-p", "2222",
+		"-i", "C:\\Users\\dev\\.ssh\\id_ed25519", "dev@build.example.com", "--",
+		"cd '/srv/app' && 'bash' '-l' '-c' 'echo '\"'\"'hi'\"'\"''"}
+	result := wb.buildWSLArgs("", "/srv/app", "echo 'hi'", false)
+	if strings.Join(result, "|") != strings.Join(expected, "|") {
+		t.Errorf("期望 %v，但得到 %v", expected, result)
+	}
+
+	if tty := wb.buildWSLArgs("", "", "claude", true); tty[1] != "-t" {
+		t.Errorf("交互模式应使用 -t，但得到 %v", tty)
+	}
+}
+
+func TestContainerExecutor_Command(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.Backend = BackendContainer
+	cfg.WSL.LoginShell = false
+	cfg.Container = config.ContainerConfig{
+		Runtime: "podman",
+		Image:   "node:20",
+		Mounts:  []string{"claude-home:/root/.claude"},
+	}
+
+	bridge, err := NewBridge(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("创建桥接器失败: %v", err)
+	}
+	wb := bridge.(*wslBridge)
+
+	expected := []string{"podman", "run", "--rm", "-i",
+		"-v", "C:\\work\\app:/mnt/c/work/app", "-w", "/mnt/c/work/app",
+		"-v", "claude-home:/root/.claude", "node:20", "bash", "-c", "claude"}
+	result := wb.buildWSLArgs("", "/mnt/c/work/app", "claude", false)
+	if strings.Join(result, "|") != strings.Join(expected, "|") {
+		t.Errorf("期望 %v，但得到 %v", expected, result)
+	}
+}
+
+func TestWSLBridge_BuildExecArgs(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.ClaudeCode.Executable = "claude"
+	wb := NewWSLBridge(cfg, zap.NewNop()).(*wslBridge)
+
+	// 参数中的 shell 元字符必须作为独立的 argv 元素原样传递
+	argv := wb.claudeArgv([]string{"-p", "fix $(rm -rf ~); echo `id`"})
+	result := wb.buildExecArgs("Ubuntu", "/mnt/c/my project", argv, false)
+
+	expected := []string{"wsl", "-d", "Ubuntu", "--cd", "/mnt/c/my project", "--exec",
+		"bash", "-l", "-c", `exec "$@"`, "bash", "claude", "-p", "fix $(rm -rf ~); echo `id`"}
+	if strings.Join(result, "|") != strings.Join(expected, "|") {
+		t.Errorf("期望 %v，但得到 %v", expected, result)
+	}
+}
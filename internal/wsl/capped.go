@@ -0,0 +1,124 @@
+package wsl
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// truncationMarkerFormat 截断的输出中代替被省略内容的标记行
+const truncationMarkerFormat = "... [输出超过上限，已省略 %d 字节] ...\n"
+
+// TruncationMarker 获取省略了 omitted 字节时插入的标记行
+func TruncationMarker(omitted int64) string {
+	return fmt.Sprintf(truncationMarkerFormat, omitted)
+}
+
+// TailBuffer 只保留最后 max 字节的输出，丢弃时尽量从行首开始保留
+type TailBuffer struct {
+	max     int
+	data    []byte
+	start   int // data 中保留内容的起点，超过 max 时才整理底层数组
+	dropped int64
+}
+
+// NewTailBuffer 创建最多保留 max 字节的缓冲
+func NewTailBuffer(max int) *TailBuffer {
+	return &TailBuffer{max: max}
+}
+
+// Write 追加输出，超过上限时丢弃最早的内容
+func (b *TailBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+	if excess := len(b.data) - b.start - b.max; excess > 0 {
+		cut := b.start + excess
+		// 从下一行开始保留，避免结尾部分以半行开头
+		if i := bytes.IndexByte(b.data[cut:], '\n'); i >= 0 && i+1 < len(b.data)-cut {
+			cut += i + 1
+		}
+		b.dropped += int64(cut - b.start)
+		b.start = cut
+	}
+	if b.start > b.max {
+		b.data = append(b.data[:0], b.data[b.start:]...)
+		b.start = 0
+	}
+	return len(p), nil
+}
+
+// Bytes 获取保留的内容
+func (b *TailBuffer) Bytes() []byte {
+	return b.data[b.start:]
+}
+
+// Dropped 获取已丢弃的字节数
+func (b *TailBuffer) Dropped() int64 {
+	return b.dropped
+}
+
+// CappedBuffer 最多保存约 max 字节的输出，超过时保留开头和结尾各一半，中间以标记行代替
+// max 不大于 0 时不限制；不是并发安全的
+type CappedBuffer struct {
+	max   int
+	head  bytes.Buffer
+	tail  *TailBuffer // 开头部分写满后创建
+	total int64
+}
+
+// NewCappedBuffer 创建最多保存 max 字节的缓冲
+func NewCappedBuffer(max int) *CappedBuffer {
+	return &CappedBuffer{max: max}
+}
+
+// Write 追加输出，开头部分写满后写入结尾部分
+func (b *CappedBuffer) Write(p []byte) (int, error) {
+	b.total += int64(len(p))
+	if b.tail == nil {
+		room := len(p)
+		if b.max > 0 {
+			room = min(room, b.max/2-b.head.Len())
+		}
+		b.head.Write(p[:room])
+		if room == len(p) {
+			return len(p), nil
+		}
+		b.tail = NewTailBuffer(b.max - b.max/2)
+		p = p[room:]
+	}
+	b.tail.Write(p)
+	return len(p), nil
+}
+
+// WriteString 追加字符串输出
+func (b *CappedBuffer) WriteString(s string) (int, error) {
+	return b.Write([]byte(s))
+}
+
+// String 获取保存的内容，有内容被省略时开头和结尾之间为标记行
+func (b *CappedBuffer) String() string {
+	if b.tail == nil {
+		return b.head.String()
+	}
+	var sb bytes.Buffer
+	sb.Write(b.head.Bytes())
+	if omitted := b.tail.Dropped(); omitted > 0 {
+		if n := b.head.Len(); n > 0 && b.head.Bytes()[n-1] != '\n' {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(TruncationMarker(omitted))
+	}
+	sb.Write(b.tail.Bytes())
+	return sb.String()
+}
+
+// Total 获取写入的总字节数
+func (b *CappedBuffer) Total() int64 {
+	return b.total
+}
+
+// Omitted 获取被省略的字节数
+func (b *CappedBuffer) Omitted() int64 {
+	if b.tail == nil {
+		return 0
+	}
+	return b.tail.Dropped()
+}
@@ -0,0 +1,56 @@
+package wsl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCappedBuffer(t *testing.T) {
+	tests := []struct {
+		name    string
+		max     int
+		writes  []string
+		want    string
+		omitted int64
+	}{
+		{"不限制", 0, []string{"a\n", "b\n"}, "a\nb\n", 0},
+		{"未超过上限", 20, []string{"line1\n", "line2\n"}, "line1\nline2\n", 0},
+		{"保留开头和结尾", 12, []string{"head\n", "middle1\n", "middle2\n", "end\n"}, "head\nm\n" + TruncationMarker(15) + "end\n", 15},
+		{"开头截在行中间", 8, []string{"abcdefgh\n", "xy\n"}, "abcd\n" + TruncationMarker(5) + "xy\n", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewCappedBuffer(tt.max)
+			total := 0
+			for _, w := range tt.writes {
+				b.WriteString(w)
+				total += len(w)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			if b.Omitted() != tt.omitted || b.Total() != int64(total) {
+				t.Errorf("Omitted() = %d, Total() = %d", b.Omitted(), b.Total())
+			}
+		})
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	b := NewTailBuffer(10)
+	for i := 0; i < 1000; i++ {
+		b.Write([]byte("0123\n"))
+	}
+	if got := string(b.Bytes()); got != "0123\n0123\n" {
+		t.Errorf("Bytes() = %q", got)
+	}
+	if b.Dropped() != 4990 {
+		t.Errorf("Dropped() = %d", b.Dropped())
+	}
+	// 超过上限的单行从中间截断
+	b.Write([]byte(strings.Repeat("x", 25)))
+	if got := string(b.Bytes()); got != strings.Repeat("x", 10) {
+		t.Errorf("Bytes() = %q", got)
+	}
+}
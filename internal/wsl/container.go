@@ -0,0 +1,79 @@
+package wsl
+
+import (
+	"os/exec"
+
+	"auto-claude-code/internal/config"
+	"auto-claude-code/internal/converter"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// containerExecutor 通过 Docker/Podman 在一次性容器中执行
+// 工作目录以相同的 Linux 路径挂载到容器内，因此路径转换规则与 WSL 后端一致
+type containerExecutor struct {
+	config        config.ContainerConfig
+	pathConverter converter.PathConverter
+}
+
+// newContainerExecutor 创建容器执行后端
+func newContainerExecutor(cfg config.ContainerConfig, pathMappings map[string]string) *containerExecutor {
+	return &containerExecutor{
+		config:        cfg,
+		pathConverter: converter.NewPathConverterWithMappings(pathMappings),
+	}
+}
+
+// Name 获取后端名称
+func (e *containerExecutor) Name() string {
+	return BackendContainer
+}
+
+// Check 检查容器运行时是否可用以及镜像是否已存在
+func (e *containerExecutor) Check() error {
+	runtime := e.runtime()
+	if _, err := exec.LookPath(runtime); err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrWSLNotFound, "%s 命令不可用", runtime)
+	}
+
+	if err := exec.Command(runtime, "image", "inspect", e.config.Image).Run(); err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrWSLNotFound, "容器镜像不可用: %s", e.config.Image).
+			WithDetailsf("请先运行: %s pull %s", runtime, e.config.Image)
+	}
+
+	return nil
+}
+
+// Command 构建容器运行参数，每次调用使用一个执行完即删除的容器
+func (e *containerExecutor) Command(distro, workDir string, tty bool, shellArgs []string) []string {
+	args := []string{e.runtime(), "run", "--rm", "-i"}
+	if tty {
+		args = append(args, "-t")
+	}
+
+	if workDir != "" {
+		args = append(args, "-v", e.hostPath(workDir)+":"+workDir, "-w", workDir)
+	}
+	for _, mount := range e.config.Mounts {
+		args = append(args, "-v", mount)
+	}
+
+	args = append(args, e.config.ExtraArgs...)
+	args = append(args, e.config.Image)
+	return append(args, shellArgs...)
+}
+
+// runtime 获取容器运行时命令
+func (e *containerExecutor) runtime() string {
+	if e.config.Runtime != "" {
+		return e.config.Runtime
+	}
+	return "docker"
+}
+
+// hostPath 将容器内的工作目录还原为宿主机路径，无法还原时原样使用
+func (e *containerExecutor) hostPath(workDir string) string {
+	if hostPath, err := e.pathConverter.ConvertToWindows(workDir); err == nil {
+		return hostPath
+	}
+	return workDir
+}
@@ -0,0 +1,94 @@
+package wsl
+
+import (
+	"os/exec"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+
+	"go.uber.org/zap"
+)
+
+// 执行后端类型
+const (
+	BackendWSL       = "wsl"
+	BackendSSH       = "ssh"
+	BackendContainer = "container"
+)
+
+// Executor 执行后端接口
+// 负责把一次 shell 调用（如 bash -l -c ...）包装为本地可执行的进程参数
+type Executor interface {
+	// Name 获取后端名称
+	Name() string
+
+	// Check 检查后端是否可用
+	Check() error
+
+	// Command 构建进程参数，workDir 为命令的工作目录（可为空），tty 表示调用方会提供真实终端
+	Command(distro, workDir string, tty bool, shellArgs []string) []string
+}
+
+// NewBridge 根据配置的执行后端创建桥接器
+func NewBridge(cfg *config.Config, logger *zap.Logger) (WSLBridge, error) {
+	if cfg == nil {
+		cfg = config.GetDefaultConfig()
+	}
+
+	switch cfg.Backend {
+	case BackendWSL, "":
+		return NewWSLBridge(cfg, logger), nil
+	case BackendSSH:
+		return &wslBridge{
+			config:   cfg,
+			logger:   logger,
+			executor: newSSHExecutor(cfg.SSH),
+		}, nil
+	case BackendContainer:
+		return &wslBridge{
+			config:   cfg,
+			logger:   logger,
+			executor: newContainerExecutor(cfg.Container, cfg.WSL.PathMappings),
+		}, nil
+	default:
+		return nil, apperrors.Newf(apperrors.ErrConfigInvalid, "不支持的执行后端: %s", cfg.Backend)
+	}
+}
+
+// wslExecutor 通过 wsl.exe 在本机 WSL 发行版中执行
+type wslExecutor struct{}
+
+// Name 获取后端名称
+func (e *wslExecutor) Name() string {
+	return BackendWSL
+}
+
+// Check 检查 WSL 是否可用
+func (e *wslExecutor) Check() error {
+	// 检查 wsl.exe 是否存在
+	if _, err := exec.LookPath("wsl"); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrWSLNotFound, "WSL 命令不可用")
+	}
+
+	// 尝试执行简单的 WSL 命令
+	cmd := exec.Command("wsl", "--status")
+	if err := cmd.Run(); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrWSLNotFound, "WSL 服务不可用")
+	}
+
+	return nil
+}
+
+// Command 构建 wsl 调用参数
+// 使用 --exec 直接执行 shell，避免 wsl 再经过默认 shell 拼接和解析参数
+func (e *wslExecutor) Command(distro, workDir string, tty bool, shellArgs []string) []string {
+	args := []string{"wsl"}
+	if distro != "" {
+		args = append(args, "-d", distro)
+	}
+	if workDir != "" {
+		args = append(args, "--cd", workDir)
+	}
+	args = append(args, "--exec")
+	return append(args, shellArgs...)
+}
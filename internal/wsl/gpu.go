@@ -0,0 +1,67 @@
+package wsl
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// gpuDetectScript 检测 WSLg 和 GPU 计算支持的脚本
+// /dev/dxg 为 WSL2 的 GPU 半虚拟化设备，/usr/lib/wsl/lib 由 Windows 驱动提供 CUDA 用户态库
+const gpuDetectScript = `echo "dxg $([ -e /dev/dxg ] && echo yes)"; ` +
+	`echo "wslg $([ -d /mnt/wslg ] && echo yes)"; ` +
+	`echo "cuda $(ls /usr/lib/wsl/lib/libcuda.so* >/dev/null 2>&1 && echo yes)"; ` +
+	`echo "gpu $(nvidia-smi --query-gpu=name --format=csv,noheader 2>/dev/null | head -n 1)"`
+
+// gpuEnvExports 为需要 GPU 加速的任务导出的环境变量
+// 仅在变量未设置时使用 WSLg 的默认值，已有设置保持不变
+const gpuEnvExports = `export LD_LIBRARY_PATH="/usr/lib/wsl/lib${LD_LIBRARY_PATH:+:$LD_LIBRARY_PATH}"; ` +
+	`export DISPLAY="${DISPLAY:-:0}"; ` +
+	`export WAYLAND_DISPLAY="${WAYLAND_DISPLAY:-wayland-0}"; ` +
+	`export XDG_RUNTIME_DIR="${XDG_RUNTIME_DIR:-/mnt/wslg/runtime-dir}"; `
+
+// GPUInfo 发行版中的 GPU 和图形支持情况
+type GPUInfo struct {
+	DXG     bool   `json:"dxg"`               // GPU 半虚拟化设备可用
+	WSLg    bool   `json:"wslg"`              // WSLg 图形支持可用
+	CUDA    bool   `json:"cuda"`              // CUDA 用户态库可用
+	GPUName string `json:"gpuName,omitempty"` // nvidia-smi 报告的 GPU 名称
+}
+
+// Available 是否可以为任务提供 GPU 加速
+func (g *GPUInfo) Available() bool {
+	return g.DXG && g.CUDA
+}
+
+// DetectGPU 检测发行版中的 WSLg 和 CUDA 支持
+func (wb *wslBridge) DetectGPU(distro string) (*GPUInfo, error) {
+	wb.logger.Debug("检测 GPU 支持", zap.String("distro", distro))
+
+	output, err := wb.ExecuteCommandWithOutput(distro, gpuDetectScript)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseGPUInfo(output), nil
+}
+
+// parseGPUInfo 解析 GPU 检测脚本的输出
+func parseGPUInfo(output string) *GPUInfo {
+	info := &GPUInfo{}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "dxg":
+			info.DXG = value == "yes"
+		case "wslg":
+			info.WSLg = value == "yes"
+		case "cuda":
+			info.CUDA = value == "yes"
+		case "gpu":
+			info.GPUName = value
+		}
+	}
+	return info
+}
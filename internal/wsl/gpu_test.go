@@ -0,0 +1,41 @@
+package wsl
+
+import "testing"
+
+func TestParseGPUInfo(t *testing.T) {
+	tests := []struct {
+		name      string
+		output    string
+		available bool
+		wslg      bool
+		gpuName   string
+	}{
+		{
+			name:      "CUDA和WSLg可用",
+			output:    "dxg yes\nwslg yes\ncuda yes\ngpu NVIDIA GeForce RTX 4090",
+			available: true,
+			wslg:      true,
+			gpuName:   "NVIDIA GeForce RTX 4090",
+		},
+		{
+			name:      "仅WSLg",
+			output:    "dxg yes\nwslg yes\ncuda\ngpu",
+			available: false,
+			wslg:      true,
+		},
+		{
+			name:      "无GPU设备",
+			output:    "dxg\nwslg\ncuda\ngpu",
+			available: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := parseGPUInfo(tt.output)
+			if info.Available() != tt.available || info.WSLg != tt.wslg || info.GPUName != tt.gpuName {
+				t.Errorf("解析结果不符合预期: %+v", info)
+			}
+		})
+	}
+}
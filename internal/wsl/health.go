@@ -0,0 +1,151 @@
+package wsl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// claudeAPIHost Claude Code 访问的 API 主机
+	claudeAPIHost = "api.anthropic.com"
+	// maxClockSkew 允许的最大时钟偏差，偏差过大会导致 TLS 和令牌校验失败
+	maxClockSkew = time.Minute
+	// minFreeDiskKB 家目录所在分区的最小剩余空间（1GB）
+	minFreeDiskKB = 1024 * 1024
+)
+
+// healthCheckScript 在发行版内检查网络、时钟和磁盘状态的脚本
+const healthCheckScript = `if getent hosts ` + claudeAPIHost + ` >/dev/null 2>&1; then echo "dns ok"; else echo "dns fail"; fi; ` +
+	`if command -v curl >/dev/null 2>&1; then ` +
+	`echo "https $(curl -s -o /dev/null -w '%{http_code}' --max-time 10 https://` + claudeAPIHost + ` 2>/dev/null)"; ` +
+	`else echo "https nocurl"; fi; ` +
+	`echo "time $(date +%s)"; ` +
+	`echo "disk $(df -Pk ~ | tail -n 1 | awk '{print $4}')"`
+
+// HealthCheck 单项健康检查结果
+type HealthCheck struct {
+	Name       string `json:"name"`
+	Passed     bool   `json:"passed"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// CheckDistroHealth 在发行版内检查 DNS 解析、HTTPS 连通性、时钟偏差和磁盘空间
+func (wb *wslBridge) CheckDistroHealth(distro string) ([]HealthCheck, error) {
+	wb.logger.Debug("检查发行版健康状态", zap.String("distro", distro))
+
+	output, err := wb.ExecuteCommandWithOutput(distro, healthCheckScript)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseHealthOutput(output, time.Now()), nil
+}
+
+// parseHealthOutput 解析健康检查脚本的输出，now 为宿主机当前时间
+func parseHealthOutput(output string, now time.Time) []HealthCheck {
+	values := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		if key != "" {
+			values[key] = strings.TrimSpace(value)
+		}
+	}
+
+	return []HealthCheck{
+		checkDNS(values["dns"]),
+		checkHTTPS(values["https"]),
+		checkClock(values["time"], now),
+		checkDisk(values["disk"]),
+	}
+}
+
+// checkDNS 检查 DNS 解析结果
+func checkDNS(value string) HealthCheck {
+	check := HealthCheck{Name: "DNS 解析"}
+	if value == "ok" {
+		check.Passed = true
+		check.Message = "可以解析 " + claudeAPIHost
+		return check
+	}
+
+	check.Message = "无法解析 " + claudeAPIHost
+	check.Suggestion = "检查 /etc/resolv.conf 中的 nameserver，或在 /etc/wsl.conf 中设置 [network] generateResolvConf 后执行 wsl --shutdown"
+	return check
+}
+
+// checkHTTPS 检查 HTTPS 连通性，收到任意 HTTP 状态码即视为可达
+func checkHTTPS(value string) HealthCheck {
+	check := HealthCheck{Name: "HTTPS 连通性"}
+
+	if value == "nocurl" {
+		check.Message = "发行版中未安装 curl，无法检查"
+		check.Suggestion = "安装 curl，例如: sudo apt install curl"
+		return check
+	}
+
+	if code, err := strconv.Atoi(value); err == nil && code > 0 {
+		check.Passed = true
+		check.Message = fmt.Sprintf("https://%s 可访问 (HTTP %d)", claudeAPIHost, code)
+		return check
+	}
+
+	check.Message = fmt.Sprintf("无法连接 https://%s", claudeAPIHost)
+	check.Suggestion = "检查防火墙和代理设置，需要代理时可通过 wsl.env_passthrough 透传 HTTPS_PROXY"
+	return check
+}
+
+// checkClock 检查发行版与宿主机之间的时钟偏差
+func checkClock(value string, now time.Time) HealthCheck {
+	check := HealthCheck{Name: "时钟同步"}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		check.Message = "无法读取发行版时间"
+		check.Suggestion = "确认发行版中 date 命令可用"
+		return check
+	}
+
+	skew := now.Sub(time.Unix(seconds, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	skew = skew.Truncate(time.Second)
+
+	if skew <= maxClockSkew {
+		check.Passed = true
+		check.Message = fmt.Sprintf("与宿主机偏差 %s", skew)
+		return check
+	}
+
+	check.Message = fmt.Sprintf("与宿主机偏差 %s，超过 %s", skew, maxClockSkew)
+	check.Suggestion = "执行 sudo hwclock -s，或运行 wsl --shutdown 后重新启动发行版以同步时间"
+	return check
+}
+
+// checkDisk 检查家目录所在分区的剩余空间
+func checkDisk(value string) HealthCheck {
+	check := HealthCheck{Name: "磁盘空间"}
+
+	availableKB, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		check.Message = "无法读取磁盘剩余空间"
+		check.Suggestion = "确认发行版中 df 命令可用"
+		return check
+	}
+
+	message := fmt.Sprintf("剩余 %.1f GB", float64(availableKB)/1024/1024)
+	if availableKB >= minFreeDiskKB {
+		check.Passed = true
+		check.Message = message
+		return check
+	}
+
+	check.Message = message + "，不足 1 GB"
+	check.Suggestion = "清理发行版中的缓存（如 npm cache clean --force）或扩展 WSL 虚拟磁盘"
+	return check
+}
@@ -0,0 +1,54 @@
+package wsl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHealthOutput(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name     string
+		output   string
+		expected []bool
+	}{
+		{
+			name:     "全部通过",
+			output:   "dns ok\nhttps 404\ntime 1700000010\ndisk 20971520",
+			expected: []bool{true, true, true, true},
+		},
+		{
+			name:     "网络不可用且时钟偏差过大",
+			output:   "dns fail\nhttps 000\ntime 1699990000\ndisk 20971520",
+			expected: []bool{false, false, false, true},
+		},
+		{
+			name:     "缺少curl且磁盘不足",
+			output:   "dns ok\nhttps nocurl\ntime 1700000000\ndisk 102400",
+			expected: []bool{true, false, true, false},
+		},
+		{
+			name:     "输出缺失",
+			output:   "",
+			expected: []bool{false, false, false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := parseHealthOutput(tt.output, now)
+			if len(checks) != len(tt.expected) {
+				t.Fatalf("期望 %d 项检查，但得到 %d 项", len(tt.expected), len(checks))
+			}
+			for i, check := range checks {
+				if check.Passed != tt.expected[i] {
+					t.Errorf("%s 期望 %v，但得到 %v (%s)", check.Name, tt.expected[i], check.Passed, check.Message)
+				}
+				if !check.Passed && check.Suggestion == "" {
+					t.Errorf("%s 未通过但没有给出建议", check.Name)
+				}
+			}
+		})
+	}
+}
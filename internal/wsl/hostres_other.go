@@ -0,0 +1,10 @@
+//go:build !windows
+
+package wsl
+
+import "runtime"
+
+// DetectHostResources 非 Windows 平台无法获取 .wslconfig 对应的宿主机内存，仅返回处理器数量
+func DetectHostResources() HostResources {
+	return HostResources{CPUs: runtime.NumCPU()}
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+package wsl
+
+import (
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// memoryStatusEx 对应 Win32 MEMORYSTATUSEX 结构
+type memoryStatusEx struct {
+	length               uint32
+	memoryLoad           uint32
+	totalPhys            uint64
+	availPhys            uint64
+	totalPageFile        uint64
+	availPageFile        uint64
+	totalVirtual         uint64
+	availVirtual         uint64
+	availExtendedVirtual uint64
+}
+
+var procGlobalMemoryStatusEx = windows.NewLazySystemDLL("kernel32.dll").NewProc("GlobalMemoryStatusEx")
+
+// DetectHostResources 获取 Windows 宿主机的物理内存和处理器数量
+func DetectHostResources() HostResources {
+	host := HostResources{CPUs: runtime.NumCPU()}
+
+	status := memoryStatusEx{}
+	status.length = uint32(unsafe.Sizeof(status))
+	if ret, _, _ := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status))); ret != 0 {
+		host.MemoryMB = status.totalPhys / 1024 / 1024
+	}
+	return host
+}
@@ -0,0 +1,82 @@
+package wsl
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"auto-claude-code/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// limitedExecScript 构建应用资源限制后 exec "$@" 的脚本
+// pidFile 非空时先记录进程 PID，供超时或取消时在发行版内逐级发送信号
+func limitedExecScript(limits *config.ResourceLimits, pidFile string) string {
+	var b strings.Builder
+
+	if pidFile != "" {
+		// exec 不会改变 PID，因此这里记录的就是最终 Claude Code 进程的 PID
+		fmt.Fprintf(&b, "echo $$ > %s; ", ShellQuote(pidFile))
+	}
+
+	target := `"$@"`
+	if limits != nil {
+		if limits.MaxOpenFiles > 0 {
+			fmt.Fprintf(&b, "ulimit -n %d; ", limits.MaxOpenFiles)
+		}
+		if limits.Nice != 0 {
+			target = fmt.Sprintf("nice -n %d %s", limits.Nice, target)
+		}
+		if limits.MaxMemoryMB > 0 {
+			// 优先使用 cgroup 限制常驻内存；不可用时退回 ulimit -v 限制虚拟内存
+			fmt.Fprintf(&b, "if command -v systemd-run >/dev/null 2>&1 && systemd-run --user --scope --quiet true >/dev/null 2>&1; "+
+				"then exec systemd-run --user --scope --quiet -p MemoryMax=%dM %s; fi; ", limits.MaxMemoryMB, target)
+			fmt.Fprintf(&b, "ulimit -v %d; ", limits.MaxMemoryMB*1024)
+		}
+	}
+
+	b.WriteString("exec " + target)
+	return b.String()
+}
+
+// killGracePeriod 解析 SIGTERM 到 SIGKILL 的等待时间，未配置时返回 0
+func killGracePeriod(limits *config.ResourceLimits) time.Duration {
+	if limits == nil || limits.KillGracePeriod == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(limits.KillGracePeriod)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// newPIDFile 生成发行版内用于记录任务进程 PID 的临时文件路径，taskID 非空时包含在文件名中便于排查
+func newPIDFile(taskID string) string {
+	if taskID != "" {
+		return fmt.Sprintf("/tmp/auto-claude-code-%d-%s-%d.pid", os.Getpid(), taskID, time.Now().UnixNano())
+	}
+	return fmt.Sprintf("/tmp/auto-claude-code-%d-%d.pid", os.Getpid(), time.Now().UnixNano())
+}
+
+// signalTreeScript 构建向 PID 文件记录的进程及其所有后代进程发送信号的脚本
+// 先收集整个进程树再统一发送，避免父进程退出后子进程被重新挂到 init 下而漏掉
+func signalTreeScript(pidFile, signal string) string {
+	return fmt.Sprintf(`tree() { echo "$1"; for c in $(pgrep -P "$1" 2>/dev/null); do tree "$c"; done; }; `+
+		`pid=$(cat %s 2>/dev/null); if [ -n "$pid" ]; then kill -%s $(tree "$pid") 2>/dev/null; fi; true`,
+		ShellQuote(pidFile), signal)
+}
+
+// signalProcess 在发行版内向 PID 文件记录的进程及其所有后代进程发送信号
+func (wb *wslBridge) signalProcess(distro, pidFile, signal string) {
+	script := signalTreeScript(pidFile, signal)
+
+	if _, err := wb.ExecuteCommandWithOutput(distro, script); err != nil {
+		wb.logger.Warn("发送进程信号失败",
+			zap.String("signal", signal),
+			zap.String("pidFile", pidFile),
+			zap.Error(err))
+	}
+}
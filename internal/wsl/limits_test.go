@@ -0,0 +1,104 @@
+package wsl
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-claude-code/internal/config"
+)
+
+func TestLimitedExecScript(t *testing.T) {
+	tests := []struct {
+		name     string
+		limits   *config.ResourceLimits
+		pidFile  string
+		expected string
+	}{
+		{
+			name:     "无限制",
+			limits:   nil,
+			expected: `exec "$@"`,
+		},
+		{
+			name:     "nice和文件数限制并记录PID",
+			limits:   &config.ResourceLimits{Nice: 10, MaxOpenFiles: 1024},
+			pidFile:  "/tmp/task.pid",
+			expected: `echo $$ > '/tmp/task.pid'; ulimit -n 1024; exec nice -n 10 "$@"`,
+		},
+		{
+			name:   "内存限制",
+			limits: &config.ResourceLimits{MaxMemoryMB: 2048},
+			expected: `if command -v systemd-run >/dev/null 2>&1 && systemd-run --user --scope --quiet true >/dev/null 2>&1; ` +
+				`then exec systemd-run --user --scope --quiet -p MemoryMax=2048M "$@"; fi; ulimit -v 2097152; exec "$@"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := limitedExecScript(tt.limits, tt.pidFile); result != tt.expected {
+				t.Errorf("期望 %q，但得到 %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestSignalTreeScript(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("需要 Linux 的 sh 和 pgrep")
+	}
+	if _, err := exec.LookPath("pgrep"); err != nil {
+		t.Skip("未找到 pgrep")
+	}
+
+	// 父进程、子进程和孙进程都应收到信号
+	pidFile := filepath.Join(t.TempDir(), "task.pid")
+	cmd := exec.Command("sh", "-c", `echo $$ > "$0"; sh -c 'sleep 30 & wait' & sleep 30 & wait`, pidFile)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("启动进程失败: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(done)
+	}()
+
+	var descendants []string
+	for i := 0; i < 100 && len(descendants) < 3; i++ {
+		time.Sleep(20 * time.Millisecond)
+		out, _ := exec.Command("sh", "-c", `tree() { for c in $(pgrep -P "$1"); do echo "$c"; tree "$c"; done; }; tree "$0"`, strconv.Itoa(cmd.Process.Pid)).Output()
+		descendants = strings.Fields(string(out))
+	}
+	if len(descendants) < 3 {
+		t.Fatalf("后代进程 = %v", descendants)
+	}
+
+	if err := exec.Command("sh", "-c", signalTreeScript(pidFile, "KILL")).Run(); err != nil {
+		t.Fatalf("执行信号脚本失败: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("父进程未结束")
+	}
+	// 信号送达需要一点时间，结束的进程可能短暂保留为僵尸进程
+	for _, pid := range descendants {
+		var stat []byte
+		for i := 0; i < 100; i++ {
+			var err error
+			if stat, err = os.ReadFile("/proc/" + pid + "/stat"); err != nil || strings.Contains(string(stat), ") Z ") {
+				stat = nil
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if stat != nil {
+			t.Errorf("后代进程 %s 仍在运行: %s", pid, stat)
+		}
+	}
+}
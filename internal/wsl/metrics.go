@@ -0,0 +1,148 @@
+package wsl
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "auto-claude-code/internal/errors"
+
+	"go.uber.org/zap"
+)
+
+// resourceSampleScript 采集发行版资源使用情况的脚本
+// 两次读取 /proc/stat 以计算采样间隔内的 CPU 使用率
+const resourceSampleScript = `grep -E '^(MemTotal|MemAvailable):' /proc/meminfo; ` +
+	`head -n 1 /proc/stat; sleep 0.2; head -n 1 /proc/stat; ` +
+	`echo "loadavg $(cat /proc/loadavg)"; ` +
+	`echo "disk $(df -Pk / | tail -n 1)"`
+
+// ResourceMetrics 发行版资源使用情况
+type ResourceMetrics struct {
+	Distro            string     `json:"distro"`
+	MemoryTotalKB     uint64     `json:"memoryTotalKB"`
+	MemoryAvailableKB uint64     `json:"memoryAvailableKB"`
+	MemoryUsedPercent float64    `json:"memoryUsedPercent"`
+	CPUUsedPercent    float64    `json:"cpuUsedPercent"`
+	LoadAverage       [3]float64 `json:"loadAverage"`
+	DiskTotalKB       uint64     `json:"diskTotalKB"`
+	DiskUsedKB        uint64     `json:"diskUsedKB"`
+	DiskUsedPercent   float64    `json:"diskUsedPercent"`
+	SampledAt         time.Time  `json:"sampledAt"`
+}
+
+// GetResourceMetrics 采样发行版内的内存、CPU 和磁盘使用情况
+func (wb *wslBridge) GetResourceMetrics(distro string) (*ResourceMetrics, error) {
+	wb.logger.Debug("采集 WSL 资源指标", zap.String("distro", distro))
+
+	output, err := wb.ExecuteCommandWithOutput(distro, resourceSampleScript)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := parseResourceMetrics(output)
+	if err != nil {
+		return nil, err
+	}
+	metrics.Distro = distro
+
+	return metrics, nil
+}
+
+// parseResourceMetrics 解析资源采样脚本的输出
+func parseResourceMetrics(output string) (*ResourceMetrics, error) {
+	metrics := &ResourceMetrics{SampledAt: time.Now()}
+
+	var cpuSamples [][]uint64
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "MemTotal:":
+			metrics.MemoryTotalKB = parseUint(fields, 1)
+		case "MemAvailable:":
+			metrics.MemoryAvailableKB = parseUint(fields, 1)
+		case "cpu":
+			sample := make([]uint64, 0, len(fields)-1)
+			for i := 1; i < len(fields); i++ {
+				sample = append(sample, parseUint(fields, i))
+			}
+			cpuSamples = append(cpuSamples, sample)
+		case "loadavg":
+			for i := 0; i < 3 && i+1 < len(fields); i++ {
+				metrics.LoadAverage[i], _ = strconv.ParseFloat(fields[i+1], 64)
+			}
+		case "disk":
+			// disk <filesystem> <1024-blocks> <used> <available> <capacity> <mounted on>
+			metrics.DiskTotalKB = parseUint(fields, 2)
+			metrics.DiskUsedKB = parseUint(fields, 3)
+		}
+	}
+
+	if metrics.MemoryTotalKB == 0 {
+		return nil, apperrors.New(apperrors.ErrWSLCommandFailed, "无法解析 WSL 内存信息")
+	}
+
+	metrics.MemoryUsedPercent = percent(metrics.MemoryTotalKB-metrics.MemoryAvailableKB, metrics.MemoryTotalKB)
+	metrics.DiskUsedPercent = percent(metrics.DiskUsedKB, metrics.DiskTotalKB)
+
+	if len(cpuSamples) >= 2 {
+		metrics.CPUUsedPercent = cpuUsage(cpuSamples[0], cpuSamples[1])
+	}
+
+	return metrics, nil
+}
+
+// cpuUsage 根据两次 /proc/stat 采样计算 CPU 使用率
+// 字段顺序: user nice system idle iowait irq softirq steal ...
+func cpuUsage(before, after []uint64) float64 {
+	var totalBefore, totalAfter uint64
+	for _, v := range before {
+		totalBefore += v
+	}
+	for _, v := range after {
+		totalAfter += v
+	}
+
+	idle := func(sample []uint64) uint64 {
+		var v uint64
+		if len(sample) > 3 {
+			v += sample[3]
+		}
+		if len(sample) > 4 {
+			v += sample[4]
+		}
+		return v
+	}
+
+	if totalAfter <= totalBefore {
+		return 0
+	}
+	total := totalAfter - totalBefore
+	idleDelta := idle(after) - idle(before)
+	if idleDelta > total {
+		return 0
+	}
+
+	return percent(total-idleDelta, total)
+}
+
+// parseUint 安全地解析指定位置的字段
+func parseUint(fields []string, index int) uint64 {
+	if index >= len(fields) {
+		return 0
+	}
+	v, _ := strconv.ParseUint(fields[index], 10, 64)
+	return v
+}
+
+// percent 计算百分比，保留一位小数
+func percent(part, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(int(float64(part)*1000/float64(total))) / 10
+}
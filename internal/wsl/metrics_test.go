@@ -0,0 +1,42 @@
+package wsl
+
+import (
+	"testing"
+)
+
+func TestParseResourceMetrics(t *testing.T) {
+	output := `MemTotal:        8000000 kB
+MemAvailable:    2000000 kB
+cpu  100 0 100 800 0 0 0 0 0 0
+cpu  150 0 150 850 50 0 0 0 0 0
+loadavg 1.50 0.75 0.25 2/300 1234
+disk /dev/sdc 1000000 250000 750000 25% /`
+
+	metrics, err := parseResourceMetrics(output)
+	if err != nil {
+		t.Fatalf("意外的错误: %v", err)
+	}
+
+	if metrics.MemoryUsedPercent != 75 {
+		t.Errorf("内存使用率期望 75，但得到 %v", metrics.MemoryUsedPercent)
+	}
+
+	// 总增量 200，空闲增量 (850+50)-(800+0)=100
+	if metrics.CPUUsedPercent != 50 {
+		t.Errorf("CPU 使用率期望 50，但得到 %v", metrics.CPUUsedPercent)
+	}
+
+	if metrics.LoadAverage != [3]float64{1.5, 0.75, 0.25} {
+		t.Errorf("负载期望 [1.5 0.75 0.25]，但得到 %v", metrics.LoadAverage)
+	}
+
+	if metrics.DiskUsedPercent != 25 {
+		t.Errorf("磁盘使用率期望 25，但得到 %v", metrics.DiskUsedPercent)
+	}
+}
+
+func TestParseResourceMetrics_Invalid(t *testing.T) {
+	if _, err := parseResourceMetrics("garbage"); err == nil {
+		t.Errorf("期望错误但没有返回错误")
+	}
+}
@@ -0,0 +1,112 @@
+package wsl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// minNodeMajor Claude Code 要求的最低 Node.js 主版本
+const minNodeMajor = 18
+
+// nodeDiagnosticScript 收集 Node.js 运行时信息的脚本
+// plain 一项在最小环境的非登录 shell 中查找 node，用于判断 node 是否依赖 profile 中的 PATH 设置
+const nodeDiagnosticScript = `echo "node $(command -v node)"; ` +
+	`echo "version $(node --version 2>/dev/null)"; ` +
+	`echo "npm $(npm --version 2>/dev/null)"; ` +
+	`echo "nvm_dir $([ -s "${NVM_DIR:-$HOME/.nvm}/nvm.sh" ] && echo yes)"; ` +
+	`echo "nvm_loaded $(command -v nvm >/dev/null 2>&1 && echo yes)"; ` +
+	`echo "plain $(env -i HOME="$HOME" PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin sh -c 'command -v node')"`
+
+// NodeDiagnostics 发行版中 Node.js 运行时的诊断信息
+type NodeDiagnostics struct {
+	NodePath     string `json:"nodePath,omitempty"`
+	NodeVersion  string `json:"nodeVersion,omitempty"`
+	NPMVersion   string `json:"npmVersion,omitempty"`
+	NVMInstalled bool   `json:"nvmInstalled"`
+	NVMLoaded    bool   `json:"nvmLoaded"`
+	PlainPath    string `json:"plainPath,omitempty"` // 非登录、无 profile 的 shell 中找到的 node
+}
+
+// DiagnoseNode 诊断发行版中 Claude Code 依赖的 Node.js 运行时
+func (wb *wslBridge) DiagnoseNode(distro string) (*NodeDiagnostics, error) {
+	wb.logger.Debug("诊断 Node.js 环境", zap.String("distro", distro))
+
+	output, err := wb.ExecuteCommandWithOutput(distro, nodeDiagnosticScript)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseNodeDiagnostics(output), nil
+}
+
+// parseNodeDiagnostics 解析诊断脚本的输出
+func parseNodeDiagnostics(output string) *NodeDiagnostics {
+	diag := &NodeDiagnostics{}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "node":
+			diag.NodePath = value
+		case "version":
+			diag.NodeVersion = value
+		case "npm":
+			diag.NPMVersion = value
+		case "nvm_dir":
+			diag.NVMInstalled = value == "yes"
+		case "nvm_loaded":
+			diag.NVMLoaded = value == "yes"
+		case "plain":
+			diag.PlainPath = value
+		}
+	}
+	return diag
+}
+
+// UsesNVM node 是否由 nvm 提供
+func (d *NodeDiagnostics) UsesNVM() bool {
+	return strings.Contains(d.NodePath, "/.nvm/")
+}
+
+// Issues 根据诊断信息给出具体问题和修复建议
+func (d *NodeDiagnostics) Issues() []string {
+	var issues []string
+
+	switch {
+	case d.NodePath == "" && d.NVMInstalled:
+		issues = append(issues, "检测到 nvm 但当前 shell 未加载，node 不在 PATH 中；"+
+			"请在 ~/.profile 或 ~/.bash_profile 中加载 nvm.sh，并确保 wsl.login_shell 为 true")
+	case d.NodePath == "":
+		issues = append(issues, fmt.Sprintf("未找到 Node.js，请在发行版中安装 Node.js %d+（推荐使用 nvm）", minNodeMajor))
+	case strings.HasPrefix(d.NodePath, "/mnt/"):
+		issues = append(issues, fmt.Sprintf("当前使用的是 Windows 上的 Node.js (%s)，请在发行版中安装 Linux 版本", d.NodePath))
+	}
+
+	if d.NodePath != "" {
+		if major := nodeMajorVersion(d.NodeVersion); major > 0 && major < minNodeMajor {
+			issues = append(issues, fmt.Sprintf("Node.js 版本过低 (%s)，Claude Code 需要 %d+", d.NodeVersion, minNodeMajor))
+		}
+		if d.NPMVersion == "" {
+			issues = append(issues, "未找到 npm，无法通过 npm 安装或更新 Claude Code")
+		}
+		if d.UsesNVM() && d.PlainPath == "" {
+			issues = append(issues, "node 由 nvm 提供，仅在加载 nvm 的 shell 中可用；关闭 wsl.login_shell 后将找不到 node 和 Claude Code")
+		}
+	}
+
+	return issues
+}
+
+// nodeMajorVersion 解析 vX.Y.Z 形式的主版本号
+func nodeMajorVersion(version string) int {
+	major, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return 0
+	}
+	return n
+}
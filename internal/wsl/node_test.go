@@ -0,0 +1,49 @@
+package wsl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNodeDiagnostics_Issues(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		contains []string
+	}{
+		{
+			name:     "系统node正常",
+			output:   "node /usr/bin/node\nversion v20.11.1\nnpm 10.2.4\nnvm_dir\nnvm_loaded\nplain /usr/bin/node",
+			contains: nil,
+		},
+		{
+			name:     "nvm未加载",
+			output:   "node\nversion\nnpm\nnvm_dir yes\nnvm_loaded\nplain",
+			contains: []string{"nvm"},
+		},
+		{
+			name:     "使用Windows上的node",
+			output:   "node /mnt/c/Program Files/nodejs/node\nversion v20.0.0\nnpm 9.0.0\nnvm_dir\nnvm_loaded\nplain",
+			contains: []string{"Windows"},
+		},
+		{
+			name:     "nvm提供的旧版本node",
+			output:   "node /home/u/.nvm/versions/node/v16.20.0/bin/node\nversion v16.20.0\nnpm 8.19.4\nnvm_dir yes\nnvm_loaded yes\nplain",
+			contains: []string{"版本过低", "login_shell"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := parseNodeDiagnostics(tt.output).Issues()
+			if len(issues) != len(tt.contains) {
+				t.Fatalf("期望 %d 个问题，但得到 %v", len(tt.contains), issues)
+			}
+			for i, keyword := range tt.contains {
+				if !strings.Contains(issues[i], keyword) {
+					t.Errorf("问题 %q 应包含 %q", issues[i], keyword)
+				}
+			}
+		})
+	}
+}
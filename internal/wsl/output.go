@@ -0,0 +1,123 @@
+package wsl
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// 输出流名称
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// OutputOptions 命令输入输出转发选项
+// 为 nil 时使用当前进程的标准输入输出
+type OutputOptions struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// OnLine 按行回调，stream 为 StreamStdout 或 StreamStderr
+	OnLine func(stream, line string)
+
+	// MaxCaptureBytes 捕获到 ExecResult 的 stdout 和 stderr 各自的上限，超过时保留开头和结尾，0 表示不限制
+	// 只影响 ExecResult，OnLine 和转发的写入器仍收到全部输出
+	MaxCaptureBytes int
+}
+
+// defaultOutputOptions 使用当前进程标准输入输出的默认选项
+func defaultOutputOptions() *OutputOptions {
+	return &OutputOptions{
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+// writers 组合出最终的 stdout/stderr 写入器，extra 中的写入器会一并接收输出
+// 返回的 flush 函数需在命令结束后调用，用于输出最后一行不完整的内容
+func (o *OutputOptions) writers(extraStdout, extraStderr io.Writer) (stdout, stderr io.Writer, flush func()) {
+	stdoutWriters := []io.Writer{o.Stdout, extraStdout}
+	stderrWriters := []io.Writer{o.Stderr, extraStderr}
+	flush = func() {}
+
+	if o.OnLine != nil {
+		stdoutLines := newLineWriter(StreamStdout, o.OnLine)
+		stderrLines := newLineWriter(StreamStderr, o.OnLine)
+		stdoutWriters = append(stdoutWriters, stdoutLines)
+		stderrWriters = append(stderrWriters, stderrLines)
+		flush = func() {
+			stdoutLines.Flush()
+			stderrLines.Flush()
+		}
+	}
+
+	return combineWriters(stdoutWriters...), combineWriters(stderrWriters...), flush
+}
+
+// combineWriters 合并非空的写入器
+func combineWriters(writers ...io.Writer) io.Writer {
+	var nonNil []io.Writer
+	for _, w := range writers {
+		if w != nil {
+			nonNil = append(nonNil, w)
+		}
+	}
+
+	switch len(nonNil) {
+	case 0:
+		return io.Discard
+	case 1:
+		return nonNil[0]
+	default:
+		return io.MultiWriter(nonNil...)
+	}
+}
+
+// lineWriter 将写入内容按行拆分并回调
+type lineWriter struct {
+	stream string
+	onLine func(stream, line string)
+	buf    bytes.Buffer
+	mutex  sync.Mutex
+}
+
+// newLineWriter 创建按行回调的写入器
+func newLineWriter(stream string, onLine func(stream, line string)) *lineWriter {
+	return &lineWriter{
+		stream: stream,
+		onLine: onLine,
+	}
+}
+
+// Write 实现 io.Writer 接口
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	lw.mutex.Lock()
+	defer lw.mutex.Unlock()
+
+	lw.buf.Write(p)
+	for {
+		idx := bytes.IndexByte(lw.buf.Bytes(), '\n')
+		if idx < 0 {
+			break
+		}
+		line := string(bytes.TrimRight(lw.buf.Next(idx+1), "\r\n"))
+		lw.onLine(lw.stream, line)
+	}
+
+	return len(p), nil
+}
+
+// Flush 输出缓冲区中剩余的不完整行
+func (lw *lineWriter) Flush() {
+	lw.mutex.Lock()
+	defer lw.mutex.Unlock()
+
+	if lw.buf.Len() > 0 {
+		lw.onLine(lw.stream, lw.buf.String())
+		lw.buf.Reset()
+	}
+}
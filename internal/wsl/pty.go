@@ -0,0 +1,120 @@
+package wsl
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	apperrors "auto-claude-code/internal/errors"
+	"auto-claude-code/internal/tracing"
+)
+
+// PTYSize 伪终端的列数和行数
+type PTYSize struct {
+	Cols int `json:"cols"`
+	Rows int `json:"rows"`
+}
+
+// DefaultPTYSize 客户端未指定尺寸时伪终端的大小
+var DefaultPTYSize = PTYSize{Cols: 120, Rows: 30}
+
+// valid 尺寸是否可用于伪终端
+func (s PTYSize) valid() bool {
+	return s.Cols > 0 && s.Rows > 0 && s.Cols <= 0x7FFF && s.Rows <= 0x7FFF
+}
+
+// PTY 在伪终端中运行的进程，Read 读取终端输出，Write 写入键盘输入
+// 进程退出后 Read 在输出读完时返回 io.EOF
+type PTY interface {
+	io.ReadWriter
+
+	// Resize 调整终端尺寸
+	Resize(size PTYSize) error
+
+	// Wait 等待进程退出并返回退出码
+	Wait() (int, error)
+
+	// Close 结束仍在运行的进程并释放伪终端，可重复调用
+	Close() error
+}
+
+// StartClaudeCodePTY 在伪终端中启动交互式 Claude Code，调用方负责读写终端，并在结束后调用 Close
+func (wb *wslBridge) StartClaudeCodePTY(ctx context.Context, distro, workingDir string, args []string, opts *RunOptions, size PTYSize) (_ PTY, err error) {
+	_, span := tracing.StartKind(ctx, tracing.SpanKindClient, "wsl.start_claude_code_pty",
+		tracing.String("wsl.distro", distro),
+		tracing.String("wsl.working_dir", workingDir))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	wb.logger.Info("在伪终端中启动 Claude Code",
+		zap.String("distro", distro),
+		zap.String("workingDir", workingDir),
+		zap.Strings("args", args))
+
+	if err := wb.CheckClaudeCode(distro); err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &RunOptions{}
+	}
+	if !size.valid() {
+		size = DefaultPTYSize
+	}
+
+	// 与 RunClaudeCode 相同，记录 PID 以便结束发行版内的进程树
+	pidFile := newPIDFile(opts.TaskID)
+	script := limitedExecScript(opts.Limits, pidFile)
+	if opts.GPU {
+		script = gpuEnvExports + script
+	}
+	argv := wb.executor.Command(distro, workingDir, true, wb.shellArgs(script, wb.claudeArgv(args)...))
+	path, err := exec.LookPath(argv[0])
+	if err != nil {
+		return nil, apperrors.Wrapf(err, apperrors.ErrWSLNotFound, "%s 命令不可用", argv[0])
+	}
+	argv[0] = path
+
+	pty, err := startPTY(argv, append(os.Environ(), "TERM=xterm-256color"), size)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bridgePTY{
+		PTY: pty,
+		cleanup: func(exited bool) {
+			if !exited {
+				wb.signalProcess(distro, pidFile, "KILL")
+			}
+			wb.ExecuteCommandWithOutput(distro, "rm -f "+ShellQuote(pidFile))
+		},
+	}, nil
+}
+
+// bridgePTY 关闭时结束发行版内的进程树并删除 PID 文件
+// 仅关闭本地伪终端不一定会结束发行版内的进程
+type bridgePTY struct {
+	PTY
+	cleanup   func(exited bool)
+	exited    atomic.Bool
+	closeOnce sync.Once
+}
+
+// Wait 等待进程退出并记录已退出
+func (p *bridgePTY) Wait() (int, error) {
+	code, err := p.PTY.Wait()
+	p.exited.Store(true)
+	return code, err
+}
+
+// Close 结束发行版内仍在运行的进程后释放伪终端
+func (p *bridgePTY) Close() error {
+	p.closeOnce.Do(func() { p.cleanup(p.exited.Load()) })
+	return p.PTY.Close()
+}
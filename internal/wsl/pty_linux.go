@@ -0,0 +1,133 @@
+//go:build linux
+
+package wsl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// unixPTY 通过 /dev/ptmx 创建的伪终端
+type unixPTY struct {
+	master    *os.File
+	cmd       *exec.Cmd
+	closeOnce sync.Once
+}
+
+// startPTY 在新的伪终端中启动进程，进程成为新会话的首进程并以伪终端为控制终端
+func startPTY(argv []string, env []string, size PTYSize) (PTY, error) {
+	// 以非阻塞方式打开，读写经过运行时的网络轮询器，Close 可以中断阻塞的 Read
+	fd, err := unix.Open("/dev/ptmx", unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "无法创建伪终端")
+	}
+	master := os.NewFile(uintptr(fd), "/dev/ptmx")
+
+	var ptsNumber int
+	if err := control(master, func(fd int) error {
+		if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+			return err
+		}
+		n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+		ptsNumber = n
+		return err
+	}); err != nil {
+		master.Close()
+		return nil, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "无法解锁伪终端")
+	}
+
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", ptsNumber), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "无法打开伪终端从设备")
+	}
+	defer slave.Close()
+
+	p := &unixPTY{master: master}
+	if err := p.Resize(size); err != nil {
+		master.Close()
+		return nil, err
+	}
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Env = env
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		return nil, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "Claude Code 启动失败")
+	}
+	p.cmd = cmd
+	return p, nil
+}
+
+// control 在伪终端主设备的文件描述符上执行 ioctl
+func control(f *os.File, fn func(fd int) error) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var fnErr error
+	if err := conn.Control(func(fd uintptr) { fnErr = fn(int(fd)) }); err != nil {
+		return err
+	}
+	return fnErr
+}
+
+// Read 读取终端输出，从设备全部关闭后 Linux 返回 EIO，这里转换为 io.EOF
+func (p *unixPTY) Read(b []byte) (int, error) {
+	n, err := p.master.Read(b)
+	if errors.Is(err, syscall.EIO) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Write 写入键盘输入
+func (p *unixPTY) Write(b []byte) (int, error) {
+	return p.master.Write(b)
+}
+
+// Resize 调整终端尺寸，前台进程组会收到 SIGWINCH
+func (p *unixPTY) Resize(size PTYSize) error {
+	if !size.valid() {
+		return apperrors.Newf(apperrors.ErrInvalidRequest, "无效的终端尺寸: %dx%d", size.Cols, size.Rows)
+	}
+	err := control(p.master, func(fd int) error {
+		return unix.IoctlSetWinsize(fd, unix.TIOCSWINSZ, &unix.Winsize{Row: uint16(size.Rows), Col: uint16(size.Cols)})
+	})
+	if err != nil {
+		return apperrors.Wrap(err, apperrors.ErrInternal, "调整终端尺寸失败")
+	}
+	return nil
+}
+
+// Wait 等待进程退出，非零退出码不视为错误
+func (p *unixPTY) Wait() (int, error) {
+	err := p.cmd.Wait()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return -1, err
+	}
+	return p.cmd.ProcessState.ExitCode(), nil
+}
+
+// Close 结束仍在运行的进程并关闭主设备
+func (p *unixPTY) Close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		// 进程已退出时 Kill 返回 os.ErrProcessDone
+		p.cmd.Process.Kill()
+		err = p.master.Close()
+	})
+	return err
+}
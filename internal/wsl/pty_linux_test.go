@@ -0,0 +1,54 @@
+//go:build linux
+
+package wsl
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStartPTY(t *testing.T) {
+	if _, err := os.Stat("/dev/ptmx"); err != nil {
+		t.Skip("没有 /dev/ptmx")
+	}
+
+	pty, err := startPTY([]string{"/bin/sh", "-c", `stty size; read line; echo "got:$line"; exit 3`}, os.Environ(), PTYSize{Cols: 100, Rows: 40})
+	if err != nil {
+		t.Fatalf("startPTY() error = %v", err)
+	}
+	defer pty.Close()
+
+	if _, err := pty.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&out, pty)
+		close(done)
+	}()
+
+	code, err := pty.Wait()
+	if err != nil || code != 3 {
+		t.Errorf("Wait() = %d, %v", code, err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		pty.Close()
+		<-done
+	}
+
+	if got := out.String(); !strings.Contains(got, "40 100") || !strings.Contains(got, "got:hello") {
+		t.Errorf("终端输出 = %q", got)
+	}
+
+	if err := pty.Resize(PTYSize{}); err == nil {
+		t.Error("Resize() 无效尺寸应返回错误")
+	}
+}
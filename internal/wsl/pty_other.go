@@ -0,0 +1,19 @@
+//go:build !windows
+
+package wsl
+
+import (
+	apperrors "auto-claude-code/internal/errors"
+
+	"go.uber.org/zap"
+)
+
+// isTerminal 非 Windows 平台不提供 ConPTY，总是回退到管道模式
+func isTerminal() bool {
+	return false
+}
+
+// runInPTY 非 Windows 平台不支持 ConPTY
+func runInPTY(logger *zap.Logger, argv []string, env []string) (int, error) {
+	return -1, apperrors.New(apperrors.ErrClaudeCodeFailed, "当前平台不支持 ConPTY 伪终端")
+}
@@ -0,0 +1,12 @@
+//go:build !windows && !linux
+
+package wsl
+
+import (
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// startPTY 当前平台不支持伪终端
+func startPTY(argv []string, env []string, size PTYSize) (PTY, error) {
+	return nil, apperrors.New(apperrors.ErrClaudeCodeFailed, "当前平台不支持伪终端")
+}
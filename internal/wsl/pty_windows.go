@@ -0,0 +1,332 @@
+//go:build windows
+
+package wsl
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	apperrors "auto-claude-code/internal/errors"
+
+	"go.uber.org/zap"
+)
+
+// ptyResizePollInterval 终端尺寸轮询间隔
+const ptyResizePollInterval = 250 * time.Millisecond
+
+// isTerminal 检查标准输入输出是否连接到控制台
+func isTerminal() bool {
+	var mode uint32
+	if err := windows.GetConsoleMode(windows.Handle(os.Stdin.Fd()), &mode); err != nil {
+		return false
+	}
+	if err := windows.GetConsoleMode(windows.Handle(os.Stdout.Fd()), &mode); err != nil {
+		return false
+	}
+	return true
+}
+
+// consoleSize 获取当前控制台窗口尺寸
+func consoleSize() (windows.Coord, error) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(os.Stdout.Fd()), &info); err != nil {
+		return windows.Coord{}, err
+	}
+	return windows.Coord{
+		X: info.Window.Right - info.Window.Left + 1,
+		Y: info.Window.Bottom - info.Window.Top + 1,
+	}, nil
+}
+
+// runInPTY 在 ConPTY 伪终端中运行命令，返回进程退出码
+func runInPTY(logger *zap.Logger, argv []string, env []string) (int, error) {
+	size, err := consoleSize()
+	if err != nil {
+		return -1, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "无法获取控制台尺寸")
+	}
+
+	// 创建伪终端的输入输出管道
+	var ptyInRead, ptyInWrite, ptyOutRead, ptyOutWrite windows.Handle
+	if err := windows.CreatePipe(&ptyInRead, &ptyInWrite, nil, 0); err != nil {
+		return -1, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "无法创建伪终端输入管道")
+	}
+	if err := windows.CreatePipe(&ptyOutRead, &ptyOutWrite, nil, 0); err != nil {
+		windows.CloseHandle(ptyInRead)
+		windows.CloseHandle(ptyInWrite)
+		return -1, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "无法创建伪终端输出管道")
+	}
+
+	var hpc windows.Handle
+	err = windows.CreatePseudoConsole(size, ptyInRead, ptyOutWrite, 0, &hpc)
+	// 伪终端已持有管道的另一端，这里可以关闭
+	windows.CloseHandle(ptyInRead)
+	windows.CloseHandle(ptyOutWrite)
+	if err != nil {
+		windows.CloseHandle(ptyInWrite)
+		windows.CloseHandle(ptyOutRead)
+		return -1, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "无法创建 ConPTY 伪终端")
+	}
+
+	ptyIn := os.NewFile(uintptr(ptyInWrite), "conpty-in")
+	ptyOut := os.NewFile(uintptr(ptyOutRead), "conpty-out")
+	defer ptyIn.Close()
+	defer ptyOut.Close()
+
+	var closeOnce sync.Once
+	closePTY := func() { closeOnce.Do(func() { windows.ClosePseudoConsole(hpc) }) }
+	defer closePTY()
+
+	pi, err := startPTYProcess(hpc, argv, env)
+	if err != nil {
+		return -1, err
+	}
+	defer windows.CloseHandle(pi.Process)
+	defer windows.CloseHandle(pi.Thread)
+
+	logger.Info("Claude Code 已在 ConPTY 中启动", zap.Uint32("pid", pi.ProcessId))
+
+	// 切换控制台到原始 VT 模式，结束时恢复
+	restore := enableRawConsole(logger)
+	defer restore()
+
+	// 转发输入输出
+	outputDone := make(chan struct{})
+	go func() {
+		io.Copy(os.Stdout, ptyOut)
+		close(outputDone)
+	}()
+	go io.Copy(ptyIn, os.Stdin)
+
+	// 跟踪窗口尺寸变化
+	stopResize := make(chan struct{})
+	go watchConsoleResize(logger, hpc, size, stopResize)
+
+	_, err = windows.WaitForSingleObject(pi.Process, windows.INFINITE)
+	close(stopResize)
+	if err != nil {
+		return -1, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "等待 Claude Code 进程失败")
+	}
+
+	var exitCode uint32
+	if err := windows.GetExitCodeProcess(pi.Process, &exitCode); err != nil {
+		return -1, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "无法获取 Claude Code 退出码")
+	}
+
+	// 关闭伪终端后输出管道才会结束
+	closePTY()
+	<-outputDone
+
+	return int(exitCode), nil
+}
+
+// startPTYProcess 以伪终端作为控制台启动进程
+func startPTYProcess(hpc windows.Handle, argv []string, env []string) (*windows.ProcessInformation, error) {
+	attrs, err := windows.NewProcThreadAttributeList(1)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "无法创建进程属性列表")
+	}
+	defer attrs.Delete()
+
+	// PROC_THREAD_ATTRIBUTE_PSEUDOCONSOLE 的值就是 HPCON 本身
+	if err := attrs.Update(windows.PROC_THREAD_ATTRIBUTE_PSEUDOCONSOLE, *(*unsafe.Pointer)(unsafe.Pointer(&hpc)), unsafe.Sizeof(hpc)); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "无法设置伪终端属性")
+	}
+
+	si := &windows.StartupInfoEx{}
+	si.Cb = uint32(unsafe.Sizeof(*si))
+	// 不继承父进程的标准句柄，确保子进程使用伪终端
+	si.Flags = windows.STARTF_USESTDHANDLES
+	si.ProcThreadAttributeList = attrs.List()
+
+	cmdLine, err := windows.UTF16PtrFromString(windows.ComposeCommandLine(argv))
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "无效的命令行")
+	}
+
+	envBlock := createEnvBlock(env)
+
+	pi := &windows.ProcessInformation{}
+	flags := uint32(windows.EXTENDED_STARTUPINFO_PRESENT | windows.CREATE_UNICODE_ENVIRONMENT)
+	if err := windows.CreateProcess(nil, cmdLine, nil, nil, false, flags, &envBlock[0], nil, &si.StartupInfo, pi); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "Claude Code 启动失败")
+	}
+
+	return pi, nil
+}
+
+// createEnvBlock 构建 CreateProcess 所需的 UTF-16 环境变量块
+func createEnvBlock(env []string) []uint16 {
+	if len(env) == 0 {
+		return []uint16{0, 0}
+	}
+
+	var block []uint16
+	for _, kv := range env {
+		block = append(block, utf16.Encode([]rune(kv))...)
+		block = append(block, 0)
+	}
+	return append(block, 0)
+}
+
+// enableRawConsole 将控制台切换为原始 VT 输入/输出模式，返回恢复函数
+func enableRawConsole(logger *zap.Logger) func() {
+	stdin := windows.Handle(os.Stdin.Fd())
+	stdout := windows.Handle(os.Stdout.Fd())
+
+	var inMode, outMode uint32
+	inErr := windows.GetConsoleMode(stdin, &inMode)
+	outErr := windows.GetConsoleMode(stdout, &outMode)
+
+	if inErr == nil {
+		if err := windows.SetConsoleMode(stdin, windows.ENABLE_VIRTUAL_TERMINAL_INPUT); err != nil {
+			logger.Warn("无法设置控制台原始输入模式", zap.Error(err))
+		}
+	}
+	if outErr == nil {
+		rawOut := outMode | windows.ENABLE_PROCESSED_OUTPUT | windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING | windows.DISABLE_NEWLINE_AUTO_RETURN
+		if err := windows.SetConsoleMode(stdout, rawOut); err != nil {
+			logger.Warn("无法设置控制台 VT 输出模式", zap.Error(err))
+		}
+	}
+
+	return func() {
+		if inErr == nil {
+			windows.SetConsoleMode(stdin, inMode)
+		}
+		if outErr == nil {
+			windows.SetConsoleMode(stdout, outMode)
+		}
+	}
+}
+
+// watchConsoleResize 轮询控制台尺寸并同步到伪终端
+func watchConsoleResize(logger *zap.Logger, hpc windows.Handle, last windows.Coord, stop <-chan struct{}) {
+	ticker := time.NewTicker(ptyResizePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			size, err := consoleSize()
+			if err != nil || size == last {
+				continue
+			}
+			if err := windows.ResizePseudoConsole(hpc, size); err != nil {
+				logger.Debug("调整伪终端尺寸失败", zap.Error(err))
+				continue
+			}
+			last = size
+		}
+	}
+}
+
+// conPTY 通过 ConPTY 创建的伪终端
+type conPTY struct {
+	hpc       windows.Handle
+	in        *os.File
+	out       *os.File
+	pi        *windows.ProcessInformation
+	closeOnce sync.Once
+	hpcOnce   sync.Once
+}
+
+// startPTY 在新的 ConPTY 伪终端中启动进程
+func startPTY(argv []string, env []string, size PTYSize) (PTY, error) {
+	var ptyInRead, ptyInWrite, ptyOutRead, ptyOutWrite windows.Handle
+	if err := windows.CreatePipe(&ptyInRead, &ptyInWrite, nil, 0); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "无法创建伪终端输入管道")
+	}
+	if err := windows.CreatePipe(&ptyOutRead, &ptyOutWrite, nil, 0); err != nil {
+		windows.CloseHandle(ptyInRead)
+		windows.CloseHandle(ptyInWrite)
+		return nil, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "无法创建伪终端输出管道")
+	}
+
+	var hpc windows.Handle
+	err := windows.CreatePseudoConsole(windows.Coord{X: int16(size.Cols), Y: int16(size.Rows)}, ptyInRead, ptyOutWrite, 0, &hpc)
+	windows.CloseHandle(ptyInRead)
+	windows.CloseHandle(ptyOutWrite)
+	if err != nil {
+		windows.CloseHandle(ptyInWrite)
+		windows.CloseHandle(ptyOutRead)
+		return nil, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "无法创建 ConPTY 伪终端")
+	}
+
+	p := &conPTY{
+		hpc: hpc,
+		in:  os.NewFile(uintptr(ptyInWrite), "conpty-in"),
+		out: os.NewFile(uintptr(ptyOutRead), "conpty-out"),
+	}
+	pi, err := startPTYProcess(hpc, argv, env)
+	if err != nil {
+		p.closePseudoConsole()
+		p.in.Close()
+		p.out.Close()
+		return nil, err
+	}
+	p.pi = pi
+	return p, nil
+}
+
+// Read 读取终端输出
+func (p *conPTY) Read(b []byte) (int, error) {
+	return p.out.Read(b)
+}
+
+// Write 写入键盘输入
+func (p *conPTY) Write(b []byte) (int, error) {
+	return p.in.Write(b)
+}
+
+// Resize 调整伪终端尺寸
+func (p *conPTY) Resize(size PTYSize) error {
+	if !size.valid() {
+		return apperrors.Newf(apperrors.ErrInvalidRequest, "无效的终端尺寸: %dx%d", size.Cols, size.Rows)
+	}
+	if err := windows.ResizePseudoConsole(p.hpc, windows.Coord{X: int16(size.Cols), Y: int16(size.Rows)}); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrInternal, "调整终端尺寸失败")
+	}
+	return nil
+}
+
+// Wait 等待进程退出，之后关闭伪终端使输出管道在读完后结束
+func (p *conPTY) Wait() (int, error) {
+	if _, err := windows.WaitForSingleObject(p.pi.Process, windows.INFINITE); err != nil {
+		return -1, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "等待 Claude Code 进程失败")
+	}
+
+	var exitCode uint32
+	if err := windows.GetExitCodeProcess(p.pi.Process, &exitCode); err != nil {
+		return -1, apperrors.Wrap(err, apperrors.ErrClaudeCodeFailed, "无法获取 Claude Code 退出码")
+	}
+	p.closePseudoConsole()
+	return int(exitCode), nil
+}
+
+// Close 结束仍在运行的进程并释放伪终端和管道
+func (p *conPTY) Close() error {
+	p.closeOnce.Do(func() {
+		if event, _ := windows.WaitForSingleObject(p.pi.Process, 0); event == uint32(windows.WAIT_TIMEOUT) {
+			windows.TerminateProcess(p.pi.Process, 1)
+		}
+		p.closePseudoConsole()
+		p.in.Close()
+		p.out.Close()
+		windows.CloseHandle(p.pi.Process)
+		windows.CloseHandle(p.pi.Thread)
+	})
+	return nil
+}
+
+// closePseudoConsole 关闭伪终端，可重复调用
+func (p *conPTY) closePseudoConsole() {
+	p.hpcOnce.Do(func() { windows.ClosePseudoConsole(p.hpc) })
+}
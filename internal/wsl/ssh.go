@@ -0,0 +1,81 @@
+package wsl
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"auto-claude-code/internal/config"
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// sshExecutor 通过 ssh 在远程 Linux 主机上执行
+type sshExecutor struct {
+	config config.SSHConfig
+}
+
+// newSSHExecutor 创建 SSH 执行后端
+func newSSHExecutor(cfg config.SSHConfig) *sshExecutor {
+	return &sshExecutor{config: cfg}
+}
+
+// Name 获取后端名称
+func (e *sshExecutor) Name() string {
+	return BackendSSH
+}
+
+// Check 检查 ssh 客户端是否存在以及远程主机是否可连接
+func (e *sshExecutor) Check() error {
+	if _, err := exec.LookPath("ssh"); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrWSLNotFound, "ssh 命令不可用")
+	}
+
+	args := e.Command("", "", false, []string{"true"})
+	if output, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+		return apperrors.Wrapf(err, apperrors.ErrWSLNotFound, "无法连接到远程主机 %s", e.target()).
+			WithDetails(strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// Command 构建 ssh 调用参数
+// ssh 会把剩余参数用空格拼接后交给远程 shell，因此每个参数都需要单独引用
+func (e *sshExecutor) Command(distro, workDir string, tty bool, shellArgs []string) []string {
+	args := []string{"ssh"}
+
+	if tty {
+		args = append(args, "-t")
+	} else {
+		// 非交互调用禁止密码提示，避免进程挂起等待输入
+		args = append(args, "-T", "-o", "BatchMode=yes")
+	}
+
+	if e.config.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(e.config.Port))
+	}
+	if e.config.KeyFile != "" {
+		args = append(args, "-i", e.config.KeyFile)
+	}
+	args = append(args, e.config.ExtraArgs...)
+	args = append(args, e.target(), "--")
+
+	quoted := make([]string, len(shellArgs))
+	for i, arg := range shellArgs {
+		quoted[i] = ShellQuote(arg)
+	}
+
+	remote := strings.Join(quoted, " ")
+	if workDir != "" {
+		remote = "cd " + ShellQuote(workDir) + " && " + remote
+	}
+	return append(args, remote)
+}
+
+// target 获取 ssh 连接目标（user@host）
+func (e *sshExecutor) target() string {
+	if e.config.User != "" {
+		return e.config.User + "@" + e.config.Host
+	}
+	return e.config.Host
+}
@@ -0,0 +1,325 @@
+package wsl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 调优建议涉及的配置文件
+const (
+	WSLConfigFile = ".wslconfig"
+	WSLConfFile   = "/etc/wsl.conf"
+)
+
+const (
+	// baseMemoryGB 发行版自身及工具链的基础内存
+	baseMemoryGB = 2
+	// taskMemoryGB 每个并发 Claude Code 任务预留的内存
+	taskMemoryGB = 1
+	// defaultAutomountOptions 推荐的 automount 选项，metadata 使 /mnt 下的文件支持 chmod
+	defaultAutomountOptions = "metadata,umask=22,fmask=11"
+)
+
+// WSLConfReadCommand 读取发行版 wsl.conf 的命令，文件不存在时输出为空
+const WSLConfReadCommand = "cat " + WSLConfFile + " 2>/dev/null || true"
+
+// WSLConfWriteCommand 生成写入发行版 wsl.conf 的命令，写入 /etc 需要 sudo 权限
+func WSLConfWriteCommand(content string) string {
+	return fmt.Sprintf("printf '%%s' %s | sudo tee %s >/dev/null", ShellQuote(content), WSLConfFile)
+}
+
+// HostResources 宿主机资源，未知的字段为 0
+type HostResources struct {
+	MemoryMB uint64
+	CPUs     int
+}
+
+// TuneAdvice 单项 WSL 配置建议
+type TuneAdvice struct {
+	File        string `json:"file"`
+	Section     string `json:"section"`
+	Key         string `json:"key"`
+	Current     string `json:"current,omitempty"`
+	Recommended string `json:"recommended"`
+	Reason      string `json:"reason"`
+}
+
+// AdviseWSLConfig 根据并发任务数检查 .wslconfig 和 wsl.conf 并给出建议
+func AdviseWSLConfig(wslconfig, wslconf *INIFile, maxTasks int, host HostResources) []TuneAdvice {
+	if maxTasks < 1 {
+		maxTasks = 1
+	}
+
+	var advice []TuneAdvice
+	advice = append(advice, adviseMemory(wslconfig, maxTasks, host)...)
+	advice = append(advice, adviseProcessors(wslconfig, maxTasks, host)...)
+	advice = append(advice, adviseSwap(wslconfig)...)
+	advice = append(advice, adviseAutomount(wslconf)...)
+	return advice
+}
+
+// ApplyAdvice 将建议写入对应的配置文件
+func ApplyAdvice(wslconfig, wslconf *INIFile, advice []TuneAdvice) {
+	for _, a := range advice {
+		switch a.File {
+		case WSLConfigFile:
+			wslconfig.Set(a.Section, a.Key, a.Recommended)
+		case WSLConfFile:
+			wslconf.Set(a.Section, a.Key, a.Recommended)
+		}
+	}
+}
+
+// adviseMemory 检查 WSL 虚拟机内存上限，未配置时 WSL 默认使用宿主机内存的 50%
+func adviseMemory(wslconfig *INIFile, maxTasks int, host HostResources) []TuneAdvice {
+	neededGB := uint64(baseMemoryGB + maxTasks*taskMemoryGB)
+	if host.MemoryMB > 0 {
+		// 最多使用宿主机 80% 的内存，为 Windows 保留余量
+		if limit := host.MemoryMB * 8 / 10 / 1024; limit < neededGB {
+			neededGB = limit
+		}
+		if neededGB < baseMemoryGB {
+			neededGB = baseMemoryGB
+		}
+	}
+
+	current, ok := wslconfig.Get("wsl2", "memory")
+	var currentMB uint64
+	if ok {
+		currentMB, ok = parseSizeMB(current)
+	} else if host.MemoryMB > 0 {
+		currentMB, ok = host.MemoryMB/2, true
+	}
+	if !ok || currentMB >= neededGB*1024 {
+		return nil
+	}
+
+	return []TuneAdvice{{
+		File:        WSLConfigFile,
+		Section:     "wsl2",
+		Key:         "memory",
+		Current:     current,
+		Recommended: fmt.Sprintf("%dGB", neededGB),
+		Reason:      fmt.Sprintf("%d 个并发任务约需 %dGB 内存（基础 %dGB + 每任务 %dGB）", maxTasks, neededGB, baseMemoryGB, taskMemoryGB),
+	}}
+}
+
+// adviseProcessors 检查 WSL 虚拟机可用的处理器数量，未配置时 WSL 使用全部处理器
+func adviseProcessors(wslconfig *INIFile, maxTasks int, host HostResources) []TuneAdvice {
+	current, ok := wslconfig.Get("wsl2", "processors")
+	if !ok {
+		return nil
+	}
+
+	needed := maxTasks + 1
+	if host.CPUs > 0 && needed > host.CPUs {
+		needed = host.CPUs
+	}
+
+	n, err := strconv.Atoi(current)
+	if err != nil || n >= needed {
+		return nil
+	}
+
+	return []TuneAdvice{{
+		File:        WSLConfigFile,
+		Section:     "wsl2",
+		Key:         "processors",
+		Current:     current,
+		Recommended: strconv.Itoa(needed),
+		Reason:      fmt.Sprintf("%d 个并发任务至少需要 %d 个处理器，避免任务互相抢占", maxTasks, needed),
+	}}
+}
+
+// adviseSwap 检查交换空间，关闭 swap 时内存峰值会直接触发 OOM
+func adviseSwap(wslconfig *INIFile) []TuneAdvice {
+	current, ok := wslconfig.Get("wsl2", "swap")
+	if !ok {
+		return nil
+	}
+	if size, ok := parseSizeMB(current); !ok || size > 0 {
+		return nil
+	}
+
+	recommended := "4GB"
+	if memory, ok := wslconfig.Get("wsl2", "memory"); ok {
+		if mb, ok := parseSizeMB(memory); ok && mb >= 4096 {
+			recommended = fmt.Sprintf("%dGB", mb/1024/4)
+		}
+	}
+
+	return []TuneAdvice{{
+		File:        WSLConfigFile,
+		Section:     "wsl2",
+		Key:         "swap",
+		Current:     current,
+		Recommended: recommended,
+		Reason:      "swap 为 0 时任务的内存峰值会直接触发 OOM 导致进程被杀死",
+	}}
+}
+
+// adviseAutomount 检查 Windows 盘符的自动挂载配置
+func adviseAutomount(wslconf *INIFile) []TuneAdvice {
+	var advice []TuneAdvice
+
+	if enabled, ok := wslconf.Get("automount", "enabled"); ok && strings.EqualFold(enabled, "false") {
+		advice = append(advice, TuneAdvice{
+			File:        WSLConfFile,
+			Section:     "automount",
+			Key:         "enabled",
+			Current:     enabled,
+			Recommended: "true",
+			Reason:      "关闭自动挂载后 /mnt/<盘符> 路径不可用，路径转换将失败",
+		})
+	}
+
+	current, _ := wslconf.Get("automount", "options")
+	options := strings.Trim(current, `"`)
+	if !strings.Contains(options, "metadata") {
+		recommended := defaultAutomountOptions
+		if options != "" {
+			recommended = options + ",metadata"
+		}
+		advice = append(advice, TuneAdvice{
+			File:        WSLConfFile,
+			Section:     "automount",
+			Key:         "options",
+			Current:     current,
+			Recommended: `"` + recommended + `"`,
+			Reason:      "未启用 metadata 时 /mnt 下的文件无法保存 Linux 权限，git 和脚本的可执行位会丢失",
+		})
+	}
+
+	return advice
+}
+
+// parseSizeMB 解析 .wslconfig 中的容量值（如 8GB、512MB），无单位时按字节处理
+func parseSizeMB(value string) (uint64, bool) {
+	v := strings.ToUpper(strings.TrimSpace(value))
+
+	units := []struct {
+		suffix string
+		factor float64
+	}{
+		{"TB", 1024 * 1024}, {"GB", 1024}, {"MB", 1}, {"KB", 1.0 / 1024},
+		{"T", 1024 * 1024}, {"G", 1024}, {"M", 1}, {"K", 1.0 / 1024},
+		{"B", 1.0 / 1024 / 1024},
+	}
+	factor := 1.0 / 1024 / 1024
+	for _, unit := range units {
+		if strings.HasSuffix(v, unit.suffix) {
+			v = strings.TrimSuffix(v, unit.suffix)
+			factor = unit.factor
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return uint64(n * factor), true
+}
+
+// INIFile 保留原有注释和顺序的简单 INI 文件
+type INIFile struct {
+	lines []string
+}
+
+// ParseINI 解析 INI 文件内容
+func ParseINI(content string) *INIFile {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if strings.TrimSpace(content) == "" {
+		return &INIFile{}
+	}
+	return &INIFile{lines: strings.Split(strings.TrimRight(content, "\n"), "\n")}
+}
+
+// Get 获取指定节中的键值，节名和键名不区分大小写
+func (f *INIFile) Get(section, key string) (string, bool) {
+	current := ""
+	for _, line := range f.lines {
+		if name, ok := iniSection(line); ok {
+			current = name
+			continue
+		}
+		if !strings.EqualFold(current, section) {
+			continue
+		}
+		if k, v, ok := iniKeyValue(line); ok && strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Set 设置指定节中的键值，键或节不存在时追加
+func (f *INIFile) Set(section, key, value string) {
+	entry := key + "=" + value
+	current := ""
+	sectionEnd := -1
+
+	for i, line := range f.lines {
+		if name, ok := iniSection(line); ok {
+			current = name
+			continue
+		}
+		if !strings.EqualFold(current, section) {
+			continue
+		}
+		if k, _, ok := iniKeyValue(line); ok && strings.EqualFold(k, key) {
+			f.lines[i] = entry
+			return
+		}
+		if strings.TrimSpace(line) != "" {
+			sectionEnd = i
+		}
+	}
+
+	// 节存在但没有该键时，插入到节内最后一个非空行之后
+	for i, line := range f.lines {
+		if name, ok := iniSection(line); ok && strings.EqualFold(name, section) {
+			if sectionEnd < i {
+				sectionEnd = i
+			}
+			f.lines = append(f.lines[:sectionEnd+1], append([]string{entry}, f.lines[sectionEnd+1:]...)...)
+			return
+		}
+	}
+
+	if len(f.lines) > 0 {
+		f.lines = append(f.lines, "")
+	}
+	f.lines = append(f.lines, "["+section+"]", entry)
+}
+
+// String 输出 INI 文件内容
+func (f *INIFile) String() string {
+	if len(f.lines) == 0 {
+		return ""
+	}
+	return strings.Join(f.lines, "\n") + "\n"
+}
+
+// iniSection 解析节标题行
+func iniSection(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+		return strings.TrimSpace(line[1 : len(line)-1]), true
+	}
+	return "", false
+}
+
+// iniKeyValue 解析键值行，忽略注释
+func iniKeyValue(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+		return "", "", false
+	}
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return "", "", false
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(value), true
+}
@@ -0,0 +1,115 @@
+package wsl
+
+import (
+	"testing"
+)
+
+func TestParseSizeMB(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected uint64
+		ok       bool
+	}{
+		{"8GB", 8192, true},
+		{"512MB", 512, true},
+		{"4g", 4096, true},
+		{"0", 0, true},
+		{"1073741824", 1024, true},
+		{"abc", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseSizeMB(tt.value)
+			if ok != tt.ok || got != tt.expected {
+				t.Errorf("parseSizeMB(%q) = %d, %v, 期望 %d, %v", tt.value, got, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestINIFile_GetSet(t *testing.T) {
+	ini := ParseINI("# 注释\r\n[wsl2]\r\nmemory=4GB\r\n\r\n[experimental]\r\nsparseVhd=true\r\n")
+
+	if v, ok := ini.Get("WSL2", "Memory"); !ok || v != "4GB" {
+		t.Errorf("Get memory = %q, %v", v, ok)
+	}
+	if _, ok := ini.Get("wsl2", "sparseVhd"); ok {
+		t.Error("不应读取其他节的键")
+	}
+
+	ini.Set("wsl2", "memory", "8GB")
+	ini.Set("wsl2", "processors", "4")
+	ini.Set("automount", "options", `"metadata"`)
+
+	expected := "# 注释\n[wsl2]\nmemory=8GB\nprocessors=4\n\n[experimental]\nsparseVhd=true\n\n[automount]\noptions=\"metadata\"\n"
+	if got := ini.String(); got != expected {
+		t.Errorf("String() = %q, 期望 %q", got, expected)
+	}
+}
+
+func TestAdviseWSLConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		wslconfig string
+		wslconf   string
+		maxTasks  int
+		host      HostResources
+		expected  map[string]string
+	}{
+		{
+			name:      "配置合适",
+			wslconfig: "[wsl2]\nmemory=16GB\nprocessors=8\n",
+			wslconf:   "[automount]\noptions = \"metadata\"\n",
+			maxTasks:  5,
+			host:      HostResources{MemoryMB: 32768, CPUs: 16},
+			expected:  map[string]string{},
+		},
+		{
+			name:      "内存和处理器不足",
+			wslconfig: "[wsl2]\nmemory=4GB\nprocessors=2\nswap=0\n",
+			wslconf:   "[automount]\noptions = \"umask=22\"\n",
+			maxTasks:  5,
+			host:      HostResources{MemoryMB: 32768, CPUs: 16},
+			expected: map[string]string{
+				"memory":     "7GB",
+				"processors": "6",
+				"swap":       "1GB",
+				"options":    `"umask=22,metadata"`,
+			},
+		},
+		{
+			name:     "未配置时使用默认值并受宿主机限制",
+			maxTasks: 10,
+			host:     HostResources{MemoryMB: 8192, CPUs: 4},
+			expected: map[string]string{
+				"memory":  "6GB",
+				"options": `"` + defaultAutomountOptions + `"`,
+			},
+		},
+		{
+			name:     "关闭自动挂载",
+			wslconf:  "[automount]\nenabled=false\noptions=metadata\n",
+			maxTasks: 1,
+			expected: map[string]string{"enabled": "true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			advice := AdviseWSLConfig(ParseINI(tt.wslconfig), ParseINI(tt.wslconf), tt.maxTasks, tt.host)
+			got := make(map[string]string)
+			for _, a := range advice {
+				got[a.Key] = a.Recommended
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("建议 = %v, 期望 %v", got, tt.expected)
+			}
+			for key, value := range tt.expected {
+				if got[key] != value {
+					t.Errorf("%s 建议 = %q, 期望 %q", key, got[key], value)
+				}
+			}
+		})
+	}
+}
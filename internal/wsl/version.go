@@ -0,0 +1,70 @@
+package wsl
+
+import (
+	"regexp"
+	"strings"
+
+	apperrors "auto-claude-code/internal/errors"
+)
+
+// Claude Code 启动方式
+const (
+	LauncherDirect = "direct"
+	LauncherNPX    = "npx"
+)
+
+// versionRegex 匹配 --version 输出中的语义化版本号
+var versionRegex = regexp.MustCompile(`\d+\.\d+\.\d+[0-9A-Za-z.+-]*`)
+
+// claudeBaseArgv 获取启动 Claude Code 的基础参数
+// npx 方式会按配置的版本临时拉取对应的包，从而在同一发行版中使用不同版本
+func (wb *wslBridge) claudeBaseArgv() []string {
+	if wb.config.ClaudeCode.Launcher == LauncherNPX {
+		pkg := claudeCodeNPMPackage
+		if version := wb.config.ClaudeCode.Version; version != "" {
+			pkg += "@" + version
+		}
+		return []string{"npx", "-y", pkg}
+	}
+	return []string{wb.executable()}
+}
+
+// claudeShellCommand 获取启动 Claude Code 的 shell 命令片段
+func (wb *wslBridge) claudeShellCommand() string {
+	base := wb.claudeBaseArgv()
+	quoted := make([]string, len(base))
+	for i, arg := range base {
+		quoted[i] = ShellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// verifyVersion 校验 --version 输出是否满足 claude_code.version
+func (wb *wslBridge) verifyVersion(versionOutput string) error {
+	want := wb.config.ClaudeCode.Version
+	if want == "" {
+		return nil
+	}
+
+	actual := parseVersion(versionOutput)
+	if actual == "" {
+		return apperrors.Newf(apperrors.ErrClaudeCodeVersion, "无法识别 Claude Code 版本: %s", versionOutput)
+	}
+	if !versionMatches(actual, want) {
+		return apperrors.Newf(apperrors.ErrClaudeCodeVersion,
+			"Claude Code 版本不匹配: 要求 %s，实际 %s", want, actual).
+			WithDetailsf("运行 auto-claude-code install 安装指定版本，或将 claude_code.launcher 设置为 %s", LauncherNPX)
+	}
+	return nil
+}
+
+// parseVersion 从 --version 输出中提取版本号
+func parseVersion(output string) string {
+	return versionRegex.FindString(output)
+}
+
+// versionMatches 检查实际版本是否满足要求，want 可以是完整版本或版本前缀（如 1.0）
+func versionMatches(actual, want string) bool {
+	want = strings.TrimPrefix(want, "v")
+	return actual == want || strings.HasPrefix(actual, want+".")
+}
@@ -0,0 +1,75 @@
+package wsl
+
+import (
+	"strings"
+	"testing"
+
+	"auto-claude-code/internal/config"
+
+	"go.uber.org/zap"
+)
+
+func TestVersionMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		want     string
+		expected bool
+	}{
+		{
+			name:     "完整版本匹配",
+			output:   "1.0.35 (Claude Code)",
+			want:     "1.0.35",
+			expected: true,
+		},
+		{
+			name:     "前缀匹配",
+			output:   "1.0.35 (Claude Code)",
+			want:     "1.0",
+			expected: true,
+		},
+		{
+			name:     "带v前缀",
+			output:   "1.0.35",
+			want:     "v1.0.35",
+			expected: true,
+		},
+		{
+			name:     "前缀不在版本边界",
+			output:   "1.0.35",
+			want:     "1.0.3",
+			expected: false,
+		},
+		{
+			name:     "版本不同",
+			output:   "2.1.0 (Claude Code)",
+			want:     "1.0",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := versionMatches(parseVersion(tt.output), tt.want); result != tt.expected {
+				t.Errorf("期望 %v，但得到 %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestWSLBridge_ClaudeArgvNPX(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.ClaudeCode.Launcher = LauncherNPX
+	cfg.ClaudeCode.Version = "1.0.35"
+
+	wb := NewWSLBridge(cfg, zap.NewNop()).(*wslBridge)
+
+	expected := []string{"npx", "-y", "@anthropic-ai/claude-code@1.0.35", "-p", "hi"}
+	if result := wb.claudeArgv([]string{"-p", "hi"}); strings.Join(result, " ") != strings.Join(expected, " ") {
+		t.Errorf("期望 %v，但得到 %v", expected, result)
+	}
+
+	if err := wb.verifyVersion("1.0.34 (Claude Code)"); err == nil {
+		t.Error("版本不匹配时应返回错误")
+	}
+}